@@ -0,0 +1,117 @@
+package main
+
+import (
+	"customer-service/internal/encryption"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strconv"
+)
+
+const usage = `Usage: keys [flags] <command> [args]
+
+Commands:
+  generate            Create a new key file with a version 1 key and a blind index key
+  add                 Add a new key version without making it current
+  activate <version>  Make <version> the key new data is encrypted with
+  list                Show the key versions in the file
+
+Rotating keys without downtime:
+  1. keys add, then roll the new file out to every instance
+  2. keys activate <version>, then roll it out again
+  3. the re-encryption job rewrites existing rows under the new key
+  4. once no rows use the old version, it may be removed from the file
+
+Flags:
+`
+
+func main() {
+	path := flag.String("file", envOr("ENCRYPTION_KEYS_FILE", "keys.json"), "key file")
+	flag.Usage = func() {
+		fmt.Fprint(flag.CommandLine.Output(), usage)
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	switch flag.Arg(0) {
+	case "generate":
+		if _, err := os.Stat(*path); !errors.Is(err, os.ErrNotExist) {
+			log.Fatalf("%s already exists; refusing to overwrite it", *path)
+		}
+		file, err := encryption.NewKeyFile()
+		if err != nil {
+			log.Fatalf("Failed to generate keys: %v", err)
+		}
+		if err := encryption.WriteKeyFile(*path, file); err != nil {
+			log.Fatalf("Failed to write key file: %v", err)
+		}
+		log.Printf("Created %s with key version %d", *path, file.CurrentVersion)
+
+	case "add":
+		file := readKeyFile(*path)
+		version, err := file.AddKey(false)
+		if err != nil {
+			log.Fatalf("Failed to generate key: %v", err)
+		}
+		if err := encryption.WriteKeyFile(*path, file); err != nil {
+			log.Fatalf("Failed to write key file: %v", err)
+		}
+		log.Printf("Added key version %d; version %d is still current", version, file.CurrentVersion)
+
+	case "activate":
+		version, err := strconv.Atoi(flag.Arg(1))
+		if flag.NArg() != 2 || err != nil {
+			log.Fatalf("activate requires a key version")
+		}
+		file := readKeyFile(*path)
+		if _, ok := file.Keys[strconv.Itoa(version)]; !ok {
+			log.Fatalf("Key version %d is not in %s", version, *path)
+		}
+		file.CurrentVersion = version
+		if err := encryption.WriteKeyFile(*path, file); err != nil {
+			log.Fatalf("Failed to write key file: %v", err)
+		}
+		log.Printf("Key version %d is now current", version)
+
+	case "list":
+		file := readKeyFile(*path)
+		versions := make([]int, 0, len(file.Keys))
+		for rawVersion := range file.Keys {
+			if v, err := strconv.Atoi(rawVersion); err == nil {
+				versions = append(versions, v)
+			}
+		}
+		sort.Ints(versions)
+		for _, v := range versions {
+			marker := ""
+			if v == file.CurrentVersion {
+				marker = " (current)"
+			}
+			fmt.Printf("%d%s\n", v, marker)
+		}
+
+	default:
+		flag.Usage()
+		os.Exit(2)
+	}
+}
+
+// readKeyFile reads the key file or exits
+func readKeyFile(path string) *encryption.KeyFile {
+	file, err := encryption.ReadKeyFile(path)
+	if err != nil {
+		log.Fatalf("Failed to read key file: %v", err)
+	}
+	return file
+}
+
+// envOr returns the environment variable key, or fallback when it is unset
+func envOr(key, fallback string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return fallback
+}
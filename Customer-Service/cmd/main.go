@@ -1,14 +1,50 @@
 package main
 
 import (
+	"context"
+	"customer-service/internal/audit"
+	auditcontrollers "customer-service/internal/audit/controllers"
 	"customer-service/internal/config"
+	consentcontrollers "customer-service/internal/consent/controllers"
+	consentrepository "customer-service/internal/consent/repository"
+	consentservice "customer-service/internal/consent/service"
 	"customer-service/internal/customer/controllers"
 	"customer-service/internal/customer/repository"
 	"customer-service/internal/customer/service"
 	"customer-service/internal/database"
+	"customer-service/internal/encryption"
+	"customer-service/internal/events"
+	"customer-service/internal/idempotency"
+	kyccontrollers "customer-service/internal/kyc/controllers"
+	kycrepository "customer-service/internal/kyc/repository"
+	kycservice "customer-service/internal/kyc/service"
+	"customer-service/internal/kyc/storage"
+	privacycontrollers "customer-service/internal/privacy/controllers"
+	privacyrepository "customer-service/internal/privacy/repository"
+	privacyservice "customer-service/internal/privacy/service"
+	relationshipcontrollers "customer-service/internal/relationship/controllers"
+	relationshiprepository "customer-service/internal/relationship/repository"
+	relationshipservice "customer-service/internal/relationship/service"
+	retentioncontrollers "customer-service/internal/retention/controllers"
+	retentionrepository "customer-service/internal/retention/repository"
+	retentionservice "customer-service/internal/retention/service"
+	riskcontrollers "customer-service/internal/risk/controllers"
+	riskrepository "customer-service/internal/risk/repository"
+	"customer-service/internal/risk/rules"
+	riskservice "customer-service/internal/risk/service"
+	screeningcontrollers "customer-service/internal/screening/controllers"
+	screeningrepository "customer-service/internal/screening/repository"
+	screeningservice "customer-service/internal/screening/service"
+	webhookcontrollers "customer-service/internal/webhook/controllers"
+	webhookrepository "customer-service/internal/webhook/repository"
+	webhookservice "customer-service/internal/webhook/service"
 	"customer-service/pkg/middleware"
+	"errors"
+	"fmt"
 	"log"
 	"net/http"
+	"os"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
@@ -39,19 +75,184 @@ func main() {
 		log.Fatalf("Failed to initialize database: %v", err)
 	}
 
-	// Run database migrations
-	if err := database.AutoMigrate(); err != nil {
-		log.Fatalf("Failed to run database migrations: %v", err)
+	// Apply migrations only when explicitly enabled, then refuse to start on an outdated schema
+	if cfg.Database.AutoMigrate {
+		if err := database.MigrateUp(); err != nil {
+			log.Fatalf("Failed to run database migrations: %v", err)
+		}
+	}
+	if err := database.CheckSchema(); err != nil {
+		log.Fatalf("Database schema check failed: %v (run `make migrate` to apply pending migrations)", err)
+	}
+
+	// Initialize PII encryption
+	keys, err := newKeyProvider(cfg)
+	if err != nil {
+		log.Fatalf("Failed to load encryption keys: %v", err)
 	}
+	cipher := encryption.NewCipher(keys)
+	encryption.Register(cipher)
 
 	// Initialize dependencies
 	db := database.GetDB()
-	customerRepo := repository.NewCustomerRepository(db)
-	customerService := service.NewCustomerService(customerRepo)
+	customerRepo := repository.NewCustomerRepository(db, cipher, repository.QueryTimeouts{
+		Default: cfg.Database.QueryTimeout,
+		Search:  cfg.Database.SearchTimeout,
+	})
+	transactor := database.NewTransactor(db)
+	outboxRepo := events.NewOutboxRepository(db)
+	auditStore := audit.NewStore(db, transactor)
+	auditController := auditcontrollers.NewAuditController(auditStore)
+	documentStore, err := storage.NewLocalStore(cfg.KYC.StorageDir)
+	if err != nil {
+		log.Fatalf("Failed to initialize KYC document storage: %v", err)
+	}
+	kycService := kycservice.NewKYCService(kycrepository.NewKYCRepository(db), customerRepo, documentStore, outboxRepo, auditStore, transactor, kycservice.Config{
+		MaxDocumentSize: int64(cfg.KYC.MaxDocumentSize),
+		Validity:        cfg.KYC.Validity,
+	})
+	kycController := kyccontrollers.NewKYCController(kycService, int64(cfg.KYC.MaxDocumentSize))
+	ruleset, err := rules.Load(cfg.Risk.RulesFile)
+	if err != nil {
+		log.Fatalf("Failed to load risk rules: %v", err)
+	}
+	screeningRepo := screeningrepository.NewScreeningRepository(db)
+	riskService := riskservice.NewRiskService(riskrepository.NewRiskRepository(db), customerRepo, screeningRepo, outboxRepo, auditStore, transactor, ruleset)
+	riskController := riskcontrollers.NewRiskController(riskService)
+	relationshipService := relationshipservice.NewRelationshipService(relationshiprepository.NewRelationshipRepository(db), customerRepo, outboxRepo, auditStore, transactor)
+	relationshipController := relationshipcontrollers.NewRelationshipController(relationshipService)
+	consentService := consentservice.NewConsentService(consentrepository.NewConsentRepository(db), customerRepo, outboxRepo, auditStore, transactor)
+	consentController := consentcontrollers.NewConsentController(consentService)
+	exportStore, err := storage.NewLocalStore(cfg.Privacy.ExportDir)
+	if err != nil {
+		log.Fatalf("Failed to initialize data export storage: %v", err)
+	}
+	privacyRepo := privacyrepository.NewPrivacyRepository(db)
+	privacyService := privacyservice.NewPrivacyService(privacyRepo, documentStore, exportStore, outboxRepo, auditStore, transactor, privacyservice.Config{
+		ResponseDeadline: cfg.Privacy.ResponseDeadline,
+	})
+	privacyController := privacycontrollers.NewPrivacyController(privacyService)
+	retentionService := retentionservice.NewRetentionService(retentionrepository.NewRetentionRepository(db), privacyRepo, customerRepo, documentStore, exportStore, outboxRepo, auditStore, transactor, retentionservice.Config{
+		RestoreWindow: cfg.Retention.RestoreWindow,
+	})
+	retentionController := retentioncontrollers.NewRetentionController(retentionService)
+	screeningService := screeningservice.NewScreeningService(screeningRepo, customerRepo, cipher, outboxRepo, auditStore, riskService, transactor, screeningservice.Config{
+		WatchlistDir:   cfg.Screening.WatchlistDir,
+		NameThreshold:  cfg.Screening.NameThreshold,
+		AlertThreshold: cfg.Screening.AlertThreshold,
+	})
+	screeningController := screeningcontrollers.NewScreeningController(screeningService)
+	customerService := service.NewCustomerService(customerRepo, outboxRepo, auditStore, kycService, screeningService, riskService, transactor, service.Config{
+		CursorSecret: cfg.App.CursorSecret,
+	})
 	customerController := controllers.NewCustomerController(customerService)
+	webhookRepo := webhookrepository.NewWebhookRepository(db)
+	webhookService := webhookservice.NewWebhookService(webhookRepo, cipher)
+	webhookController := webhookcontrollers.NewWebhookController(webhookService)
+
+	// Initialize authentication
+	authenticator, err := middleware.NewAuthenticator(middleware.AuthConfig{
+		Secret:   cfg.App.JWTSecret,
+		Audience: cfg.App.JWTAudience,
+		Issuer:   cfg.App.JWTIssuer,
+		JWKSFile: cfg.App.JWKSFile,
+	})
+	if err != nil {
+		log.Fatalf("Failed to initialize authentication: %v", err)
+	}
+
+	// Relay domain events from the outbox to the configured publisher and to webhook subscribers
+	publisher, err := newEventPublisher(cfg)
+	if err != nil {
+		log.Fatalf("Failed to initialize event publisher: %v", err)
+	}
+	publishers := events.MultiPublisher{webhookservice.NewDispatcher(webhookRepo)}
+	if publisher != nil {
+		publishers = append(events.MultiPublisher{publisher}, publishers...)
+	}
+	relay := events.NewRelay(outboxRepo, publishers, transactor, events.RelayConfig{
+		PollInterval: cfg.Events.PollInterval,
+		BatchSize:    cfg.Events.BatchSize,
+		MaxAttempts:  cfg.Events.MaxAttempts,
+	})
+	go relay.Run(context.Background())
+
+	// Send queued webhook deliveries, retrying failures with backoff
+	webhookWorker := webhookservice.NewWorker(webhookRepo, transactor, webhookservice.WorkerConfig{
+		PollInterval: cfg.Webhooks.PollInterval,
+		Timeout:      cfg.Webhooks.Timeout,
+		MaxAttempts:  cfg.Webhooks.MaxAttempts,
+		DisableAfter: cfg.Webhooks.DisableAfter,
+	})
+	go webhookWorker.Run(context.Background())
+
+	// Rewrite PII still in plaintext or under a retired key
+	reencryptor := service.NewReencryptor(customerRepo, cipher, service.ReencryptionConfig{
+		Interval:  cfg.Encryption.ReencryptInterval,
+		BatchSize: cfg.Encryption.ReencryptBatchSize,
+	})
+	go reencryptor.Run(context.Background())
+
+	// Encrypt webhook signing secrets stored in plaintext or under a retired key
+	go func() {
+		rewritten, err := webhookService.ReencryptSecrets(context.Background())
+		if err != nil {
+			log.Printf("Webhook secret re-encryption: %v", err)
+		}
+		if rewritten > 0 {
+			log.Printf("Webhook secret re-encryption: rewrote %d secret(s)", rewritten)
+		}
+	}()
+
+	// Give customers created before addresses and contact points were kept separately theirs
+	go func() {
+		backfilled, err := customerService.BackfillContacts(context.Background(), cfg.Encryption.ReencryptBatchSize)
+		if err != nil {
+			log.Printf("Contact backfill: %v", err)
+		}
+		if backfilled > 0 {
+			log.Printf("Contact backfill: gave %d customer(s) their addresses and contact points", backfilled)
+		}
+	}()
+
+	// Expire KYC verifications whose validity has ended
+	kycExpirer := kycservice.NewExpirer(kycService, kycservice.ExpiryConfig{
+		Interval: cfg.KYC.ExpiryInterval,
+	})
+	go kycExpirer.Run(context.Background())
+
+	// Rescreen every customer after a watchlist is loaded
+	rescreener := screeningservice.NewRescreener(screeningService, screeningservice.RescreenConfig{
+		Interval:  cfg.Screening.RescreenInterval,
+		BatchSize: cfg.Screening.RescreenBatchSize,
+	})
+	go rescreener.Run(context.Background())
+
+	// Rate customers again once their review is due or the rules change
+	riskReviewer := riskservice.NewReviewer(riskService, riskservice.ReviewConfig{
+		Interval:  cfg.Risk.ReviewInterval,
+		BatchSize: cfg.Risk.ReviewBatchSize,
+	})
+	go riskReviewer.Run(context.Background())
+
+	// Purge deleted customers past the retention period of their status
+	purger := retentionservice.NewPurger(retentionService, retentionservice.PurgeConfig{
+		Interval:     cfg.Retention.Interval,
+		PollInterval: cfg.Retention.PollInterval,
+		BatchSize:    cfg.Retention.BatchSize,
+		DryRun:       cfg.Retention.DryRun,
+	})
+	go purger.Run(context.Background())
+
+	// Record the heads of the audit chains so that removing their ends is noticed
+	go auditStore.RunCheckpoints(context.Background(), cfg.Audit.CheckpointInterval)
+
+	// Idempotency keys for mutating endpoints, with expired keys purged hourly
+	idempotencyStore := idempotency.NewStore(db, cipher)
+	go idempotencyStore.RunJanitor(context.Background(), time.Hour)
 
 	// Setup router
-	router := setupRouter(cfg, customerController)
+	router := setupRouter(cfg, authenticator, idempotencyStore, customerController, webhookController, auditController, kycController, screeningController, riskController, relationshipController, consentController, privacyController, retentionController)
 
 	// Start server
 	log.Printf("Starting server on %s", cfg.GetServerAddress())
@@ -60,7 +261,41 @@ func main() {
 	}
 }
 
-func setupRouter(cfg *config.Config, customerController *controllers.CustomerController) *gin.Engine {
+// newEventPublisher creates the publisher selected by EVENTS_PUBLISHER, or nil when disabled
+func newEventPublisher(cfg *config.Config) (events.EventPublisher, error) {
+	switch cfg.Events.Publisher {
+	case "stdout":
+		return events.NewWriterPublisher(os.Stdout), nil
+	case "file":
+		// The file stays open for the lifetime of the process
+		publisher, _, err := events.NewFilePublisher(cfg.Events.File)
+		return publisher, err
+	case "none", "":
+		log.Println("Event publishing disabled; events are only delivered to webhooks")
+		return nil, nil
+	default:
+		return nil, fmt.Errorf("unknown event publisher %q", cfg.Events.Publisher)
+	}
+}
+
+// newKeyProvider loads the encryption key file. In development a missing file is
+// created with fresh keys, so the service can start without any setup.
+func newKeyProvider(cfg *config.Config) (encryption.KeyProvider, error) {
+	path := cfg.Encryption.KeysFile
+	if _, err := os.Stat(path); errors.Is(err, os.ErrNotExist) && cfg.IsDevelopment() {
+		file, err := encryption.NewKeyFile()
+		if err != nil {
+			return nil, err
+		}
+		if err := encryption.WriteKeyFile(path, file); err != nil {
+			return nil, err
+		}
+		log.Printf("Generated development encryption keys in %s", path)
+	}
+	return encryption.NewFileKeyProvider(path)
+}
+
+func setupRouter(cfg *config.Config, authenticator *middleware.Authenticator, idempotencyStore middleware.IdempotencyStore, customerController *controllers.CustomerController, webhookController *webhookcontrollers.WebhookController, auditController *auditcontrollers.AuditController, kycController *kyccontrollers.KYCController, screeningController *screeningcontrollers.ScreeningController, riskController *riskcontrollers.RiskController, relationshipController *relationshipcontrollers.RelationshipController, consentController *consentcontrollers.ConsentController, privacyController *privacycontrollers.PrivacyController, retentionController *retentioncontrollers.RetentionController) *gin.Engine {
 	// Set gin mode
 	if cfg.IsProduction() {
 		gin.SetMode(gin.ReleaseMode)
@@ -70,6 +305,7 @@ func setupRouter(cfg *config.Config, customerController *controllers.CustomerCon
 	router := gin.New()
 
 	// Add middleware
+	router.Use(middleware.RequestID())
 	router.Use(middleware.Logger())
 	router.Use(middleware.Recovery())
 	router.Use(middleware.CORS())
@@ -85,15 +321,124 @@ func setupRouter(cfg *config.Config, customerController *controllers.CustomerCon
 
 	// API v1 routes
 	v1 := router.Group("/api/v1")
+	v1.Use(middleware.Auth(authenticator), auditcontrollers.CaptureMetadata())
 	{
+		readers := middleware.RequireRoles(middleware.RoleTeller, middleware.RoleBackOffice, middleware.RoleAdmin)
+		writers := middleware.RequireRoles(middleware.RoleBackOffice, middleware.RoleAdmin)
+		admins := middleware.RequireRoles(middleware.RoleAdmin)
+		notifiers := middleware.RequireRoles(middleware.RoleNotificationService, middleware.RoleBackOffice, middleware.RoleAdmin)
+		idempotent := middleware.Idempotency(idempotencyStore, cfg.App.IdempotencyTTL, cfg.App.IdempotencyLease)
+
 		customers := v1.Group("/customers")
 		{
-			customers.POST("", customerController.CreateCustomer)
-			customers.GET("/:id", customerController.GetCustomer)
-			customers.PUT("/:id", customerController.UpdateCustomer)
-			customers.DELETE("/:id", customerController.DeleteCustomer)
-			customers.GET("", customerController.ListCustomers)
-			customers.GET("/search", customerController.SearchCustomers)
+			customers.POST("", writers, idempotent, customerController.CreateCustomer)
+			customers.GET("/:id", readers, customerController.GetCustomer)
+			customers.PUT("/:id", writers, idempotent, customerController.UpdateCustomer)
+			customers.PATCH("/:id", writers, idempotent, customerController.PatchCustomer)
+			customers.DELETE("/:id", admins, idempotent, customerController.DeleteCustomer)
+			customers.GET("", readers, customerController.ListCustomers)
+			customers.GET("/search", readers, customerController.SearchCustomers)
+			customers.POST("/:id/status", writers, idempotent, customerController.ChangeStatus)
+			customers.GET("/:id/status/history", readers, customerController.GetStatusHistory)
+			customers.GET("/:id/duplicates", writers, customerController.FindDuplicates)
+			customers.POST("/:id/merge", admins, idempotent, customerController.MergeCustomers)
+			customers.GET("/:id/owners", writers, customerController.GetBeneficialOwners)
+			customers.PUT("/:id/owners", writers, idempotent, customerController.ReplaceBeneficialOwners)
+			customers.GET("/:id/addresses", readers, customerController.ListAddresses)
+			customers.POST("/:id/addresses", writers, idempotent, customerController.AddAddress)
+			customers.PUT("/:id/addresses/:addressId", writers, idempotent, customerController.UpdateAddress)
+			customers.DELETE("/:id/addresses/:addressId", writers, idempotent, customerController.DeleteAddress)
+			customers.POST("/:id/addresses/:addressId/verification", writers, idempotent, customerController.VerifyAddress)
+			customers.GET("/:id/contact-points", readers, customerController.ListContactPoints)
+			customers.POST("/:id/contact-points", writers, idempotent, customerController.AddContactPoint)
+			customers.PUT("/:id/contact-points/:contactId", writers, idempotent, customerController.UpdateContactPoint)
+			customers.DELETE("/:id/contact-points/:contactId", writers, idempotent, customerController.DeleteContactPoint)
+			customers.POST("/:id/contact-points/:contactId/verification", writers, idempotent, customerController.VerifyContactPoint)
+			customers.GET("/:id/audit", writers, auditController.GetCustomerAudit)
+			customers.GET("/:id/kyc", readers, kycController.GetSummary)
+			customers.POST("/:id/kyc/cases", writers, idempotent, kycController.OpenCase)
+			customers.GET("/:id/screening", writers, screeningController.ListCustomerAlerts)
+			customers.POST("/:id/screening", writers, idempotent, screeningController.ScreenCustomer)
+			customers.GET("/:id/risk", writers, riskController.GetRisk)
+			customers.GET("/:id/relationships", writers, relationshipController.ListRelationships)
+			customers.POST("/:id/relationships", writers, idempotent, relationshipController.CreateRelationship)
+			customers.GET("/:id/relationships/graph", writers, relationshipController.GetGraph)
+			customers.GET("/:id/consents", readers, consentController.ListConsents)
+			customers.GET("/:id/consents/history", readers, consentController.GetHistory)
+			customers.POST("/:id/consents", writers, idempotent, consentController.Grant)
+			customers.POST("/:id/consents/withdraw", writers, idempotent, consentController.Withdraw)
+			customers.GET("/:id/data-requests", writers, privacyController.ListRequests)
+			customers.POST("/:id/data-requests", writers, idempotent, privacyController.CreateRequest)
+			customers.GET("/:id/retention", admins, retentionController.GetCustomerRetention)
+			customers.PUT("/:id/legal-hold", admins, idempotent, retentionController.SetLegalHold)
+			customers.POST("/:id/restore", admins, idempotent, retentionController.RestoreCustomer)
+		}
+
+		kyc := v1.Group("/kyc/cases")
+		{
+			kyc.GET("/:caseId", writers, kycController.GetCase)
+			// Uploads are not idempotent-wrapped: the middleware buffers the whole body
+			kyc.POST("/:caseId/documents", writers, kycController.UploadDocument)
+			kyc.GET("/:caseId/documents/:documentId/content", writers, kycController.DownloadDocument)
+			kyc.POST("/:caseId/submit", writers, idempotent, kycController.SubmitCase)
+			kyc.POST("/:caseId/decisions", admins, idempotent, kycController.DecideCase)
+		}
+
+		screening := v1.Group("/screening")
+		{
+			screening.POST("/watchlists", admins, idempotent, screeningController.LoadWatchlist)
+			screening.GET("/watchlists", admins, screeningController.ListWatchlists)
+			screening.GET("/runs/:id", admins, screeningController.GetRun)
+			screening.GET("/alerts", writers, screeningController.ListAlerts)
+			screening.GET("/alerts/:id", writers, screeningController.GetAlert)
+			screening.POST("/alerts/:id/disposition", admins, idempotent, screeningController.DispositionAlert)
+		}
+
+		relationships := v1.Group("/relationships")
+		{
+			relationships.GET("/:id", writers, relationshipController.GetRelationship)
+			relationships.POST("/:id/end", writers, idempotent, relationshipController.EndRelationship)
+		}
+
+		consents := v1.Group("/consents")
+		{
+			consents.GET("/contactable", notifiers, consentController.ListContactable)
+		}
+
+		dataRequests := v1.Group("/data-requests")
+		{
+			dataRequests.GET("/:requestId", writers, privacyController.GetRequest)
+			dataRequests.POST("/:requestId/process", admins, idempotent, privacyController.ProcessRequest)
+			dataRequests.POST("/:requestId/reject", admins, idempotent, privacyController.RejectRequest)
+			dataRequests.GET("/:requestId/export", admins, privacyController.DownloadExport)
+		}
+
+		retention := v1.Group("/retention", admins)
+		{
+			retention.GET("/policies", retentionController.ListPolicies)
+			retention.PUT("/policies/:status", idempotent, retentionController.SetPolicy)
+			retention.DELETE("/policies/:status", retentionController.DeletePolicy)
+			retention.POST("/runs", idempotent, retentionController.StartRun)
+			retention.GET("/runs", retentionController.ListRuns)
+			retention.GET("/runs/:id", retentionController.GetRun)
+			retention.GET("/runs/:id/items", retentionController.ListRunItems)
+		}
+
+		auditLog := v1.Group("/audit", admins)
+		{
+			auditLog.GET("", auditController.ListEntries)
+			auditLog.GET("/verify", auditController.VerifyChain)
+		}
+
+		webhooks := v1.Group("/webhooks", admins)
+		{
+			webhooks.POST("", idempotent, webhookController.CreateSubscription)
+			webhooks.GET("", webhookController.ListSubscriptions)
+			webhooks.GET("/:id", webhookController.GetSubscription)
+			webhooks.PUT("/:id", idempotent, webhookController.UpdateSubscription)
+			webhooks.DELETE("/:id", webhookController.DeleteSubscription)
+			webhooks.GET("/:id/deliveries", webhookController.ListDeliveries)
+			webhooks.POST("/:id/deliveries/:deliveryId/replay", idempotent, webhookController.ReplayDelivery)
 		}
 	}
 
@@ -3,10 +3,54 @@ package main
 import (
 	"customer-service/internal/config"
 	"customer-service/internal/database"
+	"flag"
+	"fmt"
 	"log"
+	"os"
+	"strconv"
+	"text/tabwriter"
+	"time"
 )
 
+const usage = `Usage: migrate [flags] <command> [args]
+
+Commands:
+  up              Apply all pending migrations (default)
+  down N          Roll back the last N migrations
+  status          Show applied and pending migrations
+  create <name>   Create a new empty up/down migration pair
+  force <version> Mark the schema as being at <version> and clear the dirty flag
+
+Flags:
+`
+
 func main() {
+	dir := flag.String("dir", database.MigrationsDir, "migrations source directory (used by create)")
+	flag.Usage = func() {
+		fmt.Fprint(flag.CommandLine.Output(), usage)
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	command := flag.Arg(0)
+	if command == "" {
+		command = "up"
+	}
+
+	// create only touches the filesystem, so handle it before connecting
+	if command == "create" {
+		if flag.NArg() != 2 {
+			log.Fatalf("create requires a migration name")
+		}
+		upPath, downPath, err := database.CreateMigration(*dir, flag.Arg(1))
+		if err != nil {
+			log.Fatalf("Failed to create migration: %v", err)
+		}
+		log.Printf("Created %s", upPath)
+		log.Printf("Created %s", downPath)
+		return
+	}
+
 	// Load configuration
 	cfg, err := config.Load()
 	if err != nil {
@@ -17,11 +61,75 @@ func main() {
 	if err := database.InitDatabase(cfg); err != nil {
 		log.Fatalf("Failed to initialize database: %v", err)
 	}
+	defer database.CloseDatabase()
 
-	// Run migrations
-	if err := database.AutoMigrate(); err != nil {
-		log.Fatalf("Failed to run migrations: %v", err)
+	migrator, err := database.NewMigrator(database.GetDB())
+	if err != nil {
+		log.Fatalf("Failed to load migrations: %v", err)
 	}
 
-	log.Println("Migrations completed successfully")
+	switch command {
+	case "up":
+		applied, err := migrator.Up()
+		if err != nil {
+			log.Fatalf("Failed to run migrations: %v", err)
+		}
+		log.Printf("Migrations completed successfully (%d applied)", applied)
+
+	case "down":
+		n, err := strconv.Atoi(flag.Arg(1))
+		if flag.NArg() != 2 || err != nil || n <= 0 {
+			log.Fatalf("down requires a positive number of migrations to roll back")
+		}
+		rolledBack, err := migrator.Down(n)
+		if err != nil {
+			log.Fatalf("Failed to roll back migrations: %v", err)
+		}
+		log.Printf("Rolled back %d migration(s)", rolledBack)
+
+	case "status":
+		statuses, err := migrator.Status()
+		if err != nil {
+			log.Fatalf("Failed to read migration status: %v", err)
+		}
+		printStatus(statuses)
+
+	case "force":
+		version, err := strconv.ParseInt(flag.Arg(1), 10, 64)
+		if flag.NArg() != 2 || err != nil {
+			log.Fatalf("force requires a migration version")
+		}
+		if err := migrator.Force(version); err != nil {
+			log.Fatalf("Failed to force version: %v", err)
+		}
+		log.Printf("Schema forced to version %d", version)
+
+	default:
+		flag.Usage()
+		os.Exit(2)
+	}
+}
+
+// printStatus renders the migration status as a table
+func printStatus(statuses []database.MigrationStatus) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "VERSION\tNAME\tSTATUS\tAPPLIED AT")
+	for _, s := range statuses {
+		state := "pending"
+		switch {
+		case s.Dirty:
+			state = "dirty"
+		case s.Missing:
+			state = "applied (file missing)"
+		case s.Applied:
+			state = "applied"
+		}
+
+		appliedAt := "-"
+		if s.AppliedAt != nil {
+			appliedAt = s.AppliedAt.Format(time.RFC3339)
+		}
+		fmt.Fprintf(w, "%06d\t%s\t%s\t%s\n", s.Version, s.Name, state, appliedAt)
+	}
+	w.Flush()
 }
@@ -0,0 +1,27 @@
+package audit
+
+import "context"
+
+// SystemActor is recorded when an action is not performed on behalf of a user
+const SystemActor = "system"
+
+// metadataKey is the context key under which request metadata is stored
+type metadataKey struct{}
+
+// Metadata describes who performed an action and from where
+type Metadata struct {
+	Actor     string
+	RequestID string
+	ClientIP  string
+}
+
+// WithMetadata returns a copy of ctx carrying the request metadata
+func WithMetadata(ctx context.Context, meta Metadata) context.Context {
+	return context.WithValue(ctx, metadataKey{}, meta)
+}
+
+// MetadataFromContext returns the request metadata carried by ctx, if any
+func MetadataFromContext(ctx context.Context) Metadata {
+	meta, _ := ctx.Value(metadataKey{}).(Metadata)
+	return meta
+}
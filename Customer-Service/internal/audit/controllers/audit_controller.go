@@ -0,0 +1,111 @@
+package controllers
+
+import (
+	"customer-service/internal/audit"
+	"customer-service/pkg/apierror"
+	"customer-service/pkg/middleware"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// AuditController handles HTTP requests for the audit trail
+type AuditController struct {
+	store *audit.Store
+}
+
+// NewAuditController creates a new audit controller instance
+func NewAuditController(store *audit.Store) *AuditController {
+	return &AuditController{
+		store: store,
+	}
+}
+
+// CaptureMetadata stores the caller, request ID and client IP in the request
+// context, where the audit recorder picks them up. It must run after authentication.
+func CaptureMetadata() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		meta := audit.Metadata{
+			RequestID: c.GetString(apierror.RequestIDKey),
+			ClientIP:  c.ClientIP(),
+		}
+		if claims, ok := middleware.GetClaims(c); ok {
+			meta.Actor = claims.Subject
+		}
+
+		c.Request = c.Request.WithContext(audit.WithMetadata(c.Request.Context(), meta))
+		c.Next()
+	}
+}
+
+// GetCustomerAudit handles GET /customers/:id/audit
+func (ctrl *AuditController) GetCustomerAudit(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		apierror.Abort(c, http.StatusBadRequest, apierror.CodeInvalidRequest, "invalid customer ID",
+			apierror.FieldError{Field: "id", Message: "must be a valid UUID"})
+		return
+	}
+
+	var query audit.Query
+	if !ctrl.bindQuery(c, &query) {
+		return
+	}
+	query.CustomerID = &id
+
+	ctrl.list(c, query)
+}
+
+// ListEntries handles GET /audit
+func (ctrl *AuditController) ListEntries(c *gin.Context) {
+	var query audit.Query
+	if !ctrl.bindQuery(c, &query) {
+		return
+	}
+
+	ctrl.list(c, query)
+}
+
+// VerifyChain handles GET /audit/verify
+func (ctrl *AuditController) VerifyChain(c *gin.Context) {
+	result, err := ctrl.store.Verify(c.Request.Context())
+	if err != nil {
+		ctrl.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// list writes a page of entries matching the query
+func (ctrl *AuditController) list(c *gin.Context, query audit.Query) {
+	entries, err := ctrl.store.List(c.Request.Context(), query)
+	if err != nil {
+		ctrl.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, entries)
+}
+
+// bindQuery parses the audit filters, writing an error response on failure
+func (ctrl *AuditController) bindQuery(c *gin.Context, query *audit.Query) bool {
+	if err := c.ShouldBindQuery(query); err != nil {
+		apierror.Abort(c, http.StatusBadRequest, apierror.CodeInvalidRequest, "invalid query parameters: "+err.Error())
+		return false
+	}
+	if !query.From.IsZero() && !query.To.IsZero() && !query.From.Before(query.To) {
+		apierror.Abort(c, http.StatusBadRequest, apierror.CodeValidationFailed, "request validation failed",
+			apierror.FieldError{Field: "to", Message: "must be after from"})
+		return false
+	}
+	return true
+}
+
+// handleError maps store errors to HTTP responses
+func (ctrl *AuditController) handleError(c *gin.Context, err error) {
+	log.Printf("Unhandled error processing %s %s: %v", c.Request.Method, c.Request.URL.Path, err)
+	apierror.Abort(c, http.StatusInternalServerError, apierror.CodeInternal, "internal server error")
+}
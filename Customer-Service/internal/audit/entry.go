@@ -0,0 +1,265 @@
+package audit
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Actions recorded in the audit trail
+const (
+	ActionCustomerCreate         = "customer.create"
+	ActionCustomerUpdate         = "customer.update"
+	ActionCustomerPatch          = "customer.patch"
+	ActionCustomerDelete         = "customer.delete"
+	ActionCustomerStatusChange   = "customer.status_change"
+	ActionCustomerView           = "customer.view"
+	ActionCustomerList           = "customer.list"
+	ActionCustomerSearch         = "customer.search"
+	ActionCustomerStatusHistory  = "customer.status_history.view"
+	ActionCustomerDuplicates     = "customer.duplicates.view"
+	ActionCustomerMerge          = "customer.merge"
+	ActionCustomerOwnersView     = "customer.owners.view"
+	ActionCustomerOwnersUpdate   = "customer.owners.update"
+	ActionCustomerContactsView   = "customer.contacts.view"
+	ActionCustomerContactsUpdate = "customer.contacts.update"
+
+	ActionKYCView           = "kyc.view"
+	ActionKYCCaseOpen       = "kyc.case_open"
+	ActionKYCDocumentUpload = "kyc.document_upload"
+	ActionKYCDocumentView   = "kyc.document_view"
+	ActionKYCCaseSubmit     = "kyc.case_submit"
+	ActionKYCDecision       = "kyc.decision"
+	ActionKYCCaseExpire     = "kyc.case_expire"
+
+	ActionScreeningView        = "screening.view"
+	ActionScreeningAlertRaise  = "screening.alert_raise"
+	ActionScreeningDisposition = "screening.alert_disposition"
+
+	ActionRiskView   = "risk.view"
+	ActionRiskAssess = "risk.assess"
+
+	ActionRelationshipView   = "relationship.view"
+	ActionRelationshipCreate = "relationship.create"
+	ActionRelationshipEnd    = "relationship.end"
+
+	ActionConsentView        = "consent.view"
+	ActionConsentGrant       = "consent.grant"
+	ActionConsentWithdraw    = "consent.withdraw"
+	ActionConsentContactable = "consent.contactable"
+
+	ActionDataRequestCreate   = "data_request.create"
+	ActionDataRequestView     = "data_request.view"
+	ActionDataRequestReject   = "data_request.reject"
+	ActionDataRequestFail     = "data_request.fail"
+	ActionDataRequestExport   = "data_request.export"
+	ActionDataRequestDownload = "data_request.download"
+	ActionDataRequestErase    = "data_request.erase"
+
+	ActionRetentionView    = "retention.view"
+	ActionRetentionHold    = "retention.legal_hold"
+	ActionRetentionRestore = "retention.restore"
+	ActionRetentionPurge   = "retention.purge"
+
+	ActionAuditCheckpoint = "audit.checkpoint"
+)
+
+// genesisHash is the previous hash of the first entry in the chain
+var genesisHash = strings.Repeat("0", 64)
+
+// Change is the before and after value of a single field. The audit trail cannot be
+// changed or erased, so personal data is kept out of it: Redacted marks a change whose
+// personal data was left out, entirely or from the values recorded.
+type Change struct {
+	Field    string `json:"field"`
+	Before   any    `json:"before"`
+	After    any    `json:"after"`
+	Redacted bool   `json:"redacted,omitempty"`
+}
+
+// RedactedChange records that a field holding personal data changed, without its values
+func RedactedChange(field string) Change {
+	return Change{Field: field, Redacted: true}
+}
+
+// Entry is a single record of the append-only audit trail. Each entry stores the
+// hash of its predecessor on the chain of its customer, so altering or removing a
+// row breaks the chain.
+type Entry struct {
+	Sequence   int64      `json:"sequence" gorm:"primaryKey;autoIncrement"`
+	ID         uuid.UUID  `json:"id" gorm:"type:uuid;not null;uniqueIndex"`
+	OccurredAt time.Time  `json:"occurred_at" gorm:"not null"`
+	Actor      string     `json:"actor" gorm:"not null;size:255"`
+	Action     string     `json:"action" gorm:"not null;size:100"`
+	CustomerID *uuid.UUID `json:"customer_id,omitempty" gorm:"type:uuid;index"`
+	RequestID  string     `json:"request_id,omitempty" gorm:"size:100"`
+	ClientIP   string     `json:"client_ip,omitempty" gorm:"size:64"`
+	Changes    *string    `json:"-" gorm:"type:jsonb"`
+	PrevHash   string     `json:"prev_hash" gorm:"not null;size:64"`
+	Hash       string     `json:"hash" gorm:"not null;size:64"`
+	// ChainID is the customer the entry is chained with, or uuid.Nil for an entry
+	// without one; nil for entries written to the single chain kept before
+	ChainID *uuid.UUID `json:"-" gorm:"type:uuid"`
+}
+
+// TableName returns the table name for Entry model
+func (Entry) TableName() string {
+	return "audit_log"
+}
+
+// MarshalJSON renders the stored changes as structured JSON rather than a string
+func (e Entry) MarshalJSON() ([]byte, error) {
+	type entry Entry
+	var changes json.RawMessage
+	if e.Changes != nil {
+		changes = json.RawMessage(*e.Changes)
+	}
+	return json.Marshal(struct {
+		entry
+		Changes json.RawMessage `json:"changes,omitempty"`
+	}{entry(e), changes})
+}
+
+// NewEntry creates an entry for an action on a customer, filling in the actor,
+// request ID and client IP from the metadata carried by ctx
+func NewEntry(ctx context.Context, action string, customerID *uuid.UUID, changes []Change) (*Entry, error) {
+	meta := MetadataFromContext(ctx)
+	entry := &Entry{
+		ID:         uuid.New(),
+		OccurredAt: time.Now().UTC().Truncate(time.Microsecond),
+		Actor:      meta.Actor,
+		Action:     action,
+		CustomerID: customerID,
+		RequestID:  meta.RequestID,
+		ClientIP:   meta.ClientIP,
+	}
+	if entry.Actor == "" {
+		entry.Actor = SystemActor
+	}
+
+	if len(changes) > 0 {
+		data, err := json.Marshal(changes)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode audit changes: %w", err)
+		}
+		encoded := string(data)
+		entry.Changes = &encoded
+	}
+	return entry, nil
+}
+
+// computeHash returns the chain hash of the entry given the hash of its predecessor.
+// The changes are canonicalised first, because PostgreSQL stores jsonb in its own
+// key order and spacing.
+func (e *Entry) computeHash(prevHash string) (string, error) {
+	var changes json.RawMessage
+	if e.Changes != nil {
+		canonical, err := canonicalJSON([]byte(*e.Changes))
+		if err != nil {
+			return "", err
+		}
+		changes = canonical
+	}
+
+	var customerID string
+	if e.CustomerID != nil {
+		customerID = e.CustomerID.String()
+	}
+
+	data, err := json.Marshal(struct {
+		ID         string          `json:"id"`
+		OccurredAt string          `json:"occurred_at"`
+		Actor      string          `json:"actor"`
+		Action     string          `json:"action"`
+		CustomerID string          `json:"customer_id"`
+		RequestID  string          `json:"request_id"`
+		ClientIP   string          `json:"client_ip"`
+		Changes    json.RawMessage `json:"changes,omitempty"`
+		PrevHash   string          `json:"prev_hash"`
+	}{
+		ID:         e.ID.String(),
+		OccurredAt: e.OccurredAt.UTC().Format(time.RFC3339Nano),
+		Actor:      e.Actor,
+		Action:     e.Action,
+		CustomerID: customerID,
+		RequestID:  e.RequestID,
+		ClientIP:   e.ClientIP,
+		Changes:    changes,
+		PrevHash:   prevHash,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to encode audit entry: %w", err)
+	}
+
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// ChainHead is the last entry of a chain when a checkpoint was taken
+type ChainHead struct {
+	ChainID  uuid.UUID `json:"chain_id"`
+	Sequence int64     `json:"sequence"`
+	Hash     string    `json:"hash"`
+}
+
+// chainHeadsField is the change under which a checkpoint records chain heads
+const chainHeadsField = "chain_heads"
+
+// newCheckpoint creates a checkpoint entry recording the given chain heads, linked
+// to the entry whose hash is prevHash
+func newCheckpoint(ctx context.Context, heads []ChainHead, prevHash string) (*Entry, error) {
+	entry, err := NewEntry(ctx, ActionAuditCheckpoint, nil, []Change{{Field: chainHeadsField, After: heads}})
+	if err != nil {
+		return nil, err
+	}
+	entry.PrevHash = prevHash
+	if entry.Hash, err = entry.computeHash(prevHash); err != nil {
+		return nil, err
+	}
+	return entry, nil
+}
+
+// chainHeads returns the chain heads recorded by a checkpoint entry
+func (e *Entry) chainHeads() ([]ChainHead, error) {
+	if e.Changes == nil {
+		return nil, nil
+	}
+	var changes []struct {
+		Field string          `json:"field"`
+		After json.RawMessage `json:"after"`
+	}
+	if err := json.Unmarshal([]byte(*e.Changes), &changes); err != nil {
+		return nil, fmt.Errorf("failed to decode audit checkpoint: %w", err)
+	}
+	var heads []ChainHead
+	for _, change := range changes {
+		if change.Field != chainHeadsField {
+			continue
+		}
+		var recorded []ChainHead
+		if err := json.Unmarshal(change.After, &recorded); err != nil {
+			return nil, fmt.Errorf("failed to decode audit checkpoint: %w", err)
+		}
+		heads = append(heads, recorded...)
+	}
+	return heads, nil
+}
+
+// canonicalJSON re-encodes a JSON document with sorted object keys and compact spacing
+func canonicalJSON(data []byte) ([]byte, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+
+	var value any
+	if err := decoder.Decode(&value); err != nil {
+		return nil, fmt.Errorf("failed to decode audit changes: %w", err)
+	}
+	return json.Marshal(value)
+}
@@ -0,0 +1,414 @@
+package audit
+
+import (
+	"context"
+	"customer-service/internal/database"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Recorder appends entries to the audit trail
+type Recorder interface {
+	// Record stores one entry for an action on a customer. Called with a
+	// transactional context, the entry is committed together with the change.
+	Record(ctx context.Context, action string, customerID uuid.UUID, changes []Change) error
+	// RecordAccess stores one entry per customer that was read by an action
+	RecordAccess(ctx context.Context, action string, customerIDs []uuid.UUID) error
+}
+
+// Query filters audit entries. Zero values match everything.
+type Query struct {
+	CustomerID *uuid.UUID `form:"-"`
+	Actor      string     `form:"actor"`
+	Action     string     `form:"action"`
+	From       time.Time  `form:"from" time_format:"2006-01-02T15:04:05Z07:00"`
+	To         time.Time  `form:"to" time_format:"2006-01-02T15:04:05Z07:00"`
+	Page       int        `form:"page"`
+	PageSize   int        `form:"page_size"`
+}
+
+// ListResponse represents a page of audit entries
+type ListResponse struct {
+	Entries    []Entry `json:"entries"`
+	Total      int64   `json:"total"`
+	Page       int     `json:"page"`
+	PageSize   int     `json:"page_size"`
+	TotalPages int     `json:"total_pages"`
+}
+
+// VerifyResult reports the outcome of checking the hash chain
+type VerifyResult struct {
+	Valid   bool  `json:"valid"`
+	Checked int64 `json:"checked"`
+	// BrokenAt is the sequence of the first entry whose hash does not match
+	BrokenAt *int64 `json:"broken_at,omitempty"`
+	Reason   string `json:"reason,omitempty"`
+	// Checkpoint is the hash of the latest checkpoint. Only entries since the last
+	// checkpoint, or trailing checkpoints themselves, can be removed without Verify
+	// noticing, so it can be compared with a value noted down earlier.
+	Checkpoint string `json:"checkpoint,omitempty"`
+}
+
+// Store is the PostgreSQL backed audit trail
+type Store struct {
+	db *gorm.DB
+	tx database.Transactor
+}
+
+// NewStore creates a new audit store
+func NewStore(db *gorm.DB, tx database.Transactor) *Store {
+	return &Store{db: db, tx: tx}
+}
+
+// chainLockClass keys the PostgreSQL advisory locks serialising appends to a chain,
+// together with a hash of the chain ID
+const chainLockClass = 7246003
+
+// checkpointLockKey is the PostgreSQL advisory lock held shared by appends and
+// exclusively while a checkpoint is taken, so a checkpoint sees every entry that was
+// given a sequence before it
+const checkpointLockKey = 7246005
+
+// verifyBatchSize is the number of entries read at a time while verifying
+const verifyBatchSize = 1000
+
+// Record stores one entry for an action on a customer
+func (s *Store) Record(ctx context.Context, action string, customerID uuid.UUID, changes []Change) error {
+	entry, err := NewEntry(ctx, action, &customerID, changes)
+	if err != nil {
+		return err
+	}
+	return s.append(ctx, []*Entry{entry})
+}
+
+// RecordAccess stores one entry per customer that was read
+func (s *Store) RecordAccess(ctx context.Context, action string, customerIDs []uuid.UUID) error {
+	entries := make([]*Entry, 0, len(customerIDs))
+	for i := range customerIDs {
+		entry, err := NewEntry(ctx, action, &customerIDs[i], nil)
+		if err != nil {
+			return err
+		}
+		entries = append(entries, entry)
+	}
+	return s.append(ctx, entries)
+}
+
+// append links each entry to the end of the chain of its customer and stores them.
+// Appends to a chain are serialised with an advisory lock held until the surrounding
+// transaction ends, so appends for other customers go ahead. Locks are taken in
+// chain order.
+func (s *Store) append(ctx context.Context, entries []*Entry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+
+	heads := make(map[uuid.UUID]string)
+	for _, entry := range entries {
+		chain := uuid.Nil
+		if entry.CustomerID != nil {
+			chain = *entry.CustomerID
+		}
+		entry.ChainID = &chain
+		heads[chain] = genesisHash
+	}
+	chains := make([]uuid.UUID, 0, len(heads))
+	for chain := range heads {
+		chains = append(chains, chain)
+	}
+	slices.SortFunc(chains, func(a, b uuid.UUID) int { return slices.Compare(a[:], b[:]) })
+
+	return s.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		db := database.Conn(ctx, s.db)
+		if err := db.Exec("SELECT pg_advisory_xact_lock_shared(?)", checkpointLockKey).Error; err != nil {
+			return fmt.Errorf("failed to acquire audit checkpoint lock: %w", err)
+		}
+		for _, chain := range chains {
+			if err := db.Exec("SELECT pg_advisory_xact_lock(?, hashtext(?))", chainLockClass, chain.String()).Error; err != nil {
+				return fmt.Errorf("failed to acquire audit chain lock: %w", err)
+			}
+
+			var last Entry
+			err := db.Where("chain_id = ?", chain).Order("sequence DESC").Limit(1).Take(&last).Error
+			switch {
+			case err == nil:
+				heads[chain] = last.Hash
+			case !errors.Is(err, gorm.ErrRecordNotFound):
+				return fmt.Errorf("failed to read audit chain head: %w", err)
+			}
+		}
+
+		for _, entry := range entries {
+			prevHash := heads[*entry.ChainID]
+			hash, err := entry.computeHash(prevHash)
+			if err != nil {
+				return err
+			}
+			entry.PrevHash = prevHash
+			entry.Hash = hash
+			heads[*entry.ChainID] = hash
+		}
+
+		if err := db.Create(entries).Error; err != nil {
+			return fmt.Errorf("failed to append audit entries: %w", err)
+		}
+		return nil
+	})
+}
+
+// Checkpoint records the head of every chain appended to since the previous
+// checkpoint in a new entry on the single chain kept before entries were chained per
+// customer, and returns it, or nil if no chain was appended to. Verify checks that
+// every recorded head is still there, so removing a whole chain, or its last entries,
+// is noticed once a checkpoint has covered them. Appends wait while it runs.
+func (s *Store) Checkpoint(ctx context.Context) (*Entry, error) {
+	var checkpoint *Entry
+	err := s.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		db := database.Conn(ctx, s.db)
+		if err := db.Exec("SELECT pg_advisory_xact_lock(?)", checkpointLockKey).Error; err != nil {
+			return fmt.Errorf("failed to acquire audit checkpoint lock: %w", err)
+		}
+
+		prevHash := genesisHash
+		var after int64
+		var last Entry
+		err := db.Where("chain_id IS NULL").Order("sequence DESC").Limit(1).Take(&last).Error
+		switch {
+		case err == nil:
+			prevHash = last.Hash
+			if last.Action == ActionAuditCheckpoint {
+				after = last.Sequence
+			}
+		case !errors.Is(err, gorm.ErrRecordNotFound):
+			return fmt.Errorf("failed to read audit checkpoint: %w", err)
+		}
+
+		var heads []ChainHead
+		err = db.Model(&Entry{}).
+			Select("DISTINCT ON (chain_id) chain_id, sequence, hash").
+			Where("chain_id IS NOT NULL AND sequence > ?", after).
+			Order("chain_id, sequence DESC").
+			Scan(&heads).Error
+		if err != nil {
+			return fmt.Errorf("failed to read audit chain heads: %w", err)
+		}
+		if len(heads) == 0 {
+			return nil
+		}
+
+		if checkpoint, err = newCheckpoint(ctx, heads, prevHash); err != nil {
+			return err
+		}
+		if err := db.Create(checkpoint).Error; err != nil {
+			return fmt.Errorf("failed to append audit checkpoint: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return checkpoint, nil
+}
+
+// RunCheckpoints takes a checkpoint every interval until ctx is cancelled
+func (s *Store) RunCheckpoints(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := s.Checkpoint(ctx); err != nil {
+				log.Printf("Audit checkpoint: %v", err)
+			}
+		}
+	}
+}
+
+// List returns a page of entries matching the query, newest first
+func (s *Store) List(ctx context.Context, query Query) (*ListResponse, error) {
+	if query.Page < 1 {
+		query.Page = 1
+	}
+	if query.PageSize < 1 || query.PageSize > 100 {
+		query.PageSize = 50
+	}
+
+	db := database.Conn(ctx, s.db).Model(&Entry{})
+	if query.CustomerID != nil {
+		db = db.Where("customer_id = ?", *query.CustomerID)
+	}
+	if query.Actor != "" {
+		db = db.Where("actor = ?", query.Actor)
+	}
+	if query.Action != "" {
+		db = db.Where("action = ?", query.Action)
+	}
+	if !query.From.IsZero() {
+		db = db.Where("occurred_at >= ?", query.From)
+	}
+	if !query.To.IsZero() {
+		db = db.Where("occurred_at < ?", query.To)
+	}
+
+	var total int64
+	if err := db.Count(&total).Error; err != nil {
+		return nil, fmt.Errorf("failed to count audit entries: %w", err)
+	}
+
+	var entries []Entry
+	offset := (query.Page - 1) * query.PageSize
+	if err := db.Order("sequence DESC").Offset(offset).Limit(query.PageSize).Find(&entries).Error; err != nil {
+		return nil, fmt.Errorf("failed to list audit entries: %w", err)
+	}
+
+	totalPages := int(total) / query.PageSize
+	if int(total)%query.PageSize > 0 {
+		totalPages++
+	}
+
+	return &ListResponse{
+		Entries:    entries,
+		Total:      total,
+		Page:       query.Page,
+		PageSize:   query.PageSize,
+		TotalPages: totalPages,
+	}, nil
+}
+
+// Verify walks every chain in sequence order, first the single chain kept before
+// entries were chained per customer, then the chain of each customer, and reports the
+// first entry that was altered, or whose predecessor was altered or removed. A
+// checkpoint on the first chain whose recorded chain heads are gone is reported too.
+func (s *Store) Verify(ctx context.Context) (*VerifyResult, error) {
+	db := database.Conn(ctx, s.db)
+	result := &VerifyResult{Valid: true}
+
+	prevHash := genesisHash
+	var after int64
+	for {
+		var batch []Entry
+		if err := db.Where("chain_id IS NULL AND sequence > ?", after).Order("sequence").Limit(verifyBatchSize).Find(&batch).Error; err != nil {
+			return nil, fmt.Errorf("failed to read audit entries: %w", err)
+		}
+
+		for i := range batch {
+			ok, err := result.check(&batch[i], prevHash)
+			if err != nil {
+				return nil, err
+			}
+			if !ok {
+				return result, nil
+			}
+			if batch[i].Action == ActionAuditCheckpoint {
+				if ok, err := s.checkHeads(db, result, &batch[i]); err != nil || !ok {
+					return result, err
+				}
+				result.Checkpoint = batch[i].Hash
+			}
+			prevHash = batch[i].Hash
+		}
+
+		if len(batch) < verifyBatchSize {
+			break
+		}
+		after = batch[len(batch)-1].Sequence
+	}
+
+	chain := uuid.Nil
+	after = 0
+	prevHash = genesisHash
+	for {
+		var batch []Entry
+		err := db.Where("chain_id IS NOT NULL AND (chain_id, sequence) > (?, ?)", chain, after).
+			Order("chain_id, sequence").
+			Limit(verifyBatchSize).
+			Find(&batch).Error
+		if err != nil {
+			return nil, fmt.Errorf("failed to read audit entries: %w", err)
+		}
+
+		for i := range batch {
+			entry := &batch[i]
+			if *entry.ChainID != chain {
+				chain = *entry.ChainID
+				prevHash = genesisHash
+			}
+			ok, err := result.check(entry, prevHash)
+			if err != nil {
+				return nil, err
+			}
+			if !ok {
+				return result, nil
+			}
+			prevHash = entry.Hash
+			after = entry.Sequence
+		}
+
+		if len(batch) < verifyBatchSize {
+			return result, nil
+		}
+	}
+}
+
+// checkHeads verifies that every chain head recorded by a checkpoint is still
+// stored unaltered, marking the result as failed at the checkpoint otherwise
+func (s *Store) checkHeads(db *gorm.DB, result *VerifyResult, checkpoint *Entry) (bool, error) {
+	heads, err := checkpoint.chainHeads()
+	if err != nil {
+		return false, err
+	}
+
+	for start := 0; start < len(heads); start += verifyBatchSize {
+		batch := heads[start:min(start+verifyBatchSize, len(heads))]
+		tuples := make([][]any, len(batch))
+		for i, head := range batch {
+			tuples[i] = []any{head.ChainID, head.Sequence, head.Hash}
+		}
+
+		var found int64
+		if err := db.Model(&Entry{}).Where("(chain_id, sequence, hash) IN ?", tuples).Count(&found).Error; err != nil {
+			return false, fmt.Errorf("failed to read audit chain heads: %w", err)
+		}
+		if found != int64(len(batch)) {
+			result.broken(checkpoint.Sequence, "a chain head recorded by the checkpoint is missing or altered")
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// check verifies an entry against the hash of its predecessor, counting it when it
+// matches and marking the result as failed otherwise
+func (r *VerifyResult) check(entry *Entry, prevHash string) (bool, error) {
+	if entry.PrevHash != prevHash {
+		r.broken(entry.Sequence, "previous hash does not match the preceding entry")
+		return false, nil
+	}
+	hash, err := entry.computeHash(entry.PrevHash)
+	if err != nil {
+		return false, err
+	}
+	if hash != entry.Hash {
+		r.broken(entry.Sequence, "entry content does not match its hash")
+		return false, nil
+	}
+	r.Checked++
+	return true, nil
+}
+
+// broken marks the result as failed at the given sequence
+func (r *VerifyResult) broken(sequence int64, reason string) *VerifyResult {
+	r.Valid = false
+	r.BrokenAt = &sequence
+	r.Reason = reason
+	return r
+}
@@ -0,0 +1,358 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// chain returns entries about a customer linked as append links them
+func chain(t *testing.T, customerID uuid.UUID, changes ...[]Change) []Entry {
+	t.Helper()
+	ctx := WithMetadata(context.Background(), Metadata{Actor: "user-1", RequestID: "req-1", ClientIP: "10.0.0.1"})
+
+	entries := make([]Entry, len(changes))
+	prevHash := genesisHash
+	for i := range changes {
+		entry, err := NewEntry(ctx, ActionCustomerUpdate, &customerID, changes[i])
+		if err != nil {
+			t.Fatalf("NewEntry() error = %v", err)
+		}
+		entry.Sequence = int64(i + 1)
+		entry.ChainID = &customerID
+		entry.PrevHash = prevHash
+		if entry.Hash, err = entry.computeHash(prevHash); err != nil {
+			t.Fatalf("computeHash() error = %v", err)
+		}
+		prevHash = entry.Hash
+		entries[i] = *entry
+	}
+	return entries
+}
+
+// verify checks entries in order as Verify does, returning the result
+func verify(t *testing.T, entries []Entry) *VerifyResult {
+	t.Helper()
+	result := &VerifyResult{Valid: true}
+	prevHash := genesisHash
+	for i := range entries {
+		ok, err := result.check(&entries[i], prevHash)
+		if err != nil {
+			t.Fatalf("check() error = %v", err)
+		}
+		if !ok {
+			break
+		}
+		prevHash = entries[i].Hash
+	}
+	return result
+}
+
+func TestVerifyChain(t *testing.T) {
+	customerID := uuid.New()
+	changes := [][]Change{
+		{{Field: "status", Before: "inactive", After: "active"}},
+		{RedactedChange("email")},
+		nil,
+		{{Field: "occupation", Redacted: true}, {Field: "products", Before: []string{"savings"}, After: []string{"savings", "loan"}}},
+	}
+
+	tests := []struct {
+		name     string
+		tamper   func(entries []Entry) []Entry
+		valid    bool
+		checked  int64
+		brokenAt int64
+		reason   string
+	}{
+		{
+			name:    "intact chain",
+			tamper:  func(entries []Entry) []Entry { return entries },
+			valid:   true,
+			checked: 4,
+		},
+		{
+			name: "changes stored in another key order and spacing",
+			tamper: func(entries []Entry) []Entry {
+				reordered := `[{"after": "active", "field": "status", "before": "inactive"}]`
+				entries[0].Changes = &reordered
+				return entries
+			},
+			valid:   true,
+			checked: 4,
+		},
+		{
+			name: "altered actor",
+			tamper: func(entries []Entry) []Entry {
+				entries[1].Actor = "someone-else"
+				return entries
+			},
+			checked:  1,
+			brokenAt: 2,
+			reason:   "entry content does not match its hash",
+		},
+		{
+			name: "altered changes",
+			tamper: func(entries []Entry) []Entry {
+				altered := `[{"field": "status", "before": "inactive", "after": "closed"}]`
+				entries[0].Changes = &altered
+				return entries
+			},
+			brokenAt: 1,
+			reason:   "entry content does not match its hash",
+		},
+		{
+			name: "removed entry",
+			tamper: func(entries []Entry) []Entry {
+				return append(entries[:1:1], entries[2:]...)
+			},
+			checked:  1,
+			brokenAt: 3,
+			reason:   "previous hash does not match the preceding entry",
+		},
+		{
+			name: "removed first entry",
+			tamper: func(entries []Entry) []Entry {
+				return entries[1:]
+			},
+			brokenAt: 2,
+			reason:   "previous hash does not match the preceding entry",
+		},
+		{
+			name: "rehashed entry",
+			tamper: func(entries []Entry) []Entry {
+				entries[2].Action = ActionCustomerDelete
+				entries[2].Hash, _ = entries[2].computeHash(entries[2].PrevHash)
+				return entries
+			},
+			checked:  3,
+			brokenAt: 4,
+			reason:   "previous hash does not match the preceding entry",
+		},
+		{
+			name: "swapped entries",
+			tamper: func(entries []Entry) []Entry {
+				entries[1], entries[2] = entries[2], entries[1]
+				return entries
+			},
+			checked:  1,
+			brokenAt: 3,
+			reason:   "previous hash does not match the preceding entry",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := verify(t, tt.tamper(chain(t, customerID, changes...)))
+
+			if result.Valid != tt.valid {
+				t.Errorf("Valid = %v, want %v", result.Valid, tt.valid)
+			}
+			if result.Checked != tt.checked {
+				t.Errorf("Checked = %d, want %d", result.Checked, tt.checked)
+			}
+			if tt.valid {
+				if result.BrokenAt != nil {
+					t.Errorf("BrokenAt = %d, want none", *result.BrokenAt)
+				}
+				return
+			}
+			if result.BrokenAt == nil || *result.BrokenAt != tt.brokenAt {
+				t.Errorf("BrokenAt = %v, want %d", result.BrokenAt, tt.brokenAt)
+			}
+			if result.Reason != tt.reason {
+				t.Errorf("Reason = %q, want %q", result.Reason, tt.reason)
+			}
+		})
+	}
+}
+
+func TestComputeHash(t *testing.T) {
+	customerID := uuid.New()
+	entry, err := NewEntry(context.Background(), ActionCustomerView, &customerID, []Change{{Field: "status", Before: "active", After: "closed"}})
+	if err != nil {
+		t.Fatalf("NewEntry() error = %v", err)
+	}
+	if entry.Actor != SystemActor {
+		t.Errorf("Actor = %q, want %q", entry.Actor, SystemActor)
+	}
+
+	want, err := entry.computeHash(genesisHash)
+	if err != nil {
+		t.Fatalf("computeHash() error = %v", err)
+	}
+	if again, _ := entry.computeHash(genesisHash); again != want {
+		t.Errorf("computeHash() is not deterministic: %s != %s", again, want)
+	}
+
+	otherCustomer := uuid.New()
+	otherChanges := `[{"field": "status", "before": "active", "after": "suspended"}]`
+	tests := []struct {
+		name     string
+		alter    func(e *Entry)
+		prevHash string
+	}{
+		{name: "customer", alter: func(e *Entry) { e.CustomerID = &otherCustomer }},
+		{name: "no customer", alter: func(e *Entry) { e.CustomerID = nil }},
+		{name: "actor", alter: func(e *Entry) { e.Actor = "user-2" }},
+		{name: "action", alter: func(e *Entry) { e.Action = ActionCustomerDelete }},
+		{name: "time", alter: func(e *Entry) { e.OccurredAt = e.OccurredAt.Add(time.Microsecond) }},
+		{name: "request", alter: func(e *Entry) { e.RequestID = "req-2" }},
+		{name: "client", alter: func(e *Entry) { e.ClientIP = "10.0.0.2" }},
+		{name: "changes", alter: func(e *Entry) { e.Changes = &otherChanges }},
+		{name: "no changes", alter: func(e *Entry) { e.Changes = nil }},
+		{name: "previous hash", alter: func(e *Entry) {}, prevHash: want},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			altered := *entry
+			tt.alter(&altered)
+			prevHash := genesisHash
+			if tt.prevHash != "" {
+				prevHash = tt.prevHash
+			}
+			got, err := altered.computeHash(prevHash)
+			if err != nil {
+				t.Fatalf("computeHash() error = %v", err)
+			}
+			if got == want {
+				t.Errorf("computeHash() = %s with another %s, want a different hash", got, tt.name)
+			}
+		})
+	}
+}
+
+func TestCheckpoint(t *testing.T) {
+	alice, bob := uuid.New(), uuid.New()
+	aliceChain := chain(t, alice, nil, nil, nil)
+	bobChain := chain(t, bob, nil)
+	heads := []ChainHead{
+		{ChainID: alice, Sequence: aliceChain[2].Sequence, Hash: aliceChain[2].Hash},
+		{ChainID: bob, Sequence: bobChain[0].Sequence, Hash: bobChain[0].Hash},
+	}
+
+	// The single chain kept before: entries without a chain, then checkpoints
+	legacy := chain(t, uuid.New(), nil, nil)
+	for i := range legacy {
+		legacy[i].ChainID = nil
+	}
+	first, err := newCheckpoint(context.Background(), heads[:1], legacy[1].Hash)
+	if err != nil {
+		t.Fatalf("newCheckpoint() error = %v", err)
+	}
+	first.Sequence = 3
+	second, err := newCheckpoint(context.Background(), heads, first.Hash)
+	if err != nil {
+		t.Fatalf("newCheckpoint() error = %v", err)
+	}
+	second.Sequence = 4
+
+	if first.Action != ActionAuditCheckpoint || first.CustomerID != nil || first.Actor != SystemActor {
+		t.Errorf("newCheckpoint() = %+v, want a system checkpoint without a customer", first)
+	}
+	got, err := second.chainHeads()
+	if err != nil {
+		t.Fatalf("chainHeads() error = %v", err)
+	}
+	if !reflect.DeepEqual(got, heads) {
+		t.Errorf("chainHeads() = %v, want %v", got, heads)
+	}
+
+	tests := []struct {
+		name     string
+		tamper   func(entries []Entry) []Entry
+		valid    bool
+		checked  int64
+		brokenAt int64
+	}{
+		{
+			name:    "checkpoints after the entries kept before",
+			tamper:  func(entries []Entry) []Entry { return entries },
+			valid:   true,
+			checked: 4,
+		},
+		{
+			name: "recorded head replaced",
+			tamper: func(entries []Entry) []Entry {
+				altered := strings.Replace(*entries[3].Changes, heads[1].Hash, aliceChain[1].Hash, 1)
+				entries[3].Changes = &altered
+				return entries
+			},
+			checked:  3,
+			brokenAt: 4,
+		},
+		{
+			name: "recorded chain dropped",
+			tamper: func(entries []Entry) []Entry {
+				changes, _ := json.Marshal([]Change{{Field: chainHeadsField, After: heads[:1]}})
+				altered := string(changes)
+				entries[3].Changes = &altered
+				return entries
+			},
+			checked:  3,
+			brokenAt: 4,
+		},
+		{
+			name: "checkpoint removed",
+			tamper: func(entries []Entry) []Entry {
+				return append(entries[:2:2], entries[3])
+			},
+			checked:  2,
+			brokenAt: 4,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entries := tt.tamper(append(slices.Clone(legacy), *first, *second))
+			result := verify(t, entries)
+
+			if result.Valid != tt.valid || result.Checked != tt.checked {
+				t.Errorf("Valid = %v, Checked = %d, want %v, %d", result.Valid, result.Checked, tt.valid, tt.checked)
+			}
+			if !tt.valid && (result.BrokenAt == nil || *result.BrokenAt != tt.brokenAt) {
+				t.Errorf("BrokenAt = %v, want %d", result.BrokenAt, tt.brokenAt)
+			}
+		})
+	}
+}
+
+func TestChainHeads(t *testing.T) {
+	chainID := uuid.New()
+	head := ChainHead{ChainID: chainID, Sequence: 42, Hash: strings.Repeat("a", 64)}
+	stored := `[{"after": [{"hash": "` + head.Hash + `", "chain_id": "` + chainID.String() + `", "sequence": 42}], "field": "chain_heads", "before": null}]`
+	other := `[{"field": "status", "before": "active", "after": "closed"}]`
+	malformed := `{"field": "chain_heads"}`
+
+	tests := []struct {
+		name    string
+		changes *string
+		want    []ChainHead
+		wantErr bool
+	}{
+		{name: "as stored by PostgreSQL", changes: &stored, want: []ChainHead{head}},
+		{name: "no changes"},
+		{name: "other changes", changes: &other},
+		{name: "malformed", changes: &malformed, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entry := Entry{Action: ActionAuditCheckpoint, Changes: tt.changes}
+			got, err := entry.chainHeads()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("chainHeads() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("chainHeads() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
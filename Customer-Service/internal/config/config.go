@@ -5,25 +5,49 @@ import (
 	"log"
 	"os"
 	"strconv"
+	"time"
 
 	"github.com/joho/godotenv"
 )
 
+// Placeholder secrets, as published in this repository and .env.example. They are
+// only accepted in development.
+const (
+	placeholderJWTSecret    = "your_jwt_secret_key_here"
+	placeholderCursorSecret = "your_cursor_secret_here"
+)
+
+// minSecretLength is the shortest signing secret accepted outside development, in bytes
+const minSecretLength = 32
+
 // Config holds all configuration for the application
 type Config struct {
-	Database DatabaseConfig
-	Server   ServerConfig
-	App      AppConfig
+	Database   DatabaseConfig
+	Server     ServerConfig
+	App        AppConfig
+	Events     EventsConfig
+	Webhooks   WebhooksConfig
+	Encryption EncryptionConfig
+	KYC        KYCConfig
+	Screening  ScreeningConfig
+	Risk       RiskConfig
+	Privacy    PrivacyConfig
+	Retention  RetentionConfig
+	Audit      AuditConfig
 }
 
 // DatabaseConfig holds database configuration
 type DatabaseConfig struct {
-	Host     string
-	Port     int
-	User     string
-	Password string
-	DBName   string
-	SSLMode  string
+	Host        string
+	Port        int
+	User        string
+	Password    string
+	DBName      string
+	SSLMode     string
+	AutoMigrate bool
+	// QueryTimeout bounds a single query; SearchTimeout applies to free-text search
+	QueryTimeout  time.Duration
+	SearchTimeout time.Duration
 }
 
 // ServerConfig holds server configuration
@@ -37,6 +61,100 @@ type AppConfig struct {
 	Environment string
 	LogLevel    string
 	JWTSecret   string
+	JWTAudience string
+	JWTIssuer   string
+	JWKSFile    string
+	// IdempotencyTTL is how long stored Idempotency-Key responses are replayed
+	IdempotencyTTL time.Duration
+	// IdempotencyLease is how long a running request holds its Idempotency-Key
+	IdempotencyLease time.Duration
+	// CursorSecret signs the pagination cursors handed to clients
+	CursorSecret string
+}
+
+// EventsConfig holds domain event publishing configuration
+type EventsConfig struct {
+	// Publisher is one of "stdout", "file" or "none"
+	Publisher    string
+	File         string
+	PollInterval time.Duration
+	BatchSize    int
+	MaxAttempts  int
+}
+
+// WebhooksConfig holds outgoing webhook delivery configuration
+type WebhooksConfig struct {
+	PollInterval time.Duration
+	Timeout      time.Duration
+	MaxAttempts  int
+	// DisableAfter is the number of consecutive failures that disables a subscription
+	DisableAfter int
+}
+
+// EncryptionConfig holds PII encryption configuration
+type EncryptionConfig struct {
+	// KeysFile is the JSON key file read by the file key provider
+	KeysFile string
+	// ReencryptInterval is how often rows under an older key are rewritten
+	ReencryptInterval  time.Duration
+	ReencryptBatchSize int
+}
+
+// KYCConfig holds identity verification configuration
+type KYCConfig struct {
+	// StorageDir is the directory uploaded documents are kept in
+	StorageDir string
+	// MaxDocumentSize is the largest accepted upload in bytes
+	MaxDocumentSize int
+	// Validity is how long a verification lasts before the customer must be verified again
+	Validity       time.Duration
+	ExpiryInterval time.Duration
+}
+
+// ScreeningConfig holds sanctions and PEP screening configuration
+type ScreeningConfig struct {
+	// WatchlistDir is the directory watchlist files are loaded from
+	WatchlistDir string
+	// NameThreshold is the lowest name similarity, from 0 to 1, considered a candidate
+	NameThreshold float64
+	// AlertThreshold is the lowest final score that raises an alert
+	AlertThreshold    float64
+	RescreenInterval  time.Duration
+	RescreenBatchSize int
+}
+
+// RiskConfig holds customer risk rating configuration
+type RiskConfig struct {
+	// RulesFile is the YAML or JSON file the scoring rules are loaded from
+	RulesFile       string
+	ReviewInterval  time.Duration
+	ReviewBatchSize int
+}
+
+// PrivacyConfig holds data subject request configuration
+type PrivacyConfig struct {
+	// ExportDir is the directory access request exports are kept in
+	ExportDir string
+	// ResponseDeadline is how long after a request is received it must be answered
+	ResponseDeadline time.Duration
+}
+
+// RetentionConfig holds the retention of deleted customers configuration
+type RetentionConfig struct {
+	// RestoreWindow is how long after deletion a customer may be restored
+	RestoreWindow time.Duration
+	// Interval is how often a scheduled purge run starts
+	Interval     time.Duration
+	PollInterval time.Duration
+	BatchSize    int
+	// DryRun makes scheduled runs only report what they would purge
+	DryRun bool
+}
+
+// AuditConfig holds audit trail configuration
+type AuditConfig struct {
+	// CheckpointInterval is how often the heads of the audit chains are recorded
+	CheckpointInterval time.Duration
 }
 
 // Load loads configuration from environment variables
@@ -48,27 +166,126 @@ func Load() (*Config, error) {
 
 	config := &Config{
 		Database: DatabaseConfig{
-			Host:     getEnv("DB_HOST", "localhost"),
-			Port:     getEnvAsInt("DB_PORT", 5432),
-			User:     getEnv("DB_USER", "postgres"),
-			Password: getEnv("DB_PASSWORD", ""),
-			DBName:   getEnv("DB_NAME", "core_bank"),
-			SSLMode:  getEnv("DB_SSL_MODE", "disable"),
+			Host:          getEnv("DB_HOST", "localhost"),
+			Port:          getEnvAsInt("DB_PORT", 5432),
+			User:          getEnv("DB_USER", "postgres"),
+			Password:      getEnv("DB_PASSWORD", ""),
+			DBName:        getEnv("DB_NAME", "core_bank"),
+			SSLMode:       getEnv("DB_SSL_MODE", "disable"),
+			AutoMigrate:   getEnvAsBool("DB_AUTO_MIGRATE", false),
+			QueryTimeout:  getEnvAsDuration("DB_QUERY_TIMEOUT", 5*time.Second),
+			SearchTimeout: getEnvAsDuration("DB_SEARCH_TIMEOUT", 3*time.Second),
 		},
 		Server: ServerConfig{
 			Host: getEnv("SERVER_HOST", "localhost"),
 			Port: getEnvAsInt("SERVER_PORT", 8080),
 		},
 		App: AppConfig{
-			Environment: getEnv("APP_ENV", "development"),
-			LogLevel:    getEnv("LOG_LEVEL", "info"),
-			JWTSecret:   getEnv("JWT_SECRET", "your_jwt_secret_key_here"),
+			Environment:      getEnv("APP_ENV", "development"),
+			LogLevel:         getEnv("LOG_LEVEL", "info"),
+			JWTSecret:        os.Getenv("JWT_SECRET"),
+			JWTAudience:      getEnv("JWT_AUDIENCE", "customer-service"),
+			JWTIssuer:        getEnv("JWT_ISSUER", ""),
+			JWKSFile:         getEnv("JWT_JWKS_FILE", ""),
+			IdempotencyTTL:   getEnvAsDuration("IDEMPOTENCY_TTL", 24*time.Hour),
+			IdempotencyLease: getEnvAsDuration("IDEMPOTENCY_LEASE", time.Minute),
+			CursorSecret:     os.Getenv("CURSOR_SECRET"),
+		},
+		Events: EventsConfig{
+			Publisher:    getEnv("EVENTS_PUBLISHER", "stdout"),
+			File:         getEnv("EVENTS_FILE", "events.log"),
+			PollInterval: getEnvAsDuration("EVENTS_POLL_INTERVAL", time.Second),
+			BatchSize:    getEnvAsInt("EVENTS_BATCH_SIZE", 100),
+			MaxAttempts:  getEnvAsInt("EVENTS_MAX_ATTEMPTS", 20),
+		},
+		Webhooks: WebhooksConfig{
+			PollInterval: getEnvAsDuration("WEBHOOK_POLL_INTERVAL", 5*time.Second),
+			Timeout:      getEnvAsDuration("WEBHOOK_TIMEOUT", 10*time.Second),
+			MaxAttempts:  getEnvAsInt("WEBHOOK_MAX_ATTEMPTS", 10),
+			DisableAfter: getEnvAsInt("WEBHOOK_DISABLE_AFTER", 20),
+		},
+		Encryption: EncryptionConfig{
+			KeysFile:           getEnv("ENCRYPTION_KEYS_FILE", "keys.json"),
+			ReencryptInterval:  getEnvAsDuration("ENCRYPTION_REENCRYPT_INTERVAL", time.Minute),
+			ReencryptBatchSize: getEnvAsInt("ENCRYPTION_REENCRYPT_BATCH_SIZE", 100),
+		},
+		KYC: KYCConfig{
+			StorageDir:      getEnv("KYC_STORAGE_DIR", "data/kyc"),
+			MaxDocumentSize: getEnvAsInt("KYC_MAX_DOCUMENT_SIZE", 10<<20),
+			Validity:        getEnvAsDuration("KYC_VALIDITY", 365*24*time.Hour),
+			ExpiryInterval:  getEnvAsDuration("KYC_EXPIRY_INTERVAL", time.Hour),
+		},
+		Screening: ScreeningConfig{
+			WatchlistDir:      getEnv("SCREENING_WATCHLIST_DIR", "watchlists"),
+			NameThreshold:     getEnvAsFloat("SCREENING_NAME_THRESHOLD", 0.85),
+			AlertThreshold:    getEnvAsFloat("SCREENING_ALERT_THRESHOLD", 0.88),
+			RescreenInterval:  getEnvAsDuration("SCREENING_RESCREEN_INTERVAL", 10*time.Second),
+			RescreenBatchSize: getEnvAsInt("SCREENING_RESCREEN_BATCH_SIZE", 100),
+		},
+		Risk: RiskConfig{
+			RulesFile:       getEnv("RISK_RULES_FILE", "risk_rules.yaml"),
+			ReviewInterval:  getEnvAsDuration("RISK_REVIEW_INTERVAL", time.Hour),
+			ReviewBatchSize: getEnvAsInt("RISK_REVIEW_BATCH_SIZE", 100),
+		},
+		Privacy: PrivacyConfig{
+			ExportDir:        getEnv("PRIVACY_EXPORT_DIR", "data/exports"),
+			ResponseDeadline: getEnvAsDuration("PRIVACY_RESPONSE_DEADLINE", 30*24*time.Hour),
+		},
+		Retention: RetentionConfig{
+			RestoreWindow: getEnvAsDuration("RETENTION_RESTORE_WINDOW", 30*24*time.Hour),
+			Interval:      getEnvAsDuration("RETENTION_INTERVAL", 24*time.Hour),
+			PollInterval:  getEnvAsDuration("RETENTION_POLL_INTERVAL", time.Minute),
+			BatchSize:     getEnvAsInt("RETENTION_BATCH_SIZE", 100),
+			DryRun:        getEnvAsBool("RETENTION_DRY_RUN", true),
+		},
+		Audit: AuditConfig{
+			CheckpointInterval: getEnvAsDuration("AUDIT_CHECKPOINT_INTERVAL", time.Minute),
 		},
 	}
 
+	if err := config.checkSecrets(); err != nil {
+		return nil, err
+	}
 	return config, nil
 }
 
+// checkSecrets makes sure tokens can only be signed by whoever holds the configured
+// secrets. Outside development, a secret must be set, must not be the published
+// placeholder and must be at least minSecretLength bytes long. JWT_SECRET may be left
+// unset when RS256 keys are configured, which disables HS256 tokens. In development,
+// unset secrets fall back to the placeholders.
+func (c *Config) checkSecrets() error {
+	if c.IsDevelopment() {
+		if c.App.JWTSecret == "" {
+			c.App.JWTSecret = placeholderJWTSecret
+		}
+		if c.App.CursorSecret == "" {
+			c.App.CursorSecret = placeholderCursorSecret
+		}
+		return nil
+	}
+
+	if c.App.JWTSecret != "" || c.App.JWKSFile == "" {
+		if err := checkSecret("JWT_SECRET", c.App.JWTSecret, placeholderJWTSecret); err != nil {
+			return err
+		}
+	}
+	return checkSecret("CURSOR_SECRET", c.App.CursorSecret, placeholderCursorSecret)
+}
+
+// checkSecret rejects a missing, placeholder or short secret
+func checkSecret(name, value, placeholder string) error {
+	switch {
+	case value == "":
+		return fmt.Errorf("%s must be set outside development", name)
+	case value == placeholder:
+		return fmt.Errorf("%s must not be the placeholder value outside development", name)
+	case len(value) < minSecretLength:
+		return fmt.Errorf("%s must be at least %d bytes long outside development", name, minSecretLength)
+	}
+	return nil
+}
+
 // GetDatabaseDSN returns the database connection string
 func (c *Config) GetDatabaseDSN() string {
 	return fmt.Sprintf(
@@ -114,3 +331,33 @@ func getEnvAsInt(key string, fallback int) int {
 	}
 	return fallback
 }
+
+// getEnvAsFloat gets an environment variable as a float with a fallback value
+func getEnvAsFloat(key string, fallback float64) float64 {
+	if value := os.Getenv(key); value != "" {
+		if floatValue, err := strconv.ParseFloat(value, 64); err == nil {
+			return floatValue
+		}
+	}
+	return fallback
+}
+
+// getEnvAsBool gets an environment variable as a boolean with a fallback value
+func getEnvAsBool(key string, fallback bool) bool {
+	if value := os.Getenv(key); value != "" {
+		if boolValue, err := strconv.ParseBool(value); err == nil {
+			return boolValue
+		}
+	}
+	return fallback
+}
+
+// getEnvAsDuration gets an environment variable as a duration (e.g. "5s") with a fallback value
+func getEnvAsDuration(key string, fallback time.Duration) time.Duration {
+	if value := os.Getenv(key); value != "" {
+		if duration, err := time.ParseDuration(value); err == nil {
+			return duration
+		}
+	}
+	return fallback
+}
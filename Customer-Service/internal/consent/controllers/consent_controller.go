@@ -0,0 +1,184 @@
+package controllers
+
+import (
+	"customer-service/internal/consent/models"
+	"customer-service/internal/consent/service"
+	customermodels "customer-service/internal/customer/models"
+	"customer-service/pkg/apierror"
+	"customer-service/pkg/middleware"
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// ConsentController handles HTTP requests for customer consents
+type ConsentController struct {
+	service service.ConsentService
+}
+
+// NewConsentController creates a new consent controller instance
+func NewConsentController(consentService service.ConsentService) *ConsentController {
+	return &ConsentController{
+		service: consentService,
+	}
+}
+
+// ListConsents handles GET /customers/:id/consents
+func (ctrl *ConsentController) ListConsents(c *gin.Context) {
+	id, ok := ctrl.parseUUID(c, "id")
+	if !ok {
+		return
+	}
+
+	consents, err := ctrl.service.ListConsents(c.Request.Context(), id)
+	if err != nil {
+		ctrl.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, consents)
+}
+
+// GetHistory handles GET /customers/:id/consents/history
+func (ctrl *ConsentController) GetHistory(c *gin.Context) {
+	id, ok := ctrl.parseUUID(c, "id")
+	if !ok {
+		return
+	}
+
+	history, err := ctrl.service.GetHistory(c.Request.Context(), id)
+	if err != nil {
+		ctrl.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, history)
+}
+
+// Grant handles POST /customers/:id/consents
+func (ctrl *ConsentController) Grant(c *gin.Context) {
+	id, ok := ctrl.parseUUID(c, "id")
+	if !ok {
+		return
+	}
+
+	var req models.GrantRequest
+	if !ctrl.bindJSON(c, &req) {
+		return
+	}
+
+	consent, err := ctrl.service.Grant(c.Request.Context(), id, req, actorFromContext(c))
+	if err != nil {
+		ctrl.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, consent)
+}
+
+// Withdraw handles POST /customers/:id/consents/withdraw
+func (ctrl *ConsentController) Withdraw(c *gin.Context) {
+	id, ok := ctrl.parseUUID(c, "id")
+	if !ok {
+		return
+	}
+
+	var req models.WithdrawRequest
+	if !ctrl.bindJSON(c, &req) {
+		return
+	}
+
+	consent, err := ctrl.service.Withdraw(c.Request.Context(), id, req, actorFromContext(c))
+	if err != nil {
+		ctrl.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, consent)
+}
+
+// ListContactable handles GET /consents/contactable
+func (ctrl *ConsentController) ListContactable(c *gin.Context) {
+	var query models.ContactableQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		apierror.Abort(c, http.StatusBadRequest, apierror.CodeInvalidRequest, "invalid query parameters: "+err.Error())
+		return
+	}
+	if c.Query("after") != "" {
+		after, ok := ctrl.parseUUID(c, "after")
+		if !ok {
+			return
+		}
+		query.After = after
+	}
+
+	contactable, err := ctrl.service.ListContactable(c.Request.Context(), query)
+	if err != nil {
+		ctrl.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, contactable)
+}
+
+// bindJSON decodes and validates the request body, writing an error response on failure
+func (ctrl *ConsentController) bindJSON(c *gin.Context, req any) bool {
+	if err := c.ShouldBindJSON(req); err != nil {
+		apierror.Abort(c, http.StatusBadRequest, apierror.CodeInvalidRequest, "invalid request body: "+err.Error())
+		return false
+	}
+
+	if err := customermodels.ValidateStruct(req); err != nil {
+		ctrl.handleError(c, err)
+		return false
+	}
+
+	return true
+}
+
+// parseUUID parses a UUID path or query parameter, writing an error response on failure
+func (ctrl *ConsentController) parseUUID(c *gin.Context, param string) (uuid.UUID, bool) {
+	raw := c.Param(param)
+	if raw == "" {
+		raw = c.Query(param)
+	}
+	id, err := uuid.Parse(raw)
+	if err != nil {
+		apierror.Abort(c, http.StatusBadRequest, apierror.CodeInvalidRequest, "invalid "+param,
+			apierror.FieldError{Field: param, Message: "must be a valid UUID"})
+		return uuid.Nil, false
+	}
+	return id, true
+}
+
+// handleError maps service errors to HTTP responses
+func (ctrl *ConsentController) handleError(c *gin.Context, err error) {
+	var validationErr *customermodels.ValidationError
+	switch {
+	case errors.As(err, &validationErr):
+		fields := make([]apierror.FieldError, len(validationErr.Fields))
+		for i, f := range validationErr.Fields {
+			fields[i] = apierror.FieldError{Field: f.Field, Message: f.Message}
+		}
+		apierror.Abort(c, http.StatusBadRequest, apierror.CodeValidationFailed, "request validation failed", fields...)
+	case errors.Is(err, customermodels.ErrCustomerNotFound):
+		apierror.Abort(c, http.StatusNotFound, apierror.CodeNotFound, err.Error())
+	case errors.Is(err, models.ErrConsentNotGranted):
+		apierror.Abort(c, http.StatusConflict, apierror.CodeConflict, err.Error())
+	default:
+		log.Printf("Unhandled error processing %s %s: %v", c.Request.Method, c.Request.URL.Path, err)
+		apierror.Abort(c, http.StatusInternalServerError, apierror.CodeInternal, "internal server error")
+	}
+}
+
+// actorFromContext returns the subject of the authenticated caller
+func actorFromContext(c *gin.Context) string {
+	claims, ok := middleware.GetClaims(c)
+	if !ok {
+		return ""
+	}
+	return claims.Subject
+}
@@ -0,0 +1,166 @@
+package models
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Domain errors returned by the consent repository and service layers
+var (
+	ErrConsentNotGranted = errors.New("consent is not currently granted")
+)
+
+// DefaultContactableLimit and MaxContactableLimit bound a page of contactable customers
+const (
+	DefaultContactableLimit = 100
+	MaxContactableLimit     = 1000
+)
+
+// Purpose is what a customer consents to
+type Purpose string
+
+const (
+	PurposeMarketing   Purpose = "marketing"
+	PurposeDataSharing Purpose = "data_sharing"
+	PurposeProfiling   Purpose = "profiling"
+)
+
+// Channel is the means by which a customer may be contacted for marketing
+type Channel string
+
+const (
+	ChannelEmail Channel = "email"
+	ChannelSMS   Channel = "sms"
+	ChannelPhone Channel = "phone"
+	ChannelPost  Channel = "post"
+)
+
+// Status is whether a consent is currently given
+type Status string
+
+const (
+	StatusGranted   Status = "granted"
+	StatusWithdrawn Status = "withdrawn"
+)
+
+// Source is where a consent was given or withdrawn
+type Source string
+
+const (
+	SourceWeb        Source = "web"
+	SourceMobileApp  Source = "mobile_app"
+	SourceBranch     Source = "branch"
+	SourceCallCenter Source = "call_center"
+	SourcePaper      Source = "paper"
+)
+
+// Consent is the current state of one consent of a customer. Marketing consent is
+// given per channel; other purposes have no channel.
+type Consent struct {
+	ID            uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	CustomerID    uuid.UUID  `json:"customer_id" gorm:"type:uuid;not null"`
+	Purpose       Purpose    `json:"purpose" gorm:"not null;size:30"`
+	Channel       Channel    `json:"channel,omitempty" gorm:"not null;size:20"`
+	Status        Status     `json:"status" gorm:"not null;size:20"`
+	PolicyVersion string     `json:"policy_version" gorm:"not null;size:50"`
+	Source        Source     `json:"source" gorm:"not null;size:20"`
+	GrantedAt     *time.Time `json:"granted_at,omitempty"`
+	WithdrawnAt   *time.Time `json:"withdrawn_at,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+}
+
+// TableName returns the table name for Consent model
+func (Consent) TableName() string {
+	return "customer_consents"
+}
+
+// ChangedAt returns when the consent was last granted or withdrawn
+func (c *Consent) ChangedAt() time.Time {
+	if c.Status == StatusWithdrawn && c.WithdrawnAt != nil {
+		return *c.WithdrawnAt
+	}
+	if c.GrantedAt != nil {
+		return *c.GrantedAt
+	}
+	return time.Time{}
+}
+
+// Record is an entry of the append-only consent ledger: one grant or withdrawal of a
+// consent, effective from EffectiveAt
+type Record struct {
+	ID            uuid.UUID `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	CustomerID    uuid.UUID `json:"customer_id" gorm:"type:uuid;not null;index"`
+	Purpose       Purpose   `json:"purpose" gorm:"not null;size:30"`
+	Channel       Channel   `json:"channel,omitempty" gorm:"not null;size:20"`
+	Status        Status    `json:"status" gorm:"not null;size:20"`
+	PolicyVersion string    `json:"policy_version" gorm:"not null;size:50"`
+	Source        Source    `json:"source" gorm:"not null;size:20"`
+	EffectiveAt   time.Time `json:"effective_at" gorm:"not null"`
+	Note          string    `json:"note,omitempty" gorm:"size:500"`
+	RecordedBy    string    `json:"recorded_by" gorm:"not null;size:255"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// TableName returns the table name for Record model
+func (Record) TableName() string {
+	return "consent_records"
+}
+
+// GrantRequest represents the request payload for granting a consent. GrantedAt
+// defaults to now and may be earlier when consent was given on paper.
+type GrantRequest struct {
+	Purpose       Purpose    `json:"purpose" validate:"required,oneof=marketing data_sharing profiling"`
+	Channel       Channel    `json:"channel" validate:"omitempty,oneof=email sms phone post"`
+	PolicyVersion string     `json:"policy_version" validate:"required,max=50"`
+	Source        Source     `json:"source" validate:"required,oneof=web mobile_app branch call_center paper"`
+	GrantedAt     *time.Time `json:"granted_at"`
+	Note          string     `json:"note" validate:"max=500"`
+}
+
+// WithdrawRequest represents the request payload for withdrawing a consent.
+// WithdrawnAt defaults to now.
+type WithdrawRequest struct {
+	Purpose     Purpose    `json:"purpose" validate:"required,oneof=marketing data_sharing profiling"`
+	Channel     Channel    `json:"channel" validate:"omitempty,oneof=email sms phone post"`
+	Source      Source     `json:"source" validate:"required,oneof=web mobile_app branch call_center paper"`
+	WithdrawnAt *time.Time `json:"withdrawn_at"`
+	Note        string     `json:"note" validate:"max=500"`
+}
+
+// ListResponse lists the current consents of a customer
+type ListResponse struct {
+	CustomerID uuid.UUID `json:"customer_id"`
+	Consents   []Consent `json:"consents"`
+}
+
+// HistoryResponse lists every grant and withdrawal recorded for a customer
+type HistoryResponse struct {
+	CustomerID uuid.UUID `json:"customer_id"`
+	Records    []Record  `json:"records"`
+}
+
+// ContactableQuery pages through the customers who may currently be contacted for
+// marketing on a channel, in customer ID order starting after After
+type ContactableQuery struct {
+	Channel Channel   `form:"channel" validate:"required,oneof=email sms phone post"`
+	After   uuid.UUID `form:"-"`
+	Limit   int       `form:"limit"`
+}
+
+// Contactable is a customer who may currently be contacted on a channel
+type Contactable struct {
+	CustomerID    uuid.UUID `json:"customer_id"`
+	PolicyVersion string    `json:"policy_version"`
+	GrantedAt     time.Time `json:"granted_at"`
+}
+
+// ContactableResponse is a page of contactable customers. NextAfter is set when
+// there may be more, and is passed as after to fetch the next page.
+type ContactableResponse struct {
+	Channel   Channel       `json:"channel"`
+	Customers []Contactable `json:"customers"`
+	NextAfter *uuid.UUID    `json:"next_after,omitempty"`
+}
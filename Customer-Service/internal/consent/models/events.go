@@ -0,0 +1,15 @@
+package models
+
+import "time"
+
+// CustomerConsentChangedPayload is the payload of a CustomerConsentChanged event,
+// emitted when a customer grants or withdraws a consent. Channel is only set for
+// marketing consent.
+type CustomerConsentChangedPayload struct {
+	Purpose       Purpose   `json:"purpose"`
+	Channel       Channel   `json:"channel,omitempty"`
+	Status        Status    `json:"status"`
+	PolicyVersion string    `json:"policy_version"`
+	EffectiveAt   time.Time `json:"effective_at"`
+	Actor         string    `json:"actor"`
+}
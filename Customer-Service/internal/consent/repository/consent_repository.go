@@ -0,0 +1,125 @@
+package repository
+
+import (
+	"context"
+	"customer-service/internal/consent/models"
+	customermodels "customer-service/internal/customer/models"
+	"customer-service/internal/database"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ConsentRepository defines the interface for consent data access
+type ConsentRepository interface {
+	Get(ctx context.Context, customerID uuid.UUID, purpose models.Purpose, channel models.Channel) (*models.Consent, error)
+	List(ctx context.Context, customerID uuid.UUID) ([]models.Consent, error)
+	ListRecords(ctx context.Context, customerID uuid.UUID) ([]models.Record, error)
+	Save(ctx context.Context, consent *models.Consent, record *models.Record) error
+	ListContactable(ctx context.Context, channel models.Channel, after uuid.UUID, limit int) ([]models.Consent, error)
+	LockCustomer(ctx context.Context, customerID uuid.UUID) error
+}
+
+type consentRepository struct {
+	db *gorm.DB
+}
+
+// NewConsentRepository creates a new consent repository instance
+func NewConsentRepository(db *gorm.DB) ConsentRepository {
+	return &consentRepository{db: db}
+}
+
+// Get retrieves the current state of one consent of a customer, or nil if it was
+// never granted or withdrawn
+func (r *consentRepository) Get(ctx context.Context, customerID uuid.UUID, purpose models.Purpose, channel models.Channel) (*models.Consent, error) {
+	var consent models.Consent
+	err := database.Conn(ctx, r.db).
+		Where("customer_id = ? AND purpose = ? AND channel = ?", customerID, purpose, channel).
+		First(&consent).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get consent: %w", err)
+	}
+	return &consent, nil
+}
+
+// List returns the current state of every consent of a customer
+func (r *consentRepository) List(ctx context.Context, customerID uuid.UUID) ([]models.Consent, error) {
+	var consents []models.Consent
+	err := database.Conn(ctx, r.db).
+		Where("customer_id = ?", customerID).
+		Order("purpose, channel").
+		Find(&consents).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list consents: %w", err)
+	}
+	return consents, nil
+}
+
+// ListRecords returns the consent ledger of a customer, latest first
+func (r *consentRepository) ListRecords(ctx context.Context, customerID uuid.UUID) ([]models.Record, error) {
+	var records []models.Record
+	err := database.Conn(ctx, r.db).
+		Where("customer_id = ?", customerID).
+		Order("effective_at DESC, created_at DESC").
+		Find(&records).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list consent records: %w", err)
+	}
+	return records, nil
+}
+
+// Save stores the new state of a consent and appends the grant or withdrawal that
+// led to it to the ledger, in a single transaction. Callers hold the customer lock
+// from LockCustomer, so a consent is never created twice.
+func (r *consentRepository) Save(ctx context.Context, consent *models.Consent, record *models.Record) error {
+	return database.Conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(consent).Error; err != nil {
+			return fmt.Errorf("failed to save consent: %w", err)
+		}
+		if err := tx.Create(record).Error; err != nil {
+			return fmt.Errorf("failed to record consent change: %w", err)
+		}
+		return nil
+	})
+}
+
+// ListContactable returns the granted marketing consents on a channel of customers
+// that are neither closed nor deleted, in customer ID order starting after the
+// given ID
+func (r *consentRepository) ListContactable(ctx context.Context, channel models.Channel, after uuid.UUID, limit int) ([]models.Consent, error) {
+	var consents []models.Consent
+	err := database.Conn(ctx, r.db).
+		Joins("JOIN customers ON customers.id = customer_consents.customer_id").
+		Where("customer_consents.purpose = ? AND customer_consents.channel = ? AND customer_consents.status = ?",
+			models.PurposeMarketing, channel, models.StatusGranted).
+		Where("customer_consents.customer_id > ?", after).
+		Where("customers.deleted_at IS NULL AND customers.status <> ?", customermodels.CustomerStatusClosed).
+		Order("customer_consents.customer_id").
+		Limit(limit).
+		Find(&consents).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list contactable customers: %w", err)
+	}
+	return consents, nil
+}
+
+// LockCustomer locks the row of a customer until the transaction in ctx ends, so
+// that changes to its consents are applied one at a time
+func (r *consentRepository) LockCustomer(ctx context.Context, customerID uuid.UUID) error {
+	var locked []uuid.UUID
+	err := database.Conn(ctx, r.db).
+		Raw("SELECT id FROM customers WHERE id = ? FOR UPDATE", customerID).
+		Scan(&locked).Error
+	if err != nil {
+		return fmt.Errorf("failed to lock customer: %w", err)
+	}
+	if len(locked) == 0 {
+		return customermodels.ErrCustomerNotFound
+	}
+	return nil
+}
@@ -0,0 +1,310 @@
+package service
+
+import (
+	"context"
+	"customer-service/internal/audit"
+	"customer-service/internal/consent/models"
+	"customer-service/internal/consent/repository"
+	customermodels "customer-service/internal/customer/models"
+	customerrepository "customer-service/internal/customer/repository"
+	"customer-service/internal/database"
+	"customer-service/internal/events"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ConsentService defines the interface for customer consent management
+type ConsentService interface {
+	ListConsents(ctx context.Context, customerID uuid.UUID) (*models.ListResponse, error)
+	GetHistory(ctx context.Context, customerID uuid.UUID) (*models.HistoryResponse, error)
+	Grant(ctx context.Context, customerID uuid.UUID, req models.GrantRequest, actor string) (*models.Consent, error)
+	Withdraw(ctx context.Context, customerID uuid.UUID, req models.WithdrawRequest, actor string) (*models.Consent, error)
+	ListContactable(ctx context.Context, query models.ContactableQuery) (*models.ContactableResponse, error)
+}
+
+type consentService struct {
+	repo      repository.ConsentRepository
+	customers customerrepository.CustomerRepository
+	outbox    events.Outbox
+	audit     audit.Recorder
+	tx        database.Transactor
+}
+
+// NewConsentService creates a new consent service instance. Every grant and
+// withdrawal is appended to the customer's consent ledger together with an audit
+// entry and a CustomerConsentChanged event.
+func NewConsentService(repo repository.ConsentRepository, customers customerrepository.CustomerRepository, outbox events.Outbox, recorder audit.Recorder, tx database.Transactor) ConsentService {
+	return &consentService{
+		repo:      repo,
+		customers: customers,
+		outbox:    outbox,
+		audit:     recorder,
+		tx:        tx,
+	}
+}
+
+// ListConsents returns the current state of every consent a customer has granted or
+// withdrawn. Consents never recorded are not given.
+func (s *consentService) ListConsents(ctx context.Context, customerID uuid.UUID) (*models.ListResponse, error) {
+	if _, err := s.customers.GetByID(ctx, customerID); err != nil {
+		return nil, err
+	}
+
+	consents, err := s.repo.List(ctx, customerID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.audit.RecordAccess(ctx, audit.ActionConsentView, []uuid.UUID{customerID}); err != nil {
+		return nil, err
+	}
+
+	return &models.ListResponse{
+		CustomerID: customerID,
+		Consents:   consents,
+	}, nil
+}
+
+// GetHistory returns every grant and withdrawal recorded for a customer
+func (s *consentService) GetHistory(ctx context.Context, customerID uuid.UUID) (*models.HistoryResponse, error) {
+	if _, err := s.customers.GetByID(ctx, customerID); err != nil {
+		return nil, err
+	}
+
+	records, err := s.repo.ListRecords(ctx, customerID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.audit.RecordAccess(ctx, audit.ActionConsentView, []uuid.UUID{customerID}); err != nil {
+		return nil, err
+	}
+
+	return &models.HistoryResponse{
+		CustomerID: customerID,
+		Records:    records,
+	}, nil
+}
+
+// Grant records that a customer gave a consent under a policy version. Granting a
+// consent already given records the new policy version. Closed customers cannot
+// grant consent.
+func (s *consentService) Grant(ctx context.Context, customerID uuid.UUID, req models.GrantRequest, actor string) (*models.Consent, error) {
+	if actor == "" {
+		return nil, customermodels.NewValidationError("actor", "actor is required")
+	}
+	if err := customermodels.ValidateStruct(req); err != nil {
+		return nil, err
+	}
+	grantedAt, err := effectiveAt(req.Purpose, req.Channel, req.GrantedAt, "granted_at")
+	if err != nil {
+		return nil, err
+	}
+
+	customer, err := s.customers.GetByID(ctx, customerID)
+	if err != nil {
+		return nil, err
+	}
+	if customer.Status == customermodels.CustomerStatusClosed {
+		return nil, customermodels.NewValidationError("customer_id", "a closed customer cannot grant consent")
+	}
+
+	var consent *models.Consent
+	err = s.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := s.repo.LockCustomer(ctx, customerID); err != nil {
+			return err
+		}
+		current, err := s.repo.Get(ctx, customerID, req.Purpose, req.Channel)
+		if err != nil {
+			return err
+		}
+		if err := checkOrder(current, grantedAt, "granted_at"); err != nil {
+			return err
+		}
+
+		consent = current
+		if consent == nil {
+			consent = &models.Consent{CustomerID: customerID, Purpose: req.Purpose, Channel: req.Channel}
+		}
+		before := *consent
+		consent.Status = models.StatusGranted
+		consent.PolicyVersion = req.PolicyVersion
+		consent.Source = req.Source
+		consent.GrantedAt = &grantedAt
+		consent.WithdrawnAt = nil
+
+		return s.save(ctx, &before, consent, grantedAt, req.Note, actor, audit.ActionConsentGrant)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return consent, nil
+}
+
+// Withdraw records that a customer withdrew a consent they had given
+func (s *consentService) Withdraw(ctx context.Context, customerID uuid.UUID, req models.WithdrawRequest, actor string) (*models.Consent, error) {
+	if actor == "" {
+		return nil, customermodels.NewValidationError("actor", "actor is required")
+	}
+	if err := customermodels.ValidateStruct(req); err != nil {
+		return nil, err
+	}
+	withdrawnAt, err := effectiveAt(req.Purpose, req.Channel, req.WithdrawnAt, "withdrawn_at")
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := s.customers.GetByID(ctx, customerID); err != nil {
+		return nil, err
+	}
+
+	var consent *models.Consent
+	err = s.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := s.repo.LockCustomer(ctx, customerID); err != nil {
+			return err
+		}
+		consent, err = s.repo.Get(ctx, customerID, req.Purpose, req.Channel)
+		if err != nil {
+			return err
+		}
+		if consent == nil || consent.Status != models.StatusGranted {
+			return models.ErrConsentNotGranted
+		}
+		if err := checkOrder(consent, withdrawnAt, "withdrawn_at"); err != nil {
+			return err
+		}
+
+		before := *consent
+		consent.Status = models.StatusWithdrawn
+		consent.Source = req.Source
+		consent.WithdrawnAt = &withdrawnAt
+
+		return s.save(ctx, &before, consent, withdrawnAt, req.Note, actor, audit.ActionConsentWithdraw)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return consent, nil
+}
+
+// ListContactable returns a page of the customers who may currently be contacted for
+// marketing on a channel: those whose latest marketing consent on it is a grant and
+// who are neither closed nor deleted
+func (s *consentService) ListContactable(ctx context.Context, query models.ContactableQuery) (*models.ContactableResponse, error) {
+	if err := customermodels.ValidateStruct(query); err != nil {
+		return nil, err
+	}
+	if query.Limit <= 0 {
+		query.Limit = models.DefaultContactableLimit
+	}
+	if query.Limit > models.MaxContactableLimit {
+		query.Limit = models.MaxContactableLimit
+	}
+
+	consents, err := s.repo.ListContactable(ctx, query.Channel, query.After, query.Limit)
+	if err != nil {
+		return nil, err
+	}
+
+	response := &models.ContactableResponse{
+		Channel:   query.Channel,
+		Customers: make([]models.Contactable, len(consents)),
+	}
+	ids := make([]uuid.UUID, len(consents))
+	for i, consent := range consents {
+		response.Customers[i] = models.Contactable{
+			CustomerID:    consent.CustomerID,
+			PolicyVersion: consent.PolicyVersion,
+			GrantedAt:     *consent.GrantedAt,
+		}
+		ids[i] = consent.CustomerID
+	}
+	if len(consents) == query.Limit {
+		response.NextAfter = &ids[len(ids)-1]
+	}
+
+	if err := s.audit.RecordAccess(ctx, audit.ActionConsentContactable, ids); err != nil {
+		return nil, err
+	}
+	return response, nil
+}
+
+// save stores the new state of a consent with its ledger entry, audit entry and event
+func (s *consentService) save(ctx context.Context, before, consent *models.Consent, at time.Time, note, actor, action string) error {
+	record := &models.Record{
+		CustomerID:    consent.CustomerID,
+		Purpose:       consent.Purpose,
+		Channel:       consent.Channel,
+		Status:        consent.Status,
+		PolicyVersion: consent.PolicyVersion,
+		Source:        consent.Source,
+		EffectiveAt:   at,
+		Note:          note,
+		RecordedBy:    actor,
+	}
+	if err := s.repo.Save(ctx, consent, record); err != nil {
+		return err
+	}
+
+	field := "consents." + string(consent.Purpose)
+	if consent.Channel != "" {
+		field += "." + string(consent.Channel)
+	}
+	var beforeStatus any
+	if before.Status != "" {
+		beforeStatus = before.Status
+	}
+	changes := []audit.Change{
+		{Field: field, Before: beforeStatus, After: consent.Status},
+		{Field: field + ".policy_version", Before: before.PolicyVersion, After: consent.PolicyVersion},
+	}
+	if err := s.audit.Record(ctx, action, consent.CustomerID, changes); err != nil {
+		return err
+	}
+
+	event, err := events.NewEvent(events.CustomerConsentChanged, consent.CustomerID, models.CustomerConsentChangedPayload{
+		Purpose:       consent.Purpose,
+		Channel:       consent.Channel,
+		Status:        consent.Status,
+		PolicyVersion: consent.PolicyVersion,
+		EffectiveAt:   at,
+		Actor:         actor,
+	})
+	if err != nil {
+		return err
+	}
+	return s.outbox.Append(ctx, event)
+}
+
+// effectiveAt checks that the channel suits the purpose and returns when a grant or
+// withdrawal takes effect: now, or the given time if it is not in the future
+func effectiveAt(purpose models.Purpose, channel models.Channel, at *time.Time, field string) (time.Time, error) {
+	verr := &customermodels.ValidationError{}
+	if purpose == models.PurposeMarketing && channel == "" {
+		verr.Add("channel", "channel is required for marketing consent")
+	}
+	if purpose != models.PurposeMarketing && channel != "" {
+		verr.Add("channel", "only applies to marketing consent")
+	}
+
+	now := time.Now().UTC().Truncate(time.Microsecond)
+	effective := now
+	if at != nil {
+		effective = at.UTC().Truncate(time.Microsecond)
+		if effective.After(now) {
+			verr.Add(field, "cannot be in the future")
+		}
+	}
+	if verr.HasErrors() {
+		return time.Time{}, verr
+	}
+	return effective, nil
+}
+
+// checkOrder rejects a grant or withdrawal dated before the latest change to the
+// consent, which would otherwise rewrite its history
+func checkOrder(current *models.Consent, at time.Time, field string) error {
+	if current != nil && at.Before(current.ChangedAt()) {
+		return customermodels.NewValidationError(field, "cannot be earlier than the latest change to this consent")
+	}
+	return nil
+}
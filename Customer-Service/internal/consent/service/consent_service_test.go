@@ -0,0 +1,405 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"customer-service/internal/audit"
+	"customer-service/internal/consent/models"
+	customermodels "customer-service/internal/customer/models"
+	customerrepository "customer-service/internal/customer/repository"
+	"customer-service/internal/events"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// memoryConsents keeps consents and their ledger in memory
+type memoryConsents struct {
+	consents []models.Consent
+	records  []models.Record
+	// limit is the page size ListContactable was last asked for
+	limit int
+}
+
+func (r *memoryConsents) Get(ctx context.Context, customerID uuid.UUID, purpose models.Purpose, channel models.Channel) (*models.Consent, error) {
+	for _, consent := range r.consents {
+		if consent.CustomerID == customerID && consent.Purpose == purpose && consent.Channel == channel {
+			return &consent, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *memoryConsents) List(ctx context.Context, customerID uuid.UUID) ([]models.Consent, error) {
+	var consents []models.Consent
+	for _, consent := range r.consents {
+		if consent.CustomerID == customerID {
+			consents = append(consents, consent)
+		}
+	}
+	return consents, nil
+}
+
+func (r *memoryConsents) ListRecords(ctx context.Context, customerID uuid.UUID) ([]models.Record, error) {
+	var records []models.Record
+	for _, record := range r.records {
+		if record.CustomerID == customerID {
+			records = append(records, record)
+		}
+	}
+	return records, nil
+}
+
+func (r *memoryConsents) Save(ctx context.Context, consent *models.Consent, record *models.Record) error {
+	r.records = append(r.records, *record)
+	for i := range r.consents {
+		if r.consents[i].ID == consent.ID {
+			r.consents[i] = *consent
+			return nil
+		}
+	}
+	consent.ID = uuid.New()
+	r.consents = append(r.consents, *consent)
+	return nil
+}
+
+func (r *memoryConsents) ListContactable(ctx context.Context, channel models.Channel, after uuid.UUID, limit int) ([]models.Consent, error) {
+	r.limit = limit
+	var consents []models.Consent
+	for _, consent := range r.consents {
+		if consent.Purpose == models.PurposeMarketing && consent.Channel == channel &&
+			consent.Status == models.StatusGranted && bytes.Compare(consent.CustomerID[:], after[:]) > 0 {
+			consents = append(consents, consent)
+		}
+	}
+	slices.SortFunc(consents, func(a, b models.Consent) int {
+		return bytes.Compare(a.CustomerID[:], b.CustomerID[:])
+	})
+	return consents[:min(limit, len(consents))], nil
+}
+
+func (r *memoryConsents) LockCustomer(ctx context.Context, customerID uuid.UUID) error {
+	return nil
+}
+
+// customerDirectory serves customers by ID. Calls to any other repository method panic.
+type customerDirectory struct {
+	customerrepository.CustomerRepository
+	customers []customermodels.Customer
+}
+
+func (d *customerDirectory) GetByID(ctx context.Context, id uuid.UUID) (*customermodels.Customer, error) {
+	for _, customer := range d.customers {
+		if customer.ID == id {
+			return &customer, nil
+		}
+	}
+	return nil, customermodels.ErrCustomerNotFound
+}
+
+// discard drops audit entries and events, and runs transactions directly
+type discard struct{}
+
+func (discard) Record(ctx context.Context, action string, customerID uuid.UUID, changes []audit.Change) error {
+	return nil
+}
+
+func (discard) RecordAccess(ctx context.Context, action string, customerIDs []uuid.UUID) error {
+	return nil
+}
+
+func (discard) Append(ctx context.Context, event *events.Event) error {
+	return nil
+}
+
+func (discard) WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
+
+func newTestService(customers ...customermodels.Customer) (*consentService, *memoryConsents) {
+	repo := &memoryConsents{}
+	return &consentService{
+		repo:      repo,
+		customers: &customerDirectory{customers: customers},
+		outbox:    discard{},
+		audit:     discard{},
+		tx:        discard{},
+	}, repo
+}
+
+func TestConsentLedger(t *testing.T) {
+	now := time.Now().UTC()
+	hoursAgo := func(hours int) *time.Time {
+		at := now.Add(-time.Duration(hours) * time.Hour).Truncate(time.Microsecond)
+		return &at
+	}
+	// grant and withdraw change the marketing email consent the cases check
+	grant := func(version string, at *time.Time) models.GrantRequest {
+		return models.GrantRequest{
+			Purpose: models.PurposeMarketing, Channel: models.ChannelEmail,
+			PolicyVersion: version, Source: models.SourceWeb, GrantedAt: at,
+		}
+	}
+	withdraw := func(at *time.Time) models.WithdrawRequest {
+		return models.WithdrawRequest{
+			Purpose: models.PurposeMarketing, Channel: models.ChannelEmail,
+			Source: models.SourceWeb, WithdrawnAt: at,
+		}
+	}
+
+	type step struct {
+		grant    *models.GrantRequest
+		withdraw *models.WithdrawRequest
+		wantErr  error
+		field    string
+	}
+	granting := func(req models.GrantRequest) step { return step{grant: &req} }
+	withdrawing := func(req models.WithdrawRequest) step { return step{withdraw: &req} }
+	failing := func(s step, wantErr error, field string) step {
+		s.wantErr, s.field = wantErr, field
+		return s
+	}
+
+	tests := []struct {
+		name   string
+		status customermodels.CustomerStatus
+		steps  []step
+		// wantStatus and wantVersion describe the marketing email consent afterwards;
+		// an empty status means it was never recorded
+		wantStatus    models.Status
+		wantVersion   string
+		wantGrantedAt *time.Time
+		wantRecords   int
+	}{
+		{
+			name:        "first grant",
+			steps:       []step{granting(grant("2026-01", hoursAgo(1)))},
+			wantStatus:  models.StatusGranted,
+			wantVersion: "2026-01", wantGrantedAt: hoursAgo(1),
+			wantRecords: 1,
+		},
+		{
+			name:        "granted again under a new policy version",
+			steps:       []step{granting(grant("2026-01", hoursAgo(3))), granting(grant("2026-07", hoursAgo(1)))},
+			wantStatus:  models.StatusGranted,
+			wantVersion: "2026-07", wantGrantedAt: hoursAgo(1),
+			wantRecords: 2,
+		},
+		{
+			name:        "withdrawn",
+			steps:       []step{granting(grant("2026-01", hoursAgo(3))), withdrawing(withdraw(hoursAgo(1)))},
+			wantStatus:  models.StatusWithdrawn,
+			wantVersion: "2026-01", wantGrantedAt: hoursAgo(3),
+			wantRecords: 2,
+		},
+		{
+			name: "granted again after withdrawal",
+			steps: []step{
+				granting(grant("2026-01", hoursAgo(3))), withdrawing(withdraw(hoursAgo(2))), granting(grant("2026-07", hoursAgo(1))),
+			},
+			wantStatus:  models.StatusGranted,
+			wantVersion: "2026-07", wantGrantedAt: hoursAgo(1),
+			wantRecords: 3,
+		},
+		{
+			name:        "withdrawn without a grant",
+			steps:       []step{failing(withdrawing(withdraw(nil)), models.ErrConsentNotGranted, "")},
+			wantRecords: 0,
+		},
+		{
+			name: "withdrawn twice",
+			steps: []step{
+				granting(grant("2026-01", hoursAgo(3))), withdrawing(withdraw(hoursAgo(2))),
+				failing(withdrawing(withdraw(nil)), models.ErrConsentNotGranted, ""),
+			},
+			wantStatus:  models.StatusWithdrawn,
+			wantVersion: "2026-01", wantGrantedAt: hoursAgo(3),
+			wantRecords: 2,
+		},
+		{
+			name: "withdrawal dated before the grant",
+			steps: []step{
+				granting(grant("2026-01", hoursAgo(1))),
+				failing(withdrawing(withdraw(hoursAgo(2))), nil, "withdrawn_at"),
+			},
+			wantStatus:  models.StatusGranted,
+			wantVersion: "2026-01", wantGrantedAt: hoursAgo(1),
+			wantRecords: 1,
+		},
+		{
+			name: "older policy version dated before the latest grant",
+			steps: []step{
+				granting(grant("2026-07", hoursAgo(1))),
+				failing(granting(grant("2026-01", hoursAgo(2))), nil, "granted_at"),
+			},
+			wantStatus:  models.StatusGranted,
+			wantVersion: "2026-07", wantGrantedAt: hoursAgo(1),
+			wantRecords: 1,
+		},
+		{
+			name:        "grant dated in the future",
+			steps:       []step{failing(granting(grant("2026-01", hoursAgo(-1))), nil, "granted_at")},
+			wantRecords: 0,
+		},
+		{
+			name: "marketing without a channel",
+			steps: []step{failing(granting(models.GrantRequest{
+				Purpose: models.PurposeMarketing, PolicyVersion: "2026-01", Source: models.SourceWeb,
+			}), nil, "channel")},
+			wantRecords: 0,
+		},
+		{
+			name: "profiling on a channel",
+			steps: []step{failing(granting(models.GrantRequest{
+				Purpose: models.PurposeProfiling, Channel: models.ChannelEmail, PolicyVersion: "2026-01", Source: models.SourceWeb,
+			}), nil, "channel")},
+			wantRecords: 0,
+		},
+		{
+			name:        "closed customer",
+			status:      customermodels.CustomerStatusClosed,
+			steps:       []step{failing(granting(grant("2026-01", nil)), nil, "customer_id")},
+			wantRecords: 0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			customer := customermodels.Customer{ID: uuid.New(), Status: customermodels.CustomerStatusActive}
+			if tt.status != "" {
+				customer.Status = tt.status
+			}
+			s, repo := newTestService(customer)
+
+			for i, step := range tt.steps {
+				var err error
+				if step.grant != nil {
+					_, err = s.Grant(context.Background(), customer.ID, *step.grant, "user-1")
+				} else {
+					_, err = s.Withdraw(context.Background(), customer.ID, *step.withdraw, "user-1")
+				}
+				switch {
+				case step.field != "":
+					var verr *customermodels.ValidationError
+					if !errors.As(err, &verr) || len(verr.Fields) != 1 || verr.Fields[0].Field != step.field {
+						t.Errorf("step %d error = %v, want a validation error on %s", i, err, step.field)
+					}
+				case !errors.Is(err, step.wantErr):
+					t.Errorf("step %d error = %v, want %v", i, err, step.wantErr)
+				}
+			}
+
+			consent, _ := repo.Get(context.Background(), customer.ID, models.PurposeMarketing, models.ChannelEmail)
+			switch {
+			case consent == nil && tt.wantStatus != "":
+				t.Errorf("consent not recorded, want it %s", tt.wantStatus)
+			case consent != nil && (consent.Status != tt.wantStatus || consent.PolicyVersion != tt.wantVersion):
+				t.Errorf("consent %s under %s, want %s under %s", consent.Status, consent.PolicyVersion, tt.wantStatus, tt.wantVersion)
+			case consent != nil && !consent.GrantedAt.Equal(*tt.wantGrantedAt):
+				t.Errorf("consent granted at %v, want %v", consent.GrantedAt, tt.wantGrantedAt)
+			case consent != nil && (consent.Status == models.StatusWithdrawn) != (consent.WithdrawnAt != nil):
+				t.Errorf("consent %s withdrawn at %v", consent.Status, consent.WithdrawnAt)
+			}
+
+			if len(repo.records) != tt.wantRecords {
+				t.Fatalf("ledger has %d records, want %d", len(repo.records), tt.wantRecords)
+			}
+			if tt.wantRecords > 0 {
+				latest := repo.records[len(repo.records)-1]
+				if latest.Status != consent.Status || latest.PolicyVersion != consent.PolicyVersion || !latest.EffectiveAt.Equal(consent.ChangedAt()) {
+					t.Errorf("latest record %s under %s at %v, want the consent's current state", latest.Status, latest.PolicyVersion, latest.EffectiveAt)
+				}
+			}
+		})
+	}
+}
+
+func TestListContactable(t *testing.T) {
+	s, repo := newTestService()
+	grantedAt := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	var ids []uuid.UUID
+	consent := func(purpose models.Purpose, channel models.Channel, status models.Status) models.Consent {
+		id := uuid.New()
+		if purpose == models.PurposeMarketing && channel == models.ChannelEmail && status == models.StatusGranted {
+			ids = append(ids, id)
+		}
+		return models.Consent{
+			ID: uuid.New(), CustomerID: id, Purpose: purpose, Channel: channel, Status: status,
+			PolicyVersion: "2026-01", GrantedAt: &grantedAt,
+		}
+	}
+	repo.consents = []models.Consent{
+		consent(models.PurposeMarketing, models.ChannelEmail, models.StatusGranted),
+		consent(models.PurposeMarketing, models.ChannelEmail, models.StatusWithdrawn),
+		consent(models.PurposeMarketing, models.ChannelEmail, models.StatusGranted),
+		consent(models.PurposeMarketing, models.ChannelSMS, models.StatusGranted),
+		consent(models.PurposeMarketing, models.ChannelEmail, models.StatusGranted),
+		consent(models.PurposeProfiling, "", models.StatusGranted),
+	}
+	slices.SortFunc(ids, func(a, b uuid.UUID) int { return bytes.Compare(a[:], b[:]) })
+
+	tests := []struct {
+		name          string
+		query         models.ContactableQuery
+		wantLimit     int
+		wantCustomers []uuid.UUID
+		wantNext      *uuid.UUID
+	}{
+		{
+			name:          "default page size",
+			query:         models.ContactableQuery{Channel: models.ChannelEmail},
+			wantLimit:     models.DefaultContactableLimit,
+			wantCustomers: ids,
+		},
+		{
+			name:      "page size capped",
+			query:     models.ContactableQuery{Channel: models.ChannelEmail, Limit: 5000},
+			wantLimit: models.MaxContactableLimit, wantCustomers: ids,
+		},
+		{
+			name:      "full page",
+			query:     models.ContactableQuery{Channel: models.ChannelEmail, Limit: 2},
+			wantLimit: 2, wantCustomers: ids[:2], wantNext: &ids[1],
+		},
+		{
+			name:      "next page",
+			query:     models.ContactableQuery{Channel: models.ChannelEmail, After: ids[1], Limit: 2},
+			wantLimit: 2, wantCustomers: ids[2:],
+		},
+		{
+			name:      "another channel",
+			query:     models.ContactableQuery{Channel: models.ChannelPost},
+			wantLimit: models.DefaultContactableLimit,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := s.ListContactable(context.Background(), tt.query)
+			if err != nil {
+				t.Fatalf("ListContactable() error = %v", err)
+			}
+			if repo.limit != tt.wantLimit {
+				t.Errorf("page size %d, want %d", repo.limit, tt.wantLimit)
+			}
+			var customers []uuid.UUID
+			for _, customer := range got.Customers {
+				customers = append(customers, customer.CustomerID)
+			}
+			if !slices.Equal(customers, tt.wantCustomers) {
+				t.Errorf("customers %v, want %v", customers, tt.wantCustomers)
+			}
+			if (got.NextAfter == nil) != (tt.wantNext == nil) || (got.NextAfter != nil && *got.NextAfter != *tt.wantNext) {
+				t.Errorf("next after %v, want %v", got.NextAfter, tt.wantNext)
+			}
+		})
+	}
+
+	var verr *customermodels.ValidationError
+	if _, err := s.ListContactable(context.Background(), models.ContactableQuery{}); !errors.As(err, &verr) {
+		t.Errorf("ListContactable() without a channel error = %v, want a validation error", err)
+	}
+}
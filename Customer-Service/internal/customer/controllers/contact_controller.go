@@ -0,0 +1,215 @@
+package controllers
+
+import (
+	"customer-service/internal/customer/models"
+	"customer-service/pkg/apierror"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// ListAddresses handles GET /customers/:id/addresses
+func (ctrl *CustomerController) ListAddresses(c *gin.Context) {
+	id, ok := ctrl.parseID(c)
+	if !ok {
+		return
+	}
+
+	addresses, err := ctrl.service.ListAddresses(c.Request.Context(), id)
+	if err != nil {
+		ctrl.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, addresses)
+}
+
+// AddAddress handles POST /customers/:id/addresses
+func (ctrl *CustomerController) AddAddress(c *gin.Context) {
+	id, ok := ctrl.parseID(c)
+	if !ok {
+		return
+	}
+
+	var req models.AddressRequest
+	if !ctrl.bindJSON(c, &req) {
+		return
+	}
+
+	address, err := ctrl.service.AddAddress(c.Request.Context(), id, req)
+	if err != nil {
+		ctrl.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, address)
+}
+
+// UpdateAddress handles PUT /customers/:id/addresses/:addressId
+func (ctrl *CustomerController) UpdateAddress(c *gin.Context) {
+	id, addressID, ok := ctrl.parseIDs(c, "addressId")
+	if !ok {
+		return
+	}
+
+	var req models.AddressRequest
+	if !ctrl.bindJSON(c, &req) {
+		return
+	}
+
+	address, err := ctrl.service.UpdateAddress(c.Request.Context(), id, addressID, req)
+	if err != nil {
+		ctrl.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, address)
+}
+
+// DeleteAddress handles DELETE /customers/:id/addresses/:addressId
+func (ctrl *CustomerController) DeleteAddress(c *gin.Context) {
+	id, addressID, ok := ctrl.parseIDs(c, "addressId")
+	if !ok {
+		return
+	}
+
+	if err := ctrl.service.DeleteAddress(c.Request.Context(), id, addressID); err != nil {
+		ctrl.handleError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// VerifyAddress handles POST /customers/:id/addresses/:addressId/verification
+func (ctrl *CustomerController) VerifyAddress(c *gin.Context) {
+	id, addressID, ok := ctrl.parseIDs(c, "addressId")
+	if !ok {
+		return
+	}
+
+	var req models.VerificationRequest
+	if !ctrl.bindJSON(c, &req) {
+		return
+	}
+
+	address, err := ctrl.service.VerifyAddress(c.Request.Context(), id, addressID, req)
+	if err != nil {
+		ctrl.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, address)
+}
+
+// ListContactPoints handles GET /customers/:id/contact-points
+func (ctrl *CustomerController) ListContactPoints(c *gin.Context) {
+	id, ok := ctrl.parseID(c)
+	if !ok {
+		return
+	}
+
+	points, err := ctrl.service.ListContactPoints(c.Request.Context(), id)
+	if err != nil {
+		ctrl.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, points)
+}
+
+// AddContactPoint handles POST /customers/:id/contact-points
+func (ctrl *CustomerController) AddContactPoint(c *gin.Context) {
+	id, ok := ctrl.parseID(c)
+	if !ok {
+		return
+	}
+
+	var req models.ContactPointRequest
+	if !ctrl.bindJSON(c, &req) {
+		return
+	}
+
+	point, err := ctrl.service.AddContactPoint(c.Request.Context(), id, req)
+	if err != nil {
+		ctrl.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, point)
+}
+
+// UpdateContactPoint handles PUT /customers/:id/contact-points/:contactId
+func (ctrl *CustomerController) UpdateContactPoint(c *gin.Context) {
+	id, contactID, ok := ctrl.parseIDs(c, "contactId")
+	if !ok {
+		return
+	}
+
+	var req models.ContactPointRequest
+	if !ctrl.bindJSON(c, &req) {
+		return
+	}
+
+	point, err := ctrl.service.UpdateContactPoint(c.Request.Context(), id, contactID, req)
+	if err != nil {
+		ctrl.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, point)
+}
+
+// DeleteContactPoint handles DELETE /customers/:id/contact-points/:contactId
+func (ctrl *CustomerController) DeleteContactPoint(c *gin.Context) {
+	id, contactID, ok := ctrl.parseIDs(c, "contactId")
+	if !ok {
+		return
+	}
+
+	if err := ctrl.service.DeleteContactPoint(c.Request.Context(), id, contactID); err != nil {
+		ctrl.handleError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// VerifyContactPoint handles POST /customers/:id/contact-points/:contactId/verification
+func (ctrl *CustomerController) VerifyContactPoint(c *gin.Context) {
+	id, contactID, ok := ctrl.parseIDs(c, "contactId")
+	if !ok {
+		return
+	}
+
+	var req models.VerificationRequest
+	if !ctrl.bindJSON(c, &req) {
+		return
+	}
+
+	point, err := ctrl.service.VerifyContactPoint(c.Request.Context(), id, contactID, req)
+	if err != nil {
+		ctrl.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, point)
+}
+
+// parseIDs parses the :id path parameter and the ID of a sub-resource, writing an
+// error response on failure
+func (ctrl *CustomerController) parseIDs(c *gin.Context, param string) (uuid.UUID, uuid.UUID, bool) {
+	id, ok := ctrl.parseID(c)
+	if !ok {
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	subID, err := uuid.Parse(c.Param(param))
+	if err != nil {
+		apierror.Abort(c, http.StatusBadRequest, apierror.CodeInvalidRequest, "invalid "+param,
+			apierror.FieldError{Field: param, Message: "must be a valid UUID"})
+		return uuid.Nil, uuid.Nil, false
+	}
+	return id, subID, true
+}
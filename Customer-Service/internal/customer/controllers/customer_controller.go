@@ -0,0 +1,391 @@
+package controllers
+
+import (
+	"context"
+	"customer-service/internal/customer/models"
+	"customer-service/internal/customer/service"
+	"customer-service/pkg/apierror"
+	"customer-service/pkg/middleware"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// statusClientClosedRequest is the non-standard status logged when the client disconnects
+const statusClientClosedRequest = 499
+
+// CustomerController handles HTTP requests for customer operations
+type CustomerController struct {
+	service service.CustomerService
+}
+
+// NewCustomerController creates a new customer controller instance
+func NewCustomerController(customerService service.CustomerService) *CustomerController {
+	return &CustomerController{
+		service: customerService,
+	}
+}
+
+// CreateCustomer handles POST /customers
+func (ctrl *CustomerController) CreateCustomer(c *gin.Context) {
+	var req models.CustomerRequest
+	if !ctrl.bindJSON(c, &req) {
+		return
+	}
+
+	customer, err := ctrl.service.CreateCustomer(c.Request.Context(), req)
+	if err != nil {
+		ctrl.handleError(c, err)
+		return
+	}
+
+	c.Header("Location", "/api/v1/customers/"+customer.ID.String())
+	c.Header("ETag", etag(customer.Version))
+	c.JSON(http.StatusCreated, customer)
+}
+
+// GetCustomer handles GET /customers/:id
+func (ctrl *CustomerController) GetCustomer(c *gin.Context) {
+	id, ok := ctrl.parseID(c)
+	if !ok {
+		return
+	}
+
+	customer, err := ctrl.service.GetCustomer(c.Request.Context(), id)
+	if err != nil {
+		ctrl.handleError(c, err)
+		return
+	}
+
+	c.Header("ETag", etag(customer.Version))
+	if notModified(c, customer.Version) {
+		c.Status(http.StatusNotModified)
+		return
+	}
+	c.JSON(http.StatusOK, customer)
+}
+
+// UpdateCustomer handles PUT /customers/:id
+func (ctrl *CustomerController) UpdateCustomer(c *gin.Context) {
+	id, ok := ctrl.parseID(c)
+	if !ok {
+		return
+	}
+
+	version, ok := requireIfMatch(c)
+	if !ok {
+		return
+	}
+
+	var req models.CustomerRequest
+	if !ctrl.bindJSON(c, &req) {
+		return
+	}
+
+	customer, err := ctrl.service.UpdateCustomer(c.Request.Context(), id, req, version)
+	if err != nil {
+		ctrl.handleError(c, err)
+		return
+	}
+
+	c.Header("ETag", etag(customer.Version))
+	c.JSON(http.StatusOK, customer)
+}
+
+// PatchCustomer handles PATCH /customers/:id
+func (ctrl *CustomerController) PatchCustomer(c *gin.Context) {
+	id, ok := ctrl.parseID(c)
+	if !ok {
+		return
+	}
+
+	var format models.PatchFormat
+	switch c.ContentType() {
+	case "application/merge-patch+json", "application/json":
+		format = models.PatchFormatMerge
+	case "application/json-patch+json":
+		format = models.PatchFormatJSONPatch
+	default:
+		apierror.Abort(c, http.StatusUnsupportedMediaType, apierror.CodeUnsupportedMedia,
+			"Content-Type must be application/merge-patch+json or application/json-patch+json")
+		return
+	}
+
+	version, ok := requireIfMatch(c)
+	if !ok {
+		return
+	}
+
+	patch, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		apierror.Abort(c, http.StatusBadRequest, apierror.CodeInvalidRequest, "failed to read request body")
+		return
+	}
+
+	result, err := ctrl.service.PatchCustomer(c.Request.Context(), id, format, patch, version)
+	if err != nil {
+		ctrl.handleError(c, err)
+		return
+	}
+
+	c.Header("ETag", etag(result.Customer.Version))
+	c.JSON(http.StatusOK, result)
+}
+
+// DeleteCustomer handles DELETE /customers/:id
+func (ctrl *CustomerController) DeleteCustomer(c *gin.Context) {
+	id, ok := ctrl.parseID(c)
+	if !ok {
+		return
+	}
+
+	version, ok := requireIfMatch(c)
+	if !ok {
+		return
+	}
+
+	if err := ctrl.service.DeleteCustomer(c.Request.Context(), id, version); err != nil {
+		ctrl.handleError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// ListCustomers handles GET /customers
+func (ctrl *CustomerController) ListCustomers(c *gin.Context) {
+	var req models.CustomerListRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		apierror.Abort(c, http.StatusBadRequest, apierror.CodeInvalidRequest, "invalid query parameters: "+err.Error())
+		return
+	}
+
+	customers, err := ctrl.service.ListCustomers(c.Request.Context(), req)
+	if err != nil {
+		ctrl.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, customers)
+}
+
+// SearchCustomers handles GET /customers/search
+func (ctrl *CustomerController) SearchCustomers(c *gin.Context) {
+	var req models.CustomerSearchRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		apierror.Abort(c, http.StatusBadRequest, apierror.CodeInvalidRequest, "invalid query parameters: "+err.Error())
+		return
+	}
+
+	customers, err := ctrl.service.SearchCustomers(c.Request.Context(), req)
+	if err != nil {
+		ctrl.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, customers)
+}
+
+// ChangeStatus handles POST /customers/:id/status
+func (ctrl *CustomerController) ChangeStatus(c *gin.Context) {
+	id, ok := ctrl.parseID(c)
+	if !ok {
+		return
+	}
+
+	var req models.StatusChangeRequest
+	if !ctrl.bindJSON(c, &req) {
+		return
+	}
+
+	customer, err := ctrl.service.ChangeStatus(c.Request.Context(), id, req, actorFromContext(c))
+	if err != nil {
+		ctrl.handleError(c, err)
+		return
+	}
+
+	c.Header("ETag", etag(customer.Version))
+	c.JSON(http.StatusOK, customer)
+}
+
+// GetStatusHistory handles GET /customers/:id/status/history
+func (ctrl *CustomerController) GetStatusHistory(c *gin.Context) {
+	id, ok := ctrl.parseID(c)
+	if !ok {
+		return
+	}
+
+	history, err := ctrl.service.GetStatusHistory(c.Request.Context(), id)
+	if err != nil {
+		ctrl.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, history)
+}
+
+// FindDuplicates handles GET /customers/:id/duplicates
+func (ctrl *CustomerController) FindDuplicates(c *gin.Context) {
+	id, ok := ctrl.parseID(c)
+	if !ok {
+		return
+	}
+
+	duplicates, err := ctrl.service.FindDuplicates(c.Request.Context(), id)
+	if err != nil {
+		ctrl.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, duplicates)
+}
+
+// MergeCustomers handles POST /customers/:id/merge, merging the customer named in the
+// body into the one in the path
+func (ctrl *CustomerController) MergeCustomers(c *gin.Context) {
+	id, ok := ctrl.parseID(c)
+	if !ok {
+		return
+	}
+
+	version, ok := requireIfMatch(c)
+	if !ok {
+		return
+	}
+
+	var req models.MergeRequest
+	if !ctrl.bindJSON(c, &req) {
+		return
+	}
+
+	result, err := ctrl.service.MergeCustomers(c.Request.Context(), id, req, version, actorFromContext(c))
+	if err != nil {
+		ctrl.handleError(c, err)
+		return
+	}
+
+	c.Header("ETag", etag(result.Customer.Version))
+	c.JSON(http.StatusOK, result)
+}
+
+// GetBeneficialOwners handles GET /customers/:id/owners
+func (ctrl *CustomerController) GetBeneficialOwners(c *gin.Context) {
+	id, ok := ctrl.parseID(c)
+	if !ok {
+		return
+	}
+
+	owners, err := ctrl.service.GetBeneficialOwners(c.Request.Context(), id)
+	if err != nil {
+		ctrl.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, owners)
+}
+
+// ReplaceBeneficialOwners handles PUT /customers/:id/owners
+func (ctrl *CustomerController) ReplaceBeneficialOwners(c *gin.Context) {
+	id, ok := ctrl.parseID(c)
+	if !ok {
+		return
+	}
+
+	var req models.BeneficialOwnersRequest
+	if !ctrl.bindJSON(c, &req) {
+		return
+	}
+
+	owners, err := ctrl.service.ReplaceBeneficialOwners(c.Request.Context(), id, req)
+	if err != nil {
+		ctrl.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, owners)
+}
+
+// bindJSON decodes and validates the request body, writing an error response on failure
+func (ctrl *CustomerController) bindJSON(c *gin.Context, req any) bool {
+	if err := c.ShouldBindJSON(req); err != nil {
+		apierror.Abort(c, http.StatusBadRequest, apierror.CodeInvalidRequest, "invalid request body: "+err.Error())
+		return false
+	}
+
+	if err := models.ValidateStruct(req); err != nil {
+		ctrl.handleError(c, err)
+		return false
+	}
+
+	return true
+}
+
+// parseID parses the :id path parameter, writing an error response on failure
+func (ctrl *CustomerController) parseID(c *gin.Context) (uuid.UUID, bool) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		apierror.Abort(c, http.StatusBadRequest, apierror.CodeInvalidRequest, "invalid customer ID",
+			apierror.FieldError{Field: "id", Message: "must be a valid UUID"})
+		return uuid.Nil, false
+	}
+	return id, true
+}
+
+// handleError maps service errors to HTTP responses
+func (ctrl *CustomerController) handleError(c *gin.Context, err error) {
+	var validationErr *models.ValidationError
+	var mergedErr *models.MergedError
+	switch {
+	case errors.As(err, &validationErr):
+		fields := make([]apierror.FieldError, len(validationErr.Fields))
+		for i, f := range validationErr.Fields {
+			fields[i] = apierror.FieldError{Field: f.Field, Message: f.Message}
+		}
+		apierror.Abort(c, http.StatusBadRequest, apierror.CodeValidationFailed, "request validation failed", fields...)
+	case errors.As(err, &mergedErr):
+		c.Header("Location", "/api/v1/customers/"+mergedErr.SurvivorID.String())
+		apierror.Abort(c, http.StatusPermanentRedirect, apierror.CodeCustomerMerged, err.Error())
+	case errors.Is(err, models.ErrCustomerNotFound),
+		errors.Is(err, models.ErrAddressNotFound),
+		errors.Is(err, models.ErrContactPointNotFound):
+		apierror.Abort(c, http.StatusNotFound, apierror.CodeNotFound, err.Error())
+	case errors.Is(err, models.ErrCustomerAlreadyExists),
+		errors.Is(err, models.ErrRegistrationExists),
+		errors.Is(err, models.ErrContactPointExists),
+		errors.Is(err, models.ErrPrimaryContact):
+		apierror.Abort(c, http.StatusConflict, apierror.CodeConflict, err.Error())
+	case errors.Is(err, models.ErrNotOrganization):
+		apierror.Abort(c, http.StatusConflict, apierror.CodeInvalidCustomerType, err.Error())
+	case errors.Is(err, models.ErrVersionConflict):
+		apierror.Abort(c, http.StatusPreconditionFailed, apierror.CodePreconditionFailed, err.Error())
+	case errors.Is(err, models.ErrInvalidPatch):
+		apierror.Abort(c, http.StatusBadRequest, apierror.CodeInvalidRequest, err.Error())
+	case errors.Is(err, models.ErrPatchFailed):
+		apierror.Abort(c, http.StatusUnprocessableEntity, apierror.CodePatchFailed, err.Error())
+	case errors.Is(err, models.ErrInvalidStatusTransition):
+		apierror.Abort(c, http.StatusConflict, apierror.CodeInvalidTransition, err.Error())
+	case errors.Is(err, models.ErrKYCNotVerified):
+		apierror.Abort(c, http.StatusConflict, apierror.CodeKYCNotVerified, err.Error())
+	case errors.Is(err, context.DeadlineExceeded):
+		apierror.Abort(c, http.StatusGatewayTimeout, apierror.CodeTimeout, "the request timed out")
+	case errors.Is(err, context.Canceled):
+		// The client went away; nobody will read the response body
+		c.AbortWithStatus(statusClientClosedRequest)
+	default:
+		log.Printf("Unhandled error processing %s %s: %v", c.Request.Method, c.Request.URL.Path, err)
+		apierror.Abort(c, http.StatusInternalServerError, apierror.CodeInternal, "internal server error")
+	}
+}
+
+// actorFromContext returns the subject of the authenticated caller
+func actorFromContext(c *gin.Context) string {
+	claims, ok := middleware.GetClaims(c)
+	if !ok {
+		return ""
+	}
+	return claims.Subject
+}
@@ -0,0 +1,71 @@
+package controllers
+
+import (
+	"customer-service/internal/customer/models"
+	"customer-service/pkg/apierror"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// etag renders a customer version as a strong entity tag
+func etag(version int64) string {
+	return `"` + strconv.FormatInt(version, 10) + `"`
+}
+
+// parseETag extracts the version from an entity tag, accepting weak tags
+func parseETag(tag string) (int64, bool) {
+	tag = strings.TrimPrefix(strings.TrimSpace(tag), "W/")
+	if len(tag) < 2 || tag[0] != '"' || tag[len(tag)-1] != '"' {
+		return 0, false
+	}
+
+	version, err := strconv.ParseInt(tag[1:len(tag)-1], 10, 64)
+	if err != nil || version <= 0 {
+		return 0, false
+	}
+	return version, true
+}
+
+// requireIfMatch reads the version a write is conditioned on from the If-Match header.
+// "*" matches any version. Writes an error response and returns false if the header
+// is missing or malformed.
+func requireIfMatch(c *gin.Context) (int64, bool) {
+	header := strings.TrimSpace(c.GetHeader("If-Match"))
+	if header == "" {
+		apierror.Abort(c, http.StatusPreconditionRequired, apierror.CodePreconditionRequired,
+			"If-Match header is required; send the ETag returned by GET")
+		return 0, false
+	}
+	if header == "*" {
+		return models.AnyVersion, true
+	}
+
+	version, ok := parseETag(header)
+	if !ok {
+		apierror.Abort(c, http.StatusBadRequest, apierror.CodeInvalidRequest, "invalid If-Match header",
+			apierror.FieldError{Field: "If-Match", Message: "must be a single entity tag or *"})
+		return 0, false
+	}
+	return version, true
+}
+
+// notModified reports whether the If-None-Match header matches the current version
+func notModified(c *gin.Context, version int64) bool {
+	header := c.GetHeader("If-None-Match")
+	if header == "" {
+		return false
+	}
+
+	for _, tag := range strings.Split(header, ",") {
+		if strings.TrimSpace(tag) == "*" {
+			return true
+		}
+		if v, ok := parseETag(tag); ok && v == version {
+			return true
+		}
+	}
+	return false
+}
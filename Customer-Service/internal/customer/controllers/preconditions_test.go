@@ -0,0 +1,144 @@
+package controllers
+
+import (
+	"customer-service/internal/customer/models"
+	"customer-service/pkg/apierror"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func init() {
+	gin.SetMode(gin.TestMode)
+}
+
+// requestContext returns a gin context for a request with the given headers
+func requestContext(headers map[string]string) (*gin.Context, *httptest.ResponseRecorder) {
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodPut, "/customers/1", nil)
+	for name, value := range headers {
+		c.Request.Header.Set(name, value)
+	}
+	return c, w
+}
+
+func TestParseETag(t *testing.T) {
+	tests := []struct {
+		tag     string
+		version int64
+		ok      bool
+	}{
+		{tag: `"3"`, version: 3, ok: true},
+		{tag: ` "42" `, version: 42, ok: true},
+		{tag: `W/"7"`, version: 7, ok: true},
+		{tag: etag(9223372036854775807), version: 9223372036854775807, ok: true},
+		{tag: `3`},
+		{tag: `"3`},
+		{tag: `""`},
+		{tag: `"0"`},
+		{tag: `"-1"`},
+		{tag: `"abc"`},
+		{tag: `"1", "2"`},
+		{tag: `w/"3"`},
+		{tag: `"9223372036854775808"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.tag, func(t *testing.T) {
+			version, ok := parseETag(tt.tag)
+			if version != tt.version || ok != tt.ok {
+				t.Errorf("parseETag(%q) = %d, %v, want %d, %v", tt.tag, version, ok, tt.version, tt.ok)
+			}
+		})
+	}
+}
+
+func TestRequireIfMatch(t *testing.T) {
+	tests := []struct {
+		name    string
+		header  string
+		version int64
+		status  int
+		code    string
+	}{
+		{name: "strong tag", header: `"5"`, version: 5},
+		{name: "weak tag", header: `W/"5"`, version: 5},
+		{name: "any version", header: "*", version: models.AnyVersion},
+		{name: "any version with spaces", header: " * ", version: models.AnyVersion},
+		{name: "missing", status: http.StatusPreconditionRequired, code: apierror.CodePreconditionRequired},
+		{name: "blank", header: "  ", status: http.StatusPreconditionRequired, code: apierror.CodePreconditionRequired},
+		{name: "unquoted", header: "5", status: http.StatusBadRequest, code: apierror.CodeInvalidRequest},
+		{name: "list of tags", header: `"4", "5"`, status: http.StatusBadRequest, code: apierror.CodeInvalidRequest},
+		{name: "not a version", header: `"v5"`, status: http.StatusBadRequest, code: apierror.CodeInvalidRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			headers := map[string]string{}
+			if tt.header != "" {
+				headers["If-Match"] = tt.header
+			}
+			c, w := requestContext(headers)
+
+			version, ok := requireIfMatch(c)
+			if tt.code == "" {
+				if !ok || version != tt.version {
+					t.Errorf("requireIfMatch() = %d, %v, want %d, true", version, ok, tt.version)
+				}
+				if c.IsAborted() {
+					t.Error("requireIfMatch() aborted the request")
+				}
+				return
+			}
+
+			if ok {
+				t.Fatalf("requireIfMatch() = %d, true, want false", version)
+			}
+			if w.Code != tt.status {
+				t.Errorf("status = %d, want %d", w.Code, tt.status)
+			}
+			var body apierror.Response
+			if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+				t.Fatalf("decoding the error response: %v", err)
+			}
+			if body.Error.Code != tt.code {
+				t.Errorf("code = %q, want %q", body.Error.Code, tt.code)
+			}
+		})
+	}
+}
+
+func TestNotModified(t *testing.T) {
+	tests := []struct {
+		name   string
+		header string
+		want   bool
+	}{
+		{name: "no header"},
+		{name: "current version", header: `"3"`, want: true},
+		{name: "weak current version", header: `W/"3"`, want: true},
+		{name: "older version", header: `"2"`},
+		{name: "list holding the current version", header: `"1", W/"3"`, want: true},
+		{name: "list of other versions", header: `"1","2"`},
+		{name: "any version", header: "*", want: true},
+		{name: "malformed", header: "3"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			headers := map[string]string{}
+			if tt.header != "" {
+				headers["If-None-Match"] = tt.header
+			}
+			c, _ := requestContext(headers)
+
+			if got := notModified(c, 3); got != tt.want {
+				t.Errorf("notModified() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
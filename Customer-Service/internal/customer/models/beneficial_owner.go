@@ -0,0 +1,55 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// OwnerRole describes how an individual holds an interest in an organization
+type OwnerRole string
+
+const (
+	// OwnerRoleOwner holds a share of the organization
+	OwnerRoleOwner OwnerRole = "owner"
+	// OwnerRoleController controls the organization without holding a share, such as
+	// a director or senior manager
+	OwnerRoleController OwnerRole = "controller"
+)
+
+// BeneficialOwner links an organization to an individual customer who owns or
+// controls it. Controllers always have an ownership percentage of zero.
+type BeneficialOwner struct {
+	ID                  uuid.UUID `json:"-" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	OrganizationID      uuid.UUID `json:"-" gorm:"type:uuid;not null;index"`
+	OwnerID             uuid.UUID `json:"customer_id" gorm:"type:uuid;not null;index"`
+	Role                OwnerRole `json:"role" gorm:"not null;size:20"`
+	OwnershipPercentage float64   `json:"ownership_percentage" gorm:"type:numeric(5,2);not null"`
+	CreatedAt           time.Time `json:"declared_at"`
+}
+
+// TableName returns the table name for BeneficialOwner model
+func (BeneficialOwner) TableName() string {
+	return "beneficial_owners"
+}
+
+// BeneficialOwnerRequest declares a single owner or controller of an organization
+type BeneficialOwnerRequest struct {
+	CustomerID          uuid.UUID `json:"customer_id" validate:"required"`
+	Role                OwnerRole `json:"role" validate:"required,oneof=owner controller"`
+	OwnershipPercentage float64   `json:"ownership_percentage" validate:"gte=0,lte=100"`
+}
+
+// BeneficialOwnersRequest represents the request payload for declaring the owners and
+// controllers of an organization. It replaces any earlier declaration.
+type BeneficialOwnersRequest struct {
+	Owners []BeneficialOwnerRequest `json:"owners" validate:"max=100,dive"`
+}
+
+// BeneficialOwnersResponse lists the declared owners and controllers of an organization
+// with the total ownership they account for
+type BeneficialOwnersResponse struct {
+	OrganizationID    uuid.UUID         `json:"organization_id"`
+	Owners            []BeneficialOwner `json:"owners"`
+	DeclaredOwnership float64           `json:"declared_ownership"`
+}
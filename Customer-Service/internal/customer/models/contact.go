@@ -0,0 +1,150 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// AddressType says what an address is used for
+type AddressType string
+
+const (
+	AddressTypeResidential AddressType = "residential"
+	AddressTypeMailing     AddressType = "mailing"
+	AddressTypeWork        AddressType = "work"
+)
+
+// ContactKind is the medium of a contact point
+type ContactKind string
+
+const (
+	ContactKindEmail ContactKind = "email"
+	ContactKindPhone ContactKind = "phone"
+)
+
+// ContactType says what a contact point is used for
+type ContactType string
+
+const (
+	ContactTypePersonal ContactType = "personal"
+	ContactTypeWork     ContactType = "work"
+	ContactTypeMobile   ContactType = "mobile"
+	ContactTypeHome     ContactType = "home"
+)
+
+// VerificationStatus records whether an address or contact point has been confirmed
+// to belong to the customer
+type VerificationStatus string
+
+const (
+	VerificationUnverified VerificationStatus = "unverified"
+	VerificationVerified   VerificationStatus = "verified"
+	VerificationFailed     VerificationStatus = "failed"
+)
+
+// CustomerAddress is one of the addresses of a customer. It applies from ValidFrom up
+// to, but not including, ValidTo. Each customer has at most one primary address of
+// each type; the primary residential address is the customer's own address.
+type CustomerAddress struct {
+	ID                 uuid.UUID          `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	CustomerID         uuid.UUID          `json:"customer_id" gorm:"type:uuid;not null;index"`
+	Type               AddressType        `json:"type" gorm:"not null;size:20"`
+	IsPrimary          bool               `json:"is_primary" gorm:"not null"`
+	Address            Address            `json:"address" gorm:"embedded;embeddedPrefix:address_"`
+	ValidFrom          time.Time          `json:"valid_from" gorm:"type:date;not null"`
+	ValidTo            *time.Time         `json:"valid_to,omitempty" gorm:"type:date"`
+	VerificationStatus VerificationStatus `json:"verification_status" gorm:"not null;size:20"`
+	VerifiedAt         *time.Time         `json:"verified_at,omitempty"`
+	CreatedAt          time.Time          `json:"created_at"`
+	UpdatedAt          time.Time          `json:"updated_at"`
+
+	// KeyVersion is the key the address is encrypted with
+	KeyVersion *int `json:"-"`
+}
+
+// TableName returns the table name for CustomerAddress model
+func (CustomerAddress) TableName() string {
+	return "customer_addresses"
+}
+
+// ActiveOn reports whether the address applies on the given day
+func (a *CustomerAddress) ActiveOn(day time.Time) bool {
+	return !day.Before(a.ValidFrom) && (a.ValidTo == nil || day.Before(*a.ValidTo))
+}
+
+// ContactPoint is one of the email addresses or phone numbers of a customer, with the
+// same validity period and primary rules as CustomerAddress. The primary email and
+// primary phone are the customer's own email and phone.
+type ContactPoint struct {
+	ID                 uuid.UUID          `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	CustomerID         uuid.UUID          `json:"customer_id" gorm:"type:uuid;not null;index"`
+	Kind               ContactKind        `json:"kind" gorm:"not null;size:10"`
+	Type               ContactType        `json:"type" gorm:"not null;size:20"`
+	Value              string             `json:"value" gorm:"-"`
+	IsPrimary          bool               `json:"is_primary" gorm:"not null"`
+	ValidFrom          time.Time          `json:"valid_from" gorm:"type:date;not null"`
+	ValidTo            *time.Time         `json:"valid_to,omitempty" gorm:"type:date"`
+	VerificationStatus VerificationStatus `json:"verification_status" gorm:"not null;size:20"`
+	VerifiedAt         *time.Time         `json:"verified_at,omitempty"`
+	CreatedAt          time.Time          `json:"created_at"`
+	UpdatedAt          time.Time          `json:"updated_at"`
+
+	// Value is stored in the column of its kind, encrypted and blind indexed exactly
+	// like the customer's email and phone columns
+	Email      string `json:"-" gorm:"type:text;serializer:encrypted"`
+	Phone      string `json:"-" gorm:"type:text;serializer:encrypted"`
+	EmailIndex string `json:"-" gorm:"column:email_bidx;size:64"`
+	PhoneIndex string `json:"-" gorm:"column:phone_bidx;size:64"`
+	// KeyVersion is the key the value is encrypted with
+	KeyVersion *int `json:"-"`
+}
+
+// TableName returns the table name for ContactPoint model
+func (ContactPoint) TableName() string {
+	return "customer_contact_points"
+}
+
+// ActiveOn reports whether the contact point applies on the given day
+func (p *ContactPoint) ActiveOn(day time.Time) bool {
+	return !day.Before(p.ValidFrom) && (p.ValidTo == nil || day.Before(*p.ValidTo))
+}
+
+// AddressRequest represents the request payload for adding or replacing an address.
+// ValidFrom defaults to today.
+type AddressRequest struct {
+	Type      AddressType `json:"type" validate:"required,oneof=residential mailing work"`
+	IsPrimary bool        `json:"is_primary"`
+	Address   Address     `json:"address"`
+	ValidFrom *time.Time  `json:"valid_from"`
+	ValidTo   *time.Time  `json:"valid_to"`
+}
+
+// ContactPointRequest represents the request payload for adding or replacing a
+// contact point. ValidFrom defaults to today.
+type ContactPointRequest struct {
+	Kind      ContactKind `json:"kind" validate:"required,oneof=email phone"`
+	Type      ContactType `json:"type" validate:"required,oneof=personal work mobile home"`
+	Value     string      `json:"value" validate:"required,max=255"`
+	IsPrimary bool        `json:"is_primary"`
+	ValidFrom *time.Time  `json:"valid_from"`
+	ValidTo   *time.Time  `json:"valid_to"`
+}
+
+// VerificationRequest represents the request payload for recording the outcome of
+// verifying an address or contact point
+type VerificationRequest struct {
+	Status VerificationStatus `json:"status" validate:"required,oneof=verified failed unverified"`
+}
+
+// AddressListResponse lists the addresses of a customer
+type AddressListResponse struct {
+	CustomerID uuid.UUID         `json:"customer_id"`
+	Addresses  []CustomerAddress `json:"addresses"`
+}
+
+// ContactPointListResponse lists the email addresses and phone numbers of a customer
+type ContactPointListResponse struct {
+	CustomerID    uuid.UUID      `json:"customer_id"`
+	ContactPoints []ContactPoint `json:"contact_points"`
+}
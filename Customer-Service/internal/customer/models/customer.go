@@ -7,30 +7,72 @@ import (
 	"gorm.io/gorm"
 )
 
-// Customer represents a bank customer
+// Customer represents a bank customer: a natural person, or an organization such as a
+// company. Individuals are named by FirstName and LastName, organizations by LegalName.
 type Customer struct {
 	ID          uuid.UUID      `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
-	FirstName   string         `json:"first_name" gorm:"not null;size:100" validate:"required,min=2,max=100"`
-	LastName    string         `json:"last_name" gorm:"not null;size:100" validate:"required,min=2,max=100"`
-	Email       string         `json:"email" gorm:"uniqueIndex;not null;size:255" validate:"required,email"`
-	Phone       string         `json:"phone" gorm:"size:20" validate:"required,min=10,max=20"`
-	DateOfBirth *time.Time     `json:"date_of_birth" gorm:"type:date"`
+	Type        CustomerType   `json:"type" gorm:"not null;size:20;default:'individual'"`
+	FirstName   string         `json:"first_name" gorm:"not null;size:100" validate:"omitempty,min=2,max=100"`
+	LastName    string         `json:"last_name" gorm:"not null;size:100" validate:"omitempty,min=2,max=100"`
+	Email       string         `json:"email" gorm:"type:text;not null;serializer:encrypted" validate:"required,email"`
+	Phone       string         `json:"phone" gorm:"type:text;serializer:encrypted" validate:"required,min=10,max=20"`
+	DateOfBirth *time.Time     `json:"date_of_birth" gorm:"type:text;serializer:encrypted"`
 	Address     Address        `json:"address" gorm:"embedded;embeddedPrefix:address_"`
-	Status      CustomerStatus `json:"status" gorm:"default:'active'"`
+	Occupation  string         `json:"occupation" gorm:"size:100"`
+	Products    []string       `json:"products" gorm:"type:jsonb;serializer:json;not null"`
+	Status      CustomerStatus `json:"status" gorm:"default:'inactive'"`
+	Version     int64          `json:"version" gorm:"not null;default:1"`
 	CreatedAt   time.Time      `json:"created_at"`
 	UpdatedAt   time.Time      `json:"updated_at"`
 	DeletedAt   gorm.DeletedAt `json:"-" gorm:"index"`
+
+	// Organization details, empty for individuals
+	LegalName          string     `json:"legal_name" gorm:"size:200"`
+	RegistrationNumber string     `json:"registration_number" gorm:"size:100"`
+	IncorporationDate  *time.Time `json:"incorporation_date" gorm:"type:date"`
+	Jurisdiction       string     `json:"jurisdiction" gorm:"size:100"`
+
+	// Blind indexes (keyed hashes) of the encrypted email and phone, for exact-match lookups
+	EmailIndex string `json:"-" gorm:"column:email_bidx;size:64"`
+	PhoneIndex string `json:"-" gorm:"column:phone_bidx;size:64"`
+	// Blind indexes of the encrypted address and date of birth, for filtering. The date
+	// of birth is indexed by year, month and day, so that few cover a range.
+	CountryIndex    string `json:"-" gorm:"column:address_country_bidx;size:64"`
+	CityIndex       string `json:"-" gorm:"column:address_city_bidx;size:64"`
+	StateIndex      string `json:"-" gorm:"column:address_state_bidx;size:64"`
+	BirthYearIndex  string `json:"-" gorm:"column:birth_year_bidx;size:64"`
+	BirthMonthIndex string `json:"-" gorm:"column:birth_month_bidx;size:64"`
+	BirthDateIndex  string `json:"-" gorm:"column:birth_date_bidx;size:64"`
+	// PhoneSuffixIndex is the blind index of the last digits of the phone number,
+	// for search by the end of the number
+	PhoneSuffixIndex string `json:"-" gorm:"column:phone_suffix_bidx;size:64"`
+	// BlindIndexVersion is the set of blind indexes the row carries; rows behind are
+	// indexed again by the re-encryption job
+	BlindIndexVersion int `json:"-" gorm:"not null;default:1"`
+	// KeyVersion is the key the PII columns are encrypted with; nil for legacy plaintext rows
+	KeyVersion *int `json:"-"`
+	// ErasedAt is when the customer's PII was erased on request; the row is kept,
+	// soft deleted, for the records that must be retained
+	ErasedAt *time.Time `json:"-"`
 }
 
-// Address represents customer address information
+// Address represents customer address information. Every field is encrypted at rest.
 type Address struct {
-	Street     string `json:"street" gorm:"size:255"`
-	City       string `json:"city" gorm:"size:100"`
-	State      string `json:"state" gorm:"size:100"`
-	PostalCode string `json:"postal_code" gorm:"size:20"`
-	Country    string `json:"country" gorm:"size:100"`
+	Street     string `json:"street" gorm:"type:text;serializer:encrypted"`
+	City       string `json:"city" gorm:"type:text;serializer:encrypted"`
+	State      string `json:"state" gorm:"type:text;serializer:encrypted"`
+	PostalCode string `json:"postal_code" gorm:"type:text;serializer:encrypted"`
+	Country    string `json:"country" gorm:"type:text;serializer:encrypted"`
 }
 
+// CustomerType distinguishes natural persons from organizations
+type CustomerType string
+
+const (
+	CustomerTypeIndividual   CustomerType = "individual"
+	CustomerTypeOrganization CustomerType = "organization"
+)
+
 // CustomerStatus represents the status of a customer
 type CustomerStatus string
 
@@ -43,59 +85,166 @@ const (
 
 // CustomerRequest represents the request payload for creating/updating a customer
 type CustomerRequest struct {
-	FirstName   string     `json:"first_name" validate:"required,min=2,max=100"`
-	LastName    string     `json:"last_name" validate:"required,min=2,max=100"`
-	Email       string     `json:"email" validate:"required,email"`
-	Phone       string     `json:"phone" validate:"required,min=10,max=20"`
-	DateOfBirth *time.Time `json:"date_of_birth"`
-	Address     Address    `json:"address"`
+	Type        CustomerType `json:"type" validate:"omitempty,oneof=individual organization"`
+	FirstName   string       `json:"first_name" validate:"omitempty,min=2,max=100"`
+	LastName    string       `json:"last_name" validate:"omitempty,min=2,max=100"`
+	Email       string       `json:"email" validate:"required,email"`
+	Phone       string       `json:"phone" validate:"required,min=10,max=20"`
+	DateOfBirth *time.Time   `json:"date_of_birth"`
+	Address     Address      `json:"address"`
+	Occupation  string       `json:"occupation" validate:"max=100"`
+	Products    []string     `json:"products" validate:"max=20,dive,required,max=50"`
+
+	LegalName          string     `json:"legal_name" validate:"omitempty,min=2,max=200"`
+	RegistrationNumber string     `json:"registration_number" validate:"max=100"`
+	IncorporationDate  *time.Time `json:"incorporation_date"`
+	Jurisdiction       string     `json:"jurisdiction" validate:"max=100"`
 }
 
 // CustomerResponse represents the response payload for customer operations
 type CustomerResponse struct {
 	ID          uuid.UUID      `json:"id"`
+	Type        CustomerType   `json:"type"`
 	FirstName   string         `json:"first_name"`
 	LastName    string         `json:"last_name"`
 	Email       string         `json:"email"`
 	Phone       string         `json:"phone"`
 	DateOfBirth *time.Time     `json:"date_of_birth"`
 	Address     Address        `json:"address"`
+	Occupation  string         `json:"occupation"`
+	Products    []string       `json:"products"`
 	Status      CustomerStatus `json:"status"`
+	Version     int64          `json:"version"`
 	CreatedAt   time.Time      `json:"created_at"`
 	UpdatedAt   time.Time      `json:"updated_at"`
+
+	LegalName          string     `json:"legal_name,omitempty"`
+	RegistrationNumber string     `json:"registration_number,omitempty"`
+	IncorporationDate  *time.Time `json:"incorporation_date,omitempty"`
+	Jurisdiction       string     `json:"jurisdiction,omitempty"`
 }
 
-// CustomerListResponse represents the response for listing customers
+// AnyVersion disables the optimistic concurrency check on a write
+const AnyVersion int64 = 0
+
+// CustomerListResponse represents the response for listing customers. Page and
+// TotalPages are only set when paging by offset; Total is left out when the count
+// was not requested. NextCursor is set while more customers follow the page.
+// Matches are only set by a search query, one per customer in the same order.
 type CustomerListResponse struct {
-	Customers  []CustomerResponse `json:"customers"`
-	Total      int64              `json:"total"`
-	Page       int                `json:"page"`
-	PageSize   int                `json:"page_size"`
-	TotalPages int                `json:"total_pages"`
+	Customers      []CustomerResponse `json:"customers"`
+	Total          *int64             `json:"total,omitempty"`
+	TotalEstimated bool               `json:"total_estimated,omitempty"`
+	Page           int                `json:"page,omitempty"`
+	PageSize       int                `json:"page_size"`
+	TotalPages     *int               `json:"total_pages,omitempty"`
+	NextCursor     string             `json:"next_cursor,omitempty"`
+	Matches        []CustomerMatch    `json:"matches,omitempty"`
+}
+
+// CustomerMatch tells how well a customer matched a search query, and where: the
+// matching fields, with the matched text wrapped in <mark> tags
+type CustomerMatch struct {
+	CustomerID uuid.UUID         `json:"customer_id"`
+	Rank       float64           `json:"rank"`
+	Highlights map[string]string `json:"highlights,omitempty"`
+}
+
+// SearchHit is a customer found by a search. Rank is the relevance of the customer
+// to the search query, zero without one.
+type SearchHit struct {
+	Customer
+	Rank       float64           `gorm:"column:search_rank;->"`
+	Highlights map[string]string `gorm:"-"`
+}
+
+// CountMode selects how the total of a customer listing is computed
+type CountMode string
+
+const (
+	CountExact CountMode = "exact"
+	// CountEstimated takes the planner's row estimate instead of counting the matches
+	CountEstimated CountMode = "estimated"
+	CountNone      CountMode = "none"
+)
+
+// PageParams represents the paging parameters of customer listings. A cursor
+// continues the listing after the last customer of the previous page and cannot be
+// combined with page.
+type PageParams struct {
+	Cursor   string    `form:"cursor"`
+	Count    CountMode `form:"count"`
+	Page     int       `form:"page"`
+	PageSize int       `form:"page_size"`
+}
+
+// CustomerListRequest represents listing parameters
+type CustomerListRequest struct {
+	CustomerFilter
+	PageParams
 }
 
 // CustomerSearchRequest represents search parameters
 type CustomerSearchRequest struct {
-	Query    string         `json:"query" form:"query"`
-	Status   CustomerStatus `json:"status" form:"status"`
-	Page     int            `json:"page" form:"page"`
-	PageSize int            `json:"page_size" form:"page_size"`
+	Query string `json:"query" form:"query"`
+	CustomerFilter
+	PageParams
+}
+
+// Cursor is the position of a customer in listing order. Sort is the order it was
+// issued for and Values are the customer's sort fields, as formatted by SortField;
+// Rank is set when customers are ordered by relevance first.
+type Cursor struct {
+	Rank   *float64  `json:"rank,omitempty"`
+	Sort   string    `json:"sort"`
+	Values []string  `json:"values"`
+	ID     uuid.UUID `json:"id"`
+}
+
+// PageRequest selects the customers of a listing page: those after a cursor when
+// After is set, or those from an offset. Customers are ordered by relevance first
+// when Ranked, then by Sort, with the ID breaking ties. Count says how the total is
+// computed.
+type PageRequest struct {
+	After  *Cursor
+	Offset int
+	Limit  int
+	Count  CountMode
+	Sort   []SortOrder
+	Ranked bool
 }
 
 // ToResponse converts Customer model to CustomerResponse
 func (c *Customer) ToResponse() CustomerResponse {
 	return CustomerResponse{
 		ID:          c.ID,
+		Type:        c.Type,
 		FirstName:   c.FirstName,
 		LastName:    c.LastName,
 		Email:       c.Email,
 		Phone:       c.Phone,
 		DateOfBirth: c.DateOfBirth,
 		Address:     c.Address,
+		Occupation:  c.Occupation,
+		Products:    c.Products,
 		Status:      c.Status,
+		Version:     c.Version,
 		CreatedAt:   c.CreatedAt,
 		UpdatedAt:   c.UpdatedAt,
+
+		LegalName:          c.LegalName,
+		RegistrationNumber: c.RegistrationNumber,
+		IncorporationDate:  c.IncorporationDate,
+		Jurisdiction:       c.Jurisdiction,
+	}
+}
+
+// Name returns the full name of an individual or the legal name of an organization
+func (c *Customer) Name() string {
+	if c.Type == CustomerTypeOrganization {
+		return c.LegalName
 	}
+	return c.FirstName + " " + c.LastName
 }
 
 // TableName returns the table name for Customer model
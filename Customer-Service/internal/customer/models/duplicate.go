@@ -0,0 +1,70 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// MergedError is returned when a customer ID belongs to a record that was merged
+// into another customer
+type MergedError struct {
+	SurvivorID uuid.UUID
+}
+
+// Error implements the error interface
+func (e *MergedError) Error() string {
+	return "customer was merged into " + e.SurvivorID.String()
+}
+
+// CustomerMerge is the tombstone of a merged customer. Lookups of MergedID are
+// redirected to SurvivorID, which always names a customer that was not merged itself.
+type CustomerMerge struct {
+	MergedID      uuid.UUID `json:"merged_id" gorm:"type:uuid;primary_key"`
+	SurvivorID    uuid.UUID `json:"survivor_id" gorm:"type:uuid;not null;index"`
+	ChangedFields []string  `json:"changed_fields" gorm:"type:jsonb;serializer:json;not null"`
+	Reason        string    `json:"reason" gorm:"not null;size:500"`
+	MergedBy      string    `json:"merged_by" gorm:"not null;size:255"`
+	CreatedAt     time.Time `json:"merged_at"`
+}
+
+// TableName returns the table name for CustomerMerge model
+func (CustomerMerge) TableName() string {
+	return "customer_merges"
+}
+
+// DuplicateScores holds the similarity, from 0 to 1, of each compared field. A field
+// missing from either customer is left out.
+type DuplicateScores struct {
+	Name        float64  `json:"name"`
+	Phone       *float64 `json:"phone,omitempty"`
+	DateOfBirth *float64 `json:"date_of_birth,omitempty"`
+	Address     *float64 `json:"address,omitempty"`
+}
+
+// DuplicateCandidate is a customer that may be the same person as the one checked
+type DuplicateCandidate struct {
+	Customer CustomerResponse `json:"customer"`
+	Score    float64          `json:"score"`
+	Scores   DuplicateScores  `json:"scores"`
+}
+
+// DuplicateListResponse lists the likely duplicates of a customer, best match first
+type DuplicateListResponse struct {
+	CustomerID uuid.UUID            `json:"customer_id"`
+	Candidates []DuplicateCandidate `json:"candidates"`
+}
+
+// MergeRequest represents the request payload for merging a duplicate into a customer
+type MergeRequest struct {
+	DuplicateID uuid.UUID `json:"duplicate_id" validate:"required"`
+	Reason      string    `json:"reason" validate:"required,max=500"`
+}
+
+// MergeResponse represents the surviving customer after a merge. ChangedFields lists
+// the survivor fields filled in from the duplicate.
+type MergeResponse struct {
+	Customer      CustomerResponse `json:"customer"`
+	MergedID      uuid.UUID        `json:"merged_id"`
+	ChangedFields []string         `json:"changed_fields"`
+}
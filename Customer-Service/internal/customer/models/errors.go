@@ -0,0 +1,59 @@
+package models
+
+import (
+	"errors"
+	"strings"
+)
+
+// Domain errors returned by the repository and service layers. Callers should
+// compare against these with errors.Is rather than matching on messages.
+var (
+	ErrCustomerNotFound        = errors.New("customer not found")
+	ErrCustomerAlreadyExists   = errors.New("customer with this email already exists")
+	ErrInvalidStatusTransition = errors.New("invalid customer status transition")
+	ErrVersionConflict         = errors.New("customer has been modified since it was read")
+	ErrInvalidPatch            = errors.New("invalid patch document")
+	ErrPatchFailed             = errors.New("patch could not be applied")
+	ErrKYCNotVerified          = errors.New("customer identity has not been verified")
+	ErrRegistrationExists      = errors.New("organization with this registration number already exists")
+	ErrNotOrganization         = errors.New("customer is not an organization")
+	ErrAddressNotFound         = errors.New("address not found")
+	ErrContactPointNotFound    = errors.New("contact point not found")
+	ErrContactPointExists      = errors.New("customer already has this contact point")
+	ErrPrimaryContact          = errors.New("a primary address or contact point cannot be removed")
+)
+
+// FieldError describes a validation failure on a single request field
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// ValidationError is returned when a request fails business validation
+type ValidationError struct {
+	Fields []FieldError
+}
+
+// NewValidationError creates a validation error for a single field
+func NewValidationError(field, message string) *ValidationError {
+	return &ValidationError{Fields: []FieldError{{Field: field, Message: message}}}
+}
+
+// Add appends a field error to the validation error
+func (e *ValidationError) Add(field, message string) {
+	e.Fields = append(e.Fields, FieldError{Field: field, Message: message})
+}
+
+// HasErrors reports whether any field errors were recorded
+func (e *ValidationError) HasErrors() bool {
+	return len(e.Fields) > 0
+}
+
+// Error implements the error interface
+func (e *ValidationError) Error() string {
+	messages := make([]string, len(e.Fields))
+	for i, f := range e.Fields {
+		messages[i] = f.Field + ": " + f.Message
+	}
+	return "validation failed: " + strings.Join(messages, "; ")
+}
@@ -0,0 +1,58 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// CustomerCreatedPayload is the payload of a CustomerCreated event
+type CustomerCreatedPayload struct {
+	Customer CustomerResponse `json:"customer"`
+}
+
+// CustomerUpdatedPayload is the payload of a CustomerUpdated event
+type CustomerUpdatedPayload struct {
+	Customer      CustomerResponse `json:"customer"`
+	ChangedFields []string         `json:"changed_fields"`
+}
+
+// CustomerStatusChangedPayload is the payload of a CustomerStatusChanged event
+type CustomerStatusChangedPayload struct {
+	FromStatus CustomerStatus   `json:"from_status"`
+	ToStatus   CustomerStatus   `json:"to_status"`
+	ReasonCode StatusReasonCode `json:"reason_code"`
+	Note       string           `json:"note,omitempty"`
+	Actor      string           `json:"actor"`
+	Version    int64            `json:"version"`
+}
+
+// CustomerDeletedPayload is the payload of a CustomerDeleted event
+type CustomerDeletedPayload struct {
+	DeletedAt time.Time `json:"deleted_at"`
+}
+
+// CustomerMergedPayload is the payload of a CustomerMerged event, emitted for the
+// surviving customer. ChangedFields lists the fields filled in from the merged record.
+type CustomerMergedPayload struct {
+	MergedCustomerID uuid.UUID        `json:"merged_customer_id"`
+	Customer         CustomerResponse `json:"customer"`
+	ChangedFields    []string         `json:"changed_fields"`
+}
+
+// CustomerBeneficialOwnersChangedPayload is the payload of a
+// CustomerBeneficialOwnersChanged event, emitted for the organization with its full
+// declaration
+type CustomerBeneficialOwnersChangedPayload struct {
+	Owners            []BeneficialOwner `json:"owners"`
+	DeclaredOwnership float64           `json:"declared_ownership"`
+}
+
+// CustomerContactsChangedPayload is the payload of a CustomerContactsChanged event.
+// Change is one of added, updated, removed or verified, and either Address or
+// ContactPoint holds the entry as it now stands, or as it was when removed.
+type CustomerContactsChangedPayload struct {
+	Change       string           `json:"change"`
+	Address      *CustomerAddress `json:"address,omitempty"`
+	ContactPoint *ContactPoint    `json:"contact_point,omitempty"`
+}
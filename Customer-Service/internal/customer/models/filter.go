@@ -0,0 +1,71 @@
+package models
+
+import "time"
+
+// CustomerFilter represents the filter and sort parameters of customer listings.
+// Every filter given must match. Filters taking a list match any of its values,
+// given as repeated parameters or, for status and type, separated by commas. Bounds
+// are inclusive: dates are YYYY-MM-DD, and created and updated bounds also accept
+// RFC 3339 timestamps. Sort lists fields separated by commas, each descending when
+// prefixed with a minus sign.
+type CustomerFilter struct {
+	Status      []string `form:"status"`
+	Type        []string `form:"type"`
+	CreatedFrom string   `form:"created_from"`
+	CreatedTo   string   `form:"created_to"`
+	UpdatedFrom string   `form:"updated_from"`
+	UpdatedTo   string   `form:"updated_to"`
+	Country     []string `form:"country"`
+	City        []string `form:"city"`
+	State       []string `form:"state"`
+	BornFrom    string   `form:"born_from"`
+	BornTo      string   `form:"born_to"`
+	MinAge      *int     `form:"min_age"`
+	MaxAge      *int     `form:"max_age"`
+	Sort        string   `form:"sort"`
+}
+
+// ListFilter is a validated CustomerFilter. Created and updated ranges include From
+// and exclude Before; the birth date range includes both of its days. A nil bound is
+// open.
+type ListFilter struct {
+	Statuses      []CustomerStatus
+	Types         []CustomerType
+	CreatedFrom   *time.Time
+	CreatedBefore *time.Time
+	UpdatedFrom   *time.Time
+	UpdatedBefore *time.Time
+	Countries     []string
+	Cities        []string
+	States        []string
+	BornFrom      *time.Time
+	BornTo        *time.Time
+}
+
+// SortOrder is one field of a listing's order
+type SortOrder struct {
+	Field string
+	Desc  bool
+}
+
+// SortField is a customer field listings can be sorted by. Only plaintext columns
+// qualify, as the database cannot order encrypted ones. Value formats the field of a
+// customer for cursors, with times in RFC 3339.
+type SortField struct {
+	Column string
+	Time   bool
+	Value  func(c *Customer) string
+}
+
+// SortFields are the fields listings can be sorted by, keyed by their JSON name
+var SortFields = map[string]SortField{
+	"created_at": {Column: "created_at", Time: true, Value: func(c *Customer) string { return c.CreatedAt.UTC().Format(time.RFC3339Nano) }},
+	"updated_at": {Column: "updated_at", Time: true, Value: func(c *Customer) string { return c.UpdatedAt.UTC().Format(time.RFC3339Nano) }},
+	"first_name": {Column: "first_name", Value: func(c *Customer) string { return c.FirstName }},
+	"last_name":  {Column: "last_name", Value: func(c *Customer) string { return c.LastName }},
+	"status":     {Column: "status", Value: func(c *Customer) string { return string(c.Status) }},
+	"type":       {Column: "type", Value: func(c *Customer) string { return string(c.Type) }},
+}
+
+// DefaultSort orders listings newest first
+var DefaultSort = []SortOrder{{Field: "created_at", Desc: true}}
@@ -0,0 +1,17 @@
+package models
+
+// PatchFormat identifies how a PATCH request body should be interpreted
+type PatchFormat string
+
+const (
+	// PatchFormatMerge is an RFC 7396 JSON Merge Patch (application/merge-patch+json)
+	PatchFormatMerge PatchFormat = "merge"
+	// PatchFormatJSONPatch is an RFC 6902 JSON Patch (application/json-patch+json)
+	PatchFormatJSONPatch PatchFormat = "json-patch"
+)
+
+// CustomerPatchResponse represents the result of a partial customer update
+type CustomerPatchResponse struct {
+	Customer      CustomerResponse `json:"customer"`
+	ChangedFields []string         `json:"changed_fields"`
+}
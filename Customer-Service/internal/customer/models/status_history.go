@@ -0,0 +1,54 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// StatusReasonCode explains why a customer's status was changed
+type StatusReasonCode string
+
+const (
+	StatusReasonCustomerRequest StatusReasonCode = "customer_request"
+	StatusReasonDormancy        StatusReasonCode = "dormancy"
+	StatusReasonReactivation    StatusReasonCode = "reactivation"
+	StatusReasonFraudSuspected  StatusReasonCode = "fraud_suspected"
+	StatusReasonComplianceHold  StatusReasonCode = "compliance_hold"
+	StatusReasonCourtOrder      StatusReasonCode = "court_order"
+	StatusReasonDeceased        StatusReasonCode = "deceased"
+	StatusReasonOther           StatusReasonCode = "other"
+	// StatusReasonMerged is recorded by the service when a customer is merged into
+	// a duplicate record; it cannot be requested through a status change
+	StatusReasonMerged StatusReasonCode = "merged"
+)
+
+// CustomerStatusHistory records a single status transition of a customer
+type CustomerStatusHistory struct {
+	ID         uuid.UUID        `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	CustomerID uuid.UUID        `json:"customer_id" gorm:"type:uuid;not null;index"`
+	FromStatus CustomerStatus   `json:"from_status" gorm:"not null;size:20"`
+	ToStatus   CustomerStatus   `json:"to_status" gorm:"not null;size:20"`
+	ReasonCode StatusReasonCode `json:"reason_code" gorm:"not null;size:50"`
+	Note       string           `json:"note" gorm:"size:500"`
+	Actor      string           `json:"actor" gorm:"not null;size:255"`
+	CreatedAt  time.Time        `json:"created_at" gorm:"index"`
+}
+
+// StatusChangeRequest represents the request payload for changing a customer's status
+type StatusChangeRequest struct {
+	Status     CustomerStatus   `json:"status" validate:"required,oneof=active inactive suspended closed"`
+	ReasonCode StatusReasonCode `json:"reason_code" validate:"required,oneof=customer_request dormancy reactivation fraud_suspected compliance_hold court_order deceased other"`
+	Note       string           `json:"note" validate:"max=500"`
+}
+
+// StatusHistoryResponse represents the status history of a customer
+type StatusHistoryResponse struct {
+	CustomerID uuid.UUID               `json:"customer_id"`
+	History    []CustomerStatusHistory `json:"history"`
+}
+
+// TableName returns the table name for CustomerStatusHistory model
+func (CustomerStatusHistory) TableName() string {
+	return "customer_status_history"
+}
@@ -0,0 +1,79 @@
+package models
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// validate checks the `validate` struct tags of request models, reporting fields by their JSON names
+var validate = newValidator()
+
+func newValidator() *validator.Validate {
+	v := validator.New()
+	v.RegisterTagNameFunc(func(field reflect.StructField) string {
+		name := strings.SplitN(field.Tag.Get("json"), ",", 2)[0]
+		if name == "-" {
+			return ""
+		}
+		return name
+	})
+	return v
+}
+
+// ValidateStruct checks a request model against its validate tags and returns a
+// *ValidationError listing every failing field
+func ValidateStruct(v any) error {
+	err := validate.Struct(v)
+	if err == nil {
+		return nil
+	}
+
+	var validationErrors validator.ValidationErrors
+	if !errors.As(err, &validationErrors) {
+		return err
+	}
+
+	verr := &ValidationError{}
+	for _, fe := range validationErrors {
+		verr.Add(fieldPath(fe), validationMessage(fe))
+	}
+	return verr
+}
+
+// fieldPath strips the top-level struct name from a validator namespace
+func fieldPath(fe validator.FieldError) string {
+	namespace := fe.Namespace()
+	if i := strings.Index(namespace, "."); i >= 0 {
+		return namespace[i+1:]
+	}
+	return namespace
+}
+
+// validationMessage renders a human readable message for a validator failure
+func validationMessage(fe validator.FieldError) string {
+	switch fe.Tag() {
+	case "required":
+		return "is required"
+	case "email":
+		return "must be a valid email address"
+	case "min":
+		return "must be at least " + fe.Param() + " characters"
+	case "max":
+		return "must be at most " + fe.Param() + " characters"
+	case "len":
+		return "must be exactly " + fe.Param() + " characters"
+	case "url":
+		return "must be a valid URL"
+	case "gte":
+		return "must be at least " + fe.Param()
+	case "lte":
+		return "must be at most " + fe.Param()
+	case "oneof":
+		return "must be one of: " + fe.Param()
+	default:
+		return "failed " + fe.Tag() + " validation"
+	}
+}
@@ -0,0 +1,384 @@
+package repository
+
+import (
+	"context"
+	"customer-service/internal/customer/models"
+	"customer-service/internal/encryption"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// addressColumns are the encrypted columns of an address row
+var addressColumns = []string{
+	"address_street", "address_city", "address_state", "address_postal_code", "address_country",
+}
+
+// contactPointError maps a unique violation on a customer's contact points to
+// ErrContactPointExists, and a lost race to become primary to ErrVersionConflict
+func contactPointError(ctx context.Context, action string, err error) error {
+	switch {
+	case !strings.Contains(err.Error(), "duplicate key"):
+		return queryError(ctx, action, err)
+	case strings.Contains(err.Error(), "_primary"):
+		return models.ErrVersionConflict
+	default:
+		return models.ErrContactPointExists
+	}
+}
+
+// protectContactPoint moves the value of a contact point into the column of its
+// kind and sets its blind index and the key version it is about to be encrypted with
+func (r *customerRepository) protectContactPoint(ctx context.Context, point *models.ContactPoint) error {
+	keyVersion, err := r.cipher.CurrentVersion(ctx)
+	if err != nil {
+		return err
+	}
+
+	switch point.Kind {
+	case models.ContactKindEmail:
+		point.Email = point.Value
+		point.EmailIndex, err = r.cipher.BlindIndex(ctx, "email", encryption.NormalizeEmail(point.Value))
+	case models.ContactKindPhone:
+		point.Phone = point.Value
+		point.PhoneIndex, err = r.cipher.BlindIndex(ctx, "phone", encryption.NormalizePhone(point.Value))
+	}
+	if err != nil {
+		return err
+	}
+	point.KeyVersion = &keyVersion
+	return nil
+}
+
+// contactPointColumns returns the value columns used by contact points of a kind;
+// the columns of the other kind are left empty
+func contactPointColumns(kind models.ContactKind) []string {
+	if kind == models.ContactKindPhone {
+		return []string{"phone", "phone_bidx", "key_version"}
+	}
+	return []string{"email", "email_bidx", "key_version"}
+}
+
+// unusedContactPointColumns returns the value columns of the other kind
+func unusedContactPointColumns(kind models.ContactKind) []string {
+	if kind == models.ContactKindPhone {
+		return contactPointColumns(models.ContactKindEmail)[:2]
+	}
+	return contactPointColumns(models.ContactKindPhone)[:2]
+}
+
+// loadValues copies the stored value of each contact point into Value
+func loadValues(points []models.ContactPoint) {
+	for i := range points {
+		if points[i].Kind == models.ContactKindPhone {
+			points[i].Value = points[i].Phone
+		} else {
+			points[i].Value = points[i].Email
+		}
+	}
+}
+
+// ListAddresses returns every address of a customer, including ended and future
+// ones, primary addresses first
+func (r *customerRepository) ListAddresses(ctx context.Context, customerID uuid.UUID) ([]models.CustomerAddress, error) {
+	db, ctx, cancel := r.session(ctx, r.timeouts.Default)
+	defer cancel()
+
+	var addresses []models.CustomerAddress
+	err := db.Where("customer_id = ?", customerID).
+		Order("is_primary DESC, type, valid_from DESC, created_at").
+		Find(&addresses).Error
+	if err != nil {
+		return nil, queryError(ctx, "list addresses", err)
+	}
+	return addresses, nil
+}
+
+// GetAddress retrieves an address of a customer by ID
+func (r *customerRepository) GetAddress(ctx context.Context, customerID, id uuid.UUID) (*models.CustomerAddress, error) {
+	db, ctx, cancel := r.session(ctx, r.timeouts.Default)
+	defer cancel()
+
+	var address models.CustomerAddress
+	if err := db.Where("id = ? AND customer_id = ?", id, customerID).First(&address).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, models.ErrAddressNotFound
+		}
+		return nil, queryError(ctx, "get address", err)
+	}
+	return &address, nil
+}
+
+// CreateAddress stores a new address. A primary address replaces the customer's
+// previous primary address of the same type.
+func (r *customerRepository) CreateAddress(ctx context.Context, address *models.CustomerAddress) error {
+	db, ctx, cancel := r.session(ctx, r.timeouts.Default)
+	defer cancel()
+
+	keyVersion, err := r.cipher.CurrentVersion(ctx)
+	if err != nil {
+		return err
+	}
+	address.KeyVersion = &keyVersion
+	if address.ID == uuid.Nil {
+		address.ID = uuid.New() // encrypted columns are bound to it
+	}
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		if address.IsPrimary {
+			if err := clearPrimaryAddress(tx, address); err != nil {
+				return queryError(ctx, "clear primary address", err)
+			}
+		}
+		if err := tx.Create(address).Error; err != nil {
+			return contactPointError(ctx, "create address", err)
+		}
+		return nil
+	})
+}
+
+// UpdateAddress saves every field of an existing address, with the same primary
+// handling as CreateAddress
+func (r *customerRepository) UpdateAddress(ctx context.Context, address *models.CustomerAddress) error {
+	db, ctx, cancel := r.session(ctx, r.timeouts.Default)
+	defer cancel()
+
+	keyVersion, err := r.cipher.CurrentVersion(ctx)
+	if err != nil {
+		return err
+	}
+	address.KeyVersion = &keyVersion
+	if address.ID == uuid.Nil {
+		address.ID = uuid.New() // encrypted columns are bound to it
+	}
+	address.UpdatedAt = time.Now()
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		if address.IsPrimary {
+			if err := clearPrimaryAddress(tx, address); err != nil {
+				return queryError(ctx, "clear primary address", err)
+			}
+		}
+		result := tx.Model(address).
+			Where("customer_id = ?", address.CustomerID).
+			Select("*").
+			Omit("id", "customer_id", "created_at").
+			Updates(address)
+		if result.Error != nil {
+			return contactPointError(ctx, "update address", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return models.ErrAddressNotFound
+		}
+		return nil
+	})
+}
+
+// clearPrimaryAddress unsets the primary flag of the other addresses of the same type
+func clearPrimaryAddress(tx *gorm.DB, address *models.CustomerAddress) error {
+	return tx.Model(&models.CustomerAddress{}).
+		Where("customer_id = ? AND type = ? AND is_primary AND id <> ?", address.CustomerID, address.Type, address.ID).
+		Updates(map[string]any{"is_primary": false, "updated_at": time.Now()}).Error
+}
+
+// DeleteAddress removes an address of a customer
+func (r *customerRepository) DeleteAddress(ctx context.Context, customerID, id uuid.UUID) error {
+	db, ctx, cancel := r.session(ctx, r.timeouts.Default)
+	defer cancel()
+
+	result := db.Where("id = ? AND customer_id = ?", id, customerID).Delete(&models.CustomerAddress{})
+	if result.Error != nil {
+		return queryError(ctx, "delete address", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return models.ErrAddressNotFound
+	}
+	return nil
+}
+
+// ListContactPoints returns every email address and phone number of a customer,
+// including ended and future ones, primary ones first
+func (r *customerRepository) ListContactPoints(ctx context.Context, customerID uuid.UUID) ([]models.ContactPoint, error) {
+	db, ctx, cancel := r.session(ctx, r.timeouts.Default)
+	defer cancel()
+
+	var points []models.ContactPoint
+	err := db.Where("customer_id = ?", customerID).
+		Order("is_primary DESC, kind, valid_from DESC, created_at").
+		Find(&points).Error
+	if err != nil {
+		return nil, queryError(ctx, "list contact points", err)
+	}
+	loadValues(points)
+	return points, nil
+}
+
+// GetContactPoint retrieves a contact point of a customer by ID
+func (r *customerRepository) GetContactPoint(ctx context.Context, customerID, id uuid.UUID) (*models.ContactPoint, error) {
+	db, ctx, cancel := r.session(ctx, r.timeouts.Default)
+	defer cancel()
+
+	var point models.ContactPoint
+	if err := db.Where("id = ? AND customer_id = ?", id, customerID).First(&point).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, models.ErrContactPointNotFound
+		}
+		return nil, queryError(ctx, "get contact point", err)
+	}
+	points := []models.ContactPoint{point}
+	loadValues(points)
+	return &points[0], nil
+}
+
+// CreateContactPoint stores a new contact point. A primary contact point replaces the
+// customer's previous primary one of the same kind.
+func (r *customerRepository) CreateContactPoint(ctx context.Context, point *models.ContactPoint) error {
+	db, ctx, cancel := r.session(ctx, r.timeouts.Default)
+	defer cancel()
+
+	if point.ID == uuid.Nil {
+		point.ID = uuid.New() // encrypted columns are bound to it
+	}
+	if err := r.protectContactPoint(ctx, point); err != nil {
+		return err
+	}
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		if point.IsPrimary {
+			if err := clearPrimaryContactPoint(tx, point); err != nil {
+				return queryError(ctx, "clear primary contact point", err)
+			}
+		}
+		if err := tx.Omit(unusedContactPointColumns(point.Kind)...).Create(point).Error; err != nil {
+			return contactPointError(ctx, "create contact point", err)
+		}
+		return nil
+	})
+}
+
+// UpdateContactPoint saves every field of an existing contact point, with the same
+// primary handling as CreateContactPoint. The kind of a contact point cannot change.
+func (r *customerRepository) UpdateContactPoint(ctx context.Context, point *models.ContactPoint) error {
+	db, ctx, cancel := r.session(ctx, r.timeouts.Default)
+	defer cancel()
+
+	if err := r.protectContactPoint(ctx, point); err != nil {
+		return err
+	}
+	point.UpdatedAt = time.Now()
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		if point.IsPrimary {
+			if err := clearPrimaryContactPoint(tx, point); err != nil {
+				return queryError(ctx, "clear primary contact point", err)
+			}
+		}
+		selected := append([]string{
+			"type", "is_primary", "valid_from", "valid_to", "verification_status", "verified_at", "updated_at",
+		}, contactPointColumns(point.Kind)...)
+		result := tx.Model(point).
+			Where("customer_id = ? AND kind = ?", point.CustomerID, point.Kind).
+			Select(selected).
+			Updates(point)
+		if result.Error != nil {
+			return contactPointError(ctx, "update contact point", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return models.ErrContactPointNotFound
+		}
+		return nil
+	})
+}
+
+// clearPrimaryContactPoint unsets the primary flag of the other contact points of the
+// same kind
+func clearPrimaryContactPoint(tx *gorm.DB, point *models.ContactPoint) error {
+	return tx.Model(&models.ContactPoint{}).
+		Where("customer_id = ? AND kind = ? AND is_primary AND id <> ?", point.CustomerID, point.Kind, point.ID).
+		Updates(map[string]any{"is_primary": false, "updated_at": time.Now()}).Error
+}
+
+// DeleteContactPoint removes a contact point of a customer
+func (r *customerRepository) DeleteContactPoint(ctx context.Context, customerID, id uuid.UUID) error {
+	db, ctx, cancel := r.session(ctx, r.timeouts.Default)
+	defer cancel()
+
+	result := db.Where("id = ? AND customer_id = ?", id, customerID).Delete(&models.ContactPoint{})
+	if result.Error != nil {
+		return queryError(ctx, "delete contact point", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return models.ErrContactPointNotFound
+	}
+	return nil
+}
+
+// reencryptContacts rewrites the addresses and contact points of a customer that are
+// not encrypted with keyVersion or not bound to their rows. Rows changed since they
+// were read are skipped, as the write that changed them already used the current key.
+func (r *customerRepository) reencryptContacts(ctx context.Context, tx *gorm.DB, customerID uuid.UUID, keyVersion int) error {
+	stale := "customer_id = ? AND (key_version IS NULL OR key_version <> ? OR %s LIKE ?)"
+	unbound := encryption.UnboundPrefix + "%"
+
+	var addresses []models.CustomerAddress
+	if err := tx.Where(fmt.Sprintf(stale, "address_street"), customerID, keyVersion, unbound).Find(&addresses).Error; err != nil {
+		return queryError(ctx, "list addresses to re-encrypt", err)
+	}
+	for i := range addresses {
+		addresses[i].KeyVersion = &keyVersion
+		err := tx.Model(&addresses[i]).
+			Where("updated_at = ?", addresses[i].UpdatedAt).
+			Select(append([]string{"key_version"}, addressColumns...)).
+			UpdateColumns(&addresses[i]).Error
+		if err != nil {
+			return queryError(ctx, "re-encrypt address", err)
+		}
+	}
+
+	var points []models.ContactPoint
+	if err := tx.Where(fmt.Sprintf(stale, "COALESCE(email, phone)"), customerID, keyVersion, unbound).Find(&points).Error; err != nil {
+		return queryError(ctx, "list contact points to re-encrypt", err)
+	}
+	loadValues(points)
+	for i := range points {
+		if err := r.protectContactPoint(ctx, &points[i]); err != nil {
+			return err
+		}
+		err := tx.Model(&points[i]).
+			Where("updated_at = ?", points[i].UpdatedAt).
+			Select(contactPointColumns(points[i].Kind)).
+			UpdateColumns(&points[i]).Error
+		if err != nil {
+			return queryError(ctx, "re-encrypt contact point", err)
+		}
+	}
+	return nil
+}
+
+// LockWithoutContacts locks and returns customers, including deleted but not erased
+// ones, that have neither addresses nor contact points, in ID order starting after
+// the given ID. Customers locked by a concurrent write are skipped. Must run inside a
+// transaction.
+func (r *customerRepository) LockWithoutContacts(ctx context.Context, after uuid.UUID, limit int) ([]models.Customer, error) {
+	db, ctx, cancel := r.session(ctx, r.timeouts.Default)
+	defer cancel()
+
+	var customers []models.Customer
+	err := db.Unscoped().
+		Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+		Where(`NOT EXISTS (SELECT 1 FROM customer_addresses a WHERE a.customer_id = customers.id)
+			AND NOT EXISTS (SELECT 1 FROM customer_contact_points p WHERE p.customer_id = customers.id)
+			AND erased_at IS NULL AND id > ?`, after).
+		Order("id").
+		Limit(limit).
+		Find(&customers).Error
+	if err != nil {
+		return nil, queryError(ctx, "list customers without contacts", err)
+	}
+	return customers, nil
+}
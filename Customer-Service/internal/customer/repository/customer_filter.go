@@ -0,0 +1,230 @@
+package repository
+
+import (
+	"context"
+	"customer-service/internal/customer/models"
+	"customer-service/internal/screening/matching"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// birthUnits are the units the date of birth is indexed by, largest first, with the
+// column each blind index is keyed for and the layout of the indexed value
+var birthUnits = [...]struct {
+	column string
+	layout string
+}{
+	{"birth_year", "2006"},
+	{"birth_month", "2006-01"},
+	{"birth_date", "2006-01-02"},
+}
+
+// earliestBirth bounds birth date ranges left open at the start
+var earliestBirth = time.Date(1800, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+// applyFilter restricts query to the customers matching a listing filter. Encrypted
+// fields are matched through their blind indexes, so address values match whole,
+// ignoring case and accents.
+func (r *customerRepository) applyFilter(ctx context.Context, db, query *gorm.DB, filter models.ListFilter) (*gorm.DB, error) {
+	if len(filter.Statuses) > 0 {
+		query = query.Where("status IN ?", filter.Statuses)
+	}
+	if len(filter.Types) > 0 {
+		query = query.Where("type IN ?", filter.Types)
+	}
+	if filter.CreatedFrom != nil {
+		query = query.Where("created_at >= ?", *filter.CreatedFrom)
+	}
+	if filter.CreatedBefore != nil {
+		query = query.Where("created_at < ?", *filter.CreatedBefore)
+	}
+	if filter.UpdatedFrom != nil {
+		query = query.Where("updated_at >= ?", *filter.UpdatedFrom)
+	}
+	if filter.UpdatedBefore != nil {
+		query = query.Where("updated_at < ?", *filter.UpdatedBefore)
+	}
+
+	address := []struct {
+		column string
+		values []string
+	}{
+		{"address_country", filter.Countries},
+		{"address_city", filter.Cities},
+		{"address_state", filter.States},
+	}
+	for _, field := range address {
+		if len(field.values) == 0 {
+			continue
+		}
+		indexes := make([]string, len(field.values))
+		for i, value := range field.values {
+			index, err := r.cipher.BlindIndex(ctx, field.column, matching.Normalize(value))
+			if err != nil {
+				return nil, err
+			}
+			indexes[i] = index
+		}
+		query = query.Where(field.column+"_bidx IN ?", indexes)
+	}
+
+	if filter.BornFrom != nil || filter.BornTo != nil {
+		born, err := r.bornBetween(ctx, db, filter.BornFrom, filter.BornTo)
+		if err != nil {
+			return nil, err
+		}
+		query = query.Where(born)
+	}
+	return query, nil
+}
+
+// bornBetween returns the condition matching customers born between two days,
+// inclusive. An open start reaches back to earliestBirth and an open end to today.
+func (r *customerRepository) bornBetween(ctx context.Context, db *gorm.DB, from, to *time.Time) (*gorm.DB, error) {
+	start, end := earliestBirth, time.Now().UTC().Truncate(24*time.Hour)
+	if from != nil {
+		start = *from
+	}
+	if to != nil {
+		end = *to
+	}
+	if start.After(end) {
+		return db.Where("FALSE"), nil
+	}
+
+	var conditions *gorm.DB
+	for i, dates := range coverDates(start, end) {
+		if len(dates) == 0 {
+			continue
+		}
+		indexes := make([]string, len(dates))
+		for j, date := range dates {
+			index, err := r.cipher.BlindIndex(ctx, birthUnits[i].column, date.Format(birthUnits[i].layout))
+			if err != nil {
+				return nil, err
+			}
+			indexes[j] = index
+		}
+		condition := birthUnits[i].column + "_bidx IN ?"
+		if conditions == nil {
+			conditions = db.Where(condition, indexes)
+		} else {
+			conditions = conditions.Or(condition, indexes)
+		}
+	}
+	return conditions, nil
+}
+
+// coverDates splits the days from start to end, inclusive, into whole years, whole
+// months and single days, in the order of birthUnits, taking the largest unit that
+// fits at each step. A range of a few decades takes a few dozen values.
+func coverDates(start, end time.Time) [len(birthUnits)][]time.Time {
+	var cover [len(birthUnits)][]time.Time
+	for day := start; !day.After(end); {
+		switch {
+		case day.YearDay() == 1 && !day.AddDate(1, 0, -1).After(end):
+			cover[0] = append(cover[0], day)
+			day = day.AddDate(1, 0, 0)
+		case day.Day() == 1 && !day.AddDate(0, 1, -1).After(end):
+			cover[1] = append(cover[1], day)
+			day = day.AddDate(0, 1, 0)
+		default:
+			cover[2] = append(cover[2], day)
+			day = day.AddDate(0, 0, 1)
+		}
+	}
+	return cover
+}
+
+// sortKey is an expression customers are ordered by, with the value it takes at a
+// cursor position
+type sortKey struct {
+	expr  any
+	value any
+	desc  bool
+}
+
+// sortKeys returns the keys of a page's order: the rank when ranked, the sort fields,
+// then the ID in the direction of the last field, so the order is total. Values are
+// only set when the page continues from a cursor.
+func sortKeys(page models.PageRequest, rank *clause.Expr) ([]sortKey, error) {
+	var keys []sortKey
+	after := page.After
+	if page.Ranked {
+		key := sortKey{expr: *rank, desc: true}
+		if after != nil {
+			if after.Rank == nil {
+				return nil, fmt.Errorf("cursor has no rank")
+			}
+			key.value = *after.Rank
+		}
+		keys = append(keys, key)
+	}
+
+	desc := false
+	for i, order := range page.Sort {
+		field, ok := models.SortFields[order.Field]
+		if !ok {
+			return nil, fmt.Errorf("unknown sort field %q", order.Field)
+		}
+		key := sortKey{expr: clause.Column{Name: field.Column}, desc: order.Desc}
+		if after != nil {
+			if i >= len(after.Values) {
+				return nil, fmt.Errorf("cursor has %d sort values, want %d", len(after.Values), len(page.Sort))
+			}
+			key.value = after.Values[i]
+			if field.Time {
+				t, err := time.Parse(time.RFC3339Nano, after.Values[i])
+				if err != nil {
+					return nil, fmt.Errorf("cursor value of %s: %w", order.Field, err)
+				}
+				key.value = t
+			}
+		}
+		keys = append(keys, key)
+		desc = order.Desc
+	}
+
+	id := sortKey{expr: clause.Column{Name: "id"}, desc: desc}
+	if after != nil {
+		id.value = after.ID
+	}
+	return append(keys, id), nil
+}
+
+// afterKeys returns the condition selecting the customers that come after the cursor
+// values of keys: those past it on the first key, or equal on it and past it on the
+// next, and so on
+func afterKeys(keys []sortKey) clause.Expression {
+	alternatives := make([]clause.Expression, len(keys))
+	for i, key := range keys {
+		conditions := make([]clause.Expression, 0, i+1)
+		for _, equal := range keys[:i] {
+			conditions = append(conditions, gorm.Expr("? = ?", equal.expr, equal.value))
+		}
+		op := "? > ?"
+		if key.desc {
+			op = "? < ?"
+		}
+		conditions = append(conditions, gorm.Expr(op, key.expr, key.value))
+		alternatives[i] = clause.And(conditions...)
+	}
+	return clause.Or(alternatives...)
+}
+
+// orderByKeys returns the ORDER BY columns of keys. The rank is ordered by the alias
+// it is selected as.
+func orderByKeys(keys []sortKey) []clause.OrderByColumn {
+	columns := make([]clause.OrderByColumn, len(keys))
+	for i, key := range keys {
+		column, ok := key.expr.(clause.Column)
+		if !ok {
+			column = clause.Column{Name: "search_rank", Raw: true}
+		}
+		columns[i] = clause.OrderByColumn{Column: column, Desc: key.desc}
+	}
+	return columns
+}
@@ -0,0 +1,68 @@
+package repository
+
+import (
+	"testing"
+	"time"
+)
+
+// date returns midnight UTC of a day
+func date(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+func TestCoverDates(t *testing.T) {
+	tests := []struct {
+		name       string
+		start, end time.Time
+		counts     [len(birthUnits)]int
+	}{
+		{name: "single day", start: date(1990, time.May, 17), end: date(1990, time.May, 17), counts: [3]int{0, 0, 1}},
+		{name: "empty", start: date(1990, time.May, 18), end: date(1990, time.May, 17)},
+		{name: "whole month", start: date(1990, time.April, 1), end: date(1990, time.April, 30), counts: [3]int{0, 1, 0}},
+		{name: "month short of a day", start: date(1990, time.April, 1), end: date(1990, time.April, 29), counts: [3]int{0, 0, 29}},
+		{name: "February of a leap year", start: date(2000, time.February, 1), end: date(2000, time.February, 29), counts: [3]int{0, 1, 0}},
+		{name: "February short of its leap day", start: date(2000, time.February, 1), end: date(2000, time.February, 28), counts: [3]int{0, 0, 28}},
+		{name: "whole year", start: date(1990, time.January, 1), end: date(1990, time.December, 31), counts: [3]int{1, 0, 0}},
+		{name: "days and months", start: date(1990, time.January, 15), end: date(1990, time.March, 10), counts: [3]int{0, 1, 27}},
+		{name: "decade", start: date(1990, time.January, 1), end: date(1999, time.December, 31), counts: [3]int{10, 0, 0}},
+		{name: "decade shifted by a day", start: date(1990, time.January, 2), end: date(2000, time.January, 1), counts: [3]int{9, 11, 31}},
+		{name: "age range", start: date(1960, time.June, 16), end: date(2008, time.June, 15), counts: [3]int{47, 11, 30}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cover := coverDates(tt.start, tt.end)
+
+			for i := range cover {
+				if len(cover[i]) != tt.counts[i] {
+					t.Errorf("%d %s values, want %d", len(cover[i]), birthUnits[i].column, tt.counts[i])
+				}
+			}
+
+			// Every day of the range is covered exactly once and no other day is
+			covered := map[time.Time]int{}
+			for _, year := range cover[0] {
+				for day := year; day.Year() == year.Year(); day = day.AddDate(0, 0, 1) {
+					covered[day]++
+				}
+			}
+			for _, month := range cover[1] {
+				for day := month; day.Month() == month.Month(); day = day.AddDate(0, 0, 1) {
+					covered[day]++
+				}
+			}
+			for _, day := range cover[2] {
+				covered[day]++
+			}
+			for day := tt.start; !day.After(tt.end); day = day.AddDate(0, 0, 1) {
+				if covered[day] != 1 {
+					t.Errorf("%s is covered %d times", day.Format("2006-01-02"), covered[day])
+				}
+				delete(covered, day)
+			}
+			for day := range covered {
+				t.Errorf("%s is outside the range but covered", day.Format("2006-01-02"))
+			}
+		})
+	}
+}
@@ -1,155 +1,742 @@
 package repository
 
 import (
+	"context"
 	"customer-service/internal/customer/models"
+	"customer-service/internal/customer/search"
+	"customer-service/internal/database"
+	"customer-service/internal/encryption"
+	"customer-service/internal/screening/matching"
+	"database/sql"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"slices"
 	"strings"
+	"time"
 
 	"github.com/google/uuid"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // CustomerRepository defines the interface for customer data access
 type CustomerRepository interface {
-	Create(customer *models.Customer) error
-	GetByID(id uuid.UUID) (*models.Customer, error)
-	GetByEmail(email string) (*models.Customer, error)
-	Update(customer *models.Customer) error
-	Delete(id uuid.UUID) error
-	List(page, pageSize int) ([]models.Customer, int64, error)
-	Search(req models.CustomerSearchRequest) ([]models.Customer, int64, error)
+	Create(ctx context.Context, customer *models.Customer) error
+	GetByID(ctx context.Context, id uuid.UUID) (*models.Customer, error)
+	ListByIDs(ctx context.Context, ids []uuid.UUID) ([]models.Customer, error)
+	GetByEmail(ctx context.Context, email string) (*models.Customer, error)
+	Update(ctx context.Context, customer *models.Customer) error
+	UpdateFields(ctx context.Context, customer *models.Customer, columns []string) error
+	Delete(ctx context.Context, id uuid.UUID, expectedVersion int64) error
+	List(ctx context.Context, filter models.ListFilter, page models.PageRequest) ([]models.Customer, int64, error)
+	Search(ctx context.Context, query string, filter models.ListFilter, page models.PageRequest) ([]models.SearchHit, int64, error)
+	ChangeStatus(ctx context.Context, customer *models.Customer, history *models.CustomerStatusHistory) error
+	ListStatusHistory(ctx context.Context, customerID uuid.UUID) ([]models.CustomerStatusHistory, error)
+	ListAfter(ctx context.Context, after uuid.UUID, limit int) ([]models.Customer, error)
+	ListDuplicateCandidates(ctx context.Context, customer *models.Customer, limit int) ([]models.Customer, error)
+	CreateMerge(ctx context.Context, merge *models.CustomerMerge) error
+	GetMerge(ctx context.Context, mergedID uuid.UUID) (*models.CustomerMerge, error)
+	ListBeneficialOwners(ctx context.Context, organizationID uuid.UUID) ([]models.BeneficialOwner, error)
+	ReplaceBeneficialOwners(ctx context.Context, organizationID uuid.UUID, owners []models.BeneficialOwner) error
+	ListAddresses(ctx context.Context, customerID uuid.UUID) ([]models.CustomerAddress, error)
+	GetAddress(ctx context.Context, customerID, id uuid.UUID) (*models.CustomerAddress, error)
+	CreateAddress(ctx context.Context, address *models.CustomerAddress) error
+	UpdateAddress(ctx context.Context, address *models.CustomerAddress) error
+	DeleteAddress(ctx context.Context, customerID, id uuid.UUID) error
+	ListContactPoints(ctx context.Context, customerID uuid.UUID) ([]models.ContactPoint, error)
+	GetContactPoint(ctx context.Context, customerID, id uuid.UUID) (*models.ContactPoint, error)
+	CreateContactPoint(ctx context.Context, point *models.ContactPoint) error
+	UpdateContactPoint(ctx context.Context, point *models.ContactPoint) error
+	DeleteContactPoint(ctx context.Context, customerID, id uuid.UUID) error
+	LockWithoutContacts(ctx context.Context, after uuid.UUID, limit int) ([]models.Customer, error)
+	ListStaleEncryption(ctx context.Context, keyVersion int, after uuid.UUID, limit int) ([]models.Customer, error)
+	Reencrypt(ctx context.Context, customer *models.Customer) (bool, error)
+}
+
+// encryptedColumns are the customer columns encrypted at rest. They are always
+// written together, so that key_version describes all of them.
+var encryptedColumns = []string{
+	"email", "phone", "date_of_birth",
+	"address_street", "address_city", "address_state", "address_postal_code", "address_country",
+}
+
+// indexColumns are the blind index columns protect sets, written with the encrypted
+// columns
+var indexColumns = []string{
+	"email_bidx", "phone_bidx", "address_country_bidx", "address_city_bidx", "address_state_bidx",
+	"birth_year_bidx", "birth_month_bidx", "birth_date_bidx", "phone_suffix_bidx", "blind_index_version", "key_version",
+}
+
+// blindIndexVersion is the set of blind indexes protect computes. Version 1 had the
+// email and phone only; version 2 added the address and date of birth, and version 3
+// the runs of the phone number's digits, which version 4 replaced by its last digits.
+const blindIndexVersion = 4
+
+// QueryTimeouts bounds how long a single repository call may hold a database connection.
+// A zero value disables the corresponding timeout.
+type QueryTimeouts struct {
+	Default time.Duration
+	Search  time.Duration
 }
 
 type customerRepository struct {
-	db *gorm.DB
+	db       *gorm.DB
+	cipher   *encryption.Cipher
+	timeouts QueryTimeouts
 }
 
-// NewCustomerRepository creates a new customer repository instance
-func NewCustomerRepository(db *gorm.DB) CustomerRepository {
+// NewCustomerRepository creates a new customer repository instance. PII columns are
+// encrypted by the GORM serializer registered with encryption.Register; cipher is
+// used for the blind indexes and must be the same instance.
+func NewCustomerRepository(db *gorm.DB, cipher *encryption.Cipher, timeouts QueryTimeouts) CustomerRepository {
 	return &customerRepository{
-		db: db,
+		db:       db,
+		cipher:   cipher,
+		timeouts: timeouts,
+	}
+}
+
+// session returns a database handle bound to ctx, limited by the given timeout.
+// The handle joins the transaction carried by ctx, if any.
+func (r *customerRepository) session(ctx context.Context, timeout time.Duration) (*gorm.DB, context.Context, context.CancelFunc) {
+	if timeout <= 0 {
+		ctx, cancel := context.WithCancel(ctx)
+		return database.Conn(ctx, r.db), ctx, cancel
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	return database.Conn(ctx, r.db), ctx, cancel
+}
+
+// queryError wraps a failed query, preserving context cancellation or deadline errors
+// so callers can tell a timeout from a database failure
+func queryError(ctx context.Context, action string, err error) error {
+	if ctxErr := ctx.Err(); ctxErr != nil && !errors.Is(err, ctxErr) {
+		return fmt.Errorf("failed to %s: %w: %w", action, ctxErr, err)
+	}
+	return fmt.Errorf("failed to %s: %w", action, err)
+}
+
+// duplicateError maps a unique violation to the domain error of the index it broke,
+// returning nil for any other error
+func duplicateError(err error) error {
+	switch {
+	case !strings.Contains(err.Error(), "duplicate key"):
+		return nil
+	case strings.Contains(err.Error(), "idx_customers_registration"):
+		return models.ErrRegistrationExists
+	default:
+		return models.ErrCustomerAlreadyExists
+	}
+}
+
+// protect sets the blind indexes of a customer and the key version its PII is
+// about to be encrypted with
+func (r *customerRepository) protect(ctx context.Context, customer *models.Customer) error {
+	emailIndex, err := r.cipher.BlindIndex(ctx, "email", encryption.NormalizeEmail(customer.Email))
+	if err != nil {
+		return err
+	}
+	phoneIndex, err := r.cipher.BlindIndex(ctx, "phone", encryption.NormalizePhone(customer.Phone))
+	if err != nil {
+		return err
 	}
+	keyVersion, err := r.cipher.CurrentVersion(ctx)
+	if err != nil {
+		return err
+	}
+
+	address := []struct {
+		column string
+		value  string
+		index  *string
+	}{
+		{"address_country", customer.Address.Country, &customer.CountryIndex},
+		{"address_city", customer.Address.City, &customer.CityIndex},
+		{"address_state", customer.Address.State, &customer.StateIndex},
+	}
+	for _, field := range address {
+		if *field.index, err = r.optionalIndex(ctx, field.column, matching.Normalize(field.value)); err != nil {
+			return err
+		}
+	}
+
+	birth := [...]*string{&customer.BirthYearIndex, &customer.BirthMonthIndex, &customer.BirthDateIndex}
+	for i, unit := range birthUnits {
+		*birth[i] = ""
+		if customer.DateOfBirth == nil {
+			continue
+		}
+		if *birth[i], err = r.cipher.BlindIndex(ctx, unit.column, customer.DateOfBirth.Format(unit.layout)); err != nil {
+			return err
+		}
+	}
+
+	if customer.PhoneSuffixIndex, err = search.PhoneSuffixIndex(ctx, r.cipher, customer.Phone); err != nil {
+		return err
+	}
+
+	customer.EmailIndex = emailIndex
+	customer.PhoneIndex = phoneIndex
+	customer.BlindIndexVersion = blindIndexVersion
+	customer.KeyVersion = &keyVersion
+	return nil
+}
+
+// optionalIndex returns the blind index of a normalized value, or an empty string for
+// an empty value, so that customers without one never match a filter
+func (r *customerRepository) optionalIndex(ctx context.Context, column, normalized string) (string, error) {
+	if normalized == "" {
+		return "", nil
+	}
+	return r.cipher.BlindIndex(ctx, column, normalized)
 }
 
 // Create creates a new customer record
-func (r *customerRepository) Create(customer *models.Customer) error {
-	if err := r.db.Create(customer).Error; err != nil {
-		if strings.Contains(err.Error(), "duplicate key") {
-			return errors.New("customer with this email already exists")
+func (r *customerRepository) Create(ctx context.Context, customer *models.Customer) error {
+	db, ctx, cancel := r.session(ctx, r.timeouts.Default)
+	defer cancel()
+
+	// Encrypted columns are bound to the row's ID, so it is assigned before they are
+	// written rather than by the database
+	if customer.ID == uuid.Nil {
+		customer.ID = uuid.New()
+	}
+	if err := r.protect(ctx, customer); err != nil {
+		return err
+	}
+	if err := db.Create(customer).Error; err != nil {
+		if dupErr := duplicateError(err); dupErr != nil {
+			return dupErr
 		}
-		return fmt.Errorf("failed to create customer: %w", err)
+		return queryError(ctx, "create customer", err)
 	}
 	return nil
 }
 
 // GetByID retrieves a customer by ID
-func (r *customerRepository) GetByID(id uuid.UUID) (*models.Customer, error) {
+func (r *customerRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.Customer, error) {
+	db, ctx, cancel := r.session(ctx, r.timeouts.Default)
+	defer cancel()
+
 	var customer models.Customer
-	if err := r.db.Where("id = ?", id).First(&customer).Error; err != nil {
+	if err := db.Where("id = ?", id).First(&customer).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, errors.New("customer not found")
+			return nil, models.ErrCustomerNotFound
 		}
-		return nil, fmt.Errorf("failed to get customer: %w", err)
+		return nil, queryError(ctx, "get customer", err)
 	}
 	return &customer, nil
 }
 
-// GetByEmail retrieves a customer by email
-func (r *customerRepository) GetByEmail(email string) (*models.Customer, error) {
+// ListByIDs retrieves the customers with the given IDs. IDs of missing or deleted
+// customers are skipped.
+func (r *customerRepository) ListByIDs(ctx context.Context, ids []uuid.UUID) ([]models.Customer, error) {
+	db, ctx, cancel := r.session(ctx, r.timeouts.Default)
+	defer cancel()
+
+	var customers []models.Customer
+	if len(ids) == 0 {
+		return customers, nil
+	}
+	if err := db.Where("id IN ?", ids).Find(&customers).Error; err != nil {
+		return nil, queryError(ctx, "list customers", err)
+	}
+	return customers, nil
+}
+
+// GetByEmail retrieves a customer by email, matching its blind index. Rows not yet
+// encrypted are matched on the plaintext column.
+func (r *customerRepository) GetByEmail(ctx context.Context, email string) (*models.Customer, error) {
+	db, ctx, cancel := r.session(ctx, r.timeouts.Default)
+	defer cancel()
+
+	emailIndex, err := r.cipher.BlindIndex(ctx, "email", encryption.NormalizeEmail(email))
+	if err != nil {
+		return nil, err
+	}
+
 	var customer models.Customer
-	if err := r.db.Where("email = ?", email).First(&customer).Error; err != nil {
+	if err := db.Where("email_bidx = ? OR (key_version IS NULL AND email = ?)", emailIndex, email).First(&customer).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, errors.New("customer not found")
+			return nil, models.ErrCustomerNotFound
 		}
-		return nil, fmt.Errorf("failed to get customer: %w", err)
+		return nil, queryError(ctx, "get customer", err)
 	}
 	return &customer, nil
 }
 
-// Update updates an existing customer record
-func (r *customerRepository) Update(customer *models.Customer) error {
-	if err := r.db.Save(customer).Error; err != nil {
-		if strings.Contains(err.Error(), "duplicate key") {
-			return errors.New("customer with this email already exists")
+// Update updates an existing customer record if its stored version still matches
+// customer.Version, and increments the version on success
+func (r *customerRepository) Update(ctx context.Context, customer *models.Customer) error {
+	db, ctx, cancel := r.session(ctx, r.timeouts.Default)
+	defer cancel()
+
+	if err := r.protect(ctx, customer); err != nil {
+		return err
+	}
+
+	expectedVersion := customer.Version
+	customer.Version++
+
+	result := db.Model(customer).
+		Where("version = ?", expectedVersion).
+		Select("*").
+		Omit("id", "created_at", "deleted_at").
+		Updates(customer)
+	if result.Error != nil {
+		customer.Version = expectedVersion
+		if dupErr := duplicateError(result.Error); dupErr != nil {
+			return dupErr
 		}
-		return fmt.Errorf("failed to update customer: %w", err)
+		return queryError(ctx, "update customer", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		customer.Version = expectedVersion
+		return models.ErrVersionConflict
 	}
 	return nil
 }
 
-// Delete soft deletes a customer record
-func (r *customerRepository) Delete(id uuid.UUID) error {
-	result := r.db.Delete(&models.Customer{}, id)
+// UpdateFields writes only the given columns of a customer, taking their values from
+// customer, with the same version check and increment as Update. The encrypted
+// columns are always rewritten with them, under the current key.
+func (r *customerRepository) UpdateFields(ctx context.Context, customer *models.Customer, columns []string) error {
+	db, ctx, cancel := r.session(ctx, r.timeouts.Default)
+	defer cancel()
+
+	if err := r.protect(ctx, customer); err != nil {
+		return err
+	}
+
+	selected := append(append([]string{"version", "updated_at"}, indexColumns...), encryptedColumns...)
+	for _, column := range columns {
+		if !slices.Contains(selected, column) {
+			selected = append(selected, column)
+		}
+	}
+
+	expectedVersion := customer.Version
+	customer.Version++
+
+	result := db.Model(customer).
+		Where("version = ?", expectedVersion).
+		Select(selected).
+		Updates(customer)
 	if result.Error != nil {
-		return fmt.Errorf("failed to delete customer: %w", result.Error)
+		customer.Version = expectedVersion
+		if dupErr := duplicateError(result.Error); dupErr != nil {
+			return dupErr
+		}
+		return queryError(ctx, "update customer", result.Error)
 	}
 	if result.RowsAffected == 0 {
-		return errors.New("customer not found")
+		customer.Version = expectedVersion
+		return models.ErrVersionConflict
 	}
 	return nil
 }
 
-// List retrieves customers with pagination
-func (r *customerRepository) List(page, pageSize int) ([]models.Customer, int64, error) {
+// Delete soft deletes a customer record. Unless expectedVersion is models.AnyVersion,
+// the record is only deleted if its version still matches.
+func (r *customerRepository) Delete(ctx context.Context, id uuid.UUID, expectedVersion int64) error {
+	db, ctx, cancel := r.session(ctx, r.timeouts.Default)
+	defer cancel()
+
+	query := db.Where("id = ?", id)
+	if expectedVersion != models.AnyVersion {
+		query = query.Where("version = ?", expectedVersion)
+	}
+
+	result := query.Delete(&models.Customer{})
+	if result.Error != nil {
+		return queryError(ctx, "delete customer", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		if expectedVersion != models.AnyVersion {
+			return models.ErrVersionConflict
+		}
+		return models.ErrCustomerNotFound
+	}
+	return nil
+}
+
+// List retrieves a page of the customers matching a filter, in the page's order
+func (r *customerRepository) List(ctx context.Context, filter models.ListFilter, page models.PageRequest) ([]models.Customer, int64, error) {
+	db, ctx, cancel := r.session(ctx, r.timeouts.Default)
+	defer cancel()
+
+	query, err := r.applyFilter(ctx, db, db.Model(&models.Customer{}), filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
 	var customers []models.Customer
+	total, err := paginate(ctx, query, page, nil, &customers, "list customers")
+	return customers, total, err
+}
+
+// Search retrieves a page of the customers matching a query and a filter. Unless the
+// page is sorted otherwise, the most relevant customers come first: names are ranked
+// by full-text match of word prefixes and by trigram similarity, and an exact email or
+// phone match ranks above both.
+func (r *customerRepository) Search(ctx context.Context, text string, filter models.ListFilter, page models.PageRequest) ([]models.SearchHit, int64, error) {
+	db, ctx, cancel := r.session(ctx, r.timeouts.Search)
+	defer cancel()
+
+	query, err := r.applyFilter(ctx, db, db.Model(&models.Customer{}), filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var hits []models.SearchHit
+	if strings.TrimSpace(text) == "" {
+		total, err := paginate(ctx, query, page, nil, &hits, "search customers")
+		return hits, total, err
+	}
+
+	q, err := search.Parse(ctx, r.cipher, text)
+	if err != nil {
+		return nil, 0, err
+	}
+	conditions, rank := searchConditions(db, q)
+	total, err := paginate(ctx, query.Where(conditions), page, &rank, &hits, "search customers")
+	if err != nil {
+		return nil, 0, err
+	}
+	for i := range hits {
+		hits[i].Highlights = q.Highlight(&hits[i].Customer)
+	}
+	return hits, total, nil
+}
+
+// searchConditions returns the conditions matching customers to a search query and
+// the expression ranking them. Names are matched through the search_name and
+// search_vector columns, which fold case and accents, and their trigram and full-text
+// indexes. The last digits of a phone number match through phone_suffix_bidx, and
+// legacy plaintext rows also match part of an email or phone number.
+func searchConditions(db *gorm.DB, q *search.Query) (*gorm.DB, clause.Expr) {
+	conditions := db.Where("email_bidx = ?", q.EmailIndex).
+		Or("key_version IS NULL AND email ILIKE ?", "%"+escapeLike(q.Text)+"%")
+	exact := gorm.Expr("email_bidx = ?", q.EmailIndex)
+	if q.Digits != "" {
+		conditions = conditions.
+			Or("phone_bidx IN ?", q.PhoneIndexes).
+			Or("key_version IS NULL AND regexp_replace(phone, '[^0-9]', '', 'g') LIKE ?", "%"+q.Digits+"%")
+		exact = gorm.Expr("(? OR phone_bidx IN ?)", exact, q.PhoneIndexes)
+	}
+	if q.SuffixIndex != "" {
+		conditions = conditions.Or("phone_suffix_bidx = ?", q.SuffixIndex)
+	}
+	rank := gorm.Expr("CASE WHEN ? THEN 1 ELSE 0 END", exact)
+
+	if q.Name != "" {
+		conditions = conditions.
+			Or("search_name LIKE ?", "%"+escapeLike(q.Name)+"%").
+			Or("? <% search_name", q.Name)
+		rank = gorm.Expr("? + word_similarity(?, search_name)", rank, q.Name)
+	}
+	if len(q.Terms) > 0 {
+		tsQuery := gorm.Expr("to_tsquery('simple', ?)", q.TSQuery())
+		conditions = conditions.Or("search_vector @@ ?", tsQuery)
+		rank = gorm.Expr("? + ts_rank(search_vector, ?)", rank, tsQuery)
+	}
+	return conditions, rank
+}
+
+// paginate computes the total of the customers matched by query as the page asks, then
+// retrieves the page into dest. Customers are ordered by rank when the page is ranked,
+// then by its sort fields and their ID, so a cursor continues right after the last
+// customer returned, whatever was added or removed since. A given rank is selected as
+// search_rank even when the page is not ordered by it.
+func paginate(ctx context.Context, query *gorm.DB, page models.PageRequest, rank *clause.Expr, dest any, action string) (int64, error) {
 	var total int64
+	switch page.Count {
+	case models.CountExact:
+		if err := query.Count(&total).Error; err != nil {
+			return 0, queryError(ctx, "count customers", err)
+		}
+	case models.CountEstimated:
+		var err error
+		if total, err = estimateCount(ctx, query); err != nil {
+			return 0, queryError(ctx, "estimate customers", err)
+		}
+	}
+
+	if page.Ranked && rank == nil {
+		return 0, fmt.Errorf("%s: ranked page without a rank", action)
+	}
+	keys, err := sortKeys(page, rank)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", action, err)
+	}
+	if page.After == nil {
+		query = query.Offset(page.Offset)
+	} else {
+		query = query.Where(afterKeys(keys))
+	}
 
-	// Count total records
-	if err := r.db.Model(&models.Customer{}).Count(&total).Error; err != nil {
-		return nil, 0, fmt.Errorf("failed to count customers: %w", err)
+	if rank != nil {
+		query = query.Select("customers.*, ? AS search_rank", *rank)
 	}
+	for _, column := range orderByKeys(keys) {
+		query = query.Order(column)
+	}
+	if err := query.Limit(page.Limit).Find(dest).Error; err != nil {
+		return 0, queryError(ctx, action, err)
+	}
+	return total, nil
+}
 
-	// Calculate offset
-	offset := (page - 1) * pageSize
+// estimateCount returns the planner's estimate of how many customers query matches,
+// which unlike a count does not read them
+func estimateCount(ctx context.Context, query *gorm.DB) (int64, error) {
+	stmt := query.Session(&gorm.Session{DryRun: true}).Select("id").Find(&[]models.Customer{}).Statement
+	if stmt.Error != nil {
+		return 0, stmt.Error
+	}
 
-	// Retrieve customers with pagination
-	if err := r.db.Limit(pageSize).Offset(offset).Order("created_at DESC").Find(&customers).Error; err != nil {
-		return nil, 0, fmt.Errorf("failed to list customers: %w", err)
+	var plan []byte
+	if err := stmt.ConnPool.QueryRowContext(ctx, "EXPLAIN (FORMAT JSON) "+stmt.SQL.String(), stmt.Vars...).Scan(&plan); err != nil {
+		return 0, err
+	}
+	var explained []struct {
+		Plan struct {
+			Rows float64 `json:"Plan Rows"`
+		} `json:"Plan"`
 	}
+	if err := json.Unmarshal(plan, &explained); err != nil || len(explained) == 0 {
+		return 0, fmt.Errorf("unexpected query plan: %s", plan)
+	}
+	return int64(explained[0].Plan.Rows), nil
+}
+
+// ChangeStatus updates a customer's status and records the transition in a single transaction
+func (r *customerRepository) ChangeStatus(ctx context.Context, customer *models.Customer, history *models.CustomerStatusHistory) error {
+	db, ctx, cancel := r.session(ctx, r.timeouts.Default)
+	defer cancel()
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		// Only apply the change if the status has not moved since it was read
+		result := tx.Model(&models.Customer{}).
+			Where("id = ? AND status = ?", customer.ID, history.FromStatus).
+			Updates(map[string]any{
+				"status":  history.ToStatus,
+				"version": gorm.Expr("version + 1"),
+			})
+		if result.Error != nil {
+			return queryError(ctx, "update customer status", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return models.ErrInvalidStatusTransition
+		}
+
+		if err := tx.Create(history).Error; err != nil {
+			return queryError(ctx, "record status history", err)
+		}
 
-	return customers, total, nil
+		customer.Status = history.ToStatus
+		customer.Version++
+		return nil
+	})
 }
 
-// Search searches customers based on criteria
-func (r *customerRepository) Search(req models.CustomerSearchRequest) ([]models.Customer, int64, error) {
+// ListStatusHistory retrieves the status transitions of a customer, most recent first
+func (r *customerRepository) ListStatusHistory(ctx context.Context, customerID uuid.UUID) ([]models.CustomerStatusHistory, error) {
+	db, ctx, cancel := r.session(ctx, r.timeouts.Default)
+	defer cancel()
+
+	var history []models.CustomerStatusHistory
+	if err := db.Where("customer_id = ?", customerID).Order("created_at DESC").Find(&history).Error; err != nil {
+		return nil, queryError(ctx, "list status history", err)
+	}
+	return history, nil
+}
+
+// ListAfter returns customers in ID order starting after the given ID, for jobs that
+// walk the whole customer base in batches
+func (r *customerRepository) ListAfter(ctx context.Context, after uuid.UUID, limit int) ([]models.Customer, error) {
+	db, ctx, cancel := r.session(ctx, r.timeouts.Default)
+	defer cancel()
+
 	var customers []models.Customer
-	var total int64
+	if err := db.Where("id > ?", after).Order("id").Limit(limit).Find(&customers).Error; err != nil {
+		return nil, queryError(ctx, "list customers", err)
+	}
+	return customers, nil
+}
 
-	query := r.db.Model(&models.Customer{})
+// ListDuplicateCandidates returns other customers of the same type sharing the phone
+// number of a customer or the first letters of their first, last or legal name.
+// Encrypted fields can only be compared in memory, so this narrows the customers
+// worth scoring.
+func (r *customerRepository) ListDuplicateCandidates(ctx context.Context, customer *models.Customer, limit int) ([]models.Customer, error) {
+	db, ctx, cancel := r.session(ctx, r.timeouts.Search)
+	defer cancel()
 
-	// Apply search filters
-	if req.Query != "" {
-		searchTerm := "%" + req.Query + "%"
-		query = query.Where(
-			"first_name ILIKE ? OR last_name ILIKE ? OR email ILIKE ? OR phone ILIKE ?",
-			searchTerm, searchTerm, searchTerm, searchTerm,
-		)
+	query := db.Where("id <> ? AND type = ?", customer.ID, customer.Type)
+	conditions := db.Where("first_name ILIKE ? OR last_name ILIKE ?", namePrefix(customer.FirstName), namePrefix(customer.LastName))
+	if customer.Type == models.CustomerTypeOrganization {
+		conditions = db.Where("legal_name ILIKE ?", namePrefix(customer.LegalName))
+	}
+	if phone := encryption.NormalizePhone(customer.Phone); phone != "" {
+		phoneIndex, err := r.cipher.BlindIndex(ctx, "phone", phone)
+		if err != nil {
+			return nil, err
+		}
+		conditions = conditions.Or("phone_bidx = ?", phoneIndex)
 	}
 
-	if req.Status != "" {
-		query = query.Where("status = ?", req.Status)
+	var customers []models.Customer
+	if err := query.Where(conditions).Order("created_at DESC").Limit(limit).Find(&customers).Error; err != nil {
+		return nil, queryError(ctx, "list duplicate candidates", err)
 	}
+	return customers, nil
+}
 
-	// Count total matching records
-	if err := query.Count(&total).Error; err != nil {
-		return nil, 0, fmt.Errorf("failed to count customers: %w", err)
+// namePrefix returns an ILIKE pattern matching names starting like name
+func namePrefix(name string) string {
+	runes := []rune(strings.TrimSpace(name))
+	if len(runes) > 3 {
+		runes = runes[:3]
 	}
+	return escapeLike(string(runes)) + "%"
+}
+
+// escapeLike escapes the LIKE wildcards in a value
+func escapeLike(value string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(value)
+}
+
+// CreateMerge stores the tombstone of a merged customer and points the tombstones of
+// customers previously merged into it at the new survivor, so redirects never chain
+func (r *customerRepository) CreateMerge(ctx context.Context, merge *models.CustomerMerge) error {
+	db, ctx, cancel := r.session(ctx, r.timeouts.Default)
+	defer cancel()
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&models.CustomerMerge{}).
+			Where("survivor_id = ?", merge.MergedID).
+			Update("survivor_id", merge.SurvivorID).Error
+		if err != nil {
+			return queryError(ctx, "redirect merged customers", err)
+		}
+		if err := tx.Create(merge).Error; err != nil {
+			return queryError(ctx, "record customer merge", err)
+		}
+		return nil
+	})
+}
 
-	// Apply pagination
-	if req.Page <= 0 {
-		req.Page = 1
+// GetMerge retrieves the tombstone of a merged customer
+func (r *customerRepository) GetMerge(ctx context.Context, mergedID uuid.UUID) (*models.CustomerMerge, error) {
+	db, ctx, cancel := r.session(ctx, r.timeouts.Default)
+	defer cancel()
+
+	var merge models.CustomerMerge
+	if err := db.Where("merged_id = ?", mergedID).First(&merge).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, models.ErrCustomerNotFound
+		}
+		return nil, queryError(ctx, "get customer merge", err)
 	}
-	if req.PageSize <= 0 {
-		req.PageSize = 10
+	return &merge, nil
+}
+
+// ListBeneficialOwners returns the declared owners and controllers of an organization,
+// largest ownership first
+func (r *customerRepository) ListBeneficialOwners(ctx context.Context, organizationID uuid.UUID) ([]models.BeneficialOwner, error) {
+	db, ctx, cancel := r.session(ctx, r.timeouts.Default)
+	defer cancel()
+
+	var owners []models.BeneficialOwner
+	err := db.Where("organization_id = ?", organizationID).
+		Order("ownership_percentage DESC, created_at").
+		Find(&owners).Error
+	if err != nil {
+		return nil, queryError(ctx, "list beneficial owners", err)
 	}
+	return owners, nil
+}
 
-	offset := (req.Page - 1) * req.PageSize
+// ReplaceBeneficialOwners replaces the declared owners and controllers of an
+// organization in a single transaction
+func (r *customerRepository) ReplaceBeneficialOwners(ctx context.Context, organizationID uuid.UUID, owners []models.BeneficialOwner) error {
+	db, ctx, cancel := r.session(ctx, r.timeouts.Default)
+	defer cancel()
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("organization_id = ?", organizationID).Delete(&models.BeneficialOwner{}).Error; err != nil {
+			return queryError(ctx, "delete beneficial owners", err)
+		}
+		if len(owners) == 0 {
+			return nil
+		}
+		if err := tx.Create(&owners).Error; err != nil {
+			return queryError(ctx, "create beneficial owners", err)
+		}
+		return nil
+	})
+}
 
-	// Retrieve customers
-	if err := query.Limit(req.PageSize).Offset(offset).Order("created_at DESC").Find(&customers).Error; err != nil {
-		return nil, 0, fmt.Errorf("failed to search customers: %w", err)
+// ListStaleEncryption returns customers, including deleted but not erased ones, whose
+// PII or whose addresses and contact points are not encrypted with keyVersion or not
+// bound to their rows, or whose blind indexes predate the current set, in ID order
+// starting after the given ID
+func (r *customerRepository) ListStaleEncryption(ctx context.Context, keyVersion int, after uuid.UUID, limit int) ([]models.Customer, error) {
+	db, ctx, cancel := r.session(ctx, r.timeouts.Default)
+	defer cancel()
+
+	var customers []models.Customer
+	err := db.Unscoped().
+		Where(`(key_version IS NULL OR key_version <> @version OR blind_index_version <> @indexVersion
+			OR email LIKE @unbound
+			OR EXISTS (SELECT 1 FROM customer_addresses a WHERE a.customer_id = customers.id
+				AND (a.key_version IS NULL OR a.key_version <> @version OR a.address_street LIKE @unbound))
+			OR EXISTS (SELECT 1 FROM customer_contact_points p WHERE p.customer_id = customers.id
+				AND (p.key_version IS NULL OR p.key_version <> @version OR COALESCE(p.email, p.phone) LIKE @unbound)))
+			AND erased_at IS NULL AND id > @after`,
+			sql.Named("version", keyVersion), sql.Named("indexVersion", blindIndexVersion),
+			sql.Named("unbound", encryption.UnboundPrefix+"%"), sql.Named("after", after)).
+		Order("id").
+		Limit(limit).
+		Find(&customers).Error
+	if err != nil {
+		return nil, queryError(ctx, "list customers to re-encrypt", err)
 	}
+	return customers, nil
+}
 
-	return customers, total, nil
+// Reencrypt rewrites the PII columns and blind indexes of a customer, and its stale
+// addresses and contact points, under the current key without changing its version
+// or updated_at. It reports false if the customer was modified since it was read, in
+// which case that write already re-encrypted it and its other rows are left for the
+// next pass.
+func (r *customerRepository) Reencrypt(ctx context.Context, customer *models.Customer) (bool, error) {
+	db, ctx, cancel := r.session(ctx, r.timeouts.Default)
+	defer cancel()
+
+	if err := r.protect(ctx, customer); err != nil {
+		return false, err
+	}
+
+	rewritten := false
+	err := db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Unscoped().Model(customer).
+			Where("version = ?", customer.Version).
+			Select(append(slices.Clone(indexColumns), encryptedColumns...)).
+			UpdateColumns(customer)
+		if result.Error != nil {
+			return queryError(ctx, "re-encrypt customer", result.Error)
+		}
+		rewritten = result.RowsAffected == 1
+		if !rewritten {
+			return nil
+		}
+		return r.reencryptContacts(ctx, tx, customer.ID, *customer.KeyVersion)
+	})
+	if err != nil {
+		return false, err
+	}
+	return rewritten, nil
 }
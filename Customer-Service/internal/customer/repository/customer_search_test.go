@@ -0,0 +1,89 @@
+package repository
+
+import (
+	"context"
+	"customer-service/internal/customer/search"
+	"customer-service/internal/encryption"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+func TestSearchConditions(t *testing.T) {
+	file, err := encryption.NewKeyFile()
+	if err != nil {
+		t.Fatalf("NewKeyFile() error = %v", err)
+	}
+	path := filepath.Join(t.TempDir(), "keys.json")
+	if err := encryption.WriteKeyFile(path, file); err != nil {
+		t.Fatalf("WriteKeyFile() error = %v", err)
+	}
+	keys, err := encryption.NewFileKeyProvider(path)
+	if err != nil {
+		t.Fatalf("NewFileKeyProvider() error = %v", err)
+	}
+	cipher := encryption.NewCipher(keys)
+
+	// SQL is only rendered, never run
+	db, err := gorm.Open(postgres.New(postgres.Config{DSN: "host=localhost"}), &gorm.Config{DisableAutomaticPing: true})
+	if err != nil {
+		t.Fatalf("gorm.Open() error = %v", err)
+	}
+
+	tests := []struct {
+		query string
+		// want and wantNot are fragments the conditions must and must not contain
+		want    []string
+		wantNot []string
+	}{
+		{
+			query:   "Jane",
+			want:    []string{"email_bidx = ", "search_name LIKE '%jane%'"},
+			wantNot: []string{"phone_bidx", "phone_suffix_bidx"},
+		},
+		{
+			query:   "+1 (555) 010-9999",
+			want:    []string{"phone_bidx IN (", "LIKE '%15550109999%'"},
+			wantNot: []string{"phone_suffix_bidx"},
+		},
+		{
+			query: "9999",
+			want:  []string{"phone_bidx IN (", "phone_suffix_bidx = "},
+		},
+		{
+			query:   "999",
+			want:    []string{"phone_bidx IN ("},
+			wantNot: []string{"phone_suffix_bidx"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			q, err := search.Parse(context.Background(), cipher, tt.query)
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			sql := db.ToSQL(func(tx *gorm.DB) *gorm.DB {
+				conditions, _ := searchConditions(tx, q)
+				return tx.Table("customers").Where(conditions).Find(&[]map[string]any{})
+			})
+
+			for _, fragment := range tt.want {
+				if !strings.Contains(sql, fragment) {
+					t.Errorf("conditions %s do not contain %q", sql, fragment)
+				}
+			}
+			for _, fragment := range tt.wantNot {
+				if strings.Contains(sql, fragment) {
+					t.Errorf("conditions %s contain %q", sql, fragment)
+				}
+			}
+			if q.SuffixIndex != "" && !strings.Contains(sql, "phone_suffix_bidx = '"+q.SuffixIndex+"'") {
+				t.Errorf("conditions %s do not look up the suffix index %s", sql, q.SuffixIndex)
+			}
+		})
+	}
+}
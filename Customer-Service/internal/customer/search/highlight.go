@@ -0,0 +1,104 @@
+package search
+
+import (
+	"customer-service/internal/customer/models"
+	"customer-service/internal/screening/matching"
+	"html"
+	"slices"
+	"strings"
+)
+
+// Tags wrapped around matched text in highlights
+const (
+	markStart = "<mark>"
+	markEnd   = "</mark>"
+)
+
+// Highlight returns the fields of a customer that match the query, keyed by their
+// JSON name, with the matched text wrapped in <mark> tags and the rest HTML escaped.
+// Name words are marked when they contain a term, or start with it for terms shorter
+// than three letters; email and phone are marked whole. Fields matched only by
+// similarity have nothing to mark and are left out.
+func (q *Query) Highlight(customer *models.Customer) map[string]string {
+	highlights := make(map[string]string)
+	names := map[string]string{
+		"first_name": customer.FirstName,
+		"last_name":  customer.LastName,
+		"legal_name": customer.LegalName,
+	}
+	for field, value := range names {
+		if fragment, ok := q.highlightWords(value); ok {
+			highlights[field] = fragment
+		}
+	}
+
+	legacy := customer.KeyVersion == nil
+	switch {
+	case customer.Email == "":
+	case customer.EmailIndex == q.EmailIndex,
+		legacy && q.Text != "" && strings.Contains(strings.ToLower(customer.Email), strings.ToLower(q.Text)):
+		highlights["email"] = mark(customer.Email)
+	}
+	switch {
+	case customer.Phone == "":
+	case customer.PhoneIndex != "" && slices.Contains(q.PhoneIndexes, customer.PhoneIndex),
+		q.SuffixIndex != "" && customer.PhoneSuffixIndex == q.SuffixIndex,
+		legacy && q.Digits != "" && strings.Contains(digits(customer.Phone), q.Digits):
+		highlights["phone"] = mark(customer.Phone)
+	}
+
+	if len(highlights) == 0 {
+		return nil
+	}
+	return highlights
+}
+
+// highlightWords marks the words of a name matching a term, reporting whether any did
+func (q *Query) highlightWords(value string) (string, bool) {
+	var b strings.Builder
+	matched := false
+	start := -1
+	flush := func(end int) {
+		word := value[start:end]
+		if q.matchesWord(matching.Normalize(word)) {
+			b.WriteString(mark(word))
+			matched = true
+		} else {
+			b.WriteString(html.EscapeString(word))
+		}
+		start = -1
+	}
+
+	for i, r := range value {
+		switch {
+		case isWordRune(r):
+			if start < 0 {
+				start = i
+			}
+			continue
+		case start >= 0:
+			flush(i)
+		}
+		b.WriteString(html.EscapeString(string(r)))
+	}
+	if start >= 0 {
+		flush(len(value))
+	}
+	return b.String(), matched
+}
+
+// matchesWord reports whether a folded word matches one of the query terms
+func (q *Query) matchesWord(word string) bool {
+	for _, term := range q.Terms {
+		if len([]rune(term)) < 3 && strings.HasPrefix(word, term) ||
+			len([]rune(term)) >= 3 && strings.Contains(word, term) {
+			return true
+		}
+	}
+	return false
+}
+
+// mark wraps a value in highlight tags
+func mark(value string) string {
+	return markStart + html.EscapeString(value) + markEnd
+}
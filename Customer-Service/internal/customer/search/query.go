@@ -0,0 +1,106 @@
+package search
+
+import (
+	"context"
+	"customer-service/internal/encryption"
+	"customer-service/internal/screening/matching"
+	"strings"
+	"unicode"
+)
+
+// PhoneSuffixDigits is how many of the last digits of a phone number are blind
+// indexed on their own, so that a query of just those digits finds the number.
+// Besides the whole number, only they are indexed, so the indexes reveal no more
+// than which customers share the end of their number.
+const PhoneSuffixDigits = 4
+
+// Query is a free-text customer search, parsed into what each kind of field can be
+// matched on. Names match on their folded words, ignoring case and accents. The
+// encrypted email only matches whole values, through its blind index, and the
+// encrypted phone matches whole values, or its last PhoneSuffixDigits digits given
+// alone; on legacy plaintext rows, part of an email or phone number matches too.
+type Query struct {
+	// Text is the query as given, trimmed
+	Text string
+	// Name is the query folded as names are indexed, and Terms its words
+	Name  string
+	Terms []string
+	// Digits are the digits of the query, to compare with phone numbers
+	Digits string
+
+	EmailIndex   string
+	PhoneIndexes []string
+	// SuffixIndex is the blind index of Digits as the last digits of a phone
+	// number, set when the query has exactly PhoneSuffixDigits digits
+	SuffixIndex string
+}
+
+// Parse parses a search query. Phone numbers are matched whatever their formatting,
+// so "+1 (555) 010-9999" finds a customer stored as "15550109999" and the reverse.
+func Parse(ctx context.Context, cipher *encryption.Cipher, text string) (*Query, error) {
+	text = strings.TrimSpace(text)
+	q := &Query{
+		Text:   text,
+		Name:   matching.Normalize(text),
+		Terms:  matching.Tokens(text),
+		Digits: digits(text),
+	}
+
+	var err error
+	q.EmailIndex, err = cipher.BlindIndex(ctx, "email", encryption.NormalizeEmail(text))
+	if err != nil {
+		return nil, err
+	}
+	if q.Digits != "" {
+		// Blind indexes keep a leading plus sign, so look up the number with and without one
+		for _, phone := range []string{q.Digits, "+" + q.Digits} {
+			index, err := cipher.BlindIndex(ctx, "phone", phone)
+			if err != nil {
+				return nil, err
+			}
+			q.PhoneIndexes = append(q.PhoneIndexes, index)
+		}
+	}
+	if len(q.Digits) == PhoneSuffixDigits {
+		if q.SuffixIndex, err = cipher.BlindIndex(ctx, "phone_suffix", q.Digits); err != nil {
+			return nil, err
+		}
+	}
+	return q, nil
+}
+
+// PhoneSuffixIndex returns the blind index of the last PhoneSuffixDigits digits of
+// a phone number, or an empty string for a number with fewer digits
+func PhoneSuffixIndex(ctx context.Context, cipher *encryption.Cipher, phone string) (string, error) {
+	number := digits(phone)
+	if len(number) < PhoneSuffixDigits {
+		return "", nil
+	}
+	return cipher.BlindIndex(ctx, "phone_suffix", number[len(number)-PhoneSuffixDigits:])
+}
+
+// TSQuery returns the full-text query matching names with a word starting with each
+// term. Terms are letters and digits only, so they never carry tsquery operators.
+func (q *Query) TSQuery() string {
+	prefixes := make([]string, len(q.Terms))
+	for i, term := range q.Terms {
+		prefixes[i] = term + ":*"
+	}
+	return strings.Join(prefixes, " & ")
+}
+
+// digits returns the digits of a value
+func digits(value string) string {
+	var b strings.Builder
+	for _, r := range value {
+		if r >= '0' && r <= '9' {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// isWordRune reports whether r is part of a word, as names are tokenized
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || unicode.Is(unicode.Mn, r)
+}
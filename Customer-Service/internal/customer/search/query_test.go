@@ -0,0 +1,159 @@
+package search
+
+import (
+	"context"
+	"customer-service/internal/customer/models"
+	"customer-service/internal/encryption"
+	"path/filepath"
+	"testing"
+)
+
+// newCipher returns a cipher with a new key file
+func newCipher(t *testing.T) *encryption.Cipher {
+	t.Helper()
+	file, err := encryption.NewKeyFile()
+	if err != nil {
+		t.Fatalf("NewKeyFile() error = %v", err)
+	}
+	path := filepath.Join(t.TempDir(), "keys.json")
+	if err := encryption.WriteKeyFile(path, file); err != nil {
+		t.Fatalf("WriteKeyFile() error = %v", err)
+	}
+	keys, err := encryption.NewFileKeyProvider(path)
+	if err != nil {
+		t.Fatalf("NewFileKeyProvider() error = %v", err)
+	}
+	return encryption.NewCipher(keys)
+}
+
+// indexed returns an encrypted customer with the phone blind indexes the repository
+// stores for it
+func indexed(t *testing.T, cipher *encryption.Cipher, phone string) *models.Customer {
+	t.Helper()
+	ctx := context.Background()
+	version := 1
+	customer := &models.Customer{Phone: phone, KeyVersion: &version}
+	var err error
+	if customer.PhoneIndex, err = cipher.BlindIndex(ctx, "phone", encryption.NormalizePhone(phone)); err != nil {
+		t.Fatalf("BlindIndex() error = %v", err)
+	}
+	if customer.PhoneSuffixIndex, err = PhoneSuffixIndex(ctx, cipher, phone); err != nil {
+		t.Fatalf("PhoneSuffixIndex() error = %v", err)
+	}
+	return customer
+}
+
+func TestPhoneSearch(t *testing.T) {
+	cipher := newCipher(t)
+
+	tests := []struct {
+		name   string
+		phone  string
+		query  string
+		legacy bool
+		want   bool
+	}{
+		{name: "same number", phone: "+15550109999", query: "+15550109999", want: true},
+		{name: "formatted query", phone: "+15550109999", query: "+1 (555) 010-9999", want: true},
+		{name: "query without plus sign", phone: "+15550109999", query: "1-555-010-9999", want: true},
+		{name: "stored without plus sign", phone: "15550109999", query: "+1 555 010 9999", want: true},
+		{name: "last four digits", phone: "+1-555-010-9999", query: "9999", want: true},
+		{name: "last four digits formatted", phone: "+15550109999", query: "99-99", want: true},
+		{name: "other last four digits", phone: "+15550109999", query: "9998"},
+		{name: "four digits not at the end", phone: "+15550109999", query: "5550"},
+		{name: "last three digits", phone: "+15550109999", query: "999"},
+		{name: "last five digits", phone: "+15550109999", query: "09999"},
+		{name: "number without country code", phone: "+15550109999", query: "5550109999"},
+		{name: "area code", phone: "+15550109999", query: "+1 (555)"},
+		{name: "name", phone: "+15550109999", query: "Jane"},
+		{name: "legacy number containing the digits", phone: "+1 (555) 010-9999", query: "555-010", legacy: true, want: true},
+		{name: "legacy number without the digits", phone: "+1 (555) 010-9999", query: "555-011", legacy: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			customer := indexed(t, cipher, tt.phone)
+			if tt.legacy {
+				customer = &models.Customer{Phone: tt.phone}
+			}
+			q, err := Parse(context.Background(), cipher, tt.query)
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+
+			_, got := q.Highlight(customer)["phone"]
+			if got != tt.want {
+				t.Errorf("phone %q matched by %q = %v, want %v", tt.phone, tt.query, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseSuffixIndex(t *testing.T) {
+	cipher := newCipher(t)
+	ctx := context.Background()
+
+	tests := []struct {
+		query string
+		want  bool
+	}{
+		{query: "9999", want: true},
+		{query: " 99 99 ", want: true},
+		{query: "999"},
+		{query: "09999"},
+		{query: "+15550109999"},
+		{query: "Jane"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			q, err := Parse(ctx, cipher, tt.query)
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			if (q.SuffixIndex != "") != tt.want {
+				t.Errorf("SuffixIndex = %q, want one: %v", q.SuffixIndex, tt.want)
+			}
+		})
+	}
+}
+
+func TestPhoneSuffixIndex(t *testing.T) {
+	cipher := newCipher(t)
+	ctx := context.Background()
+
+	index := func(phone string) string {
+		t.Helper()
+		got, err := PhoneSuffixIndex(ctx, cipher, phone)
+		if err != nil {
+			t.Fatalf("PhoneSuffixIndex() error = %v", err)
+		}
+		return got
+	}
+	want := index("+15550109999")
+
+	tests := []struct {
+		phone string
+		same  bool
+	}{
+		{phone: "+1 (555) 010-9999", same: true},
+		{phone: "+442079469999", same: true},
+		{phone: "+15550109998"},
+		{phone: "+15559999010"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.phone, func(t *testing.T) {
+			if got := index(tt.phone); (got == want) != tt.same {
+				t.Errorf("PhoneSuffixIndex(%q) same as for +15550109999: %v, want %v", tt.phone, !tt.same, tt.same)
+			}
+		})
+	}
+
+	if got := index("999"); got != "" {
+		t.Errorf("PhoneSuffixIndex() of a number with three digits = %q, want none", got)
+	}
+	if phone, err := cipher.BlindIndex(ctx, "phone", "9999"); err != nil || phone == index("9999") {
+		t.Errorf("PhoneSuffixIndex(9999) = BlindIndex(phone, 9999) = %s, want indexes apart from whole numbers", phone)
+	}
+}
@@ -0,0 +1,59 @@
+package service
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"customer-service/internal/customer/models"
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+)
+
+// cursorCodec turns listing positions into opaque cursors and back. Cursors are
+// signed, so a client cannot craft one pointing anywhere it was not given.
+type cursorCodec struct {
+	secret []byte
+}
+
+// encode returns the cursor of a position
+func (c cursorCodec) encode(position models.Cursor) (string, error) {
+	payload, err := json.Marshal(position)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(payload) + "." +
+		base64.RawURLEncoding.EncodeToString(c.sign(payload)), nil
+}
+
+// decode verifies a cursor produced by encode and returns its position
+func (c cursorCodec) decode(token string) (*models.Cursor, error) {
+	invalid := models.NewValidationError("cursor", "cursor is invalid")
+
+	encodedPayload, encodedSignature, ok := strings.Cut(token, ".")
+	if !ok {
+		return nil, invalid
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(encodedPayload)
+	if err != nil {
+		return nil, invalid
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(encodedSignature)
+	if err != nil || !hmac.Equal(signature, c.sign(payload)) {
+		return nil, invalid
+	}
+
+	var cursor models.Cursor
+	if err := json.Unmarshal(payload, &cursor); err != nil {
+		return nil, invalid
+	}
+	return &cursor, nil
+}
+
+// sign returns the HMAC of a cursor payload
+func (c cursorCodec) sign(payload []byte) []byte {
+	mac := hmac.New(sha256.New, c.secret)
+	mac.Write([]byte("customer-cursor"))
+	mac.Write([]byte{0})
+	mac.Write(payload)
+	return mac.Sum(nil)
+}
@@ -0,0 +1,90 @@
+package service
+
+import (
+	"customer-service/internal/customer/models"
+	"encoding/base64"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestCursorRoundTrip(t *testing.T) {
+	codec := cursorCodec{secret: []byte("0123456789abcdef0123456789abcdef")}
+	rank := 0.75
+
+	tests := []struct {
+		name     string
+		position models.Cursor
+	}{
+		{"ID only", models.Cursor{ID: uuid.New()}},
+		{"sorted", models.Cursor{Sort: "last_name,-created_at", Values: []string{"Smith", "2024-01-15T10:30:00.123456Z"}, ID: uuid.New()}},
+		{"ranked", models.Cursor{Rank: &rank, Values: []string{}, ID: uuid.New()}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			token, err := codec.encode(tt.position)
+			if err != nil {
+				t.Fatalf("encode() error = %v", err)
+			}
+			got, err := codec.decode(token)
+			if err != nil {
+				t.Fatalf("decode() error = %v", err)
+			}
+			if !reflect.DeepEqual(*got, tt.position) {
+				t.Errorf("decode() = %+v, want %+v", *got, tt.position)
+			}
+		})
+	}
+}
+
+func TestCursorRejectsTampering(t *testing.T) {
+	codec := cursorCodec{secret: []byte("0123456789abcdef0123456789abcdef")}
+	token, err := codec.encode(models.Cursor{Sort: "last_name", Values: []string{"Smith"}, ID: uuid.New()})
+	if err != nil {
+		t.Fatalf("encode() error = %v", err)
+	}
+	payload, signature, _ := strings.Cut(token, ".")
+
+	forged := base64.RawURLEncoding.EncodeToString([]byte(`{"sort":"last_name","values":["Jones"],"id":"` + uuid.NewString() + `"}`))
+	other := cursorCodec{secret: []byte("fedcba9876543210fedcba9876543210")}
+	otherToken, err := other.encode(models.Cursor{ID: uuid.New()})
+	if err != nil {
+		t.Fatalf("encode() error = %v", err)
+	}
+
+	tests := []struct {
+		name  string
+		token string
+	}{
+		{"empty", ""},
+		{"no signature", payload},
+		{"empty signature", payload + "."},
+		{"forged payload", forged + "." + signature},
+		{"truncated signature", payload + "." + signature[:len(signature)-2]},
+		{"signature of another secret", otherToken},
+		{"payload not base64", "!!!." + signature},
+		{"signature not base64", payload + ".!!!"},
+		{"signed payload that is not a cursor", func() string {
+			junk := []byte("not json")
+			return base64.RawURLEncoding.EncodeToString(junk) + "." +
+				base64.RawURLEncoding.EncodeToString(codec.sign(junk))
+		}()},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cursor, err := codec.decode(tt.token)
+			if cursor != nil {
+				t.Errorf("decode() = %+v, want nil", cursor)
+			}
+			var verr *models.ValidationError
+			if !errors.As(err, &verr) || len(verr.Fields) != 1 || verr.Fields[0].Field != "cursor" {
+				t.Errorf("decode() error = %v, want a cursor validation error", err)
+			}
+		})
+	}
+}
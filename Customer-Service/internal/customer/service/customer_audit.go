@@ -0,0 +1,139 @@
+package service
+
+import (
+	"customer-service/internal/audit"
+	"customer-service/internal/customer/models"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// personalFields are the customer fields holding personal data, the ones an erasure
+// clears. The audit trail is append-only and outlives erasure and purging, so only
+// the fact that they changed is recorded, never their values.
+var personalFields = map[string]bool{
+	"first_name":          true,
+	"last_name":           true,
+	"email":               true,
+	"phone":               true,
+	"date_of_birth":       true,
+	"address.street":      true,
+	"address.city":        true,
+	"address.state":       true,
+	"address.postal_code": true,
+	"address.country":     true,
+	"occupation":          true,
+}
+
+// auditChanges returns the field-level differences between two states of a customer
+// for the audit trail, with personalFields redacted. A nil before or after records a
+// creation or removal, listing every field.
+func auditChanges(before, after *models.Customer) []audit.Change {
+	var beforeReq, afterReq *models.CustomerRequest
+	if before != nil {
+		req := requestFromCustomer(before)
+		beforeReq = &req
+	}
+	if after != nil {
+		req := requestFromCustomer(after)
+		afterReq = &req
+	}
+
+	changes := make([]audit.Change, 0)
+	for _, field := range patchableFields {
+		var oldValue, newValue any
+		if beforeReq != nil {
+			oldValue = field.value(beforeReq)
+		}
+		if afterReq != nil {
+			newValue = field.value(afterReq)
+		}
+		if beforeReq != nil && afterReq != nil && sameValue(oldValue, newValue) {
+			continue
+		}
+		if personalFields[field.path] {
+			changes = append(changes, audit.RedactedChange(field.path))
+			continue
+		}
+		changes = append(changes, audit.Change{Field: field.path, Before: oldValue, After: newValue})
+	}
+
+	var oldStatus, newStatus any
+	if before != nil {
+		oldStatus = before.Status
+	}
+	if after != nil {
+		newStatus = after.Status
+	}
+	if oldStatus != newStatus {
+		changes = append(changes, audit.Change{Field: "status", Before: oldStatus, After: newStatus})
+	}
+	return changes
+}
+
+// auditedAddress is what the audit trail records of an address: everything but the
+// address lines, which are encrypted at rest and must not be kept in plaintext
+type auditedAddress struct {
+	Type               models.AddressType        `json:"type"`
+	IsPrimary          bool                      `json:"is_primary"`
+	ValidFrom          time.Time                 `json:"valid_from"`
+	ValidTo            *time.Time                `json:"valid_to,omitempty"`
+	VerificationStatus models.VerificationStatus `json:"verification_status"`
+}
+
+// auditedContactPoint is what the audit trail records of a contact point: everything
+// but its value
+type auditedContactPoint struct {
+	Kind               models.ContactKind        `json:"kind"`
+	Type               models.ContactType        `json:"type"`
+	IsPrimary          bool                      `json:"is_primary"`
+	ValidFrom          time.Time                 `json:"valid_from"`
+	ValidTo            *time.Time                `json:"valid_to,omitempty"`
+	VerificationStatus models.VerificationStatus `json:"verification_status"`
+}
+
+// addressChange returns the redacted audit change of an address. A nil before or
+// after records an addition or removal.
+func addressChange(id uuid.UUID, before, after *models.CustomerAddress) audit.Change {
+	audited := func(a *models.CustomerAddress) any {
+		if a == nil {
+			return nil
+		}
+		return auditedAddress{
+			Type:               a.Type,
+			IsPrimary:          a.IsPrimary,
+			ValidFrom:          a.ValidFrom,
+			ValidTo:            a.ValidTo,
+			VerificationStatus: a.VerificationStatus,
+		}
+	}
+	return audit.Change{Field: "addresses." + id.String(), Before: audited(before), After: audited(after), Redacted: true}
+}
+
+// contactPointChange returns the redacted audit change of a contact point, in the
+// same way as addressChange
+func contactPointChange(id uuid.UUID, before, after *models.ContactPoint) audit.Change {
+	audited := func(p *models.ContactPoint) any {
+		if p == nil {
+			return nil
+		}
+		return auditedContactPoint{
+			Kind:               p.Kind,
+			Type:               p.Type,
+			IsPrimary:          p.IsPrimary,
+			ValidFrom:          p.ValidFrom,
+			ValidTo:            p.ValidTo,
+			VerificationStatus: p.VerificationStatus,
+		}
+	}
+	return audit.Change{Field: "contact_points." + id.String(), Before: audited(before), After: audited(after), Redacted: true}
+}
+
+// customerIDs returns the IDs of the given customers
+func customerIDs(customers []models.Customer) []uuid.UUID {
+	ids := make([]uuid.UUID, len(customers))
+	for i := range customers {
+		ids[i] = customers[i].ID
+	}
+	return ids
+}
@@ -0,0 +1,747 @@
+package service
+
+import (
+	"context"
+	"customer-service/internal/audit"
+	"customer-service/internal/customer/models"
+	"customer-service/internal/encryption"
+	"customer-service/internal/events"
+	"net/mail"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Changes reported by CustomerContactsChanged events
+const (
+	contactChangeAdded    = "added"
+	contactChangeUpdated  = "updated"
+	contactChangeRemoved  = "removed"
+	contactChangeVerified = "verified"
+)
+
+// ListAddresses returns every address of a customer, including ended and future ones
+func (s *customerService) ListAddresses(ctx context.Context, id uuid.UUID) (*models.AddressListResponse, error) {
+	if _, err := s.repo.GetByID(ctx, id); err != nil {
+		return nil, s.redirectMerged(ctx, id, err)
+	}
+
+	addresses, err := s.repo.ListAddresses(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.audit.RecordAccess(ctx, audit.ActionCustomerContactsView, []uuid.UUID{id}); err != nil {
+		return nil, err
+	}
+
+	return &models.AddressListResponse{
+		CustomerID: id,
+		Addresses:  addresses,
+	}, nil
+}
+
+// AddAddress adds an address to a customer. The first address of a type that is
+// already valid becomes its primary address; a new primary residential address
+// becomes the customer's address.
+func (s *customerService) AddAddress(ctx context.Context, id uuid.UUID, req models.AddressRequest) (*models.CustomerAddress, error) {
+	customer, address, err := s.prepareAddress(ctx, id, req)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := s.syncContacts(ctx, customer); err != nil {
+			return err
+		}
+		addresses, err := s.repo.ListAddresses(ctx, id)
+		if err != nil {
+			return err
+		}
+		if primaryAddress(addresses, address.Type) == nil && address.ActiveOn(today()) && address.ValidTo == nil {
+			address.IsPrimary = true
+		}
+
+		if err := s.repo.CreateAddress(ctx, address); err != nil {
+			return err
+		}
+		if err := s.applyPrimaryAddress(ctx, customer, address); err != nil {
+			return err
+		}
+		return s.recordAddressChange(ctx, contactChangeAdded, nil, address)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return address, nil
+}
+
+// UpdateAddress replaces an address of a customer. Changing the address resets its
+// verification. A primary address keeps its type and stays primary until another
+// address replaces it.
+func (s *customerService) UpdateAddress(ctx context.Context, id, addressID uuid.UUID, req models.AddressRequest) (*models.CustomerAddress, error) {
+	customer, updated, err := s.prepareAddress(ctx, id, req)
+	if err != nil {
+		return nil, err
+	}
+
+	var address *models.CustomerAddress
+	err = s.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := s.syncContacts(ctx, customer); err != nil {
+			return err
+		}
+		address, err = s.repo.GetAddress(ctx, id, addressID)
+		if err != nil {
+			return err
+		}
+		if address.IsPrimary {
+			verr := &models.ValidationError{}
+			if updated.Type != address.Type {
+				verr.Add("type", "cannot be changed on a primary address")
+			}
+			if !updated.IsPrimary {
+				verr.Add("is_primary", "cannot be unset; make another address primary instead")
+			}
+			if verr.HasErrors() {
+				return verr
+			}
+		}
+
+		before := *address
+		address.Type = updated.Type
+		address.IsPrimary = updated.IsPrimary
+		address.ValidFrom = updated.ValidFrom
+		address.ValidTo = updated.ValidTo
+		if address.Address != updated.Address {
+			address.Address = updated.Address
+			address.VerificationStatus = models.VerificationUnverified
+			address.VerifiedAt = nil
+		}
+
+		if err := s.repo.UpdateAddress(ctx, address); err != nil {
+			return err
+		}
+		if err := s.applyPrimaryAddress(ctx, customer, address); err != nil {
+			return err
+		}
+		return s.recordAddressChange(ctx, contactChangeUpdated, &before, address)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return address, nil
+}
+
+// DeleteAddress removes an address of a customer. Primary addresses cannot be removed.
+func (s *customerService) DeleteAddress(ctx context.Context, id, addressID uuid.UUID) error {
+	customer, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return s.redirectMerged(ctx, id, err)
+	}
+
+	return s.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := s.syncContacts(ctx, customer); err != nil {
+			return err
+		}
+		address, err := s.repo.GetAddress(ctx, id, addressID)
+		if err != nil {
+			return err
+		}
+		if address.IsPrimary {
+			return models.ErrPrimaryContact
+		}
+		if err := s.repo.DeleteAddress(ctx, id, addressID); err != nil {
+			return err
+		}
+		return s.recordAddressChange(ctx, contactChangeRemoved, address, nil)
+	})
+}
+
+// VerifyAddress records the outcome of verifying an address of a customer
+func (s *customerService) VerifyAddress(ctx context.Context, id, addressID uuid.UUID, req models.VerificationRequest) (*models.CustomerAddress, error) {
+	if err := models.ValidateStruct(req); err != nil {
+		return nil, err
+	}
+	customer, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, s.redirectMerged(ctx, id, err)
+	}
+
+	var address *models.CustomerAddress
+	err = s.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := s.syncContacts(ctx, customer); err != nil {
+			return err
+		}
+		address, err = s.repo.GetAddress(ctx, id, addressID)
+		if err != nil {
+			return err
+		}
+
+		before := *address
+		address.VerificationStatus, address.VerifiedAt = verification(req.Status)
+		if err := s.repo.UpdateAddress(ctx, address); err != nil {
+			return err
+		}
+		return s.recordAddressChange(ctx, contactChangeVerified, &before, address)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return address, nil
+}
+
+// ListContactPoints returns every email address and phone number of a customer,
+// including ended and future ones
+func (s *customerService) ListContactPoints(ctx context.Context, id uuid.UUID) (*models.ContactPointListResponse, error) {
+	if _, err := s.repo.GetByID(ctx, id); err != nil {
+		return nil, s.redirectMerged(ctx, id, err)
+	}
+
+	points, err := s.repo.ListContactPoints(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.audit.RecordAccess(ctx, audit.ActionCustomerContactsView, []uuid.UUID{id}); err != nil {
+		return nil, err
+	}
+
+	return &models.ContactPointListResponse{
+		CustomerID:    id,
+		ContactPoints: points,
+	}, nil
+}
+
+// AddContactPoint adds an email address or phone number to a customer, with the
+// same primary rules as AddAddress. A new primary email or phone becomes the
+// customer's email or phone.
+func (s *customerService) AddContactPoint(ctx context.Context, id uuid.UUID, req models.ContactPointRequest) (*models.ContactPoint, error) {
+	customer, point, err := s.prepareContactPoint(ctx, id, req)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := s.syncContacts(ctx, customer); err != nil {
+			return err
+		}
+		points, err := s.repo.ListContactPoints(ctx, id)
+		if err != nil {
+			return err
+		}
+		if primaryContactPoint(points, point.Kind) == nil && point.ActiveOn(today()) && point.ValidTo == nil {
+			point.IsPrimary = true
+		}
+
+		if err := s.repo.CreateContactPoint(ctx, point); err != nil {
+			return err
+		}
+		if err := s.applyPrimaryContactPoint(ctx, customer, point); err != nil {
+			return err
+		}
+		return s.recordContactPointChange(ctx, contactChangeAdded, nil, point)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return point, nil
+}
+
+// UpdateContactPoint replaces a contact point of a customer, with the same rules as
+// UpdateAddress. The kind of a contact point cannot be changed.
+func (s *customerService) UpdateContactPoint(ctx context.Context, id, contactID uuid.UUID, req models.ContactPointRequest) (*models.ContactPoint, error) {
+	customer, updated, err := s.prepareContactPoint(ctx, id, req)
+	if err != nil {
+		return nil, err
+	}
+
+	var point *models.ContactPoint
+	err = s.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := s.syncContacts(ctx, customer); err != nil {
+			return err
+		}
+		point, err = s.repo.GetContactPoint(ctx, id, contactID)
+		if err != nil {
+			return err
+		}
+		verr := &models.ValidationError{}
+		if updated.Kind != point.Kind {
+			verr.Add("kind", "cannot be changed")
+		}
+		if point.IsPrimary && !updated.IsPrimary {
+			verr.Add("is_primary", "cannot be unset; make another contact point primary instead")
+		}
+		if verr.HasErrors() {
+			return verr
+		}
+
+		before := *point
+		point.Type = updated.Type
+		point.IsPrimary = updated.IsPrimary
+		point.ValidFrom = updated.ValidFrom
+		point.ValidTo = updated.ValidTo
+		if !sameContactValue(point.Kind, point.Value, updated.Value) {
+			point.VerificationStatus = models.VerificationUnverified
+			point.VerifiedAt = nil
+		}
+		point.Value = updated.Value
+
+		if err := s.repo.UpdateContactPoint(ctx, point); err != nil {
+			return err
+		}
+		if err := s.applyPrimaryContactPoint(ctx, customer, point); err != nil {
+			return err
+		}
+		return s.recordContactPointChange(ctx, contactChangeUpdated, &before, point)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return point, nil
+}
+
+// DeleteContactPoint removes a contact point of a customer. Primary contact points
+// cannot be removed.
+func (s *customerService) DeleteContactPoint(ctx context.Context, id, contactID uuid.UUID) error {
+	customer, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return s.redirectMerged(ctx, id, err)
+	}
+
+	return s.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := s.syncContacts(ctx, customer); err != nil {
+			return err
+		}
+		point, err := s.repo.GetContactPoint(ctx, id, contactID)
+		if err != nil {
+			return err
+		}
+		if point.IsPrimary {
+			return models.ErrPrimaryContact
+		}
+		if err := s.repo.DeleteContactPoint(ctx, id, contactID); err != nil {
+			return err
+		}
+		return s.recordContactPointChange(ctx, contactChangeRemoved, point, nil)
+	})
+}
+
+// VerifyContactPoint records the outcome of verifying a contact point of a customer
+func (s *customerService) VerifyContactPoint(ctx context.Context, id, contactID uuid.UUID, req models.VerificationRequest) (*models.ContactPoint, error) {
+	if err := models.ValidateStruct(req); err != nil {
+		return nil, err
+	}
+	customer, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, s.redirectMerged(ctx, id, err)
+	}
+
+	var point *models.ContactPoint
+	err = s.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := s.syncContacts(ctx, customer); err != nil {
+			return err
+		}
+		point, err = s.repo.GetContactPoint(ctx, id, contactID)
+		if err != nil {
+			return err
+		}
+
+		before := *point
+		point.VerificationStatus, point.VerifiedAt = verification(req.Status)
+		if err := s.repo.UpdateContactPoint(ctx, point); err != nil {
+			return err
+		}
+		return s.recordContactPointChange(ctx, contactChangeVerified, &before, point)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return point, nil
+}
+
+// prepareAddress validates an address request and loads the customer it is for
+func (s *customerService) prepareAddress(ctx context.Context, id uuid.UUID, req models.AddressRequest) (*models.Customer, *models.CustomerAddress, error) {
+	if err := models.ValidateStruct(req); err != nil {
+		return nil, nil, err
+	}
+
+	verr := &models.ValidationError{}
+	address := trimAddress(req.Address)
+	if address.Street == "" && address.City == "" {
+		verr.Add("address", "street or city is required")
+	}
+	if address.Country == "" {
+		verr.Add("address.country", "country is required")
+	}
+	validFrom, validTo := validityPeriod(verr, req.ValidFrom, req.ValidTo, req.IsPrimary)
+	if verr.HasErrors() {
+		return nil, nil, verr
+	}
+
+	customer, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, nil, s.redirectMerged(ctx, id, err)
+	}
+	return customer, &models.CustomerAddress{
+		CustomerID:         id,
+		Type:               req.Type,
+		IsPrimary:          req.IsPrimary,
+		Address:            address,
+		ValidFrom:          validFrom,
+		ValidTo:            validTo,
+		VerificationStatus: models.VerificationUnverified,
+	}, nil
+}
+
+// prepareContactPoint validates a contact point request and loads the customer it is for
+func (s *customerService) prepareContactPoint(ctx context.Context, id uuid.UUID, req models.ContactPointRequest) (*models.Customer, *models.ContactPoint, error) {
+	if err := models.ValidateStruct(req); err != nil {
+		return nil, nil, err
+	}
+
+	verr := &models.ValidationError{}
+	value := strings.TrimSpace(req.Value)
+	switch req.Kind {
+	case models.ContactKindEmail:
+		if parsed, err := mail.ParseAddress(value); err != nil || parsed.Address != value {
+			verr.Add("value", "must be a valid email address")
+		}
+		if req.Type != models.ContactTypePersonal && req.Type != models.ContactTypeWork {
+			verr.Add("type", "must be one of: personal work")
+		}
+	case models.ContactKindPhone:
+		if len(value) < 10 || len(value) > 20 {
+			verr.Add("value", "must be between 10 and 20 characters")
+		}
+	}
+	validFrom, validTo := validityPeriod(verr, req.ValidFrom, req.ValidTo, req.IsPrimary)
+	if verr.HasErrors() {
+		return nil, nil, verr
+	}
+
+	customer, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, nil, s.redirectMerged(ctx, id, err)
+	}
+	return customer, &models.ContactPoint{
+		CustomerID:         id,
+		Kind:               req.Kind,
+		Type:               req.Type,
+		Value:              value,
+		IsPrimary:          req.IsPrimary,
+		ValidFrom:          validFrom,
+		ValidTo:            validTo,
+		VerificationStatus: models.VerificationUnverified,
+	}, nil
+}
+
+// validityPeriod returns the dates an address or contact point applies between,
+// recording any problem with them in verr. A primary one must already apply and
+// cannot have an end date, because it stands for the customer's current details.
+func validityPeriod(verr *models.ValidationError, from, to *time.Time, primary bool) (time.Time, *time.Time) {
+	validFrom := today()
+	if from != nil {
+		validFrom = day(*from)
+	}
+	var validTo *time.Time
+	if to != nil {
+		end := day(*to)
+		validTo = &end
+		if !end.After(validFrom) {
+			verr.Add("valid_to", "must be after valid_from")
+		}
+	}
+
+	if primary {
+		if validFrom.After(today()) {
+			verr.Add("valid_from", "cannot be in the future for a primary entry")
+		}
+		if validTo != nil {
+			verr.Add("valid_to", "cannot be set for a primary entry")
+		}
+	}
+	return validFrom, validTo
+}
+
+// syncContacts brings the primary residential address, primary email and primary
+// phone of a customer in line with the customer's own fields, which remain the
+// source for them. Must run inside a transaction.
+func (s *customerService) syncContacts(ctx context.Context, customer *models.Customer) error {
+	addresses, err := s.repo.ListAddresses(ctx, customer.ID)
+	if err != nil {
+		return err
+	}
+	if err := s.syncPrimaryAddress(ctx, customer, addresses); err != nil {
+		return err
+	}
+
+	points, err := s.repo.ListContactPoints(ctx, customer.ID)
+	if err != nil {
+		return err
+	}
+	if err := s.syncPrimaryContactPoint(ctx, customer.ID, models.ContactKindEmail, customer.Email, points); err != nil {
+		return err
+	}
+	return s.syncPrimaryContactPoint(ctx, customer.ID, models.ContactKindPhone, customer.Phone, points)
+}
+
+// syncPrimaryAddress makes the customer's address its primary residential address.
+// An existing residential address that matches is promoted; otherwise the primary
+// one is changed, or removed when the customer no longer has an address.
+func (s *customerService) syncPrimaryAddress(ctx context.Context, customer *models.Customer, addresses []models.CustomerAddress) error {
+	primary := primaryAddress(addresses, models.AddressTypeResidential)
+	if primary != nil && primary.Address == customer.Address {
+		return nil
+	}
+	if customer.Address == (models.Address{}) {
+		if primary == nil {
+			return nil
+		}
+		return s.repo.DeleteAddress(ctx, customer.ID, primary.ID)
+	}
+
+	for i := range addresses {
+		candidate := &addresses[i]
+		if candidate.Type == models.AddressTypeResidential && candidate.Address == customer.Address &&
+			candidate.ActiveOn(today()) && candidate.ValidTo == nil {
+			candidate.IsPrimary = true
+			return s.repo.UpdateAddress(ctx, candidate)
+		}
+	}
+	if primary != nil {
+		primary.Address = customer.Address
+		primary.VerificationStatus = models.VerificationUnverified
+		primary.VerifiedAt = nil
+		return s.repo.UpdateAddress(ctx, primary)
+	}
+	return s.repo.CreateAddress(ctx, &models.CustomerAddress{
+		CustomerID:         customer.ID,
+		Type:               models.AddressTypeResidential,
+		IsPrimary:          true,
+		Address:            customer.Address,
+		ValidFrom:          today(),
+		VerificationStatus: models.VerificationUnverified,
+	})
+}
+
+// syncPrimaryContactPoint makes value the customer's primary contact point of a kind,
+// in the same way as syncPrimaryAddress
+func (s *customerService) syncPrimaryContactPoint(ctx context.Context, customerID uuid.UUID, kind models.ContactKind, value string, points []models.ContactPoint) error {
+	primary := primaryContactPoint(points, kind)
+	if primary != nil && primary.Value == value {
+		return nil
+	}
+	if value == "" {
+		if primary == nil {
+			return nil
+		}
+		return s.repo.DeleteContactPoint(ctx, customerID, primary.ID)
+	}
+
+	for i := range points {
+		candidate := &points[i]
+		if candidate.Kind == kind && sameContactValue(kind, candidate.Value, value) &&
+			candidate.ActiveOn(today()) && candidate.ValidTo == nil {
+			candidate.IsPrimary = true
+			candidate.Value = value
+			return s.repo.UpdateContactPoint(ctx, candidate)
+		}
+	}
+	if primary != nil {
+		if !sameContactValue(kind, primary.Value, value) {
+			primary.VerificationStatus = models.VerificationUnverified
+			primary.VerifiedAt = nil
+		}
+		primary.Value = value
+		return s.repo.UpdateContactPoint(ctx, primary)
+	}
+
+	contactType := models.ContactTypePersonal
+	if kind == models.ContactKindPhone {
+		contactType = models.ContactTypeMobile
+	}
+	return s.repo.CreateContactPoint(ctx, &models.ContactPoint{
+		CustomerID:         customerID,
+		Kind:               kind,
+		Type:               contactType,
+		Value:              value,
+		IsPrimary:          true,
+		ValidFrom:          today(),
+		VerificationStatus: models.VerificationUnverified,
+	})
+}
+
+// BackfillContacts gives customers created before addresses and contact points were
+// kept separately their primary entries, taken from the customer's own fields,
+// batchSize customers per transaction. It returns how many customers were given
+// entries. Customers being changed at the same time are skipped, as the change
+// gives them theirs.
+func (s *customerService) BackfillContacts(ctx context.Context, batchSize int) (int, error) {
+	backfilled := 0
+	after := uuid.Nil
+	for {
+		var customers []models.Customer
+		err := s.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+			var err error
+			customers, err = s.repo.LockWithoutContacts(ctx, after, batchSize)
+			if err != nil {
+				return err
+			}
+			for i := range customers {
+				if err := s.syncContacts(ctx, &customers[i]); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return backfilled, err
+		}
+		backfilled += len(customers)
+
+		if len(customers) < batchSize {
+			return backfilled, nil
+		}
+		after = customers[len(customers)-1].ID
+	}
+}
+
+// applyPrimaryAddress makes a primary residential address the customer's address
+func (s *customerService) applyPrimaryAddress(ctx context.Context, customer *models.Customer, address *models.CustomerAddress) error {
+	if !address.IsPrimary || address.Type != models.AddressTypeResidential {
+		return nil
+	}
+	return s.updateFromContacts(ctx, customer, func(req *models.CustomerRequest) {
+		req.Address = address.Address
+	})
+}
+
+// applyPrimaryContactPoint makes a primary email or phone the customer's email or phone
+func (s *customerService) applyPrimaryContactPoint(ctx context.Context, customer *models.Customer, point *models.ContactPoint) error {
+	if !point.IsPrimary {
+		return nil
+	}
+	return s.updateFromContacts(ctx, customer, func(req *models.CustomerRequest) {
+		if point.Kind == models.ContactKindEmail {
+			req.Email = point.Value
+		} else {
+			req.Phone = point.Value
+		}
+	})
+}
+
+// updateFromContacts applies a change to the customer's contact fields as an
+// ordinary customer update, so that it is audited, screened, rated and published
+// like one
+func (s *customerService) updateFromContacts(ctx context.Context, customer *models.Customer, change func(req *models.CustomerRequest)) error {
+	current := requestFromCustomer(customer)
+	updated := current
+	change(&updated)
+
+	columns, changedFields := diffRequests(current, updated)
+	if len(columns) == 0 {
+		return nil
+	}
+	if updated.Email != current.Email {
+		if err := s.checkEmailAvailable(ctx, updated.Email, customer.ID); err != nil {
+			return err
+		}
+	}
+
+	before := *customer
+	applyRequest(customer, updated)
+	return s.saveFields(ctx, customer, &before, columns, changedFields, audit.ActionCustomerUpdate)
+}
+
+// recordAddressChange audits a change to an address and publishes it. A nil before
+// or after records an addition or removal.
+func (s *customerService) recordAddressChange(ctx context.Context, change string, before, after *models.CustomerAddress) error {
+	current := after
+	if current == nil {
+		current = before
+	}
+	changes := []audit.Change{addressChange(current.ID, before, after)}
+	if err := s.audit.Record(ctx, audit.ActionCustomerContactsUpdate, current.CustomerID, changes); err != nil {
+		return err
+	}
+	return s.emit(ctx, events.CustomerContactsChanged, current.CustomerID, models.CustomerContactsChangedPayload{
+		Change:  change,
+		Address: current,
+	})
+}
+
+// recordContactPointChange audits a change to a contact point and publishes it, in the
+// same way as recordAddressChange
+func (s *customerService) recordContactPointChange(ctx context.Context, change string, before, after *models.ContactPoint) error {
+	current := after
+	if current == nil {
+		current = before
+	}
+	changes := []audit.Change{contactPointChange(current.ID, before, after)}
+	if err := s.audit.Record(ctx, audit.ActionCustomerContactsUpdate, current.CustomerID, changes); err != nil {
+		return err
+	}
+	return s.emit(ctx, events.CustomerContactsChanged, current.CustomerID, models.CustomerContactsChangedPayload{
+		Change:       change,
+		ContactPoint: current,
+	})
+}
+
+// primaryAddress returns the primary address of a type, or nil if there is none
+func primaryAddress(addresses []models.CustomerAddress, addressType models.AddressType) *models.CustomerAddress {
+	for i := range addresses {
+		if addresses[i].IsPrimary && addresses[i].Type == addressType {
+			return &addresses[i]
+		}
+	}
+	return nil
+}
+
+// primaryContactPoint returns the primary contact point of a kind, or nil if there is none
+func primaryContactPoint(points []models.ContactPoint, kind models.ContactKind) *models.ContactPoint {
+	for i := range points {
+		if points[i].IsPrimary && points[i].Kind == kind {
+			return &points[i]
+		}
+	}
+	return nil
+}
+
+// sameContactValue compares two email addresses or phone numbers in the form their
+// blind indexes are computed from
+func sameContactValue(kind models.ContactKind, a, b string) bool {
+	if kind == models.ContactKindEmail {
+		return encryption.NormalizeEmail(a) == encryption.NormalizeEmail(b)
+	}
+	return encryption.NormalizePhone(a) == encryption.NormalizePhone(b)
+}
+
+// trimAddress returns an address with surrounding whitespace removed from every field
+func trimAddress(address models.Address) models.Address {
+	return models.Address{
+		Street:     strings.TrimSpace(address.Street),
+		City:       strings.TrimSpace(address.City),
+		State:      strings.TrimSpace(address.State),
+		PostalCode: strings.TrimSpace(address.PostalCode),
+		Country:    strings.TrimSpace(address.Country),
+	}
+}
+
+// verification returns the verification status to store and when it was verified
+func verification(status models.VerificationStatus) (models.VerificationStatus, *time.Time) {
+	if status != models.VerificationVerified {
+		return status, nil
+	}
+	now := time.Now().UTC()
+	return status, &now
+}
+
+// day truncates a time to midnight UTC of its date
+func day(t time.Time) time.Time {
+	year, month, date := t.UTC().Date()
+	return time.Date(year, month, date, 0, 0, 0, 0, time.UTC)
+}
+
+// today returns the current date at midnight UTC
+func today() time.Time {
+	return day(time.Now())
+}
@@ -0,0 +1,550 @@
+package service
+
+import (
+	"context"
+	"customer-service/internal/audit"
+	"customer-service/internal/customer/models"
+	"customer-service/internal/events"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// contactRepository keeps addresses and contact points in memory, following the
+// repository contract: a primary entry saved replaces the other primary entry of its
+// type or kind. Customers are served by stubRepository.
+type contactRepository struct {
+	stubRepository
+	addresses []models.CustomerAddress
+	points    []models.ContactPoint
+}
+
+func (r *contactRepository) ListAddresses(ctx context.Context, customerID uuid.UUID) ([]models.CustomerAddress, error) {
+	return append([]models.CustomerAddress(nil), r.addresses...), nil
+}
+
+func (r *contactRepository) GetAddress(ctx context.Context, customerID, id uuid.UUID) (*models.CustomerAddress, error) {
+	for _, address := range r.addresses {
+		if address.ID == id {
+			return &address, nil
+		}
+	}
+	return nil, models.ErrAddressNotFound
+}
+
+func (r *contactRepository) CreateAddress(ctx context.Context, address *models.CustomerAddress) error {
+	address.ID = uuid.New()
+	r.addresses = append(r.addresses, *address)
+	return r.UpdateAddress(ctx, address)
+}
+
+func (r *contactRepository) UpdateAddress(ctx context.Context, address *models.CustomerAddress) error {
+	for i := range r.addresses {
+		switch {
+		case r.addresses[i].ID == address.ID:
+			r.addresses[i] = *address
+		case address.IsPrimary && r.addresses[i].Type == address.Type:
+			r.addresses[i].IsPrimary = false
+		}
+	}
+	return nil
+}
+
+func (r *contactRepository) DeleteAddress(ctx context.Context, customerID, id uuid.UUID) error {
+	for i := range r.addresses {
+		if r.addresses[i].ID == id {
+			r.addresses = append(r.addresses[:i], r.addresses[i+1:]...)
+			return nil
+		}
+	}
+	return models.ErrAddressNotFound
+}
+
+func (r *contactRepository) ListContactPoints(ctx context.Context, customerID uuid.UUID) ([]models.ContactPoint, error) {
+	return append([]models.ContactPoint(nil), r.points...), nil
+}
+
+func (r *contactRepository) GetContactPoint(ctx context.Context, customerID, id uuid.UUID) (*models.ContactPoint, error) {
+	for _, point := range r.points {
+		if point.ID == id {
+			return &point, nil
+		}
+	}
+	return nil, models.ErrContactPointNotFound
+}
+
+func (r *contactRepository) CreateContactPoint(ctx context.Context, point *models.ContactPoint) error {
+	point.ID = uuid.New()
+	r.points = append(r.points, *point)
+	return r.UpdateContactPoint(ctx, point)
+}
+
+func (r *contactRepository) UpdateContactPoint(ctx context.Context, point *models.ContactPoint) error {
+	for i := range r.points {
+		switch {
+		case r.points[i].ID == point.ID:
+			r.points[i] = *point
+		case point.IsPrimary && r.points[i].Kind == point.Kind:
+			r.points[i].IsPrimary = false
+		}
+	}
+	return nil
+}
+
+func (r *contactRepository) DeleteContactPoint(ctx context.Context, customerID, id uuid.UUID) error {
+	for i := range r.points {
+		if r.points[i].ID == id {
+			r.points = append(r.points[:i], r.points[i+1:]...)
+			return nil
+		}
+	}
+	return models.ErrContactPointNotFound
+}
+
+// primaries returns the primary residential address, email and phone of the repository
+func (r *contactRepository) primaries() (address *models.CustomerAddress, email, phone *models.ContactPoint) {
+	return primaryAddress(r.addresses, models.AddressTypeResidential),
+		primaryContactPoint(r.points, models.ContactKindEmail),
+		primaryContactPoint(r.points, models.ContactKindPhone)
+}
+
+// discard drops audit entries and events, and runs transactions directly
+type discard struct{}
+
+func (discard) Record(ctx context.Context, action string, customerID uuid.UUID, changes []audit.Change) error {
+	return nil
+}
+
+func (discard) RecordAccess(ctx context.Context, action string, customerIDs []uuid.UUID) error {
+	return nil
+}
+
+func (discard) Append(ctx context.Context, event *events.Event) error {
+	return nil
+}
+
+func (discard) WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
+
+var (
+	home  = models.Address{Street: "12 Main Street", City: "London", PostalCode: "SW1A 1AA", Country: "GB"}
+	moved = models.Address{Street: "1 High Street", City: "Leeds", Country: "GB"}
+)
+
+func verifiedAddress(addressType models.AddressType, primary bool, address models.Address) models.CustomerAddress {
+	verifiedAt := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	return models.CustomerAddress{
+		ID: uuid.New(), Type: addressType, IsPrimary: primary, Address: address,
+		ValidFrom: date(2020, 1, 1), VerificationStatus: models.VerificationVerified, VerifiedAt: &verifiedAt,
+	}
+}
+
+func verifiedContactPoint(kind models.ContactKind, contactType models.ContactType, primary bool, value string) models.ContactPoint {
+	verifiedAt := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	return models.ContactPoint{
+		ID: uuid.New(), Kind: kind, Type: contactType, IsPrimary: primary, Value: value,
+		ValidFrom: date(2020, 1, 1), VerificationStatus: models.VerificationVerified, VerifiedAt: &verifiedAt,
+	}
+}
+
+func TestSyncContacts(t *testing.T) {
+	jane := models.Customer{ID: uuid.New(), Email: "jane@example.com", Phone: "+15550109999", Address: home}
+	with := func(fn func(c *models.Customer)) models.Customer {
+		customer := jane
+		fn(&customer)
+		return customer
+	}
+	inLine := func() ([]models.CustomerAddress, []models.ContactPoint) {
+		return []models.CustomerAddress{verifiedAddress(models.AddressTypeResidential, true, home)},
+			[]models.ContactPoint{
+				verifiedContactPoint(models.ContactKindEmail, models.ContactTypePersonal, true, "jane@example.com"),
+				verifiedContactPoint(models.ContactKindPhone, models.ContactTypeMobile, true, "+15550109999"),
+			}
+	}
+
+	tests := []struct {
+		name     string
+		customer models.Customer
+		// bare customers have no entries yet; the others start with entries in line
+		// with their fields, plus addresses and points
+		bare      bool
+		addresses []models.CustomerAddress
+		points    []models.ContactPoint
+		// want* are the primary entries afterwards; an empty value means none
+		wantAddress       models.Address
+		wantAddressStatus models.VerificationStatus
+		wantEmail         string
+		wantEmailStatus   models.VerificationStatus
+		wantPhone         string
+		wantAddresses     int
+		wantPoints        int
+	}{
+		{
+			name:        "customer without entries",
+			customer:    jane,
+			bare:        true,
+			wantAddress: home, wantAddressStatus: models.VerificationUnverified,
+			wantEmail: "jane@example.com", wantEmailStatus: models.VerificationUnverified,
+			wantPhone:     "+15550109999",
+			wantAddresses: 1, wantPoints: 2,
+		},
+		{
+			name:        "entries already in line",
+			customer:    jane,
+			wantAddress: home, wantAddressStatus: models.VerificationVerified,
+			wantEmail: "jane@example.com", wantEmailStatus: models.VerificationVerified,
+			wantPhone:     "+15550109999",
+			wantAddresses: 1, wantPoints: 2,
+		},
+		{
+			name:        "email in another case",
+			customer:    with(func(c *models.Customer) { c.Email = "Jane@Example.com" }),
+			wantAddress: home, wantAddressStatus: models.VerificationVerified,
+			wantEmail: "Jane@Example.com", wantEmailStatus: models.VerificationVerified,
+			wantPhone:     "+15550109999",
+			wantAddresses: 1, wantPoints: 2,
+		},
+		{
+			name:        "email changed",
+			customer:    with(func(c *models.Customer) { c.Email = "jane.doe@example.com" }),
+			wantAddress: home, wantAddressStatus: models.VerificationVerified,
+			wantEmail: "jane.doe@example.com", wantEmailStatus: models.VerificationUnverified,
+			wantPhone:     "+15550109999",
+			wantAddresses: 1, wantPoints: 2,
+		},
+		{
+			name:        "phone removed",
+			customer:    with(func(c *models.Customer) { c.Phone = "" }),
+			wantAddress: home, wantAddressStatus: models.VerificationVerified,
+			wantEmail: "jane@example.com", wantEmailStatus: models.VerificationVerified,
+			wantAddresses: 1, wantPoints: 1,
+		},
+		{
+			name:      "address removed",
+			customer:  with(func(c *models.Customer) { c.Address = models.Address{} }),
+			wantEmail: "jane@example.com", wantEmailStatus: models.VerificationVerified,
+			wantPhone:     "+15550109999",
+			wantAddresses: 0, wantPoints: 2,
+		},
+		{
+			name:        "residential address on record promoted",
+			customer:    with(func(c *models.Customer) { c.Address = moved }),
+			addresses:   []models.CustomerAddress{verifiedAddress(models.AddressTypeResidential, false, moved)},
+			wantAddress: moved, wantAddressStatus: models.VerificationVerified,
+			wantEmail: "jane@example.com", wantEmailStatus: models.VerificationVerified,
+			wantPhone:     "+15550109999",
+			wantAddresses: 2, wantPoints: 2,
+		},
+		{
+			name:     "ended residential address not promoted",
+			customer: with(func(c *models.Customer) { c.Address = moved }),
+			addresses: []models.CustomerAddress{func() models.CustomerAddress {
+				ended := verifiedAddress(models.AddressTypeResidential, false, moved)
+				end := date(2021, 1, 1)
+				ended.ValidTo = &end
+				return ended
+			}()},
+			wantAddress: moved, wantAddressStatus: models.VerificationUnverified,
+			wantEmail: "jane@example.com", wantEmailStatus: models.VerificationVerified,
+			wantPhone:     "+15550109999",
+			wantAddresses: 2, wantPoints: 2,
+		},
+		{
+			name:        "mailing address not promoted",
+			customer:    with(func(c *models.Customer) { c.Address = moved }),
+			addresses:   []models.CustomerAddress{verifiedAddress(models.AddressTypeMailing, false, moved)},
+			wantAddress: moved, wantAddressStatus: models.VerificationUnverified,
+			wantEmail: "jane@example.com", wantEmailStatus: models.VerificationVerified,
+			wantPhone:     "+15550109999",
+			wantAddresses: 2, wantPoints: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &contactRepository{}
+			if !tt.bare {
+				repo.addresses, repo.points = inLine()
+			}
+			repo.addresses = append(repo.addresses, tt.addresses...)
+			repo.points = append(repo.points, tt.points...)
+			s := &customerService{repo: repo}
+
+			if err := s.syncContacts(context.Background(), &tt.customer); err != nil {
+				t.Fatalf("syncContacts() error = %v", err)
+			}
+
+			address, email, phone := repo.primaries()
+			switch {
+			case address == nil && tt.wantAddress != (models.Address{}):
+				t.Errorf("no primary address, want %+v", tt.wantAddress)
+			case address != nil && (address.Address != tt.wantAddress || address.VerificationStatus != tt.wantAddressStatus):
+				t.Errorf("primary address %+v %s, want %+v %s", address.Address, address.VerificationStatus, tt.wantAddress, tt.wantAddressStatus)
+			}
+			switch {
+			case email == nil && tt.wantEmail != "":
+				t.Errorf("no primary email, want %s", tt.wantEmail)
+			case email != nil && (email.Value != tt.wantEmail || email.VerificationStatus != tt.wantEmailStatus):
+				t.Errorf("primary email %s %s, want %s %s", email.Value, email.VerificationStatus, tt.wantEmail, tt.wantEmailStatus)
+			}
+			if (phone == nil && tt.wantPhone != "") || (phone != nil && phone.Value != tt.wantPhone) {
+				t.Errorf("primary phone %+v, want %q", phone, tt.wantPhone)
+			}
+			if len(repo.addresses) != tt.wantAddresses || len(repo.points) != tt.wantPoints {
+				t.Errorf("%d addresses and %d contact points, want %d and %d", len(repo.addresses), len(repo.points), tt.wantAddresses, tt.wantPoints)
+			}
+
+			if err := s.syncContacts(context.Background(), &tt.customer); err != nil {
+				t.Fatalf("syncContacts() a second time error = %v", err)
+			}
+			if len(repo.addresses) != tt.wantAddresses || len(repo.points) != tt.wantPoints {
+				t.Errorf("syncContacts() a second time left %d addresses and %d contact points", len(repo.addresses), len(repo.points))
+			}
+		})
+	}
+}
+
+func TestValidityPeriod(t *testing.T) {
+	yesterday, tomorrow := today().AddDate(0, 0, -1), today().AddDate(0, 0, 1)
+	lateEvening := time.Date(2030, 5, 1, 23, 30, 0, 0, time.FixedZone("", -5*60*60))
+
+	tests := []struct {
+		name     string
+		from, to *time.Time
+		primary  bool
+		wantFrom time.Time
+		wantTo   *time.Time
+		fields   []string
+	}{
+		{name: "from today by default", wantFrom: today()},
+		{name: "dates truncated to the UTC day", from: &lateEvening, wantFrom: date(2030, 5, 2)},
+		{name: "ended", from: &yesterday, to: &tomorrow, wantFrom: yesterday, wantTo: &tomorrow},
+		{name: "ending the day it starts", from: &tomorrow, to: &tomorrow, wantFrom: tomorrow, wantTo: &tomorrow, fields: []string{"valid_to"}},
+		{name: "primary from the past", from: &yesterday, primary: true, wantFrom: yesterday},
+		{name: "primary in the future", from: &tomorrow, primary: true, wantFrom: tomorrow, fields: []string{"valid_from"}},
+		{name: "primary with an end", to: &tomorrow, primary: true, wantFrom: today(), wantTo: &tomorrow, fields: []string{"valid_to"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			verr := &models.ValidationError{}
+			from, to := validityPeriod(verr, tt.from, tt.to, tt.primary)
+			if !from.Equal(tt.wantFrom) || (to == nil) != (tt.wantTo == nil) || (to != nil && !to.Equal(*tt.wantTo)) {
+				t.Errorf("validityPeriod() = %v, %v, want %v, %v", from, to, tt.wantFrom, tt.wantTo)
+			}
+			var fields []string
+			for _, field := range verr.Fields {
+				fields = append(fields, field.Field)
+			}
+			if len(fields) != len(tt.fields) || (len(fields) > 0 && fields[0] != tt.fields[0]) {
+				t.Errorf("validityPeriod() rejected %v, want %v", fields, tt.fields)
+			}
+		})
+	}
+}
+
+func TestContactChanges(t *testing.T) {
+	jane := models.Customer{ID: uuid.New(), Email: "jane@example.com", Phone: "+15550109999", Address: home}
+	residential := verifiedAddress(models.AddressTypeResidential, true, home)
+	mailing := verifiedAddress(models.AddressTypeMailing, false, moved)
+	personalEmail := verifiedContactPoint(models.ContactKindEmail, models.ContactTypePersonal, true, "jane@example.com")
+	workEmail := verifiedContactPoint(models.ContactKindEmail, models.ContactTypeWork, false, "jane@work.example.com")
+	mobile := verifiedContactPoint(models.ContactKindPhone, models.ContactTypeMobile, true, "+15550109999")
+	tomorrow := today().AddDate(0, 0, 1)
+
+	emailRequest := func(value string) models.ContactPointRequest {
+		return models.ContactPointRequest{Kind: models.ContactKindEmail, Type: models.ContactTypeWork, Value: value}
+	}
+
+	tests := []struct {
+		name    string
+		change  func(s *customerService) error
+		wantErr error
+		field   string
+		// check inspects the entries after a change that succeeded
+		check func(t *testing.T, repo *contactRepository)
+	}{
+		{
+			name: "primary address removed",
+			change: func(s *customerService) error {
+				return s.DeleteAddress(context.Background(), jane.ID, residential.ID)
+			},
+			wantErr: models.ErrPrimaryContact,
+		},
+		{
+			name: "primary email removed",
+			change: func(s *customerService) error {
+				return s.DeleteContactPoint(context.Background(), jane.ID, personalEmail.ID)
+			},
+			wantErr: models.ErrPrimaryContact,
+		},
+		{
+			name: "unknown address removed",
+			change: func(s *customerService) error {
+				return s.DeleteAddress(context.Background(), jane.ID, uuid.New())
+			},
+			wantErr: models.ErrAddressNotFound,
+		},
+		{
+			name: "type of the primary address changed",
+			change: func(s *customerService) error {
+				_, err := s.UpdateAddress(context.Background(), jane.ID, residential.ID,
+					models.AddressRequest{Type: models.AddressTypeMailing, IsPrimary: true, Address: home})
+				return err
+			},
+			field: "type",
+		},
+		{
+			name: "primary address no longer primary",
+			change: func(s *customerService) error {
+				_, err := s.UpdateAddress(context.Background(), jane.ID, residential.ID,
+					models.AddressRequest{Type: models.AddressTypeResidential, Address: home})
+				return err
+			},
+			field: "is_primary",
+		},
+		{
+			name: "address without a country",
+			change: func(s *customerService) error {
+				_, err := s.AddAddress(context.Background(), jane.ID,
+					models.AddressRequest{Type: models.AddressTypeWork, Address: models.Address{City: "Leeds"}})
+				return err
+			},
+			field: "address.country",
+		},
+		{
+			name: "primary email no longer primary",
+			change: func(s *customerService) error {
+				req := emailRequest("jane@example.com")
+				req.Type = models.ContactTypePersonal
+				_, err := s.UpdateContactPoint(context.Background(), jane.ID, personalEmail.ID, req)
+				return err
+			},
+			field: "is_primary",
+		},
+		{
+			name: "email turned into a phone",
+			change: func(s *customerService) error {
+				_, err := s.UpdateContactPoint(context.Background(), jane.ID, workEmail.ID,
+					models.ContactPointRequest{Kind: models.ContactKindPhone, Type: models.ContactTypeWork, Value: "+15550108888"})
+				return err
+			},
+			field: "kind",
+		},
+		{
+			name: "invalid email",
+			change: func(s *customerService) error {
+				_, err := s.AddContactPoint(context.Background(), jane.ID, emailRequest("Jane <jane@example.org>"))
+				return err
+			},
+			field: "value",
+		},
+		{
+			name: "mobile email",
+			change: func(s *customerService) error {
+				req := emailRequest("jane@example.org")
+				req.Type = models.ContactTypeMobile
+				_, err := s.AddContactPoint(context.Background(), jane.ID, req)
+				return err
+			},
+			field: "type",
+		},
+		{
+			name: "primary email from tomorrow",
+			change: func(s *customerService) error {
+				req := emailRequest("jane@example.org")
+				req.IsPrimary, req.ValidFrom = true, &tomorrow
+				_, err := s.AddContactPoint(context.Background(), jane.ID, req)
+				return err
+			},
+			field: "valid_from",
+		},
+		{
+			name: "second email added",
+			change: func(s *customerService) error {
+				_, err := s.AddContactPoint(context.Background(), jane.ID, emailRequest(" jane@example.org "))
+				return err
+			},
+			check: func(t *testing.T, repo *contactRepository) {
+				added := repo.points[len(repo.points)-1]
+				if added.Value != "jane@example.org" || added.IsPrimary || added.VerificationStatus != models.VerificationUnverified {
+					t.Errorf("added %s primary %v %s, want jane@example.org, not primary and unverified", added.Value, added.IsPrimary, added.VerificationStatus)
+				}
+				if _, email, _ := repo.primaries(); email.ID != personalEmail.ID {
+					t.Errorf("primary email changed to %s", email.Value)
+				}
+			},
+		},
+		{
+			name: "email changed in case only",
+			change: func(s *customerService) error {
+				_, err := s.UpdateContactPoint(context.Background(), jane.ID, workEmail.ID, emailRequest("Jane@Work.example.com"))
+				return err
+			},
+			check: func(t *testing.T, repo *contactRepository) {
+				point, _ := repo.GetContactPoint(context.Background(), jane.ID, workEmail.ID)
+				if point.Value != "Jane@Work.example.com" || point.VerificationStatus != models.VerificationVerified || point.VerifiedAt == nil {
+					t.Errorf("changed to %s %s, want Jane@Work.example.com still verified", point.Value, point.VerificationStatus)
+				}
+			},
+		},
+		{
+			name: "email changed",
+			change: func(s *customerService) error {
+				_, err := s.UpdateContactPoint(context.Background(), jane.ID, workEmail.ID, emailRequest("jane@new-work.example.com"))
+				return err
+			},
+			check: func(t *testing.T, repo *contactRepository) {
+				point, _ := repo.GetContactPoint(context.Background(), jane.ID, workEmail.ID)
+				if point.VerificationStatus != models.VerificationUnverified || point.VerifiedAt != nil {
+					t.Errorf("changed email is %s verified at %v, want it unverified", point.VerificationStatus, point.VerifiedAt)
+				}
+			},
+		},
+		{
+			name: "mailing address removed",
+			change: func(s *customerService) error {
+				return s.DeleteAddress(context.Background(), jane.ID, mailing.ID)
+			},
+			check: func(t *testing.T, repo *contactRepository) {
+				if len(repo.addresses) != 1 || repo.addresses[0].ID != residential.ID {
+					t.Errorf("addresses left %+v, want only the residential one", repo.addresses)
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &contactRepository{
+				stubRepository: stubRepository{customers: []models.Customer{jane}},
+				addresses:      []models.CustomerAddress{residential, mailing},
+				points:         []models.ContactPoint{personalEmail, workEmail, mobile},
+			}
+			s := &customerService{repo: repo, outbox: discard{}, audit: discard{}, tx: discard{}}
+
+			err := tt.change(s)
+			switch {
+			case tt.field != "":
+				var verr *models.ValidationError
+				if !errors.As(err, &verr) || len(verr.Fields) != 1 || verr.Fields[0].Field != tt.field {
+					t.Errorf("error = %v, want a validation error on %s", err, tt.field)
+				}
+			case tt.wantErr != nil:
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("error = %v, want %v", err, tt.wantErr)
+				}
+			case err != nil:
+				t.Fatalf("error = %v", err)
+			default:
+				tt.check(t, repo)
+			}
+			if tt.check == nil && (len(repo.addresses) != 2 || len(repo.points) != 3) {
+				t.Errorf("rejected change left %d addresses and %d contact points", len(repo.addresses), len(repo.points))
+			}
+		})
+	}
+}
@@ -0,0 +1,160 @@
+package service
+
+import (
+	"context"
+	"customer-service/internal/audit"
+	"customer-service/internal/customer/models"
+	"customer-service/internal/encryption"
+	"customer-service/internal/screening/matching"
+	"sort"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// duplicateCandidateLimit bounds how many customers are scored per check
+	duplicateCandidateLimit = 200
+	// duplicateThreshold is the lowest score reported as a likely duplicate
+	duplicateThreshold = 0.75
+	// unknownFieldScore stands in for a field missing from either customer, so a
+	// matching name alone never makes a duplicate
+	unknownFieldScore = 0.5
+)
+
+// duplicateWeights is how much each compared field contributes to the score
+var duplicateWeights = struct {
+	name, phone, dateOfBirth, address float64
+}{name: 0.4, phone: 0.25, dateOfBirth: 0.2, address: 0.15}
+
+// FindDuplicates returns the customers likely to be the same person as the given one
+func (s *customerService) FindDuplicates(ctx context.Context, id uuid.UUID) (*models.DuplicateListResponse, error) {
+	customer, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, s.redirectMerged(ctx, id, err)
+	}
+
+	candidates, err := s.repo.ListDuplicateCandidates(ctx, customer, duplicateCandidateLimit)
+	if err != nil {
+		return nil, err
+	}
+
+	duplicates := make([]models.DuplicateCandidate, 0)
+	for i := range candidates {
+		score, scores := scoreDuplicate(customer, &candidates[i])
+		if score < duplicateThreshold {
+			continue
+		}
+		duplicates = append(duplicates, models.DuplicateCandidate{
+			Customer: candidates[i].ToResponse(),
+			Score:    score,
+			Scores:   scores,
+		})
+	}
+	sort.SliceStable(duplicates, func(i, j int) bool {
+		return duplicates[i].Score > duplicates[j].Score
+	})
+
+	viewed := []uuid.UUID{customer.ID}
+	for _, duplicate := range duplicates {
+		viewed = append(viewed, duplicate.Customer.ID)
+	}
+	if err := s.audit.RecordAccess(ctx, audit.ActionCustomerDuplicates, viewed); err != nil {
+		return nil, err
+	}
+
+	return &models.DuplicateListResponse{
+		CustomerID: customer.ID,
+		Candidates: duplicates,
+	}, nil
+}
+
+// scoreDuplicate compares two customers on name, phone, date of birth and address and
+// returns the weighted score together with the score of each field. Organizations are
+// compared on their legal name and have no date of birth.
+func scoreDuplicate(a, b *models.Customer) (float64, models.DuplicateScores) {
+	scores := models.DuplicateScores{
+		Name:        roundScore(matching.NameSimilarity(matching.Tokens(a.Name()), matching.Tokens(b.Name()))),
+		Phone:       comparePhone(a.Phone, b.Phone),
+		DateOfBirth: compareDateOfBirth(a, b),
+		Address:     compareAddress(a.Address, b.Address),
+	}
+
+	score := duplicateWeights.name*scores.Name +
+		duplicateWeights.phone*orUnknown(scores.Phone) +
+		duplicateWeights.dateOfBirth*orUnknown(scores.DateOfBirth) +
+		duplicateWeights.address*orUnknown(scores.Address)
+	return roundScore(score), scores
+}
+
+// comparePhone scores two phone numbers as equal or not once formatting is removed
+func comparePhone(a, b string) *float64 {
+	a, b = encryption.NormalizePhone(a), encryption.NormalizePhone(b)
+	if a == "" || b == "" {
+		return nil
+	}
+	if a == b {
+		return fieldScore(1)
+	}
+	return fieldScore(0)
+}
+
+// compareDateOfBirth scores an exact date as 1 and a date with the day and month
+// swapped, a common entry mistake, as 0.5
+func compareDateOfBirth(a, b *models.Customer) *float64 {
+	if a.DateOfBirth == nil || b.DateOfBirth == nil {
+		return nil
+	}
+	x, y := a.DateOfBirth.UTC(), b.DateOfBirth.UTC()
+	switch {
+	case x.Year() == y.Year() && x.YearDay() == y.YearDay():
+		return fieldScore(1)
+	case x.Year() == y.Year() && int(x.Month()) == y.Day() && x.Day() == int(y.Month()):
+		return fieldScore(0.5)
+	default:
+		return fieldScore(0)
+	}
+}
+
+// compareAddress scores two addresses on their postal code and street. Addresses in
+// different countries never match.
+func compareAddress(a, b models.Address) *float64 {
+	postalA, postalB := strings.ReplaceAll(matching.Normalize(a.PostalCode), " ", ""), strings.ReplaceAll(matching.Normalize(b.PostalCode), " ", "")
+	streetA, streetB := matching.Normalize(a.Street), matching.Normalize(b.Street)
+	if (postalA == "" || postalB == "") && (streetA == "" || streetB == "") {
+		return nil
+	}
+
+	countryA, countryB := matching.Normalize(a.Country), matching.Normalize(b.Country)
+	if countryA != "" && countryB != "" && countryA != countryB {
+		return fieldScore(0)
+	}
+
+	score := 0.0
+	if postalA != "" && postalA == postalB {
+		score += 0.5
+	}
+	if streetA != "" && streetB != "" {
+		score += 0.5 * matching.JaroWinkler(streetA, streetB)
+	}
+	return fieldScore(score)
+}
+
+// fieldScore returns a pointer to a rounded score
+func fieldScore(score float64) *float64 {
+	rounded := roundScore(score)
+	return &rounded
+}
+
+// orUnknown returns the score of a compared field, or the neutral score if it was missing
+func orUnknown(score *float64) float64 {
+	if score == nil {
+		return unknownFieldScore
+	}
+	return *score
+}
+
+// roundScore keeps scores to four decimal places
+func roundScore(score float64) float64 {
+	return float64(int(score*10000+0.5)) / 10000
+}
@@ -0,0 +1,115 @@
+package service
+
+import (
+	"customer-service/internal/customer/models"
+	"testing"
+	"time"
+)
+
+func TestScoreDuplicate(t *testing.T) {
+	date := func(value string) *time.Time {
+		d, _ := time.Parse("2006-01-02", value)
+		return &d
+	}
+	address := models.Address{Street: "12 Main Street", City: "London", PostalCode: "SW1A 1AA", Country: "GB"}
+	jane := models.Customer{
+		Type: models.CustomerTypeIndividual, FirstName: "Jane", LastName: "Doe",
+		Phone: "+15550109999", DateOfBirth: date("1985-03-07"), Address: address,
+	}
+	acme := models.Customer{
+		Type: models.CustomerTypeOrganization, LegalName: "Acme Trading Ltd",
+		Phone: "+442079460000", IncorporationDate: date("2001-05-01"), Address: address,
+	}
+
+	// with returns a copy of base changed by fn
+	with := func(base models.Customer, fn func(c *models.Customer)) models.Customer {
+		fn(&base)
+		return base
+	}
+
+	tests := []struct {
+		name      string
+		a, b      models.Customer
+		want      float64
+		wantPhone bool
+		wantDOB   bool
+		wantAddr  bool
+	}{
+		{
+			name: "same customer",
+			a:    jane, b: jane,
+			want: 1, wantPhone: true, wantDOB: true, wantAddr: true,
+		},
+		{
+			name: "formatting differences",
+			a:    jane,
+			b: with(jane, func(c *models.Customer) {
+				c.FirstName, c.LastName = "JANE", "doe"
+				c.Phone = "+1 (555) 010-9999"
+				c.Address.PostalCode, c.Address.Street, c.Address.Country = "sw1a1aa", "12 main street", "gb"
+			}),
+			want: 1, wantPhone: true, wantDOB: true, wantAddr: true,
+		},
+		{
+			name: "name in reverse order",
+			a:    jane, b: with(jane, func(c *models.Customer) { c.FirstName, c.LastName = "Doe", "Jane" }),
+			want: 1, wantPhone: true, wantDOB: true, wantAddr: true,
+		},
+		{
+			name: "day and month swapped",
+			a:    jane, b: with(jane, func(c *models.Customer) { c.DateOfBirth = date("1985-07-03") }),
+			want: 0.9, wantPhone: true, wantDOB: true, wantAddr: true,
+		},
+		{
+			name: "other phone number",
+			a:    jane, b: with(jane, func(c *models.Customer) { c.Phone = "+15550108888" }),
+			want: 0.75, wantPhone: true, wantDOB: true, wantAddr: true,
+		},
+		{
+			name: "same address in another country",
+			a:    jane, b: with(jane, func(c *models.Customer) { c.Address.Country = "IE" }),
+			want: 0.85, wantPhone: true, wantDOB: true, wantAddr: true,
+		},
+		{
+			name: "only the postal code in common",
+			a:    jane, b: with(jane, func(c *models.Customer) { c.Address.Street = "" }),
+			want: 0.925, wantPhone: true, wantDOB: true, wantAddr: true,
+		},
+		{
+			name: "name alone",
+			a:    jane,
+			b:    models.Customer{Type: models.CustomerTypeIndividual, FirstName: "Jane", LastName: "Doe"},
+			want: 0.7,
+		},
+		{
+			name: "someone else",
+			a:    jane,
+			b: models.Customer{
+				Type: models.CustomerTypeIndividual, FirstName: "Oliver", LastName: "Quinn",
+				Phone: "+15550101234", DateOfBirth: date("1990-11-21"),
+			},
+			want: 0.3194, wantPhone: true, wantDOB: true,
+		},
+		{
+			name: "organization compared on its legal name",
+			a:    acme, b: with(acme, func(c *models.Customer) { c.LegalName = "ACME Trading Ltd." }),
+			want: 0.9, wantPhone: true, wantAddr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, scores := scoreDuplicate(&tt.a, &tt.b)
+			if got != tt.want {
+				t.Errorf("scoreDuplicate() = %v, want %v (scores %+v)", got, tt.want, scores)
+			}
+			if reverse, _ := scoreDuplicate(&tt.b, &tt.a); reverse != got {
+				t.Errorf("scoreDuplicate() the other way round = %v, want %v", reverse, got)
+			}
+			if (scores.Phone != nil) != tt.wantPhone || (scores.DateOfBirth != nil) != tt.wantDOB || (scores.Address != nil) != tt.wantAddr {
+				t.Errorf("compared phone %v, date of birth %v, address %v; want %v, %v, %v",
+					scores.Phone != nil, scores.DateOfBirth != nil, scores.Address != nil, tt.wantPhone, tt.wantDOB, tt.wantAddr)
+			}
+		})
+	}
+}
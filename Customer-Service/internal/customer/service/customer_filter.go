@@ -0,0 +1,235 @@
+package service
+
+import (
+	"customer-service/internal/customer/models"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Limits on listing filters, keeping the queries they build small
+const (
+	maxFilterValues = 50
+	maxAge          = 150
+)
+
+// dateLayout is the layout of the date filters
+const dateLayout = "2006-01-02"
+
+// parseFilter validates the filter and sort parameters of a listing, returning the
+// filter and the order they describe. Fields are only ever taken from
+// models.SortFields and values are passed to the database as parameters, so nothing
+// a client sends ends up in the SQL itself.
+func parseFilter(f models.CustomerFilter) (models.ListFilter, []models.SortOrder, error) {
+	verr := &models.ValidationError{}
+	var filter models.ListFilter
+
+	for _, status := range filterValues(verr, "status", f.Status, true) {
+		switch models.CustomerStatus(status) {
+		case models.CustomerStatusActive, models.CustomerStatusInactive,
+			models.CustomerStatusSuspended, models.CustomerStatusClosed:
+			filter.Statuses = append(filter.Statuses, models.CustomerStatus(status))
+		default:
+			verr.Add("status", fmt.Sprintf("unknown status %q", status))
+		}
+	}
+	for _, customerType := range filterValues(verr, "type", f.Type, true) {
+		switch models.CustomerType(customerType) {
+		case models.CustomerTypeIndividual, models.CustomerTypeOrganization:
+			filter.Types = append(filter.Types, models.CustomerType(customerType))
+		default:
+			verr.Add("type", fmt.Sprintf("unknown type %q", customerType))
+		}
+	}
+	filter.Countries = filterValues(verr, "country", f.Country, false)
+	filter.Cities = filterValues(verr, "city", f.City, false)
+	filter.States = filterValues(verr, "state", f.State, false)
+
+	filter.CreatedFrom, filter.CreatedBefore = timeRange(verr, "created", f.CreatedFrom, f.CreatedTo)
+	filter.UpdatedFrom, filter.UpdatedBefore = timeRange(verr, "updated", f.UpdatedFrom, f.UpdatedTo)
+	filter.BornFrom, filter.BornTo = birthRange(verr, f, today())
+
+	order, err := parseSort(f.Sort)
+	if err != nil {
+		verr.Add("sort", err.Error())
+	}
+
+	if verr.HasErrors() {
+		return filter, nil, verr
+	}
+	return filter, order, nil
+}
+
+// filterValues returns the non-empty values of a list filter, trimmed. Values given
+// in one parameter are split on commas when commas is set.
+func filterValues(verr *models.ValidationError, field string, params []string, commas bool) []string {
+	var values []string
+	for _, param := range params {
+		parts := []string{param}
+		if commas {
+			parts = strings.Split(param, ",")
+		}
+		for _, part := range parts {
+			if part = strings.TrimSpace(part); part != "" && !slices.Contains(values, part) {
+				values = append(values, part)
+			}
+		}
+	}
+	if len(values) > maxFilterValues {
+		verr.Add(field, fmt.Sprintf("at most %d values are allowed", maxFilterValues))
+		return nil
+	}
+	return values
+}
+
+// timeRange parses the inclusive bounds of a created or updated range into a start
+// and an exclusive end. A date bound covers its whole day, in UTC.
+func timeRange(verr *models.ValidationError, prefix, from, to string) (*time.Time, *time.Time) {
+	start, ok := parseBound(verr, prefix+"_from", from)
+	if !ok {
+		return nil, nil
+	}
+	end, ok := parseBound(verr, prefix+"_to", to)
+	if !ok {
+		return nil, nil
+	}
+
+	var before *time.Time
+	if end != nil {
+		next := end.Add(time.Microsecond) // timestamps are stored to the microsecond
+		if _, err := time.Parse(dateLayout, strings.TrimSpace(to)); err == nil {
+			next = end.AddDate(0, 0, 1)
+		}
+		before = &next
+	}
+	if start != nil && before != nil && !start.Before(*before) {
+		verr.Add(prefix+"_to", "must not be before "+prefix+"_from")
+	}
+	return start, before
+}
+
+// parseBound parses a date or an RFC 3339 timestamp, reporting false if it is invalid
+func parseBound(verr *models.ValidationError, field, value string) (*time.Time, bool) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return nil, true
+	}
+	if t, err := time.Parse(dateLayout, value); err == nil {
+		return &t, true
+	}
+	t, err := time.Parse(time.RFC3339Nano, value)
+	if err != nil {
+		verr.Add(field, "must be a date (YYYY-MM-DD) or an RFC 3339 timestamp")
+		return nil, false
+	}
+	t = t.UTC().Truncate(time.Microsecond)
+	return &t, true
+}
+
+// birthRange returns the days the date of birth of matching customers falls between,
+// inclusive, combining the born_from and born_to dates with the min_age and max_age
+// ages as of the day on. A customer is min_age once their birthday has come, and stays
+// max_age until the day before the next one.
+func birthRange(verr *models.ValidationError, f models.CustomerFilter, on time.Time) (*time.Time, *time.Time) {
+	from := parseDate(verr, "born_from", f.BornFrom)
+	to := parseDate(verr, "born_to", f.BornTo)
+	if from != nil && to != nil && from.After(*to) {
+		verr.Add("born_to", "must not be before born_from")
+	}
+
+	if f.MinAge != nil && (*f.MinAge < 0 || *f.MinAge > maxAge) ||
+		f.MaxAge != nil && (*f.MaxAge < 0 || *f.MaxAge > maxAge) {
+		verr.Add("age", fmt.Sprintf("ages must be between 0 and %d", maxAge))
+		return from, to
+	}
+	if f.MinAge != nil && f.MaxAge != nil && *f.MinAge > *f.MaxAge {
+		verr.Add("max_age", "must not be less than min_age")
+		return from, to
+	}
+
+	if f.MinAge != nil {
+		latest := yearsBefore(on, *f.MinAge)
+		if to == nil || latest.Before(*to) {
+			to = &latest
+		}
+	}
+	if f.MaxAge != nil {
+		earliest := yearsBefore(on, *f.MaxAge+1).AddDate(0, 0, 1)
+		if from == nil || earliest.After(*from) {
+			from = &earliest
+		}
+	}
+	return from, to
+}
+
+// yearsBefore returns the day n years before day. February 29 goes back to February 28
+// in years without one rather than rolling over into March, since a customer born on
+// March 1 has not had their birthday by February 29.
+func yearsBefore(day time.Time, n int) time.Time {
+	t := day.AddDate(-n, 0, 0)
+	if t.Day() != day.Day() {
+		t = t.AddDate(0, 0, -t.Day())
+	}
+	return t
+}
+
+// parseDate parses an optional date
+func parseDate(verr *models.ValidationError, field, value string) *time.Time {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return nil
+	}
+	t, err := time.Parse(dateLayout, value)
+	if err != nil {
+		verr.Add(field, "must be a date (YYYY-MM-DD)")
+		return nil
+	}
+	return &t
+}
+
+// parseSort parses a sort parameter: field names from models.SortFields separated by
+// commas, each descending when prefixed with a minus sign. An empty parameter gives
+// no order.
+func parseSort(param string) ([]models.SortOrder, error) {
+	if strings.TrimSpace(param) == "" {
+		return nil, nil
+	}
+
+	var order []models.SortOrder
+	for _, part := range strings.Split(param, ",") {
+		part = strings.TrimSpace(part)
+		field, desc := strings.CutPrefix(part, "-")
+		if _, ok := models.SortFields[field]; !ok {
+			return nil, fmt.Errorf("unknown sort field %q, sortable fields are %s", field, strings.Join(sortableFields(), ", "))
+		}
+		if slices.ContainsFunc(order, func(o models.SortOrder) bool { return o.Field == field }) {
+			return nil, fmt.Errorf("sort field %q is repeated", field)
+		}
+		order = append(order, models.SortOrder{Field: field, Desc: desc})
+	}
+	return order, nil
+}
+
+// sortableFields returns the names of models.SortFields in alphabetical order
+func sortableFields() []string {
+	fields := make([]string, 0, len(models.SortFields))
+	for field := range models.SortFields {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+	return fields
+}
+
+// formatSort returns the sort parameter describing an order, as parseSort reads it
+func formatSort(order []models.SortOrder) string {
+	parts := make([]string, len(order))
+	for i, o := range order {
+		parts[i] = o.Field
+		if o.Desc {
+			parts[i] = "-" + o.Field
+		}
+	}
+	return strings.Join(parts, ",")
+}
@@ -0,0 +1,169 @@
+package service
+
+import (
+	"customer-service/internal/customer/models"
+	"fmt"
+	"testing"
+	"time"
+)
+
+// date returns midnight UTC of a day
+func date(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+// ageOn returns the age on a day of a customer born on another, counting a birthday
+// on February 29 as reached on March 1 in years without one
+func ageOn(born, on time.Time) int {
+	age := on.Year() - born.Year()
+	if on.Month() < born.Month() || on.Month() == born.Month() && on.Day() < born.Day() {
+		age--
+	}
+	return age
+}
+
+func TestBirthRangeAges(t *testing.T) {
+	days := []time.Time{
+		date(2026, time.June, 15),
+		date(2026, time.January, 1),
+		date(2026, time.December, 31),
+		date(2028, time.February, 29),
+		date(2027, time.February, 28),
+		date(2027, time.March, 1),
+	}
+	ages := []int{0, 1, 17, 18, 30, 65, maxAge}
+
+	for _, on := range days {
+		for _, age := range ages {
+			t.Run(fmt.Sprintf("%s age %d", on.Format(dateLayout), age), func(t *testing.T) {
+				verr := &models.ValidationError{}
+				from, to := birthRange(verr, models.CustomerFilter{MinAge: &age, MaxAge: &age}, on)
+				if verr.HasErrors() {
+					t.Fatalf("birthRange() error = %v", verr)
+				}
+
+				if got := ageOn(*to, on); got != age {
+					t.Errorf("age %d: latest birth %s gives age %d", age, to.Format(dateLayout), got)
+				}
+				if next := to.AddDate(0, 0, 1); ageOn(next, on) >= age {
+					t.Errorf("age %d: birth %s after the latest is also old enough", age, next.Format(dateLayout))
+				}
+				if got := ageOn(*from, on); got != age {
+					t.Errorf("age %d: earliest birth %s gives age %d", age, from.Format(dateLayout), got)
+				}
+				if prev := from.AddDate(0, 0, -1); ageOn(prev, on) <= age {
+					t.Errorf("age %d: birth %s before the earliest is also young enough", age, prev.Format(dateLayout))
+				}
+			})
+		}
+	}
+}
+
+func TestBirthRange(t *testing.T) {
+	on := date(2026, time.June, 15)
+	ptr := func(n int) *int { return &n }
+
+	tests := []struct {
+		name     string
+		filter   models.CustomerFilter
+		from, to time.Time
+		field    string
+	}{
+		{
+			name: "no bounds",
+		},
+		{
+			name:   "dates only",
+			filter: models.CustomerFilter{BornFrom: "1980-01-01", BornTo: " 1989-12-31 "},
+			from:   date(1980, time.January, 1),
+			to:     date(1989, time.December, 31),
+		},
+		{
+			name:   "minimum age narrows a later date",
+			filter: models.CustomerFilter{BornTo: "2020-01-01", MinAge: ptr(18)},
+			to:     date(2008, time.June, 15),
+		},
+		{
+			name:   "earlier date narrows the minimum age",
+			filter: models.CustomerFilter{BornTo: "1990-01-01", MinAge: ptr(18)},
+			to:     date(1990, time.January, 1),
+		},
+		{
+			name:   "maximum age narrows an earlier date",
+			filter: models.CustomerFilter{BornFrom: "1900-01-01", MaxAge: ptr(30)},
+			from:   date(1995, time.June, 16),
+		},
+		{
+			name:   "later date narrows the maximum age",
+			filter: models.CustomerFilter{BornFrom: "2000-01-01", MaxAge: ptr(30)},
+			from:   date(2000, time.January, 1),
+		},
+		{
+			name:   "age range",
+			filter: models.CustomerFilter{MinAge: ptr(18), MaxAge: ptr(25)},
+			from:   date(2000, time.June, 16),
+			to:     date(2008, time.June, 15),
+		},
+		{
+			name:   "invalid date",
+			filter: models.CustomerFilter{BornFrom: "15/06/1990"},
+			field:  "born_from",
+		},
+		{
+			name:   "timestamp instead of a date",
+			filter: models.CustomerFilter{BornTo: "1990-06-15T00:00:00Z"},
+			field:  "born_to",
+		},
+		{
+			name:   "dates reversed",
+			filter: models.CustomerFilter{BornFrom: "1990-01-02", BornTo: "1990-01-01"},
+			field:  "born_to",
+		},
+		{
+			name:   "negative age",
+			filter: models.CustomerFilter{MinAge: ptr(-1)},
+			field:  "age",
+		},
+		{
+			name:   "age over the limit",
+			filter: models.CustomerFilter{MaxAge: ptr(maxAge + 1)},
+			field:  "age",
+		},
+		{
+			name:   "ages reversed",
+			filter: models.CustomerFilter{MinAge: ptr(30), MaxAge: ptr(20)},
+			field:  "max_age",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			verr := &models.ValidationError{}
+			from, to := birthRange(verr, tt.filter, on)
+
+			if tt.field != "" {
+				if len(verr.Fields) != 1 || verr.Fields[0].Field != tt.field {
+					t.Errorf("birthRange() errors = %v, want one on %s", verr.Fields, tt.field)
+				}
+				return
+			}
+			if verr.HasErrors() {
+				t.Fatalf("birthRange() error = %v", verr)
+			}
+			if !sameDay(from, tt.from) {
+				t.Errorf("from = %v, want %v", from, tt.from)
+			}
+			if !sameDay(to, tt.to) {
+				t.Errorf("to = %v, want %v", to, tt.to)
+			}
+		})
+	}
+}
+
+// sameDay reports whether a bound is the day want, a zero want meaning no bound
+func sameDay(bound *time.Time, want time.Time) bool {
+	if bound == nil {
+		return want.IsZero()
+	}
+	return bound.Equal(want)
+}
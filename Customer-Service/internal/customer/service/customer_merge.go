@@ -0,0 +1,163 @@
+package service
+
+import (
+	"context"
+	"customer-service/internal/audit"
+	"customer-service/internal/customer/models"
+	"customer-service/internal/events"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/google/uuid"
+)
+
+// MergeCustomers merges a duplicate record into the survivor. Survivor fields that
+// are empty are filled in from the duplicate; the duplicate is closed, deleted and
+// replaced by a tombstone that redirects lookups of its ID to the survivor. Records
+// kept under the duplicate's ID, such as KYC cases, stay where they are.
+func (s *customerService) MergeCustomers(ctx context.Context, survivorID uuid.UUID, req models.MergeRequest, expectedVersion int64, actor string) (*models.MergeResponse, error) {
+	if actor == "" {
+		return nil, models.NewValidationError("actor", "actor is required")
+	}
+	if req.DuplicateID == survivorID {
+		return nil, models.NewValidationError("duplicate_id", "must differ from the surviving customer")
+	}
+
+	survivor, err := s.repo.GetByID(ctx, survivorID)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkVersion(survivor, expectedVersion); err != nil {
+		return nil, err
+	}
+	if survivor.Status == models.CustomerStatusClosed {
+		return nil, fmt.Errorf("%w: cannot merge into a closed customer", models.ErrInvalidStatusTransition)
+	}
+	duplicate, err := s.repo.GetByID(ctx, req.DuplicateID)
+	if err != nil {
+		return nil, err
+	}
+	if duplicate.Type != survivor.Type {
+		return nil, models.NewValidationError("duplicate_id", "must be the same type of customer")
+	}
+
+	current := requestFromCustomer(survivor)
+	merged := fillFromDuplicate(current, duplicate)
+	columns, changedFields := diffRequests(current, merged)
+
+	before := *survivor
+	applyRequest(survivor, merged)
+	history := &models.CustomerStatusHistory{
+		CustomerID: duplicate.ID,
+		FromStatus: duplicate.Status,
+		ToStatus:   models.CustomerStatusClosed,
+		ReasonCode: models.StatusReasonMerged,
+		Note:       "merged into " + survivor.ID.String(),
+		Actor:      actor,
+	}
+
+	err = s.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		if len(columns) > 0 {
+			if err := s.repo.UpdateFields(ctx, survivor, columns); err != nil {
+				return err
+			}
+			if err := s.screenIfChanged(ctx, survivor, changedFields); err != nil {
+				return err
+			}
+			if err := s.rateIfChanged(ctx, survivor, changedFields); err != nil {
+				return err
+			}
+			if err := s.syncContacts(ctx, survivor); err != nil {
+				return err
+			}
+		}
+
+		if err := s.repo.ChangeStatus(ctx, duplicate, history); err != nil {
+			if errors.Is(err, models.ErrInvalidStatusTransition) {
+				return fmt.Errorf("%w: duplicate changed while merging", models.ErrVersionConflict)
+			}
+			return err
+		}
+		if err := s.repo.Delete(ctx, duplicate.ID, duplicate.Version); err != nil {
+			return err
+		}
+		if err := s.repo.CreateMerge(ctx, &models.CustomerMerge{
+			MergedID:      duplicate.ID,
+			SurvivorID:    survivor.ID,
+			ChangedFields: changedFields,
+			Reason:        req.Reason,
+			MergedBy:      actor,
+		}); err != nil {
+			return err
+		}
+
+		survivorChanges := append(auditChanges(&before, survivor), audit.Change{Field: "merged_customer_id", Before: nil, After: duplicate.ID})
+		if err := s.audit.Record(ctx, audit.ActionCustomerMerge, survivor.ID, survivorChanges); err != nil {
+			return err
+		}
+		duplicateChanges := []audit.Change{
+			{Field: "status", Before: history.FromStatus, After: history.ToStatus},
+			{Field: "merged_into", Before: nil, After: survivor.ID},
+		}
+		if err := s.audit.Record(ctx, audit.ActionCustomerMerge, duplicate.ID, duplicateChanges); err != nil {
+			return err
+		}
+
+		return s.emit(ctx, events.CustomerMerged, survivor.ID, models.CustomerMergedPayload{
+			MergedCustomerID: duplicate.ID,
+			Customer:         survivor.ToResponse(),
+			ChangedFields:    changedFields,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.MergeResponse{
+		Customer:      survivor.ToResponse(),
+		MergedID:      duplicate.ID,
+		ChangedFields: changedFields,
+	}, nil
+}
+
+// fillFromDuplicate returns the survivor's fields with the empty ones taken from the
+// duplicate. The address is taken as a whole, never mixed from both records, and the
+// products of both are kept. Registration details identify an organization and are
+// never taken from the duplicate.
+func fillFromDuplicate(survivor models.CustomerRequest, duplicate *models.Customer) models.CustomerRequest {
+	if survivor.Phone == "" {
+		survivor.Phone = duplicate.Phone
+	}
+	if survivor.DateOfBirth == nil {
+		survivor.DateOfBirth = duplicate.DateOfBirth
+	}
+	if survivor.Address == (models.Address{}) {
+		survivor.Address = duplicate.Address
+	}
+	if survivor.Occupation == "" {
+		survivor.Occupation = duplicate.Occupation
+	}
+	if survivor.IncorporationDate == nil {
+		survivor.IncorporationDate = duplicate.IncorporationDate
+	}
+	survivor.Products = append(slices.Clone(survivor.Products), duplicate.Products...)
+	normalizeRequest(&survivor)
+	return survivor
+}
+
+// redirectMerged turns a not found error for a merged customer into a MergedError
+// naming the survivor
+func (s *customerService) redirectMerged(ctx context.Context, id uuid.UUID, err error) error {
+	if !errors.Is(err, models.ErrCustomerNotFound) {
+		return err
+	}
+	merge, mergeErr := s.repo.GetMerge(ctx, id)
+	if mergeErr != nil {
+		if errors.Is(mergeErr, models.ErrCustomerNotFound) {
+			return err
+		}
+		return mergeErr
+	}
+	return &models.MergedError{SurvivorID: merge.SurvivorID}
+}
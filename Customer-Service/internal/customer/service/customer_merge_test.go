@@ -0,0 +1,191 @@
+package service
+
+import (
+	"context"
+	"customer-service/internal/customer/models"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestFillFromDuplicate(t *testing.T) {
+	born := time.Date(1985, 3, 7, 0, 0, 0, 0, time.UTC)
+	incorporated := time.Date(2001, 5, 1, 0, 0, 0, 0, time.UTC)
+	home := models.Address{Street: "12 Main Street", City: "London", PostalCode: "SW1A 1AA", Country: "GB"}
+	work := models.Address{City: "Leeds", Country: "GB"}
+
+	tests := []struct {
+		name      string
+		survivor  models.CustomerRequest
+		duplicate models.Customer
+		want      models.CustomerRequest
+	}{
+		{
+			name:     "empty fields filled in",
+			survivor: models.CustomerRequest{FirstName: "Jane", LastName: "Doe", Email: "jane@example.com"},
+			duplicate: models.Customer{
+				FirstName: "Janet", Email: "janet@example.com", Phone: "+15550109999",
+				DateOfBirth: &born, Address: home, Occupation: "Engineer",
+			},
+			want: models.CustomerRequest{
+				FirstName: "Jane", LastName: "Doe", Email: "jane@example.com", Phone: "+15550109999",
+				DateOfBirth: &born, Address: home, Occupation: "Engineer", Products: []string{},
+			},
+		},
+		{
+			name: "survivor fields kept",
+			survivor: models.CustomerRequest{
+				Phone: "+15550101111", DateOfBirth: &born, Address: work, Occupation: "Teacher",
+			},
+			duplicate: models.Customer{Phone: "+15550109999", DateOfBirth: &incorporated, Address: home, Occupation: "Engineer"},
+			want: models.CustomerRequest{
+				Phone: "+15550101111", DateOfBirth: &born, Address: work, Occupation: "Teacher", Products: []string{},
+			},
+		},
+		{
+			name:      "partial address not mixed with the duplicate's",
+			survivor:  models.CustomerRequest{Address: models.Address{Country: "GB"}},
+			duplicate: models.Customer{Address: home},
+			want:      models.CustomerRequest{Address: models.Address{Country: "GB"}, Products: []string{}},
+		},
+		{
+			name:      "products of both kept once",
+			survivor:  models.CustomerRequest{Products: []string{"savings", "checking"}},
+			duplicate: models.Customer{Products: []string{"Mortgage", "checking "}},
+			want:      models.CustomerRequest{Products: []string{"checking", "mortgage", "savings"}},
+		},
+		{
+			name: "registration details never taken",
+			survivor: models.CustomerRequest{
+				Type: models.CustomerTypeOrganization, LegalName: "Acme Trading Ltd",
+			},
+			duplicate: models.Customer{
+				Type: models.CustomerTypeOrganization, LegalName: "Acme Ltd",
+				RegistrationNumber: "01234567", Jurisdiction: "GB", IncorporationDate: &incorporated,
+			},
+			want: models.CustomerRequest{
+				Type: models.CustomerTypeOrganization, LegalName: "Acme Trading Ltd",
+				IncorporationDate: &incorporated, Products: []string{},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			survivorProducts := append([]string(nil), tt.survivor.Products...)
+			got := fillFromDuplicate(tt.survivor, &tt.duplicate)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("fillFromDuplicate() = %+v, want %+v", got, tt.want)
+			}
+			if !reflect.DeepEqual(tt.survivor.Products, survivorProducts) {
+				t.Errorf("survivor products changed to %v", tt.survivor.Products)
+			}
+		})
+	}
+}
+
+func TestMergeCustomersRejects(t *testing.T) {
+	survivor := models.Customer{ID: uuid.New(), Type: models.CustomerTypeIndividual, Status: models.CustomerStatusActive, Version: 3}
+	closed := models.Customer{ID: uuid.New(), Type: models.CustomerTypeIndividual, Status: models.CustomerStatusClosed, Version: 1}
+	duplicate := models.Customer{ID: uuid.New(), Type: models.CustomerTypeIndividual, Status: models.CustomerStatusActive, Version: 1}
+	organization := models.Customer{ID: uuid.New(), Type: models.CustomerTypeOrganization, Status: models.CustomerStatusActive, Version: 1}
+	s := &customerService{
+		repo: &stubRepository{customers: []models.Customer{survivor, closed, duplicate, organization}},
+	}
+
+	tests := []struct {
+		name        string
+		survivorID  uuid.UUID
+		duplicateID uuid.UUID
+		version     int64
+		actor       string
+		wantErr     error
+		field       string
+	}{
+		{name: "missing actor", survivorID: survivor.ID, duplicateID: duplicate.ID, field: "actor"},
+		{name: "merge into itself", survivorID: survivor.ID, duplicateID: survivor.ID, actor: "user-1", field: "duplicate_id"},
+		{name: "stale survivor version", survivorID: survivor.ID, duplicateID: duplicate.ID, version: 2, actor: "user-1", wantErr: models.ErrVersionConflict},
+		{name: "closed survivor", survivorID: closed.ID, duplicateID: duplicate.ID, actor: "user-1", wantErr: models.ErrInvalidStatusTransition},
+		{name: "unknown survivor", survivorID: uuid.New(), duplicateID: duplicate.ID, actor: "user-1", wantErr: models.ErrCustomerNotFound},
+		{name: "unknown duplicate", survivorID: survivor.ID, duplicateID: uuid.New(), actor: "user-1", wantErr: models.ErrCustomerNotFound},
+		{name: "different type of customer", survivorID: survivor.ID, duplicateID: organization.ID, version: 3, actor: "user-1", field: "duplicate_id"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := s.MergeCustomers(context.Background(), tt.survivorID, models.MergeRequest{DuplicateID: tt.duplicateID}, tt.version, tt.actor)
+			if tt.field != "" {
+				var verr *models.ValidationError
+				if !errors.As(err, &verr) || len(verr.Fields) != 1 || verr.Fields[0].Field != tt.field {
+					t.Errorf("MergeCustomers() error = %v, want a validation error on %s", err, tt.field)
+				}
+				return
+			}
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("MergeCustomers() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// mergeRepository serves customers and the tombstones of merged ones
+type mergeRepository struct {
+	stubRepository
+	merges []models.CustomerMerge
+	err    error
+}
+
+func (r *mergeRepository) GetMerge(ctx context.Context, mergedID uuid.UUID) (*models.CustomerMerge, error) {
+	if r.err != nil {
+		return nil, r.err
+	}
+	for _, merge := range r.merges {
+		if merge.MergedID == mergedID {
+			return &merge, nil
+		}
+	}
+	return nil, models.ErrCustomerNotFound
+}
+
+func TestRedirectMerged(t *testing.T) {
+	merged, survivor := uuid.New(), uuid.New()
+	unavailable := errors.New("connection refused")
+
+	tests := []struct {
+		name         string
+		id           uuid.UUID
+		err          error
+		repoErr      error
+		wantErr      error
+		wantSurvivor uuid.UUID
+	}{
+		{name: "merged customer", id: merged, err: models.ErrCustomerNotFound, wantSurvivor: survivor},
+		{name: "customer never merged", id: uuid.New(), err: models.ErrCustomerNotFound, wantErr: models.ErrCustomerNotFound},
+		{name: "other error", id: merged, err: unavailable, wantErr: unavailable},
+		{name: "tombstone lookup failing", id: merged, err: models.ErrCustomerNotFound, repoErr: unavailable, wantErr: unavailable},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &customerService{repo: &mergeRepository{
+				merges: []models.CustomerMerge{{MergedID: merged, SurvivorID: survivor}},
+				err:    tt.repoErr,
+			}}
+			err := s.redirectMerged(context.Background(), tt.id, tt.err)
+
+			var redirect *models.MergedError
+			if tt.wantSurvivor != uuid.Nil {
+				if !errors.As(err, &redirect) || redirect.SurvivorID != tt.wantSurvivor {
+					t.Errorf("redirectMerged() = %v, want a redirect to %s", err, tt.wantSurvivor)
+				}
+				return
+			}
+			if !errors.Is(err, tt.wantErr) || errors.As(err, &redirect) {
+				t.Errorf("redirectMerged() = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
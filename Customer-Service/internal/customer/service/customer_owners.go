@@ -0,0 +1,160 @@
+package service
+
+import (
+	"context"
+	"customer-service/internal/audit"
+	"customer-service/internal/customer/models"
+	"customer-service/internal/events"
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/google/uuid"
+)
+
+// GetBeneficialOwners returns the declared owners and controllers of an organization
+func (s *customerService) GetBeneficialOwners(ctx context.Context, id uuid.UUID) (*models.BeneficialOwnersResponse, error) {
+	organization, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, s.redirectMerged(ctx, id, err)
+	}
+	if organization.Type != models.CustomerTypeOrganization {
+		return nil, models.ErrNotOrganization
+	}
+
+	owners, err := s.repo.ListBeneficialOwners(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.audit.RecordAccess(ctx, audit.ActionCustomerOwnersView, []uuid.UUID{id}); err != nil {
+		return nil, err
+	}
+	return ownersResponse(id, owners), nil
+}
+
+// ReplaceBeneficialOwners replaces the owners and controllers declared for an
+// organization. Every owner must be an existing individual customer; owners hold a
+// share of more than zero, controllers none, and the shares together cannot exceed
+// 100 percent.
+func (s *customerService) ReplaceBeneficialOwners(ctx context.Context, id uuid.UUID, req models.BeneficialOwnersRequest) (*models.BeneficialOwnersResponse, error) {
+	if err := models.ValidateStruct(req); err != nil {
+		return nil, err
+	}
+
+	organization, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, s.redirectMerged(ctx, id, err)
+	}
+	if organization.Type != models.CustomerTypeOrganization {
+		return nil, models.ErrNotOrganization
+	}
+	if err := s.validateOwners(ctx, id, req.Owners); err != nil {
+		return nil, err
+	}
+
+	owners := make([]models.BeneficialOwner, len(req.Owners))
+	for i, owner := range req.Owners {
+		owners[i] = models.BeneficialOwner{
+			OrganizationID:      id,
+			OwnerID:             owner.CustomerID,
+			Role:                owner.Role,
+			OwnershipPercentage: owner.OwnershipPercentage,
+		}
+	}
+
+	var response *models.BeneficialOwnersResponse
+	err = s.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		previous, err := s.repo.ListBeneficialOwners(ctx, id)
+		if err != nil {
+			return err
+		}
+		if err := s.repo.ReplaceBeneficialOwners(ctx, id, owners); err != nil {
+			return err
+		}
+
+		changes := []audit.Change{{Field: "beneficial_owners", Before: previous, After: owners}}
+		if err := s.audit.Record(ctx, audit.ActionCustomerOwnersUpdate, id, changes); err != nil {
+			return err
+		}
+
+		response = ownersResponse(id, owners)
+		return s.emit(ctx, events.CustomerBeneficialOwnersChanged, id, models.CustomerBeneficialOwnersChangedPayload{
+			Owners:            response.Owners,
+			DeclaredOwnership: response.DeclaredOwnership,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return response, nil
+}
+
+// validateOwners checks a declaration of owners against each other and against the
+// customers they name
+func (s *customerService) validateOwners(ctx context.Context, organizationID uuid.UUID, owners []models.BeneficialOwnerRequest) error {
+	verr := &models.ValidationError{}
+	type declaration struct {
+		customerID uuid.UUID
+		role       models.OwnerRole
+	}
+	declared := make(map[declaration]bool, len(owners))
+	var total int64
+
+	for i, owner := range owners {
+		field := fmt.Sprintf("owners[%d]", i)
+		hundredths := math.Round(owner.OwnershipPercentage * 100)
+		switch {
+		case math.Abs(owner.OwnershipPercentage*100-hundredths) > 1e-6:
+			verr.Add(field+".ownership_percentage", "must have at most 2 decimal places")
+		case owner.Role == models.OwnerRoleOwner && hundredths == 0:
+			verr.Add(field+".ownership_percentage", "must be greater than 0 for an owner")
+		case owner.Role == models.OwnerRoleController && hundredths != 0:
+			verr.Add(field+".ownership_percentage", "must be 0 for a controller")
+		}
+		total += int64(hundredths)
+
+		key := declaration{customerID: owner.CustomerID, role: owner.Role}
+		if declared[key] {
+			verr.Add(field+".customer_id", "is already declared with role "+string(owner.Role))
+			continue
+		}
+		declared[key] = true
+
+		if owner.CustomerID == organizationID {
+			verr.Add(field+".customer_id", "cannot be the organization itself")
+			continue
+		}
+		customer, err := s.repo.GetByID(ctx, owner.CustomerID)
+		if errors.Is(err, models.ErrCustomerNotFound) {
+			verr.Add(field+".customer_id", "customer not found")
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if customer.Type != models.CustomerTypeIndividual {
+			verr.Add(field+".customer_id", "must be an individual customer")
+		}
+	}
+
+	if total > 100*100 {
+		verr.Add("owners", "ownership percentages must not add up to more than 100")
+	}
+	if verr.HasErrors() {
+		return verr
+	}
+	return nil
+}
+
+// ownersResponse lists the owners of an organization with the total share they hold
+func ownersResponse(organizationID uuid.UUID, owners []models.BeneficialOwner) *models.BeneficialOwnersResponse {
+	var hundredths int64
+	for _, owner := range owners {
+		hundredths += int64(math.Round(owner.OwnershipPercentage * 100))
+	}
+	return &models.BeneficialOwnersResponse{
+		OrganizationID:    organizationID,
+		Owners:            owners,
+		DeclaredOwnership: float64(hundredths) / 100,
+	}
+}
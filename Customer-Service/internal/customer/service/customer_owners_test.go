@@ -0,0 +1,154 @@
+package service
+
+import (
+	"context"
+	"customer-service/internal/customer/models"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestValidateOwners(t *testing.T) {
+	organization := models.Customer{ID: uuid.New(), Type: models.CustomerTypeOrganization}
+	subsidiary := models.Customer{ID: uuid.New(), Type: models.CustomerTypeOrganization}
+	alice := models.Customer{ID: uuid.New(), Type: models.CustomerTypeIndividual}
+	bob := models.Customer{ID: uuid.New(), Type: models.CustomerTypeIndividual}
+	carol := models.Customer{ID: uuid.New(), Type: models.CustomerTypeIndividual}
+	s := &customerService{
+		repo: &stubRepository{customers: []models.Customer{organization, subsidiary, alice, bob, carol}},
+	}
+
+	owner := func(customer models.Customer, percentage float64) models.BeneficialOwnerRequest {
+		return models.BeneficialOwnerRequest{CustomerID: customer.ID, Role: models.OwnerRoleOwner, OwnershipPercentage: percentage}
+	}
+	controller := func(customer models.Customer, percentage float64) models.BeneficialOwnerRequest {
+		return models.BeneficialOwnerRequest{CustomerID: customer.ID, Role: models.OwnerRoleController, OwnershipPercentage: percentage}
+	}
+
+	tests := []struct {
+		name   string
+		owners []models.BeneficialOwnerRequest
+		fields []string
+	}{
+		{
+			name: "no owners",
+		},
+		{
+			name:   "shares adding up to 100",
+			owners: []models.BeneficialOwnerRequest{owner(alice, 60), owner(bob, 40)},
+		},
+		{
+			name:   "shares adding up to 100 only in exact decimals",
+			owners: []models.BeneficialOwnerRequest{owner(alice, 70.1), owner(bob, 29.9)},
+		},
+		{
+			name:   "thirds",
+			owners: []models.BeneficialOwnerRequest{owner(alice, 33.33), owner(bob, 33.33), owner(carol, 33.34)},
+		},
+		{
+			name:   "shares under 100",
+			owners: []models.BeneficialOwnerRequest{owner(alice, 25)},
+		},
+		{
+			name:   "shares over 100 by a hundredth",
+			owners: []models.BeneficialOwnerRequest{owner(alice, 50.01), owner(bob, 50)},
+			fields: []string{"owners"},
+		},
+		{
+			name:   "controllers alongside a whole share",
+			owners: []models.BeneficialOwnerRequest{owner(alice, 100), controller(bob, 0), controller(alice, 0)},
+		},
+		{
+			name:   "owner without a share",
+			owners: []models.BeneficialOwnerRequest{owner(alice, 0)},
+			fields: []string{"owners[0].ownership_percentage"},
+		},
+		{
+			name:   "owner with a share that rounds to nothing",
+			owners: []models.BeneficialOwnerRequest{owner(alice, 0.001)},
+			fields: []string{"owners[0].ownership_percentage"},
+		},
+		{
+			name:   "controller with a share",
+			owners: []models.BeneficialOwnerRequest{owner(alice, 90), controller(bob, 10)},
+			fields: []string{"owners[1].ownership_percentage"},
+		},
+		{
+			name:   "share with three decimal places",
+			owners: []models.BeneficialOwnerRequest{owner(alice, 10.005)},
+			fields: []string{"owners[0].ownership_percentage"},
+		},
+		{
+			name:   "owner declared twice",
+			owners: []models.BeneficialOwnerRequest{owner(alice, 30), owner(bob, 30), owner(alice, 30)},
+			fields: []string{"owners[2].customer_id"},
+		},
+		{
+			name:   "repeated owner counting towards the total",
+			owners: []models.BeneficialOwnerRequest{owner(alice, 60), owner(alice, 60)},
+			fields: []string{"owners[1].customer_id", "owners"},
+		},
+		{
+			name:   "organization owning itself",
+			owners: []models.BeneficialOwnerRequest{owner(organization, 10)},
+			fields: []string{"owners[0].customer_id"},
+		},
+		{
+			name:   "unknown customer",
+			owners: []models.BeneficialOwnerRequest{owner(models.Customer{ID: uuid.New()}, 10)},
+			fields: []string{"owners[0].customer_id"},
+		},
+		{
+			name:   "organization as owner",
+			owners: []models.BeneficialOwnerRequest{owner(subsidiary, 10)},
+			fields: []string{"owners[0].customer_id"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := s.validateOwners(context.Background(), organization.ID, tt.owners)
+
+			var fields []string
+			var verr *models.ValidationError
+			if errors.As(err, &verr) {
+				for _, field := range verr.Fields {
+					fields = append(fields, field.Field)
+				}
+			} else if err != nil {
+				t.Fatalf("validateOwners() error = %v", err)
+			}
+			if !reflect.DeepEqual(fields, tt.fields) {
+				t.Errorf("validateOwners() errors on %v, want %v", fields, tt.fields)
+			}
+		})
+	}
+}
+
+func TestOwnersResponseTotal(t *testing.T) {
+	tests := []struct {
+		name        string
+		percentages []float64
+		want        float64
+	}{
+		{"none", nil, 0},
+		{"whole", []float64{100}, 100},
+		{"exact decimals", []float64{70.1, 29.9}, 100},
+		{"thirds", []float64{33.33, 33.33, 33.33}, 99.99},
+		{"controllers", []float64{12.5, 0, 0}, 12.5},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			owners := make([]models.BeneficialOwner, len(tt.percentages))
+			for i, percentage := range tt.percentages {
+				owners[i] = models.BeneficialOwner{OwnerID: uuid.New(), OwnershipPercentage: percentage}
+			}
+			if got := ownersResponse(uuid.New(), owners).DeclaredOwnership; got != tt.want {
+				t.Errorf("DeclaredOwnership = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,261 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"customer-service/internal/audit"
+	"customer-service/internal/customer/models"
+	"customer-service/internal/events"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	jsonpatch "github.com/evanphx/json-patch/v5"
+	"github.com/google/uuid"
+)
+
+// patchableField maps a field of the patch document to its database column
+type patchableField struct {
+	path   string
+	column string
+	value  func(req *models.CustomerRequest) any
+}
+
+// patchableFields lists every customer field a PATCH request may change
+var patchableFields = []patchableField{
+	{"first_name", "first_name", func(r *models.CustomerRequest) any { return r.FirstName }},
+	{"last_name", "last_name", func(r *models.CustomerRequest) any { return r.LastName }},
+	{"email", "email", func(r *models.CustomerRequest) any { return r.Email }},
+	{"phone", "phone", func(r *models.CustomerRequest) any { return r.Phone }},
+	{"date_of_birth", "date_of_birth", func(r *models.CustomerRequest) any { return r.DateOfBirth }},
+	{"address.street", "address_street", func(r *models.CustomerRequest) any { return r.Address.Street }},
+	{"address.city", "address_city", func(r *models.CustomerRequest) any { return r.Address.City }},
+	{"address.state", "address_state", func(r *models.CustomerRequest) any { return r.Address.State }},
+	{"address.postal_code", "address_postal_code", func(r *models.CustomerRequest) any { return r.Address.PostalCode }},
+	{"address.country", "address_country", func(r *models.CustomerRequest) any { return r.Address.Country }},
+	{"occupation", "occupation", func(r *models.CustomerRequest) any { return r.Occupation }},
+	{"products", "products", func(r *models.CustomerRequest) any { return r.Products }},
+	{"legal_name", "legal_name", func(r *models.CustomerRequest) any { return r.LegalName }},
+	{"registration_number", "registration_number", func(r *models.CustomerRequest) any { return r.RegistrationNumber }},
+	{"incorporation_date", "incorporation_date", func(r *models.CustomerRequest) any { return r.IncorporationDate }},
+	{"jurisdiction", "jurisdiction", func(r *models.CustomerRequest) any { return r.Jurisdiction }},
+}
+
+// PatchCustomer applies an RFC 7396 merge patch or RFC 6902 JSON patch to a customer.
+// Only the resulting document is validated, and only changed columns are written.
+func (s *customerService) PatchCustomer(ctx context.Context, id uuid.UUID, format models.PatchFormat, patch []byte, expectedVersion int64) (*models.CustomerPatchResponse, error) {
+	customer, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkVersion(customer, expectedVersion); err != nil {
+		return nil, err
+	}
+
+	current := requestFromCustomer(customer)
+	patched, err := applyPatch(current, format, patch)
+	if err != nil {
+		return nil, err
+	}
+
+	// Validate the document as a whole, exactly as a PUT would
+	if err := models.ValidateStruct(patched); err != nil {
+		return nil, err
+	}
+	if err := validateTypeUnchanged(customer, *patched); err != nil {
+		return nil, err
+	}
+	if err := s.validateCustomerRequest(*patched); err != nil {
+		return nil, err
+	}
+	normalizeRequest(patched)
+
+	columns, changedFields := diffRequests(current, *patched)
+	if len(changedFields) == 0 {
+		return &models.CustomerPatchResponse{
+			Customer:      customer.ToResponse(),
+			ChangedFields: changedFields,
+		}, nil
+	}
+
+	// Check if email is being changed and if new email already exists
+	if slices.Contains(columns, "email") {
+		if err := s.checkEmailAvailable(ctx, patched.Email, customer.ID); err != nil {
+			return nil, err
+		}
+	}
+
+	before := *customer
+	applyRequest(customer, *patched)
+	err = s.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		return s.saveFields(ctx, customer, &before, columns, changedFields, audit.ActionCustomerPatch)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.CustomerPatchResponse{
+		Customer:      customer.ToResponse(),
+		ChangedFields: changedFields,
+	}, nil
+}
+
+// saveFields writes the given columns of a customer and records the update: it is
+// audited under action, screened and rated when a relevant field changed, carried
+// over to the primary addresses and contact points, and published as CustomerUpdated.
+// Must run inside a transaction.
+func (s *customerService) saveFields(ctx context.Context, customer, before *models.Customer, columns, changedFields []string, action string) error {
+	if err := s.repo.UpdateFields(ctx, customer, columns); err != nil {
+		return err
+	}
+	if err := s.audit.Record(ctx, action, customer.ID, auditChanges(before, customer)); err != nil {
+		return err
+	}
+	if err := s.screenIfChanged(ctx, customer, changedFields); err != nil {
+		return err
+	}
+	if err := s.rateIfChanged(ctx, customer, changedFields); err != nil {
+		return err
+	}
+	if err := s.syncContacts(ctx, customer); err != nil {
+		return err
+	}
+	return s.emit(ctx, events.CustomerUpdated, customer.ID, models.CustomerUpdatedPayload{
+		Customer:      customer.ToResponse(),
+		ChangedFields: changedFields,
+	})
+}
+
+// applyPatch applies the patch to the JSON form of the current customer and decodes the result
+func applyPatch(current models.CustomerRequest, format models.PatchFormat, patch []byte) (*models.CustomerRequest, error) {
+	document, err := json.Marshal(current)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode customer: %w", err)
+	}
+
+	var result []byte
+	switch format {
+	case models.PatchFormatMerge:
+		var object map[string]json.RawMessage
+		if err := json.Unmarshal(patch, &object); err != nil {
+			return nil, fmt.Errorf("%w: merge patch must be a JSON object", models.ErrInvalidPatch)
+		}
+		result, err = jsonpatch.MergePatch(document, patch)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", models.ErrInvalidPatch, err)
+		}
+	case models.PatchFormatJSONPatch:
+		operations, err := jsonpatch.DecodePatch(patch)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", models.ErrInvalidPatch, err)
+		}
+		result, err = operations.Apply(document)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", models.ErrPatchFailed, err)
+		}
+	default:
+		return nil, fmt.Errorf("%w: unsupported patch format %q", models.ErrInvalidPatch, format)
+	}
+
+	// Reject fields that are not part of the editable document, such as id or status
+	decoder := json.NewDecoder(bytes.NewReader(result))
+	decoder.DisallowUnknownFields()
+
+	var patched models.CustomerRequest
+	if err := decoder.Decode(&patched); err != nil {
+		return nil, models.NewValidationError("patch", "patched document is not a valid customer: "+err.Error())
+	}
+	return &patched, nil
+}
+
+// diffRequests returns the columns whose values differ between two documents
+// and the matching document field paths
+func diffRequests(before, after models.CustomerRequest) ([]string, []string) {
+	columns := make([]string, 0)
+	changedFields := make([]string, 0)
+	for _, field := range patchableFields {
+		oldValue, newValue := field.value(&before), field.value(&after)
+		if !sameValue(oldValue, newValue) {
+			columns = append(columns, field.column)
+			changedFields = append(changedFields, field.path)
+		}
+	}
+	return columns, changedFields
+}
+
+// requestFromCustomer returns the editable fields of a customer
+func requestFromCustomer(customer *models.Customer) models.CustomerRequest {
+	return models.CustomerRequest{
+		Type:        customer.Type,
+		FirstName:   customer.FirstName,
+		LastName:    customer.LastName,
+		Email:       customer.Email,
+		Phone:       customer.Phone,
+		DateOfBirth: customer.DateOfBirth,
+		Address:     customer.Address,
+		Occupation:  customer.Occupation,
+		Products:    customer.Products,
+
+		LegalName:          customer.LegalName,
+		RegistrationNumber: customer.RegistrationNumber,
+		IncorporationDate:  customer.IncorporationDate,
+		Jurisdiction:       customer.Jurisdiction,
+	}
+}
+
+// applyRequest copies the editable fields of a request onto a customer
+func applyRequest(customer *models.Customer, req models.CustomerRequest) {
+	customer.FirstName = req.FirstName
+	customer.LastName = req.LastName
+	customer.Email = req.Email
+	customer.Phone = req.Phone
+	customer.DateOfBirth = req.DateOfBirth
+	customer.Address = req.Address
+	customer.Occupation = req.Occupation
+	customer.Products = req.Products
+	customer.LegalName = req.LegalName
+	customer.RegistrationNumber = req.RegistrationNumber
+	customer.IncorporationDate = req.IncorporationDate
+	customer.Jurisdiction = req.Jurisdiction
+}
+
+// normalizeRequest trims the free-text fields and stores product codes lowercased,
+// sorted and without repeats, so that reordering them is not a change
+func normalizeRequest(req *models.CustomerRequest) {
+	req.Occupation = strings.TrimSpace(req.Occupation)
+	req.LegalName = strings.TrimSpace(req.LegalName)
+	req.RegistrationNumber = strings.TrimSpace(req.RegistrationNumber)
+	req.Jurisdiction = strings.TrimSpace(req.Jurisdiction)
+
+	products := make([]string, 0, len(req.Products))
+	for _, product := range req.Products {
+		product = strings.ToLower(strings.TrimSpace(product))
+		if product != "" && !slices.Contains(products, product) {
+			products = append(products, product)
+		}
+	}
+	slices.Sort(products)
+	req.Products = products
+}
+
+// sameValue compares two field values, treating dates by instant rather than representation
+// and lists by their elements
+func sameValue(a, b any) bool {
+	la, aIsList := a.([]string)
+	lb, bIsList := b.([]string)
+	if aIsList || bIsList {
+		return slices.Equal(la, lb)
+	}
+
+	ta, aIsTime := a.(*time.Time)
+	tb, bIsTime := b.(*time.Time)
+	if aIsTime || bIsTime {
+		if ta == nil || tb == nil {
+			return ta == nil && tb == nil
+		}
+		return ta.Equal(*tb)
+	}
+	return a == b
+}
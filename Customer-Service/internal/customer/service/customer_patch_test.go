@@ -0,0 +1,228 @@
+package service
+
+import (
+	"customer-service/internal/customer/models"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// patchTarget returns the document the patch tests apply to
+func patchTarget() models.CustomerRequest {
+	born := time.Date(1990, time.May, 17, 0, 0, 0, 0, time.UTC)
+	return models.CustomerRequest{
+		Type:        models.CustomerTypeIndividual,
+		FirstName:   "Jane",
+		LastName:    "Smith",
+		Email:       "jane@example.com",
+		Phone:       "+15551234567",
+		DateOfBirth: &born,
+		Address:     models.Address{Street: "1 Main St", City: "Springfield", Country: "US"},
+		Occupation:  "Engineer",
+		Products:    []string{"checking", "savings"},
+	}
+}
+
+func TestApplyPatch(t *testing.T) {
+	tests := []struct {
+		name    string
+		format  models.PatchFormat
+		patch   string
+		want    func(req *models.CustomerRequest)
+		wantErr error
+		field   string
+	}{
+		{
+			name:   "merge sets a field",
+			format: models.PatchFormatMerge,
+			patch:  `{"last_name": "Jones"}`,
+			want:   func(req *models.CustomerRequest) { req.LastName = "Jones" },
+		},
+		{
+			name:   "merge null clears a field",
+			format: models.PatchFormatMerge,
+			patch:  `{"occupation": null, "date_of_birth": null}`,
+			want: func(req *models.CustomerRequest) {
+				req.Occupation = ""
+				req.DateOfBirth = nil
+			},
+		},
+		{
+			name:   "merge into a nested object keeps its other fields",
+			format: models.PatchFormatMerge,
+			patch:  `{"address": {"city": "Shelbyville"}}`,
+			want:   func(req *models.CustomerRequest) { req.Address.City = "Shelbyville" },
+		},
+		{
+			name:   "merge replaces a list whole",
+			format: models.PatchFormatMerge,
+			patch:  `{"products": ["loan"]}`,
+			want:   func(req *models.CustomerRequest) { req.Products = []string{"loan"} },
+		},
+		{
+			name:   "empty merge patch",
+			format: models.PatchFormatMerge,
+			patch:  `{}`,
+			want:   func(req *models.CustomerRequest) {},
+		},
+		{
+			name:    "merge patch that is not an object",
+			format:  models.PatchFormatMerge,
+			patch:   `["last_name"]`,
+			wantErr: models.ErrInvalidPatch,
+		},
+		{
+			name:    "merge patch that is not JSON",
+			format:  models.PatchFormatMerge,
+			patch:   `{"last_name": `,
+			wantErr: models.ErrInvalidPatch,
+		},
+		{
+			name:   "merge adding a field outside the document",
+			format: models.PatchFormatMerge,
+			patch:  `{"status": "active"}`,
+			field:  "patch",
+		},
+		{
+			name:   "merge giving a field the wrong type",
+			format: models.PatchFormatMerge,
+			patch:  `{"products": "loan"}`,
+			field:  "patch",
+		},
+		{
+			name:   "JSON patch replace and add",
+			format: models.PatchFormatJSONPatch,
+			patch:  `[{"op": "replace", "path": "/email", "value": "jane@example.org"}, {"op": "add", "path": "/products/-", "value": "loan"}]`,
+			want: func(req *models.CustomerRequest) {
+				req.Email = "jane@example.org"
+				req.Products = []string{"checking", "savings", "loan"}
+			},
+		},
+		{
+			name:   "JSON patch passing test",
+			format: models.PatchFormatJSONPatch,
+			patch:  `[{"op": "test", "path": "/last_name", "value": "Smith"}, {"op": "replace", "path": "/last_name", "value": "Jones"}]`,
+			want:   func(req *models.CustomerRequest) { req.LastName = "Jones" },
+		},
+		{
+			name:    "JSON patch failing test",
+			format:  models.PatchFormatJSONPatch,
+			patch:   `[{"op": "test", "path": "/last_name", "value": "Jones"}, {"op": "replace", "path": "/last_name", "value": "Brown"}]`,
+			wantErr: models.ErrPatchFailed,
+		},
+		{
+			name:    "JSON patch removing a missing member",
+			format:  models.PatchFormatJSONPatch,
+			patch:   `[{"op": "remove", "path": "/products/5"}]`,
+			wantErr: models.ErrPatchFailed,
+		},
+		{
+			name:    "JSON patch that is not a list",
+			format:  models.PatchFormatJSONPatch,
+			patch:   `{"op": "replace", "path": "/last_name", "value": "Jones"}`,
+			wantErr: models.ErrInvalidPatch,
+		},
+		{
+			name:   "JSON patch adding a field outside the document",
+			format: models.PatchFormatJSONPatch,
+			patch:  `[{"op": "add", "path": "/id", "value": "1"}]`,
+			field:  "patch",
+		},
+		{
+			name:    "unsupported format",
+			format:  "xml-patch",
+			patch:   `{}`,
+			wantErr: models.ErrInvalidPatch,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := applyPatch(patchTarget(), tt.format, []byte(tt.patch))
+
+			switch {
+			case tt.field != "":
+				var verr *models.ValidationError
+				if !errors.As(err, &verr) || len(verr.Fields) != 1 || verr.Fields[0].Field != tt.field {
+					t.Errorf("applyPatch() error = %v, want a validation error on %s", err, tt.field)
+				}
+			case tt.wantErr != nil:
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("applyPatch() error = %v, want %v", err, tt.wantErr)
+				}
+			case err != nil:
+				t.Fatalf("applyPatch() error = %v", err)
+			default:
+				want := patchTarget()
+				tt.want(&want)
+				if !reflect.DeepEqual(*got, want) {
+					t.Errorf("applyPatch() = %+v, want %+v", *got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestDiffRequests(t *testing.T) {
+	tests := []struct {
+		name    string
+		change  func(req *models.CustomerRequest)
+		columns []string
+		fields  []string
+	}{
+		{
+			name:    "unchanged",
+			change:  func(req *models.CustomerRequest) {},
+			columns: []string{},
+			fields:  []string{},
+		},
+		{
+			name: "same date in another time zone",
+			change: func(req *models.CustomerRequest) {
+				born := req.DateOfBirth.In(time.FixedZone("UTC+2", 2*60*60))
+				req.DateOfBirth = &born
+			},
+			columns: []string{},
+			fields:  []string{},
+		},
+		{
+			name:    "products reordered and repeated",
+			change:  func(req *models.CustomerRequest) { req.Products = []string{" Savings", "checking", "savings"} },
+			columns: []string{},
+			fields:  []string{},
+		},
+		{
+			name:    "date of birth cleared",
+			change:  func(req *models.CustomerRequest) { req.DateOfBirth = nil },
+			columns: []string{"date_of_birth"},
+			fields:  []string{"date_of_birth"},
+		},
+		{
+			name: "nested and top-level fields",
+			change: func(req *models.CustomerRequest) {
+				req.Email = "jane@example.org"
+				req.Address.PostalCode = "12345"
+				req.Products = append(req.Products, "loan")
+			},
+			columns: []string{"email", "address_postal_code", "products"},
+			fields:  []string{"email", "address.postal_code", "products"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			before, after := patchTarget(), patchTarget()
+			tt.change(&after)
+			normalizeRequest(&after)
+
+			columns, fields := diffRequests(before, after)
+			if !reflect.DeepEqual(columns, tt.columns) {
+				t.Errorf("columns = %v, want %v", columns, tt.columns)
+			}
+			if !reflect.DeepEqual(fields, tt.fields) {
+				t.Errorf("changed fields = %v, want %v", fields, tt.fields)
+			}
+		})
+	}
+}
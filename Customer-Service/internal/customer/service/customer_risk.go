@@ -0,0 +1,27 @@
+package service
+
+import (
+	"context"
+	"customer-service/internal/customer/models"
+	riskmodels "customer-service/internal/risk/models"
+	"slices"
+)
+
+// RiskRater scores customers against the risk rules
+type RiskRater interface {
+	Rate(ctx context.Context, customer *models.Customer, trigger riskmodels.Trigger) error
+}
+
+// ratedFields are the customer fields the risk rules depend on; changing any of them
+// rates the customer again. Names are included because they decide PEP screening hits.
+var ratedFields = []string{"first_name", "last_name", "legal_name", "date_of_birth", "address.country", "occupation", "products"}
+
+// rateIfChanged rates an updated customer when a rated field changed
+func (s *customerService) rateIfChanged(ctx context.Context, customer *models.Customer, changedFields []string) error {
+	for _, field := range changedFields {
+		if slices.Contains(ratedFields, field) {
+			return s.rater.Rate(ctx, customer, riskmodels.TriggerCustomerUpdate)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,27 @@
+package service
+
+import (
+	"context"
+	"customer-service/internal/customer/models"
+	screeningmodels "customer-service/internal/screening/models"
+	"slices"
+)
+
+// Screener matches customers against sanctions and PEP watchlists
+type Screener interface {
+	Screen(ctx context.Context, customer *models.Customer, trigger screeningmodels.Trigger) error
+}
+
+// screenedFields are the customer fields screening matches on; changing any of them
+// screens the customer again
+var screenedFields = []string{"first_name", "last_name", "date_of_birth", "address.country", "legal_name", "jurisdiction"}
+
+// screenIfChanged screens an updated customer when a screened field changed
+func (s *customerService) screenIfChanged(ctx context.Context, customer *models.Customer, changedFields []string) error {
+	for _, field := range changedFields {
+		if slices.Contains(screenedFields, field) {
+			return s.screener.Screen(ctx, customer, screeningmodels.TriggerCustomerUpdate)
+		}
+	}
+	return nil
+}
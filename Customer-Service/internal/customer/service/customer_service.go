@@ -1,61 +1,133 @@
 package service
 
 import (
+	"context"
+	"customer-service/internal/audit"
 	"customer-service/internal/customer/models"
 	"customer-service/internal/customer/repository"
+	"customer-service/internal/database"
+	"customer-service/internal/events"
+	riskmodels "customer-service/internal/risk/models"
+	screeningmodels "customer-service/internal/screening/models"
 	"errors"
 	"math"
+	"strings"
+	"time"
 
 	"github.com/google/uuid"
 )
 
+// Config holds the customer service settings
+type Config struct {
+	// CursorSecret is the key listing cursors are signed with
+	CursorSecret string
+}
+
 // CustomerService defines the interface for customer business logic
 type CustomerService interface {
-	CreateCustomer(req models.CustomerRequest) (*models.CustomerResponse, error)
-	GetCustomer(id uuid.UUID) (*models.CustomerResponse, error)
-	UpdateCustomer(id uuid.UUID, req models.CustomerRequest) (*models.CustomerResponse, error)
-	DeleteCustomer(id uuid.UUID) error
-	ListCustomers(page, pageSize int) (*models.CustomerListResponse, error)
-	SearchCustomers(req models.CustomerSearchRequest) (*models.CustomerListResponse, error)
+	CreateCustomer(ctx context.Context, req models.CustomerRequest) (*models.CustomerResponse, error)
+	GetCustomer(ctx context.Context, id uuid.UUID) (*models.CustomerResponse, error)
+	UpdateCustomer(ctx context.Context, id uuid.UUID, req models.CustomerRequest, expectedVersion int64) (*models.CustomerResponse, error)
+	PatchCustomer(ctx context.Context, id uuid.UUID, format models.PatchFormat, patch []byte, expectedVersion int64) (*models.CustomerPatchResponse, error)
+	DeleteCustomer(ctx context.Context, id uuid.UUID, expectedVersion int64) error
+	ListCustomers(ctx context.Context, req models.CustomerListRequest) (*models.CustomerListResponse, error)
+	SearchCustomers(ctx context.Context, req models.CustomerSearchRequest) (*models.CustomerListResponse, error)
+	ChangeStatus(ctx context.Context, id uuid.UUID, req models.StatusChangeRequest, actor string) (*models.CustomerResponse, error)
+	GetStatusHistory(ctx context.Context, id uuid.UUID) (*models.StatusHistoryResponse, error)
+	FindDuplicates(ctx context.Context, id uuid.UUID) (*models.DuplicateListResponse, error)
+	MergeCustomers(ctx context.Context, survivorID uuid.UUID, req models.MergeRequest, expectedVersion int64, actor string) (*models.MergeResponse, error)
+	GetBeneficialOwners(ctx context.Context, id uuid.UUID) (*models.BeneficialOwnersResponse, error)
+	ReplaceBeneficialOwners(ctx context.Context, id uuid.UUID, req models.BeneficialOwnersRequest) (*models.BeneficialOwnersResponse, error)
+	ListAddresses(ctx context.Context, id uuid.UUID) (*models.AddressListResponse, error)
+	AddAddress(ctx context.Context, id uuid.UUID, req models.AddressRequest) (*models.CustomerAddress, error)
+	UpdateAddress(ctx context.Context, id, addressID uuid.UUID, req models.AddressRequest) (*models.CustomerAddress, error)
+	DeleteAddress(ctx context.Context, id, addressID uuid.UUID) error
+	VerifyAddress(ctx context.Context, id, addressID uuid.UUID, req models.VerificationRequest) (*models.CustomerAddress, error)
+	ListContactPoints(ctx context.Context, id uuid.UUID) (*models.ContactPointListResponse, error)
+	AddContactPoint(ctx context.Context, id uuid.UUID, req models.ContactPointRequest) (*models.ContactPoint, error)
+	UpdateContactPoint(ctx context.Context, id, contactID uuid.UUID, req models.ContactPointRequest) (*models.ContactPoint, error)
+	DeleteContactPoint(ctx context.Context, id, contactID uuid.UUID) error
+	VerifyContactPoint(ctx context.Context, id, contactID uuid.UUID, req models.VerificationRequest) (*models.ContactPoint, error)
+	BackfillContacts(ctx context.Context, batchSize int) (int, error)
 }
 
 type customerService struct {
-	repo repository.CustomerRepository
+	repo     repository.CustomerRepository
+	outbox   events.Outbox
+	audit    audit.Recorder
+	kyc      VerificationChecker
+	screener Screener
+	rater    RiskRater
+	tx       database.Transactor
+	cursors  cursorCodec
 }
 
-// NewCustomerService creates a new customer service instance
-func NewCustomerService(repo repository.CustomerRepository) CustomerService {
+// NewCustomerService creates a new customer service instance. Domain events and
+// audit entries are written in the same transaction as the change that caused them,
+// and every read is recorded in the audit trail before its result is returned.
+// Customers are created inactive and can only be activated once kyc verifies them.
+// They are screened against watchlists and risk rated when created and whenever a
+// screened or rated field changes.
+func NewCustomerService(repo repository.CustomerRepository, outbox events.Outbox, recorder audit.Recorder, kyc VerificationChecker, screener Screener, rater RiskRater, tx database.Transactor, cfg Config) CustomerService {
 	return &customerService{
-		repo: repo,
+		repo:     repo,
+		outbox:   outbox,
+		audit:    recorder,
+		kyc:      kyc,
+		screener: screener,
+		rater:    rater,
+		tx:       tx,
+		cursors:  cursorCodec{secret: []byte(cfg.CursorSecret)},
 	}
 }
 
-// CreateCustomer creates a new customer
-func (s *customerService) CreateCustomer(req models.CustomerRequest) (*models.CustomerResponse, error) {
+// CreateCustomer creates a new customer, an individual unless the request says otherwise
+func (s *customerService) CreateCustomer(ctx context.Context, req models.CustomerRequest) (*models.CustomerResponse, error) {
+	if req.Type == "" {
+		req.Type = models.CustomerTypeIndividual
+	}
+
 	// Validate business rules
 	if err := s.validateCustomerRequest(req); err != nil {
 		return nil, err
 	}
+	normalizeRequest(&req)
 
 	// Check if customer with email already exists
-	existingCustomer, _ := s.repo.GetByEmail(req.Email)
-	if existingCustomer != nil {
-		return nil, errors.New("customer with this email already exists")
+	if err := s.checkEmailAvailable(ctx, req.Email, uuid.Nil); err != nil {
+		return nil, err
 	}
 
 	// Create customer model
 	customer := &models.Customer{
-		FirstName:   req.FirstName,
-		LastName:    req.LastName,
-		Email:       req.Email,
-		Phone:       req.Phone,
-		DateOfBirth: req.DateOfBirth,
-		Address:     req.Address,
-		Status:      models.CustomerStatusActive,
+		Type:    req.Type,
+		Status:  models.CustomerStatusInactive,
+		Version: 1,
 	}
+	applyRequest(customer, req)
 
-	// Save to database
-	if err := s.repo.Create(customer); err != nil {
+	// Save to database together with the CustomerCreated event
+	err := s.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := s.repo.Create(ctx, customer); err != nil {
+			return err
+		}
+		if err := s.audit.Record(ctx, audit.ActionCustomerCreate, customer.ID, auditChanges(nil, customer)); err != nil {
+			return err
+		}
+		if err := s.screener.Screen(ctx, customer, screeningmodels.TriggerOnboarding); err != nil {
+			return err
+		}
+		if err := s.rater.Rate(ctx, customer, riskmodels.TriggerOnboarding); err != nil {
+			return err
+		}
+		if err := s.syncContacts(ctx, customer); err != nil {
+			return err
+		}
+		return s.emit(ctx, events.CustomerCreated, customer.ID, models.CustomerCreatedPayload{
+			Customer: customer.ToResponse(),
+		})
+	})
+	if err != nil {
 		return nil, err
 	}
 
@@ -64,10 +136,15 @@ func (s *customerService) CreateCustomer(req models.CustomerRequest) (*models.Cu
 	return &response, nil
 }
 
-// GetCustomer retrieves a customer by ID
-func (s *customerService) GetCustomer(id uuid.UUID) (*models.CustomerResponse, error) {
-	customer, err := s.repo.GetByID(id)
+// GetCustomer retrieves a customer by ID. Looking up a merged customer returns a
+// MergedError naming the survivor.
+func (s *customerService) GetCustomer(ctx context.Context, id uuid.UUID) (*models.CustomerResponse, error) {
+	customer, err := s.repo.GetByID(ctx, id)
 	if err != nil {
+		return nil, s.redirectMerged(ctx, id, err)
+	}
+
+	if err := s.audit.RecordAccess(ctx, audit.ActionCustomerView, []uuid.UUID{customer.ID}); err != nil {
 		return nil, err
 	}
 
@@ -75,37 +152,66 @@ func (s *customerService) GetCustomer(id uuid.UUID) (*models.CustomerResponse, e
 	return &response, nil
 }
 
-// UpdateCustomer updates an existing customer
-func (s *customerService) UpdateCustomer(id uuid.UUID, req models.CustomerRequest) (*models.CustomerResponse, error) {
-	// Validate business rules
-	if err := s.validateCustomerRequest(req); err != nil {
+// UpdateCustomer updates an existing customer. Unless expectedVersion is models.AnyVersion,
+// the update is rejected with ErrVersionConflict when the stored version differs. The
+// customer type cannot be changed.
+func (s *customerService) UpdateCustomer(ctx context.Context, id uuid.UUID, req models.CustomerRequest, expectedVersion int64) (*models.CustomerResponse, error) {
+	// Get existing customer
+	customer, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkVersion(customer, expectedVersion); err != nil {
 		return nil, err
 	}
 
-	// Get existing customer
-	customer, err := s.repo.GetByID(id)
-	if err != nil {
+	// Validate business rules
+	if req.Type == "" {
+		req.Type = customer.Type
+	}
+	if err := validateTypeUnchanged(customer, req); err != nil {
+		return nil, err
+	}
+	if err := s.validateCustomerRequest(req); err != nil {
 		return nil, err
 	}
+	normalizeRequest(&req)
 
 	// Check if email is being changed and if new email already exists
 	if customer.Email != req.Email {
-		existingCustomer, _ := s.repo.GetByEmail(req.Email)
-		if existingCustomer != nil {
-			return nil, errors.New("customer with this email already exists")
+		if err := s.checkEmailAvailable(ctx, req.Email, customer.ID); err != nil {
+			return nil, err
 		}
 	}
 
 	// Update customer fields
-	customer.FirstName = req.FirstName
-	customer.LastName = req.LastName
-	customer.Email = req.Email
-	customer.Phone = req.Phone
-	customer.DateOfBirth = req.DateOfBirth
-	customer.Address = req.Address
+	_, changedFields := diffRequests(requestFromCustomer(customer), req)
+	before := *customer
+	applyRequest(customer, req)
 
-	// Save changes
-	if err := s.repo.Update(customer); err != nil {
+	// Save changes together with the CustomerUpdated event
+	err = s.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := s.repo.Update(ctx, customer); err != nil {
+			return err
+		}
+		if err := s.audit.Record(ctx, audit.ActionCustomerUpdate, customer.ID, auditChanges(&before, customer)); err != nil {
+			return err
+		}
+		if err := s.screenIfChanged(ctx, customer, changedFields); err != nil {
+			return err
+		}
+		if err := s.rateIfChanged(ctx, customer, changedFields); err != nil {
+			return err
+		}
+		if err := s.syncContacts(ctx, customer); err != nil {
+			return err
+		}
+		return s.emit(ctx, events.CustomerUpdated, customer.ID, models.CustomerUpdatedPayload{
+			Customer:      customer.ToResponse(),
+			ChangedFields: changedFields,
+		})
+	})
+	if err != nil {
 		return nil, err
 	}
 
@@ -114,105 +220,379 @@ func (s *customerService) UpdateCustomer(id uuid.UUID, req models.CustomerReques
 	return &response, nil
 }
 
-// DeleteCustomer deletes a customer
-func (s *customerService) DeleteCustomer(id uuid.UUID) error {
+// DeleteCustomer deletes a customer, subject to the same version check as UpdateCustomer
+func (s *customerService) DeleteCustomer(ctx context.Context, id uuid.UUID, expectedVersion int64) error {
 	// Check if customer exists
-	_, err := s.repo.GetByID(id)
+	customer, err := s.repo.GetByID(ctx, id)
 	if err != nil {
 		return err
 	}
+	if err := checkVersion(customer, expectedVersion); err != nil {
+		return err
+	}
 
-	// Perform soft delete
-	return s.repo.Delete(id)
+	// Perform soft delete together with the CustomerDeleted event
+	deletedAt := time.Now().UTC()
+	return s.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := s.repo.Delete(ctx, id, expectedVersion); err != nil {
+			return err
+		}
+		changes := []audit.Change{{Field: "deleted_at", Before: nil, After: deletedAt}}
+		if err := s.audit.Record(ctx, audit.ActionCustomerDelete, id, changes); err != nil {
+			return err
+		}
+		return s.emit(ctx, events.CustomerDeleted, id, models.CustomerDeletedPayload{
+			DeletedAt: deletedAt,
+		})
+	})
 }
 
-// ListCustomers lists customers with pagination
-func (s *customerService) ListCustomers(page, pageSize int) (*models.CustomerListResponse, error) {
-	// Set default values
-	if page <= 0 {
-		page = 1
+// ListCustomers retrieves a page of the customers matching a filter, by offset or
+// after a cursor, newest first unless sorted otherwise
+func (s *customerService) ListCustomers(ctx context.Context, req models.CustomerListRequest) (*models.CustomerListResponse, error) {
+	filter, order, err := parseFilter(req.CustomerFilter)
+	if err != nil {
+		return nil, err
 	}
-	if pageSize <= 0 {
-		pageSize = 10
+	page, err := s.pageRequest(&req.PageParams, order, false)
+	if err != nil {
+		return nil, err
+	}
+
+	customers, total, err := s.repo.List(ctx, filter, page)
+	if err != nil {
+		return nil, err
 	}
-	if pageSize > 100 {
-		pageSize = 100 // Limit maximum page size
+	hits := make([]models.SearchHit, len(customers))
+	for i, customer := range customers {
+		hits[i] = models.SearchHit{Customer: customer}
 	}
+	return s.listResponse(ctx, audit.ActionCustomerList, hits, false, total, page, req.PageParams)
+}
 
-	customers, total, err := s.repo.List(page, pageSize)
+// SearchCustomers searches customers based on criteria. With a query, the most
+// relevant come first unless sorted otherwise, and the matches are returned.
+func (s *customerService) SearchCustomers(ctx context.Context, req models.CustomerSearchRequest) (*models.CustomerListResponse, error) {
+	filter, order, err := parseFilter(req.CustomerFilter)
+	if err != nil {
+		return nil, err
+	}
+	query := strings.TrimSpace(req.Query) != ""
+	page, err := s.pageRequest(&req.PageParams, order, query && len(order) == 0)
 	if err != nil {
 		return nil, err
 	}
 
-	// Convert to response format
-	customerResponses := make([]models.CustomerResponse, len(customers))
-	for i, customer := range customers {
-		customerResponses[i] = customer.ToResponse()
+	hits, total, err := s.repo.Search(ctx, req.Query, filter, page)
+	if err != nil {
+		return nil, err
 	}
+	return s.listResponse(ctx, audit.ActionCustomerSearch, hits, query, total, page, req.PageParams)
+}
 
-	// Calculate total pages
-	totalPages := int(math.Ceil(float64(total) / float64(pageSize)))
+// pageRequest validates paging parameters, applying the defaults to the page number
+// and size, and returns the page to retrieve in the given order, newest first when
+// none is given. The total is counted exactly by default when paging by offset, as it
+// always was, and left out when following a cursor. One customer more than the page
+// size is asked for, to tell whether more follow. Cursors are only accepted by a
+// listing in the same order, ranked or not, as the one that issued them.
+func (s *customerService) pageRequest(params *models.PageParams, order []models.SortOrder, ranked bool) (models.PageRequest, error) {
+	cursor, count, pageNum, pageSize := params.Cursor, params.Count, &params.Page, &params.PageSize
+	if *pageSize <= 0 {
+		*pageSize = 10
+	}
+	if *pageSize > 100 {
+		*pageSize = 100 // Limit maximum page size
+	}
+	if len(order) == 0 {
+		order = models.DefaultSort
+	}
+	page := models.PageRequest{Limit: *pageSize + 1, Count: count, Sort: order, Ranked: ranked}
 
-	return &models.CustomerListResponse{
-		Customers:  customerResponses,
-		Total:      total,
-		Page:       page,
-		PageSize:   pageSize,
-		TotalPages: totalPages,
-	}, nil
+	switch count {
+	case "", models.CountExact, models.CountEstimated, models.CountNone:
+	default:
+		return page, models.NewValidationError("count", "count must be one of exact, estimated or none")
+	}
+
+	if cursor != "" {
+		if *pageNum > 1 {
+			return page, models.NewValidationError("page", "page cannot be combined with cursor")
+		}
+		*pageNum = 0
+		after, err := s.cursors.decode(cursor)
+		if err != nil {
+			return page, err
+		}
+		if (after.Rank != nil) != ranked || after.Sort != formatSort(order) || len(after.Values) != len(order) {
+			return page, models.NewValidationError("cursor", "cursor belongs to another listing")
+		}
+		page.After = after
+		if page.Count == "" {
+			page.Count = models.CountNone
+		}
+		return page, nil
+	}
+
+	if *pageNum <= 0 {
+		*pageNum = 1
+	}
+	page.Offset = (*pageNum - 1) * *pageSize
+	if page.Count == "" {
+		page.Count = models.CountExact
+	}
+	return page, nil
+}
+
+// listResponse records access to a page of customers and builds its response, with
+// the rank and highlights of each customer when matches is set. The customer past the
+// page size only tells that more follow, and is left out.
+func (s *customerService) listResponse(ctx context.Context, action string, hits []models.SearchHit, matches bool, total int64, page models.PageRequest, params models.PageParams) (*models.CustomerListResponse, error) {
+	pageSize := params.PageSize
+	response := &models.CustomerListResponse{
+		Page:     params.Page,
+		PageSize: pageSize,
+	}
+	if len(hits) > pageSize {
+		hits = hits[:pageSize]
+		last := hits[pageSize-1]
+		position := models.Cursor{Sort: formatSort(page.Sort), Values: make([]string, len(page.Sort)), ID: last.ID}
+		for i, order := range page.Sort {
+			position.Values[i] = models.SortFields[order.Field].Value(&last.Customer)
+		}
+		if page.Ranked {
+			position.Rank = &last.Rank
+		}
+		cursor, err := s.cursors.encode(position)
+		if err != nil {
+			return nil, err
+		}
+		response.NextCursor = cursor
+	}
+
+	ids := make([]uuid.UUID, len(hits))
+	for i := range hits {
+		ids[i] = hits[i].ID
+	}
+	if err := s.audit.RecordAccess(ctx, action, ids); err != nil {
+		return nil, err
+	}
+
+	// Convert to response format
+	response.Customers = make([]models.CustomerResponse, len(hits))
+	for i, hit := range hits {
+		response.Customers[i] = hit.ToResponse()
+	}
+	if matches {
+		response.Matches = make([]models.CustomerMatch, len(hits))
+		for i, hit := range hits {
+			response.Matches[i] = models.CustomerMatch{CustomerID: hit.ID, Rank: hit.Rank, Highlights: hit.Highlights}
+		}
+	}
+
+	if page.Count != models.CountNone {
+		response.Total = &total
+		response.TotalEstimated = page.Count == models.CountEstimated
+		if page.After == nil {
+			// Calculate total pages
+			totalPages := int(math.Ceil(float64(total) / float64(pageSize)))
+			response.TotalPages = &totalPages
+		}
+	}
+	return response, nil
 }
 
-// SearchCustomers searches customers based on criteria
-func (s *customerService) SearchCustomers(req models.CustomerSearchRequest) (*models.CustomerListResponse, error) {
-	// Set default values
-	if req.Page <= 0 {
-		req.Page = 1
+// ChangeStatus moves a customer to a new status, enforcing the status state machine
+func (s *customerService) ChangeStatus(ctx context.Context, id uuid.UUID, req models.StatusChangeRequest, actor string) (*models.CustomerResponse, error) {
+	if actor == "" {
+		return nil, models.NewValidationError("actor", "actor is required")
 	}
-	if req.PageSize <= 0 {
-		req.PageSize = 10
+	if req.ReasonCode == "" {
+		return nil, models.NewValidationError("reason_code", "reason code is required")
 	}
-	if req.PageSize > 100 {
-		req.PageSize = 100 // Limit maximum page size
+
+	customer, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := validateTransition(customer.Status, req.Status); err != nil {
+		return nil, err
+	}
+	if req.Status == models.CustomerStatusActive {
+		verified, err := s.kyc.IsVerified(ctx, customer.ID)
+		if err != nil {
+			return nil, err
+		}
+		if !verified {
+			return nil, models.ErrKYCNotVerified
+		}
 	}
 
-	customers, total, err := s.repo.Search(req)
+	history := &models.CustomerStatusHistory{
+		CustomerID: customer.ID,
+		FromStatus: customer.Status,
+		ToStatus:   req.Status,
+		ReasonCode: req.ReasonCode,
+		Note:       req.Note,
+		Actor:      actor,
+	}
+
+	err = s.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := s.repo.ChangeStatus(ctx, customer, history); err != nil {
+			return err
+		}
+		changes := []audit.Change{{Field: "status", Before: history.FromStatus, After: history.ToStatus}}
+		if err := s.audit.Record(ctx, audit.ActionCustomerStatusChange, customer.ID, changes); err != nil {
+			return err
+		}
+		return s.emit(ctx, events.CustomerStatusChanged, customer.ID, models.CustomerStatusChangedPayload{
+			FromStatus: history.FromStatus,
+			ToStatus:   history.ToStatus,
+			ReasonCode: history.ReasonCode,
+			Note:       history.Note,
+			Actor:      history.Actor,
+			Version:    customer.Version,
+		})
+	})
 	if err != nil {
 		return nil, err
 	}
 
-	// Convert to response format
-	customerResponses := make([]models.CustomerResponse, len(customers))
-	for i, customer := range customers {
-		customerResponses[i] = customer.ToResponse()
+	// Reload to pick up the new updated_at timestamp
+	customer, err = s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
 	}
 
-	// Calculate total pages
-	totalPages := int(math.Ceil(float64(total) / float64(req.PageSize)))
+	response := customer.ToResponse()
+	return &response, nil
+}
 
-	return &models.CustomerListResponse{
-		Customers:  customerResponses,
-		Total:      total,
-		Page:       req.Page,
-		PageSize:   req.PageSize,
-		TotalPages: totalPages,
+// GetStatusHistory retrieves the status transitions recorded for a customer
+func (s *customerService) GetStatusHistory(ctx context.Context, id uuid.UUID) (*models.StatusHistoryResponse, error) {
+	// Check if customer exists
+	if _, err := s.repo.GetByID(ctx, id); err != nil {
+		return nil, err
+	}
+
+	history, err := s.repo.ListStatusHistory(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.audit.RecordAccess(ctx, audit.ActionCustomerStatusHistory, []uuid.UUID{id}); err != nil {
+		return nil, err
+	}
+
+	return &models.StatusHistoryResponse{
+		CustomerID: id,
+		History:    history,
 	}, nil
 }
 
-// validateCustomerRequest validates the customer request
-func (s *customerService) validateCustomerRequest(req models.CustomerRequest) error {
-	if req.FirstName == "" {
-		return errors.New("first name is required")
+// emit appends a domain event for a customer to the outbox
+func (s *customerService) emit(ctx context.Context, eventType string, customerID uuid.UUID, payload any) error {
+	event, err := events.NewEvent(eventType, customerID, payload)
+	if err != nil {
+		return err
+	}
+	return s.outbox.Append(ctx, event)
+}
+
+// validateTypeUnchanged rejects a request that would turn an individual into an
+// organization or the reverse
+func validateTypeUnchanged(customer *models.Customer, req models.CustomerRequest) error {
+	if req.Type != customer.Type {
+		return models.NewValidationError("type", "cannot be changed")
+	}
+	return nil
+}
+
+// checkVersion rejects a write made against a stale copy of the customer
+func checkVersion(customer *models.Customer, expectedVersion int64) error {
+	if expectedVersion != models.AnyVersion && customer.Version != expectedVersion {
+		return models.ErrVersionConflict
+	}
+	return nil
+}
+
+// checkEmailAvailable rejects an email already held by a customer other than the one
+// with ID owner. Emails are compared through their blind index, so a customer may
+// change the case of their own email. Pass uuid.Nil for a new customer.
+func (s *customerService) checkEmailAvailable(ctx context.Context, email string, owner uuid.UUID) error {
+	existing, err := s.repo.GetByEmail(ctx, email)
+	if errors.Is(err, models.ErrCustomerNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if existing.ID != owner {
+		return models.ErrCustomerAlreadyExists
 	}
-	if req.LastName == "" {
-		return errors.New("last name is required")
+	return nil
+}
+
+// validateCustomerRequest validates the customer request. Individuals need a first and
+// last name; organizations need a legal name, registration number and jurisdiction,
+// and neither may carry the other's fields.
+func (s *customerService) validateCustomerRequest(req models.CustomerRequest) error {
+	verr := &models.ValidationError{}
+	switch req.Type {
+	case models.CustomerTypeIndividual:
+		if req.FirstName == "" {
+			verr.Add("first_name", "first name is required")
+		}
+		if req.LastName == "" {
+			verr.Add("last_name", "last name is required")
+		}
+		if req.LegalName != "" {
+			verr.Add("legal_name", "only applies to organizations")
+		}
+		if req.RegistrationNumber != "" {
+			verr.Add("registration_number", "only applies to organizations")
+		}
+		if req.IncorporationDate != nil {
+			verr.Add("incorporation_date", "only applies to organizations")
+		}
+		if req.Jurisdiction != "" {
+			verr.Add("jurisdiction", "only applies to organizations")
+		}
+	case models.CustomerTypeOrganization:
+		if req.LegalName == "" {
+			verr.Add("legal_name", "legal name is required")
+		}
+		if req.RegistrationNumber == "" {
+			verr.Add("registration_number", "registration number is required")
+		}
+		if req.Jurisdiction == "" {
+			verr.Add("jurisdiction", "jurisdiction is required")
+		}
+		if req.FirstName != "" {
+			verr.Add("first_name", "only applies to individuals")
+		}
+		if req.LastName != "" {
+			verr.Add("last_name", "only applies to individuals")
+		}
+		if req.DateOfBirth != nil {
+			verr.Add("date_of_birth", "only applies to individuals")
+		}
+		if req.Occupation != "" {
+			verr.Add("occupation", "only applies to individuals")
+		}
+	default:
+		verr.Add("type", "must be one of: individual organization")
 	}
 	if req.Email == "" {
-		return errors.New("email is required")
+		verr.Add("email", "email is required")
 	}
 	if req.Phone == "" {
-		return errors.New("phone is required")
+		verr.Add("phone", "phone is required")
 	}
-	
+
 	// Add more validation rules as needed
+	if verr.HasErrors() {
+		return verr
+	}
 	return nil
 }
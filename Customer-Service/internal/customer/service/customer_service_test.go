@@ -0,0 +1,62 @@
+package service
+
+import (
+	"context"
+	"customer-service/internal/customer/models"
+	"customer-service/internal/customer/repository"
+	"customer-service/internal/encryption"
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+// emailRepository looks customers up by email as the blind index matches them,
+// ignoring case, or fails every lookup with err
+type emailRepository struct {
+	repository.CustomerRepository
+	customers []models.Customer
+	err       error
+}
+
+func (r *emailRepository) GetByEmail(ctx context.Context, email string) (*models.Customer, error) {
+	if r.err != nil {
+		return nil, r.err
+	}
+	for _, customer := range r.customers {
+		if encryption.NormalizeEmail(customer.Email) == encryption.NormalizeEmail(email) {
+			return &customer, nil
+		}
+	}
+	return nil, models.ErrCustomerNotFound
+}
+
+func TestCheckEmailAvailable(t *testing.T) {
+	jane := models.Customer{ID: uuid.New(), Email: "Jane@example.com"}
+	dbErr := errors.New("connection reset")
+
+	tests := []struct {
+		name    string
+		email   string
+		owner   uuid.UUID
+		err     error
+		wantErr error
+	}{
+		{name: "new email", email: "john@example.com", owner: uuid.Nil},
+		{name: "taken by another customer", email: "jane@example.com", owner: uuid.New(), wantErr: models.ErrCustomerAlreadyExists},
+		{name: "taken when creating", email: "JANE@example.com", owner: uuid.Nil, wantErr: models.ErrCustomerAlreadyExists},
+		{name: "own email in another case", email: "jane@example.com", owner: jane.ID},
+		{name: "lookup failure", email: "john@example.com", owner: jane.ID, err: dbErr, wantErr: dbErr},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &customerService{repo: &emailRepository{customers: []models.Customer{jane}, err: tt.err}}
+
+			err := s.checkEmailAvailable(context.Background(), tt.email, tt.owner)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("checkEmailAvailable() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,99 @@
+package service
+
+import (
+	"context"
+	"customer-service/internal/customer/repository"
+	"customer-service/internal/encryption"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ReencryptionConfig controls how often the re-encryption job runs and its batch size
+type ReencryptionConfig struct {
+	Interval  time.Duration
+	BatchSize int
+}
+
+// Reencryptor rewrites customer PII encrypted with an older key, or still in
+// plaintext, under the current key, and computes the blind indexes rows written
+// before some were added are missing. It runs alongside normal traffic: reads handle
+// every key version, and rows changed concurrently are skipped because the write
+// that changed them already used the current key.
+type Reencryptor struct {
+	repo   repository.CustomerRepository
+	cipher *encryption.Cipher
+	cfg    ReencryptionConfig
+}
+
+// NewReencryptor creates a new re-encryption job
+func NewReencryptor(repo repository.CustomerRepository, cipher *encryption.Cipher, cfg ReencryptionConfig) *Reencryptor {
+	if cfg.Interval <= 0 {
+		cfg.Interval = time.Minute
+	}
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = 100
+	}
+
+	return &Reencryptor{
+		repo:   repo,
+		cipher: cipher,
+		cfg:    cfg,
+	}
+}
+
+// Run re-encrypts stale rows every interval until ctx is cancelled
+func (r *Reencryptor) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.cfg.Interval)
+	defer ticker.Stop()
+
+	for {
+		rewritten, err := r.RunOnce(ctx)
+		if err != nil && ctx.Err() == nil {
+			log.Printf("Re-encryption: %v", err)
+		}
+		if rewritten > 0 {
+			log.Printf("Re-encryption: rewrote %d customer(s) under the current key", rewritten)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunOnce makes one pass over all customers not encrypted with the current key and
+// returns how many were rewritten
+func (r *Reencryptor) RunOnce(ctx context.Context) (int, error) {
+	keyVersion, err := r.cipher.CurrentVersion(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	rewritten := 0
+	after := uuid.Nil
+	for {
+		customers, err := r.repo.ListStaleEncryption(ctx, keyVersion, after, r.cfg.BatchSize)
+		if err != nil {
+			return rewritten, err
+		}
+
+		for i := range customers {
+			ok, err := r.repo.Reencrypt(ctx, &customers[i])
+			if err != nil {
+				return rewritten, err
+			}
+			if ok {
+				rewritten++
+			}
+		}
+
+		if len(customers) < r.cfg.BatchSize {
+			return rewritten, nil
+		}
+		after = customers[len(customers)-1].ID
+	}
+}
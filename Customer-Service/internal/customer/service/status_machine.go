@@ -0,0 +1,56 @@
+package service
+
+import (
+	"context"
+	"customer-service/internal/customer/models"
+	"fmt"
+
+	"github.com/google/uuid"
+)
+
+// VerificationChecker reports whether a customer's identity has been verified (KYC)
+type VerificationChecker interface {
+	IsVerified(ctx context.Context, customerID uuid.UUID) (bool, error)
+}
+
+// statusTransitions lists the statuses a customer may move to from each status.
+// Closed is terminal: a closed customer can never be reopened. Moving to active
+// additionally requires a valid KYC verification.
+var statusTransitions = map[models.CustomerStatus][]models.CustomerStatus{
+	models.CustomerStatusActive: {
+		models.CustomerStatusInactive,
+		models.CustomerStatusSuspended,
+		models.CustomerStatusClosed,
+	},
+	models.CustomerStatusInactive: {
+		models.CustomerStatusActive,
+		models.CustomerStatusSuspended,
+		models.CustomerStatusClosed,
+	},
+	models.CustomerStatusSuspended: {
+		models.CustomerStatusActive,
+		models.CustomerStatusClosed,
+	},
+	models.CustomerStatusClosed: {},
+}
+
+// canTransition reports whether a customer may move from one status to another
+func canTransition(from, to models.CustomerStatus) bool {
+	for _, allowed := range statusTransitions[from] {
+		if allowed == to {
+			return true
+		}
+	}
+	return false
+}
+
+// validateTransition returns ErrInvalidStatusTransition when the move is not allowed
+func validateTransition(from, to models.CustomerStatus) error {
+	if _, known := statusTransitions[to]; !known {
+		return models.NewValidationError("status", fmt.Sprintf("unknown status %q", to))
+	}
+	if !canTransition(from, to) {
+		return fmt.Errorf("%w: %s to %s", models.ErrInvalidStatusTransition, from, to)
+	}
+	return nil
+}
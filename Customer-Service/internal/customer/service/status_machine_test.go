@@ -0,0 +1,145 @@
+package service
+
+import (
+	"context"
+	"customer-service/internal/customer/models"
+	"customer-service/internal/customer/repository"
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestValidateTransition(t *testing.T) {
+	const (
+		active    = models.CustomerStatusActive
+		inactive  = models.CustomerStatusInactive
+		suspended = models.CustomerStatusSuspended
+		closed    = models.CustomerStatusClosed
+	)
+
+	tests := []struct {
+		from, to   models.CustomerStatus
+		wantErr    error
+		validation bool
+	}{
+		{from: inactive, to: active},
+		{from: inactive, to: suspended},
+		{from: inactive, to: closed},
+		{from: active, to: inactive},
+		{from: active, to: suspended},
+		{from: active, to: closed},
+		{from: suspended, to: active},
+		{from: suspended, to: closed},
+		{from: suspended, to: inactive, wantErr: models.ErrInvalidStatusTransition},
+		{from: active, to: active, wantErr: models.ErrInvalidStatusTransition},
+		{from: closed, to: active, wantErr: models.ErrInvalidStatusTransition},
+		{from: closed, to: inactive, wantErr: models.ErrInvalidStatusTransition},
+		{from: closed, to: suspended, wantErr: models.ErrInvalidStatusTransition},
+		{from: closed, to: closed, wantErr: models.ErrInvalidStatusTransition},
+		{from: active, to: "deleted", validation: true},
+		{from: active, to: "", validation: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.from)+" to "+string(tt.to), func(t *testing.T) {
+			err := validateTransition(tt.from, tt.to)
+
+			var verr *models.ValidationError
+			switch {
+			case tt.validation:
+				if !errors.As(err, &verr) {
+					t.Errorf("validateTransition() error = %v, want a validation error", err)
+				}
+			case tt.wantErr != nil:
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("validateTransition() error = %v, want %v", err, tt.wantErr)
+				}
+			case err != nil:
+				t.Errorf("validateTransition() error = %v, want nil", err)
+			}
+		})
+	}
+}
+
+// statusRepository serves the one customer ChangeStatus reads. Calls to any other
+// repository method panic.
+type statusRepository struct {
+	repository.CustomerRepository
+	customer *models.Customer
+}
+
+func (r *statusRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.Customer, error) {
+	customer := *r.customer
+	return &customer, nil
+}
+
+// kycResult answers IsVerified with a fixed result
+type kycResult bool
+
+func (v kycResult) IsVerified(ctx context.Context, customerID uuid.UUID) (bool, error) {
+	return bool(v), nil
+}
+
+func TestChangeStatusRejects(t *testing.T) {
+	tests := []struct {
+		name     string
+		status   models.CustomerStatus
+		req      models.StatusChangeRequest
+		actor    string
+		verified bool
+		wantErr  error
+		field    string
+	}{
+		{
+			name:    "activation without KYC verification",
+			status:  models.CustomerStatusInactive,
+			req:     models.StatusChangeRequest{Status: models.CustomerStatusActive, ReasonCode: "kyc_passed"},
+			actor:   "user-1",
+			wantErr: models.ErrKYCNotVerified,
+		},
+		{
+			name:     "reopening a closed customer",
+			status:   models.CustomerStatusClosed,
+			req:      models.StatusChangeRequest{Status: models.CustomerStatusActive, ReasonCode: "reopened"},
+			actor:    "user-1",
+			verified: true,
+			wantErr:  models.ErrInvalidStatusTransition,
+		},
+		{
+			name:   "missing reason code",
+			status: models.CustomerStatusActive,
+			req:    models.StatusChangeRequest{Status: models.CustomerStatusSuspended},
+			actor:  "user-1",
+			field:  "reason_code",
+		},
+		{
+			name:   "missing actor",
+			status: models.CustomerStatusActive,
+			req:    models.StatusChangeRequest{Status: models.CustomerStatusSuspended, ReasonCode: "fraud_suspected"},
+			field:  "actor",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			customer := &models.Customer{ID: uuid.New(), Status: tt.status}
+			s := &customerService{
+				repo: &statusRepository{customer: customer},
+				kyc:  kycResult(tt.verified),
+			}
+
+			_, err := s.ChangeStatus(context.Background(), customer.ID, tt.req, tt.actor)
+			if tt.field != "" {
+				var verr *models.ValidationError
+				if !errors.As(err, &verr) || len(verr.Fields) != 1 || verr.Fields[0].Field != tt.field {
+					t.Errorf("ChangeStatus() error = %v, want a validation error on %s", err, tt.field)
+				}
+				return
+			}
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("ChangeStatus() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
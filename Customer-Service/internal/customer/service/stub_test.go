@@ -0,0 +1,24 @@
+package service
+
+import (
+	"context"
+	"customer-service/internal/customer/models"
+	"customer-service/internal/customer/repository"
+
+	"github.com/google/uuid"
+)
+
+// stubRepository serves customers by ID. Calls to any other repository method panic.
+type stubRepository struct {
+	repository.CustomerRepository
+	customers []models.Customer
+}
+
+func (r *stubRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.Customer, error) {
+	for _, customer := range r.customers {
+		if customer.ID == id {
+			return &customer, nil
+		}
+	}
+	return nil, models.ErrCustomerNotFound
+}
@@ -2,7 +2,6 @@ package database
 
 import (
 	"customer-service/internal/config"
-	"customer-service/internal/customer/models"
 	"fmt"
 	"log"
 	"time"
@@ -74,24 +73,31 @@ func initDatabaseWithRetry(cfg *config.Config, maxRetries int, retryDelay time.D
 	return fmt.Errorf("failed to connect to database after %d attempts", maxRetries)
 }
 
-// AutoMigrate runs database migrations
-func AutoMigrate() error {
-	if DB == nil {
-		return fmt.Errorf("database connection not initialized")
+// MigrateUp applies all pending schema migrations
+func MigrateUp() error {
+	migrator, err := NewMigrator(DB)
+	if err != nil {
+		return err
 	}
 
-	// Run auto-migration for all models
-	err := DB.AutoMigrate(
-		&models.Customer{},
-	)
+	applied, err := migrator.Up()
 	if err != nil {
-		return fmt.Errorf("failed to run auto-migration: %w", err)
+		return fmt.Errorf("failed to run migrations: %w", err)
 	}
 
-	log.Println("Database migration completed successfully")
+	log.Printf("Database migration completed successfully (%d applied)", applied)
 	return nil
 }
 
+// CheckSchema returns an error unless every embedded migration has been applied
+func CheckSchema() error {
+	migrator, err := NewMigrator(DB)
+	if err != nil {
+		return err
+	}
+	return migrator.Check()
+}
+
 // GetDB returns the database connection
 func GetDB() *gorm.DB {
 	return DB
@@ -0,0 +1,22 @@
+-- Baseline schema. IF NOT EXISTS lets databases created by the former
+-- GORM auto-migration adopt this migration without changes.
+CREATE TABLE IF NOT EXISTS customers (
+    id                  UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    first_name          VARCHAR(100) NOT NULL,
+    last_name           VARCHAR(100) NOT NULL,
+    email               VARCHAR(255) NOT NULL,
+    phone               VARCHAR(20),
+    date_of_birth       DATE,
+    address_street      VARCHAR(255),
+    address_city        VARCHAR(100),
+    address_state       VARCHAR(100),
+    address_postal_code VARCHAR(20),
+    address_country     VARCHAR(100),
+    status              TEXT DEFAULT 'active',
+    created_at          TIMESTAMPTZ,
+    updated_at          TIMESTAMPTZ,
+    deleted_at          TIMESTAMPTZ
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_customers_email ON customers (email);
+CREATE INDEX IF NOT EXISTS idx_customers_deleted_at ON customers (deleted_at);
@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS customer_status_history (
+    id          UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    customer_id UUID NOT NULL REFERENCES customers (id),
+    from_status VARCHAR(20) NOT NULL,
+    to_status   VARCHAR(20) NOT NULL,
+    reason_code VARCHAR(50) NOT NULL,
+    note        VARCHAR(500),
+    actor       VARCHAR(255) NOT NULL,
+    created_at  TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_customer_status_history_customer_id ON customer_status_history (customer_id);
+CREATE INDEX IF NOT EXISTS idx_customer_status_history_created_at ON customer_status_history (created_at);
@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS idempotency_keys (
+    actor            VARCHAR(255) NOT NULL,
+    key              VARCHAR(255) NOT NULL,
+    request_hash     CHAR(64) NOT NULL,
+    completed        BOOLEAN NOT NULL DEFAULT FALSE,
+    status_code      INTEGER,
+    response_headers JSONB,
+    response_body    BYTEA,
+    created_at       TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    expires_at       TIMESTAMPTZ NOT NULL,
+    PRIMARY KEY (actor, key)
+);
+
+CREATE INDEX IF NOT EXISTS idx_idempotency_keys_expires_at ON idempotency_keys (expires_at);
@@ -0,0 +1,16 @@
+CREATE TABLE IF NOT EXISTS outbox_events (
+    sequence        BIGSERIAL PRIMARY KEY,
+    id              UUID NOT NULL UNIQUE DEFAULT gen_random_uuid(),
+    aggregate_id    UUID NOT NULL,
+    event_type      VARCHAR(100) NOT NULL,
+    payload         JSONB NOT NULL,
+    occurred_at     TIMESTAMPTZ NOT NULL,
+    published_at    TIMESTAMPTZ,
+    attempts        INTEGER NOT NULL DEFAULT 0,
+    last_error      TEXT,
+    next_attempt_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- Unpublished events are scanned in sequence order by the relay
+CREATE INDEX IF NOT EXISTS idx_outbox_events_unpublished ON outbox_events (sequence) WHERE published_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_outbox_events_aggregate_id ON outbox_events (aggregate_id, sequence);
@@ -0,0 +1,38 @@
+CREATE TABLE IF NOT EXISTS webhook_subscriptions (
+    id                   UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    url                  VARCHAR(2048) NOT NULL,
+    description          VARCHAR(255),
+    event_types          JSONB NOT NULL DEFAULT '[]',
+    secret               VARCHAR(255) NOT NULL,
+    active               BOOLEAN NOT NULL DEFAULT TRUE,
+    disabled_reason      VARCHAR(500),
+    disabled_at          TIMESTAMPTZ,
+    consecutive_failures INTEGER NOT NULL DEFAULT 0,
+    created_by           VARCHAR(255),
+    created_at           TIMESTAMPTZ,
+    updated_at           TIMESTAMPTZ
+);
+
+CREATE TABLE IF NOT EXISTS webhook_deliveries (
+    id               UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    subscription_id  UUID NOT NULL REFERENCES webhook_subscriptions (id) ON DELETE CASCADE,
+    event_id         UUID NOT NULL,
+    event_type       VARCHAR(100) NOT NULL,
+    payload          JSONB NOT NULL,
+    status           VARCHAR(20) NOT NULL,
+    attempts         INTEGER NOT NULL DEFAULT 0,
+    next_attempt_at  TIMESTAMPTZ,
+    last_attempt_at  TIMESTAMPTZ,
+    last_status_code INTEGER,
+    last_error       TEXT,
+    delivered_at     TIMESTAMPTZ,
+    replay_of        UUID REFERENCES webhook_deliveries (id) ON DELETE SET NULL,
+    created_at       TIMESTAMPTZ
+);
+
+-- An event is enqueued once per subscription; replays are separate rows
+CREATE UNIQUE INDEX IF NOT EXISTS idx_webhook_deliveries_event
+    ON webhook_deliveries (subscription_id, event_id) WHERE replay_of IS NULL;
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_subscription_id ON webhook_deliveries (subscription_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_due
+    ON webhook_deliveries (next_attempt_at) WHERE status = 'pending';
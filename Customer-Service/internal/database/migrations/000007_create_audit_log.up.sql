@@ -0,0 +1,32 @@
+CREATE TABLE IF NOT EXISTS audit_log (
+    sequence    BIGSERIAL PRIMARY KEY,
+    id          UUID NOT NULL UNIQUE,
+    occurred_at TIMESTAMPTZ NOT NULL,
+    actor       VARCHAR(255) NOT NULL,
+    action      VARCHAR(100) NOT NULL,
+    customer_id UUID,
+    request_id  VARCHAR(100),
+    client_ip   VARCHAR(64),
+    changes     JSONB,
+    prev_hash   CHAR(64) NOT NULL,
+    hash        CHAR(64) NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_audit_log_customer_id ON audit_log (customer_id, sequence);
+CREATE INDEX IF NOT EXISTS idx_audit_log_actor ON audit_log (actor, occurred_at);
+CREATE INDEX IF NOT EXISTS idx_audit_log_occurred_at ON audit_log (occurred_at);
+
+-- The audit log is append-only: reject any attempt to change or remove entries
+CREATE OR REPLACE FUNCTION audit_log_reject_change() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'audit_log is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER audit_log_no_update_delete
+    BEFORE UPDATE OR DELETE ON audit_log
+    FOR EACH ROW EXECUTE FUNCTION audit_log_reject_change();
+
+CREATE TRIGGER audit_log_no_truncate
+    BEFORE TRUNCATE ON audit_log
+    FOR EACH STATEMENT EXECUTE FUNCTION audit_log_reject_change();
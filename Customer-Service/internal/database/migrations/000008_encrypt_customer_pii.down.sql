@@ -0,0 +1,27 @@
+-- Encrypted values cannot be decrypted in SQL, so refuse to roll back over them
+DO $$
+BEGIN
+    IF EXISTS (SELECT 1 FROM customers WHERE key_version IS NOT NULL) THEN
+        RAISE EXCEPTION 'customers contain encrypted PII; it must be decrypted before rolling back';
+    END IF;
+END
+$$;
+
+DROP INDEX IF EXISTS idx_customers_key_version;
+DROP INDEX IF EXISTS idx_customers_phone_bidx;
+DROP INDEX IF EXISTS idx_customers_email_bidx;
+DROP INDEX IF EXISTS idx_customers_email_legacy;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_customers_email ON customers (email);
+
+ALTER TABLE customers
+    DROP COLUMN IF EXISTS key_version,
+    DROP COLUMN IF EXISTS phone_bidx,
+    DROP COLUMN IF EXISTS email_bidx,
+    ALTER COLUMN email TYPE VARCHAR(255),
+    ALTER COLUMN phone TYPE VARCHAR(20),
+    ALTER COLUMN date_of_birth TYPE DATE USING date_of_birth::date,
+    ALTER COLUMN address_street TYPE VARCHAR(255),
+    ALTER COLUMN address_city TYPE VARCHAR(100),
+    ALTER COLUMN address_state TYPE VARCHAR(100),
+    ALTER COLUMN address_postal_code TYPE VARCHAR(20),
+    ALTER COLUMN address_country TYPE VARCHAR(100);
@@ -0,0 +1,22 @@
+-- PII columns hold application-encrypted text from now on. Existing plaintext
+-- rows (key_version IS NULL) stay readable and are encrypted in the background.
+ALTER TABLE customers
+    ALTER COLUMN email TYPE TEXT,
+    ALTER COLUMN phone TYPE TEXT,
+    ALTER COLUMN date_of_birth TYPE TEXT USING to_char(date_of_birth, 'YYYY-MM-DD'),
+    ALTER COLUMN address_street TYPE TEXT,
+    ALTER COLUMN address_city TYPE TEXT,
+    ALTER COLUMN address_state TYPE TEXT,
+    ALTER COLUMN address_postal_code TYPE TEXT,
+    ALTER COLUMN address_country TYPE TEXT,
+    ADD COLUMN IF NOT EXISTS email_bidx CHAR(64),
+    ADD COLUMN IF NOT EXISTS phone_bidx CHAR(64),
+    ADD COLUMN IF NOT EXISTS key_version INTEGER;
+
+-- Ciphertexts differ on every write, so uniqueness moves to the blind index.
+-- Plaintext rows keep a unique email until they are encrypted.
+DROP INDEX IF EXISTS idx_customers_email;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_customers_email_legacy ON customers (email) WHERE key_version IS NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_customers_email_bidx ON customers (email_bidx);
+CREATE INDEX IF NOT EXISTS idx_customers_phone_bidx ON customers (phone_bidx);
+CREATE INDEX IF NOT EXISTS idx_customers_key_version ON customers (key_version);
@@ -0,0 +1,5 @@
+ALTER TABLE customers ALTER COLUMN status SET DEFAULT 'active';
+
+DROP TABLE IF EXISTS kyc_decisions;
+DROP TABLE IF EXISTS kyc_documents;
+DROP TABLE IF EXISTS kyc_cases;
@@ -0,0 +1,52 @@
+CREATE TABLE IF NOT EXISTS kyc_cases (
+    id           UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    customer_id  UUID NOT NULL REFERENCES customers (id),
+    status       VARCHAR(20) NOT NULL
+        CHECK (status IN ('pending', 'in_review', 'verified', 'rejected', 'expired')),
+    opened_by    VARCHAR(255) NOT NULL,
+    submitted_at TIMESTAMPTZ,
+    decided_at   TIMESTAMPTZ,
+    expires_at   TIMESTAMPTZ,
+    created_at   TIMESTAMPTZ,
+    updated_at   TIMESTAMPTZ
+);
+
+-- A customer has at most one case that is still being worked on
+CREATE UNIQUE INDEX IF NOT EXISTS idx_kyc_cases_open
+    ON kyc_cases (customer_id) WHERE status IN ('pending', 'in_review');
+CREATE INDEX IF NOT EXISTS idx_kyc_cases_customer_id ON kyc_cases (customer_id, decided_at);
+CREATE INDEX IF NOT EXISTS idx_kyc_cases_expiry ON kyc_cases (expires_at) WHERE status = 'verified';
+
+CREATE TABLE IF NOT EXISTS kyc_documents (
+    id              UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    case_id         UUID NOT NULL REFERENCES kyc_cases (id),
+    customer_id     UUID NOT NULL REFERENCES customers (id),
+    type            VARCHAR(30) NOT NULL
+        CHECK (type IN ('passport', 'national_id', 'proof_of_address')),
+    file_name       VARCHAR(255) NOT NULL,
+    content_type    VARCHAR(100) NOT NULL,
+    size            BIGINT NOT NULL,
+    sha256          CHAR(64) NOT NULL,
+    storage_key     VARCHAR(500) NOT NULL UNIQUE,
+    issuing_country CHAR(2),
+    expires_on      DATE,
+    uploaded_by     VARCHAR(255) NOT NULL,
+    created_at      TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_kyc_documents_case_id ON kyc_documents (case_id);
+
+CREATE TABLE IF NOT EXISTS kyc_decisions (
+    id         UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    case_id    UUID NOT NULL REFERENCES kyc_cases (id),
+    decision   VARCHAR(20) NOT NULL
+        CHECK (decision IN ('approve', 'reject', 'request_changes')),
+    reason     VARCHAR(500),
+    reviewer   VARCHAR(255) NOT NULL,
+    created_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_kyc_decisions_case_id ON kyc_decisions (case_id);
+
+-- New customers wait for KYC verification before they can be activated
+ALTER TABLE customers ALTER COLUMN status SET DEFAULT 'inactive';
@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS screening_runs;
+DROP TABLE IF EXISTS screening_alerts;
+DROP TABLE IF EXISTS watchlist_entries;
+DROP TABLE IF EXISTS watchlists;
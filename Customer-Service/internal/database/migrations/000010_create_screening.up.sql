@@ -0,0 +1,75 @@
+CREATE TABLE IF NOT EXISTS watchlists (
+    id          UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    name        VARCHAR(100) NOT NULL,
+    format      VARCHAR(30) NOT NULL
+        CHECK (format IN ('ofac_sdn', 'eu_consolidated')),
+    category    VARCHAR(20) NOT NULL
+        CHECK (category IN ('sanctions', 'pep')),
+    file_name   VARCHAR(255) NOT NULL,
+    sha256      CHAR(64) NOT NULL,
+    entry_count INTEGER NOT NULL,
+    active      BOOLEAN NOT NULL,
+    loaded_by   VARCHAR(255) NOT NULL,
+    created_at  TIMESTAMPTZ
+);
+
+-- Only one version of each list is active; loading it again retires the previous one
+CREATE UNIQUE INDEX IF NOT EXISTS idx_watchlists_active_name ON watchlists (name) WHERE active;
+
+CREATE TABLE IF NOT EXISTS watchlist_entries (
+    id             UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    watchlist_id   UUID NOT NULL REFERENCES watchlists (id),
+    external_id    VARCHAR(100) NOT NULL,
+    kind           VARCHAR(20) NOT NULL
+        CHECK (kind IN ('individual', 'entity')),
+    names          JSONB NOT NULL,
+    dates_of_birth JSONB NOT NULL,
+    countries      JSONB NOT NULL,
+    programs       JSONB NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_watchlist_entries_watchlist_id ON watchlist_entries (watchlist_id, kind);
+
+CREATE TABLE IF NOT EXISTS screening_alerts (
+    id                  UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    customer_id         UUID NOT NULL REFERENCES customers (id),
+    watchlist_id        UUID NOT NULL REFERENCES watchlists (id),
+    -- Entries are removed when their list is replaced; the alert keeps its own copy
+    entry_id            UUID REFERENCES watchlist_entries (id) ON DELETE SET NULL,
+    list_name           VARCHAR(100) NOT NULL,
+    category            VARCHAR(20) NOT NULL,
+    external_id         VARCHAR(100) NOT NULL,
+    matched_name        VARCHAR(500) NOT NULL,
+    score               DOUBLE PRECISION NOT NULL,
+    details             JSONB NOT NULL,
+    subject_fingerprint VARCHAR(64) NOT NULL,
+    trigger             VARCHAR(20) NOT NULL
+        CHECK (trigger IN ('onboarding', 'customer_update', 'rescreen', 'manual')),
+    status              VARCHAR(20) NOT NULL
+        CHECK (status IN ('open', 'confirmed_match', 'false_positive')),
+    disposition_note    VARCHAR(1000),
+    dispositioned_by    VARCHAR(255),
+    dispositioned_at    TIMESTAMPTZ,
+    created_at          TIMESTAMPTZ,
+    updated_at          TIMESTAMPTZ
+);
+
+-- A customer is alerted once per listed party until their screened details change
+CREATE UNIQUE INDEX IF NOT EXISTS idx_screening_alerts_dedup
+    ON screening_alerts (customer_id, list_name, external_id, subject_fingerprint);
+CREATE INDEX IF NOT EXISTS idx_screening_alerts_status ON screening_alerts (status, created_at);
+CREATE INDEX IF NOT EXISTS idx_screening_alerts_customer_id ON screening_alerts (customer_id, created_at);
+
+CREATE TABLE IF NOT EXISTS screening_runs (
+    id           UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    watchlist_id UUID REFERENCES watchlists (id),
+    status       VARCHAR(20) NOT NULL
+        CHECK (status IN ('running', 'completed', 'superseded')),
+    cursor       UUID NOT NULL,
+    screened     INTEGER NOT NULL,
+    alerts       INTEGER NOT NULL,
+    started_at   TIMESTAMPTZ NOT NULL,
+    finished_at  TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_screening_runs_running ON screening_runs (started_at) WHERE status = 'running';
@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS customer_merges (
+    merged_id      UUID PRIMARY KEY REFERENCES customers (id),
+    survivor_id    UUID NOT NULL REFERENCES customers (id),
+    changed_fields JSONB NOT NULL DEFAULT '[]',
+    reason         VARCHAR(500) NOT NULL,
+    merged_by      VARCHAR(255) NOT NULL,
+    created_at     TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_customer_merges_survivor_id ON customer_merges (survivor_id);
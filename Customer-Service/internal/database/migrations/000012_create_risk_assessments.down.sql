@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS risk_assessments;
+
+ALTER TABLE customers
+    DROP COLUMN IF EXISTS products,
+    DROP COLUMN IF EXISTS occupation;
@@ -0,0 +1,17 @@
+ALTER TABLE customers
+    ADD COLUMN IF NOT EXISTS occupation VARCHAR(100),
+    ADD COLUMN IF NOT EXISTS products   JSONB NOT NULL DEFAULT '[]';
+
+CREATE TABLE IF NOT EXISTS risk_assessments (
+    id             UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    customer_id    UUID NOT NULL REFERENCES customers (id),
+    score          INTEGER NOT NULL,
+    rating         VARCHAR(10) NOT NULL CHECK (rating IN ('low', 'medium', 'high')),
+    factors        JSONB NOT NULL DEFAULT '[]',
+    rules_version  VARCHAR(100) NOT NULL,
+    trigger        VARCHAR(30) NOT NULL,
+    next_review_at TIMESTAMPTZ NOT NULL,
+    created_at     TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_risk_assessments_customer_id ON risk_assessments (customer_id, created_at DESC);
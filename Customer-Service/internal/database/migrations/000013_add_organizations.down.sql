@@ -0,0 +1,10 @@
+DROP TABLE IF EXISTS beneficial_owners;
+
+DROP INDEX IF EXISTS idx_customers_registration;
+
+ALTER TABLE customers
+    DROP COLUMN IF EXISTS jurisdiction,
+    DROP COLUMN IF EXISTS incorporation_date,
+    DROP COLUMN IF EXISTS registration_number,
+    DROP COLUMN IF EXISTS legal_name,
+    DROP COLUMN IF EXISTS type;
@@ -0,0 +1,24 @@
+ALTER TABLE customers
+    ADD COLUMN IF NOT EXISTS type                VARCHAR(20) NOT NULL DEFAULT 'individual'
+        CHECK (type IN ('individual', 'organization')),
+    ADD COLUMN IF NOT EXISTS legal_name          VARCHAR(200),
+    ADD COLUMN IF NOT EXISTS registration_number VARCHAR(100),
+    ADD COLUMN IF NOT EXISTS incorporation_date  DATE,
+    ADD COLUMN IF NOT EXISTS jurisdiction        VARCHAR(100);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_customers_registration
+    ON customers (jurisdiction, registration_number)
+    WHERE type = 'organization' AND deleted_at IS NULL;
+
+CREATE TABLE IF NOT EXISTS beneficial_owners (
+    id                   UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    organization_id      UUID NOT NULL REFERENCES customers (id),
+    owner_id             UUID NOT NULL REFERENCES customers (id),
+    role                 VARCHAR(20) NOT NULL CHECK (role IN ('owner', 'controller')),
+    ownership_percentage NUMERIC(5, 2) NOT NULL DEFAULT 0
+        CHECK (ownership_percentage >= 0 AND ownership_percentage <= 100),
+    created_at           TIMESTAMPTZ,
+    UNIQUE (organization_id, owner_id, role)
+);
+
+CREATE INDEX IF NOT EXISTS idx_beneficial_owners_owner_id ON beneficial_owners (owner_id);
@@ -0,0 +1,20 @@
+CREATE TABLE IF NOT EXISTS customer_relationships (
+    id               UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    from_customer_id UUID NOT NULL REFERENCES customers (id),
+    to_customer_id   UUID NOT NULL REFERENCES customers (id),
+    type             VARCHAR(30) NOT NULL CHECK (type IN (
+        'spouse', 'guardian_of', 'minor_of', 'authorized_signatory', 'power_of_attorney', 'household_member'
+    )),
+    effective_from   DATE NOT NULL,
+    effective_to     DATE,
+    note             VARCHAR(500),
+    created_by       VARCHAR(255) NOT NULL,
+    ended_by         VARCHAR(255),
+    created_at       TIMESTAMPTZ,
+    updated_at       TIMESTAMPTZ,
+    CHECK (from_customer_id <> to_customer_id),
+    CHECK (effective_to IS NULL OR effective_to >= effective_from)
+);
+
+CREATE INDEX IF NOT EXISTS idx_customer_relationships_from_customer_id ON customer_relationships (from_customer_id);
+CREATE INDEX IF NOT EXISTS idx_customer_relationships_to_customer_id ON customer_relationships (to_customer_id);
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS customer_contact_points;
+
+DROP TABLE IF EXISTS customer_addresses;
@@ -0,0 +1,59 @@
+CREATE TABLE IF NOT EXISTS customer_addresses (
+    id                  UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    customer_id         UUID NOT NULL REFERENCES customers (id),
+    type                VARCHAR(20) NOT NULL CHECK (type IN ('residential', 'mailing', 'work')),
+    is_primary          BOOLEAN NOT NULL DEFAULT FALSE,
+    address_street      TEXT,
+    address_city        TEXT,
+    address_state       TEXT,
+    address_postal_code TEXT,
+    address_country     TEXT,
+    valid_from          DATE NOT NULL,
+    valid_to            DATE,
+    verification_status VARCHAR(20) NOT NULL DEFAULT 'unverified'
+        CHECK (verification_status IN ('unverified', 'verified', 'failed')),
+    verified_at         TIMESTAMPTZ,
+    key_version         INTEGER,
+    created_at          TIMESTAMPTZ,
+    updated_at          TIMESTAMPTZ,
+    CHECK (valid_to IS NULL OR valid_to > valid_from)
+);
+
+CREATE INDEX IF NOT EXISTS idx_customer_addresses_customer_id ON customer_addresses (customer_id);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_customer_addresses_primary
+    ON customer_addresses (customer_id, type)
+    WHERE is_primary;
+
+CREATE TABLE IF NOT EXISTS customer_contact_points (
+    id                  UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    customer_id         UUID NOT NULL REFERENCES customers (id),
+    kind                VARCHAR(10) NOT NULL CHECK (kind IN ('email', 'phone')),
+    type                VARCHAR(20) NOT NULL CHECK (type IN ('personal', 'work', 'mobile', 'home')),
+    is_primary          BOOLEAN NOT NULL DEFAULT FALSE,
+    email               TEXT,
+    phone               TEXT,
+    email_bidx          VARCHAR(64),
+    phone_bidx          VARCHAR(64),
+    valid_from          DATE NOT NULL,
+    valid_to            DATE,
+    verification_status VARCHAR(20) NOT NULL DEFAULT 'unverified'
+        CHECK (verification_status IN ('unverified', 'verified', 'failed')),
+    verified_at         TIMESTAMPTZ,
+    key_version         INTEGER,
+    created_at          TIMESTAMPTZ,
+    updated_at          TIMESTAMPTZ,
+    CHECK ((kind = 'email' AND email IS NOT NULL AND phone IS NULL)
+        OR (kind = 'phone' AND phone IS NOT NULL AND email IS NULL)),
+    CHECK (valid_to IS NULL OR valid_to > valid_from)
+);
+
+CREATE INDEX IF NOT EXISTS idx_customer_contact_points_customer_id ON customer_contact_points (customer_id);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_customer_contact_points_primary
+    ON customer_contact_points (customer_id, kind)
+    WHERE is_primary;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_customer_contact_points_email
+    ON customer_contact_points (customer_id, email_bidx)
+    WHERE kind = 'email';
+CREATE UNIQUE INDEX IF NOT EXISTS idx_customer_contact_points_phone
+    ON customer_contact_points (customer_id, phone_bidx)
+    WHERE kind = 'phone';
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS consent_records;
+
+DROP TABLE IF EXISTS customer_consents;
@@ -0,0 +1,36 @@
+CREATE TABLE IF NOT EXISTS customer_consents (
+    id             UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    customer_id    UUID NOT NULL REFERENCES customers (id),
+    purpose        VARCHAR(30) NOT NULL CHECK (purpose IN ('marketing', 'data_sharing', 'profiling')),
+    channel        VARCHAR(20) NOT NULL DEFAULT '' CHECK (channel IN ('', 'email', 'sms', 'phone', 'post')),
+    status         VARCHAR(20) NOT NULL CHECK (status IN ('granted', 'withdrawn')),
+    policy_version VARCHAR(50) NOT NULL,
+    source         VARCHAR(20) NOT NULL,
+    granted_at     TIMESTAMPTZ,
+    withdrawn_at   TIMESTAMPTZ,
+    created_at     TIMESTAMPTZ,
+    updated_at     TIMESTAMPTZ,
+    UNIQUE (customer_id, purpose, channel),
+    CHECK ((purpose = 'marketing') = (channel <> ''))
+);
+
+-- Serves the contactable customers query, which pages through one channel in customer order
+CREATE INDEX IF NOT EXISTS idx_customer_consents_granted
+    ON customer_consents (purpose, channel, customer_id)
+    WHERE status = 'granted';
+
+CREATE TABLE IF NOT EXISTS consent_records (
+    id             UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    customer_id    UUID NOT NULL REFERENCES customers (id),
+    purpose        VARCHAR(30) NOT NULL,
+    channel        VARCHAR(20) NOT NULL DEFAULT '',
+    status         VARCHAR(20) NOT NULL CHECK (status IN ('granted', 'withdrawn')),
+    policy_version VARCHAR(50) NOT NULL,
+    source         VARCHAR(20) NOT NULL,
+    effective_at   TIMESTAMPTZ NOT NULL,
+    note           VARCHAR(500),
+    recorded_by    VARCHAR(255) NOT NULL,
+    created_at     TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_consent_records_customer_id ON consent_records (customer_id);
@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS idx_customers_email_legacy;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_customers_email_legacy ON customers (email) WHERE key_version IS NULL;
+
+ALTER TABLE customers DROP COLUMN IF EXISTS erased_at;
+
+DROP TABLE IF EXISTS data_subject_requests;
@@ -0,0 +1,35 @@
+CREATE TABLE IF NOT EXISTS data_subject_requests (
+    id               UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    customer_id      UUID NOT NULL REFERENCES customers (id),
+    type             VARCHAR(20) NOT NULL CHECK (type IN ('access', 'erasure')),
+    status           VARCHAR(20) NOT NULL
+        CHECK (status IN ('received', 'in_progress', 'completed', 'rejected', 'failed')),
+    note             VARCHAR(500),
+    requested_by     VARCHAR(255) NOT NULL,
+    due_at           TIMESTAMPTZ NOT NULL,
+    processed_by     VARCHAR(255),
+    closed_at        TIMESTAMPTZ,
+    rejection_reason VARCHAR(500),
+    last_error       TEXT,
+    evidence         JSONB,
+    export_key       VARCHAR(500),
+    export_sha256    CHAR(64),
+    export_size      BIGINT,
+    created_at       TIMESTAMPTZ,
+    updated_at       TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_data_subject_requests_customer_id ON data_subject_requests (customer_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_data_subject_requests_due
+    ON data_subject_requests (due_at) WHERE status IN ('received', 'in_progress', 'failed');
+-- A customer has at most one open request of each type
+CREATE UNIQUE INDEX IF NOT EXISTS idx_data_subject_requests_open
+    ON data_subject_requests (customer_id, type)
+    WHERE status IN ('received', 'in_progress', 'failed');
+
+-- Erased customers keep their row, emptied of PII, and are no longer re-encrypted
+ALTER TABLE customers ADD COLUMN IF NOT EXISTS erased_at TIMESTAMPTZ;
+
+DROP INDEX IF EXISTS idx_customers_email_legacy;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_customers_email_legacy
+    ON customers (email) WHERE key_version IS NULL AND erased_at IS NULL;
@@ -0,0 +1,6 @@
+DROP TABLE IF EXISTS retention_run_items;
+DROP TABLE IF EXISTS retention_runs;
+DROP TABLE IF EXISTS retention_policies;
+
+ALTER TABLE customers DROP COLUMN IF EXISTS legal_hold_reason;
+ALTER TABLE customers DROP COLUMN IF EXISTS legal_hold;
@@ -0,0 +1,49 @@
+-- A customer on legal hold is never purged, whatever the retention policy says
+ALTER TABLE customers ADD COLUMN IF NOT EXISTS legal_hold BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE customers ADD COLUMN IF NOT EXISTS legal_hold_reason VARCHAR(500);
+
+CREATE TABLE IF NOT EXISTS retention_policies (
+    status         VARCHAR(20) PRIMARY KEY
+        CHECK (status IN ('active', 'inactive', 'suspended', 'closed')),
+    retention_days INTEGER NOT NULL CHECK (retention_days > 0),
+    action         VARCHAR(20) NOT NULL CHECK (action IN ('delete', 'anonymize')),
+    updated_by     VARCHAR(255) NOT NULL,
+    created_at     TIMESTAMPTZ,
+    updated_at     TIMESTAMPTZ
+);
+
+CREATE TABLE IF NOT EXISTS retention_runs (
+    id           UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    dry_run      BOOLEAN NOT NULL,
+    status       VARCHAR(20) NOT NULL CHECK (status IN ('running', 'completed')),
+    started_by   VARCHAR(255) NOT NULL,
+    cursor       UUID NOT NULL,
+    examined     INTEGER NOT NULL,
+    deleted      INTEGER NOT NULL,
+    anonymized   INTEGER NOT NULL,
+    held         INTEGER NOT NULL,
+    failed       INTEGER NOT NULL,
+    started_at   TIMESTAMPTZ NOT NULL,
+    finished_at  TIMESTAMPTZ
+);
+
+-- Only one run purges at a time
+CREATE UNIQUE INDEX IF NOT EXISTS idx_retention_runs_running ON retention_runs ((TRUE)) WHERE status = 'running';
+CREATE INDEX IF NOT EXISTS idx_retention_runs_started_at ON retention_runs (started_at);
+
+-- Items outlive the customers they name, so customer_id has no foreign key
+CREATE TABLE IF NOT EXISTS retention_run_items (
+    id              UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    run_id          UUID NOT NULL REFERENCES retention_runs (id) ON DELETE CASCADE,
+    customer_id     UUID NOT NULL,
+    customer_status VARCHAR(20) NOT NULL,
+    deleted_at      TIMESTAMPTZ NOT NULL,
+    action          VARCHAR(20) NOT NULL,
+    outcome         VARCHAR(20) NOT NULL
+        CHECK (outcome IN ('deleted', 'anonymized', 'would_delete', 'would_anonymize', 'held', 'failed')),
+    merged_ids      JSONB,
+    error           TEXT,
+    created_at      TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_retention_run_items_run_id ON retention_run_items (run_id, outcome, customer_id);
@@ -0,0 +1 @@
+DROP INDEX IF EXISTS idx_customers_created_at_id;
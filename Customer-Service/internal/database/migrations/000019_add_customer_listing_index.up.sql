@@ -0,0 +1,5 @@
+-- Customers are listed newest first, with the id breaking ties, so that a page can
+-- start right after the last customer of the previous one
+CREATE INDEX IF NOT EXISTS idx_customers_created_at_id
+    ON customers (created_at DESC, id DESC)
+    WHERE deleted_at IS NULL;
@@ -0,0 +1,8 @@
+DROP INDEX IF EXISTS idx_customers_search_vector;
+DROP INDEX IF EXISTS idx_customers_search_name;
+
+ALTER TABLE customers
+    DROP COLUMN IF EXISTS search_vector,
+    DROP COLUMN IF EXISTS search_name;
+
+DROP FUNCTION IF EXISTS customer_search_name(TEXT, TEXT, TEXT);
@@ -0,0 +1,20 @@
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+CREATE EXTENSION IF NOT EXISTS unaccent;
+
+-- Folds a customer's names for search: accents removed and lowercased. unaccent is
+-- only stable, because its dictionary could change; naming the dictionary lets the
+-- function be declared immutable and used in generated columns.
+CREATE OR REPLACE FUNCTION customer_search_name(first_name TEXT, last_name TEXT, legal_name TEXT)
+RETURNS TEXT AS $$
+    SELECT lower(public.unaccent('public.unaccent'::regdictionary, concat_ws(' ', first_name, last_name, legal_name)))
+$$ LANGUAGE SQL IMMUTABLE PARALLEL SAFE;
+
+ALTER TABLE customers
+    ADD COLUMN IF NOT EXISTS search_name TEXT
+        GENERATED ALWAYS AS (customer_search_name(first_name, last_name, legal_name)) STORED,
+    ADD COLUMN IF NOT EXISTS search_vector TSVECTOR
+        GENERATED ALWAYS AS (to_tsvector('simple', customer_search_name(first_name, last_name, legal_name))) STORED;
+
+-- Trigram index for substring and similarity matches, full-text index for word prefixes
+CREATE INDEX IF NOT EXISTS idx_customers_search_name ON customers USING GIN (search_name gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS idx_customers_search_vector ON customers USING GIN (search_vector);
@@ -0,0 +1,18 @@
+DROP INDEX IF EXISTS idx_customers_last_name_id;
+DROP INDEX IF EXISTS idx_customers_updated_at_id;
+DROP INDEX IF EXISTS idx_customers_blind_index_version;
+DROP INDEX IF EXISTS idx_customers_birth_date_bidx;
+DROP INDEX IF EXISTS idx_customers_birth_month_bidx;
+DROP INDEX IF EXISTS idx_customers_birth_year_bidx;
+DROP INDEX IF EXISTS idx_customers_address_state_bidx;
+DROP INDEX IF EXISTS idx_customers_address_city_bidx;
+DROP INDEX IF EXISTS idx_customers_address_country_bidx;
+
+ALTER TABLE customers
+    DROP COLUMN IF EXISTS blind_index_version,
+    DROP COLUMN IF EXISTS birth_date_bidx,
+    DROP COLUMN IF EXISTS birth_month_bidx,
+    DROP COLUMN IF EXISTS birth_year_bidx,
+    DROP COLUMN IF EXISTS address_state_bidx,
+    DROP COLUMN IF EXISTS address_city_bidx,
+    DROP COLUMN IF EXISTS address_country_bidx;
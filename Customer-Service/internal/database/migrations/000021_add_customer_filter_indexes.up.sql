@@ -0,0 +1,26 @@
+-- Blind indexes of the encrypted address and date of birth, so listings can be
+-- filtered on them. The date of birth is indexed by year, month and day, which lets
+-- a range be covered by a few whole years and months and the days at its ends.
+-- Existing rows are indexed in the background by the re-encryption job, which
+-- rewrites rows whose blind_index_version is behind.
+ALTER TABLE customers
+    ADD COLUMN IF NOT EXISTS address_country_bidx CHAR(64),
+    ADD COLUMN IF NOT EXISTS address_city_bidx    CHAR(64),
+    ADD COLUMN IF NOT EXISTS address_state_bidx   CHAR(64),
+    ADD COLUMN IF NOT EXISTS birth_year_bidx      CHAR(64),
+    ADD COLUMN IF NOT EXISTS birth_month_bidx     CHAR(64),
+    ADD COLUMN IF NOT EXISTS birth_date_bidx      CHAR(64),
+    ADD COLUMN IF NOT EXISTS blind_index_version  INTEGER NOT NULL DEFAULT 1;
+
+CREATE INDEX IF NOT EXISTS idx_customers_address_country_bidx ON customers (address_country_bidx);
+CREATE INDEX IF NOT EXISTS idx_customers_address_city_bidx ON customers (address_city_bidx);
+CREATE INDEX IF NOT EXISTS idx_customers_address_state_bidx ON customers (address_state_bidx);
+CREATE INDEX IF NOT EXISTS idx_customers_birth_year_bidx ON customers (birth_year_bidx);
+CREATE INDEX IF NOT EXISTS idx_customers_birth_month_bidx ON customers (birth_month_bidx);
+CREATE INDEX IF NOT EXISTS idx_customers_birth_date_bidx ON customers (birth_date_bidx);
+CREATE INDEX IF NOT EXISTS idx_customers_blind_index_version ON customers (blind_index_version);
+
+-- Sorting by the other whitelisted fields, with the id breaking ties as in the
+-- default order
+CREATE INDEX IF NOT EXISTS idx_customers_updated_at_id ON customers (updated_at, id) WHERE deleted_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_customers_last_name_id ON customers (last_name, id) WHERE deleted_at IS NULL;
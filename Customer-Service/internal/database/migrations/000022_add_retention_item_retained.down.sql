@@ -0,0 +1 @@
+ALTER TABLE retention_run_items DROP COLUMN IF EXISTS retained;
@@ -0,0 +1,2 @@
+-- The records a retention run kept of each customer it purged, and why
+ALTER TABLE retention_run_items ADD COLUMN IF NOT EXISTS retained JSONB;
@@ -0,0 +1,9 @@
+DROP INDEX IF EXISTS idx_outbox_events_pending_aggregate;
+DROP INDEX IF EXISTS idx_outbox_events_pending;
+DROP INDEX IF EXISTS idx_outbox_events_aggregate_version;
+
+CREATE INDEX IF NOT EXISTS idx_outbox_events_unpublished ON outbox_events (sequence) WHERE published_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_outbox_events_aggregate_id ON outbox_events (aggregate_id, sequence);
+
+ALTER TABLE outbox_events DROP COLUMN IF EXISTS dead_lettered_at;
+ALTER TABLE outbox_events DROP COLUMN IF EXISTS aggregate_version;
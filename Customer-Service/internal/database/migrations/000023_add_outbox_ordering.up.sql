@@ -0,0 +1,32 @@
+-- Events are ordered per customer by aggregate_version, counting from 1, rather
+-- than by the global sequence, whose values are not committed in order
+ALTER TABLE outbox_events ADD COLUMN IF NOT EXISTS aggregate_version BIGINT;
+
+UPDATE outbox_events o
+SET aggregate_version = v.version
+FROM (
+    SELECT sequence, ROW_NUMBER() OVER (PARTITION BY aggregate_id ORDER BY sequence) AS version
+    FROM outbox_events
+) v
+WHERE o.sequence = v.sequence AND o.aggregate_version IS NULL;
+
+ALTER TABLE outbox_events ALTER COLUMN aggregate_version SET NOT NULL;
+
+-- Events the relay gave up on after too many failed attempts
+ALTER TABLE outbox_events ADD COLUMN IF NOT EXISTS dead_lettered_at TIMESTAMPTZ;
+
+DROP INDEX IF EXISTS idx_outbox_events_unpublished;
+DROP INDEX IF EXISTS idx_outbox_events_aggregate_id;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_outbox_events_aggregate_version
+    ON outbox_events (aggregate_id, aggregate_version);
+
+-- The relay scans pending events in sequence order, skipping customers whose
+-- earliest pending event is not yet due
+CREATE INDEX IF NOT EXISTS idx_outbox_events_pending
+    ON outbox_events (sequence)
+    WHERE published_at IS NULL AND dead_lettered_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_outbox_events_pending_aggregate
+    ON outbox_events (aggregate_id, aggregate_version)
+    INCLUDE (next_attempt_at)
+    WHERE published_at IS NULL AND dead_lettered_at IS NULL;
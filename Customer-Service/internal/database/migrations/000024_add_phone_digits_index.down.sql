@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_customers_phone_digits_bidx;
+ALTER TABLE customers DROP COLUMN IF EXISTS phone_digits_bidx;
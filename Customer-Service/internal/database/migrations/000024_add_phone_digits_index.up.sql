@@ -0,0 +1,7 @@
+-- Blind indexes of every run of four or more of a customer's phone digits, so that
+-- part of an encrypted phone number can be searched for. Existing rows are indexed
+-- by the re-encryption job, as their blind_index_version is behind.
+ALTER TABLE customers ADD COLUMN IF NOT EXISTS phone_digits_bidx JSONB;
+
+CREATE INDEX IF NOT EXISTS idx_customers_phone_digits_bidx
+    ON customers USING GIN (phone_digits_bidx jsonb_path_ops);
@@ -0,0 +1,3 @@
+-- A reservation is held until locked_until; a retry of the same request can take
+-- over one whose holder did not complete or release it in time, as after a crash
+ALTER TABLE idempotency_keys ADD COLUMN IF NOT EXISTS locked_until TIMESTAMPTZ NOT NULL DEFAULT NOW();
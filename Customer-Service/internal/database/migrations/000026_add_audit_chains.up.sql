@@ -0,0 +1,7 @@
+-- Entries are chained per customer from now on, so that appends for different
+-- customers no longer wait on each other. chain_id is the customer an entry is
+-- chained with, or the nil UUID for an entry without one. Entries written before
+-- have none and stay on the single chain they were written to.
+ALTER TABLE audit_log ADD COLUMN IF NOT EXISTS chain_id UUID;
+
+CREATE INDEX IF NOT EXISTS idx_audit_log_chain ON audit_log (chain_id, sequence) WHERE chain_id IS NOT NULL;
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_idempotency_keys_resource;
+ALTER TABLE idempotency_keys DROP COLUMN IF EXISTS resource_id;
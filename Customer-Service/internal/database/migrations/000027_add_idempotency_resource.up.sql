@@ -0,0 +1,17 @@
+-- resource_id is the resource a request acted on, so that stored responses about a
+-- customer can be removed when the customer is erased. Responses stored before are
+-- linked through the id of the customer they returned.
+ALTER TABLE idempotency_keys ADD COLUMN IF NOT EXISTS resource_id VARCHAR(255) NOT NULL DEFAULT '';
+
+UPDATE idempotency_keys
+SET resource_id = COALESCE(body->>'id', body->'customer'->>'id', '')
+FROM (
+    SELECT actor AS body_actor, key AS body_key, convert_from(response_body, 'UTF8')::jsonb AS body
+    FROM idempotency_keys
+    WHERE completed
+      AND length(response_body) > 0
+      AND response_headers->>'Content-Type' LIKE 'application/json%'
+) AS bodies
+WHERE actor = body_actor AND key = body_key;
+
+CREATE INDEX IF NOT EXISTS idx_idempotency_keys_resource ON idempotency_keys (resource_id) WHERE resource_id <> '';
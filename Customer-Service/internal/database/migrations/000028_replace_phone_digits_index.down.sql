@@ -0,0 +1,10 @@
+DROP INDEX IF EXISTS idx_customers_phone_suffix_bidx;
+ALTER TABLE customers DROP COLUMN IF EXISTS phone_suffix_bidx;
+
+ALTER TABLE customers ADD COLUMN IF NOT EXISTS phone_digits_bidx JSONB;
+
+CREATE INDEX IF NOT EXISTS idx_customers_phone_digits_bidx
+    ON customers USING GIN (phone_digits_bidx jsonb_path_ops);
+
+-- Have the re-encryption job index the runs of digits again
+UPDATE customers SET blind_index_version = 2 WHERE blind_index_version > 2;
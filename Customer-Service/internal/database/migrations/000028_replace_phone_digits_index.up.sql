@@ -0,0 +1,10 @@
+-- Blind indexes of every run of a customer's phone digits revealed which customers
+-- share any run of digits. They are replaced by a blind index of the last four
+-- digits alone. Existing rows are indexed by the re-encryption job, as their
+-- blind_index_version is behind.
+DROP INDEX IF EXISTS idx_customers_phone_digits_bidx;
+ALTER TABLE customers DROP COLUMN IF EXISTS phone_digits_bidx;
+
+ALTER TABLE customers ADD COLUMN IF NOT EXISTS phone_suffix_bidx VARCHAR(64);
+
+CREATE INDEX IF NOT EXISTS idx_customers_phone_suffix_bidx ON customers (phone_suffix_bidx);
@@ -0,0 +1,2 @@
+-- Fails while encrypted secrets longer than the old column are stored
+ALTER TABLE webhook_subscriptions ALTER COLUMN secret TYPE VARCHAR(255);
@@ -0,0 +1,3 @@
+-- Signing secrets are stored encrypted, which takes more room than the secret
+-- itself. Secrets stored before are encrypted by the service at startup.
+ALTER TABLE webhook_subscriptions ALTER COLUMN secret TYPE TEXT;
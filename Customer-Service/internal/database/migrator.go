@@ -0,0 +1,464 @@
+package database
+
+import (
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+// MigrationsDir is the source directory of the embedded migrations, relative to the module root
+const MigrationsDir = "internal/database/migrations"
+
+// noTransactionDirective marks a migration that must run outside a transaction,
+// e.g. one using CREATE INDEX CONCURRENTLY
+const noTransactionDirective = "-- migrate:no-transaction"
+
+// migrationLockKey is the PostgreSQL advisory lock held while migrations run
+const migrationLockKey = 7246001
+
+var migrationFilePattern = regexp.MustCompile(`^(\d+)_([a-z0-9_]+)\.(up|down)\.sql$`)
+
+var (
+	// ErrSchemaOutdated is returned when migrations are pending
+	ErrSchemaOutdated = errors.New("database schema is behind the application")
+	// ErrSchemaDirty is returned when a previous migration failed part way through
+	ErrSchemaDirty = errors.New("database schema is dirty")
+)
+
+// Migration is a single versioned schema change
+type Migration struct {
+	Version int64
+	Name    string
+	Up      string
+	Down    string
+}
+
+// MigrationStatus describes whether a migration has been applied
+type MigrationStatus struct {
+	Version   int64
+	Name      string
+	Applied   bool
+	Dirty     bool
+	Missing   bool
+	AppliedAt *time.Time
+}
+
+// schemaMigration is a row of the schema_migrations table
+type schemaMigration struct {
+	Version   int64 `gorm:"primaryKey;autoIncrement:false"`
+	Name      string
+	Dirty     bool
+	AppliedAt time.Time
+}
+
+// TableName returns the table name for schemaMigration
+func (schemaMigration) TableName() string {
+	return "schema_migrations"
+}
+
+// Migrator applies and rolls back versioned SQL migrations
+type Migrator struct {
+	db         *gorm.DB
+	migrations []Migration
+}
+
+// NewMigrator creates a migrator using the migrations embedded in the binary
+func NewMigrator(db *gorm.DB) (*Migrator, error) {
+	if db == nil {
+		return nil, fmt.Errorf("database connection not initialized")
+	}
+
+	embedded, err := fs.Sub(migrationFiles, "migrations")
+	if err != nil {
+		return nil, fmt.Errorf("failed to open embedded migrations: %w", err)
+	}
+
+	migrations, err := LoadMigrations(embedded)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Migrator{
+		db:         db,
+		migrations: migrations,
+	}, nil
+}
+
+// LoadMigrations reads and pairs the up/down migration files at the root of fsys, sorted by version
+func LoadMigrations(fsys fs.FS) ([]Migration, error) {
+	paths, err := fs.Glob(fsys, "*.sql")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list migrations: %w", err)
+	}
+
+	byVersion := make(map[int64]*Migration)
+	for _, path := range paths {
+		match := migrationFilePattern.FindStringSubmatch(path)
+		if match == nil {
+			return nil, fmt.Errorf("invalid migration file name: %s", path)
+		}
+
+		version, err := strconv.ParseInt(match[1], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid migration version in %s: %w", path, err)
+		}
+
+		content, err := fs.ReadFile(fsys, path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read migration %s: %w", path, err)
+		}
+
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = m
+		} else if m.Name != match[2] {
+			return nil, fmt.Errorf("migration version %d has conflicting names %q and %q", version, m.Name, match[2])
+		}
+
+		if match[3] == "up" {
+			m.Up = string(content)
+		} else {
+			m.Down = string(content)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if strings.TrimSpace(m.Up) == "" || strings.TrimSpace(m.Down) == "" {
+			return nil, fmt.Errorf("migration %d_%s must have non-empty up and down files", m.Version, m.Name)
+		}
+		migrations = append(migrations, *m)
+	}
+
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+	return migrations, nil
+}
+
+// Up applies all pending migrations and returns how many were applied
+func (m *Migrator) Up() (int, error) {
+	applied := 0
+	err := m.withLock(func(conn *gorm.DB) error {
+		state, err := m.appliedVersions(conn)
+		if err != nil {
+			return err
+		}
+		if err := checkDirty(state); err != nil {
+			return err
+		}
+
+		for _, migration := range m.migrations {
+			if _, ok := state[migration.Version]; ok {
+				continue
+			}
+
+			log.Printf("Applying migration %d_%s", migration.Version, migration.Name)
+			if err := m.apply(conn, migration, migration.Up, true); err != nil {
+				return err
+			}
+			applied++
+		}
+		return nil
+	})
+	return applied, err
+}
+
+// Down rolls back the last n applied migrations and returns how many were rolled back
+func (m *Migrator) Down(n int) (int, error) {
+	if n <= 0 {
+		return 0, fmt.Errorf("number of migrations to roll back must be positive")
+	}
+
+	rolledBack := 0
+	err := m.withLock(func(conn *gorm.DB) error {
+		state, err := m.appliedVersions(conn)
+		if err != nil {
+			return err
+		}
+		if err := checkDirty(state); err != nil {
+			return err
+		}
+
+		versions := make([]int64, 0, len(state))
+		for version := range state {
+			versions = append(versions, version)
+		}
+		sort.Slice(versions, func(i, j int) bool { return versions[i] > versions[j] })
+
+		known := m.byVersion()
+		for _, version := range versions {
+			if rolledBack == n {
+				break
+			}
+
+			migration, ok := known[version]
+			if !ok {
+				return fmt.Errorf("cannot roll back version %d: migration file not found", version)
+			}
+
+			log.Printf("Rolling back migration %d_%s", migration.Version, migration.Name)
+			if err := m.apply(conn, migration, migration.Down, false); err != nil {
+				return err
+			}
+			rolledBack++
+		}
+		return nil
+	})
+	return rolledBack, err
+}
+
+// Status reports every known migration and whether it has been applied
+func (m *Migrator) Status() ([]MigrationStatus, error) {
+	if err := m.ensureTable(m.db); err != nil {
+		return nil, err
+	}
+
+	state, err := m.appliedVersions(m.db)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]MigrationStatus, 0, len(m.migrations))
+	for _, migration := range m.migrations {
+		status := MigrationStatus{Version: migration.Version, Name: migration.Name}
+		if row, ok := state[migration.Version]; ok {
+			appliedAt := row.AppliedAt
+			status.Applied = true
+			status.Dirty = row.Dirty
+			status.AppliedAt = &appliedAt
+			delete(state, migration.Version)
+		}
+		statuses = append(statuses, status)
+	}
+
+	// Versions recorded in the database that this binary does not know about
+	for _, row := range state {
+		appliedAt := row.AppliedAt
+		statuses = append(statuses, MigrationStatus{
+			Version:   row.Version,
+			Name:      row.Name,
+			Applied:   true,
+			Dirty:     row.Dirty,
+			Missing:   true,
+			AppliedAt: &appliedAt,
+		})
+	}
+
+	sort.Slice(statuses, func(i, j int) bool {
+		return statuses[i].Version < statuses[j].Version
+	})
+	return statuses, nil
+}
+
+// Force marks the schema as being exactly at the given version without running any SQL.
+// It is used to recover from a dirty state after fixing the database by hand.
+func (m *Migrator) Force(version int64) error {
+	if version < 0 {
+		return fmt.Errorf("version must not be negative")
+	}
+
+	if _, ok := m.byVersion()[version]; version != 0 && !ok {
+		return fmt.Errorf("unknown migration version %d", version)
+	}
+
+	return m.withLock(func(conn *gorm.DB) error {
+		return conn.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Where("version > ?", version).Delete(&schemaMigration{}).Error; err != nil {
+				return fmt.Errorf("failed to remove migration records: %w", err)
+			}
+			if err := tx.Model(&schemaMigration{}).Where("dirty").Update("dirty", false).Error; err != nil {
+				return fmt.Errorf("failed to clear dirty flag: %w", err)
+			}
+			if version == 0 {
+				return nil
+			}
+
+			for _, migration := range m.migrations {
+				if migration.Version > version {
+					break
+				}
+				row := schemaMigration{Version: migration.Version, Name: migration.Name, AppliedAt: time.Now()}
+				if err := tx.Where("version = ?", migration.Version).FirstOrCreate(&row).Error; err != nil {
+					return fmt.Errorf("failed to record migration %d: %w", migration.Version, err)
+				}
+			}
+			return nil
+		})
+	})
+}
+
+// Check returns an error if migrations are pending or a previous migration left the schema dirty
+func (m *Migrator) Check() error {
+	statuses, err := m.Status()
+	if err != nil {
+		return err
+	}
+
+	pending := 0
+	for _, status := range statuses {
+		if status.Dirty {
+			return fmt.Errorf("%w at version %d", ErrSchemaDirty, status.Version)
+		}
+		if !status.Applied {
+			pending++
+		}
+	}
+
+	if pending > 0 {
+		return fmt.Errorf("%w: %d pending migration(s)", ErrSchemaOutdated, pending)
+	}
+	return nil
+}
+
+// apply runs a migration script and records the result in schema_migrations.
+// Scripts run in a transaction unless they opt out with the no-transaction directive,
+// in which case the version is marked dirty until the script succeeds.
+func (m *Migrator) apply(conn *gorm.DB, migration Migration, script string, up bool) error {
+	record := func(tx *gorm.DB) error {
+		if up {
+			row := schemaMigration{Version: migration.Version, Name: migration.Name, AppliedAt: time.Now()}
+			return tx.Save(&row).Error
+		}
+		return tx.Where("version = ?", migration.Version).Delete(&schemaMigration{}).Error
+	}
+
+	if !strings.HasPrefix(strings.TrimSpace(script), noTransactionDirective) {
+		err := conn.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Exec(script).Error; err != nil {
+				return err
+			}
+			return record(tx)
+		})
+		if err != nil {
+			return fmt.Errorf("migration %d_%s failed: %w", migration.Version, migration.Name, err)
+		}
+		return nil
+	}
+
+	dirty := schemaMigration{Version: migration.Version, Name: migration.Name, Dirty: true, AppliedAt: time.Now()}
+	if err := conn.Save(&dirty).Error; err != nil {
+		return fmt.Errorf("failed to mark migration %d as dirty: %w", migration.Version, err)
+	}
+	if err := conn.Exec(script).Error; err != nil {
+		return fmt.Errorf("migration %d_%s failed, schema left dirty: %w", migration.Version, migration.Name, err)
+	}
+	if err := record(conn); err != nil {
+		return fmt.Errorf("failed to record migration %d: %w", migration.Version, err)
+	}
+	if up {
+		return conn.Model(&schemaMigration{}).Where("version = ?", migration.Version).Update("dirty", false).Error
+	}
+	return nil
+}
+
+// withLock runs fn on a single connection holding the migration advisory lock
+func (m *Migrator) withLock(fn func(conn *gorm.DB) error) error {
+	return m.db.Connection(func(conn *gorm.DB) error {
+		if err := conn.Exec("SELECT pg_advisory_lock(?)", migrationLockKey).Error; err != nil {
+			return fmt.Errorf("failed to acquire migration lock: %w", err)
+		}
+		defer conn.Exec("SELECT pg_advisory_unlock(?)", migrationLockKey)
+
+		if err := m.ensureTable(conn); err != nil {
+			return err
+		}
+		return fn(conn)
+	})
+}
+
+// ensureTable creates the schema_migrations table if it does not exist
+func (m *Migrator) ensureTable(conn *gorm.DB) error {
+	err := conn.Exec(`CREATE TABLE IF NOT EXISTS schema_migrations (
+		version    BIGINT PRIMARY KEY,
+		name       VARCHAR(255) NOT NULL,
+		dirty      BOOLEAN NOT NULL DEFAULT FALSE,
+		applied_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+	)`).Error
+	if err != nil {
+		return fmt.Errorf("failed to create schema_migrations table: %w", err)
+	}
+	return nil
+}
+
+// appliedVersions loads the schema_migrations table keyed by version
+func (m *Migrator) appliedVersions(conn *gorm.DB) (map[int64]schemaMigration, error) {
+	var rows []schemaMigration
+	if err := conn.Order("version").Find(&rows).Error; err != nil {
+		return nil, fmt.Errorf("failed to read schema_migrations: %w", err)
+	}
+
+	state := make(map[int64]schemaMigration, len(rows))
+	for _, row := range rows {
+		state[row.Version] = row
+	}
+	return state, nil
+}
+
+// byVersion indexes the known migrations by version
+func (m *Migrator) byVersion() map[int64]Migration {
+	known := make(map[int64]Migration, len(m.migrations))
+	for _, migration := range m.migrations {
+		known[migration.Version] = migration
+	}
+	return known
+}
+
+// checkDirty refuses to continue while any version is marked dirty
+func checkDirty(state map[int64]schemaMigration) error {
+	for _, row := range state {
+		if row.Dirty {
+			return fmt.Errorf("%w at version %d, fix the database and run force", ErrSchemaDirty, row.Version)
+		}
+	}
+	return nil
+}
+
+// CreateMigration writes an empty up/down migration pair with the next version number
+func CreateMigration(dir, name string) (string, string, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	name = strings.Join(strings.FieldsFunc(name, func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= '0' && r <= '9')
+	}), "_")
+	if name == "" {
+		return "", "", fmt.Errorf("migration name must contain letters or digits")
+	}
+
+	migrations, err := LoadMigrations(os.DirFS(dir))
+	if err != nil {
+		return "", "", err
+	}
+
+	var next int64 = 1
+	if len(migrations) > 0 {
+		next = migrations[len(migrations)-1].Version + 1
+	}
+
+	base := fmt.Sprintf("%06d_%s", next, name)
+	upPath := filepath.Join(dir, base+".up.sql")
+	downPath := filepath.Join(dir, base+".down.sql")
+
+	if err := os.WriteFile(upPath, []byte("-- Write the forward migration here\n"), 0o644); err != nil {
+		return "", "", fmt.Errorf("failed to create %s: %w", upPath, err)
+	}
+	if err := os.WriteFile(downPath, []byte("-- Write the rollback for "+base+" here\n"), 0o644); err != nil {
+		return "", "", fmt.Errorf("failed to create %s: %w", downPath, err)
+	}
+
+	return upPath, downPath, nil
+}
@@ -0,0 +1,41 @@
+package database
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+)
+
+// txKey is the context key under which the active transaction is stored
+type txKey struct{}
+
+// Transactor runs a function inside a database transaction. Repositories that
+// obtain their handle through Conn join the transaction automatically.
+type Transactor interface {
+	WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+type gormTransactor struct {
+	db *gorm.DB
+}
+
+// NewTransactor creates a Transactor for the given connection
+func NewTransactor(db *gorm.DB) Transactor {
+	return &gormTransactor{db: db}
+}
+
+// WithinTransaction commits if fn returns nil and rolls back otherwise.
+// Calls nested inside an existing transaction use a savepoint.
+func (t *gormTransactor) WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	return Conn(ctx, t.db).Transaction(func(tx *gorm.DB) error {
+		return fn(context.WithValue(ctx, txKey{}, tx))
+	})
+}
+
+// Conn returns the transaction carried by ctx, or db when there is none, bound to ctx
+func Conn(ctx context.Context, db *gorm.DB) *gorm.DB {
+	if tx, ok := ctx.Value(txKey{}).(*gorm.DB); ok {
+		return tx.WithContext(ctx)
+	}
+	return db.WithContext(ctx)
+}
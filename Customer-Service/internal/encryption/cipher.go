@@ -0,0 +1,205 @@
+package encryption
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ciphertextPrefix marks a column value as encrypted. Values without it are legacy
+// plaintext written before encryption was enabled.
+const ciphertextPrefix = "enc:"
+
+// Encrypted values are bound to the row that owns them, under boundPrefix. Values
+// under UnboundPrefix were written before and are bound to their column only; they
+// still decrypt, and are rewritten by re-encryption.
+const (
+	boundPrefix   = "enc:r"
+	UnboundPrefix = "enc:v"
+)
+
+// ErrMalformedCiphertext is returned when an encrypted value cannot be parsed
+var ErrMalformedCiphertext = errors.New("malformed ciphertext")
+
+// Cipher performs envelope encryption: every value is encrypted with its own random
+// data key (DEK), which is in turn encrypted with a versioned key from the KeyProvider.
+// Encrypted values have the form enc:r<key version>:<wrapped DEK>:<ciphertext>.
+type Cipher struct {
+	keys KeyProvider
+}
+
+// NewCipher creates a cipher using keys from the given provider
+func NewCipher(keys KeyProvider) *Cipher {
+	return &Cipher{keys: keys}
+}
+
+// CurrentVersion returns the version of the key that new data is encrypted with
+func (c *Cipher) CurrentVersion(ctx context.Context) (int, error) {
+	key, err := c.keys.CurrentKey(ctx)
+	if err != nil {
+		return 0, err
+	}
+	return key.Version, nil
+}
+
+// Encrypt encrypts plaintext for the given column of the row identified by owner. Both
+// are authenticated, so a value copied into another column or another row fails to
+// decrypt.
+func (c *Cipher) Encrypt(ctx context.Context, column, owner string, plaintext []byte) (string, error) {
+	key, err := c.keys.CurrentKey(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	dek := make([]byte, keySize)
+	if _, err := rand.Read(dek); err != nil {
+		return "", fmt.Errorf("failed to generate data key: %w", err)
+	}
+
+	wrapped, err := seal(key.Material, dek, []byte(strconv.Itoa(key.Version)))
+	if err != nil {
+		return "", err
+	}
+	sealed, err := seal(dek, plaintext, boundData(column, owner))
+	if err != nil {
+		return "", err
+	}
+
+	return boundPrefix + strconv.Itoa(key.Version) + ":" +
+		base64.RawStdEncoding.EncodeToString(wrapped) + ":" +
+		base64.RawStdEncoding.EncodeToString(sealed), nil
+}
+
+// Decrypt decrypts a value produced by Encrypt for the same column and owner
+func (c *Cipher) Decrypt(ctx context.Context, column, owner, value string) ([]byte, error) {
+	bound, version, wrapped, sealed, err := parseCiphertext(value)
+	if err != nil {
+		return nil, err
+	}
+	additionalData := []byte(column)
+	if bound {
+		additionalData = boundData(column, owner)
+	}
+
+	key, err := c.keys.Key(ctx, version)
+	if err != nil {
+		return nil, err
+	}
+	dek, err := open(key.Material, wrapped, []byte(strconv.Itoa(version)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to unwrap data key: %w", err)
+	}
+	plaintext, err := open(dek, sealed, additionalData)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt %s: %w", column, err)
+	}
+	return plaintext, nil
+}
+
+// BlindIndex returns a keyed hash of a normalised value, used to find rows by exact
+// match without decrypting them. The column name separates the indexes of different columns.
+func (c *Cipher) BlindIndex(ctx context.Context, column, normalized string) (string, error) {
+	key, err := c.keys.BlindIndexKey(ctx)
+	if err != nil {
+		return "", err
+	}
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(column))
+	mac.Write([]byte{0})
+	mac.Write([]byte(normalized))
+	return hex.EncodeToString(mac.Sum(nil)), nil
+}
+
+// IsEncrypted reports whether a stored value was produced by Encrypt
+func IsEncrypted(value string) bool {
+	return strings.HasPrefix(value, ciphertextPrefix)
+}
+
+// CurrentPrefix returns the prefix of values encrypted under a key version and
+// bound to their row, which values due to be rewritten lack
+func CurrentPrefix(version int) string {
+	return boundPrefix + strconv.Itoa(version) + ":"
+}
+
+// boundData returns the additional data a value of a column of the owner's row is
+// sealed with
+func boundData(column, owner string) []byte {
+	return []byte(column + "\x00" + owner)
+}
+
+// parseCiphertext splits an encrypted value into whether it is bound to its row, its
+// key version, wrapped DEK and ciphertext
+func parseCiphertext(value string) (bool, int, []byte, []byte, error) {
+	var rest string
+	bound := strings.HasPrefix(value, boundPrefix)
+	switch {
+	case bound:
+		rest = strings.TrimPrefix(value, boundPrefix)
+	case strings.HasPrefix(value, UnboundPrefix):
+		rest = strings.TrimPrefix(value, UnboundPrefix)
+	default:
+		return false, 0, nil, nil, ErrMalformedCiphertext
+	}
+	parts := strings.Split(rest, ":")
+	if len(parts) != 3 {
+		return false, 0, nil, nil, ErrMalformedCiphertext
+	}
+
+	version, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return false, 0, nil, nil, ErrMalformedCiphertext
+	}
+	wrapped, err := base64.RawStdEncoding.DecodeString(parts[1])
+	if err != nil {
+		return false, 0, nil, nil, ErrMalformedCiphertext
+	}
+	sealed, err := base64.RawStdEncoding.DecodeString(parts[2])
+	if err != nil {
+		return false, 0, nil, nil, ErrMalformedCiphertext
+	}
+	return bound, version, wrapped, sealed, nil
+}
+
+// seal encrypts plaintext with AES-256-GCM, prefixing the random nonce
+func seal(key, plaintext, additionalData []byte) ([]byte, error) {
+	aead, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	return aead.Seal(nonce, nonce, plaintext, additionalData), nil
+}
+
+// open decrypts a value produced by seal
+func open(key, sealed, additionalData []byte) ([]byte, error) {
+	aead, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(sealed) < aead.NonceSize() {
+		return nil, ErrMalformedCiphertext
+	}
+	nonce, ciphertext := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+	return aead.Open(nil, nonce, ciphertext, additionalData)
+}
+
+// newGCM creates an AES-GCM AEAD for the key
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("invalid encryption key: %w", err)
+	}
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,319 @@
+package encryption
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm/schema"
+)
+
+// newKeyFile creates a key file with one key, written to a temporary directory
+func newKeyFile(t *testing.T) (*KeyFile, string) {
+	t.Helper()
+	file, err := NewKeyFile()
+	if err != nil {
+		t.Fatalf("NewKeyFile() error = %v", err)
+	}
+	path := filepath.Join(t.TempDir(), "keys.json")
+	if err := WriteKeyFile(path, file); err != nil {
+		t.Fatalf("WriteKeyFile() error = %v", err)
+	}
+	return file, path
+}
+
+// newCipher returns a cipher using the keys in the file at path
+func newCipher(t *testing.T, path string) *Cipher {
+	t.Helper()
+	keys, err := NewFileKeyProvider(path)
+	if err != nil {
+		t.Fatalf("NewFileKeyProvider() error = %v", err)
+	}
+	return NewCipher(keys)
+}
+
+func TestCipherRoundTrip(t *testing.T) {
+	_, path := newKeyFile(t)
+	c := newCipher(t, path)
+	ctx := context.Background()
+
+	tests := []struct {
+		name      string
+		plaintext []byte
+	}{
+		{"empty", []byte{}},
+		{"email", []byte("jane.smith@example.com")},
+		{"unicode", []byte("Zoë Ångström, 12 Rue de l'Église")},
+		{"binary", []byte{0, 1, 2, 0xff, 0xfe}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			value, err := c.Encrypt(ctx, "email", "row-1", tt.plaintext)
+			if err != nil {
+				t.Fatalf("Encrypt() error = %v", err)
+			}
+			if !IsEncrypted(value) || !strings.HasPrefix(value, boundPrefix+"1:") {
+				t.Errorf("Encrypt() = %q, want a value bound under key version 1", value)
+			}
+			if len(tt.plaintext) > 0 && strings.Contains(value, string(tt.plaintext)) {
+				t.Errorf("Encrypt() = %q contains the plaintext", value)
+			}
+
+			got, err := c.Decrypt(ctx, "email", "row-1", value)
+			if err != nil {
+				t.Fatalf("Decrypt() error = %v", err)
+			}
+			if string(got) != string(tt.plaintext) {
+				t.Errorf("Decrypt() = %q, want %q", got, tt.plaintext)
+			}
+		})
+	}
+
+	first, _ := c.Encrypt(ctx, "email", "row-1", []byte("same"))
+	second, _ := c.Encrypt(ctx, "email", "row-1", []byte("same"))
+	if first == second {
+		t.Error("Encrypt() gives the same value twice for the same plaintext")
+	}
+}
+
+func TestCipherRejects(t *testing.T) {
+	_, path := newKeyFile(t)
+	c := newCipher(t, path)
+	ctx := context.Background()
+
+	value, err := c.Encrypt(ctx, "email", "row-1", []byte("jane@example.com"))
+	if err != nil {
+		t.Fatalf("Encrypt() error = %v", err)
+	}
+	parts := strings.Split(strings.TrimPrefix(value, boundPrefix), ":")
+	sealed, _ := base64.RawStdEncoding.DecodeString(parts[2])
+	sealed[len(sealed)-1] ^= 1
+	tampered := boundPrefix + parts[0] + ":" + parts[1] + ":" + base64.RawStdEncoding.EncodeToString(sealed)
+
+	_, otherPath := newKeyFile(t)
+	foreign, err := newCipher(t, otherPath).Encrypt(ctx, "email", "row-1", []byte("jane@example.com"))
+	if err != nil {
+		t.Fatalf("Encrypt() error = %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		column  string
+		owner   string
+		value   string
+		wantErr error
+	}{
+		{name: "tampered ciphertext", column: "email", owner: "row-1", value: tampered},
+		{name: "another column", column: "phone", owner: "row-1", value: value},
+		{name: "another row", column: "email", owner: "row-2", value: value},
+		{name: "wrapped by another key", column: "email", owner: "row-1", value: foreign},
+		{name: "unknown key version", column: "email", owner: "row-1",
+			value: boundPrefix + "9:" + parts[1] + ":" + parts[2], wantErr: ErrUnknownKeyVersion},
+		{name: "plaintext", column: "email", owner: "row-1", value: "jane@example.com", wantErr: ErrMalformedCiphertext},
+		{name: "missing part", column: "email", owner: "row-1",
+			value: boundPrefix + parts[0] + ":" + parts[1], wantErr: ErrMalformedCiphertext},
+		{name: "version not a number", column: "email", owner: "row-1",
+			value: boundPrefix + "x:" + parts[1] + ":" + parts[2], wantErr: ErrMalformedCiphertext},
+		{name: "not base64", column: "email", owner: "row-1",
+			value: boundPrefix + parts[0] + ":" + parts[1] + ":!!!", wantErr: ErrMalformedCiphertext},
+		{name: "truncated", column: "email", owner: "row-1",
+			value: boundPrefix + parts[0] + ":" + parts[1] + ":AAAA", wantErr: ErrMalformedCiphertext},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			plaintext, err := c.Decrypt(ctx, tt.column, tt.owner, tt.value)
+			if err == nil {
+				t.Fatalf("Decrypt() = %q, want an error", plaintext)
+			}
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Errorf("Decrypt() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestDecryptUnbound(t *testing.T) {
+	file, path := newKeyFile(t)
+	c := newCipher(t, path)
+	ctx := context.Background()
+
+	// A value as written before values were bound to their row
+	material, _ := base64.StdEncoding.DecodeString(file.Keys["1"])
+	dek := make([]byte, keySize)
+	wrapped, err := seal(material, dek, []byte("1"))
+	if err != nil {
+		t.Fatalf("seal() error = %v", err)
+	}
+	sealed, err := seal(dek, []byte("jane@example.com"), []byte("email"))
+	if err != nil {
+		t.Fatalf("seal() error = %v", err)
+	}
+	value := UnboundPrefix + "1:" + base64.RawStdEncoding.EncodeToString(wrapped) + ":" + base64.RawStdEncoding.EncodeToString(sealed)
+
+	for _, owner := range []string{"row-1", ""} {
+		got, err := c.Decrypt(ctx, "email", owner, value)
+		if err != nil || string(got) != "jane@example.com" {
+			t.Errorf("Decrypt() = %q, %v, want the plaintext", got, err)
+		}
+	}
+	if _, err := c.Decrypt(ctx, "phone", "row-1", value); err == nil {
+		t.Error("Decrypt() of an unbound value under another column succeeded")
+	}
+}
+
+func TestDecryptAfterRotation(t *testing.T) {
+	file, path := newKeyFile(t)
+	ctx := context.Background()
+
+	old, err := newCipher(t, path).Encrypt(ctx, "phone", "row-1", []byte("+15551234567"))
+	if err != nil {
+		t.Fatalf("Encrypt() error = %v", err)
+	}
+
+	version, err := file.AddKey(true)
+	if err != nil {
+		t.Fatalf("AddKey() error = %v", err)
+	}
+	if err := WriteKeyFile(path, file); err != nil {
+		t.Fatalf("WriteKeyFile() error = %v", err)
+	}
+	rotated := newCipher(t, path)
+
+	if current, _ := rotated.CurrentVersion(ctx); current != version {
+		t.Errorf("CurrentVersion() = %d, want %d", current, version)
+	}
+	if got, err := rotated.Decrypt(ctx, "phone", "row-1", old); err != nil || string(got) != "+15551234567" {
+		t.Errorf("Decrypt() of a value under the old key = %q, %v", got, err)
+	}
+	current, err := rotated.Encrypt(ctx, "phone", "row-1", []byte("+15551234567"))
+	if err != nil {
+		t.Fatalf("Encrypt() error = %v", err)
+	}
+	if !strings.HasPrefix(current, boundPrefix+strconv.Itoa(version)+":") {
+		t.Errorf("Encrypt() = %q, want it under key version %d", current, version)
+	}
+
+	// Once the old key is retired, values under it can no longer be read
+	delete(file.Keys, "1")
+	if err := WriteKeyFile(path, file); err != nil {
+		t.Fatalf("WriteKeyFile() error = %v", err)
+	}
+	if _, err := newCipher(t, path).Decrypt(ctx, "phone", "row-1", old); !errors.Is(err, ErrUnknownKeyVersion) {
+		t.Errorf("Decrypt() error = %v, want %v", err, ErrUnknownKeyVersion)
+	}
+}
+
+func TestBlindIndex(t *testing.T) {
+	file, path := newKeyFile(t)
+	c := newCipher(t, path)
+	ctx := context.Background()
+
+	index := func(c *Cipher, column, value string) string {
+		t.Helper()
+		got, err := c.BlindIndex(ctx, column, value)
+		if err != nil {
+			t.Fatalf("BlindIndex() error = %v", err)
+		}
+		return got
+	}
+	email := index(c, "email", "jane@example.com")
+
+	// Rotating the encryption key keeps the blind index key
+	if _, err := file.AddKey(true); err != nil {
+		t.Fatalf("AddKey() error = %v", err)
+	}
+	if err := WriteKeyFile(path, file); err != nil {
+		t.Fatalf("WriteKeyFile() error = %v", err)
+	}
+	_, otherPath := newKeyFile(t)
+
+	tests := []struct {
+		name  string
+		index string
+		same  bool
+	}{
+		{"same value", index(c, "email", "jane@example.com"), true},
+		{"after key rotation", index(newCipher(t, path), "email", "jane@example.com"), true},
+		{"another value", index(c, "email", "john@example.com"), false},
+		{"another column", index(c, "phone", "jane@example.com"), false},
+		{"value ending where the column does", index(c, "emailjane@example.com", ""), false},
+		{"another blind index key", index(newCipher(t, otherPath), "email", "jane@example.com"), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if (tt.index == email) != tt.same {
+				t.Errorf("BlindIndex() = %s, same as %s: %v, want %v", tt.index, email, !tt.same, tt.same)
+			}
+		})
+	}
+}
+
+// encryptedRow is a model with an encrypted column
+type encryptedRow struct {
+	ID    uuid.UUID `gorm:"primaryKey"`
+	Email string    `gorm:"serializer:encrypted"`
+}
+
+func TestSerializerBindsRow(t *testing.T) {
+	_, path := newKeyFile(t)
+	Register(newCipher(t, path))
+	ctx := context.Background()
+
+	s, err := schema.Parse(&encryptedRow{}, &sync.Map{}, schema.NamingStrategy{})
+	if err != nil {
+		t.Fatalf("schema.Parse() error = %v", err)
+	}
+	field := s.LookUpField("email")
+
+	source := &encryptedRow{ID: uuid.New(), Email: "jane@example.com"}
+	stored, err := Serializer{}.Value(ctx, field, reflect.ValueOf(source).Elem(), source.Email)
+	if err != nil {
+		t.Fatalf("Value() error = %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		row     *encryptedRow
+		stored  any
+		want    string
+		wantErr bool
+	}{
+		{name: "same row", row: &encryptedRow{ID: source.ID}, stored: stored, want: "jane@example.com"},
+		{name: "bytes", row: &encryptedRow{ID: source.ID}, stored: []byte(stored.(string)), want: "jane@example.com"},
+		{name: "legacy plaintext", row: &encryptedRow{ID: uuid.New()}, stored: "john@example.com", want: "john@example.com"},
+		{name: "copied into another row", row: &encryptedRow{ID: uuid.New()}, stored: stored, wantErr: true},
+		{name: "row without its ID", row: &encryptedRow{}, stored: stored, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Serializer{}.Scan(ctx, field, reflect.ValueOf(tt.row).Elem(), tt.stored)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("Scan() = %q, want an error", tt.row.Email)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Scan() error = %v", err)
+			}
+			if tt.row.Email != tt.want {
+				t.Errorf("Scan() = %q, want %q", tt.row.Email, tt.want)
+			}
+		})
+	}
+
+	if _, err := (Serializer{}).Value(ctx, field, reflect.ValueOf(&encryptedRow{}).Elem(), "jane@example.com"); err == nil {
+		t.Error("Value() of a row without its ID succeeded")
+	}
+}
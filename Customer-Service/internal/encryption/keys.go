@@ -0,0 +1,189 @@
+package encryption
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+)
+
+// keySize is the length of key encryption and blind index keys (AES-256)
+const keySize = 32
+
+// ErrUnknownKeyVersion is returned when data was encrypted with a key the provider does not hold
+var ErrUnknownKeyVersion = errors.New("unknown encryption key version")
+
+// Key is a versioned key encryption key (KEK)
+type Key struct {
+	Version  int
+	Material []byte
+}
+
+// KeyProvider supplies the keys used to encrypt PII. Implementations may be backed by
+// a local file, a KMS or an HSM.
+type KeyProvider interface {
+	// CurrentKey returns the key that new data is encrypted with
+	CurrentKey(ctx context.Context) (Key, error)
+	// Key returns the key with the given version, to decrypt data written under it
+	Key(ctx context.Context, version int) (Key, error)
+	// BlindIndexKey returns the HMAC key used to compute blind indexes
+	BlindIndexKey(ctx context.Context) ([]byte, error)
+}
+
+// KeyFile is the JSON layout read by FileKeyProvider. Keys are base64 encoded
+// 32 byte values, indexed by version.
+type KeyFile struct {
+	CurrentVersion int               `json:"current_version"`
+	Keys           map[string]string `json:"keys"`
+	BlindIndexKey  string            `json:"blind_index_key"`
+}
+
+// FileKeyProvider is a KeyProvider reading its keys from a local JSON file
+type FileKeyProvider struct {
+	current    int
+	keys       map[int][]byte
+	blindIndex []byte
+}
+
+// NewFileKeyProvider loads the key file at path
+func NewFileKeyProvider(path string) (*FileKeyProvider, error) {
+	file, err := ReadKeyFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	provider := &FileKeyProvider{
+		current: file.CurrentVersion,
+		keys:    make(map[int][]byte, len(file.Keys)),
+	}
+	for rawVersion, encoded := range file.Keys {
+		version, err := strconv.Atoi(rawVersion)
+		if err != nil || version < 1 {
+			return nil, fmt.Errorf("invalid key version %q in %s", rawVersion, path)
+		}
+		material, err := decodeKey(encoded)
+		if err != nil {
+			return nil, fmt.Errorf("invalid key version %d in %s: %w", version, path, err)
+		}
+		provider.keys[version] = material
+	}
+	if _, ok := provider.keys[provider.current]; !ok {
+		return nil, fmt.Errorf("current key version %d is not present in %s", provider.current, path)
+	}
+
+	provider.blindIndex, err = decodeKey(file.BlindIndexKey)
+	if err != nil {
+		return nil, fmt.Errorf("invalid blind index key in %s: %w", path, err)
+	}
+	return provider, nil
+}
+
+// CurrentKey returns the key that new data is encrypted with
+func (p *FileKeyProvider) CurrentKey(ctx context.Context) (Key, error) {
+	return p.Key(ctx, p.current)
+}
+
+// Key returns the key with the given version
+func (p *FileKeyProvider) Key(ctx context.Context, version int) (Key, error) {
+	material, ok := p.keys[version]
+	if !ok {
+		return Key{}, fmt.Errorf("%w: %d", ErrUnknownKeyVersion, version)
+	}
+	return Key{Version: version, Material: material}, nil
+}
+
+// BlindIndexKey returns the HMAC key used to compute blind indexes
+func (p *FileKeyProvider) BlindIndexKey(ctx context.Context) ([]byte, error) {
+	return p.blindIndex, nil
+}
+
+// ReadKeyFile reads and parses a key file
+func ReadKeyFile(path string) (*KeyFile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read key file: %w", err)
+	}
+
+	var file KeyFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse key file %s: %w", path, err)
+	}
+	return &file, nil
+}
+
+// WriteKeyFile writes a key file readable only by its owner
+func WriteKeyFile(path string, file *KeyFile) error {
+	data, err := json.MarshalIndent(file, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode key file: %w", err)
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0o600); err != nil {
+		return fmt.Errorf("failed to write key file: %w", err)
+	}
+	return nil
+}
+
+// NewKeyFile creates a key file with a fresh version 1 key and blind index key
+func NewKeyFile() (*KeyFile, error) {
+	file := &KeyFile{Keys: make(map[string]string)}
+	if _, err := file.AddKey(true); err != nil {
+		return nil, err
+	}
+
+	blindIndex, err := generateKey()
+	if err != nil {
+		return nil, err
+	}
+	file.BlindIndexKey = blindIndex
+	return file, nil
+}
+
+// AddKey adds a key with the next version and, if activate is set, makes it current.
+// The blind index key is never rotated, since existing indexes could no longer be matched.
+func (f *KeyFile) AddKey(activate bool) (int, error) {
+	if f.Keys == nil {
+		f.Keys = make(map[string]string)
+	}
+
+	version := 1
+	for rawVersion := range f.Keys {
+		if v, err := strconv.Atoi(rawVersion); err == nil && v >= version {
+			version = v + 1
+		}
+	}
+
+	key, err := generateKey()
+	if err != nil {
+		return 0, err
+	}
+	f.Keys[strconv.Itoa(version)] = key
+	if activate {
+		f.CurrentVersion = version
+	}
+	return version, nil
+}
+
+// generateKey returns a random base64 encoded key
+func generateKey() (string, error) {
+	key := make([]byte, keySize)
+	if _, err := rand.Read(key); err != nil {
+		return "", fmt.Errorf("failed to generate key: %w", err)
+	}
+	return base64.StdEncoding.EncodeToString(key), nil
+}
+
+// decodeKey decodes a base64 key and checks its length
+func decodeKey(encoded string) ([]byte, error) {
+	key, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, err
+	}
+	if len(key) != keySize {
+		return nil, fmt.Errorf("key must be %d bytes, got %d", keySize, len(key))
+	}
+	return key, nil
+}
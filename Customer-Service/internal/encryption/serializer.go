@@ -0,0 +1,169 @@
+package encryption
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"gorm.io/gorm/schema"
+)
+
+// SerializerName is the GORM serializer that encrypts a column, used as
+// `gorm:"serializer:encrypted"`. It supports string and *time.Time fields;
+// dates are stored as YYYY-MM-DD before encryption.
+const SerializerName = "encrypted"
+
+// dateLayout is the plaintext form of encrypted date fields
+const dateLayout = "2006-01-02"
+
+// ErrNotConfigured is returned when an encrypted column is used before Register
+var ErrNotConfigured = errors.New("field encryption is not configured")
+
+var (
+	registerOnce  sync.Once
+	defaultMu     sync.RWMutex
+	defaultCipher *Cipher
+)
+
+// Register makes c the cipher used by the encrypted GORM serializer
+func Register(c *Cipher) {
+	defaultMu.Lock()
+	defaultCipher = c
+	defaultMu.Unlock()
+
+	registerOnce.Do(func() {
+		schema.RegisterSerializer(SerializerName, Serializer{})
+	})
+}
+
+// registeredCipher returns the cipher set by Register
+func registeredCipher() (*Cipher, error) {
+	defaultMu.RLock()
+	defer defaultMu.RUnlock()
+	if defaultCipher == nil {
+		return nil, ErrNotConfigured
+	}
+	return defaultCipher, nil
+}
+
+// Serializer encrypts field values on write and decrypts them on read, binding them to
+// the primary key of their row. Rows must therefore have their primary key set before
+// they are written, and reads must select it ahead of the encrypted columns, as
+// SELECT * does. Legacy plaintext values are read as they are, so rows can be
+// encrypted in the background.
+type Serializer struct{}
+
+// Scan implements the GORM serializer interface
+func (Serializer) Scan(ctx context.Context, field *schema.Field, dst reflect.Value, dbValue interface{}) error {
+	var stored string
+	switch v := dbValue.(type) {
+	case nil:
+		return field.Set(ctx, dst, reflect.Zero(field.FieldType).Interface())
+	case []byte:
+		stored = string(v)
+	case string:
+		stored = v
+	case time.Time:
+		// A date column that has not been converted to text yet
+		stored = v.Format(dateLayout)
+	default:
+		return fmt.Errorf("unsupported value %T for encrypted column %s", dbValue, field.DBName)
+	}
+
+	plaintext := stored
+	if IsEncrypted(stored) {
+		c, err := registeredCipher()
+		if err != nil {
+			return err
+		}
+		owner, err := rowOwner(ctx, field, dst)
+		if err != nil {
+			return err
+		}
+		decrypted, err := c.Decrypt(ctx, field.DBName, owner, stored)
+		if err != nil {
+			return err
+		}
+		plaintext = string(decrypted)
+	}
+
+	switch field.FieldType {
+	case reflect.TypeOf(""):
+		return field.Set(ctx, dst, plaintext)
+	case reflect.TypeOf(&time.Time{}):
+		if plaintext == "" {
+			return field.Set(ctx, dst, (*time.Time)(nil))
+		}
+		date, err := time.Parse(dateLayout, plaintext[:min(len(plaintext), len(dateLayout))])
+		if err != nil {
+			return fmt.Errorf("invalid date in encrypted column %s: %w", field.DBName, err)
+		}
+		return field.Set(ctx, dst, &date)
+	default:
+		return fmt.Errorf("unsupported field type %s for encrypted column %s", field.FieldType, field.DBName)
+	}
+}
+
+// Value implements the GORM serializer interface
+func (Serializer) Value(ctx context.Context, field *schema.Field, dst reflect.Value, fieldValue interface{}) (interface{}, error) {
+	var plaintext string
+	switch v := fieldValue.(type) {
+	case string:
+		plaintext = v
+	case *time.Time:
+		if v == nil {
+			return nil, nil
+		}
+		plaintext = v.Format(dateLayout)
+	case time.Time:
+		plaintext = v.Format(dateLayout)
+	default:
+		return nil, fmt.Errorf("unsupported value %T for encrypted column %s", fieldValue, field.DBName)
+	}
+
+	c, err := registeredCipher()
+	if err != nil {
+		return nil, err
+	}
+	owner, err := rowOwner(ctx, field, dst)
+	if err != nil {
+		return nil, err
+	}
+	return c.Encrypt(ctx, field.DBName, owner, []byte(plaintext))
+}
+
+// rowOwner returns the primary key of the row an encrypted field belongs to, which
+// its values are bound to
+func rowOwner(ctx context.Context, field *schema.Field, row reflect.Value) (string, error) {
+	key := field.Schema.PrioritizedPrimaryField
+	if key == nil {
+		return "", fmt.Errorf("encrypted column %s is in a table without a primary key", field.DBName)
+	}
+	value, zero := key.ValueOf(ctx, row)
+	if zero {
+		return "", fmt.Errorf("encrypted column %s used without the primary key of its row", field.DBName)
+	}
+	return fmt.Sprint(value), nil
+}
+
+// NormalizeEmail returns the canonical form of an email address for blind indexing
+func NormalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+// NormalizePhone returns the canonical form of a phone number for blind indexing:
+// its digits, keeping a leading plus sign
+func NormalizePhone(phone string) string {
+	phone = strings.TrimSpace(phone)
+	var b strings.Builder
+	for i, r := range phone {
+		if (r >= '0' && r <= '9') || (r == '+' && i == 0) {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
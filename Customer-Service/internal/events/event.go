@@ -0,0 +1,132 @@
+package events
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Domain event types emitted for customer changes
+const (
+	CustomerCreated       = "CustomerCreated"
+	CustomerUpdated       = "CustomerUpdated"
+	CustomerStatusChanged = "CustomerStatusChanged"
+	CustomerDeleted       = "CustomerDeleted"
+	CustomerMerged        = "CustomerMerged"
+
+	CustomerBeneficialOwnersChanged = "CustomerBeneficialOwnersChanged"
+	CustomerContactsChanged         = "CustomerContactsChanged"
+
+	CustomerKYCStatusChanged = "CustomerKYCStatusChanged"
+
+	CustomerScreeningAlertRaised        = "CustomerScreeningAlertRaised"
+	CustomerScreeningAlertDispositioned = "CustomerScreeningAlertDispositioned"
+
+	CustomerRiskRatingChanged = "CustomerRiskRatingChanged"
+
+	CustomerRelationshipCreated = "CustomerRelationshipCreated"
+	CustomerRelationshipEnded   = "CustomerRelationshipEnded"
+
+	CustomerConsentChanged = "CustomerConsentChanged"
+
+	CustomerErased = "CustomerErased"
+
+	CustomerRestored = "CustomerRestored"
+	CustomerPurged   = "CustomerPurged"
+)
+
+// Types lists every event type the service emits
+var Types = []string{
+	CustomerCreated,
+	CustomerUpdated,
+	CustomerStatusChanged,
+	CustomerDeleted,
+	CustomerMerged,
+	CustomerBeneficialOwnersChanged,
+	CustomerContactsChanged,
+	CustomerKYCStatusChanged,
+	CustomerScreeningAlertRaised,
+	CustomerScreeningAlertDispositioned,
+	CustomerRiskRatingChanged,
+	CustomerRelationshipCreated,
+	CustomerRelationshipEnded,
+	CustomerConsentChanged,
+	CustomerErased,
+	CustomerRestored,
+	CustomerPurged,
+}
+
+// IsKnownType reports whether eventType is emitted by the service
+func IsKnownType(eventType string) bool {
+	for _, t := range Types {
+		if t == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// Event is the envelope delivered to publishers. AggregateVersion numbers the
+// events of a customer from 1, in the order they were written.
+type Event struct {
+	ID               uuid.UUID       `json:"id"`
+	Type             string          `json:"type"`
+	CustomerID       uuid.UUID       `json:"customer_id"`
+	Sequence         int64           `json:"sequence"`
+	AggregateVersion int64           `json:"aggregate_version"`
+	OccurredAt       time.Time       `json:"occurred_at"`
+	Payload          json.RawMessage `json:"payload"`
+}
+
+// NewEvent creates an event for a customer with the given payload
+func NewEvent(eventType string, customerID uuid.UUID, payload any) (*Event, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode %s payload: %w", eventType, err)
+	}
+
+	return &Event{
+		ID:         uuid.New(),
+		Type:       eventType,
+		CustomerID: customerID,
+		OccurredAt: time.Now().UTC(),
+		Payload:    data,
+	}, nil
+}
+
+// OutboxEvent is a domain event stored in the outbox table until it is published,
+// or dead-lettered once the relay gives up on it
+type OutboxEvent struct {
+	Sequence         int64     `gorm:"primaryKey;autoIncrement"`
+	ID               uuid.UUID `gorm:"type:uuid;not null;uniqueIndex"`
+	AggregateID      uuid.UUID `gorm:"type:uuid;not null;index"`
+	AggregateVersion int64     `gorm:"not null"`
+	EventType        string    `gorm:"not null;size:100"`
+	Payload          string    `gorm:"type:jsonb;not null"`
+	OccurredAt       time.Time `gorm:"not null"`
+	PublishedAt      *time.Time
+	Attempts         int `gorm:"not null;default:0"`
+	LastError        *string
+	NextAttemptAt    time.Time `gorm:"not null"`
+	DeadLetteredAt   *time.Time
+}
+
+// TableName returns the table name for OutboxEvent model
+func (OutboxEvent) TableName() string {
+	return "outbox_events"
+}
+
+// ToEvent converts a stored outbox row to the published envelope
+func (o *OutboxEvent) ToEvent() Event {
+	return Event{
+		ID:               o.ID,
+		Type:             o.EventType,
+		CustomerID:       o.AggregateID,
+		Sequence:         o.Sequence,
+		AggregateVersion: o.AggregateVersion,
+		OccurredAt:       o.OccurredAt,
+		Payload:          json.RawMessage(o.Payload),
+	}
+}
@@ -0,0 +1,147 @@
+package events
+
+import (
+	"context"
+	"customer-service/internal/database"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Outbox records domain events alongside the writes that caused them
+type Outbox interface {
+	// Append stores an event. Called with a transactional context, the event is
+	// committed or rolled back together with the rest of the transaction.
+	Append(ctx context.Context, event *Event) error
+}
+
+// OutboxRepository is the data access layer used by the outbox and its relay
+type OutboxRepository interface {
+	Outbox
+	TryLockRelay(ctx context.Context) (bool, error)
+	FetchPending(ctx context.Context, now time.Time, limit int) ([]OutboxEvent, error)
+	MarkPublished(ctx context.Context, sequence int64) error
+	MarkFailed(ctx context.Context, sequence int64, cause error, nextAttempt *time.Time) error
+}
+
+type outboxRepository struct {
+	db *gorm.DB
+}
+
+// NewOutboxRepository creates a new outbox repository instance
+func NewOutboxRepository(db *gorm.DB) OutboxRepository {
+	return &outboxRepository{db: db}
+}
+
+// Append stores an event in the outbox as the next version of its customer. The
+// customer's outbox lock is held until the transaction ends, so that versions are
+// committed in the order they are given out.
+func (r *outboxRepository) Append(ctx context.Context, event *Event) error {
+	db := database.Conn(ctx, r.db)
+	if err := db.Exec("SELECT pg_advisory_xact_lock(?, hashtext(?))", appendLockClass, event.CustomerID.String()).Error; err != nil {
+		return fmt.Errorf("failed to acquire outbox lock: %w", err)
+	}
+
+	var version int64
+	err := db.Model(&OutboxEvent{}).
+		Where("aggregate_id = ?", event.CustomerID).
+		Select("COALESCE(MAX(aggregate_version), 0)").
+		Scan(&version).Error
+	if err != nil {
+		return fmt.Errorf("failed to version %s event: %w", event.Type, err)
+	}
+
+	row := OutboxEvent{
+		ID:               event.ID,
+		AggregateID:      event.CustomerID,
+		AggregateVersion: version + 1,
+		EventType:        event.Type,
+		Payload:          string(event.Payload),
+		OccurredAt:       event.OccurredAt,
+		NextAttemptAt:    event.OccurredAt,
+	}
+	if err := db.Create(&row).Error; err != nil {
+		return fmt.Errorf("failed to append %s event: %w", event.Type, err)
+	}
+	event.Sequence = row.Sequence
+	event.AggregateVersion = row.AggregateVersion
+	return nil
+}
+
+// PostgreSQL advisory locks of the outbox. The relay lock is a single key; append
+// locks are keyed by appendLockClass and a hash of the customer ID.
+const (
+	relayLockKey    = 7246002
+	appendLockClass = 7246004
+)
+
+// TryLockRelay takes the relay lock for the duration of the current transaction.
+// It returns false if another relay instance holds it.
+func (r *outboxRepository) TryLockRelay(ctx context.Context) (bool, error) {
+	var locked bool
+	if err := database.Conn(ctx, r.db).Raw("SELECT pg_try_advisory_xact_lock(?)", relayLockKey).Scan(&locked).Error; err != nil {
+		return false, fmt.Errorf("failed to acquire relay lock: %w", err)
+	}
+	return locked, nil
+}
+
+// FetchPending returns pending events due by now, in sequence order, leaving out
+// the events of customers whose earliest pending event is not due yet. A customer
+// waiting on a retry thus holds back its own events only. Within a customer,
+// sequence follows aggregate version.
+func (r *outboxRepository) FetchPending(ctx context.Context, now time.Time, limit int) ([]OutboxEvent, error) {
+	var pending []OutboxEvent
+	err := database.Conn(ctx, r.db).
+		Where("published_at IS NULL AND dead_lettered_at IS NULL AND next_attempt_at <= ?", now).
+		Where(`NOT EXISTS (SELECT 1 FROM outbox_events earlier
+			WHERE earlier.aggregate_id = outbox_events.aggregate_id
+			AND earlier.aggregate_version < outbox_events.aggregate_version
+			AND earlier.published_at IS NULL AND earlier.dead_lettered_at IS NULL
+			AND earlier.next_attempt_at > ?)`, now).
+		Order("sequence").
+		Limit(limit).
+		Find(&pending).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch pending events: %w", err)
+	}
+	return pending, nil
+}
+
+// MarkPublished records that an event has been delivered
+func (r *outboxRepository) MarkPublished(ctx context.Context, sequence int64) error {
+	err := database.Conn(ctx, r.db).Model(&OutboxEvent{}).
+		Where("sequence = ?", sequence).
+		Updates(map[string]any{
+			"published_at": time.Now(),
+			"attempts":     gorm.Expr("attempts + 1"),
+			"last_error":   nil,
+		}).Error
+	if err != nil {
+		return fmt.Errorf("failed to mark event %d published: %w", sequence, err)
+	}
+	return nil
+}
+
+// MarkFailed records a failed delivery attempt and when to retry it. A nil
+// nextAttempt means no further attempts are made and the event is dead-lettered,
+// letting the customer's later events through.
+func (r *outboxRepository) MarkFailed(ctx context.Context, sequence int64, cause error, nextAttempt *time.Time) error {
+	updates := map[string]any{
+		"attempts":   gorm.Expr("attempts + 1"),
+		"last_error": cause.Error(),
+	}
+	if nextAttempt != nil {
+		updates["next_attempt_at"] = *nextAttempt
+	} else {
+		updates["dead_lettered_at"] = time.Now()
+	}
+
+	err := database.Conn(ctx, r.db).Model(&OutboxEvent{}).
+		Where("sequence = ?", sequence).
+		Updates(updates).Error
+	if err != nil {
+		return fmt.Errorf("failed to mark event %d failed: %w", sequence, err)
+	}
+	return nil
+}
@@ -0,0 +1,104 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+)
+
+// EventPublisher delivers domain events to other services. Publish may be called
+// more than once for the same event, so consumers must deduplicate by event ID.
+type EventPublisher interface {
+	Publish(ctx context.Context, event Event) error
+}
+
+// WriterPublisher writes each event as a line of JSON, e.g. to stdout or a file
+type WriterPublisher struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// NewWriterPublisher creates a publisher writing JSON lines to w
+func NewWriterPublisher(w io.Writer) *WriterPublisher {
+	return &WriterPublisher{w: w}
+}
+
+// NewFilePublisher creates a publisher appending JSON lines to the file at path
+func NewFilePublisher(path string) (*WriterPublisher, io.Closer, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to open event file: %w", err)
+	}
+	return NewWriterPublisher(file), file, nil
+}
+
+// Publish writes the event as a single JSON line
+func (p *WriterPublisher) Publish(ctx context.Context, event Event) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to encode event: %w", err)
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if _, err := p.w.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("failed to write event: %w", err)
+	}
+	return nil
+}
+
+// MultiPublisher delivers each event to several publishers in turn. It fails if
+// any of them fails, so the relay retries the event for all of them.
+type MultiPublisher []EventPublisher
+
+// Publish hands the event to every publisher
+func (m MultiPublisher) Publish(ctx context.Context, event Event) error {
+	for _, p := range m {
+		if err := p.Publish(ctx, event); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// MemoryPublisher keeps published events in memory, for tests
+type MemoryPublisher struct {
+	mu     sync.Mutex
+	events []Event
+	err    error
+}
+
+// NewMemoryPublisher creates an empty in-memory publisher
+func NewMemoryPublisher() *MemoryPublisher {
+	return &MemoryPublisher{}
+}
+
+// Publish records the event, or returns the error set by FailWith
+func (p *MemoryPublisher) Publish(ctx context.Context, event Event) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.err != nil {
+		return p.err
+	}
+	p.events = append(p.events, event)
+	return nil
+}
+
+// FailWith makes subsequent publishes fail with err; nil restores normal operation
+func (p *MemoryPublisher) FailWith(err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.err = err
+}
+
+// Events returns a copy of the events published so far
+func (p *MemoryPublisher) Events() []Event {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]Event(nil), p.events...)
+}
@@ -0,0 +1,136 @@
+package events
+
+import (
+	"context"
+	"customer-service/internal/database"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// RelayConfig controls how often the relay polls and how it retries failures
+type RelayConfig struct {
+	PollInterval time.Duration
+	BatchSize    int
+	MinBackoff   time.Duration
+	MaxBackoff   time.Duration
+	// MaxAttempts is the number of failed attempts after which an event is dead-lettered
+	MaxAttempts int
+}
+
+// Relay publishes outbox events with at-least-once delivery. Events of the same
+// customer are published in the order they were written: when one fails, later
+// events for that customer wait until it has been delivered or dead-lettered.
+type Relay struct {
+	repo      OutboxRepository
+	publisher EventPublisher
+	tx        database.Transactor
+	cfg       RelayConfig
+}
+
+// NewRelay creates a new outbox relay
+func NewRelay(repo OutboxRepository, publisher EventPublisher, tx database.Transactor, cfg RelayConfig) *Relay {
+	if cfg.PollInterval <= 0 {
+		cfg.PollInterval = time.Second
+	}
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = 100
+	}
+	if cfg.MinBackoff <= 0 {
+		cfg.MinBackoff = time.Second
+	}
+	if cfg.MaxBackoff < cfg.MinBackoff {
+		cfg.MaxBackoff = 5 * time.Minute
+	}
+	if cfg.MaxAttempts <= 0 {
+		cfg.MaxAttempts = 20
+	}
+
+	return &Relay{
+		repo:      repo,
+		publisher: publisher,
+		tx:        tx,
+		cfg:       cfg,
+	}
+}
+
+// Run polls the outbox until ctx is cancelled
+func (r *Relay) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.cfg.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := r.ProcessBatch(ctx); err != nil && ctx.Err() == nil {
+			log.Printf("Outbox relay: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// ProcessBatch publishes one batch of due events and returns how many were delivered.
+// Only one relay instance processes the outbox at a time.
+func (r *Relay) ProcessBatch(ctx context.Context) (int, error) {
+	published := 0
+	err := r.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		locked, err := r.repo.TryLockRelay(ctx)
+		if err != nil || !locked {
+			return err
+		}
+
+		now := time.Now()
+		pending, err := r.repo.FetchPending(ctx, now, r.cfg.BatchSize)
+		if err != nil {
+			return err
+		}
+
+		blocked := make(map[uuid.UUID]bool)
+		for _, row := range pending {
+			// An earlier event for this customer failed in this batch
+			if blocked[row.AggregateID] {
+				continue
+			}
+
+			if err := r.publisher.Publish(ctx, row.ToEvent()); err != nil {
+				blocked[row.AggregateID] = true
+				attempts := row.Attempts + 1
+				var nextAttempt *time.Time
+				if attempts < r.cfg.MaxAttempts {
+					next := now.Add(r.backoff(row.Attempts))
+					nextAttempt = &next
+					log.Printf("Outbox relay: failed to publish %s %s (attempt %d): %v", row.EventType, row.ID, attempts, err)
+				} else {
+					log.Printf("Outbox relay: dead-lettered %s %s after %d attempts: %v", row.EventType, row.ID, attempts, err)
+				}
+				if err := r.repo.MarkFailed(ctx, row.Sequence, err, nextAttempt); err != nil {
+					return err
+				}
+				continue
+			}
+
+			if err := r.repo.MarkPublished(ctx, row.Sequence); err != nil {
+				return err
+			}
+			published++
+		}
+		return nil
+	})
+	return published, err
+}
+
+// backoff returns the exponential delay before retrying an event that has failed attempts times
+func (r *Relay) backoff(attempts int) time.Duration {
+	delay := r.cfg.MinBackoff
+	for i := 0; i < attempts && delay < r.cfg.MaxBackoff; i++ {
+		delay *= 2
+	}
+	if delay > r.cfg.MaxBackoff {
+		delay = r.cfg.MaxBackoff
+	}
+	return delay
+}
@@ -0,0 +1,309 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// transactionKey holds the transaction a context belongs to
+type transactionKey struct{}
+
+// memoryOutbox keeps outbox rows in memory, following the OutboxRepository contract.
+// The relay lock, like the advisory lock, is held by a transaction until it ends.
+type memoryOutbox struct {
+	rows   []OutboxEvent
+	holder any
+}
+
+func (o *memoryOutbox) Append(ctx context.Context, event *Event) error {
+	version := int64(0)
+	for _, row := range o.rows {
+		if row.AggregateID == event.CustomerID {
+			version = max(version, row.AggregateVersion)
+		}
+	}
+	o.rows = append(o.rows, OutboxEvent{
+		Sequence:         int64(len(o.rows) + 1),
+		ID:               event.ID,
+		AggregateID:      event.CustomerID,
+		AggregateVersion: version + 1,
+		EventType:        event.Type,
+		Payload:          string(event.Payload),
+		OccurredAt:       event.OccurredAt,
+		NextAttemptAt:    event.OccurredAt,
+	})
+	return nil
+}
+
+func (o *memoryOutbox) TryLockRelay(ctx context.Context) (bool, error) {
+	if o.holder != nil {
+		return false, nil
+	}
+	o.holder = ctx.Value(transactionKey{})
+	return true, nil
+}
+
+func (o *memoryOutbox) FetchPending(ctx context.Context, now time.Time, limit int) ([]OutboxEvent, error) {
+	waiting := make(map[uuid.UUID]int64)
+	for _, row := range o.rows {
+		if o.pending(row) && row.NextAttemptAt.After(now) {
+			if version, ok := waiting[row.AggregateID]; !ok || row.AggregateVersion < version {
+				waiting[row.AggregateID] = row.AggregateVersion
+			}
+		}
+	}
+
+	var pending []OutboxEvent
+	for _, row := range o.rows {
+		if len(pending) == limit {
+			break
+		}
+		if version, ok := waiting[row.AggregateID]; ok && version < row.AggregateVersion {
+			continue
+		}
+		if o.pending(row) && !row.NextAttemptAt.After(now) {
+			pending = append(pending, row)
+		}
+	}
+	return pending, nil
+}
+
+func (o *memoryOutbox) pending(row OutboxEvent) bool {
+	return row.PublishedAt == nil && row.DeadLetteredAt == nil
+}
+
+func (o *memoryOutbox) MarkPublished(ctx context.Context, sequence int64) error {
+	row := &o.rows[sequence-1]
+	now := time.Now()
+	row.PublishedAt = &now
+	row.Attempts++
+	row.LastError = nil
+	return nil
+}
+
+func (o *memoryOutbox) MarkFailed(ctx context.Context, sequence int64, cause error, nextAttempt *time.Time) error {
+	row := &o.rows[sequence-1]
+	message := cause.Error()
+	row.Attempts++
+	row.LastError = &message
+	if nextAttempt != nil {
+		row.NextAttemptAt = *nextAttempt
+	} else {
+		now := time.Now()
+		row.DeadLetteredAt = &now
+	}
+	return nil
+}
+
+// elapse moves the outbox forward in time
+func (o *memoryOutbox) elapse(d time.Duration) {
+	for i := range o.rows {
+		o.rows[i].NextAttemptAt = o.rows[i].NextAttemptAt.Add(-d)
+	}
+}
+
+// WithinTransaction runs fn as one transaction, releasing the relay lock if it took it
+func (o *memoryOutbox) WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	transaction := new(int)
+	defer func() {
+		if o.holder == transaction {
+			o.holder = nil
+		}
+	}()
+	return fn(context.WithValue(ctx, transactionKey{}, transaction))
+}
+
+// publishFunc adapts a function to EventPublisher
+type publishFunc func(ctx context.Context, event Event) error
+
+func (f publishFunc) Publish(ctx context.Context, event Event) error {
+	return f(ctx, event)
+}
+
+// appendEvents appends one event for each of the customers, in order
+func appendEvents(t *testing.T, outbox *memoryOutbox, customers ...uuid.UUID) {
+	t.Helper()
+	for _, customer := range customers {
+		event, err := NewEvent(CustomerUpdated, customer, map[string]any{})
+		if err != nil {
+			t.Fatalf("NewEvent() error = %v", err)
+		}
+		event.OccurredAt = event.OccurredAt.Add(-time.Second)
+		if err := outbox.Append(context.Background(), event); err != nil {
+			t.Fatalf("Append() error = %v", err)
+		}
+	}
+}
+
+func TestRelayProcessBatch(t *testing.T) {
+	const minBackoff = time.Second
+	first, second := uuid.New(), uuid.New()
+
+	tests := []struct {
+		name string
+		// fail maps sequences to the number of attempts at them that fail
+		fail        map[int64]int
+		maxAttempts int
+		batchSize   int
+		batches     int
+		// wantPublished lists the published sequences in publishing order
+		wantPublished    []int64
+		wantDeadLettered []int64
+		wantAttempts     map[int64]int
+	}{
+		{
+			name:          "events published in order",
+			batches:       1,
+			wantPublished: []int64{1, 2, 3, 4},
+		},
+		{
+			name:          "batch size",
+			batchSize:     3,
+			batches:       1,
+			wantPublished: []int64{1, 2, 3},
+		},
+		{
+			name:          "failed event holds back its customer's later events",
+			fail:          map[int64]int{1: 1},
+			batches:       1,
+			wantPublished: []int64{2, 4},
+			wantAttempts:  map[int64]int{1: 1, 3: 0},
+		},
+		{
+			name:          "retried event published before the later ones",
+			fail:          map[int64]int{1: 1},
+			batches:       2,
+			wantPublished: []int64{2, 4, 1, 3},
+			wantAttempts:  map[int64]int{1: 2, 3: 1},
+		},
+		{
+			name:             "dead-lettered event lets the later ones through",
+			fail:             map[int64]int{1: 100},
+			maxAttempts:      2,
+			batches:          3,
+			wantPublished:    []int64{2, 4, 3},
+			wantDeadLettered: []int64{1},
+			wantAttempts:     map[int64]int{1: 2, 3: 1},
+		},
+		{
+			name:             "event failing on its last attempt is dead-lettered at once",
+			fail:             map[int64]int{2: 1},
+			maxAttempts:      1,
+			batches:          1,
+			wantPublished:    []int64{1, 3},
+			wantDeadLettered: []int64{2},
+			wantAttempts:     map[int64]int{2: 1, 4: 0},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			outbox := &memoryOutbox{}
+			appendEvents(t, outbox, first, second, first, second)
+
+			var published []int64
+			failures := make(map[int64]int)
+			publisher := publishFunc(func(ctx context.Context, event Event) error {
+				if failures[event.Sequence] < tt.fail[event.Sequence] {
+					failures[event.Sequence]++
+					return errors.New("broker unavailable")
+				}
+				published = append(published, event.Sequence)
+				return nil
+			})
+			relay := NewRelay(outbox, publisher, outbox, RelayConfig{
+				BatchSize: tt.batchSize, MinBackoff: minBackoff, MaxBackoff: time.Minute, MaxAttempts: tt.maxAttempts,
+			})
+
+			for batch := 0; batch < tt.batches; batch++ {
+				if batch > 0 {
+					outbox.elapse(time.Minute)
+				}
+				start := time.Now()
+				before := len(published)
+				n, err := relay.ProcessBatch(context.Background())
+				if err != nil {
+					t.Fatalf("ProcessBatch() error = %v", err)
+				}
+				if n != len(published)-before {
+					t.Errorf("ProcessBatch() = %d, published %d", n, len(published)-before)
+				}
+				if batch == 0 {
+					for sequence := range tt.fail {
+						row := outbox.rows[sequence-1]
+						if row.DeadLetteredAt == nil && (row.NextAttemptAt.Before(start.Add(minBackoff)) || row.NextAttemptAt.After(time.Now().Add(minBackoff))) {
+							t.Errorf("event %d retried at %v, want %v after the attempt", sequence, row.NextAttemptAt, minBackoff)
+						}
+					}
+				}
+			}
+
+			if !slices.Equal(published, tt.wantPublished) {
+				t.Errorf("published %v, want %v", published, tt.wantPublished)
+			}
+			var deadLettered []int64
+			for _, row := range outbox.rows {
+				if row.DeadLetteredAt != nil {
+					deadLettered = append(deadLettered, row.Sequence)
+					if row.LastError == nil {
+						t.Errorf("event %d dead-lettered without its error", row.Sequence)
+					}
+				}
+			}
+			if !slices.Equal(deadLettered, tt.wantDeadLettered) {
+				t.Errorf("dead-lettered %v, want %v", deadLettered, tt.wantDeadLettered)
+			}
+			for sequence, want := range tt.wantAttempts {
+				if got := outbox.rows[sequence-1].Attempts; got != want {
+					t.Errorf("event %d attempted %d times, want %d", sequence, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestRelayLockHandoff(t *testing.T) {
+	outbox := &memoryOutbox{}
+	customer := uuid.New()
+	appendEvents(t, outbox, customer, customer)
+
+	var standby *Relay
+	standbyPublished := 0
+	standbyPublisher := publishFunc(func(ctx context.Context, event Event) error {
+		standbyPublished++
+		return nil
+	})
+	activePublisher := publishFunc(func(ctx context.Context, event Event) error {
+		// Another instance polls while this one holds the lock
+		n, err := standby.ProcessBatch(ctx)
+		if err != nil || n != 0 {
+			t.Errorf("ProcessBatch() of the other instance = %d, %v, want nothing published", n, err)
+		}
+		return nil
+	})
+
+	cfg := RelayConfig{MinBackoff: time.Second, MaxBackoff: time.Minute}
+	active := NewRelay(outbox, activePublisher, outbox, cfg)
+	standby = NewRelay(outbox, standbyPublisher, outbox, cfg)
+
+	if n, err := active.ProcessBatch(context.Background()); err != nil || n != 2 {
+		t.Fatalf("ProcessBatch() = %d, %v, want 2 published", n, err)
+	}
+	if standbyPublished != 0 {
+		t.Errorf("other instance published %d events while the lock was held, want none", standbyPublished)
+	}
+	if outbox.holder != nil {
+		t.Fatal("relay lock still held after the batch")
+	}
+
+	// With the lock released the other instance takes over
+	appendEvents(t, outbox, customer)
+	if n, err := standby.ProcessBatch(context.Background()); err != nil || n != 1 {
+		t.Errorf("ProcessBatch() after the lock was released = %d, %v, want 1 published", n, err)
+	}
+}
@@ -0,0 +1,219 @@
+package idempotency
+
+import (
+	"context"
+	"customer-service/internal/encryption"
+	"customer-service/pkg/middleware"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Key is a stored idempotency key and the response it produced. The response
+// body is encrypted, as responses carry customer data.
+type Key struct {
+	Actor           string `gorm:"primaryKey;size:255"`
+	Key             string `gorm:"primaryKey;size:255"`
+	RequestHash     string `gorm:"not null;size:64"`
+	Completed       bool   `gorm:"not null;default:false"`
+	StatusCode      int
+	ResponseHeaders *string `gorm:"type:jsonb"`
+	ResponseBody    []byte
+	ResourceID      string `gorm:"size:255;not null;default:''"`
+	CreatedAt       time.Time
+	ExpiresAt       time.Time `gorm:"not null;index"`
+	LockedUntil     time.Time `gorm:"not null"`
+}
+
+// TableName returns the table name for Key model
+func (Key) TableName() string {
+	return "idempotency_keys"
+}
+
+// Store is a PostgreSQL backed middleware.IdempotencyStore
+type Store struct {
+	db     *gorm.DB
+	cipher *encryption.Cipher
+}
+
+// NewStore creates a new idempotency key store that encrypts response bodies with cipher
+func NewStore(db *gorm.DB, cipher *encryption.Cipher) *Store {
+	return &Store{db: db, cipher: cipher}
+}
+
+// Reserve claims the key, or returns the existing record if it is already claimed.
+// A reservation of the same request whose lease has run out without it completing
+// is taken over.
+func (s *Store) Reserve(ctx context.Context, record *middleware.IdempotencyRecord) (*middleware.IdempotencyRecord, error) {
+	db := s.db.WithContext(ctx)
+
+	row := Key{
+		Actor:       record.Actor,
+		Key:         record.Key,
+		RequestHash: record.RequestHash,
+		ExpiresAt:   record.ExpiresAt,
+		LockedUntil: record.LockedUntil,
+	}
+
+	// An expired key is treated as never used
+	if err := db.Where("actor = ? AND key = ? AND expires_at < ?", record.Actor, record.Key, time.Now()).
+		Delete(&Key{}).Error; err != nil {
+		return nil, fmt.Errorf("failed to expire idempotency key: %w", err)
+	}
+
+	result := db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "actor"}, {Name: "key"}},
+		DoUpdates: clause.AssignmentColumns([]string{"locked_until", "expires_at"}),
+		Where: clause.Where{Exprs: []clause.Expression{
+			clause.Expr{SQL: "NOT idempotency_keys.completed"},
+			clause.Expr{SQL: "idempotency_keys.locked_until < ?", Vars: []any{time.Now()}},
+			clause.Expr{SQL: "idempotency_keys.request_hash = excluded.request_hash"},
+		}},
+	}).Create(&row)
+	if result.Error != nil {
+		return nil, fmt.Errorf("failed to reserve idempotency key: %w", result.Error)
+	}
+	if result.RowsAffected == 1 {
+		return nil, nil
+	}
+
+	var existing Key
+	if err := db.Where("actor = ? AND key = ?", record.Actor, record.Key).First(&existing).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			// Released between our insert and read; let the client retry
+			return nil, fmt.Errorf("idempotency key was released concurrently")
+		}
+		return nil, fmt.Errorf("failed to get idempotency key: %w", err)
+	}
+
+	stored, err := existing.toRecord()
+	if err != nil {
+		return nil, err
+	}
+	if stored.Body, err = s.openBody(ctx, &existing); err != nil {
+		return nil, err
+	}
+	return stored, nil
+}
+
+// Complete stores the response produced for a reserved key, unless the reservation
+// was taken over
+func (s *Store) Complete(ctx context.Context, record *middleware.IdempotencyRecord) error {
+	headers, err := json.Marshal(record.Headers)
+	if err != nil {
+		return fmt.Errorf("failed to encode response headers: %w", err)
+	}
+	body, err := s.sealBody(ctx, record)
+	if err != nil {
+		return err
+	}
+
+	err = s.db.WithContext(ctx).Model(&Key{}).
+		Where("actor = ? AND key = ? AND locked_until = ? AND NOT completed", record.Actor, record.Key, record.LockedUntil).
+		Updates(map[string]any{
+			"completed":        true,
+			"status_code":      record.StatusCode,
+			"response_headers": string(headers),
+			"response_body":    body,
+			"resource_id":      record.ResourceID,
+		}).Error
+	if err != nil {
+		return fmt.Errorf("failed to complete idempotency key: %w", err)
+	}
+	return nil
+}
+
+// Release removes a reservation so the request can be retried, unless it was taken over
+func (s *Store) Release(ctx context.Context, record *middleware.IdempotencyRecord) error {
+	err := s.db.WithContext(ctx).
+		Where("actor = ? AND key = ? AND locked_until = ? AND NOT completed", record.Actor, record.Key, record.LockedUntil).
+		Delete(&Key{}).Error
+	if err != nil {
+		return fmt.Errorf("failed to release idempotency key: %w", err)
+	}
+	return nil
+}
+
+// PurgeExpired deletes keys whose TTL has passed and returns how many were removed
+func (s *Store) PurgeExpired(ctx context.Context) (int64, error) {
+	result := s.db.WithContext(ctx).Where("expires_at < ?", time.Now()).Delete(&Key{})
+	if result.Error != nil {
+		return 0, fmt.Errorf("failed to purge idempotency keys: %w", result.Error)
+	}
+	return result.RowsAffected, nil
+}
+
+// RunJanitor purges expired keys every interval until ctx is cancelled
+func (s *Store) RunJanitor(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := s.PurgeExpired(ctx); err != nil {
+				log.Printf("Idempotency janitor: %v", err)
+			}
+		}
+	}
+}
+
+// sealBody encrypts a response body, bound to the key it is stored under
+func (s *Store) sealBody(ctx context.Context, record *middleware.IdempotencyRecord) ([]byte, error) {
+	if len(record.Body) == 0 {
+		return nil, nil
+	}
+	value, err := s.cipher.Encrypt(ctx, "response_body", bodyOwner(record.Actor, record.Key), record.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encrypt idempotent response: %w", err)
+	}
+	return []byte(value), nil
+}
+
+// openBody decrypts a stored response body. Bodies stored before they were
+// encrypted are returned as they are; they are gone once their TTL has passed.
+func (s *Store) openBody(ctx context.Context, k *Key) ([]byte, error) {
+	if !encryption.IsEncrypted(string(k.ResponseBody)) {
+		return k.ResponseBody, nil
+	}
+	body, err := s.cipher.Decrypt(ctx, "response_body", bodyOwner(k.Actor, k.Key), string(k.ResponseBody))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt idempotent response: %w", err)
+	}
+	return body, nil
+}
+
+// bodyOwner identifies the key a response body belongs to, so it cannot be
+// replayed under another
+func bodyOwner(actor, key string) string {
+	return actor + "\x00" + key
+}
+
+// toRecord converts a stored key to the middleware representation, with the
+// body still encrypted
+func (k *Key) toRecord() (*middleware.IdempotencyRecord, error) {
+	record := &middleware.IdempotencyRecord{
+		Key:         k.Key,
+		Actor:       k.Actor,
+		RequestHash: k.RequestHash,
+		Completed:   k.Completed,
+		StatusCode:  k.StatusCode,
+		Body:        k.ResponseBody,
+		ResourceID:  k.ResourceID,
+		ExpiresAt:   k.ExpiresAt,
+		LockedUntil: k.LockedUntil,
+	}
+	if k.ResponseHeaders != nil {
+		if err := json.Unmarshal([]byte(*k.ResponseHeaders), &record.Headers); err != nil {
+			return nil, fmt.Errorf("failed to decode response headers: %w", err)
+		}
+	}
+	return record, nil
+}
@@ -0,0 +1,71 @@
+package idempotency
+
+import (
+	"bytes"
+	"context"
+	"customer-service/internal/encryption"
+	"customer-service/pkg/middleware"
+	"path/filepath"
+	"testing"
+)
+
+// newTestStore returns a store, without a database, encrypting with a new key file
+func newTestStore(t *testing.T) *Store {
+	t.Helper()
+	file, err := encryption.NewKeyFile()
+	if err != nil {
+		t.Fatalf("NewKeyFile() error = %v", err)
+	}
+	path := filepath.Join(t.TempDir(), "keys.json")
+	if err := encryption.WriteKeyFile(path, file); err != nil {
+		t.Fatalf("WriteKeyFile() error = %v", err)
+	}
+	keys, err := encryption.NewFileKeyProvider(path)
+	if err != nil {
+		t.Fatalf("NewFileKeyProvider() error = %v", err)
+	}
+	return NewStore(nil, encryption.NewCipher(keys))
+}
+
+func TestResponseBodyEncryption(t *testing.T) {
+	s := newTestStore(t)
+	ctx := context.Background()
+	body := []byte(`{"id":"c-1","email":"jane@example.com"}`)
+
+	sealed, err := s.sealBody(ctx, &middleware.IdempotencyRecord{Actor: "teller-1", Key: "k1", Body: body})
+	if err != nil {
+		t.Fatalf("sealBody() error = %v", err)
+	}
+	if bytes.Contains(sealed, []byte("jane@example.com")) {
+		t.Fatalf("sealBody() = %s, contains the response", sealed)
+	}
+
+	tests := []struct {
+		name    string
+		key     Key
+		want    []byte
+		wantErr bool
+	}{
+		{name: "stored under its key", key: Key{Actor: "teller-1", Key: "k1", ResponseBody: sealed}, want: body},
+		{name: "moved to another key", key: Key{Actor: "teller-1", Key: "k2", ResponseBody: sealed}, wantErr: true},
+		{name: "moved to another caller", key: Key{Actor: "teller-2", Key: "k1", ResponseBody: sealed}, wantErr: true},
+		{name: "stored before encryption", key: Key{Actor: "teller-1", Key: "k1", ResponseBody: body}, want: body},
+		{name: "no body", key: Key{Actor: "teller-1", Key: "k1"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := s.openBody(ctx, &tt.key)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("openBody() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !bytes.Equal(got, tt.want) {
+				t.Errorf("openBody() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+
+	if empty, err := s.sealBody(ctx, &middleware.IdempotencyRecord{Actor: "teller-1", Key: "k1"}); err != nil || empty != nil {
+		t.Errorf("sealBody() of an empty body = %q, %v, want nothing stored", empty, err)
+	}
+}
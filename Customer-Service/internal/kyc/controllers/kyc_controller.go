@@ -0,0 +1,256 @@
+package controllers
+
+import (
+	customermodels "customer-service/internal/customer/models"
+	"customer-service/internal/kyc/models"
+	"customer-service/internal/kyc/service"
+	"customer-service/pkg/apierror"
+	"customer-service/pkg/middleware"
+	"errors"
+	"log"
+	"mime"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// multipartOverhead allows for the form fields and part headers around an uploaded file
+const multipartOverhead = 1 << 20
+
+// KYCController handles HTTP requests for KYC verification
+type KYCController struct {
+	service         service.KYCService
+	maxDocumentSize int64
+}
+
+// NewKYCController creates a new KYC controller instance. Request bodies larger than
+// maxDocumentSize plus room for the form fields are rejected before being read.
+func NewKYCController(kycService service.KYCService, maxDocumentSize int64) *KYCController {
+	return &KYCController{
+		service:         kycService,
+		maxDocumentSize: maxDocumentSize,
+	}
+}
+
+// GetSummary handles GET /customers/:id/kyc
+func (ctrl *KYCController) GetSummary(c *gin.Context) {
+	id, ok := ctrl.parseUUID(c, "id")
+	if !ok {
+		return
+	}
+
+	summary, err := ctrl.service.GetSummary(c.Request.Context(), id)
+	if err != nil {
+		ctrl.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, summary)
+}
+
+// OpenCase handles POST /customers/:id/kyc/cases
+func (ctrl *KYCController) OpenCase(c *gin.Context) {
+	id, ok := ctrl.parseUUID(c, "id")
+	if !ok {
+		return
+	}
+
+	kycCase, err := ctrl.service.OpenCase(c.Request.Context(), id, actorFromContext(c))
+	if err != nil {
+		ctrl.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, kycCase)
+}
+
+// GetCase handles GET /kyc/cases/:caseId
+func (ctrl *KYCController) GetCase(c *gin.Context) {
+	id, ok := ctrl.parseUUID(c, "caseId")
+	if !ok {
+		return
+	}
+
+	kycCase, err := ctrl.service.GetCase(c.Request.Context(), id)
+	if err != nil {
+		ctrl.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, kycCase)
+}
+
+// UploadDocument handles POST /kyc/cases/:caseId/documents. The body is a
+// multipart form with the document fields and the file in the "file" part.
+func (ctrl *KYCController) UploadDocument(c *gin.Context) {
+	id, ok := ctrl.parseUUID(c, "caseId")
+	if !ok {
+		return
+	}
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, ctrl.maxDocumentSize+multipartOverhead)
+
+	var req models.DocumentUploadRequest
+	if err := c.ShouldBind(&req); err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			ctrl.handleError(c, models.ErrDocumentTooLarge)
+			return
+		}
+		apierror.Abort(c, http.StatusBadRequest, apierror.CodeInvalidRequest, "invalid request body: "+err.Error())
+		return
+	}
+	if err := customermodels.ValidateStruct(req); err != nil {
+		ctrl.handleError(c, err)
+		return
+	}
+
+	header, err := c.FormFile("file")
+	if err != nil {
+		apierror.Abort(c, http.StatusBadRequest, apierror.CodeValidationFailed, "request validation failed",
+			apierror.FieldError{Field: "file", Message: "is required"})
+		return
+	}
+	file, err := header.Open()
+	if err != nil {
+		ctrl.handleError(c, err)
+		return
+	}
+	defer file.Close()
+
+	document, err := ctrl.service.UploadDocument(c.Request.Context(), id, req, header.Filename, file, actorFromContext(c))
+	if err != nil {
+		ctrl.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, document)
+}
+
+// DownloadDocument handles GET /kyc/cases/:caseId/documents/:documentId/content
+func (ctrl *KYCController) DownloadDocument(c *gin.Context) {
+	caseID, ok := ctrl.parseUUID(c, "caseId")
+	if !ok {
+		return
+	}
+	documentID, ok := ctrl.parseUUID(c, "documentId")
+	if !ok {
+		return
+	}
+
+	document, content, err := ctrl.service.OpenDocument(c.Request.Context(), caseID, documentID)
+	if err != nil {
+		ctrl.handleError(c, err)
+		return
+	}
+	defer content.Close()
+
+	c.DataFromReader(http.StatusOK, document.Size, document.ContentType, content, map[string]string{
+		"Content-Disposition":    mime.FormatMediaType("attachment", map[string]string{"filename": document.FileName}),
+		"Cache-Control":          "no-store",
+		"X-Content-Type-Options": "nosniff",
+	})
+}
+
+// SubmitCase handles POST /kyc/cases/:caseId/submit
+func (ctrl *KYCController) SubmitCase(c *gin.Context) {
+	id, ok := ctrl.parseUUID(c, "caseId")
+	if !ok {
+		return
+	}
+
+	kycCase, err := ctrl.service.SubmitCase(c.Request.Context(), id, actorFromContext(c))
+	if err != nil {
+		ctrl.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, kycCase)
+}
+
+// DecideCase handles POST /kyc/cases/:caseId/decisions
+func (ctrl *KYCController) DecideCase(c *gin.Context) {
+	id, ok := ctrl.parseUUID(c, "caseId")
+	if !ok {
+		return
+	}
+
+	var req models.DecisionRequest
+	if !ctrl.bindJSON(c, &req) {
+		return
+	}
+
+	kycCase, err := ctrl.service.DecideCase(c.Request.Context(), id, req, actorFromContext(c))
+	if err != nil {
+		ctrl.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, kycCase)
+}
+
+// bindJSON decodes and validates the request body, writing an error response on failure
+func (ctrl *KYCController) bindJSON(c *gin.Context, req any) bool {
+	if err := c.ShouldBindJSON(req); err != nil {
+		apierror.Abort(c, http.StatusBadRequest, apierror.CodeInvalidRequest, "invalid request body: "+err.Error())
+		return false
+	}
+
+	if err := customermodels.ValidateStruct(req); err != nil {
+		ctrl.handleError(c, err)
+		return false
+	}
+
+	return true
+}
+
+// parseUUID parses a UUID path parameter, writing an error response on failure
+func (ctrl *KYCController) parseUUID(c *gin.Context, param string) (uuid.UUID, bool) {
+	id, err := uuid.Parse(c.Param(param))
+	if err != nil {
+		apierror.Abort(c, http.StatusBadRequest, apierror.CodeInvalidRequest, "invalid "+param,
+			apierror.FieldError{Field: param, Message: "must be a valid UUID"})
+		return uuid.Nil, false
+	}
+	return id, true
+}
+
+// handleError maps service errors to HTTP responses
+func (ctrl *KYCController) handleError(c *gin.Context, err error) {
+	var validationErr *customermodels.ValidationError
+	switch {
+	case errors.As(err, &validationErr):
+		fields := make([]apierror.FieldError, len(validationErr.Fields))
+		for i, f := range validationErr.Fields {
+			fields[i] = apierror.FieldError{Field: f.Field, Message: f.Message}
+		}
+		apierror.Abort(c, http.StatusBadRequest, apierror.CodeValidationFailed, "request validation failed", fields...)
+	case errors.Is(err, customermodels.ErrCustomerNotFound),
+		errors.Is(err, models.ErrCaseNotFound),
+		errors.Is(err, models.ErrDocumentNotFound):
+		apierror.Abort(c, http.StatusNotFound, apierror.CodeNotFound, err.Error())
+	case errors.Is(err, models.ErrCaseAlreadyOpen):
+		apierror.Abort(c, http.StatusConflict, apierror.CodeConflict, err.Error())
+	case errors.Is(err, models.ErrInvalidCaseTransition):
+		apierror.Abort(c, http.StatusConflict, apierror.CodeInvalidTransition, err.Error())
+	case errors.Is(err, models.ErrMissingDocuments):
+		apierror.Abort(c, http.StatusUnprocessableEntity, apierror.CodeKYCIncomplete, err.Error())
+	case errors.Is(err, models.ErrDocumentTooLarge):
+		apierror.Abort(c, http.StatusRequestEntityTooLarge, apierror.CodePayloadTooLarge, err.Error())
+	case errors.Is(err, models.ErrUnsupportedContentType):
+		apierror.Abort(c, http.StatusUnsupportedMediaType, apierror.CodeUnsupportedMedia, err.Error())
+	default:
+		log.Printf("Unhandled error processing %s %s: %v", c.Request.Method, c.Request.URL.Path, err)
+		apierror.Abort(c, http.StatusInternalServerError, apierror.CodeInternal, "internal server error")
+	}
+}
+
+// actorFromContext returns the subject of the authenticated caller
+func actorFromContext(c *gin.Context) string {
+	claims, ok := middleware.GetClaims(c)
+	if !ok {
+		return ""
+	}
+	return claims.Subject
+}
@@ -0,0 +1,18 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// CustomerKYCStatusChangedPayload is the payload of a CustomerKYCStatusChanged event.
+// FromStatus is empty when the case was just opened.
+type CustomerKYCStatusChangedPayload struct {
+	CaseID     uuid.UUID  `json:"case_id"`
+	FromStatus CaseStatus `json:"from_status,omitempty"`
+	ToStatus   CaseStatus `json:"to_status"`
+	Reason     string     `json:"reason,omitempty"`
+	Actor      string     `json:"actor"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+}
@@ -0,0 +1,150 @@
+package models
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Domain errors returned by the KYC repository and service layers
+var (
+	ErrCaseNotFound           = errors.New("KYC case not found")
+	ErrDocumentNotFound       = errors.New("KYC document not found")
+	ErrCaseAlreadyOpen        = errors.New("customer already has an open KYC case")
+	ErrInvalidCaseTransition  = errors.New("invalid KYC case transition")
+	ErrMissingDocuments       = errors.New("KYC case is missing required documents")
+	ErrDocumentTooLarge       = errors.New("document exceeds the maximum upload size")
+	ErrUnsupportedContentType = errors.New("unsupported document content type")
+)
+
+// CaseStatus represents the state of a KYC verification case
+type CaseStatus string
+
+const (
+	CaseStatusPending  CaseStatus = "pending"
+	CaseStatusInReview CaseStatus = "in_review"
+	CaseStatusVerified CaseStatus = "verified"
+	CaseStatusRejected CaseStatus = "rejected"
+	CaseStatusExpired  CaseStatus = "expired"
+)
+
+// Case is a single verification of a customer's identity. A customer has at most
+// one open (pending or in review) case; decided cases are kept as history.
+type Case struct {
+	ID          uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	CustomerID  uuid.UUID  `json:"customer_id" gorm:"type:uuid;not null;index"`
+	Status      CaseStatus `json:"status" gorm:"not null;size:20"`
+	OpenedBy    string     `json:"opened_by" gorm:"not null;size:255"`
+	SubmittedAt *time.Time `json:"submitted_at,omitempty"`
+	DecidedAt   *time.Time `json:"decided_at,omitempty"`
+	// ExpiresAt is when a verified case lapses and the customer must be verified again
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+
+	Documents []Document `json:"documents,omitempty" gorm:"foreignKey:CaseID"`
+	Decisions []Decision `json:"decisions,omitempty" gorm:"foreignKey:CaseID"`
+}
+
+// TableName returns the table name for Case model
+func (Case) TableName() string {
+	return "kyc_cases"
+}
+
+// IsOpen reports whether documents may still be added to the case
+func (c *Case) IsOpen() bool {
+	return c.Status == CaseStatusPending || c.Status == CaseStatusInReview
+}
+
+// IsValid reports whether the case currently verifies the customer
+func (c *Case) IsValid(now time.Time) bool {
+	return c.Status == CaseStatusVerified && (c.ExpiresAt == nil || c.ExpiresAt.After(now))
+}
+
+// DocumentType represents the kind of document uploaded to a case
+type DocumentType string
+
+const (
+	DocumentTypePassport       DocumentType = "passport"
+	DocumentTypeNationalID     DocumentType = "national_id"
+	DocumentTypeProofOfAddress DocumentType = "proof_of_address"
+)
+
+// IsIdentity reports whether the document proves the customer's identity
+func (t DocumentType) IsIdentity() bool {
+	return t == DocumentTypePassport || t == DocumentTypeNationalID
+}
+
+// Document is the metadata of an uploaded document. The file itself is kept in
+// the document store under StorageKey.
+type Document struct {
+	ID             uuid.UUID    `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	CaseID         uuid.UUID    `json:"case_id" gorm:"type:uuid;not null;index"`
+	CustomerID     uuid.UUID    `json:"customer_id" gorm:"type:uuid;not null"`
+	Type           DocumentType `json:"type" gorm:"not null;size:30"`
+	FileName       string       `json:"file_name" gorm:"not null;size:255"`
+	ContentType    string       `json:"content_type" gorm:"not null;size:100"`
+	Size           int64        `json:"size" gorm:"not null"`
+	SHA256         string       `json:"sha256" gorm:"column:sha256;not null;size:64"`
+	StorageKey     string       `json:"-" gorm:"not null;size:500"`
+	IssuingCountry string       `json:"issuing_country,omitempty" gorm:"size:2"`
+	ExpiresOn      *time.Time   `json:"expires_on,omitempty" gorm:"type:date"`
+	UploadedBy     string       `json:"uploaded_by" gorm:"not null;size:255"`
+	CreatedAt      time.Time    `json:"created_at"`
+}
+
+// TableName returns the table name for Document model
+func (Document) TableName() string {
+	return "kyc_documents"
+}
+
+// DecisionOutcome is a reviewer's ruling on a case in review
+type DecisionOutcome string
+
+const (
+	// DecisionApprove verifies the customer
+	DecisionApprove DecisionOutcome = "approve"
+	// DecisionReject closes the case unverified
+	DecisionReject DecisionOutcome = "reject"
+	// DecisionRequestChanges sends the case back to pending for further documents
+	DecisionRequestChanges DecisionOutcome = "request_changes"
+)
+
+// Decision records a reviewer's ruling on a case
+type Decision struct {
+	ID        uuid.UUID       `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	CaseID    uuid.UUID       `json:"case_id" gorm:"type:uuid;not null;index"`
+	Decision  DecisionOutcome `json:"decision" gorm:"not null;size:20"`
+	Reason    string          `json:"reason,omitempty" gorm:"size:500"`
+	Reviewer  string          `json:"reviewer" gorm:"not null;size:255"`
+	CreatedAt time.Time       `json:"created_at"`
+}
+
+// TableName returns the table name for Decision model
+func (Decision) TableName() string {
+	return "kyc_decisions"
+}
+
+// DocumentUploadRequest represents the form fields sent with a document upload
+type DocumentUploadRequest struct {
+	Type           DocumentType `json:"type" form:"type" validate:"required,oneof=passport national_id proof_of_address"`
+	IssuingCountry string       `json:"issuing_country" form:"issuing_country" validate:"omitempty,len=2,alpha"`
+	ExpiresOn      *time.Time   `json:"expires_on" form:"expires_on" time_format:"2006-01-02"`
+}
+
+// DecisionRequest represents the request payload for a reviewer decision
+type DecisionRequest struct {
+	Decision DecisionOutcome `json:"decision" validate:"required,oneof=approve reject request_changes"`
+	Reason   string          `json:"reason" validate:"max=500"`
+}
+
+// Summary describes the KYC standing of a customer
+type Summary struct {
+	CustomerID uuid.UUID `json:"customer_id"`
+	// Verified reports whether the customer holds a valid verification
+	Verified  bool       `json:"verified"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	// Cases lists every case of the customer, newest first, without documents
+	Cases []Case `json:"cases"`
+}
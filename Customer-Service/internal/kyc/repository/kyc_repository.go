@@ -0,0 +1,168 @@
+package repository
+
+import (
+	"context"
+	"customer-service/internal/database"
+	"customer-service/internal/kyc/models"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// KYCRepository defines the interface for KYC case, document and decision data access
+type KYCRepository interface {
+	CreateCase(ctx context.Context, kycCase *models.Case) error
+	GetCase(ctx context.Context, id uuid.UUID) (*models.Case, error)
+	ListCases(ctx context.Context, customerID uuid.UUID) ([]models.Case, error)
+	LatestDecidedCase(ctx context.Context, customerID uuid.UUID) (*models.Case, error)
+	TransitionCase(ctx context.Context, kycCase *models.Case, from models.CaseStatus) error
+	ListExpiredCases(ctx context.Context, now time.Time, limit int) ([]models.Case, error)
+
+	CreateDocument(ctx context.Context, document *models.Document) error
+	GetDocument(ctx context.Context, caseID, id uuid.UUID) (*models.Document, error)
+
+	CreateDecision(ctx context.Context, decision *models.Decision) error
+}
+
+type kycRepository struct {
+	db *gorm.DB
+}
+
+// NewKYCRepository creates a new KYC repository instance
+func NewKYCRepository(db *gorm.DB) KYCRepository {
+	return &kycRepository{db: db}
+}
+
+// CreateCase opens a new case. It returns ErrCaseAlreadyOpen when the customer
+// already has a pending or in review case, which a partial unique index enforces.
+func (r *kycRepository) CreateCase(ctx context.Context, kycCase *models.Case) error {
+	result := database.Conn(ctx, r.db).
+		Omit(clause.Associations).
+		Clauses(clause.OnConflict{DoNothing: true}).
+		Create(kycCase)
+	if result.Error != nil {
+		return fmt.Errorf("failed to create KYC case: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return models.ErrCaseAlreadyOpen
+	}
+	return nil
+}
+
+// GetCase retrieves a case by ID together with its documents and decisions
+func (r *kycRepository) GetCase(ctx context.Context, id uuid.UUID) (*models.Case, error) {
+	var kycCase models.Case
+	err := database.Conn(ctx, r.db).
+		Preload("Documents", func(db *gorm.DB) *gorm.DB { return db.Order("created_at") }).
+		Preload("Decisions", func(db *gorm.DB) *gorm.DB { return db.Order("created_at") }).
+		Where("id = ?", id).
+		First(&kycCase).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, models.ErrCaseNotFound
+		}
+		return nil, fmt.Errorf("failed to get KYC case: %w", err)
+	}
+	return &kycCase, nil
+}
+
+// ListCases returns every case of a customer, newest first
+func (r *kycRepository) ListCases(ctx context.Context, customerID uuid.UUID) ([]models.Case, error) {
+	var cases []models.Case
+	err := database.Conn(ctx, r.db).
+		Where("customer_id = ?", customerID).
+		Order("created_at DESC").
+		Find(&cases).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list KYC cases: %w", err)
+	}
+	return cases, nil
+}
+
+// LatestDecidedCase returns the most recently decided case of a customer. A later
+// rejection supersedes an earlier verification.
+func (r *kycRepository) LatestDecidedCase(ctx context.Context, customerID uuid.UUID) (*models.Case, error) {
+	var kycCase models.Case
+	err := database.Conn(ctx, r.db).
+		Where("customer_id = ? AND decided_at IS NOT NULL", customerID).
+		Order("decided_at DESC").
+		Take(&kycCase).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, models.ErrCaseNotFound
+		}
+		return nil, fmt.Errorf("failed to get latest KYC decision: %w", err)
+	}
+	return &kycCase, nil
+}
+
+// TransitionCase saves the status and timestamps of a case, provided it is still in
+// status from. It returns ErrInvalidCaseTransition when another request moved it first.
+func (r *kycRepository) TransitionCase(ctx context.Context, kycCase *models.Case, from models.CaseStatus) error {
+	kycCase.UpdatedAt = time.Now()
+	result := database.Conn(ctx, r.db).Model(&models.Case{}).
+		Where("id = ? AND status = ?", kycCase.ID, from).
+		Updates(map[string]interface{}{
+			"status":       kycCase.Status,
+			"submitted_at": kycCase.SubmittedAt,
+			"decided_at":   kycCase.DecidedAt,
+			"expires_at":   kycCase.ExpiresAt,
+			"updated_at":   kycCase.UpdatedAt,
+		})
+	if result.Error != nil {
+		return fmt.Errorf("failed to update KYC case: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("%w: case is no longer %s", models.ErrInvalidCaseTransition, from)
+	}
+	return nil
+}
+
+// ListExpiredCases returns verified cases whose verification lapsed before now
+func (r *kycRepository) ListExpiredCases(ctx context.Context, now time.Time, limit int) ([]models.Case, error) {
+	var cases []models.Case
+	err := database.Conn(ctx, r.db).
+		Where("status = ? AND expires_at <= ?", models.CaseStatusVerified, now).
+		Order("expires_at").
+		Limit(limit).
+		Find(&cases).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list expired KYC cases: %w", err)
+	}
+	return cases, nil
+}
+
+// CreateDocument stores the metadata of an uploaded document
+func (r *kycRepository) CreateDocument(ctx context.Context, document *models.Document) error {
+	if err := database.Conn(ctx, r.db).Create(document).Error; err != nil {
+		return fmt.Errorf("failed to create KYC document: %w", err)
+	}
+	return nil
+}
+
+// GetDocument retrieves a document of a case
+func (r *kycRepository) GetDocument(ctx context.Context, caseID, id uuid.UUID) (*models.Document, error) {
+	var document models.Document
+	err := database.Conn(ctx, r.db).
+		Where("id = ? AND case_id = ?", id, caseID).
+		First(&document).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, models.ErrDocumentNotFound
+		}
+		return nil, fmt.Errorf("failed to get KYC document: %w", err)
+	}
+	return &document, nil
+}
+
+// CreateDecision stores a reviewer decision
+func (r *kycRepository) CreateDecision(ctx context.Context, decision *models.Decision) error {
+	if err := database.Conn(ctx, r.db).Create(decision).Error; err != nil {
+		return fmt.Errorf("failed to create KYC decision: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,71 @@
+package service
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// ExpiryConfig controls how often lapsed verifications are expired and the batch size
+type ExpiryConfig struct {
+	Interval  time.Duration
+	BatchSize int
+}
+
+// Expirer moves verified KYC cases to expired once their validity ends. Activation
+// checks compare expires_at themselves, so a late run never lets a lapsed
+// verification through; the job keeps the case status and event stream accurate.
+type Expirer struct {
+	service KYCService
+	cfg     ExpiryConfig
+}
+
+// NewExpirer creates a new KYC expiry job
+func NewExpirer(service KYCService, cfg ExpiryConfig) *Expirer {
+	if cfg.Interval <= 0 {
+		cfg.Interval = time.Hour
+	}
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = 100
+	}
+
+	return &Expirer{
+		service: service,
+		cfg:     cfg,
+	}
+}
+
+// Run expires lapsed verifications every interval until ctx is cancelled
+func (e *Expirer) Run(ctx context.Context) {
+	ticker := time.NewTicker(e.cfg.Interval)
+	defer ticker.Stop()
+
+	for {
+		expired, err := e.RunOnce(ctx)
+		if err != nil && ctx.Err() == nil {
+			log.Printf("KYC expiry: %v", err)
+		}
+		if expired > 0 {
+			log.Printf("KYC expiry: expired %d verification(s)", expired)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunOnce expires every verification that has lapsed and returns how many were expired
+func (e *Expirer) RunOnce(ctx context.Context) (int, error) {
+	now := time.Now()
+	total := 0
+	for {
+		expired, err := e.service.ExpireVerifications(ctx, now, e.cfg.BatchSize)
+		total += expired
+		if err != nil || expired < e.cfg.BatchSize {
+			return total, err
+		}
+	}
+}
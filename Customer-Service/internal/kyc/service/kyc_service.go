@@ -0,0 +1,504 @@
+package service
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"customer-service/internal/audit"
+	customermodels "customer-service/internal/customer/models"
+	customerrepository "customer-service/internal/customer/repository"
+	"customer-service/internal/database"
+	"customer-service/internal/events"
+	"customer-service/internal/kyc/models"
+	"customer-service/internal/kyc/repository"
+	"customer-service/internal/kyc/storage"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+)
+
+// allowedContentTypes lists the document formats accepted for upload, detected from
+// the file content rather than trusted from the client
+var allowedContentTypes = map[string]bool{
+	"application/pdf": true,
+	"image/jpeg":      true,
+	"image/png":       true,
+}
+
+// Config holds the limits applied by the KYC service
+type Config struct {
+	// MaxDocumentSize is the largest accepted upload in bytes
+	MaxDocumentSize int64
+	// Validity is how long a verification lasts before the customer must be verified again
+	Validity time.Duration
+}
+
+// KYCService defines the interface for the KYC verification workflow
+type KYCService interface {
+	GetSummary(ctx context.Context, customerID uuid.UUID) (*models.Summary, error)
+	OpenCase(ctx context.Context, customerID uuid.UUID, actor string) (*models.Case, error)
+	GetCase(ctx context.Context, id uuid.UUID) (*models.Case, error)
+	UploadDocument(ctx context.Context, caseID uuid.UUID, req models.DocumentUploadRequest, fileName string, content io.Reader, actor string) (*models.Document, error)
+	OpenDocument(ctx context.Context, caseID, documentID uuid.UUID) (*models.Document, io.ReadCloser, error)
+	SubmitCase(ctx context.Context, caseID uuid.UUID, actor string) (*models.Case, error)
+	DecideCase(ctx context.Context, caseID uuid.UUID, req models.DecisionRequest, actor string) (*models.Case, error)
+	IsVerified(ctx context.Context, customerID uuid.UUID) (bool, error)
+	ExpireVerifications(ctx context.Context, now time.Time, limit int) (int, error)
+}
+
+type kycService struct {
+	repo      repository.KYCRepository
+	customers customerrepository.CustomerRepository
+	store     storage.DocumentStore
+	outbox    events.Outbox
+	audit     audit.Recorder
+	tx        database.Transactor
+	cfg       Config
+}
+
+// NewKYCService creates a new KYC service instance. Case changes are written together
+// with their audit entries and CustomerKYCStatusChanged events.
+func NewKYCService(repo repository.KYCRepository, customers customerrepository.CustomerRepository, store storage.DocumentStore, outbox events.Outbox, recorder audit.Recorder, tx database.Transactor, cfg Config) KYCService {
+	if cfg.MaxDocumentSize <= 0 {
+		cfg.MaxDocumentSize = 10 << 20
+	}
+	if cfg.Validity <= 0 {
+		cfg.Validity = 365 * 24 * time.Hour
+	}
+
+	return &kycService{
+		repo:      repo,
+		customers: customers,
+		store:     store,
+		outbox:    outbox,
+		audit:     recorder,
+		tx:        tx,
+		cfg:       cfg,
+	}
+}
+
+// GetSummary returns whether a customer is verified together with their case history
+func (s *kycService) GetSummary(ctx context.Context, customerID uuid.UUID) (*models.Summary, error) {
+	if _, err := s.customers.GetByID(ctx, customerID); err != nil {
+		return nil, err
+	}
+
+	cases, err := s.repo.ListCases(ctx, customerID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.audit.RecordAccess(ctx, audit.ActionKYCView, []uuid.UUID{customerID}); err != nil {
+		return nil, err
+	}
+
+	summary := &models.Summary{CustomerID: customerID, Cases: cases}
+	if latest := latestDecided(cases); latest != nil && latest.IsValid(time.Now()) {
+		summary.Verified = true
+		summary.ExpiresAt = latest.ExpiresAt
+	}
+	return summary, nil
+}
+
+// OpenCase starts a new verification of a customer
+func (s *kycService) OpenCase(ctx context.Context, customerID uuid.UUID, actor string) (*models.Case, error) {
+	if actor == "" {
+		return nil, customermodels.NewValidationError("actor", "actor is required")
+	}
+
+	customer, err := s.customers.GetByID(ctx, customerID)
+	if err != nil {
+		return nil, err
+	}
+	if customer.Status == customermodels.CustomerStatusClosed {
+		return nil, fmt.Errorf("%w: customer is closed", models.ErrInvalidCaseTransition)
+	}
+
+	kycCase := &models.Case{
+		CustomerID: customerID,
+		Status:     models.CaseStatusPending,
+		OpenedBy:   actor,
+	}
+	err = s.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := s.repo.CreateCase(ctx, kycCase); err != nil {
+			return err
+		}
+		return s.recordTransition(ctx, audit.ActionKYCCaseOpen, kycCase, "", "", actor)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return kycCase, nil
+}
+
+// GetCase retrieves a case with its documents and decisions
+func (s *kycService) GetCase(ctx context.Context, id uuid.UUID) (*models.Case, error) {
+	kycCase, err := s.repo.GetCase(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.audit.RecordAccess(ctx, audit.ActionKYCView, []uuid.UUID{kycCase.CustomerID}); err != nil {
+		return nil, err
+	}
+	return kycCase, nil
+}
+
+// UploadDocument stores a document file and attaches it to an open case. The file
+// is written first and removed again if its metadata cannot be saved.
+func (s *kycService) UploadDocument(ctx context.Context, caseID uuid.UUID, req models.DocumentUploadRequest, fileName string, content io.Reader, actor string) (*models.Document, error) {
+	if err := validateUpload(req, time.Now()); err != nil {
+		return nil, err
+	}
+
+	kycCase, err := s.repo.GetCase(ctx, caseID)
+	if err != nil {
+		return nil, err
+	}
+	if !kycCase.IsOpen() {
+		return nil, fmt.Errorf("%w: documents cannot be added to a %s case", models.ErrInvalidCaseTransition, kycCase.Status)
+	}
+
+	buffered := bufio.NewReader(content)
+	head, _ := buffered.Peek(512)
+	if len(head) == 0 {
+		return nil, customermodels.NewValidationError("file", "must not be empty")
+	}
+	contentType := http.DetectContentType(head)
+	if !allowedContentTypes[contentType] {
+		return nil, fmt.Errorf("%w: %s", models.ErrUnsupportedContentType, contentType)
+	}
+
+	document := &models.Document{
+		ID:             uuid.New(),
+		CaseID:         kycCase.ID,
+		CustomerID:     kycCase.CustomerID,
+		Type:           req.Type,
+		FileName:       cleanFileName(fileName),
+		ContentType:    contentType,
+		IssuingCountry: strings.ToUpper(req.IssuingCountry),
+		ExpiresOn:      req.ExpiresOn,
+		UploadedBy:     actor,
+	}
+	document.StorageKey = fmt.Sprintf("%s/%s/%s", kycCase.CustomerID, kycCase.ID, document.ID)
+
+	// Read one byte past the limit, so an oversized file is detected rather than truncated
+	hash := sha256.New()
+	counter := &byteCounter{}
+	limited := io.TeeReader(io.LimitReader(buffered, s.cfg.MaxDocumentSize+1), io.MultiWriter(hash, counter))
+	if err := s.store.Put(ctx, document.StorageKey, limited); err != nil {
+		return nil, err
+	}
+	if counter.n > s.cfg.MaxDocumentSize {
+		s.discard(ctx, document.StorageKey)
+		return nil, fmt.Errorf("%w of %d bytes", models.ErrDocumentTooLarge, s.cfg.MaxDocumentSize)
+	}
+	document.Size = counter.n
+	document.SHA256 = hex.EncodeToString(hash.Sum(nil))
+
+	err = s.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := s.repo.CreateDocument(ctx, document); err != nil {
+			return err
+		}
+		changes := []audit.Change{
+			{Field: "document_id", Before: nil, After: document.ID},
+			{Field: "document_type", Before: nil, After: document.Type},
+			{Field: "sha256", Before: nil, After: document.SHA256},
+		}
+		return s.audit.Record(ctx, audit.ActionKYCDocumentUpload, document.CustomerID, changes)
+	})
+	if err != nil {
+		s.discard(ctx, document.StorageKey)
+		return nil, err
+	}
+	return document, nil
+}
+
+// OpenDocument returns the metadata and content of a document; the caller must close the content
+func (s *kycService) OpenDocument(ctx context.Context, caseID, documentID uuid.UUID) (*models.Document, io.ReadCloser, error) {
+	document, err := s.repo.GetDocument(ctx, caseID, documentID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	content, err := s.store.Open(ctx, document.StorageKey)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read KYC document %s: %w", document.ID, err)
+	}
+	if err := s.audit.RecordAccess(ctx, audit.ActionKYCDocumentView, []uuid.UUID{document.CustomerID}); err != nil {
+		content.Close()
+		return nil, nil, err
+	}
+	return document, content, nil
+}
+
+// SubmitCase hands a pending case to review once the required documents are present
+func (s *kycService) SubmitCase(ctx context.Context, caseID uuid.UUID, actor string) (*models.Case, error) {
+	kycCase, err := s.repo.GetCase(ctx, caseID)
+	if err != nil {
+		return nil, err
+	}
+	if kycCase.Status != models.CaseStatusPending {
+		return nil, fmt.Errorf("%w: %s to %s", models.ErrInvalidCaseTransition, kycCase.Status, models.CaseStatusInReview)
+	}
+	if missing := missingDocuments(kycCase.Documents); len(missing) > 0 {
+		return nil, fmt.Errorf("%w: %s", models.ErrMissingDocuments, strings.Join(missing, ", "))
+	}
+
+	now := time.Now()
+	kycCase.Status = models.CaseStatusInReview
+	kycCase.SubmittedAt = &now
+
+	err = s.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := s.repo.TransitionCase(ctx, kycCase, models.CaseStatusPending); err != nil {
+			return err
+		}
+		return s.recordTransition(ctx, audit.ActionKYCCaseSubmit, kycCase, models.CaseStatusPending, "", actor)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return kycCase, nil
+}
+
+// DecideCase records a reviewer's decision on a case in review. Approval verifies the
+// customer until the validity period ends or their identity document expires,
+// whichever comes first.
+func (s *kycService) DecideCase(ctx context.Context, caseID uuid.UUID, req models.DecisionRequest, actor string) (*models.Case, error) {
+	if actor == "" {
+		return nil, customermodels.NewValidationError("actor", "actor is required")
+	}
+	if req.Decision != models.DecisionApprove && req.Reason == "" {
+		return nil, customermodels.NewValidationError("reason", "is required unless the case is approved")
+	}
+
+	kycCase, err := s.repo.GetCase(ctx, caseID)
+	if err != nil {
+		return nil, err
+	}
+	if kycCase.Status != models.CaseStatusInReview {
+		return nil, fmt.Errorf("%w: only cases in review can be decided, case is %s", models.ErrInvalidCaseTransition, kycCase.Status)
+	}
+
+	now := time.Now()
+	switch req.Decision {
+	case models.DecisionApprove:
+		expiresAt := verificationExpiry(kycCase.Documents, now, s.cfg.Validity)
+		if expiresAt == nil {
+			return nil, fmt.Errorf("%w: every identity document has expired", models.ErrMissingDocuments)
+		}
+		kycCase.Status = models.CaseStatusVerified
+		kycCase.DecidedAt = &now
+		kycCase.ExpiresAt = expiresAt
+	case models.DecisionReject:
+		kycCase.Status = models.CaseStatusRejected
+		kycCase.DecidedAt = &now
+	case models.DecisionRequestChanges:
+		kycCase.Status = models.CaseStatusPending
+		kycCase.SubmittedAt = nil
+	default:
+		return nil, customermodels.NewValidationError("decision", "must be one of: approve reject request_changes")
+	}
+
+	decision := &models.Decision{
+		CaseID:   kycCase.ID,
+		Decision: req.Decision,
+		Reason:   req.Reason,
+		Reviewer: actor,
+	}
+	err = s.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := s.repo.TransitionCase(ctx, kycCase, models.CaseStatusInReview); err != nil {
+			return err
+		}
+		if err := s.repo.CreateDecision(ctx, decision); err != nil {
+			return err
+		}
+		return s.recordTransition(ctx, audit.ActionKYCDecision, kycCase, models.CaseStatusInReview, req.Reason, actor)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.repo.GetCase(ctx, caseID)
+}
+
+// IsVerified reports whether the most recent decision on a customer verified them
+// and that verification has not expired
+func (s *kycService) IsVerified(ctx context.Context, customerID uuid.UUID) (bool, error) {
+	latest, err := s.repo.LatestDecidedCase(ctx, customerID)
+	if err != nil {
+		if errors.Is(err, models.ErrCaseNotFound) {
+			return false, nil
+		}
+		return false, err
+	}
+	return latest.IsValid(time.Now()), nil
+}
+
+// ExpireVerifications moves up to limit verified cases whose validity ended before
+// now to expired, and returns how many were expired
+func (s *kycService) ExpireVerifications(ctx context.Context, now time.Time, limit int) (int, error) {
+	cases, err := s.repo.ListExpiredCases(ctx, now, limit)
+	if err != nil {
+		return 0, err
+	}
+
+	expired := 0
+	for i := range cases {
+		kycCase := &cases[i]
+		kycCase.Status = models.CaseStatusExpired
+
+		err := s.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+			if err := s.repo.TransitionCase(ctx, kycCase, models.CaseStatusVerified); err != nil {
+				return err
+			}
+			return s.recordTransition(ctx, audit.ActionKYCCaseExpire, kycCase, models.CaseStatusVerified, "verification validity ended", audit.SystemActor)
+		})
+		switch {
+		case err == nil:
+			expired++
+		case errors.Is(err, models.ErrInvalidCaseTransition):
+			// Changed concurrently; nothing left to expire
+		default:
+			return expired, err
+		}
+	}
+	return expired, nil
+}
+
+// recordTransition writes the audit entry and CustomerKYCStatusChanged event for a
+// case that moved from one status to another
+func (s *kycService) recordTransition(ctx context.Context, action string, kycCase *models.Case, from models.CaseStatus, reason, actor string) error {
+	changes := []audit.Change{{Field: "kyc_status", Before: from, After: kycCase.Status}}
+	if from == "" {
+		changes[0].Before = nil
+	}
+	if err := s.audit.Record(ctx, action, kycCase.CustomerID, changes); err != nil {
+		return err
+	}
+
+	event, err := events.NewEvent(events.CustomerKYCStatusChanged, kycCase.CustomerID, models.CustomerKYCStatusChangedPayload{
+		CaseID:     kycCase.ID,
+		FromStatus: from,
+		ToStatus:   kycCase.Status,
+		Reason:     reason,
+		Actor:      actor,
+		ExpiresAt:  kycCase.ExpiresAt,
+	})
+	if err != nil {
+		return err
+	}
+	return s.outbox.Append(ctx, event)
+}
+
+// discard removes an uploaded file whose metadata was not saved
+func (s *kycService) discard(ctx context.Context, key string) {
+	if err := s.store.Delete(context.WithoutCancel(ctx), key); err != nil {
+		log.Printf("Failed to remove orphaned KYC document %s: %v", key, err)
+	}
+}
+
+// validateUpload checks the fields the validate tags cannot express
+func validateUpload(req models.DocumentUploadRequest, now time.Time) error {
+	if !req.Type.IsIdentity() {
+		return nil
+	}
+	if req.ExpiresOn == nil {
+		return customermodels.NewValidationError("expires_on", "is required for identity documents")
+	}
+	if !req.ExpiresOn.After(now) {
+		return customermodels.NewValidationError("expires_on", "document has expired")
+	}
+	return nil
+}
+
+// missingDocuments lists the document requirements a case does not meet yet
+func missingDocuments(documents []models.Document) []string {
+	var identity, address bool
+	for _, document := range documents {
+		switch {
+		case document.Type.IsIdentity():
+			identity = true
+		case document.Type == models.DocumentTypeProofOfAddress:
+			address = true
+		}
+	}
+
+	var missing []string
+	if !identity {
+		missing = append(missing, "passport or national_id")
+	}
+	if !address {
+		missing = append(missing, string(models.DocumentTypeProofOfAddress))
+	}
+	return missing
+}
+
+// verificationExpiry returns when a verification granted now lapses: after the
+// validity period, or earlier when the longest valid identity document expires.
+// It returns nil when no identity document is valid any more.
+func verificationExpiry(documents []models.Document, now time.Time, validity time.Duration) *time.Time {
+	var documentExpiry *time.Time
+	for _, document := range documents {
+		if !document.Type.IsIdentity() || document.ExpiresOn == nil || !document.ExpiresOn.After(now) {
+			continue
+		}
+		if documentExpiry == nil || document.ExpiresOn.After(*documentExpiry) {
+			documentExpiry = document.ExpiresOn
+		}
+	}
+	if documentExpiry == nil {
+		return nil
+	}
+
+	expiresAt := now.Add(validity)
+	if documentExpiry.Before(expiresAt) {
+		expiresAt = *documentExpiry
+	}
+	return &expiresAt
+}
+
+// latestDecided returns the case decided most recently, or nil when none was decided
+func latestDecided(cases []models.Case) *models.Case {
+	var latest *models.Case
+	for i := range cases {
+		if cases[i].DecidedAt == nil {
+			continue
+		}
+		if latest == nil || cases[i].DecidedAt.After(*latest.DecidedAt) {
+			latest = &cases[i]
+		}
+	}
+	return latest
+}
+
+// cleanFileName keeps the base name of an uploaded file for display
+func cleanFileName(name string) string {
+	name = filepath.Base(strings.ReplaceAll(name, "\\", "/"))
+	if name == "." || name == "/" || name == "" {
+		return "document"
+	}
+	for len(name) > 255 {
+		_, size := utf8.DecodeLastRuneInString(name)
+		name = name[:len(name)-size]
+	}
+	return name
+}
+
+// byteCounter counts the bytes written to it
+type byteCounter struct {
+	n int64
+}
+
+// Write implements io.Writer
+func (c *byteCounter) Write(p []byte) (int, error) {
+	c.n += int64(len(p))
+	return len(p), nil
+}
@@ -0,0 +1,201 @@
+package controllers
+
+import (
+	customermodels "customer-service/internal/customer/models"
+	"customer-service/internal/privacy/models"
+	"customer-service/internal/privacy/service"
+	"customer-service/pkg/apierror"
+	"customer-service/pkg/middleware"
+	"errors"
+	"log"
+	"mime"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// PrivacyController handles HTTP requests for data subject requests
+type PrivacyController struct {
+	service service.PrivacyService
+}
+
+// NewPrivacyController creates a new privacy controller instance
+func NewPrivacyController(privacyService service.PrivacyService) *PrivacyController {
+	return &PrivacyController{
+		service: privacyService,
+	}
+}
+
+// CreateRequest handles POST /customers/:id/data-requests
+func (ctrl *PrivacyController) CreateRequest(c *gin.Context) {
+	id, ok := ctrl.parseUUID(c, "id")
+	if !ok {
+		return
+	}
+
+	var req models.CreateRequest
+	if !ctrl.bindJSON(c, &req) {
+		return
+	}
+
+	request, err := ctrl.service.CreateRequest(c.Request.Context(), id, req, actorFromContext(c))
+	if err != nil {
+		ctrl.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, request)
+}
+
+// ListRequests handles GET /customers/:id/data-requests
+func (ctrl *PrivacyController) ListRequests(c *gin.Context) {
+	id, ok := ctrl.parseUUID(c, "id")
+	if !ok {
+		return
+	}
+
+	requests, err := ctrl.service.ListRequests(c.Request.Context(), id)
+	if err != nil {
+		ctrl.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, requests)
+}
+
+// GetRequest handles GET /data-requests/:requestId
+func (ctrl *PrivacyController) GetRequest(c *gin.Context) {
+	id, ok := ctrl.parseUUID(c, "requestId")
+	if !ok {
+		return
+	}
+
+	request, err := ctrl.service.GetRequest(c.Request.Context(), id)
+	if err != nil {
+		ctrl.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, request)
+}
+
+// ProcessRequest handles POST /data-requests/:requestId/process
+func (ctrl *PrivacyController) ProcessRequest(c *gin.Context) {
+	id, ok := ctrl.parseUUID(c, "requestId")
+	if !ok {
+		return
+	}
+
+	request, err := ctrl.service.ProcessRequest(c.Request.Context(), id, actorFromContext(c))
+	if err != nil {
+		ctrl.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, request)
+}
+
+// RejectRequest handles POST /data-requests/:requestId/reject
+func (ctrl *PrivacyController) RejectRequest(c *gin.Context) {
+	id, ok := ctrl.parseUUID(c, "requestId")
+	if !ok {
+		return
+	}
+
+	var req models.RejectRequest
+	if !ctrl.bindJSON(c, &req) {
+		return
+	}
+
+	request, err := ctrl.service.RejectRequest(c.Request.Context(), id, req, actorFromContext(c))
+	if err != nil {
+		ctrl.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, request)
+}
+
+// DownloadExport handles GET /data-requests/:requestId/export
+func (ctrl *PrivacyController) DownloadExport(c *gin.Context) {
+	id, ok := ctrl.parseUUID(c, "requestId")
+	if !ok {
+		return
+	}
+
+	request, content, err := ctrl.service.OpenExport(c.Request.Context(), id)
+	if err != nil {
+		ctrl.handleError(c, err)
+		return
+	}
+	defer content.Close()
+
+	fileName := "customer-" + request.CustomerID.String() + "-export.zip"
+	c.DataFromReader(http.StatusOK, request.ExportSize, "application/zip", content, map[string]string{
+		"Content-Disposition":    mime.FormatMediaType("attachment", map[string]string{"filename": fileName}),
+		"Cache-Control":          "no-store",
+		"X-Content-Type-Options": "nosniff",
+	})
+}
+
+// bindJSON decodes and validates the request body, writing an error response on failure
+func (ctrl *PrivacyController) bindJSON(c *gin.Context, req any) bool {
+	if err := c.ShouldBindJSON(req); err != nil {
+		apierror.Abort(c, http.StatusBadRequest, apierror.CodeInvalidRequest, "invalid request body: "+err.Error())
+		return false
+	}
+
+	if err := customermodels.ValidateStruct(req); err != nil {
+		ctrl.handleError(c, err)
+		return false
+	}
+
+	return true
+}
+
+// parseUUID parses a UUID path parameter, writing an error response on failure
+func (ctrl *PrivacyController) parseUUID(c *gin.Context, param string) (uuid.UUID, bool) {
+	id, err := uuid.Parse(c.Param(param))
+	if err != nil {
+		apierror.Abort(c, http.StatusBadRequest, apierror.CodeInvalidRequest, "invalid "+param,
+			apierror.FieldError{Field: param, Message: "must be a valid UUID"})
+		return uuid.Nil, false
+	}
+	return id, true
+}
+
+// handleError maps service errors to HTTP responses
+func (ctrl *PrivacyController) handleError(c *gin.Context, err error) {
+	var validationErr *customermodels.ValidationError
+	switch {
+	case errors.As(err, &validationErr):
+		fields := make([]apierror.FieldError, len(validationErr.Fields))
+		for i, f := range validationErr.Fields {
+			fields[i] = apierror.FieldError{Field: f.Field, Message: f.Message}
+		}
+		apierror.Abort(c, http.StatusBadRequest, apierror.CodeValidationFailed, "request validation failed", fields...)
+	case errors.Is(err, customermodels.ErrCustomerNotFound),
+		errors.Is(err, models.ErrRequestNotFound),
+		errors.Is(err, models.ErrExportUnavailable):
+		apierror.Abort(c, http.StatusNotFound, apierror.CodeNotFound, err.Error())
+	case errors.Is(err, models.ErrRequestAlreadyOpen),
+		errors.Is(err, models.ErrCustomerNotClosed),
+		errors.Is(err, models.ErrCustomerErased):
+		apierror.Abort(c, http.StatusConflict, apierror.CodeConflict, err.Error())
+	case errors.Is(err, models.ErrInvalidRequestTransition):
+		apierror.Abort(c, http.StatusConflict, apierror.CodeInvalidTransition, err.Error())
+	default:
+		log.Printf("Unhandled error processing %s %s: %v", c.Request.Method, c.Request.URL.Path, err)
+		apierror.Abort(c, http.StatusInternalServerError, apierror.CodeInternal, "internal server error")
+	}
+}
+
+// actorFromContext returns the subject of the authenticated caller
+func actorFromContext(c *gin.Context) string {
+	claims, ok := middleware.GetClaims(c)
+	if !ok {
+		return ""
+	}
+	return claims.Subject
+}
@@ -0,0 +1,16 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// CustomerErasedPayload is the payload of a CustomerErased event, emitted when a
+// customer's personal data was erased on request. Consumers are expected to erase
+// their own copies; the payloads of earlier events about the customer are redacted.
+type CustomerErasedPayload struct {
+	RequestID uuid.UUID   `json:"request_id"`
+	MergedIDs []uuid.UUID `json:"merged_ids,omitempty"`
+	ErasedAt  time.Time   `json:"erased_at"`
+}
@@ -0,0 +1,108 @@
+package models
+
+import (
+	"customer-service/internal/audit"
+	consentmodels "customer-service/internal/consent/models"
+	customermodels "customer-service/internal/customer/models"
+	kycmodels "customer-service/internal/kyc/models"
+	relationshipmodels "customer-service/internal/relationship/models"
+	riskmodels "customer-service/internal/risk/models"
+	screeningmodels "customer-service/internal/screening/models"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ExportFileName is the name of the JSON document at the root of an export archive
+const ExportFileName = "export.json"
+
+// ExportedCustomer is a customer record as held, including when it was deleted
+type ExportedCustomer struct {
+	customermodels.CustomerResponse
+	DeletedAt *time.Time `json:"deleted_at,omitempty"`
+}
+
+// NewExportedCustomer converts a customer, which may be deleted, for an export
+func NewExportedCustomer(customer *customermodels.Customer) ExportedCustomer {
+	exported := ExportedCustomer{CustomerResponse: customer.ToResponse()}
+	if customer.DeletedAt.Valid {
+		exported.DeletedAt = &customer.DeletedAt.Time
+	}
+	return exported
+}
+
+// Ownership is an organization the customer is declared to own or control
+type Ownership struct {
+	OrganizationID      uuid.UUID                `json:"organization_id"`
+	Role                customermodels.OwnerRole `json:"role"`
+	OwnershipPercentage float64                  `json:"ownership_percentage"`
+	DeclaredAt          time.Time                `json:"declared_at"`
+}
+
+// ExportedDocument is a KYC document file included in an export archive under Path
+type ExportedDocument struct {
+	DocumentID  uuid.UUID              `json:"document_id"`
+	CaseID      uuid.UUID              `json:"case_id"`
+	Type        kycmodels.DocumentType `json:"type"`
+	FileName    string                 `json:"file_name"`
+	ContentType string                 `json:"content_type"`
+	Size        int64                  `json:"size"`
+	SHA256      string                 `json:"sha256"`
+	Path        string                 `json:"path"`
+
+	// StorageKey is where the file is kept in the document store
+	StorageKey string `json:"-"`
+}
+
+// Export is the machine-readable copy of everything held on a customer. It is written
+// as export.json at the root of the export archive, next to the document files it
+// lists under Documents. MergedRecords are duplicate records merged into the customer.
+type Export struct {
+	RequestID       uuid.UUID                              `json:"request_id"`
+	GeneratedAt     time.Time                              `json:"generated_at"`
+	Customer        ExportedCustomer                       `json:"customer"`
+	MergedRecords   []ExportedCustomer                     `json:"merged_records"`
+	StatusHistory   []customermodels.CustomerStatusHistory `json:"status_history"`
+	Addresses       []customermodels.CustomerAddress       `json:"addresses"`
+	ContactPoints   []customermodels.ContactPoint          `json:"contact_points"`
+	Ownerships      []Ownership                            `json:"ownerships"`
+	Relationships   []relationshipmodels.Relationship      `json:"relationships"`
+	Consents        []consentmodels.Consent                `json:"consents"`
+	ConsentRecords  []consentmodels.Record                 `json:"consent_records"`
+	KYCCases        []kycmodels.Case                       `json:"kyc_cases"`
+	Documents       []ExportedDocument                     `json:"documents"`
+	ScreeningAlerts []screeningmodels.Alert                `json:"screening_alerts"`
+	RiskAssessments []riskmodels.Assessment                `json:"risk_assessments"`
+	AuditTrail      []audit.Entry                          `json:"audit_trail"`
+	DataRequests    []Request                              `json:"data_requests"`
+}
+
+// Evidence returns the number of records exported in each category
+func (e *Export) Evidence() []EvidenceItem {
+	counts := []struct {
+		category string
+		records  int
+	}{
+		{"customer", 1},
+		{"merged_records", len(e.MergedRecords)},
+		{"status_history", len(e.StatusHistory)},
+		{"addresses", len(e.Addresses)},
+		{"contact_points", len(e.ContactPoints)},
+		{"ownerships", len(e.Ownerships)},
+		{"relationships", len(e.Relationships)},
+		{"consents", len(e.Consents)},
+		{"consent_records", len(e.ConsentRecords)},
+		{"kyc_cases", len(e.KYCCases)},
+		{"kyc_documents", len(e.Documents)},
+		{"screening_alerts", len(e.ScreeningAlerts)},
+		{"risk_assessments", len(e.RiskAssessments)},
+		{"audit_trail", len(e.AuditTrail)},
+		{"data_requests", len(e.DataRequests)},
+	}
+
+	items := make([]EvidenceItem, len(counts))
+	for i, c := range counts {
+		items[i] = EvidenceItem{Category: c.category, Action: EvidenceExported, Records: int64(c.records)}
+	}
+	return items
+}
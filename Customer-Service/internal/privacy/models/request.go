@@ -0,0 +1,113 @@
+package models
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Domain errors returned by the privacy repository and service layers
+var (
+	ErrRequestNotFound          = errors.New("data subject request not found")
+	ErrRequestAlreadyOpen       = errors.New("customer already has an open request of this type")
+	ErrInvalidRequestTransition = errors.New("invalid data subject request transition")
+	ErrCustomerNotClosed        = errors.New("customer must be closed before their data can be erased")
+	ErrCustomerErased           = errors.New("customer has already been erased")
+	ErrExportUnavailable        = errors.New("export is not available")
+)
+
+// RequestType is what a data subject asked for
+type RequestType string
+
+const (
+	// RequestTypeAccess is a request for a copy of everything held on the customer
+	RequestTypeAccess RequestType = "access"
+	// RequestTypeErasure is a request to erase the customer's personal data
+	RequestTypeErasure RequestType = "erasure"
+)
+
+// RequestStatus represents the progress of a data subject request
+type RequestStatus string
+
+const (
+	RequestStatusReceived   RequestStatus = "received"
+	RequestStatusInProgress RequestStatus = "in_progress"
+	RequestStatusCompleted  RequestStatus = "completed"
+	RequestStatusRejected   RequestStatus = "rejected"
+	// RequestStatusFailed is a request whose processing failed; it may be processed again
+	RequestStatusFailed RequestStatus = "failed"
+)
+
+// EvidenceAction is what processing a request did with one category of records
+type EvidenceAction string
+
+const (
+	EvidenceExported   EvidenceAction = "exported"
+	EvidenceAnonymized EvidenceAction = "anonymized"
+	EvidenceRedacted   EvidenceAction = "redacted"
+	EvidenceDeleted    EvidenceAction = "deleted"
+	EvidenceRetained   EvidenceAction = "retained"
+)
+
+// EvidenceItem records how many records of a category a completed request exported,
+// anonymized, redacted, deleted or retained, and why records were retained
+type EvidenceItem struct {
+	Category string         `json:"category"`
+	Action   EvidenceAction `json:"action"`
+	Records  int64          `json:"records"`
+	Reason   string         `json:"reason,omitempty"`
+}
+
+// Request is a data subject request made by or on behalf of a customer. A customer
+// has at most one open (received, in progress or failed) request of each type.
+type Request struct {
+	ID              uuid.UUID      `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	CustomerID      uuid.UUID      `json:"customer_id" gorm:"type:uuid;not null;index"`
+	Type            RequestType    `json:"type" gorm:"not null;size:20"`
+	Status          RequestStatus  `json:"status" gorm:"not null;size:20"`
+	Note            string         `json:"note,omitempty" gorm:"size:500"`
+	RequestedBy     string         `json:"requested_by" gorm:"not null;size:255"`
+	DueAt           time.Time      `json:"due_at" gorm:"not null"`
+	ProcessedBy     string         `json:"processed_by,omitempty" gorm:"size:255"`
+	ClosedAt        *time.Time     `json:"closed_at,omitempty"`
+	RejectionReason string         `json:"rejection_reason,omitempty" gorm:"size:500"`
+	LastError       string         `json:"last_error,omitempty" gorm:"type:text"`
+	Evidence        []EvidenceItem `json:"evidence,omitempty" gorm:"type:jsonb;serializer:json"`
+	CreatedAt       time.Time      `json:"created_at"`
+	UpdatedAt       time.Time      `json:"updated_at"`
+
+	// ExportKey is where the export archive of a completed access request is kept
+	// in the export store, until the customer is erased
+	ExportKey    string `json:"-" gorm:"size:500"`
+	ExportSHA256 string `json:"export_sha256,omitempty" gorm:"column:export_sha256;size:64"`
+	ExportSize   int64  `json:"export_size,omitempty"`
+}
+
+// TableName returns the table name for Request model
+func (Request) TableName() string {
+	return "data_subject_requests"
+}
+
+// IsOpen reports whether the request still has to be processed or rejected
+func (r *Request) IsOpen() bool {
+	return r.Status == RequestStatusReceived || r.Status == RequestStatusInProgress || r.Status == RequestStatusFailed
+}
+
+// CreateRequest represents the request payload for logging a data subject request
+type CreateRequest struct {
+	Type RequestType `json:"type" validate:"required,oneof=access erasure"`
+	Note string      `json:"note" validate:"max=500"`
+}
+
+// RejectRequest represents the request payload for rejecting a data subject request,
+// for example because the requester's identity could not be confirmed
+type RejectRequest struct {
+	Reason string `json:"reason" validate:"required,max=500"`
+}
+
+// ListResponse lists the data subject requests of a customer, newest first
+type ListResponse struct {
+	CustomerID uuid.UUID `json:"customer_id"`
+	Requests   []Request `json:"requests"`
+}
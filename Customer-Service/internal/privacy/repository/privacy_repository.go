@@ -0,0 +1,424 @@
+package repository
+
+import (
+	"context"
+	"customer-service/internal/audit"
+	consentmodels "customer-service/internal/consent/models"
+	customermodels "customer-service/internal/customer/models"
+	"customer-service/internal/database"
+	kycmodels "customer-service/internal/kyc/models"
+	"customer-service/internal/privacy/models"
+	relationshipmodels "customer-service/internal/relationship/models"
+	riskmodels "customer-service/internal/risk/models"
+	screeningmodels "customer-service/internal/screening/models"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// PrivacyRepository defines the interface for data subject request data access. It
+// reads and erases the records of every other domain held on a customer.
+type PrivacyRepository interface {
+	CreateRequest(ctx context.Context, request *models.Request) error
+	GetRequest(ctx context.Context, id uuid.UUID) (*models.Request, error)
+	ListRequests(ctx context.Context, customerID uuid.UUID) ([]models.Request, error)
+	TransitionRequest(ctx context.Context, request *models.Request, from models.RequestStatus) error
+
+	GetCustomer(ctx context.Context, id uuid.UUID) (*customermodels.Customer, error)
+	LockCustomer(ctx context.Context, id uuid.UUID) (*customermodels.Customer, error)
+	ListMergedIDs(ctx context.Context, survivorID uuid.UUID) ([]uuid.UUID, error)
+	Collect(ctx context.Context, customer *customermodels.Customer) (*models.Export, error)
+	ListExportKeys(ctx context.Context, customerID uuid.UUID) ([]string, error)
+	Erase(ctx context.Context, customerID uuid.UUID, mergedIDs []uuid.UUID, at time.Time) ([]models.EvidenceItem, error)
+}
+
+type privacyRepository struct {
+	db *gorm.DB
+}
+
+// NewPrivacyRepository creates a new privacy repository instance
+func NewPrivacyRepository(db *gorm.DB) PrivacyRepository {
+	return &privacyRepository{db: db}
+}
+
+// Reasons recorded for the records an erasure keeps
+const (
+	retainedForAML   = "kept for the anti-money-laundering record-keeping period"
+	retainedForAudit = "append-only, tamper-evident audit trail, which records that personal data changed but never its values"
+	retainedConsent  = "proof of the consent given and withdrawn, without notes"
+	retainedRequests = "evidence of the data subject requests handled"
+)
+
+// redactedPayload replaces the payload of events about an erased customer
+const redactedPayload = `{"redacted": true}`
+
+// CreateRequest logs a new request. It returns ErrRequestAlreadyOpen when the
+// customer already has an open request of the same type, which a partial unique
+// index enforces.
+func (r *privacyRepository) CreateRequest(ctx context.Context, request *models.Request) error {
+	result := database.Conn(ctx, r.db).
+		Clauses(clause.OnConflict{DoNothing: true}).
+		Create(request)
+	if result.Error != nil {
+		return fmt.Errorf("failed to create data subject request: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return models.ErrRequestAlreadyOpen
+	}
+	return nil
+}
+
+// GetRequest retrieves a request by ID
+func (r *privacyRepository) GetRequest(ctx context.Context, id uuid.UUID) (*models.Request, error) {
+	var request models.Request
+	if err := database.Conn(ctx, r.db).Where("id = ?", id).First(&request).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, models.ErrRequestNotFound
+		}
+		return nil, fmt.Errorf("failed to get data subject request: %w", err)
+	}
+	return &request, nil
+}
+
+// ListRequests returns every request of a customer, newest first
+func (r *privacyRepository) ListRequests(ctx context.Context, customerID uuid.UUID) ([]models.Request, error) {
+	var requests []models.Request
+	err := database.Conn(ctx, r.db).
+		Where("customer_id = ?", customerID).
+		Order("created_at DESC").
+		Find(&requests).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list data subject requests: %w", err)
+	}
+	return requests, nil
+}
+
+// TransitionRequest stores the new state of a request if it still has the status from
+func (r *privacyRepository) TransitionRequest(ctx context.Context, request *models.Request, from models.RequestStatus) error {
+	// Map updates bypass the json serializer of the evidence column
+	var evidence interface{}
+	if len(request.Evidence) > 0 {
+		data, err := json.Marshal(request.Evidence)
+		if err != nil {
+			return fmt.Errorf("failed to encode request evidence: %w", err)
+		}
+		evidence = string(data)
+	}
+
+	request.UpdatedAt = time.Now()
+	result := database.Conn(ctx, r.db).Model(&models.Request{}).
+		Where("id = ? AND status = ?", request.ID, from).
+		Updates(map[string]interface{}{
+			"status":           request.Status,
+			"processed_by":     request.ProcessedBy,
+			"closed_at":        request.ClosedAt,
+			"rejection_reason": request.RejectionReason,
+			"last_error":       request.LastError,
+			"evidence":         evidence,
+			"export_key":       request.ExportKey,
+			"export_sha256":    request.ExportSHA256,
+			"export_size":      request.ExportSize,
+			"updated_at":       request.UpdatedAt,
+		})
+	if result.Error != nil {
+		return fmt.Errorf("failed to update data subject request: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("%w: request is no longer %s", models.ErrInvalidRequestTransition, from)
+	}
+	return nil
+}
+
+// GetCustomer retrieves a customer by ID, including deleted and merged customers,
+// whose data is still held
+func (r *privacyRepository) GetCustomer(ctx context.Context, id uuid.UUID) (*customermodels.Customer, error) {
+	var customer customermodels.Customer
+	if err := database.Conn(ctx, r.db).Unscoped().Where("id = ?", id).First(&customer).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, customermodels.ErrCustomerNotFound
+		}
+		return nil, fmt.Errorf("failed to get customer: %w", err)
+	}
+	return &customer, nil
+}
+
+// LockCustomer retrieves a customer like GetCustomer and locks its row until the
+// transaction in ctx ends
+func (r *privacyRepository) LockCustomer(ctx context.Context, id uuid.UUID) (*customermodels.Customer, error) {
+	var customer customermodels.Customer
+	err := database.Conn(ctx, r.db).Unscoped().
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id = ?", id).
+		First(&customer).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, customermodels.ErrCustomerNotFound
+		}
+		return nil, fmt.Errorf("failed to lock customer: %w", err)
+	}
+	return &customer, nil
+}
+
+// ListMergedIDs returns the IDs of the duplicate records merged into a customer
+func (r *privacyRepository) ListMergedIDs(ctx context.Context, survivorID uuid.UUID) ([]uuid.UUID, error) {
+	var ids []uuid.UUID
+	err := database.Conn(ctx, r.db).Model(&customermodels.CustomerMerge{}).
+		Where("survivor_id = ?", survivorID).
+		Order("merged_id").
+		Pluck("merged_id", &ids).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list merged customers: %w", err)
+	}
+	return ids, nil
+}
+
+// Collect reads every record held on a customer. Documents lists the files of the
+// customer's KYC documents without reading them.
+func (r *privacyRepository) Collect(ctx context.Context, customer *customermodels.Customer) (*models.Export, error) {
+	db := database.Conn(ctx, r.db)
+	id := customer.ID
+	export := &models.Export{Customer: models.NewExportedCustomer(customer)}
+
+	mergedIDs, err := r.ListMergedIDs(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	var merged []customermodels.Customer
+	if len(mergedIDs) > 0 {
+		if err := db.Unscoped().Where("id IN ?", mergedIDs).Order("id").Find(&merged).Error; err != nil {
+			return nil, fmt.Errorf("failed to read merged customers: %w", err)
+		}
+	}
+	export.MergedRecords = make([]models.ExportedCustomer, len(merged))
+	for i := range merged {
+		export.MergedRecords[i] = models.NewExportedCustomer(&merged[i])
+	}
+
+	var owners []customermodels.BeneficialOwner
+	if err := db.Where("owner_id = ?", id).Order("created_at").Find(&owners).Error; err != nil {
+		return nil, fmt.Errorf("failed to read beneficial ownerships: %w", err)
+	}
+	export.Ownerships = make([]models.Ownership, len(owners))
+	for i, owner := range owners {
+		export.Ownerships[i] = models.Ownership{
+			OrganizationID:      owner.OrganizationID,
+			Role:                owner.Role,
+			OwnershipPercentage: owner.OwnershipPercentage,
+			DeclaredAt:          owner.CreatedAt,
+		}
+	}
+
+	queries := []struct {
+		what  string
+		dest  any
+		query *gorm.DB
+	}{
+		{"status history", &export.StatusHistory, db.Where("customer_id = ?", id).Order("created_at")},
+		{"addresses", &export.Addresses, db.Where("customer_id = ?", id).Order("created_at")},
+		{"contact points", &export.ContactPoints, db.Where("customer_id = ?", id).Order("created_at")},
+		{"relationships", &export.Relationships, db.Where("from_customer_id = ? OR to_customer_id = ?", id, id).Order("created_at")},
+		{"consents", &export.Consents, db.Where("customer_id = ?", id).Order("purpose, channel")},
+		{"consent records", &export.ConsentRecords, db.Where("customer_id = ?", id).Order("effective_at, created_at")},
+		{"KYC cases", &export.KYCCases, db.Where("customer_id = ?", id).Order("created_at").
+			Preload("Documents", func(db *gorm.DB) *gorm.DB { return db.Order("created_at") }).
+			Preload("Decisions", func(db *gorm.DB) *gorm.DB { return db.Order("created_at") })},
+		{"screening alerts", &export.ScreeningAlerts, db.Where("customer_id = ?", id).Order("created_at")},
+		{"risk assessments", &export.RiskAssessments, db.Where("customer_id = ?", id).Order("created_at")},
+		{"audit trail", &export.AuditTrail, db.Where("customer_id = ?", id).Order("sequence")},
+		{"data subject requests", &export.DataRequests, db.Where("customer_id = ?", id).Order("created_at")},
+	}
+	for _, q := range queries {
+		if err := q.query.Find(q.dest).Error; err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", q.what, err)
+		}
+	}
+
+	// The value of a contact point is stored in the column of its kind
+	for i := range export.ContactPoints {
+		point := &export.ContactPoints[i]
+		if point.Kind == customermodels.ContactKindPhone {
+			point.Value = point.Phone
+		} else {
+			point.Value = point.Email
+		}
+	}
+
+	for _, kycCase := range export.KYCCases {
+		for _, document := range kycCase.Documents {
+			export.Documents = append(export.Documents, models.ExportedDocument{
+				DocumentID:  document.ID,
+				CaseID:      document.CaseID,
+				Type:        document.Type,
+				FileName:    document.FileName,
+				ContentType: document.ContentType,
+				Size:        document.Size,
+				SHA256:      document.SHA256,
+				Path:        fmt.Sprintf("documents/%s/%s", document.ID, document.FileName),
+				StorageKey:  document.StorageKey,
+			})
+		}
+	}
+	return export, nil
+}
+
+// ListExportKeys returns where the export archives of a customer's access requests
+// are kept
+func (r *privacyRepository) ListExportKeys(ctx context.Context, customerID uuid.UUID) ([]string, error) {
+	var keys []string
+	err := database.Conn(ctx, r.db).Model(&models.Request{}).
+		Where("customer_id = ? AND export_key <> ''", customerID).
+		Pluck("export_key", &keys).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list exports: %w", err)
+	}
+	return keys, nil
+}
+
+// Erase anonymizes a customer and the records merged into it in place: their PII is
+// cleared, the rows are soft deleted and marked erased, their addresses and contact
+// points are deleted, free-text notes about them are cleared, the payloads of their
+// events are redacted and the responses stored for idempotent requests about them
+// are deleted. Records kept for legal retention are counted but left as they are.
+// Callers run it in a transaction, holding the lock from LockCustomer.
+func (r *privacyRepository) Erase(ctx context.Context, customerID uuid.UUID, mergedIDs []uuid.UUID, at time.Time) ([]models.EvidenceItem, error) {
+	db := database.Conn(ctx, r.db)
+	ids := append([]uuid.UUID{customerID}, mergedIDs...)
+
+	var items []models.EvidenceItem
+	step := func(category string, action models.EvidenceAction, result *gorm.DB) error {
+		if result.Error != nil {
+			return fmt.Errorf("failed to erase %s: %w", category, result.Error)
+		}
+		items = append(items, models.EvidenceItem{Category: category, Action: action, Records: result.RowsAffected})
+		return nil
+	}
+
+	anonymized := map[string]interface{}{
+		"first_name":           "",
+		"last_name":            "",
+		"email":                "",
+		"phone":                "",
+		"date_of_birth":        nil,
+		"address_street":       nil,
+		"address_city":         nil,
+		"address_state":        nil,
+		"address_postal_code":  nil,
+		"address_country":      nil,
+		"occupation":           "",
+		"email_bidx":           nil,
+		"phone_bidx":           nil,
+		"address_country_bidx": nil,
+		"address_city_bidx":    nil,
+		"address_state_bidx":   nil,
+		"birth_year_bidx":      nil,
+		"birth_month_bidx":     nil,
+		"birth_date_bidx":      nil,
+		"phone_suffix_bidx":    nil,
+		"erased_at":            at,
+		"deleted_at":           gorm.Expr("COALESCE(deleted_at, ?)", at),
+		"updated_at":           at,
+		"version":              gorm.Expr("version + 1"),
+	}
+	// UpdateColumns writes the map as it is, without running the encryption serializer
+	if err := step("customer", models.EvidenceAnonymized,
+		db.Unscoped().Model(&customermodels.Customer{}).Where("id = ?", customerID).UpdateColumns(anonymized)); err != nil {
+		return nil, err
+	}
+	if len(mergedIDs) > 0 {
+		if err := step("merged_records", models.EvidenceAnonymized,
+			db.Unscoped().Model(&customermodels.Customer{}).Where("id IN ?", mergedIDs).UpdateColumns(anonymized)); err != nil {
+			return nil, err
+		}
+	}
+
+	steps := []struct {
+		category string
+		action   models.EvidenceAction
+		result   func() *gorm.DB
+	}{
+		{"addresses", models.EvidenceDeleted, func() *gorm.DB {
+			return db.Where("customer_id IN ?", ids).Delete(&customermodels.CustomerAddress{})
+		}},
+		{"contact_points", models.EvidenceDeleted, func() *gorm.DB {
+			return db.Where("customer_id IN ?", ids).Delete(&customermodels.ContactPoint{})
+		}},
+		{"status_history_notes", models.EvidenceRedacted, func() *gorm.DB {
+			return db.Model(&customermodels.CustomerStatusHistory{}).
+				Where("customer_id IN ? AND note <> ''", ids).UpdateColumn("note", "")
+		}},
+		{"relationship_notes", models.EvidenceRedacted, func() *gorm.DB {
+			return db.Model(&relationshipmodels.Relationship{}).
+				Where("(from_customer_id IN ? OR to_customer_id IN ?) AND note <> ''", ids, ids).UpdateColumn("note", "")
+		}},
+		{"merge_reasons", models.EvidenceRedacted, func() *gorm.DB {
+			return db.Model(&customermodels.CustomerMerge{}).
+				Where("(merged_id IN ? OR survivor_id IN ?) AND reason <> ''", ids, ids).UpdateColumn("reason", "")
+		}},
+		{"consent_notes", models.EvidenceRedacted, func() *gorm.DB {
+			return db.Model(&consentmodels.Record{}).
+				Where("customer_id IN ? AND note <> ''", ids).UpdateColumn("note", "")
+		}},
+		{"webhook_deliveries", models.EvidenceRedacted, func() *gorm.DB {
+			return db.Exec(`UPDATE webhook_deliveries SET payload = jsonb_set(payload, '{payload}', ?::jsonb)
+				WHERE event_id IN (SELECT id FROM outbox_events WHERE aggregate_id IN ?)`, redactedPayload, ids)
+		}},
+		{"events", models.EvidenceRedacted, func() *gorm.DB {
+			return db.Exec("UPDATE outbox_events SET payload = ?::jsonb WHERE aggregate_id IN ?", redactedPayload, ids)
+		}},
+		{"idempotent_responses", models.EvidenceDeleted, func() *gorm.DB {
+			return db.Exec("DELETE FROM idempotency_keys WHERE resource_id IN ?", resourceIDs(ids))
+		}},
+		{"exports", models.EvidenceDeleted, func() *gorm.DB {
+			return db.Model(&models.Request{}).
+				Where("customer_id = ? AND export_key <> ''", customerID).
+				UpdateColumns(map[string]interface{}{"export_key": "", "updated_at": at})
+		}},
+	}
+	for _, s := range steps {
+		if err := step(s.category, s.action, s.result()); err != nil {
+			return nil, err
+		}
+	}
+
+	retained := []struct {
+		category string
+		reason   string
+		model    any
+		where    string
+	}{
+		{"kyc_cases", retainedForAML, &kycmodels.Case{}, "customer_id IN ?"},
+		{"kyc_documents", retainedForAML, &kycmodels.Document{}, "customer_id IN ?"},
+		{"screening_alerts", retainedForAML, &screeningmodels.Alert{}, "customer_id IN ?"},
+		{"risk_assessments", retainedForAML, &riskmodels.Assessment{}, "customer_id IN ?"},
+		{"consents", retainedConsent, &consentmodels.Consent{}, "customer_id IN ?"},
+		{"audit_trail", retainedForAudit, &audit.Entry{}, "customer_id IN ?"},
+		{"data_requests", retainedRequests, &models.Request{}, "customer_id IN ?"},
+	}
+	for _, keep := range retained {
+		var count int64
+		if err := db.Model(keep.model).Where(keep.where, ids).Count(&count).Error; err != nil {
+			return nil, fmt.Errorf("failed to count retained %s: %w", keep.category, err)
+		}
+		items = append(items, models.EvidenceItem{
+			Category: keep.category,
+			Action:   models.EvidenceRetained,
+			Records:  count,
+			Reason:   keep.reason,
+		})
+	}
+	return items, nil
+}
+
+// resourceIDs returns the IDs as idempotency keys record the resource of a request
+func resourceIDs(ids []uuid.UUID) []string {
+	values := make([]string, len(ids))
+	for i, id := range ids {
+		values[i] = id.String()
+	}
+	return values
+}
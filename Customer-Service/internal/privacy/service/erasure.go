@@ -0,0 +1,79 @@
+package service
+
+import (
+	"context"
+	"customer-service/internal/audit"
+	customermodels "customer-service/internal/customer/models"
+	"customer-service/internal/events"
+	"customer-service/internal/privacy/models"
+	"time"
+)
+
+// erase answers an erasure request. The export archives of earlier access requests
+// are deleted first; then, in one transaction, the customer and the records merged
+// into it are anonymized, the request is completed with the evidence of what was
+// erased and retained, and a CustomerErased event is emitted.
+func (s *privacyService) erase(ctx context.Context, request *models.Request) error {
+	keys, err := s.repo.ListExportKeys(ctx, request.CustomerID)
+	if err != nil {
+		return err
+	}
+	for _, key := range keys {
+		if err := s.exports.Delete(ctx, key); err != nil {
+			return err
+		}
+	}
+
+	return s.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		customer, err := s.repo.LockCustomer(ctx, request.CustomerID)
+		if err != nil {
+			return err
+		}
+		if err := checkErasable(customer); err != nil {
+			return err
+		}
+		mergedIDs, err := s.repo.ListMergedIDs(ctx, customer.ID)
+		if err != nil {
+			return err
+		}
+
+		erasedAt := time.Now().UTC().Truncate(time.Microsecond)
+		evidence, err := s.repo.Erase(ctx, customer.ID, mergedIDs, erasedAt)
+		if err != nil {
+			return err
+		}
+
+		request.Status = models.RequestStatusCompleted
+		request.ClosedAt = &erasedAt
+		request.Evidence = evidence
+		if err := s.repo.TransitionRequest(ctx, request, models.RequestStatusInProgress); err != nil {
+			return err
+		}
+		if err := s.audit.Record(ctx, audit.ActionDataRequestErase, customer.ID, statusChanges(request, models.RequestStatusInProgress)); err != nil {
+			return err
+		}
+
+		// Appended after the erasure, so its own payload is not redacted
+		event, err := events.NewEvent(events.CustomerErased, customer.ID, models.CustomerErasedPayload{
+			RequestID: request.ID,
+			MergedIDs: mergedIDs,
+			ErasedAt:  erasedAt,
+		})
+		if err != nil {
+			return err
+		}
+		return s.outbox.Append(ctx, event)
+	})
+}
+
+// checkErasable reports whether a customer's data may be erased: only once the
+// customer is closed or deleted, and only once
+func checkErasable(customer *customermodels.Customer) error {
+	if customer.ErasedAt != nil {
+		return models.ErrCustomerErased
+	}
+	if customer.Status != customermodels.CustomerStatusClosed && !customer.DeletedAt.Valid {
+		return models.ErrCustomerNotClosed
+	}
+	return nil
+}
@@ -0,0 +1,160 @@
+package service
+
+import (
+	"archive/zip"
+	"context"
+	"crypto/sha256"
+	"customer-service/internal/audit"
+	customermodels "customer-service/internal/customer/models"
+	"customer-service/internal/kyc/storage"
+	"customer-service/internal/privacy/models"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// export answers an access request: it writes a ZIP archive holding export.json and
+// the customer's KYC document files to the export store, and completes the request
+// with the archive's checksum and what it contains
+func (s *privacyService) export(ctx context.Context, request *models.Request, customer *customermodels.Customer) error {
+	export, err := s.repo.Collect(ctx, customer)
+	if err != nil {
+		return err
+	}
+	export.RequestID = request.ID
+	export.GeneratedAt = time.Now().UTC()
+
+	// The archive is streamed into the store as it is written
+	key := fmt.Sprintf("%s/%s.zip", customer.ID, request.ID)
+	hash := sha256.New()
+	counter := &byteCounter{}
+	reader, writer := io.Pipe()
+	go func() {
+		writer.CloseWithError(s.writeArchive(ctx, writer, export))
+	}()
+	err = s.exports.Put(ctx, key, io.TeeReader(reader, io.MultiWriter(hash, counter)))
+	reader.Close()
+	if err != nil {
+		return fmt.Errorf("failed to store export: %w", err)
+	}
+
+	now := time.Now()
+	request.Status = models.RequestStatusCompleted
+	request.ClosedAt = &now
+	request.Evidence = export.Evidence()
+	request.ExportKey = key
+	request.ExportSHA256 = hex.EncodeToString(hash.Sum(nil))
+	request.ExportSize = counter.n
+
+	err = s.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := s.repo.TransitionRequest(ctx, request, models.RequestStatusInProgress); err != nil {
+			return err
+		}
+		changes := append(statusChanges(request, models.RequestStatusInProgress), audit.Change{
+			Field: requestField(request, "export_sha256"),
+			After: request.ExportSHA256,
+		})
+		return s.audit.Record(ctx, audit.ActionDataRequestExport, request.CustomerID, changes)
+	})
+	if err != nil {
+		s.discard(ctx, key)
+		return err
+	}
+	return nil
+}
+
+// writeArchive writes the export as a ZIP archive: export.json first, then each KYC
+// document file under the path export.json lists it at
+func (s *privacyService) writeArchive(ctx context.Context, w io.Writer, export *models.Export) error {
+	archive := zip.NewWriter(w)
+
+	data, err := json.MarshalIndent(export, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode export: %w", err)
+	}
+	file, err := archive.Create(models.ExportFileName)
+	if err != nil {
+		return fmt.Errorf("failed to write export: %w", err)
+	}
+	if _, err := file.Write(data); err != nil {
+		return fmt.Errorf("failed to write export: %w", err)
+	}
+
+	for _, document := range export.Documents {
+		if err := s.copyDocument(ctx, archive, document); err != nil {
+			return err
+		}
+	}
+
+	if err := archive.Close(); err != nil {
+		return fmt.Errorf("failed to write export: %w", err)
+	}
+	return nil
+}
+
+// copyDocument adds the file of a KYC document to the archive
+func (s *privacyService) copyDocument(ctx context.Context, archive *zip.Writer, document models.ExportedDocument) error {
+	content, err := s.documents.Open(ctx, document.StorageKey)
+	if err != nil {
+		return fmt.Errorf("failed to read KYC document %s: %w", document.DocumentID, err)
+	}
+	defer content.Close()
+
+	file, err := archive.Create(document.Path)
+	if err != nil {
+		return fmt.Errorf("failed to write export: %w", err)
+	}
+	if _, err := io.Copy(file, content); err != nil {
+		return fmt.Errorf("failed to copy KYC document %s: %w", document.DocumentID, err)
+	}
+	return nil
+}
+
+// OpenExport returns the export archive of a completed access request. The archive
+// is no longer available once the customer has been erased.
+func (s *privacyService) OpenExport(ctx context.Context, id uuid.UUID) (*models.Request, io.ReadCloser, error) {
+	request, err := s.repo.GetRequest(ctx, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	if request.Type != models.RequestTypeAccess || request.Status != models.RequestStatusCompleted || request.ExportKey == "" {
+		return nil, nil, models.ErrExportUnavailable
+	}
+
+	content, err := s.exports.Open(ctx, request.ExportKey)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			return nil, nil, models.ErrExportUnavailable
+		}
+		return nil, nil, fmt.Errorf("failed to read export of request %s: %w", request.ID, err)
+	}
+	if err := s.audit.RecordAccess(ctx, audit.ActionDataRequestDownload, []uuid.UUID{request.CustomerID}); err != nil {
+		content.Close()
+		return nil, nil, err
+	}
+	return request, content, nil
+}
+
+// discard removes an export archive whose request was not completed
+func (s *privacyService) discard(ctx context.Context, key string) {
+	if err := s.exports.Delete(context.WithoutCancel(ctx), key); err != nil {
+		log.Printf("Failed to remove orphaned export %s: %v", key, err)
+	}
+}
+
+// byteCounter counts the bytes written to it
+type byteCounter struct {
+	n int64
+}
+
+// Write implements io.Writer
+func (c *byteCounter) Write(p []byte) (int, error) {
+	c.n += int64(len(p))
+	return len(p), nil
+}
@@ -0,0 +1,263 @@
+package service
+
+import (
+	"context"
+	"customer-service/internal/audit"
+	customermodels "customer-service/internal/customer/models"
+	"customer-service/internal/database"
+	"customer-service/internal/events"
+	"customer-service/internal/kyc/storage"
+	"customer-service/internal/privacy/models"
+	"customer-service/internal/privacy/repository"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// PrivacyService defines the interface for handling data subject requests: access
+// requests, answered with an export of everything held on the customer, and erasure
+// requests, answered by anonymizing the customer in place
+type PrivacyService interface {
+	CreateRequest(ctx context.Context, customerID uuid.UUID, req models.CreateRequest, actor string) (*models.Request, error)
+	ListRequests(ctx context.Context, customerID uuid.UUID) (*models.ListResponse, error)
+	GetRequest(ctx context.Context, id uuid.UUID) (*models.Request, error)
+	ProcessRequest(ctx context.Context, id uuid.UUID, actor string) (*models.Request, error)
+	RejectRequest(ctx context.Context, id uuid.UUID, req models.RejectRequest, actor string) (*models.Request, error)
+	OpenExport(ctx context.Context, id uuid.UUID) (*models.Request, io.ReadCloser, error)
+}
+
+// Config holds the settings of the privacy service
+type Config struct {
+	// ResponseDeadline is how long after a request is received it must be answered
+	ResponseDeadline time.Duration
+}
+
+type privacyService struct {
+	repo      repository.PrivacyRepository
+	documents storage.DocumentStore
+	exports   storage.DocumentStore
+	outbox    events.Outbox
+	audit     audit.Recorder
+	tx        database.Transactor
+	cfg       Config
+}
+
+// NewPrivacyService creates a new privacy service instance. KYC document files are
+// read from documents; export archives are kept in exports.
+func NewPrivacyService(repo repository.PrivacyRepository, documents, exports storage.DocumentStore, outbox events.Outbox, recorder audit.Recorder, tx database.Transactor, cfg Config) PrivacyService {
+	return &privacyService{
+		repo:      repo,
+		documents: documents,
+		exports:   exports,
+		outbox:    outbox,
+		audit:     recorder,
+		tx:        tx,
+		cfg:       cfg,
+	}
+}
+
+// CreateRequest logs a data subject request of a customer, due within the response
+// deadline. Only individuals are data subjects, and a customer already erased cannot
+// ask for erasure again.
+func (s *privacyService) CreateRequest(ctx context.Context, customerID uuid.UUID, req models.CreateRequest, actor string) (*models.Request, error) {
+	if actor == "" {
+		return nil, customermodels.NewValidationError("actor", "actor is required")
+	}
+	if err := customermodels.ValidateStruct(req); err != nil {
+		return nil, err
+	}
+
+	customer, err := s.repo.GetCustomer(ctx, customerID)
+	if err != nil {
+		return nil, err
+	}
+	if customer.Type == customermodels.CustomerTypeOrganization {
+		return nil, customermodels.NewValidationError("customer_id", "data subject requests only apply to individuals")
+	}
+	if req.Type == models.RequestTypeErasure && customer.ErasedAt != nil {
+		return nil, models.ErrCustomerErased
+	}
+
+	now := time.Now()
+	request := &models.Request{
+		ID:          uuid.New(),
+		CustomerID:  customerID,
+		Type:        req.Type,
+		Status:      models.RequestStatusReceived,
+		Note:        req.Note,
+		RequestedBy: actor,
+		DueAt:       now.Add(s.cfg.ResponseDeadline),
+	}
+	err = s.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := s.repo.CreateRequest(ctx, request); err != nil {
+			return err
+		}
+		return s.audit.Record(ctx, audit.ActionDataRequestCreate, customerID, statusChanges(request, ""))
+	})
+	if err != nil {
+		return nil, err
+	}
+	return request, nil
+}
+
+// ListRequests returns every data subject request of a customer, newest first
+func (s *privacyService) ListRequests(ctx context.Context, customerID uuid.UUID) (*models.ListResponse, error) {
+	if _, err := s.repo.GetCustomer(ctx, customerID); err != nil {
+		return nil, err
+	}
+
+	requests, err := s.repo.ListRequests(ctx, customerID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.audit.RecordAccess(ctx, audit.ActionDataRequestView, []uuid.UUID{customerID}); err != nil {
+		return nil, err
+	}
+
+	return &models.ListResponse{
+		CustomerID: customerID,
+		Requests:   requests,
+	}, nil
+}
+
+// GetRequest retrieves a data subject request by ID
+func (s *privacyService) GetRequest(ctx context.Context, id uuid.UUID) (*models.Request, error) {
+	request, err := s.repo.GetRequest(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.audit.RecordAccess(ctx, audit.ActionDataRequestView, []uuid.UUID{request.CustomerID}); err != nil {
+		return nil, err
+	}
+	return request, nil
+}
+
+// ProcessRequest answers a received or failed request: it exports the customer's data
+// for an access request, or erases it for an erasure request. The request is marked
+// in progress first, so it is processed once at a time; if processing fails it is
+// marked failed with the cause and may be processed again.
+func (s *privacyService) ProcessRequest(ctx context.Context, id uuid.UUID, actor string) (*models.Request, error) {
+	if actor == "" {
+		return nil, customermodels.NewValidationError("actor", "actor is required")
+	}
+
+	request, err := s.repo.GetRequest(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	from := request.Status
+	if from != models.RequestStatusReceived && from != models.RequestStatusFailed {
+		return nil, fmt.Errorf("%w: a %s request cannot be processed", models.ErrInvalidRequestTransition, from)
+	}
+
+	customer, err := s.repo.GetCustomer(ctx, request.CustomerID)
+	if err != nil {
+		return nil, err
+	}
+	if request.Type == models.RequestTypeErasure {
+		if err := checkErasable(customer); err != nil {
+			return nil, err
+		}
+	}
+
+	request.Status = models.RequestStatusInProgress
+	request.ProcessedBy = actor
+	request.LastError = ""
+	if err := s.repo.TransitionRequest(ctx, request, from); err != nil {
+		return nil, err
+	}
+
+	switch request.Type {
+	case models.RequestTypeAccess:
+		err = s.export(ctx, request, customer)
+	case models.RequestTypeErasure:
+		err = s.erase(ctx, request)
+	default:
+		err = fmt.Errorf("unknown data subject request type %q", request.Type)
+	}
+	if err != nil {
+		return nil, s.fail(ctx, request, err)
+	}
+	return request, nil
+}
+
+// RejectRequest closes a received or failed request without answering it, for
+// example because the requester's identity could not be confirmed
+func (s *privacyService) RejectRequest(ctx context.Context, id uuid.UUID, req models.RejectRequest, actor string) (*models.Request, error) {
+	if actor == "" {
+		return nil, customermodels.NewValidationError("actor", "actor is required")
+	}
+	if err := customermodels.ValidateStruct(req); err != nil {
+		return nil, err
+	}
+
+	request, err := s.repo.GetRequest(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	from := request.Status
+	if from != models.RequestStatusReceived && from != models.RequestStatusFailed {
+		return nil, fmt.Errorf("%w: a %s request cannot be rejected", models.ErrInvalidRequestTransition, from)
+	}
+
+	now := time.Now()
+	request.Status = models.RequestStatusRejected
+	request.ProcessedBy = actor
+	request.ClosedAt = &now
+	request.RejectionReason = req.Reason
+
+	err = s.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := s.repo.TransitionRequest(ctx, request, from); err != nil {
+			return err
+		}
+		// The reason is free text and stays on the request, which erasure keeps
+		changes := append(statusChanges(request, from), audit.RedactedChange(requestField(request, "rejection_reason")))
+		return s.audit.Record(ctx, audit.ActionDataRequestReject, request.CustomerID, changes)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return request, nil
+}
+
+// fail marks a request in progress as failed with the cause, dropping anything the
+// failed attempt set on it, and returns the cause
+func (s *privacyService) fail(ctx context.Context, request *models.Request, cause error) error {
+	request.Status = models.RequestStatusFailed
+	request.LastError = cause.Error()
+	request.ClosedAt = nil
+	request.Evidence = nil
+	request.ExportKey = ""
+	request.ExportSHA256 = ""
+	request.ExportSize = 0
+
+	err := s.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := s.repo.TransitionRequest(ctx, request, models.RequestStatusInProgress); err != nil {
+			return err
+		}
+		return s.audit.Record(ctx, audit.ActionDataRequestFail, request.CustomerID, statusChanges(request, models.RequestStatusInProgress))
+	})
+	if err != nil {
+		return fmt.Errorf("%w (recording the failure also failed: %v)", cause, err)
+	}
+	return cause
+}
+
+// statusChanges returns the audit changes of a request moving to its current status.
+// An empty from records the creation of the request, with its type.
+func statusChanges(request *models.Request, from models.RequestStatus) []audit.Change {
+	if from == "" {
+		return []audit.Change{
+			{Field: requestField(request, "type"), After: request.Type},
+			{Field: requestField(request, "status"), After: request.Status},
+		}
+	}
+	return []audit.Change{{Field: requestField(request, "status"), Before: from, After: request.Status}}
+}
+
+// requestField returns the audit field name of an attribute of a request
+func requestField(request *models.Request, attribute string) string {
+	return "data_requests." + request.ID.String() + "." + attribute
+}
@@ -0,0 +1,195 @@
+package controllers
+
+import (
+	customermodels "customer-service/internal/customer/models"
+	"customer-service/internal/relationship/models"
+	"customer-service/internal/relationship/service"
+	"customer-service/pkg/apierror"
+	"customer-service/pkg/middleware"
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// RelationshipController handles HTTP requests for links between customers
+type RelationshipController struct {
+	service service.RelationshipService
+}
+
+// NewRelationshipController creates a new relationship controller instance
+func NewRelationshipController(relationshipService service.RelationshipService) *RelationshipController {
+	return &RelationshipController{
+		service: relationshipService,
+	}
+}
+
+// ListRelationships handles GET /customers/:id/relationships
+func (ctrl *RelationshipController) ListRelationships(c *gin.Context) {
+	id, ok := ctrl.parseUUID(c, "id")
+	if !ok {
+		return
+	}
+
+	var query models.ListQuery
+	if !ctrl.bindQuery(c, &query) {
+		return
+	}
+
+	relationships, err := ctrl.service.ListRelationships(c.Request.Context(), id, query)
+	if err != nil {
+		ctrl.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, relationships)
+}
+
+// CreateRelationship handles POST /customers/:id/relationships
+func (ctrl *RelationshipController) CreateRelationship(c *gin.Context) {
+	id, ok := ctrl.parseUUID(c, "id")
+	if !ok {
+		return
+	}
+
+	var req models.CreateRequest
+	if !ctrl.bindJSON(c, &req) {
+		return
+	}
+
+	relationship, err := ctrl.service.CreateRelationship(c.Request.Context(), id, req, actorFromContext(c))
+	if err != nil {
+		ctrl.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, relationship)
+}
+
+// GetGraph handles GET /customers/:id/relationships/graph
+func (ctrl *RelationshipController) GetGraph(c *gin.Context) {
+	id, ok := ctrl.parseUUID(c, "id")
+	if !ok {
+		return
+	}
+
+	var query models.GraphQuery
+	if !ctrl.bindQuery(c, &query) {
+		return
+	}
+
+	graph, err := ctrl.service.GetGraph(c.Request.Context(), id, query)
+	if err != nil {
+		ctrl.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, graph)
+}
+
+// GetRelationship handles GET /relationships/:id
+func (ctrl *RelationshipController) GetRelationship(c *gin.Context) {
+	id, ok := ctrl.parseUUID(c, "id")
+	if !ok {
+		return
+	}
+
+	relationship, err := ctrl.service.GetRelationship(c.Request.Context(), id)
+	if err != nil {
+		ctrl.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, relationship)
+}
+
+// EndRelationship handles POST /relationships/:id/end
+func (ctrl *RelationshipController) EndRelationship(c *gin.Context) {
+	id, ok := ctrl.parseUUID(c, "id")
+	if !ok {
+		return
+	}
+
+	var req models.EndRequest
+	if c.Request.ContentLength != 0 && !ctrl.bindJSON(c, &req) {
+		return
+	}
+
+	relationship, err := ctrl.service.EndRelationship(c.Request.Context(), id, req, actorFromContext(c))
+	if err != nil {
+		ctrl.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, relationship)
+}
+
+// bindJSON decodes and validates the request body, writing an error response on failure
+func (ctrl *RelationshipController) bindJSON(c *gin.Context, req any) bool {
+	if err := c.ShouldBindJSON(req); err != nil {
+		apierror.Abort(c, http.StatusBadRequest, apierror.CodeInvalidRequest, "invalid request body: "+err.Error())
+		return false
+	}
+
+	if err := customermodels.ValidateStruct(req); err != nil {
+		ctrl.handleError(c, err)
+		return false
+	}
+
+	return true
+}
+
+// bindQuery parses query parameters, writing an error response on failure
+func (ctrl *RelationshipController) bindQuery(c *gin.Context, query any) bool {
+	if err := c.ShouldBindQuery(query); err != nil {
+		apierror.Abort(c, http.StatusBadRequest, apierror.CodeInvalidRequest, "invalid query parameters: "+err.Error())
+		return false
+	}
+	return true
+}
+
+// parseUUID parses a UUID path parameter, writing an error response on failure
+func (ctrl *RelationshipController) parseUUID(c *gin.Context, param string) (uuid.UUID, bool) {
+	id, err := uuid.Parse(c.Param(param))
+	if err != nil {
+		apierror.Abort(c, http.StatusBadRequest, apierror.CodeInvalidRequest, "invalid "+param,
+			apierror.FieldError{Field: param, Message: "must be a valid UUID"})
+		return uuid.Nil, false
+	}
+	return id, true
+}
+
+// handleError maps service errors to HTTP responses
+func (ctrl *RelationshipController) handleError(c *gin.Context, err error) {
+	var validationErr *customermodels.ValidationError
+	switch {
+	case errors.As(err, &validationErr):
+		fields := make([]apierror.FieldError, len(validationErr.Fields))
+		for i, f := range validationErr.Fields {
+			fields[i] = apierror.FieldError{Field: f.Field, Message: f.Message}
+		}
+		apierror.Abort(c, http.StatusBadRequest, apierror.CodeValidationFailed, "request validation failed", fields...)
+	case errors.Is(err, customermodels.ErrCustomerNotFound),
+		errors.Is(err, models.ErrRelationshipNotFound):
+		apierror.Abort(c, http.StatusNotFound, apierror.CodeNotFound, err.Error())
+	case errors.Is(err, models.ErrRelationshipExists),
+		errors.Is(err, models.ErrRelationshipEnded):
+		apierror.Abort(c, http.StatusConflict, apierror.CodeConflict, err.Error())
+	case errors.Is(err, models.ErrGuardianRequired):
+		apierror.Abort(c, http.StatusConflict, apierror.CodeGuardianRequired, err.Error())
+	default:
+		log.Printf("Unhandled error processing %s %s: %v", c.Request.Method, c.Request.URL.Path, err)
+		apierror.Abort(c, http.StatusInternalServerError, apierror.CodeInternal, "internal server error")
+	}
+}
+
+// actorFromContext returns the subject of the authenticated caller
+func actorFromContext(c *gin.Context) string {
+	claims, ok := middleware.GetClaims(c)
+	if !ok {
+		return ""
+	}
+	return claims.Subject
+}
@@ -0,0 +1,14 @@
+package models
+
+// CustomerRelationshipCreatedPayload is the payload of a CustomerRelationshipCreated
+// event, emitted for both linked customers
+type CustomerRelationshipCreatedPayload struct {
+	Relationship Relationship `json:"relationship"`
+}
+
+// CustomerRelationshipEndedPayload is the payload of a CustomerRelationshipEnded event,
+// emitted for both linked customers
+type CustomerRelationshipEndedPayload struct {
+	Relationship Relationship `json:"relationship"`
+	Actor        string       `json:"actor"`
+}
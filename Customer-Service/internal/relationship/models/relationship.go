@@ -0,0 +1,164 @@
+package models
+
+import (
+	customermodels "customer-service/internal/customer/models"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Domain errors returned by the relationship repository and service layers
+var (
+	ErrRelationshipNotFound = errors.New("relationship not found")
+	ErrRelationshipExists   = errors.New("an overlapping relationship already exists")
+	ErrRelationshipEnded    = errors.New("relationship has already ended")
+	ErrGuardianRequired     = errors.New("a minor must have a guardian")
+)
+
+// MaxGraphDepth is the furthest a relationship graph can be followed from a customer
+const MaxGraphDepth = 5
+
+// AdultAge is the age in whole years from which a customer is no longer a minor
+const AdultAge = 18
+
+// Type is the kind of link between two customers. Links are directional and read
+// from the first customer to the second: A guardian_of B means A is B's guardian.
+type Type string
+
+const (
+	TypeSpouse              Type = "spouse"
+	TypeGuardianOf          Type = "guardian_of"
+	TypeMinorOf             Type = "minor_of"
+	TypeAuthorizedSignatory Type = "authorized_signatory"
+	TypePowerOfAttorney     Type = "power_of_attorney"
+	TypeHouseholdMember     Type = "household_member"
+)
+
+// Inverse returns the type that describes the same link read from the other end.
+// Spouses and household members are linked both ways; guardian_of and minor_of are
+// two readings of one guardianship. Other types have no inverse.
+func (t Type) Inverse() Type {
+	switch t {
+	case TypeSpouse, TypeHouseholdMember:
+		return t
+	case TypeGuardianOf:
+		return TypeMinorOf
+	case TypeMinorOf:
+		return TypeGuardianOf
+	}
+	return ""
+}
+
+// IsGuardianship reports whether the link makes one customer the guardian of the other
+func (t Type) IsGuardianship() bool {
+	return t == TypeGuardianOf || t == TypeMinorOf
+}
+
+// Relationship is a typed, directional link between two customers. It applies from
+// EffectiveFrom up to, but not including, EffectiveTo; an open-ended link has no
+// EffectiveTo.
+type Relationship struct {
+	ID             uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	FromCustomerID uuid.UUID  `json:"from_customer_id" gorm:"type:uuid;not null;index"`
+	ToCustomerID   uuid.UUID  `json:"to_customer_id" gorm:"type:uuid;not null;index"`
+	Type           Type       `json:"type" gorm:"not null;size:30"`
+	EffectiveFrom  time.Time  `json:"effective_from" gorm:"type:date;not null"`
+	EffectiveTo    *time.Time `json:"effective_to,omitempty" gorm:"type:date"`
+	Note           string     `json:"note,omitempty" gorm:"size:500"`
+	CreatedBy      string     `json:"created_by" gorm:"not null;size:255"`
+	EndedBy        string     `json:"ended_by,omitempty" gorm:"size:255"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+}
+
+// TableName returns the table name for Relationship model
+func (Relationship) TableName() string {
+	return "customer_relationships"
+}
+
+// ActiveOn reports whether the relationship applies on the given day
+func (r *Relationship) ActiveOn(day time.Time) bool {
+	return !day.Before(r.EffectiveFrom) && (r.EffectiveTo == nil || day.Before(*r.EffectiveTo))
+}
+
+// Overlaps reports whether the relationship applies on any day from from up to to.
+// A nil to is open-ended.
+func (r *Relationship) Overlaps(from time.Time, to *time.Time) bool {
+	startsBeforeEnd := to == nil || r.EffectiveFrom.Before(*to)
+	endsAfterStart := r.EffectiveTo == nil || r.EffectiveTo.After(from)
+	return startsBeforeEnd && endsAfterStart
+}
+
+// Links reports whether the relationship connects the two customers with the given
+// type, in either reading
+func (r *Relationship) Links(from, to uuid.UUID, relType Type) bool {
+	if r.FromCustomerID == from && r.ToCustomerID == to && r.Type == relType {
+		return true
+	}
+	inverse := relType.Inverse()
+	return inverse != "" && r.FromCustomerID == to && r.ToCustomerID == from && r.Type == inverse
+}
+
+// Other returns the customer at the other end of the relationship
+func (r *Relationship) Other(customerID uuid.UUID) uuid.UUID {
+	if r.FromCustomerID == customerID {
+		return r.ToCustomerID
+	}
+	return r.FromCustomerID
+}
+
+// CreateRequest represents the request payload for linking a customer to another.
+// EffectiveFrom defaults to today.
+type CreateRequest struct {
+	RelatedCustomerID uuid.UUID  `json:"related_customer_id" validate:"required"`
+	Type              Type       `json:"type" validate:"required,oneof=spouse guardian_of minor_of authorized_signatory power_of_attorney household_member"`
+	EffectiveFrom     *time.Time `json:"effective_from"`
+	EffectiveTo       *time.Time `json:"effective_to"`
+	Note              string     `json:"note" validate:"max=500"`
+}
+
+// EndRequest represents the request payload for ending a relationship. EffectiveTo
+// defaults to today.
+type EndRequest struct {
+	EffectiveTo *time.Time `json:"effective_to"`
+}
+
+// ListQuery filters the relationships of a customer. Without AsOf every link is
+// listed, including ended and future ones.
+type ListQuery struct {
+	AsOf *time.Time `form:"as_of" time_format:"2006-01-02"`
+}
+
+// ListResponse lists the relationships a customer takes part in, at either end
+type ListResponse struct {
+	CustomerID    uuid.UUID      `json:"customer_id"`
+	Relationships []Relationship `json:"relationships"`
+}
+
+// GraphQuery selects how far the relationship graph of a customer is followed and
+// on which day. Depth defaults to 1 and AsOf to today.
+type GraphQuery struct {
+	Depth int        `form:"depth"`
+	AsOf  *time.Time `form:"as_of" time_format:"2006-01-02"`
+}
+
+// Node is a customer in a relationship graph, Depth links away from its root
+type Node struct {
+	CustomerID uuid.UUID                     `json:"customer_id"`
+	Type       customermodels.CustomerType   `json:"type"`
+	Name       string                        `json:"name"`
+	Status     customermodels.CustomerStatus `json:"status"`
+	Depth      int                           `json:"depth"`
+}
+
+// GraphResponse is the network of customers linked to a customer on a given day.
+// Truncated is set when the graph was cut short at its node limit.
+type GraphResponse struct {
+	CustomerID uuid.UUID      `json:"customer_id"`
+	Depth      int            `json:"depth"`
+	AsOf       time.Time      `json:"as_of"`
+	Nodes      []Node         `json:"nodes"`
+	Edges      []Relationship `json:"edges"`
+	Truncated  bool           `json:"truncated"`
+}
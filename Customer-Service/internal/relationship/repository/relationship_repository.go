@@ -0,0 +1,114 @@
+package repository
+
+import (
+	"context"
+	"customer-service/internal/database"
+	"customer-service/internal/relationship/models"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// RelationshipRepository defines the interface for customer relationship data access
+type RelationshipRepository interface {
+	Create(ctx context.Context, relationship *models.Relationship) error
+	Get(ctx context.Context, id uuid.UUID) (*models.Relationship, error)
+	ListByCustomer(ctx context.Context, customerID uuid.UUID) ([]models.Relationship, error)
+	ListActive(ctx context.Context, customerIDs []uuid.UUID, day time.Time) ([]models.Relationship, error)
+	End(ctx context.Context, relationship *models.Relationship) error
+	LockCustomers(ctx context.Context, customerIDs ...uuid.UUID) error
+}
+
+type relationshipRepository struct {
+	db *gorm.DB
+}
+
+// NewRelationshipRepository creates a new relationship repository instance
+func NewRelationshipRepository(db *gorm.DB) RelationshipRepository {
+	return &relationshipRepository{db: db}
+}
+
+// Create stores a new relationship
+func (r *relationshipRepository) Create(ctx context.Context, relationship *models.Relationship) error {
+	if err := database.Conn(ctx, r.db).Create(relationship).Error; err != nil {
+		return fmt.Errorf("failed to create relationship: %w", err)
+	}
+	return nil
+}
+
+// Get retrieves a relationship by ID
+func (r *relationshipRepository) Get(ctx context.Context, id uuid.UUID) (*models.Relationship, error) {
+	var relationship models.Relationship
+	if err := database.Conn(ctx, r.db).Where("id = ?", id).First(&relationship).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, models.ErrRelationshipNotFound
+		}
+		return nil, fmt.Errorf("failed to get relationship: %w", err)
+	}
+	return &relationship, nil
+}
+
+// ListByCustomer returns every relationship a customer takes part in, at either end,
+// including ended and future ones, latest first
+func (r *relationshipRepository) ListByCustomer(ctx context.Context, customerID uuid.UUID) ([]models.Relationship, error) {
+	var relationships []models.Relationship
+	err := database.Conn(ctx, r.db).
+		Where("from_customer_id = ? OR to_customer_id = ?", customerID, customerID).
+		Order("effective_from DESC, created_at DESC").
+		Find(&relationships).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list relationships: %w", err)
+	}
+	return relationships, nil
+}
+
+// ListActive returns the relationships in effect on day that any of the customers
+// take part in
+func (r *relationshipRepository) ListActive(ctx context.Context, customerIDs []uuid.UUID, day time.Time) ([]models.Relationship, error) {
+	var relationships []models.Relationship
+	err := database.Conn(ctx, r.db).
+		Where("(from_customer_id IN ? OR to_customer_id IN ?)", customerIDs, customerIDs).
+		Where("effective_from <= ? AND (effective_to IS NULL OR effective_to > ?)", day, day).
+		Order("created_at").
+		Find(&relationships).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list active relationships: %w", err)
+	}
+	return relationships, nil
+}
+
+// End saves the end date of a relationship and who ended it
+func (r *relationshipRepository) End(ctx context.Context, relationship *models.Relationship) error {
+	relationship.UpdatedAt = time.Now()
+	result := database.Conn(ctx, r.db).Model(&models.Relationship{}).
+		Where("id = ?", relationship.ID).
+		Updates(map[string]interface{}{
+			"effective_to": relationship.EffectiveTo,
+			"ended_by":     relationship.EndedBy,
+			"updated_at":   relationship.UpdatedAt,
+		})
+	if result.Error != nil {
+		return fmt.Errorf("failed to end relationship: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return models.ErrRelationshipNotFound
+	}
+	return nil
+}
+
+// LockCustomers locks the rows of the given customers until the transaction in ctx
+// ends, so that concurrent changes to their relationships are checked one at a time.
+// Rows are locked in ID order to avoid deadlocks.
+func (r *relationshipRepository) LockCustomers(ctx context.Context, customerIDs ...uuid.UUID) error {
+	var locked []uuid.UUID
+	err := database.Conn(ctx, r.db).
+		Raw("SELECT id FROM customers WHERE id IN ? ORDER BY id FOR UPDATE", customerIDs).
+		Scan(&locked).Error
+	if err != nil {
+		return fmt.Errorf("failed to lock customers: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,499 @@
+package service
+
+import (
+	"context"
+	"customer-service/internal/audit"
+	customermodels "customer-service/internal/customer/models"
+	customerrepository "customer-service/internal/customer/repository"
+	"customer-service/internal/database"
+	"customer-service/internal/events"
+	"customer-service/internal/relationship/models"
+	"customer-service/internal/relationship/repository"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// maxGraphNodes bounds the number of customers returned in a relationship graph
+const maxGraphNodes = 500
+
+// RelationshipService defines the interface for links between customers
+type RelationshipService interface {
+	CreateRelationship(ctx context.Context, customerID uuid.UUID, req models.CreateRequest, actor string) (*models.Relationship, error)
+	GetRelationship(ctx context.Context, id uuid.UUID) (*models.Relationship, error)
+	ListRelationships(ctx context.Context, customerID uuid.UUID, query models.ListQuery) (*models.ListResponse, error)
+	EndRelationship(ctx context.Context, id uuid.UUID, req models.EndRequest, actor string) (*models.Relationship, error)
+	GetGraph(ctx context.Context, customerID uuid.UUID, query models.GraphQuery) (*models.GraphResponse, error)
+}
+
+type relationshipService struct {
+	repo      repository.RelationshipRepository
+	customers customerrepository.CustomerRepository
+	outbox    events.Outbox
+	audit     audit.Recorder
+	tx        database.Transactor
+}
+
+// NewRelationshipService creates a new relationship service instance. Every change is
+// written together with an audit entry and an event for each of the linked customers.
+func NewRelationshipService(repo repository.RelationshipRepository, customers customerrepository.CustomerRepository, outbox events.Outbox, recorder audit.Recorder, tx database.Transactor) RelationshipService {
+	return &relationshipService{
+		repo:      repo,
+		customers: customers,
+		outbox:    outbox,
+		audit:     recorder,
+		tx:        tx,
+	}
+}
+
+// CreateRelationship links a customer to another from the given date. The link must
+// suit both customers and must not overlap an existing link of the same kind between
+// them; a customer has at most one spouse at a time, and a minor can only be linked
+// to others once they have a guardian.
+func (s *relationshipService) CreateRelationship(ctx context.Context, customerID uuid.UUID, req models.CreateRequest, actor string) (*models.Relationship, error) {
+	if actor == "" {
+		return nil, customermodels.NewValidationError("actor", "actor is required")
+	}
+	if err := customermodels.ValidateStruct(req); err != nil {
+		return nil, err
+	}
+	if req.RelatedCustomerID == customerID {
+		return nil, customermodels.NewValidationError("related_customer_id", "must differ from the customer")
+	}
+
+	relationship := &models.Relationship{
+		FromCustomerID: customerID,
+		ToCustomerID:   req.RelatedCustomerID,
+		Type:           req.Type,
+		EffectiveFrom:  today(),
+		Note:           req.Note,
+		CreatedBy:      actor,
+	}
+	if req.EffectiveFrom != nil {
+		relationship.EffectiveFrom = day(*req.EffectiveFrom)
+	}
+	if req.EffectiveTo != nil {
+		effectiveTo := day(*req.EffectiveTo)
+		if !effectiveTo.After(relationship.EffectiveFrom) {
+			return nil, customermodels.NewValidationError("effective_to", "must be after effective_from")
+		}
+		relationship.EffectiveTo = &effectiveTo
+	}
+
+	from, err := s.customers.GetByID(ctx, customerID)
+	if err != nil {
+		return nil, err
+	}
+	to, err := s.customers.GetByID(ctx, req.RelatedCustomerID)
+	if err != nil {
+		return nil, err
+	}
+	if err := validateParties(relationship, from, to); err != nil {
+		return nil, err
+	}
+
+	err = s.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := s.repo.LockCustomers(ctx, from.ID, to.ID); err != nil {
+			return err
+		}
+		if err := s.checkExisting(ctx, relationship); err != nil {
+			return err
+		}
+		for _, customer := range []*customermodels.Customer{from, to} {
+			if err := s.checkGuardian(ctx, relationship, customer); err != nil {
+				return err
+			}
+		}
+
+		if err := s.repo.Create(ctx, relationship); err != nil {
+			return err
+		}
+		changes := []audit.Change{
+			{Field: "relationship_id", Before: nil, After: relationship.ID},
+			{Field: "type", Before: nil, After: relationship.Type},
+			{Field: "from_customer_id", Before: nil, After: relationship.FromCustomerID},
+			{Field: "to_customer_id", Before: nil, After: relationship.ToCustomerID},
+			{Field: "effective_from", Before: nil, After: relationship.EffectiveFrom},
+			{Field: "effective_to", Before: nil, After: relationship.EffectiveTo},
+		}
+		payload := models.CustomerRelationshipCreatedPayload{Relationship: *relationship}
+		return s.recordBoth(ctx, audit.ActionRelationshipCreate, events.CustomerRelationshipCreated, relationship, changes, payload)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return relationship, nil
+}
+
+// GetRelationship retrieves a relationship by ID
+func (s *relationshipService) GetRelationship(ctx context.Context, id uuid.UUID) (*models.Relationship, error) {
+	relationship, err := s.repo.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	ids := []uuid.UUID{relationship.FromCustomerID, relationship.ToCustomerID}
+	if err := s.audit.RecordAccess(ctx, audit.ActionRelationshipView, ids); err != nil {
+		return nil, err
+	}
+	return relationship, nil
+}
+
+// ListRelationships returns the links a customer takes part in, optionally only those
+// in effect on a given day
+func (s *relationshipService) ListRelationships(ctx context.Context, customerID uuid.UUID, query models.ListQuery) (*models.ListResponse, error) {
+	if _, err := s.customers.GetByID(ctx, customerID); err != nil {
+		return nil, err
+	}
+
+	relationships, err := s.repo.ListByCustomer(ctx, customerID)
+	if err != nil {
+		return nil, err
+	}
+	if query.AsOf != nil {
+		asOf := day(*query.AsOf)
+		active := make([]models.Relationship, 0, len(relationships))
+		for _, relationship := range relationships {
+			if relationship.ActiveOn(asOf) {
+				active = append(active, relationship)
+			}
+		}
+		relationships = active
+	}
+	if err := s.audit.RecordAccess(ctx, audit.ActionRelationshipView, []uuid.UUID{customerID}); err != nil {
+		return nil, err
+	}
+
+	return &models.ListResponse{
+		CustomerID:    customerID,
+		Relationships: relationships,
+	}, nil
+}
+
+// EndRelationship ends a relationship on the given day, or today. A guardianship
+// cannot end while its minor would be left without a guardian.
+func (s *relationshipService) EndRelationship(ctx context.Context, id uuid.UUID, req models.EndRequest, actor string) (*models.Relationship, error) {
+	if actor == "" {
+		return nil, customermodels.NewValidationError("actor", "actor is required")
+	}
+	effectiveTo := today()
+	if req.EffectiveTo != nil {
+		effectiveTo = day(*req.EffectiveTo)
+	}
+
+	var relationship *models.Relationship
+	err := s.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		if relationship, err = s.repo.Get(ctx, id); err != nil {
+			return err
+		}
+		if err := s.repo.LockCustomers(ctx, relationship.FromCustomerID, relationship.ToCustomerID); err != nil {
+			return err
+		}
+		// Read again under the lock, in case the relationship was ended meanwhile
+		if relationship, err = s.repo.Get(ctx, id); err != nil {
+			return err
+		}
+
+		if relationship.EffectiveTo != nil && !relationship.EffectiveTo.After(today()) {
+			return models.ErrRelationshipEnded
+		}
+		if effectiveTo.Before(relationship.EffectiveFrom) {
+			return customermodels.NewValidationError("effective_to", "must not be before effective_from")
+		}
+		if relationship.EffectiveTo != nil && effectiveTo.After(*relationship.EffectiveTo) {
+			return customermodels.NewValidationError("effective_to", "must not be after the current end date")
+		}
+		if relationship.Type.IsGuardianship() {
+			if err := s.checkGuardianRemains(ctx, relationship, effectiveTo); err != nil {
+				return err
+			}
+		}
+
+		before := relationship.EffectiveTo
+		relationship.EffectiveTo = &effectiveTo
+		relationship.EndedBy = actor
+		if err := s.repo.End(ctx, relationship); err != nil {
+			return err
+		}
+		changes := []audit.Change{
+			{Field: "relationship_id", Before: relationship.ID, After: relationship.ID},
+			{Field: "effective_to", Before: before, After: relationship.EffectiveTo},
+		}
+		payload := models.CustomerRelationshipEndedPayload{Relationship: *relationship, Actor: actor}
+		return s.recordBoth(ctx, audit.ActionRelationshipEnd, events.CustomerRelationshipEnded, relationship, changes, payload)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return relationship, nil
+}
+
+// GetGraph follows the links in effect on a day outwards from a customer, breadth
+// first, up to the requested depth
+func (s *relationshipService) GetGraph(ctx context.Context, customerID uuid.UUID, query models.GraphQuery) (*models.GraphResponse, error) {
+	if query.Depth == 0 {
+		query.Depth = 1
+	}
+	if query.Depth < 1 || query.Depth > models.MaxGraphDepth {
+		return nil, customermodels.NewValidationError("depth", fmt.Sprintf("must be between 1 and %d", models.MaxGraphDepth))
+	}
+	asOf := today()
+	if query.AsOf != nil {
+		asOf = day(*query.AsOf)
+	}
+
+	root, err := s.customers.GetByID(ctx, customerID)
+	if err != nil {
+		return nil, err
+	}
+
+	graph := &models.GraphResponse{
+		CustomerID: customerID,
+		Depth:      query.Depth,
+		AsOf:       asOf,
+		Nodes:      []models.Node{newNode(root, 0)},
+		Edges:      make([]models.Relationship, 0),
+	}
+	depths := map[uuid.UUID]int{root.ID: 0}
+	seenEdges := make(map[uuid.UUID]bool)
+	frontier := []uuid.UUID{root.ID}
+
+	for depth := 1; depth <= query.Depth && len(frontier) > 0 && !graph.Truncated; depth++ {
+		relationships, err := s.repo.ListActive(ctx, frontier, asOf)
+		if err != nil {
+			return nil, err
+		}
+
+		// Customers reached for the first time at this depth, in the order found
+		reached := make([]uuid.UUID, 0)
+		for _, relationship := range relationships {
+			for _, id := range []uuid.UUID{relationship.FromCustomerID, relationship.ToCustomerID} {
+				if _, ok := depths[id]; ok || slices.Contains(reached, id) {
+					continue
+				}
+				if len(depths)+len(reached) >= maxGraphNodes {
+					graph.Truncated = true
+					continue
+				}
+				reached = append(reached, id)
+			}
+		}
+
+		customers, err := s.customers.ListByIDs(ctx, reached)
+		if err != nil {
+			return nil, err
+		}
+		frontier = make([]uuid.UUID, 0, len(customers))
+		for i := range customers {
+			depths[customers[i].ID] = depth
+			graph.Nodes = append(graph.Nodes, newNode(&customers[i], depth))
+			frontier = append(frontier, customers[i].ID)
+		}
+
+		// Keep the links whose customers both made it into the graph
+		for _, relationship := range relationships {
+			_, fromOK := depths[relationship.FromCustomerID]
+			_, toOK := depths[relationship.ToCustomerID]
+			if fromOK && toOK && !seenEdges[relationship.ID] {
+				seenEdges[relationship.ID] = true
+				graph.Edges = append(graph.Edges, relationship)
+			}
+		}
+	}
+
+	ids := make([]uuid.UUID, len(graph.Nodes))
+	for i, node := range graph.Nodes {
+		ids[i] = node.CustomerID
+	}
+	if err := s.audit.RecordAccess(ctx, audit.ActionRelationshipView, ids); err != nil {
+		return nil, err
+	}
+	return graph, nil
+}
+
+// validateParties checks that both customers can take part in the link on the day it
+// starts. Family links are between individuals; signatories and attorneys are adult
+// individuals acting for any customer; minor_of must name a minor and guardian_of an
+// adult guardian.
+func validateParties(relationship *models.Relationship, from, to *customermodels.Customer) error {
+	verr := &customermodels.ValidationError{}
+	on := relationship.EffectiveFrom
+	for _, customer := range []*customermodels.Customer{from, to} {
+		if customer.Status == customermodels.CustomerStatusClosed {
+			verr.Add(partyField(customer, from), "customer is closed")
+		}
+	}
+
+	if from.Type != customermodels.CustomerTypeIndividual {
+		verr.Add("type", fmt.Sprintf("%s can only be held by an individual", relationship.Type))
+	}
+	switch relationship.Type {
+	case models.TypeSpouse, models.TypeHouseholdMember, models.TypeGuardianOf, models.TypeMinorOf:
+		if to.Type != customermodels.CustomerTypeIndividual {
+			verr.Add("related_customer_id", fmt.Sprintf("must be an individual for %s", relationship.Type))
+		}
+	}
+	if verr.HasErrors() {
+		return verr
+	}
+
+	switch relationship.Type {
+	case models.TypeSpouse:
+		if isMinor(from, on) || isMinor(to, on) {
+			verr.Add("type", "spouses must both be adults")
+		}
+	case models.TypeGuardianOf:
+		if isMinor(from, on) {
+			verr.Add("type", "a guardian must be an adult")
+		}
+	case models.TypeMinorOf:
+		if from.DateOfBirth == nil {
+			verr.Add("type", "the minor's date of birth is required")
+		} else if !isMinor(from, on) {
+			verr.Add("type", "customer is not a minor on effective_from")
+		}
+		if isMinor(to, on) {
+			verr.Add("related_customer_id", "a guardian must be an adult")
+		}
+	case models.TypeAuthorizedSignatory, models.TypePowerOfAttorney:
+		if isMinor(from, on) {
+			verr.Add("type", fmt.Sprintf("%s must be held by an adult", relationship.Type))
+		}
+	}
+	if verr.HasErrors() {
+		return verr
+	}
+	return nil
+}
+
+// checkExisting rejects a link that overlaps one of the same kind between the same
+// customers, or that would give a customer two spouses at once
+func (s *relationshipService) checkExisting(ctx context.Context, relationship *models.Relationship) error {
+	for _, customerID := range []uuid.UUID{relationship.FromCustomerID, relationship.ToCustomerID} {
+		existing, err := s.repo.ListByCustomer(ctx, customerID)
+		if err != nil {
+			return err
+		}
+		for _, other := range existing {
+			if !other.Overlaps(relationship.EffectiveFrom, relationship.EffectiveTo) {
+				continue
+			}
+			if other.Links(relationship.FromCustomerID, relationship.ToCustomerID, relationship.Type) {
+				return fmt.Errorf("%w: %s since %s", models.ErrRelationshipExists, other.Type, other.EffectiveFrom.Format("2006-01-02"))
+			}
+			if relationship.Type == models.TypeSpouse && other.Type == models.TypeSpouse {
+				return fmt.Errorf("%w: customer %s already has a spouse", models.ErrRelationshipExists, customerID)
+			}
+		}
+	}
+	return nil
+}
+
+// checkGuardian rejects linking a minor to others, other than by a guardianship,
+// unless they have a guardian when the link starts
+func (s *relationshipService) checkGuardian(ctx context.Context, relationship *models.Relationship, customer *customermodels.Customer) error {
+	if relationship.Type.IsGuardianship() || !isMinor(customer, relationship.EffectiveFrom) {
+		return nil
+	}
+	active, err := s.repo.ListActive(ctx, []uuid.UUID{customer.ID}, relationship.EffectiveFrom)
+	if err != nil {
+		return err
+	}
+	for _, other := range active {
+		if isGuardianOf(&other, customer.ID) {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: customer %s has no guardian on %s", models.ErrGuardianRequired, customer.ID, relationship.EffectiveFrom.Format("2006-01-02"))
+}
+
+// checkGuardianRemains rejects ending a guardianship on a day its minor is still a
+// minor, unless another guardianship covers them from that day on
+func (s *relationshipService) checkGuardianRemains(ctx context.Context, relationship *models.Relationship, effectiveTo time.Time) error {
+	minorID := relationship.ToCustomerID
+	if relationship.Type == models.TypeMinorOf {
+		minorID = relationship.FromCustomerID
+	}
+	minor, err := s.customers.GetByID(ctx, minorID)
+	if err != nil {
+		return err
+	}
+	if !isMinor(minor, effectiveTo) {
+		return nil
+	}
+
+	others, err := s.repo.ListByCustomer(ctx, minorID)
+	if err != nil {
+		return err
+	}
+	for _, other := range others {
+		if other.ID != relationship.ID && isGuardianOf(&other, minorID) && other.ActiveOn(effectiveTo) {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: customer %s would be left without a guardian", models.ErrGuardianRequired, minorID)
+}
+
+// recordBoth writes an audit entry and an event for each of the linked customers
+func (s *relationshipService) recordBoth(ctx context.Context, action, eventType string, relationship *models.Relationship, changes []audit.Change, payload any) error {
+	for _, customerID := range []uuid.UUID{relationship.FromCustomerID, relationship.ToCustomerID} {
+		if err := s.audit.Record(ctx, action, customerID, changes); err != nil {
+			return err
+		}
+		event, err := events.NewEvent(eventType, customerID, payload)
+		if err != nil {
+			return err
+		}
+		if err := s.outbox.Append(ctx, event); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// isGuardianOf reports whether the relationship makes someone the guardian of minorID
+func isGuardianOf(relationship *models.Relationship, minorID uuid.UUID) bool {
+	return (relationship.Type == models.TypeGuardianOf && relationship.ToCustomerID == minorID) ||
+		(relationship.Type == models.TypeMinorOf && relationship.FromCustomerID == minorID)
+}
+
+// isMinor reports whether an individual is under age on the given day. Customers
+// without a date of birth are taken to be adults.
+func isMinor(customer *customermodels.Customer, on time.Time) bool {
+	if customer.Type != customermodels.CustomerTypeIndividual || customer.DateOfBirth == nil {
+		return false
+	}
+	return on.Before(customer.DateOfBirth.UTC().AddDate(models.AdultAge, 0, 0))
+}
+
+// partyField names the request field that refers to a customer
+func partyField(customer, from *customermodels.Customer) string {
+	if customer == from {
+		return "id"
+	}
+	return "related_customer_id"
+}
+
+// newNode describes a customer in a relationship graph
+func newNode(customer *customermodels.Customer, depth int) models.Node {
+	return models.Node{
+		CustomerID: customer.ID,
+		Type:       customer.Type,
+		Name:       customer.Name(),
+		Status:     customer.Status,
+		Depth:      depth,
+	}
+}
+
+// day truncates a time to midnight UTC of its date
+func day(t time.Time) time.Time {
+	year, month, date := t.UTC().Date()
+	return time.Date(year, month, date, 0, 0, 0, 0, time.UTC)
+}
+
+// today returns the current date at midnight UTC
+func today() time.Time {
+	return day(time.Now())
+}
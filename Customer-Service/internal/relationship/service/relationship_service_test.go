@@ -0,0 +1,435 @@
+package service
+
+import (
+	"context"
+	"customer-service/internal/audit"
+	customermodels "customer-service/internal/customer/models"
+	customerrepository "customer-service/internal/customer/repository"
+	"customer-service/internal/events"
+	"customer-service/internal/relationship/models"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// memoryRelationships keeps relationships in memory, in creation order
+type memoryRelationships struct {
+	relationships []models.Relationship
+}
+
+func (r *memoryRelationships) Create(ctx context.Context, relationship *models.Relationship) error {
+	relationship.ID = uuid.New()
+	r.relationships = append(r.relationships, *relationship)
+	return nil
+}
+
+func (r *memoryRelationships) Get(ctx context.Context, id uuid.UUID) (*models.Relationship, error) {
+	for _, relationship := range r.relationships {
+		if relationship.ID == id {
+			return &relationship, nil
+		}
+	}
+	return nil, models.ErrRelationshipNotFound
+}
+
+func (r *memoryRelationships) ListByCustomer(ctx context.Context, customerID uuid.UUID) ([]models.Relationship, error) {
+	var found []models.Relationship
+	for _, relationship := range r.relationships {
+		if relationship.FromCustomerID == customerID || relationship.ToCustomerID == customerID {
+			found = append(found, relationship)
+		}
+	}
+	return found, nil
+}
+
+func (r *memoryRelationships) ListActive(ctx context.Context, customerIDs []uuid.UUID, day time.Time) ([]models.Relationship, error) {
+	var found []models.Relationship
+	for _, relationship := range r.relationships {
+		takesPart := slices.Contains(customerIDs, relationship.FromCustomerID) || slices.Contains(customerIDs, relationship.ToCustomerID)
+		if takesPart && relationship.ActiveOn(day) {
+			found = append(found, relationship)
+		}
+	}
+	return found, nil
+}
+
+func (r *memoryRelationships) End(ctx context.Context, relationship *models.Relationship) error {
+	for i := range r.relationships {
+		if r.relationships[i].ID == relationship.ID {
+			r.relationships[i] = *relationship
+		}
+	}
+	return nil
+}
+
+func (r *memoryRelationships) LockCustomers(ctx context.Context, customerIDs ...uuid.UUID) error {
+	return nil
+}
+
+// customerDirectory serves customers by ID. Calls to any other repository method panic.
+type customerDirectory struct {
+	customerrepository.CustomerRepository
+	customers map[uuid.UUID]*customermodels.Customer
+}
+
+func (d *customerDirectory) GetByID(ctx context.Context, id uuid.UUID) (*customermodels.Customer, error) {
+	if customer, ok := d.customers[id]; ok {
+		return customer, nil
+	}
+	return nil, customermodels.ErrCustomerNotFound
+}
+
+func (d *customerDirectory) ListByIDs(ctx context.Context, ids []uuid.UUID) ([]customermodels.Customer, error) {
+	var customers []customermodels.Customer
+	for _, id := range ids {
+		if customer, ok := d.customers[id]; ok {
+			customers = append(customers, *customer)
+		}
+	}
+	return customers, nil
+}
+
+// discard drops audit entries and events, and runs transactions directly
+type discard struct{}
+
+func (discard) Record(ctx context.Context, action string, customerID uuid.UUID, changes []audit.Change) error {
+	return nil
+}
+
+func (discard) RecordAccess(ctx context.Context, action string, customerIDs []uuid.UUID) error {
+	return nil
+}
+
+func (discard) Append(ctx context.Context, event *events.Event) error {
+	return nil
+}
+
+func (discard) WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
+
+// party is a customer of the tests; born is a date of birth, or empty for an
+// organization
+func party(born string) *customermodels.Customer {
+	customer := &customermodels.Customer{ID: uuid.New(), Type: customermodels.CustomerTypeIndividual, Status: customermodels.CustomerStatusActive}
+	if born == "" {
+		customer.Type = customermodels.CustomerTypeOrganization
+		return customer
+	}
+	dateOfBirth, _ := time.Parse("2006-01-02", born)
+	customer.DateOfBirth = &dateOfBirth
+	return customer
+}
+
+// newTestService returns a service over the given customers and relationships
+func newTestService(customers []*customermodels.Customer, relationships ...models.Relationship) (*relationshipService, *memoryRelationships) {
+	repo := &memoryRelationships{relationships: relationships}
+	directory := &customerDirectory{customers: make(map[uuid.UUID]*customermodels.Customer)}
+	for _, customer := range customers {
+		directory.customers[customer.ID] = customer
+	}
+	return &relationshipService{repo: repo, customers: directory, outbox: discard{}, audit: discard{}, tx: discard{}}, repo
+}
+
+func date(value string) *time.Time {
+	d, _ := time.Parse("2006-01-02", value)
+	return &d
+}
+
+func TestCreateRelationship(t *testing.T) {
+	parent, otherParent := party("1980-01-01"), party("1982-01-01")
+	child, teen := party("2015-06-01"), party("2008-03-01")
+	partner, stranger := party("1985-01-01"), party("1990-01-01")
+	company := party("")
+	closed := party("1975-01-01")
+	closed.Status = customermodels.CustomerStatusClosed
+	customers := []*customermodels.Customer{parent, otherParent, child, teen, partner, stranger, company, closed}
+
+	link := func(from, to *customermodels.Customer, relType models.Type, effectiveFrom string, effectiveTo string) models.Relationship {
+		relationship := models.Relationship{ID: uuid.New(), FromCustomerID: from.ID, ToCustomerID: to.ID, Type: relType, EffectiveFrom: *date(effectiveFrom)}
+		if effectiveTo != "" {
+			relationship.EffectiveTo = date(effectiveTo)
+		}
+		return relationship
+	}
+	request := func(to *customermodels.Customer, relType models.Type, effectiveFrom, effectiveTo string) models.CreateRequest {
+		req := models.CreateRequest{RelatedCustomerID: to.ID, Type: relType, EffectiveFrom: date(effectiveFrom)}
+		if effectiveTo != "" {
+			req.EffectiveTo = date(effectiveTo)
+		}
+		return req
+	}
+
+	tests := []struct {
+		name     string
+		existing []models.Relationship
+		from     *customermodels.Customer
+		req      models.CreateRequest
+		wantErr  error
+		field    string
+	}{
+		{
+			name: "guardian of a minor",
+			from: parent, req: request(child, models.TypeGuardianOf, "2020-01-01", ""),
+		},
+		{
+			name: "minor of a guardian",
+			from: child, req: request(parent, models.TypeMinorOf, "2020-01-01", ""),
+		},
+		{
+			name:     "guardianship already recorded the other way round",
+			existing: []models.Relationship{link(child, parent, models.TypeMinorOf, "2016-01-01", "")},
+			from:     parent, req: request(child, models.TypeGuardianOf, "2020-01-01", ""),
+			wantErr: models.ErrRelationshipExists,
+		},
+		{
+			name:     "spouse after the previous marriage ended",
+			existing: []models.Relationship{link(parent, stranger, models.TypeSpouse, "2005-01-01", "2010-01-01")},
+			from:     parent, req: request(partner, models.TypeSpouse, "2010-01-01", ""),
+		},
+		{
+			name:     "second spouse at once",
+			existing: []models.Relationship{link(stranger, parent, models.TypeSpouse, "2005-01-01", "")},
+			from:     partner, req: request(parent, models.TypeSpouse, "2010-01-01", ""),
+			wantErr: models.ErrRelationshipExists,
+		},
+		{
+			name:     "future link overlapping an open-ended one",
+			existing: []models.Relationship{link(parent, company, models.TypeAuthorizedSignatory, "2030-01-01", "")},
+			from:     parent, req: request(company, models.TypeAuthorizedSignatory, "2020-01-01", "2030-01-02"),
+			wantErr: models.ErrRelationshipExists,
+		},
+		{
+			name:     "link ending the day the next starts",
+			existing: []models.Relationship{link(parent, company, models.TypeAuthorizedSignatory, "2030-01-01", "")},
+			from:     parent, req: request(company, models.TypeAuthorizedSignatory, "2020-01-01", "2030-01-01"),
+		},
+		{
+			name:     "minor with a guardian",
+			existing: []models.Relationship{link(parent, teen, models.TypeGuardianOf, "2008-03-01", "")},
+			from:     teen, req: request(otherParent, models.TypeHouseholdMember, "2020-01-01", ""),
+		},
+		{
+			name: "minor without a guardian",
+			from: teen, req: request(otherParent, models.TypeHouseholdMember, "2020-01-01", ""),
+			wantErr: models.ErrGuardianRequired,
+		},
+		{
+			name:     "minor whose guardianship has ended",
+			existing: []models.Relationship{link(parent, teen, models.TypeGuardianOf, "2008-03-01", "2019-01-01")},
+			from:     otherParent, req: request(teen, models.TypeHouseholdMember, "2020-01-01", ""),
+			wantErr: models.ErrGuardianRequired,
+		},
+		{
+			name: "adult needing no guardian",
+			from: teen, req: request(otherParent, models.TypeHouseholdMember, "2026-03-01", ""),
+		},
+		{
+			name: "link to itself",
+			from: parent, req: request(parent, models.TypeSpouse, "2020-01-01", ""),
+			field: "related_customer_id",
+		},
+		{
+			name: "end before start",
+			from: parent, req: request(partner, models.TypeSpouse, "2020-01-01", "2020-01-01"),
+			field: "effective_to",
+		},
+		{
+			name: "closed customer",
+			from: parent, req: request(closed, models.TypeHouseholdMember, "2020-01-01", ""),
+			field: "related_customer_id",
+		},
+		{
+			name: "link held by an organization",
+			from: company, req: request(parent, models.TypePowerOfAttorney, "2020-01-01", ""),
+			field: "type",
+		},
+		{
+			name: "organization as a spouse",
+			from: parent, req: request(company, models.TypeSpouse, "2020-01-01", ""),
+			field: "related_customer_id",
+		},
+		{
+			name: "minor as a spouse",
+			from: partner, req: request(teen, models.TypeSpouse, "2020-01-01", ""),
+			field: "type",
+		},
+		{
+			name: "minor as a guardian",
+			from: teen, req: request(child, models.TypeGuardianOf, "2020-01-01", ""),
+			field: "type",
+		},
+		{
+			name: "adult as a minor",
+			from: parent, req: request(otherParent, models.TypeMinorOf, "2020-01-01", ""),
+			field: "type",
+		},
+		{
+			name: "minor as a signatory",
+			from: teen, req: request(company, models.TypeAuthorizedSignatory, "2020-01-01", ""),
+			field: "type",
+		},
+		{
+			name: "unknown customer",
+			from: parent, req: request(party("1970-01-01"), models.TypeSpouse, "2020-01-01", ""),
+			wantErr: customermodels.ErrCustomerNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, repo := newTestService(customers, tt.existing...)
+			relationship, err := s.CreateRelationship(context.Background(), tt.from.ID, tt.req, "user-1")
+
+			switch {
+			case tt.field != "":
+				var verr *customermodels.ValidationError
+				if !errors.As(err, &verr) || len(verr.Fields) != 1 || verr.Fields[0].Field != tt.field {
+					t.Errorf("CreateRelationship() error = %v, want a validation error on %s", err, tt.field)
+				}
+			case tt.wantErr != nil:
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("CreateRelationship() error = %v, want %v", err, tt.wantErr)
+				}
+			case err != nil:
+				t.Errorf("CreateRelationship() error = %v", err)
+			case relationship.FromCustomerID != tt.from.ID || relationship.ToCustomerID != tt.req.RelatedCustomerID:
+				t.Errorf("CreateRelationship() = %+v, want a link from %s to %s", relationship, tt.from.ID, tt.req.RelatedCustomerID)
+			}
+
+			want := 0
+			if tt.field == "" && tt.wantErr == nil {
+				want = 1
+			}
+			if created := len(repo.relationships) - len(tt.existing); created != want {
+				t.Errorf("created %d relationships, want %d", created, want)
+			}
+		})
+	}
+}
+
+func TestEndGuardianship(t *testing.T) {
+	parent, otherParent, child := party("1980-01-01"), party("1982-01-01"), party("2015-06-01")
+	guardianship := models.Relationship{
+		ID: uuid.New(), FromCustomerID: parent.ID, ToCustomerID: child.ID, Type: models.TypeGuardianOf, EffectiveFrom: *date("2015-06-01"),
+	}
+	other := models.Relationship{
+		ID: uuid.New(), FromCustomerID: child.ID, ToCustomerID: otherParent.ID, Type: models.TypeMinorOf, EffectiveFrom: *date("2015-06-01"),
+	}
+	otherEnding := other
+	otherEnding.EffectiveTo = date("2025-01-01")
+
+	tests := []struct {
+		name     string
+		existing []models.Relationship
+		end      string
+		wantErr  error
+	}{
+		{name: "only guardian", existing: []models.Relationship{guardianship}, end: "2025-01-01", wantErr: models.ErrGuardianRequired},
+		{name: "another guardian remains", existing: []models.Relationship{guardianship, other}, end: "2025-01-01"},
+		{name: "other guardianship ended first", existing: []models.Relationship{guardianship, otherEnding}, end: "2025-01-01", wantErr: models.ErrGuardianRequired},
+		{name: "minor has come of age", existing: []models.Relationship{guardianship}, end: "2033-06-01"},
+		{name: "end before the start", existing: []models.Relationship{guardianship, other}, end: "2015-05-31", wantErr: &customermodels.ValidationError{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, repo := newTestService([]*customermodels.Customer{parent, otherParent, child}, tt.existing...)
+			_, err := s.EndRelationship(context.Background(), guardianship.ID, models.EndRequest{EffectiveTo: date(tt.end)}, "user-1")
+
+			var verr *customermodels.ValidationError
+			switch want := tt.wantErr.(type) {
+			case nil:
+				if err != nil {
+					t.Fatalf("EndRelationship() error = %v", err)
+				}
+				if ended, _ := repo.Get(context.Background(), guardianship.ID); ended.EffectiveTo == nil || !ended.EffectiveTo.Equal(*date(tt.end)) {
+					t.Errorf("relationship ends %v, want %s", ended.EffectiveTo, tt.end)
+				}
+			case *customermodels.ValidationError:
+				if !errors.As(err, &verr) {
+					t.Errorf("EndRelationship() error = %v, want a validation error", err)
+				}
+			default:
+				if !errors.Is(err, want) {
+					t.Errorf("EndRelationship() error = %v, want %v", err, want)
+				}
+			}
+		})
+	}
+}
+
+func TestGetGraphCycles(t *testing.T) {
+	a, b, c, d, e := party("1970-01-01"), party("1971-01-01"), party("1972-01-01"), party("1973-01-01"), party("1974-01-01")
+	link := func(from, to *customermodels.Customer, relType models.Type) models.Relationship {
+		return models.Relationship{ID: uuid.New(), FromCustomerID: from.ID, ToCustomerID: to.ID, Type: relType, EffectiveFrom: *date("2020-01-01")}
+	}
+	// a, b and c form a cycle; d hangs off c, and e off d
+	relationships := []models.Relationship{
+		link(a, b, models.TypeHouseholdMember),
+		link(b, c, models.TypeHouseholdMember),
+		link(c, a, models.TypeHouseholdMember),
+		link(a, b, models.TypeSpouse),
+		link(c, d, models.TypePowerOfAttorney),
+		link(d, e, models.TypeAuthorizedSignatory),
+	}
+	s, _ := newTestService([]*customermodels.Customer{a, b, c, d, e}, relationships...)
+
+	tests := []struct {
+		name       string
+		depth      int
+		wantDepths map[uuid.UUID]int
+		wantEdges  int
+	}{
+		// The link between b and c is only followed from b or c, one step further
+		{name: "default depth", wantDepths: map[uuid.UUID]int{a.ID: 0, b.ID: 1, c.ID: 1}, wantEdges: 3},
+		{name: "one step past the cycle", depth: 2, wantDepths: map[uuid.UUID]int{a.ID: 0, b.ID: 1, c.ID: 1, d.ID: 2}, wantEdges: 5},
+		{name: "deeper than the graph", depth: models.MaxGraphDepth, wantDepths: map[uuid.UUID]int{a.ID: 0, b.ID: 1, c.ID: 1, d.ID: 2, e.ID: 3}, wantEdges: 6},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			graph, err := s.GetGraph(context.Background(), a.ID, models.GraphQuery{Depth: tt.depth, AsOf: date("2024-01-01")})
+			if err != nil {
+				t.Fatalf("GetGraph() error = %v", err)
+			}
+
+			depths := make(map[uuid.UUID]int)
+			for _, node := range graph.Nodes {
+				if _, ok := depths[node.CustomerID]; ok {
+					t.Errorf("customer %s appears twice", node.CustomerID)
+				}
+				depths[node.CustomerID] = node.Depth
+			}
+			if len(depths) != len(tt.wantDepths) {
+				t.Errorf("graph has %d customers, want %d", len(depths), len(tt.wantDepths))
+			}
+			for id, want := range tt.wantDepths {
+				if got, ok := depths[id]; !ok || got != want {
+					t.Errorf("customer %s at depth %d (found %v), want %d", id, got, ok, want)
+				}
+			}
+
+			seen := make(map[uuid.UUID]bool)
+			for _, edge := range graph.Edges {
+				if seen[edge.ID] {
+					t.Errorf("edge %s appears twice", edge.ID)
+				}
+				seen[edge.ID] = true
+			}
+			if len(graph.Edges) != tt.wantEdges {
+				t.Errorf("graph has %d edges, want %d", len(graph.Edges), tt.wantEdges)
+			}
+		})
+	}
+
+	for _, depth := range []int{-1, models.MaxGraphDepth + 1} {
+		var verr *customermodels.ValidationError
+		if _, err := s.GetGraph(context.Background(), a.ID, models.GraphQuery{Depth: depth}); !errors.As(err, &verr) {
+			t.Errorf("GetGraph() at depth %d error = %v, want a validation error", depth, err)
+		}
+	}
+}
@@ -0,0 +1,253 @@
+package controllers
+
+import (
+	customermodels "customer-service/internal/customer/models"
+	privacymodels "customer-service/internal/privacy/models"
+	"customer-service/internal/retention/models"
+	"customer-service/internal/retention/service"
+	"customer-service/pkg/apierror"
+	"customer-service/pkg/middleware"
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// RetentionController handles HTTP requests for retention policies, legal holds,
+// restores and retention runs
+type RetentionController struct {
+	service service.RetentionService
+}
+
+// NewRetentionController creates a new retention controller instance
+func NewRetentionController(retentionService service.RetentionService) *RetentionController {
+	return &RetentionController{
+		service: retentionService,
+	}
+}
+
+// ListPolicies handles GET /retention/policies
+func (ctrl *RetentionController) ListPolicies(c *gin.Context) {
+	policies, err := ctrl.service.ListPolicies(c.Request.Context())
+	if err != nil {
+		ctrl.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, policies)
+}
+
+// SetPolicy handles PUT /retention/policies/:status
+func (ctrl *RetentionController) SetPolicy(c *gin.Context) {
+	var req models.PolicyRequest
+	if !ctrl.bindJSON(c, &req) {
+		return
+	}
+
+	status := customermodels.CustomerStatus(c.Param("status"))
+	policy, err := ctrl.service.SetPolicy(c.Request.Context(), status, req, actorFromContext(c))
+	if err != nil {
+		ctrl.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, policy)
+}
+
+// DeletePolicy handles DELETE /retention/policies/:status
+func (ctrl *RetentionController) DeletePolicy(c *gin.Context) {
+	status := customermodels.CustomerStatus(c.Param("status"))
+	if err := ctrl.service.DeletePolicy(c.Request.Context(), status); err != nil {
+		ctrl.handleError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// GetCustomerRetention handles GET /customers/:id/retention
+func (ctrl *RetentionController) GetCustomerRetention(c *gin.Context) {
+	id, ok := ctrl.parseUUID(c, "id")
+	if !ok {
+		return
+	}
+
+	retention, err := ctrl.service.GetCustomerRetention(c.Request.Context(), id)
+	if err != nil {
+		ctrl.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, retention)
+}
+
+// SetLegalHold handles PUT /customers/:id/legal-hold
+func (ctrl *RetentionController) SetLegalHold(c *gin.Context) {
+	id, ok := ctrl.parseUUID(c, "id")
+	if !ok {
+		return
+	}
+
+	var req models.LegalHoldRequest
+	if !ctrl.bindJSON(c, &req) {
+		return
+	}
+
+	retention, err := ctrl.service.SetLegalHold(c.Request.Context(), id, req, actorFromContext(c))
+	if err != nil {
+		ctrl.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, retention)
+}
+
+// RestoreCustomer handles POST /customers/:id/restore
+func (ctrl *RetentionController) RestoreCustomer(c *gin.Context) {
+	id, ok := ctrl.parseUUID(c, "id")
+	if !ok {
+		return
+	}
+
+	customer, err := ctrl.service.RestoreCustomer(c.Request.Context(), id)
+	if err != nil {
+		ctrl.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, customer)
+}
+
+// StartRun handles POST /retention/runs
+func (ctrl *RetentionController) StartRun(c *gin.Context) {
+	var req models.StartRunRequest
+	if !ctrl.bindJSON(c, &req) {
+		return
+	}
+
+	run, err := ctrl.service.StartRun(c.Request.Context(), req, actorFromContext(c))
+	if err != nil {
+		ctrl.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusAccepted, run)
+}
+
+// ListRuns handles GET /retention/runs
+func (ctrl *RetentionController) ListRuns(c *gin.Context) {
+	var req models.RunListRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		apierror.Abort(c, http.StatusBadRequest, apierror.CodeInvalidRequest, "invalid query parameters: "+err.Error())
+		return
+	}
+
+	runs, err := ctrl.service.ListRuns(c.Request.Context(), req)
+	if err != nil {
+		ctrl.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, runs)
+}
+
+// GetRun handles GET /retention/runs/:id
+func (ctrl *RetentionController) GetRun(c *gin.Context) {
+	id, ok := ctrl.parseUUID(c, "id")
+	if !ok {
+		return
+	}
+
+	run, err := ctrl.service.GetRun(c.Request.Context(), id)
+	if err != nil {
+		ctrl.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, run)
+}
+
+// ListRunItems handles GET /retention/runs/:id/items
+func (ctrl *RetentionController) ListRunItems(c *gin.Context) {
+	id, ok := ctrl.parseUUID(c, "id")
+	if !ok {
+		return
+	}
+
+	var req models.ItemListRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		apierror.Abort(c, http.StatusBadRequest, apierror.CodeInvalidRequest, "invalid query parameters: "+err.Error())
+		return
+	}
+
+	items, err := ctrl.service.ListRunItems(c.Request.Context(), id, req)
+	if err != nil {
+		ctrl.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, items)
+}
+
+// bindJSON decodes and validates the request body, writing an error response on failure
+func (ctrl *RetentionController) bindJSON(c *gin.Context, req any) bool {
+	if err := c.ShouldBindJSON(req); err != nil {
+		apierror.Abort(c, http.StatusBadRequest, apierror.CodeInvalidRequest, "invalid request body: "+err.Error())
+		return false
+	}
+
+	if err := customermodels.ValidateStruct(req); err != nil {
+		ctrl.handleError(c, err)
+		return false
+	}
+
+	return true
+}
+
+// parseUUID parses a UUID path parameter, writing an error response on failure
+func (ctrl *RetentionController) parseUUID(c *gin.Context, param string) (uuid.UUID, bool) {
+	id, err := uuid.Parse(c.Param(param))
+	if err != nil {
+		apierror.Abort(c, http.StatusBadRequest, apierror.CodeInvalidRequest, "invalid "+param,
+			apierror.FieldError{Field: param, Message: "must be a valid UUID"})
+		return uuid.Nil, false
+	}
+	return id, true
+}
+
+// handleError maps service errors to HTTP responses
+func (ctrl *RetentionController) handleError(c *gin.Context, err error) {
+	var validationErr *customermodels.ValidationError
+	switch {
+	case errors.As(err, &validationErr):
+		fields := make([]apierror.FieldError, len(validationErr.Fields))
+		for i, f := range validationErr.Fields {
+			fields[i] = apierror.FieldError{Field: f.Field, Message: f.Message}
+		}
+		apierror.Abort(c, http.StatusBadRequest, apierror.CodeValidationFailed, "request validation failed", fields...)
+	case errors.Is(err, customermodels.ErrCustomerNotFound),
+		errors.Is(err, models.ErrPolicyNotFound),
+		errors.Is(err, models.ErrRunNotFound):
+		apierror.Abort(c, http.StatusNotFound, apierror.CodeNotFound, err.Error())
+	case errors.Is(err, models.ErrRunInProgress),
+		errors.Is(err, models.ErrCustomerNotDeleted),
+		errors.Is(err, models.ErrCustomerMerged),
+		errors.Is(err, models.ErrRestoreWindowExpired),
+		errors.Is(err, privacymodels.ErrCustomerErased):
+		apierror.Abort(c, http.StatusConflict, apierror.CodeConflict, err.Error())
+	default:
+		log.Printf("Unhandled error processing %s %s: %v", c.Request.Method, c.Request.URL.Path, err)
+		apierror.Abort(c, http.StatusInternalServerError, apierror.CodeInternal, "internal server error")
+	}
+}
+
+// actorFromContext returns the subject of the authenticated caller
+func actorFromContext(c *gin.Context) string {
+	claims, ok := middleware.GetClaims(c)
+	if !ok {
+		return ""
+	}
+	return claims.Subject
+}
@@ -0,0 +1,26 @@
+package models
+
+import (
+	customermodels "customer-service/internal/customer/models"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// CustomerRestoredPayload is the payload of a CustomerRestored event, emitted when a
+// deleted customer is restored within the restore window
+type CustomerRestoredPayload struct {
+	Customer   customermodels.CustomerResponse `json:"customer"`
+	RestoredAt time.Time                       `json:"restored_at"`
+}
+
+// CustomerPurgedPayload is the payload of a CustomerPurged event, emitted when a
+// deleted customer past its retention period was deleted outright or anonymized.
+// Consumers are expected to do the same with their own copies; the payloads of
+// earlier events about the customer are redacted.
+type CustomerPurgedPayload struct {
+	RunID     uuid.UUID   `json:"run_id"`
+	Action    Action      `json:"action"`
+	MergedIDs []uuid.UUID `json:"merged_ids,omitempty"`
+	PurgedAt  time.Time   `json:"purged_at"`
+}
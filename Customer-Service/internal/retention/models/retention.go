@@ -0,0 +1,209 @@
+package models
+
+import (
+	customermodels "customer-service/internal/customer/models"
+	privacymodels "customer-service/internal/privacy/models"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Domain errors returned by the retention repository and service layers
+var (
+	ErrPolicyNotFound       = errors.New("retention policy not found")
+	ErrRunNotFound          = errors.New("retention run not found")
+	ErrRunInProgress        = errors.New("a retention run is already in progress")
+	ErrCustomerNotDeleted   = errors.New("customer is not deleted")
+	ErrCustomerMerged       = errors.New("customer was merged into another customer and cannot be restored")
+	ErrRestoreWindowExpired = errors.New("customer was deleted longer ago than the restore window")
+)
+
+// Action is what happens to a deleted customer once its retention period has passed
+type Action string
+
+const (
+	// ActionDelete removes the customer and every record held on it
+	ActionDelete Action = "delete"
+	// ActionAnonymize clears the customer's PII in place, keeping the records needed
+	// for legal retention, as an erasure request does
+	ActionAnonymize Action = "anonymize"
+)
+
+// Policy is how long deleted customers of a status are kept before they are purged.
+// Customers of a status without a policy are kept indefinitely.
+type Policy struct {
+	Status        customermodels.CustomerStatus `json:"status" gorm:"primary_key;size:20"`
+	RetentionDays int                           `json:"retention_days" gorm:"not null"`
+	Action        Action                        `json:"action" gorm:"not null;size:20"`
+	UpdatedBy     string                        `json:"updated_by" gorm:"not null;size:255"`
+	CreatedAt     time.Time                     `json:"created_at"`
+	UpdatedAt     time.Time                     `json:"updated_at"`
+}
+
+// TableName returns the table name for Policy model
+func (Policy) TableName() string {
+	return "retention_policies"
+}
+
+// Retention returns the retention period of the policy
+func (p *Policy) Retention() time.Duration {
+	return time.Duration(p.RetentionDays) * 24 * time.Hour
+}
+
+// PolicyRequest represents the request payload for setting the retention policy of a
+// customer status
+type PolicyRequest struct {
+	RetentionDays int    `json:"retention_days" validate:"required,min=1,max=36500"`
+	Action        Action `json:"action" validate:"required,oneof=delete anonymize"`
+}
+
+// PolicyListResponse represents every retention policy in force
+type PolicyListResponse struct {
+	Policies []Policy `json:"policies"`
+}
+
+// CustomerRetention describes where a customer stands in its retention: whether it
+// may still be restored, when it becomes due for purging and whether a legal hold
+// keeps it
+type CustomerRetention struct {
+	CustomerID      uuid.UUID                     `json:"customer_id"`
+	Status          customermodels.CustomerStatus `json:"status"`
+	DeletedAt       *time.Time                    `json:"deleted_at,omitempty"`
+	ErasedAt        *time.Time                    `json:"erased_at,omitempty"`
+	MergedInto      *uuid.UUID                    `json:"merged_into,omitempty"`
+	LegalHold       bool                          `json:"legal_hold"`
+	LegalHoldReason string                        `json:"legal_hold_reason,omitempty"`
+	RestorableUntil *time.Time                    `json:"restorable_until,omitempty"`
+	Policy          *Policy                       `json:"policy,omitempty"`
+	PurgeAfter      *time.Time                    `json:"purge_after,omitempty"`
+}
+
+// LegalHoldRequest represents the request payload for placing or lifting a legal hold.
+// A reason is required to place one.
+type LegalHoldRequest struct {
+	LegalHold *bool  `json:"legal_hold" validate:"required"`
+	Reason    string `json:"reason" validate:"max=500"`
+}
+
+// RunStatus represents the progress of a retention run
+type RunStatus string
+
+const (
+	RunStatusRunning   RunStatus = "running"
+	RunStatusCompleted RunStatus = "completed"
+)
+
+// Run is a pass over the deleted customers past their retention period, processed in
+// batches by the background worker. A dry run only reports what it would purge, and
+// its Deleted and Anonymized count the customers it would have purged. Cursor is the
+// ID of the last customer examined.
+type Run struct {
+	ID         uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	DryRun     bool       `json:"dry_run" gorm:"not null"`
+	Status     RunStatus  `json:"status" gorm:"not null;size:20"`
+	StartedBy  string     `json:"started_by" gorm:"not null;size:255"`
+	Cursor     uuid.UUID  `json:"-" gorm:"type:uuid;not null"`
+	Examined   int        `json:"examined" gorm:"not null"`
+	Deleted    int        `json:"deleted" gorm:"not null"`
+	Anonymized int        `json:"anonymized" gorm:"not null"`
+	Held       int        `json:"held" gorm:"not null"`
+	Failed     int        `json:"failed" gorm:"not null"`
+	StartedAt  time.Time  `json:"started_at" gorm:"not null"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+}
+
+// TableName returns the table name for Run model
+func (Run) TableName() string {
+	return "retention_runs"
+}
+
+// StartRunRequest represents the request payload for starting a retention run.
+// DryRun must be given explicitly.
+type StartRunRequest struct {
+	DryRun *bool `json:"dry_run" validate:"required"`
+}
+
+// RunListRequest represents retention run query parameters
+type RunListRequest struct {
+	Page     int `form:"page"`
+	PageSize int `form:"page_size"`
+}
+
+// RunListResponse represents a page of retention runs, newest first
+type RunListResponse struct {
+	Runs       []Run `json:"runs"`
+	Total      int64 `json:"total"`
+	Page       int   `json:"page"`
+	PageSize   int   `json:"page_size"`
+	TotalPages int   `json:"total_pages"`
+}
+
+// Outcome is what a retention run did, or in a dry run would do, with a customer
+type Outcome string
+
+const (
+	OutcomeDeleted        Outcome = "deleted"
+	OutcomeAnonymized     Outcome = "anonymized"
+	OutcomeWouldDelete    Outcome = "would_delete"
+	OutcomeWouldAnonymize Outcome = "would_anonymize"
+	// OutcomeHeld marks a customer past retention that a legal hold keeps
+	OutcomeHeld   Outcome = "held"
+	OutcomeFailed Outcome = "failed"
+)
+
+// Item is the line of a retention run's report about one customer. MergedIDs are the
+// duplicate records merged into the customer, which are purged with it.
+type Item struct {
+	ID             uuid.UUID                     `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	RunID          uuid.UUID                     `json:"run_id" gorm:"type:uuid;not null"`
+	CustomerID     uuid.UUID                     `json:"customer_id" gorm:"type:uuid;not null"`
+	CustomerStatus customermodels.CustomerStatus `json:"customer_status" gorm:"not null;size:20"`
+	DeletedAt      time.Time                     `json:"deleted_at" gorm:"not null"`
+	Action         Action                        `json:"action" gorm:"not null;size:20"`
+	Outcome        Outcome                       `json:"outcome" gorm:"not null;size:20"`
+	MergedIDs      []uuid.UUID                   `json:"merged_ids,omitempty" gorm:"type:jsonb;serializer:json"`
+	Retained       []privacymodels.EvidenceItem  `json:"retained,omitempty" gorm:"type:jsonb;serializer:json"`
+	Error          string                        `json:"error,omitempty"`
+	CreatedAt      time.Time                     `json:"created_at"`
+}
+
+// TableName returns the table name for Item model
+func (Item) TableName() string {
+	return "retention_run_items"
+}
+
+// ItemListRequest represents retention report query parameters
+type ItemListRequest struct {
+	Outcome  Outcome `form:"outcome"`
+	Page     int     `form:"page"`
+	PageSize int     `form:"page_size"`
+}
+
+// ItemListResponse represents a page of a retention run's report, in customer order
+type ItemListResponse struct {
+	RunID      uuid.UUID `json:"run_id"`
+	Items      []Item    `json:"items"`
+	Total      int64     `json:"total"`
+	Page       int       `json:"page"`
+	PageSize   int       `json:"page_size"`
+	TotalPages int       `json:"total_pages"`
+}
+
+// Candidate is a deleted customer past the retention period of its status, as found
+// by a retention run. LegalHold is set when the customer, or a record merged into it,
+// is on legal hold.
+type Candidate struct {
+	ID        uuid.UUID
+	Status    customermodels.CustomerStatus
+	DeletedAt time.Time
+	LegalHold bool
+	Action    Action
+}
+
+// PurgedFiles are the stored files of customers deleted outright: KYC document files
+// and data subject export archives. They are removed once the deletion is committed.
+type PurgedFiles struct {
+	Documents []string
+	Exports   []string
+}
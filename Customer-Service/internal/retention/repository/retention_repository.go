@@ -0,0 +1,433 @@
+package repository
+
+import (
+	"context"
+	"customer-service/internal/audit"
+	consentmodels "customer-service/internal/consent/models"
+	customermodels "customer-service/internal/customer/models"
+	"customer-service/internal/database"
+	kycmodels "customer-service/internal/kyc/models"
+	privacymodels "customer-service/internal/privacy/models"
+	relationshipmodels "customer-service/internal/relationship/models"
+	"customer-service/internal/retention/models"
+	riskmodels "customer-service/internal/risk/models"
+	screeningmodels "customer-service/internal/screening/models"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// RetentionRepository defines the interface for retention data access: policies,
+// legal holds, restores of deleted customers and the runs that purge them
+type RetentionRepository interface {
+	ListPolicies(ctx context.Context) ([]models.Policy, error)
+	GetPolicy(ctx context.Context, status customermodels.CustomerStatus) (*models.Policy, error)
+	SavePolicy(ctx context.Context, policy *models.Policy) error
+	DeletePolicy(ctx context.Context, status customermodels.CustomerStatus) error
+
+	GetCustomer(ctx context.Context, id uuid.UUID) (*models.CustomerRetention, error)
+	LockCustomer(ctx context.Context, id uuid.UUID) (*models.CustomerRetention, error)
+	SetLegalHold(ctx context.Context, id uuid.UUID, hold bool, reason string) error
+	Restore(ctx context.Context, id uuid.UUID, at time.Time) error
+
+	CreateRun(ctx context.Context, run *models.Run) error
+	GetRun(ctx context.Context, id uuid.UUID) (*models.Run, error)
+	GetLatestRun(ctx context.Context, startedBy string) (*models.Run, error)
+	ListRuns(ctx context.Context, req models.RunListRequest) ([]models.Run, int64, error)
+	ClaimRun(ctx context.Context) (*models.Run, error)
+	UpdateRun(ctx context.Context, run *models.Run) error
+	ListCandidates(ctx context.Context, run *models.Run, restoreWindow time.Duration, limit int) ([]models.Candidate, error)
+	CreateItems(ctx context.Context, items []models.Item) error
+	ListItems(ctx context.Context, runID uuid.UUID, req models.ItemListRequest) ([]models.Item, int64, error)
+	Purge(ctx context.Context, ids []uuid.UUID) (*models.PurgedFiles, []privacymodels.EvidenceItem, error)
+}
+
+type retentionRepository struct {
+	db *gorm.DB
+}
+
+// NewRetentionRepository creates a new retention repository instance
+func NewRetentionRepository(db *gorm.DB) RetentionRepository {
+	return &retentionRepository{db: db}
+}
+
+// redactedPayload replaces the payload of events about a purged customer
+const redactedPayload = `{"redacted": true}`
+
+// retainedForAudit is recorded for the audit entries a purge keeps
+const retainedForAudit = "append-only, tamper-evident audit trail, which records that personal data changed but never its values"
+
+// customerRow is the retention state of a customer as read from the database
+type customerRow struct {
+	CustomerID      uuid.UUID
+	Status          customermodels.CustomerStatus
+	DeletedAt       *time.Time
+	ErasedAt        *time.Time
+	MergedInto      *uuid.UUID
+	LegalHold       bool
+	LegalHoldReason string
+}
+
+// customerQuery reads the retention state of a customer, including deleted ones
+const customerQuery = `SELECT c.id AS customer_id, c.status, c.deleted_at, c.erased_at,
+		m.survivor_id AS merged_into, c.legal_hold, COALESCE(c.legal_hold_reason, '') AS legal_hold_reason
+	FROM customers c
+	LEFT JOIN customer_merges m ON m.merged_id = c.id
+	WHERE c.id = ?`
+
+// ListPolicies returns every retention policy, in status order
+func (r *retentionRepository) ListPolicies(ctx context.Context) ([]models.Policy, error) {
+	var policies []models.Policy
+	if err := database.Conn(ctx, r.db).Order("status").Find(&policies).Error; err != nil {
+		return nil, fmt.Errorf("failed to list retention policies: %w", err)
+	}
+	return policies, nil
+}
+
+// GetPolicy retrieves the retention policy of a customer status
+func (r *retentionRepository) GetPolicy(ctx context.Context, status customermodels.CustomerStatus) (*models.Policy, error) {
+	var policy models.Policy
+	if err := database.Conn(ctx, r.db).Where("status = ?", status).First(&policy).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, models.ErrPolicyNotFound
+		}
+		return nil, fmt.Errorf("failed to get retention policy: %w", err)
+	}
+	return &policy, nil
+}
+
+// SavePolicy creates the retention policy of a status or replaces the existing one
+func (r *retentionRepository) SavePolicy(ctx context.Context, policy *models.Policy) error {
+	err := database.Conn(ctx, r.db).
+		Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "status"}},
+			DoUpdates: clause.AssignmentColumns([]string{"retention_days", "action", "updated_by", "updated_at"}),
+		}).
+		Create(policy).Error
+	if err != nil {
+		return fmt.Errorf("failed to save retention policy: %w", err)
+	}
+	return nil
+}
+
+// DeletePolicy removes the retention policy of a status
+func (r *retentionRepository) DeletePolicy(ctx context.Context, status customermodels.CustomerStatus) error {
+	result := database.Conn(ctx, r.db).Where("status = ?", status).Delete(&models.Policy{})
+	if result.Error != nil {
+		return fmt.Errorf("failed to delete retention policy: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return models.ErrPolicyNotFound
+	}
+	return nil
+}
+
+// GetCustomer retrieves the retention state of a customer, which may be deleted
+func (r *retentionRepository) GetCustomer(ctx context.Context, id uuid.UUID) (*models.CustomerRetention, error) {
+	return r.customer(ctx, customerQuery, id)
+}
+
+// LockCustomer retrieves the retention state of a customer like GetCustomer and
+// locks its row until the transaction in ctx ends
+func (r *retentionRepository) LockCustomer(ctx context.Context, id uuid.UUID) (*models.CustomerRetention, error) {
+	return r.customer(ctx, customerQuery+" FOR UPDATE OF c", id)
+}
+
+// customer runs a customer state query
+func (r *retentionRepository) customer(ctx context.Context, query string, id uuid.UUID) (*models.CustomerRetention, error) {
+	var rows []customerRow
+	if err := database.Conn(ctx, r.db).Raw(query, id).Scan(&rows).Error; err != nil {
+		return nil, fmt.Errorf("failed to get customer retention: %w", err)
+	}
+	if len(rows) == 0 {
+		return nil, customermodels.ErrCustomerNotFound
+	}
+	row := rows[0]
+	return &models.CustomerRetention{
+		CustomerID:      row.CustomerID,
+		Status:          row.Status,
+		DeletedAt:       row.DeletedAt,
+		ErasedAt:        row.ErasedAt,
+		MergedInto:      row.MergedInto,
+		LegalHold:       row.LegalHold,
+		LegalHoldReason: row.LegalHoldReason,
+	}, nil
+}
+
+// SetLegalHold places or lifts the legal hold of a customer, which may be deleted
+func (r *retentionRepository) SetLegalHold(ctx context.Context, id uuid.UUID, hold bool, reason string) error {
+	var holdReason interface{}
+	if hold {
+		holdReason = reason
+	}
+	result := database.Conn(ctx, r.db).Unscoped().Model(&customermodels.Customer{}).
+		Where("id = ?", id).
+		UpdateColumns(map[string]interface{}{
+			"legal_hold":        hold,
+			"legal_hold_reason": holdReason,
+		})
+	if result.Error != nil {
+		return fmt.Errorf("failed to set legal hold: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return customermodels.ErrCustomerNotFound
+	}
+	return nil
+}
+
+// Restore undoes the soft delete of a customer
+func (r *retentionRepository) Restore(ctx context.Context, id uuid.UUID, at time.Time) error {
+	result := database.Conn(ctx, r.db).Unscoped().Model(&customermodels.Customer{}).
+		Where("id = ? AND deleted_at IS NOT NULL", id).
+		UpdateColumns(map[string]interface{}{
+			"deleted_at": nil,
+			"updated_at": at,
+		})
+	if result.Error != nil {
+		return fmt.Errorf("failed to restore customer: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return models.ErrCustomerNotDeleted
+	}
+	return nil
+}
+
+// CreateRun starts a run. It returns ErrRunInProgress when another run is still
+// running, which a partial unique index enforces.
+func (r *retentionRepository) CreateRun(ctx context.Context, run *models.Run) error {
+	if err := database.Conn(ctx, r.db).Create(run).Error; err != nil {
+		if strings.Contains(err.Error(), "duplicate key") {
+			return models.ErrRunInProgress
+		}
+		return fmt.Errorf("failed to create retention run: %w", err)
+	}
+	return nil
+}
+
+// GetRun retrieves a run by ID
+func (r *retentionRepository) GetRun(ctx context.Context, id uuid.UUID) (*models.Run, error) {
+	var run models.Run
+	if err := database.Conn(ctx, r.db).Where("id = ?", id).First(&run).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, models.ErrRunNotFound
+		}
+		return nil, fmt.Errorf("failed to get retention run: %w", err)
+	}
+	return &run, nil
+}
+
+// GetLatestRun retrieves the last run started by startedBy. It returns
+// ErrRunNotFound when there is none.
+func (r *retentionRepository) GetLatestRun(ctx context.Context, startedBy string) (*models.Run, error) {
+	var run models.Run
+	err := database.Conn(ctx, r.db).
+		Where("started_by = ?", startedBy).
+		Order("started_at DESC").
+		Take(&run).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, models.ErrRunNotFound
+		}
+		return nil, fmt.Errorf("failed to get latest retention run: %w", err)
+	}
+	return &run, nil
+}
+
+// ListRuns returns a page of runs, newest first
+func (r *retentionRepository) ListRuns(ctx context.Context, req models.RunListRequest) ([]models.Run, int64, error) {
+	query := database.Conn(ctx, r.db).Model(&models.Run{})
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to count retention runs: %w", err)
+	}
+
+	var runs []models.Run
+	offset := (req.Page - 1) * req.PageSize
+	if err := query.Order("started_at DESC").Offset(offset).Limit(req.PageSize).Find(&runs).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to list retention runs: %w", err)
+	}
+	return runs, total, nil
+}
+
+// ClaimRun locks the running run until the surrounding transaction ends, unless
+// another instance is processing it. It returns ErrRunNotFound when there is
+// nothing to do.
+func (r *retentionRepository) ClaimRun(ctx context.Context) (*models.Run, error) {
+	var run models.Run
+	err := database.Conn(ctx, r.db).
+		Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+		Where("status = ?", models.RunStatusRunning).
+		Take(&run).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, models.ErrRunNotFound
+		}
+		return nil, fmt.Errorf("failed to claim retention run: %w", err)
+	}
+	return &run, nil
+}
+
+// UpdateRun stores the progress of a run
+func (r *retentionRepository) UpdateRun(ctx context.Context, run *models.Run) error {
+	err := database.Conn(ctx, r.db).Model(run).
+		Select("status", "cursor", "examined", "deleted", "anonymized", "held", "failed", "finished_at").
+		Updates(run).Error
+	if err != nil {
+		return fmt.Errorf("failed to update retention run: %w", err)
+	}
+	return nil
+}
+
+// ListCandidates returns the next deleted customers after the run's cursor, in ID
+// order, that were deleted longer ago than both the retention period of their status
+// and the restore window, measured from the start of the run. Records merged into a
+// surviving customer are left out; they are purged with the survivor. Customers a
+// policy anonymizes are left out once erased. The rows stay locked until the
+// transaction in ctx ends, so they cannot be restored or placed on hold meanwhile.
+func (r *retentionRepository) ListCandidates(ctx context.Context, run *models.Run, restoreWindow time.Duration, limit int) ([]models.Candidate, error) {
+	var candidates []models.Candidate
+	err := database.Conn(ctx, r.db).Raw(`SELECT c.id, c.status, c.deleted_at, p.action,
+			c.legal_hold OR EXISTS (
+				SELECT 1 FROM customer_merges m JOIN customers d ON d.id = m.merged_id
+				WHERE m.survivor_id = c.id AND d.legal_hold
+			) AS legal_hold
+		FROM customers c
+		JOIN retention_policies p ON p.status = c.status
+		WHERE c.deleted_at IS NOT NULL
+			AND c.id > ?
+			AND c.deleted_at < ?
+			AND c.deleted_at < ?::timestamptz - make_interval(days => p.retention_days)
+			AND NOT EXISTS (SELECT 1 FROM customer_merges m WHERE m.merged_id = c.id)
+			AND (p.action = ? OR c.erased_at IS NULL)
+		ORDER BY c.id
+		LIMIT ?
+		FOR UPDATE OF c`,
+		run.Cursor, run.StartedAt.Add(-restoreWindow), run.StartedAt, models.ActionDelete, limit,
+	).Scan(&candidates).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list retention candidates: %w", err)
+	}
+	return candidates, nil
+}
+
+// CreateItems adds lines to the report of a run
+func (r *retentionRepository) CreateItems(ctx context.Context, items []models.Item) error {
+	if len(items) == 0 {
+		return nil
+	}
+	if err := database.Conn(ctx, r.db).Create(&items).Error; err != nil {
+		return fmt.Errorf("failed to record retention run items: %w", err)
+	}
+	return nil
+}
+
+// ListItems returns a page of the report of a run, in customer order
+func (r *retentionRepository) ListItems(ctx context.Context, runID uuid.UUID, req models.ItemListRequest) ([]models.Item, int64, error) {
+	query := database.Conn(ctx, r.db).Model(&models.Item{}).Where("run_id = ?", runID)
+	if req.Outcome != "" {
+		query = query.Where("outcome = ?", req.Outcome)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to count retention run items: %w", err)
+	}
+
+	var items []models.Item
+	offset := (req.Page - 1) * req.PageSize
+	if err := query.Order("customer_id").Offset(offset).Limit(req.PageSize).Find(&items).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to list retention run items: %w", err)
+	}
+	return items, total, nil
+}
+
+// Purge deletes customers outright, with every record held on them and every link
+// other customers have to them, redacts the payloads of their events and deletes the
+// responses stored for idempotent requests about them. It returns the stored files
+// of the customers, for the caller to remove once the transaction in ctx commits, and
+// the records kept: the audit trail is append-only and keeps its entries, which hold
+// no personal data.
+func (r *retentionRepository) Purge(ctx context.Context, ids []uuid.UUID) (*models.PurgedFiles, []privacymodels.EvidenceItem, error) {
+	db := database.Conn(ctx, r.db)
+
+	files := &models.PurgedFiles{}
+	if err := db.Model(&kycmodels.Document{}).Where("customer_id IN ?", ids).Pluck("storage_key", &files.Documents).Error; err != nil {
+		return nil, nil, fmt.Errorf("failed to list KYC document files: %w", err)
+	}
+	if err := db.Model(&privacymodels.Request{}).
+		Where("customer_id IN ? AND export_key <> ''", ids).
+		Pluck("export_key", &files.Exports).Error; err != nil {
+		return nil, nil, fmt.Errorf("failed to list exports: %w", err)
+	}
+	var audited int64
+	if err := db.Model(&audit.Entry{}).Where("customer_id IN ?", ids).Count(&audited).Error; err != nil {
+		return nil, nil, fmt.Errorf("failed to count audit entries: %w", err)
+	}
+
+	// Rows referring to others go first
+	steps := []struct {
+		what   string
+		result func() *gorm.DB
+	}{
+		{"KYC decisions", func() *gorm.DB {
+			return db.Where("case_id IN (?)", db.Model(&kycmodels.Case{}).Select("id").Where("customer_id IN ?", ids)).
+				Delete(&kycmodels.Decision{})
+		}},
+		{"KYC documents", func() *gorm.DB { return db.Where("customer_id IN ?", ids).Delete(&kycmodels.Document{}) }},
+		{"KYC cases", func() *gorm.DB { return db.Where("customer_id IN ?", ids).Delete(&kycmodels.Case{}) }},
+		{"screening alerts", func() *gorm.DB { return db.Where("customer_id IN ?", ids).Delete(&screeningmodels.Alert{}) }},
+		{"risk assessments", func() *gorm.DB { return db.Where("customer_id IN ?", ids).Delete(&riskmodels.Assessment{}) }},
+		{"status history", func() *gorm.DB {
+			return db.Where("customer_id IN ?", ids).Delete(&customermodels.CustomerStatusHistory{})
+		}},
+		{"addresses", func() *gorm.DB { return db.Where("customer_id IN ?", ids).Delete(&customermodels.CustomerAddress{}) }},
+		{"contact points", func() *gorm.DB { return db.Where("customer_id IN ?", ids).Delete(&customermodels.ContactPoint{}) }},
+		{"consent records", func() *gorm.DB { return db.Where("customer_id IN ?", ids).Delete(&consentmodels.Record{}) }},
+		{"consents", func() *gorm.DB { return db.Where("customer_id IN ?", ids).Delete(&consentmodels.Consent{}) }},
+		{"data subject requests", func() *gorm.DB {
+			return db.Where("customer_id IN ?", ids).Delete(&privacymodels.Request{})
+		}},
+		{"beneficial ownerships", func() *gorm.DB {
+			return db.Where("organization_id IN ? OR owner_id IN ?", ids, ids).Delete(&customermodels.BeneficialOwner{})
+		}},
+		{"relationships", func() *gorm.DB {
+			return db.Where("from_customer_id IN ? OR to_customer_id IN ?", ids, ids).Delete(&relationshipmodels.Relationship{})
+		}},
+		{"merges", func() *gorm.DB {
+			return db.Where("merged_id IN ? OR survivor_id IN ?", ids, ids).Delete(&customermodels.CustomerMerge{})
+		}},
+		{"customers", func() *gorm.DB { return db.Unscoped().Where("id IN ?", ids).Delete(&customermodels.Customer{}) }},
+		{"webhook deliveries", func() *gorm.DB {
+			return db.Exec(`UPDATE webhook_deliveries SET payload = jsonb_set(payload, '{payload}', ?::jsonb)
+				WHERE event_id IN (SELECT id FROM outbox_events WHERE aggregate_id IN ?)`, redactedPayload, ids)
+		}},
+		{"events", func() *gorm.DB {
+			return db.Exec("UPDATE outbox_events SET payload = ?::jsonb WHERE aggregate_id IN ?", redactedPayload, ids)
+		}},
+		{"idempotent responses", func() *gorm.DB {
+			resources := make([]string, len(ids))
+			for i, id := range ids {
+				resources[i] = id.String()
+			}
+			return db.Exec("DELETE FROM idempotency_keys WHERE resource_id IN ?", resources)
+		}},
+	}
+	for _, step := range steps {
+		if err := step.result().Error; err != nil {
+			return nil, nil, fmt.Errorf("failed to purge %s: %w", step.what, err)
+		}
+	}
+	retained := []privacymodels.EvidenceItem{{
+		Category: "audit_trail",
+		Action:   privacymodels.EvidenceRetained,
+		Records:  audited,
+		Reason:   retainedForAudit,
+	}}
+	return files, retained, nil
+}
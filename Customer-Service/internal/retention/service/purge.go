@@ -0,0 +1,276 @@
+package service
+
+import (
+	"context"
+	"customer-service/internal/audit"
+	"customer-service/internal/events"
+	privacymodels "customer-service/internal/privacy/models"
+	"customer-service/internal/retention/models"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// PurgeConfig controls how often scheduled retention runs start, how often runs are
+// picked up and how many customers are examined per transaction
+type PurgeConfig struct {
+	// Interval is how long after the last scheduled run the next one starts
+	Interval     time.Duration
+	PollInterval time.Duration
+	BatchSize    int
+	// DryRun makes scheduled runs only report what they would purge
+	DryRun bool
+}
+
+// Purger starts a retention run every interval and processes runs in batches that
+// each commit the purges and the run's progress, so a restart resumes where the
+// previous process stopped
+type Purger struct {
+	service RetentionService
+	cfg     PurgeConfig
+}
+
+// NewPurger creates a new retention purge job
+func NewPurger(service RetentionService, cfg PurgeConfig) *Purger {
+	if cfg.Interval <= 0 {
+		cfg.Interval = 24 * time.Hour
+	}
+	if cfg.PollInterval <= 0 {
+		cfg.PollInterval = time.Minute
+	}
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = 100
+	}
+
+	return &Purger{
+		service: service,
+		cfg:     cfg,
+	}
+}
+
+// Run schedules and processes retention runs every poll interval until ctx is cancelled
+func (p *Purger) Run(ctx context.Context) {
+	ticker := time.NewTicker(p.cfg.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := p.RunOnce(ctx); err != nil && ctx.Err() == nil {
+			log.Printf("Retention purge: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunOnce starts a scheduled run if one is due, then examines batches until no run
+// has customers left, and returns how many customers were examined
+func (p *Purger) RunOnce(ctx context.Context) (int, error) {
+	run, err := p.service.ScheduleRun(ctx, p.cfg.Interval, p.cfg.DryRun)
+	if err != nil {
+		return 0, err
+	}
+	if run != nil {
+		log.Printf("Retention purge: scheduled run %s started (dry run: %t)", run.ID, run.DryRun)
+	}
+
+	total := 0
+	for {
+		examined, err := p.service.PurgeBatch(ctx, p.cfg.BatchSize)
+		total += examined
+		if err != nil || examined < p.cfg.BatchSize {
+			return total, err
+		}
+	}
+}
+
+// PurgeBatch examines the next customers of the running retention run and returns how
+// many were examined. Each customer is purged in a savepoint, so one that fails is
+// reported and the others go ahead. Files of purged customers are removed once the
+// batch commits.
+func (s *retentionService) PurgeBatch(ctx context.Context, limit int) (int, error) {
+	examined := 0
+	var purged []*models.PurgedFiles
+	err := s.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		run, err := s.repo.ClaimRun(ctx)
+		if errors.Is(err, models.ErrRunNotFound) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		candidates, err := s.repo.ListCandidates(ctx, run, s.cfg.RestoreWindow, limit)
+		if err != nil {
+			return err
+		}
+		items := make([]models.Item, len(candidates))
+		for i, candidate := range candidates {
+			item, files, err := s.purge(ctx, run, candidate)
+			if err != nil {
+				return err
+			}
+			if files != nil {
+				purged = append(purged, files)
+			}
+			items[i] = item
+
+			run.Cursor = candidate.ID
+			run.Examined++
+			switch item.Outcome {
+			case models.OutcomeDeleted, models.OutcomeWouldDelete:
+				run.Deleted++
+			case models.OutcomeAnonymized, models.OutcomeWouldAnonymize:
+				run.Anonymized++
+			case models.OutcomeHeld:
+				run.Held++
+			case models.OutcomeFailed:
+				run.Failed++
+			}
+		}
+		if err := s.repo.CreateItems(ctx, items); err != nil {
+			return err
+		}
+		examined = len(candidates)
+
+		if len(candidates) < limit {
+			now := time.Now().UTC()
+			run.Status = models.RunStatusCompleted
+			run.FinishedAt = &now
+			logRunCompleted(run)
+		}
+		return s.repo.UpdateRun(ctx, run)
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	for _, files := range purged {
+		s.removeFiles(ctx, files)
+	}
+	return examined, nil
+}
+
+// purge reports on one candidate of a run and, unless the run is a dry run or the
+// candidate is on legal hold, purges it with the records merged into it. A purge
+// that fails is reported as failed rather than returned.
+func (s *retentionService) purge(ctx context.Context, run *models.Run, candidate models.Candidate) (models.Item, *models.PurgedFiles, error) {
+	item := models.Item{
+		RunID:          run.ID,
+		CustomerID:     candidate.ID,
+		CustomerStatus: candidate.Status,
+		DeletedAt:      candidate.DeletedAt,
+		Action:         candidate.Action,
+	}
+	mergedIDs, err := s.privacy.ListMergedIDs(ctx, candidate.ID)
+	if err != nil {
+		return item, nil, err
+	}
+	item.MergedIDs = mergedIDs
+
+	switch {
+	case candidate.LegalHold:
+		item.Outcome = models.OutcomeHeld
+		return item, nil, nil
+	case run.DryRun && candidate.Action == models.ActionDelete:
+		item.Outcome = models.OutcomeWouldDelete
+		return item, nil, nil
+	case run.DryRun:
+		item.Outcome = models.OutcomeWouldAnonymize
+		return item, nil, nil
+	}
+
+	files, retained, err := s.apply(ctx, run, candidate, mergedIDs)
+	if err != nil {
+		log.Printf("Retention purge: failed to purge customer %s: %v", candidate.ID, err)
+		item.Outcome = models.OutcomeFailed
+		item.Error = err.Error()
+		return item, nil, nil
+	}
+	if candidate.Action == models.ActionDelete {
+		item.Outcome = models.OutcomeDeleted
+	} else {
+		item.Outcome = models.OutcomeAnonymized
+	}
+	item.Retained = retained
+	return item, files, nil
+}
+
+// apply deletes or anonymizes a customer and the records merged into it in a
+// savepoint, records it in the audit trail and emits a CustomerPurged event. It
+// returns the files to remove and the records kept, with why they were kept.
+func (s *retentionService) apply(ctx context.Context, run *models.Run, candidate models.Candidate, mergedIDs []uuid.UUID) (*models.PurgedFiles, []privacymodels.EvidenceItem, error) {
+	var files *models.PurgedFiles
+	var retained []privacymodels.EvidenceItem
+	err := s.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		purgedAt := time.Now().UTC().Truncate(time.Microsecond)
+		switch candidate.Action {
+		case models.ActionDelete:
+			var err error
+			files, retained, err = s.repo.Purge(ctx, append([]uuid.UUID{candidate.ID}, mergedIDs...))
+			if err != nil {
+				return err
+			}
+		case models.ActionAnonymize:
+			keys, err := s.privacy.ListExportKeys(ctx, candidate.ID)
+			if err != nil {
+				return err
+			}
+			evidence, err := s.privacy.Erase(ctx, candidate.ID, mergedIDs, purgedAt)
+			if err != nil {
+				return err
+			}
+			for _, item := range evidence {
+				if item.Action == privacymodels.EvidenceRetained {
+					retained = append(retained, item)
+				}
+			}
+			files = &models.PurgedFiles{Exports: keys}
+		default:
+			return fmt.Errorf("unknown retention action %q", candidate.Action)
+		}
+
+		changes := []audit.Change{{Field: "retention_runs." + run.ID.String() + ".action", After: candidate.Action}}
+		if err := s.audit.Record(ctx, audit.ActionRetentionPurge, candidate.ID, changes); err != nil {
+			return err
+		}
+
+		// Appended after the purge, so its own payload is not redacted
+		event, err := events.NewEvent(events.CustomerPurged, candidate.ID, models.CustomerPurgedPayload{
+			RunID:     run.ID,
+			Action:    candidate.Action,
+			MergedIDs: mergedIDs,
+			PurgedAt:  purgedAt,
+		})
+		if err != nil {
+			return err
+		}
+		return s.outbox.Append(ctx, event)
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return files, retained, nil
+}
+
+// removeFiles deletes the stored files of purged customers. Files that cannot be
+// removed are left behind, no longer referenced.
+func (s *retentionService) removeFiles(ctx context.Context, files *models.PurgedFiles) {
+	ctx = context.WithoutCancel(ctx)
+	for _, key := range files.Documents {
+		if err := s.documents.Delete(ctx, key); err != nil {
+			log.Printf("Retention purge: failed to remove KYC document %s: %v", key, err)
+		}
+	}
+	for _, key := range files.Exports {
+		if err := s.exports.Delete(ctx, key); err != nil {
+			log.Printf("Retention purge: failed to remove export %s: %v", key, err)
+		}
+	}
+}
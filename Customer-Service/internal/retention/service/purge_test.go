@@ -0,0 +1,363 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"customer-service/internal/audit"
+	"customer-service/internal/events"
+	"customer-service/internal/kyc/storage"
+	privacymodels "customer-service/internal/privacy/models"
+	privacyrepository "customer-service/internal/privacy/repository"
+	"customer-service/internal/retention/models"
+	"customer-service/internal/retention/repository"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// retentionState is what the transactions of a purge change
+type retentionState struct {
+	run   models.Run
+	items []models.Item
+	// deleted and erased are the customers deleted outright and anonymized
+	deleted []uuid.UUID
+	erased  []uuid.UUID
+	// audited and published are the customers whose purge was recorded and published
+	audited   []uuid.UUID
+	published []uuid.UUID
+}
+
+func (s retentionState) clone() retentionState {
+	s.items = slices.Clone(s.items)
+	s.deleted = slices.Clone(s.deleted)
+	s.erased = slices.Clone(s.erased)
+	s.audited = slices.Clone(s.audited)
+	s.published = slices.Clone(s.published)
+	return s
+}
+
+// memoryRetention keeps a run and its candidates in memory. It also records audit
+// entries and events, and runs transactions, which like savepoints undo their changes
+// when they fail, nested or not.
+type memoryRetention struct {
+	repository.RetentionRepository
+	retentionState
+	candidates []models.Candidate
+	merged     map[uuid.UUID][]uuid.UUID
+	// failAudit makes recording the purge of a customer fail
+	failAudit uuid.UUID
+	// failItems makes storing the report of a batch fail
+	failItems bool
+}
+
+func (r *memoryRetention) ClaimRun(ctx context.Context) (*models.Run, error) {
+	if r.run.Status != models.RunStatusRunning {
+		return nil, models.ErrRunNotFound
+	}
+	run := r.run
+	return &run, nil
+}
+
+func (r *memoryRetention) ListCandidates(ctx context.Context, run *models.Run, restoreWindow time.Duration, limit int) ([]models.Candidate, error) {
+	var candidates []models.Candidate
+	for _, candidate := range r.candidates {
+		if len(candidates) < limit && bytes.Compare(candidate.ID[:], run.Cursor[:]) > 0 {
+			candidates = append(candidates, candidate)
+		}
+	}
+	return candidates, nil
+}
+
+func (r *memoryRetention) CreateItems(ctx context.Context, items []models.Item) error {
+	if r.failItems {
+		return errors.New("connection reset")
+	}
+	r.items = append(r.items, items...)
+	return nil
+}
+
+func (r *memoryRetention) UpdateRun(ctx context.Context, run *models.Run) error {
+	r.run = *run
+	return nil
+}
+
+func (r *memoryRetention) Purge(ctx context.Context, ids []uuid.UUID) (*models.PurgedFiles, []privacymodels.EvidenceItem, error) {
+	r.deleted = append(r.deleted, ids...)
+	files := &models.PurgedFiles{}
+	for _, id := range ids {
+		files.Documents = append(files.Documents, "documents/"+id.String())
+	}
+	return files, nil, nil
+}
+
+func (r *memoryRetention) Record(ctx context.Context, action string, customerID uuid.UUID, changes []audit.Change) error {
+	if customerID == r.failAudit {
+		return errors.New("audit chain locked")
+	}
+	r.audited = append(r.audited, customerID)
+	return nil
+}
+
+func (r *memoryRetention) RecordAccess(ctx context.Context, action string, customerIDs []uuid.UUID) error {
+	return nil
+}
+
+func (r *memoryRetention) Append(ctx context.Context, event *events.Event) error {
+	r.published = append(r.published, event.CustomerID)
+	return nil
+}
+
+func (r *memoryRetention) WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	saved := r.retentionState.clone()
+	err := fn(ctx)
+	if err != nil {
+		r.retentionState = saved
+	}
+	return err
+}
+
+// memoryPrivacy serves the merged records of the store's customers and erases them
+// into it
+type memoryPrivacy struct {
+	privacyrepository.PrivacyRepository
+	store *memoryRetention
+}
+
+func (p *memoryPrivacy) ListMergedIDs(ctx context.Context, survivorID uuid.UUID) ([]uuid.UUID, error) {
+	return p.store.merged[survivorID], nil
+}
+
+func (p *memoryPrivacy) ListExportKeys(ctx context.Context, customerID uuid.UUID) ([]string, error) {
+	return []string{"exports/" + customerID.String()}, nil
+}
+
+func (p *memoryPrivacy) Erase(ctx context.Context, customerID uuid.UUID, mergedIDs []uuid.UUID, at time.Time) ([]privacymodels.EvidenceItem, error) {
+	p.store.erased = append(p.store.erased, append([]uuid.UUID{customerID}, mergedIDs...)...)
+	return []privacymodels.EvidenceItem{
+		{Category: "customer", Action: privacymodels.EvidenceAnonymized, Records: 1},
+		{Category: "kyc_records", Action: privacymodels.EvidenceRetained, Records: 2, Reason: "anti-money laundering retention"},
+	}, nil
+}
+
+// removedFiles records the files deleted from it
+type removedFiles struct {
+	storage.DocumentStore
+	keys []string
+}
+
+func (f *removedFiles) Delete(ctx context.Context, key string) error {
+	f.keys = append(f.keys, key)
+	return nil
+}
+
+func TestPurgeBatch(t *testing.T) {
+	ids := []uuid.UUID{uuid.New(), uuid.New(), uuid.New(), uuid.New()}
+	slices.SortFunc(ids, func(a, b uuid.UUID) int { return bytes.Compare(a[:], b[:]) })
+	deleted, anonymized, held, last := ids[0], ids[1], ids[2], ids[3]
+	duplicate := uuid.New()
+	candidates := []models.Candidate{
+		{ID: deleted, Action: models.ActionDelete},
+		{ID: anonymized, Action: models.ActionAnonymize},
+		{ID: held, Action: models.ActionDelete, LegalHold: true},
+		{ID: last, Action: models.ActionDelete},
+	}
+	documents := func(ids ...uuid.UUID) []string {
+		var keys []string
+		for _, id := range ids {
+			keys = append(keys, "documents/"+id.String())
+		}
+		return keys
+	}
+
+	tests := []struct {
+		name      string
+		dryRun    bool
+		completed bool
+		failAudit uuid.UUID
+		failItems bool
+		limit     int
+		batches   int
+		// wantExamined is what each batch returns
+		wantExamined []int
+		wantErr      bool
+		// wantOutcomes are the outcomes reported, in customer order from the first
+		wantOutcomes []models.Outcome
+		// wantCounts are the deleted, anonymized, held and failed counts of the run
+		wantCounts    [4]int
+		wantCompleted bool
+		wantDeleted   []uuid.UUID
+		wantErased    []uuid.UUID
+		wantPublished []uuid.UUID
+		wantDocuments []string
+		wantExports   []string
+	}{
+		{
+			name:         "dry run",
+			dryRun:       true,
+			limit:        10,
+			batches:      1,
+			wantExamined: []int{4},
+			wantOutcomes: []models.Outcome{
+				models.OutcomeWouldDelete, models.OutcomeWouldAnonymize, models.OutcomeHeld, models.OutcomeWouldDelete,
+			},
+			wantCounts:    [4]int{2, 1, 1, 0},
+			wantCompleted: true,
+		},
+		{
+			name:         "purge",
+			limit:        10,
+			batches:      1,
+			wantExamined: []int{4},
+			wantOutcomes: []models.Outcome{
+				models.OutcomeDeleted, models.OutcomeAnonymized, models.OutcomeHeld, models.OutcomeDeleted,
+			},
+			wantCounts:    [4]int{2, 1, 1, 0},
+			wantCompleted: true,
+			wantDeleted:   []uuid.UUID{deleted, last},
+			wantErased:    []uuid.UUID{anonymized, duplicate},
+			wantPublished: []uuid.UUID{deleted, anonymized, last},
+			wantDocuments: documents(deleted, last),
+			wantExports:   []string{"exports/" + anonymized.String()},
+		},
+		{
+			name:         "failed purge undone up to its savepoint",
+			failAudit:    deleted,
+			limit:        10,
+			batches:      1,
+			wantExamined: []int{4},
+			wantOutcomes: []models.Outcome{
+				models.OutcomeFailed, models.OutcomeAnonymized, models.OutcomeHeld, models.OutcomeDeleted,
+			},
+			wantCounts:    [4]int{1, 1, 1, 1},
+			wantCompleted: true,
+			wantDeleted:   []uuid.UUID{last},
+			wantErased:    []uuid.UUID{anonymized, duplicate},
+			wantPublished: []uuid.UUID{anonymized, last},
+			wantDocuments: documents(last),
+			wantExports:   []string{"exports/" + anonymized.String()},
+		},
+		{
+			name:          "full batch leaves the run going",
+			limit:         3,
+			batches:       1,
+			wantExamined:  []int{3},
+			wantOutcomes:  []models.Outcome{models.OutcomeDeleted, models.OutcomeAnonymized, models.OutcomeHeld},
+			wantCounts:    [4]int{1, 1, 1, 0},
+			wantDeleted:   []uuid.UUID{deleted},
+			wantErased:    []uuid.UUID{anonymized, duplicate},
+			wantPublished: []uuid.UUID{deleted, anonymized},
+			wantDocuments: documents(deleted),
+			wantExports:   []string{"exports/" + anonymized.String()},
+		},
+		{
+			name:         "later batches resume after the cursor",
+			dryRun:       true,
+			limit:        2,
+			batches:      3,
+			wantExamined: []int{2, 2, 0},
+			wantOutcomes: []models.Outcome{
+				models.OutcomeWouldDelete, models.OutcomeWouldAnonymize, models.OutcomeHeld, models.OutcomeWouldDelete,
+			},
+			wantCounts:    [4]int{2, 1, 1, 0},
+			wantCompleted: true,
+		},
+		{
+			name:         "failed batch undone",
+			failItems:    true,
+			limit:        10,
+			batches:      1,
+			wantExamined: []int{0},
+			wantErr:      true,
+		},
+		{
+			name:          "no run going",
+			completed:     true,
+			limit:         10,
+			batches:       1,
+			wantExamined:  []int{0},
+			wantCompleted: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := &memoryRetention{
+				candidates: candidates,
+				merged:     map[uuid.UUID][]uuid.UUID{anonymized: {duplicate}},
+				failAudit:  tt.failAudit,
+				failItems:  tt.failItems,
+			}
+			store.run = models.Run{ID: uuid.New(), DryRun: tt.dryRun, Status: models.RunStatusRunning}
+			if tt.completed {
+				finishedAt := time.Now()
+				store.run.Status, store.run.FinishedAt = models.RunStatusCompleted, &finishedAt
+			}
+			documentFiles, exportFiles := &removedFiles{}, &removedFiles{}
+			s := &retentionService{
+				repo: store, privacy: &memoryPrivacy{store: store},
+				documents: documentFiles, exports: exportFiles,
+				outbox: store, audit: store, tx: store,
+			}
+
+			var examined []int
+			for batch := 0; batch < tt.batches; batch++ {
+				n, err := s.PurgeBatch(context.Background(), tt.limit)
+				if (err != nil) != tt.wantErr {
+					t.Fatalf("PurgeBatch() error = %v, want error %v", err, tt.wantErr)
+				}
+				examined = append(examined, n)
+			}
+			if !slices.Equal(examined, tt.wantExamined) {
+				t.Errorf("PurgeBatch() examined %v, want %v", examined, tt.wantExamined)
+			}
+
+			var outcomes []models.Outcome
+			for i, item := range store.items {
+				outcomes = append(outcomes, item.Outcome)
+				if item.CustomerID != candidates[i].ID {
+					t.Errorf("item %d is about customer %s, want %s", i, item.CustomerID, candidates[i].ID)
+				}
+				if (item.Outcome == models.OutcomeFailed) != (item.Error != "") {
+					t.Errorf("item %d %s with error %q", i, item.Outcome, item.Error)
+				}
+			}
+			if !slices.Equal(outcomes, tt.wantOutcomes) {
+				t.Errorf("outcomes %v, want %v", outcomes, tt.wantOutcomes)
+			}
+			for _, item := range store.items {
+				if item.Outcome == models.OutcomeAnonymized && (len(item.Retained) != 1 || !slices.Equal(item.MergedIDs, []uuid.UUID{duplicate})) {
+					t.Errorf("anonymized item retained %v with merged %v, want the retained records and the duplicate", item.Retained, item.MergedIDs)
+				}
+			}
+
+			run := store.run
+			counts := [4]int{run.Deleted, run.Anonymized, run.Held, run.Failed}
+			if run.Examined != len(tt.wantOutcomes) || counts != tt.wantCounts {
+				t.Errorf("run examined %d with counts %v, want %d with %v", run.Examined, counts, len(tt.wantOutcomes), tt.wantCounts)
+			}
+			wantCursor := uuid.Nil
+			if len(tt.wantOutcomes) > 0 {
+				wantCursor = candidates[len(tt.wantOutcomes)-1].ID
+			}
+			if run.Cursor != wantCursor {
+				t.Errorf("run cursor %s, want %s", run.Cursor, wantCursor)
+			}
+			if completed := run.Status == models.RunStatusCompleted && run.FinishedAt != nil; completed != tt.wantCompleted {
+				t.Errorf("run %s finished at %v, want completed %v", run.Status, run.FinishedAt, tt.wantCompleted)
+			}
+
+			if !slices.Equal(store.deleted, tt.wantDeleted) || !slices.Equal(store.erased, tt.wantErased) {
+				t.Errorf("deleted %v and erased %v, want %v and %v", store.deleted, store.erased, tt.wantDeleted, tt.wantErased)
+			}
+			if !slices.Equal(store.published, tt.wantPublished) || !slices.Equal(store.audited, tt.wantPublished) {
+				t.Errorf("audited %v and published %v, want %v", store.audited, store.published, tt.wantPublished)
+			}
+			if !slices.Equal(documentFiles.keys, tt.wantDocuments) || !slices.Equal(exportFiles.keys, tt.wantExports) {
+				t.Errorf("removed documents %v and exports %v, want %v and %v", documentFiles.keys, exportFiles.keys, tt.wantDocuments, tt.wantExports)
+			}
+		})
+	}
+}
@@ -0,0 +1,399 @@
+package service
+
+import (
+	"context"
+	"customer-service/internal/audit"
+	customermodels "customer-service/internal/customer/models"
+	customerrepository "customer-service/internal/customer/repository"
+	"customer-service/internal/database"
+	"customer-service/internal/events"
+	"customer-service/internal/kyc/storage"
+	privacymodels "customer-service/internal/privacy/models"
+	privacyrepository "customer-service/internal/privacy/repository"
+	"customer-service/internal/retention/models"
+	"customer-service/internal/retention/repository"
+	"errors"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// RetentionService defines the interface for retention of deleted customers: the
+// policies saying how long they are kept, legal holds, restores within the restore
+// window and the runs purging customers past retention
+type RetentionService interface {
+	ListPolicies(ctx context.Context) (*models.PolicyListResponse, error)
+	SetPolicy(ctx context.Context, status customermodels.CustomerStatus, req models.PolicyRequest, actor string) (*models.Policy, error)
+	DeletePolicy(ctx context.Context, status customermodels.CustomerStatus) error
+
+	GetCustomerRetention(ctx context.Context, customerID uuid.UUID) (*models.CustomerRetention, error)
+	SetLegalHold(ctx context.Context, customerID uuid.UUID, req models.LegalHoldRequest, actor string) (*models.CustomerRetention, error)
+	RestoreCustomer(ctx context.Context, customerID uuid.UUID) (*customermodels.CustomerResponse, error)
+
+	StartRun(ctx context.Context, req models.StartRunRequest, actor string) (*models.Run, error)
+	ScheduleRun(ctx context.Context, interval time.Duration, dryRun bool) (*models.Run, error)
+	GetRun(ctx context.Context, id uuid.UUID) (*models.Run, error)
+	ListRuns(ctx context.Context, req models.RunListRequest) (*models.RunListResponse, error)
+	ListRunItems(ctx context.Context, id uuid.UUID, req models.ItemListRequest) (*models.ItemListResponse, error)
+	PurgeBatch(ctx context.Context, limit int) (int, error)
+}
+
+// Config holds the settings of the retention service
+type Config struct {
+	// RestoreWindow is how long after its deletion a customer may be restored. No
+	// customer is purged before its restore window ends.
+	RestoreWindow time.Duration
+}
+
+type retentionService struct {
+	repo      repository.RetentionRepository
+	privacy   privacyrepository.PrivacyRepository
+	customers customerrepository.CustomerRepository
+	documents storage.DocumentStore
+	exports   storage.DocumentStore
+	outbox    events.Outbox
+	audit     audit.Recorder
+	tx        database.Transactor
+	cfg       Config
+}
+
+// NewRetentionService creates a new retention service instance. Anonymizing purges
+// erase customers as the privacy repository does for erasure requests; the files of
+// purged customers are removed from documents and exports.
+func NewRetentionService(repo repository.RetentionRepository, privacy privacyrepository.PrivacyRepository, customers customerrepository.CustomerRepository, documents, exports storage.DocumentStore, outbox events.Outbox, recorder audit.Recorder, tx database.Transactor, cfg Config) RetentionService {
+	return &retentionService{
+		repo:      repo,
+		privacy:   privacy,
+		customers: customers,
+		documents: documents,
+		exports:   exports,
+		outbox:    outbox,
+		audit:     recorder,
+		tx:        tx,
+		cfg:       cfg,
+	}
+}
+
+// ListPolicies returns every retention policy in force
+func (s *retentionService) ListPolicies(ctx context.Context) (*models.PolicyListResponse, error) {
+	policies, err := s.repo.ListPolicies(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &models.PolicyListResponse{Policies: policies}, nil
+}
+
+// SetPolicy sets how long deleted customers of a status are kept and what happens to
+// them afterwards, replacing any earlier policy for the status
+func (s *retentionService) SetPolicy(ctx context.Context, status customermodels.CustomerStatus, req models.PolicyRequest, actor string) (*models.Policy, error) {
+	if actor == "" {
+		return nil, customermodels.NewValidationError("actor", "actor is required")
+	}
+	if err := validateStatus(status); err != nil {
+		return nil, err
+	}
+	if err := customermodels.ValidateStruct(req); err != nil {
+		return nil, err
+	}
+
+	policy := &models.Policy{
+		Status:        status,
+		RetentionDays: req.RetentionDays,
+		Action:        req.Action,
+		UpdatedBy:     actor,
+	}
+	if err := s.repo.SavePolicy(ctx, policy); err != nil {
+		return nil, err
+	}
+	return s.repo.GetPolicy(ctx, status)
+}
+
+// DeletePolicy removes the retention policy of a status, so that its deleted
+// customers are kept indefinitely
+func (s *retentionService) DeletePolicy(ctx context.Context, status customermodels.CustomerStatus) error {
+	if err := validateStatus(status); err != nil {
+		return err
+	}
+	return s.repo.DeletePolicy(ctx, status)
+}
+
+// GetCustomerRetention returns where a customer, which may be deleted, stands in its
+// retention
+func (s *retentionService) GetCustomerRetention(ctx context.Context, customerID uuid.UUID) (*models.CustomerRetention, error) {
+	retention, err := s.repo.GetCustomer(ctx, customerID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.describe(ctx, retention); err != nil {
+		return nil, err
+	}
+	if err := s.audit.RecordAccess(ctx, audit.ActionRetentionView, []uuid.UUID{customerID}); err != nil {
+		return nil, err
+	}
+	return retention, nil
+}
+
+// SetLegalHold places or lifts a legal hold on a customer, which may be deleted. A
+// customer on hold, or whose merged records are, is never purged.
+func (s *retentionService) SetLegalHold(ctx context.Context, customerID uuid.UUID, req models.LegalHoldRequest, actor string) (*models.CustomerRetention, error) {
+	if actor == "" {
+		return nil, customermodels.NewValidationError("actor", "actor is required")
+	}
+	if err := customermodels.ValidateStruct(req); err != nil {
+		return nil, err
+	}
+	hold := *req.LegalHold
+	reason := strings.TrimSpace(req.Reason)
+	if hold && reason == "" {
+		return nil, customermodels.NewValidationError("reason", "is required to place a legal hold")
+	}
+
+	var retention *models.CustomerRetention
+	err := s.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		current, err := s.repo.LockCustomer(ctx, customerID)
+		if err != nil {
+			return err
+		}
+		if err := s.repo.SetLegalHold(ctx, customerID, hold, reason); err != nil {
+			return err
+		}
+		if !hold {
+			reason = ""
+		}
+		changes := []audit.Change{
+			{Field: "legal_hold", Before: current.LegalHold, After: hold},
+			audit.RedactedChange("legal_hold_reason"),
+		}
+		if err := s.audit.Record(ctx, audit.ActionRetentionHold, customerID, changes); err != nil {
+			return err
+		}
+
+		current.LegalHold = hold
+		current.LegalHoldReason = reason
+		retention = current
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := s.describe(ctx, retention); err != nil {
+		return nil, err
+	}
+	return retention, nil
+}
+
+// RestoreCustomer undoes the deletion of a customer within the restore window. Merged
+// and erased customers cannot be restored.
+func (s *retentionService) RestoreCustomer(ctx context.Context, customerID uuid.UUID) (*customermodels.CustomerResponse, error) {
+	var response customermodels.CustomerResponse
+	err := s.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		retention, err := s.repo.LockCustomer(ctx, customerID)
+		if err != nil {
+			return err
+		}
+
+		now := time.Now().UTC()
+		switch {
+		case retention.DeletedAt == nil:
+			return models.ErrCustomerNotDeleted
+		case retention.MergedInto != nil:
+			return models.ErrCustomerMerged
+		case retention.ErasedAt != nil:
+			return privacymodels.ErrCustomerErased
+		case now.After(retention.DeletedAt.Add(s.cfg.RestoreWindow)):
+			return models.ErrRestoreWindowExpired
+		}
+
+		if err := s.repo.Restore(ctx, customerID, now); err != nil {
+			return err
+		}
+		customer, err := s.customers.GetByID(ctx, customerID)
+		if err != nil {
+			return err
+		}
+		changes := []audit.Change{{Field: "deleted_at", Before: *retention.DeletedAt, After: nil}}
+		if err := s.audit.Record(ctx, audit.ActionRetentionRestore, customerID, changes); err != nil {
+			return err
+		}
+
+		response = customer.ToResponse()
+		event, err := events.NewEvent(events.CustomerRestored, customerID, models.CustomerRestoredPayload{
+			Customer:   response,
+			RestoredAt: now,
+		})
+		if err != nil {
+			return err
+		}
+		return s.outbox.Append(ctx, event)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// describe fills in the policy of a customer's status, until when the customer may be
+// restored and after when it will be purged
+func (s *retentionService) describe(ctx context.Context, retention *models.CustomerRetention) error {
+	policy, err := s.repo.GetPolicy(ctx, retention.Status)
+	if err != nil && !errors.Is(err, models.ErrPolicyNotFound) {
+		return err
+	}
+	retention.Policy = policy
+
+	// Merged records are restored and purged with the customer they were merged into
+	if retention.DeletedAt == nil || retention.MergedInto != nil {
+		return nil
+	}
+	if retention.ErasedAt == nil {
+		until := retention.DeletedAt.Add(s.cfg.RestoreWindow)
+		retention.RestorableUntil = &until
+	}
+	if policy != nil && (policy.Action == models.ActionDelete || retention.ErasedAt == nil) {
+		after := retention.DeletedAt.Add(max(policy.Retention(), s.cfg.RestoreWindow))
+		retention.PurgeAfter = &after
+	}
+	return nil
+}
+
+// StartRun starts a retention run now, which the background worker processes. A dry
+// run only reports what it would purge.
+func (s *retentionService) StartRun(ctx context.Context, req models.StartRunRequest, actor string) (*models.Run, error) {
+	if actor == "" {
+		return nil, customermodels.NewValidationError("actor", "actor is required")
+	}
+	if err := customermodels.ValidateStruct(req); err != nil {
+		return nil, err
+	}
+
+	run := &models.Run{
+		DryRun:    *req.DryRun,
+		Status:    models.RunStatusRunning,
+		StartedBy: actor,
+		StartedAt: time.Now().UTC(),
+	}
+	if err := s.repo.CreateRun(ctx, run); err != nil {
+		return nil, err
+	}
+	return run, nil
+}
+
+// ScheduleRun starts a scheduled run when the last one started at least interval ago.
+// It returns nil when no run was due, or another run is still in progress.
+func (s *retentionService) ScheduleRun(ctx context.Context, interval time.Duration, dryRun bool) (*models.Run, error) {
+	now := time.Now().UTC()
+	latest, err := s.repo.GetLatestRun(ctx, audit.SystemActor)
+	if err != nil && !errors.Is(err, models.ErrRunNotFound) {
+		return nil, err
+	}
+	if latest != nil && now.Sub(latest.StartedAt) < interval {
+		return nil, nil
+	}
+
+	run := &models.Run{
+		DryRun:    dryRun,
+		Status:    models.RunStatusRunning,
+		StartedBy: audit.SystemActor,
+		StartedAt: now,
+	}
+	if err := s.repo.CreateRun(ctx, run); err != nil {
+		if errors.Is(err, models.ErrRunInProgress) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return run, nil
+}
+
+// GetRun retrieves a retention run by ID
+func (s *retentionService) GetRun(ctx context.Context, id uuid.UUID) (*models.Run, error) {
+	return s.repo.GetRun(ctx, id)
+}
+
+// ListRuns lists retention runs with pagination, newest first
+func (s *retentionService) ListRuns(ctx context.Context, req models.RunListRequest) (*models.RunListResponse, error) {
+	req.Page, req.PageSize = normalizePage(req.Page, req.PageSize)
+
+	runs, total, err := s.repo.ListRuns(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return &models.RunListResponse{
+		Runs:       runs,
+		Total:      total,
+		Page:       req.Page,
+		PageSize:   req.PageSize,
+		TotalPages: totalPages(total, req.PageSize),
+	}, nil
+}
+
+// ListRunItems lists the report of a retention run with pagination, optionally
+// filtered by outcome
+func (s *retentionService) ListRunItems(ctx context.Context, id uuid.UUID, req models.ItemListRequest) (*models.ItemListResponse, error) {
+	switch req.Outcome {
+	case "", models.OutcomeDeleted, models.OutcomeAnonymized, models.OutcomeWouldDelete,
+		models.OutcomeWouldAnonymize, models.OutcomeHeld, models.OutcomeFailed:
+	default:
+		return nil, customermodels.NewValidationError("outcome",
+			"must be one of: deleted anonymized would_delete would_anonymize held failed")
+	}
+	if _, err := s.repo.GetRun(ctx, id); err != nil {
+		return nil, err
+	}
+	req.Page, req.PageSize = normalizePage(req.Page, req.PageSize)
+
+	items, total, err := s.repo.ListItems(ctx, id, req)
+	if err != nil {
+		return nil, err
+	}
+	return &models.ItemListResponse{
+		RunID:      id,
+		Items:      items,
+		Total:      total,
+		Page:       req.Page,
+		PageSize:   req.PageSize,
+		TotalPages: totalPages(total, req.PageSize),
+	}, nil
+}
+
+// validateStatus rejects a status customers cannot have
+func validateStatus(status customermodels.CustomerStatus) error {
+	switch status {
+	case customermodels.CustomerStatusActive, customermodels.CustomerStatusInactive,
+		customermodels.CustomerStatusSuspended, customermodels.CustomerStatusClosed:
+		return nil
+	}
+	return customermodels.NewValidationError("status", "must be one of: active inactive suspended closed")
+}
+
+// normalizePage applies the default page and page size
+func normalizePage(page, pageSize int) (int, int) {
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 || pageSize > 100 {
+		pageSize = 20
+	}
+	return page, pageSize
+}
+
+// totalPages returns the number of pages holding total entries
+func totalPages(total int64, pageSize int) int {
+	pages := int(total) / pageSize
+	if int(total)%pageSize > 0 {
+		pages++
+	}
+	return pages
+}
+
+// logRunCompleted reports the outcome of a finished run
+func logRunCompleted(run *models.Run) {
+	mode := ""
+	if run.DryRun {
+		mode = " (dry run)"
+	}
+	log.Printf("Retention: run %s completed%s, %d customer(s) examined, %d deleted, %d anonymized, %d held, %d failed",
+		run.ID, mode, run.Examined, run.Deleted, run.Anonymized, run.Held, run.Failed)
+}
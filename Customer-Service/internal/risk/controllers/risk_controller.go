@@ -0,0 +1,54 @@
+package controllers
+
+import (
+	customermodels "customer-service/internal/customer/models"
+	"customer-service/internal/risk/service"
+	"customer-service/pkg/apierror"
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// RiskController handles HTTP requests for customer risk ratings
+type RiskController struct {
+	service service.RiskService
+}
+
+// NewRiskController creates a new risk controller instance
+func NewRiskController(riskService service.RiskService) *RiskController {
+	return &RiskController{
+		service: riskService,
+	}
+}
+
+// GetRisk handles GET /customers/:id/risk
+func (ctrl *RiskController) GetRisk(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		apierror.Abort(c, http.StatusBadRequest, apierror.CodeInvalidRequest, "invalid id",
+			apierror.FieldError{Field: "id", Message: "must be a valid UUID"})
+		return
+	}
+
+	risk, err := ctrl.service.GetRisk(c.Request.Context(), id)
+	if err != nil {
+		ctrl.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, risk)
+}
+
+// handleError maps service errors to HTTP responses
+func (ctrl *RiskController) handleError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, customermodels.ErrCustomerNotFound):
+		apierror.Abort(c, http.StatusNotFound, apierror.CodeNotFound, err.Error())
+	default:
+		log.Printf("Unhandled error processing %s %s: %v", c.Request.Method, c.Request.URL.Path, err)
+		apierror.Abort(c, http.StatusInternalServerError, apierror.CodeInternal, "internal server error")
+	}
+}
@@ -0,0 +1,18 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// CustomerRiskRatingChangedPayload is the payload of a CustomerRiskRatingChanged event.
+// FromRating is empty for a customer's first assessment.
+type CustomerRiskRatingChangedPayload struct {
+	AssessmentID uuid.UUID `json:"assessment_id"`
+	FromRating   Rating    `json:"from_rating,omitempty"`
+	ToRating     Rating    `json:"to_rating"`
+	Score        int       `json:"score"`
+	Trigger      Trigger   `json:"trigger"`
+	NextReviewAt time.Time `json:"next_review_at"`
+}
@@ -0,0 +1,72 @@
+package models
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Domain errors returned by the risk repository and rules engine
+var (
+	ErrAssessmentNotFound = errors.New("risk assessment not found")
+	ErrInvalidRules       = errors.New("invalid risk rules")
+)
+
+// Rating is the risk band a customer's score falls in
+type Rating string
+
+const (
+	RatingLow    Rating = "low"
+	RatingMedium Rating = "medium"
+	RatingHigh   Rating = "high"
+)
+
+// Ratings lists every rating from lowest to highest
+var Ratings = []Rating{RatingLow, RatingMedium, RatingHigh}
+
+// Trigger records why a customer was rated
+type Trigger string
+
+const (
+	TriggerOnboarding     Trigger = "onboarding"
+	TriggerCustomerUpdate Trigger = "customer_update"
+	TriggerScreening      Trigger = "screening"
+	TriggerPeriodicReview Trigger = "periodic_review"
+)
+
+// Factor is a rule that matched a customer and the points it contributed. Value is
+// the customer attribute the rule matched on, such as the country or age.
+type Factor struct {
+	Rule        string `json:"rule"`
+	Factor      string `json:"factor"`
+	Value       string `json:"value"`
+	Points      int    `json:"points"`
+	Description string `json:"description,omitempty"`
+}
+
+// Assessment is one rating of a customer. The latest assessment is the customer's
+// current rating; earlier ones are kept as its history.
+type Assessment struct {
+	ID           uuid.UUID `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	CustomerID   uuid.UUID `json:"customer_id" gorm:"type:uuid;not null;index"`
+	Score        int       `json:"score" gorm:"not null"`
+	Rating       Rating    `json:"rating" gorm:"not null;size:10"`
+	Factors      []Factor  `json:"factors" gorm:"type:jsonb;serializer:json;not null"`
+	RulesVersion string    `json:"rules_version" gorm:"not null;size:100"`
+	Trigger      Trigger   `json:"trigger" gorm:"not null;size:30"`
+	NextReviewAt time.Time `json:"next_review_at" gorm:"not null"`
+	CreatedAt    time.Time `json:"assessed_at"`
+}
+
+// TableName returns the table name for Assessment model
+func (Assessment) TableName() string {
+	return "risk_assessments"
+}
+
+// RiskResponse holds a customer's current rating and every earlier one, newest first
+type RiskResponse struct {
+	CustomerID uuid.UUID    `json:"customer_id"`
+	Current    *Assessment  `json:"current"`
+	History    []Assessment `json:"history"`
+}
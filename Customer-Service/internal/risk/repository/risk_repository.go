@@ -0,0 +1,92 @@
+package repository
+
+import (
+	"context"
+	"customer-service/internal/database"
+	"customer-service/internal/risk/models"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// RiskRepository defines the interface for risk assessment data access
+type RiskRepository interface {
+	Create(ctx context.Context, assessment *models.Assessment) error
+	GetLatest(ctx context.Context, customerID uuid.UUID) (*models.Assessment, error)
+	ListByCustomer(ctx context.Context, customerID uuid.UUID) ([]models.Assessment, error)
+	ClaimDue(ctx context.Context, rulesVersion string, now time.Time, limit int) ([]uuid.UUID, error)
+}
+
+type riskRepository struct {
+	db *gorm.DB
+}
+
+// NewRiskRepository creates a new risk repository instance
+func NewRiskRepository(db *gorm.DB) RiskRepository {
+	return &riskRepository{db: db}
+}
+
+// Create stores a new assessment
+func (r *riskRepository) Create(ctx context.Context, assessment *models.Assessment) error {
+	if err := database.Conn(ctx, r.db).Create(assessment).Error; err != nil {
+		return fmt.Errorf("failed to create risk assessment: %w", err)
+	}
+	return nil
+}
+
+// GetLatest retrieves the current assessment of a customer
+func (r *riskRepository) GetLatest(ctx context.Context, customerID uuid.UUID) (*models.Assessment, error) {
+	var assessment models.Assessment
+	err := database.Conn(ctx, r.db).
+		Where("customer_id = ?", customerID).
+		Order("created_at DESC").
+		First(&assessment).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, models.ErrAssessmentNotFound
+		}
+		return nil, fmt.Errorf("failed to get risk assessment: %w", err)
+	}
+	return &assessment, nil
+}
+
+// ListByCustomer returns every assessment of a customer, newest first
+func (r *riskRepository) ListByCustomer(ctx context.Context, customerID uuid.UUID) ([]models.Assessment, error) {
+	var assessments []models.Assessment
+	err := database.Conn(ctx, r.db).
+		Where("customer_id = ?", customerID).
+		Order("created_at DESC").
+		Find(&assessments).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list risk assessments: %w", err)
+	}
+	return assessments, nil
+}
+
+// ClaimDue locks and returns customers that were never rated, were rated under other
+// rules, or whose review date has passed. Rows locked by another instance are skipped,
+// so the caller must hold a transaction for the locks to last.
+func (r *riskRepository) ClaimDue(ctx context.Context, rulesVersion string, now time.Time, limit int) ([]uuid.UUID, error) {
+	var ids []uuid.UUID
+	err := database.Conn(ctx, r.db).Raw(`
+		SELECT c.id FROM customers c
+		LEFT JOIN LATERAL (
+			SELECT rules_version, next_review_at FROM risk_assessments a
+			WHERE a.customer_id = c.id
+			ORDER BY a.created_at DESC
+			LIMIT 1
+		) latest ON true
+		WHERE c.deleted_at IS NULL
+			AND (latest.rules_version IS NULL OR latest.rules_version <> ? OR latest.next_review_at <= ?)
+		ORDER BY latest.next_review_at NULLS FIRST, c.id
+		LIMIT ?
+		FOR UPDATE OF c SKIP LOCKED`, rulesVersion, now, limit).
+		Scan(&ids).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list customers due for risk review: %w", err)
+	}
+	return ids, nil
+}
@@ -0,0 +1,310 @@
+// Package rules loads risk scoring rules from a YAML or JSON file and evaluates
+// customers against them.
+package rules
+
+import (
+	"bytes"
+	"customer-service/internal/risk/models"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Factor names the customer attribute a rule is evaluated on
+type Factor string
+
+const (
+	// FactorCountry is the country of the customer's address, matched with in
+	FactorCountry Factor = "country"
+	// FactorAge is the customer's age in whole years, matched with min and max
+	FactorAge Factor = "age"
+	// FactorOccupation is the customer's occupation, matched with in
+	FactorOccupation Factor = "occupation"
+	// FactorPEPHits is the number of PEP screening alerts not ruled a false positive,
+	// matched with min and max
+	FactorPEPHits Factor = "pep_hits"
+	// FactorProducts are the product codes the customer holds, matched with in when
+	// any of them is listed
+	FactorProducts Factor = "products"
+)
+
+// listFactors are matched against a list of values, the others against a range
+var listFactors = []Factor{FactorCountry, FactorOccupation, FactorProducts}
+
+// customerTypes are the customer types a rule can be limited to
+var customerTypes = []string{"individual", "organization"}
+
+// Rule adds Points to the score of every customer it matches. A rule matches when the
+// factor is one of In, lies within Min and Max, or, with Missing set, is unknown. A
+// rule with CustomerType only applies to customers of that type. A matching rule with
+// MinRating keeps the customer at that rating or above, whatever the score.
+type Rule struct {
+	ID           string        `json:"id" yaml:"id"`
+	Description  string        `json:"description" yaml:"description"`
+	CustomerType string        `json:"customer_type" yaml:"customer_type"`
+	Factor       Factor        `json:"factor" yaml:"factor"`
+	In           []string      `json:"in" yaml:"in"`
+	Min          *int          `json:"min" yaml:"min"`
+	Max          *int          `json:"max" yaml:"max"`
+	Missing      bool          `json:"missing" yaml:"missing"`
+	Points       int           `json:"points" yaml:"points"`
+	MinRating    models.Rating `json:"min_rating" yaml:"min_rating"`
+}
+
+// Thresholds are the lowest scores rated medium and high
+type Thresholds struct {
+	Medium int `json:"medium" yaml:"medium"`
+	High   int `json:"high" yaml:"high"`
+}
+
+// Ruleset is a versioned set of rules with the score thresholds of each rating and
+// the number of days until a customer with that rating is due for review
+type Ruleset struct {
+	Version    string         `json:"version" yaml:"version"`
+	Thresholds Thresholds     `json:"thresholds" yaml:"thresholds"`
+	ReviewDays map[string]int `json:"review_days" yaml:"review_days"`
+	Rules      []Rule         `json:"rules" yaml:"rules"`
+}
+
+// Profile holds the customer attributes rules are evaluated on
+type Profile struct {
+	CustomerType string
+	Country      string
+	DateOfBirth  *time.Time
+	Occupation   string
+	PEPHits      int
+	Products     []string
+}
+
+// Result is the outcome of evaluating a customer
+type Result struct {
+	Score        int
+	Rating       models.Rating
+	Factors      []models.Factor
+	NextReviewAt time.Time
+}
+
+// Load reads a ruleset from a file, choosing the format by its .yaml, .yml or .json
+// extension
+func Load(path string) (*Ruleset, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read risk rules: %w", err)
+	}
+
+	var ruleset Ruleset
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		decoder := yaml.NewDecoder(bytes.NewReader(data))
+		decoder.KnownFields(true)
+		err = decoder.Decode(&ruleset)
+	case ".json":
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.DisallowUnknownFields()
+		err = decoder.Decode(&ruleset)
+	default:
+		return nil, fmt.Errorf("%w: %s is neither a YAML nor a JSON file", models.ErrInvalidRules, path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", models.ErrInvalidRules, err)
+	}
+
+	if err := ruleset.validate(); err != nil {
+		return nil, err
+	}
+	return &ruleset, nil
+}
+
+// validate checks that every rule can be evaluated and every rating has a review period
+func (rs *Ruleset) validate() error {
+	if rs.Version == "" {
+		return fmt.Errorf("%w: version is required", models.ErrInvalidRules)
+	}
+	if rs.Thresholds.Medium <= 0 || rs.Thresholds.High <= rs.Thresholds.Medium {
+		return fmt.Errorf("%w: thresholds must satisfy 0 < medium < high", models.ErrInvalidRules)
+	}
+	for _, rating := range models.Ratings {
+		if rs.ReviewDays[string(rating)] <= 0 {
+			return fmt.Errorf("%w: review_days.%s must be positive", models.ErrInvalidRules, rating)
+		}
+	}
+	for key := range rs.ReviewDays {
+		if !slices.Contains(models.Ratings, models.Rating(key)) {
+			return fmt.Errorf("%w: review_days.%s is not a rating", models.ErrInvalidRules, key)
+		}
+	}
+
+	seen := make(map[string]bool, len(rs.Rules))
+	for i, rule := range rs.Rules {
+		if rule.ID == "" {
+			return fmt.Errorf("%w: rule %d has no id", models.ErrInvalidRules, i+1)
+		}
+		if seen[rule.ID] {
+			return fmt.Errorf("%w: rule id %q is used twice", models.ErrInvalidRules, rule.ID)
+		}
+		seen[rule.ID] = true
+		if err := rule.validate(); err != nil {
+			return fmt.Errorf("%w: rule %q %s", models.ErrInvalidRules, rule.ID, err.Error())
+		}
+	}
+	return nil
+}
+
+// validate checks that a rule names a known factor and a condition that suits it
+func (r *Rule) validate() error {
+	switch r.Factor {
+	case FactorCountry, FactorAge, FactorOccupation, FactorPEPHits, FactorProducts:
+	default:
+		return fmt.Errorf("has unknown factor %q", r.Factor)
+	}
+	if r.CustomerType != "" && !slices.Contains(customerTypes, r.CustomerType) {
+		return fmt.Errorf("has unknown customer_type %q", r.CustomerType)
+	}
+	if r.MinRating != "" && !slices.Contains(models.Ratings, r.MinRating) {
+		return fmt.Errorf("has unknown min_rating %q", r.MinRating)
+	}
+
+	hasRange := r.Min != nil || r.Max != nil
+	switch {
+	case r.Missing:
+		if len(r.In) > 0 || hasRange {
+			return fmt.Errorf("cannot combine missing with in, min or max")
+		}
+		if r.Factor == FactorPEPHits {
+			return fmt.Errorf("cannot use missing with %s", r.Factor)
+		}
+	case slices.Contains(listFactors, r.Factor):
+		if len(r.In) == 0 || hasRange {
+			return fmt.Errorf("must match %s with in", r.Factor)
+		}
+	default:
+		if !hasRange || len(r.In) > 0 {
+			return fmt.Errorf("must match %s with min or max", r.Factor)
+		}
+		if r.Min != nil && r.Max != nil && *r.Min > *r.Max {
+			return fmt.Errorf("has min greater than max")
+		}
+	}
+	return nil
+}
+
+// Evaluate scores a customer, rates the score and dates the next review
+func (rs *Ruleset) Evaluate(profile Profile, now time.Time) Result {
+	result := Result{Rating: models.RatingLow, Factors: make([]models.Factor, 0)}
+	floor := models.RatingLow
+	for _, rule := range rs.Rules {
+		value, ok := rule.match(profile, now)
+		if !ok {
+			continue
+		}
+		result.Score += rule.Points
+		result.Factors = append(result.Factors, models.Factor{
+			Rule:        rule.ID,
+			Factor:      string(rule.Factor),
+			Value:       value,
+			Points:      rule.Points,
+			Description: rule.Description,
+		})
+		if rule.MinRating != "" && rank(rule.MinRating) > rank(floor) {
+			floor = rule.MinRating
+		}
+	}
+
+	switch {
+	case result.Score >= rs.Thresholds.High:
+		result.Rating = models.RatingHigh
+	case result.Score >= rs.Thresholds.Medium:
+		result.Rating = models.RatingMedium
+	}
+	if rank(floor) > rank(result.Rating) {
+		result.Rating = floor
+	}
+	result.NextReviewAt = now.AddDate(0, 0, rs.ReviewDays[string(result.Rating)])
+	return result
+}
+
+// match reports whether the rule matches the customer and the value it matched on
+func (r *Rule) match(profile Profile, now time.Time) (string, bool) {
+	if r.CustomerType != "" && r.CustomerType != profile.CustomerType {
+		return "", false
+	}
+	switch r.Factor {
+	case FactorCountry:
+		return r.matchValue(strings.ToUpper(strings.TrimSpace(profile.Country)))
+	case FactorOccupation:
+		return r.matchValue(strings.ToLower(strings.TrimSpace(profile.Occupation)))
+	case FactorProducts:
+		if r.Missing {
+			return "", len(profile.Products) == 0
+		}
+		held := make([]string, 0)
+		for _, product := range profile.Products {
+			if r.listed(product) {
+				held = append(held, product)
+			}
+		}
+		return strings.Join(held, ","), len(held) > 0
+	case FactorAge:
+		if profile.DateOfBirth == nil {
+			return "", r.Missing
+		}
+		if r.Missing {
+			return "", false
+		}
+		return r.matchRange(age(*profile.DateOfBirth, now))
+	case FactorPEPHits:
+		return r.matchRange(profile.PEPHits)
+	}
+	return "", false
+}
+
+// matchValue matches a list factor, treating an empty value as unknown
+func (r *Rule) matchValue(value string) (string, bool) {
+	if r.Missing || value == "" {
+		return "", r.Missing && value == ""
+	}
+	return value, r.listed(value)
+}
+
+// matchRange matches a numeric factor against the rule's bounds
+func (r *Rule) matchRange(value int) (string, bool) {
+	if r.Min != nil && value < *r.Min {
+		return "", false
+	}
+	if r.Max != nil && value > *r.Max {
+		return "", false
+	}
+	return strconv.Itoa(value), true
+}
+
+// listed reports whether a value is in the rule's list, ignoring case
+func (r *Rule) listed(value string) bool {
+	for _, candidate := range r.In {
+		if strings.EqualFold(strings.TrimSpace(candidate), value) {
+			return true
+		}
+	}
+	return false
+}
+
+// rank orders ratings from low to high
+func rank(rating models.Rating) int {
+	return slices.Index(models.Ratings, rating)
+}
+
+// age returns the number of whole years between a date of birth and now
+func age(dateOfBirth, now time.Time) int {
+	dateOfBirth, now = dateOfBirth.UTC(), now.UTC()
+	years := now.Year() - dateOfBirth.Year()
+	if now.Month() < dateOfBirth.Month() || (now.Month() == dateOfBirth.Month() && now.Day() < dateOfBirth.Day()) {
+		years--
+	}
+	return years
+}
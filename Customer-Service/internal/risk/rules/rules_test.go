@@ -0,0 +1,227 @@
+package rules
+
+import (
+	"customer-service/internal/risk/models"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+// loadShipped loads the rules shipped with the service
+func loadShipped(t *testing.T) *Ruleset {
+	t.Helper()
+	ruleset, err := Load(filepath.Join("..", "..", "..", "risk_rules.yaml"))
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	return ruleset
+}
+
+func TestEvaluate(t *testing.T) {
+	ruleset := loadShipped(t)
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	date := func(value string) *time.Time {
+		d, _ := time.Parse("2006-01-02", value)
+		return &d
+	}
+	individual := func(country, dateOfBirth, occupation string, products ...string) Profile {
+		profile := Profile{CustomerType: "individual", Country: country, Occupation: occupation, Products: products}
+		if dateOfBirth != "" {
+			profile.DateOfBirth = date(dateOfBirth)
+		}
+		return profile
+	}
+
+	tests := []struct {
+		name       string
+		profile    Profile
+		wantScore  int
+		wantRating models.Rating
+		wantRules  []string
+		// wantValues are the values some of the matched rules matched on
+		wantValues map[string]string
+	}{
+		{
+			name:       "nothing matched",
+			profile:    individual("GB", "1980-01-01", "engineer", "checking"),
+			wantRating: models.RatingLow,
+		},
+		{
+			name:       "sanctioned country",
+			profile:    individual("IR", "1980-01-01", "engineer"),
+			wantScore:  60,
+			wantRating: models.RatingHigh,
+			wantRules:  []string{"country_sanctioned"},
+			wantValues: map[string]string{"country_sanctioned": "IR"},
+		},
+		{
+			name:       "nothing recorded",
+			profile:    individual("", "", ""),
+			wantScore:  35,
+			wantRating: models.RatingMedium,
+			wantRules:  []string{"country_unknown", "age_unknown", "occupation_unknown"},
+		},
+		{
+			name:       "organization with nothing recorded",
+			profile:    Profile{CustomerType: "organization"},
+			wantScore:  15,
+			wantRating: models.RatingLow,
+			wantRules:  []string{"country_unknown"},
+		},
+		{
+			name:       "turned 21 today",
+			profile:    individual("GB", "2005-10-16", "student"),
+			wantRating: models.RatingLow,
+		},
+		{
+			name:       "turns 21 tomorrow",
+			profile:    individual("GB", "2005-10-17", "student"),
+			wantScore:  10,
+			wantRating: models.RatingLow,
+			wantRules:  []string{"age_young_adult"},
+			wantValues: map[string]string{"age_young_adult": "20"},
+		},
+		{
+			name:       "values compared without case or spaces",
+			profile:    individual(" ve ", "1980-01-01", " Casino Operator"),
+			wantScore:  55,
+			wantRating: models.RatingMedium,
+			wantRules:  []string{"country_high_risk", "occupation_cash_intensive"},
+			wantValues: map[string]string{"country_high_risk": "VE", "occupation_cash_intensive": "casino operator"},
+		},
+		{
+			name:       "listed products",
+			profile:    individual("GB", "1980-01-01", "engineer", "private_banking", "checking", "international_wire", "crypto_custody"),
+			wantScore:  35,
+			wantRating: models.RatingMedium,
+			wantRules:  []string{"product_private_banking", "product_cross_border"},
+			wantValues: map[string]string{"product_cross_border": "international_wire,crypto_custody"},
+		},
+		{
+			name:       "politically exposed",
+			profile:    Profile{CustomerType: "individual", Country: "GB", DateOfBirth: date("1960-05-05"), Occupation: "minister", PEPHits: 2},
+			wantScore:  40,
+			wantRating: models.RatingMedium,
+			wantRules:  []string{"pep_match"},
+			wantValues: map[string]string{"pep_match": "2"},
+		},
+		{
+			name:       "scores add up",
+			profile:    Profile{CustomerType: "organization", Country: "KP", PEPHits: 1, Products: []string{"wealth_management"}},
+			wantScore:  120,
+			wantRating: models.RatingHigh,
+			wantRules:  []string{"country_sanctioned", "pep_match", "product_private_banking"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := ruleset.Evaluate(tt.profile, now)
+			if result.Score != tt.wantScore || result.Rating != tt.wantRating {
+				t.Errorf("Evaluate() = %d rated %s, want %d rated %s", result.Score, result.Rating, tt.wantScore, tt.wantRating)
+			}
+
+			var matched []string
+			for _, factor := range result.Factors {
+				matched = append(matched, factor.Rule)
+				if want, ok := tt.wantValues[factor.Rule]; ok && factor.Value != want {
+					t.Errorf("rule %s matched on %q, want %q", factor.Rule, factor.Value, want)
+				}
+			}
+			if !slices.Equal(matched, tt.wantRules) {
+				t.Errorf("matched rules %v, want %v", matched, tt.wantRules)
+			}
+
+			if want := now.AddDate(0, 0, ruleset.ReviewDays[string(tt.wantRating)]); !result.NextReviewAt.Equal(want) {
+				t.Errorf("NextReviewAt = %v, want %v", result.NextReviewAt, want)
+			}
+		})
+	}
+}
+
+func TestEvaluateThresholds(t *testing.T) {
+	one := 1
+	ruleset := &Ruleset{
+		Thresholds: Thresholds{Medium: 30, High: 60},
+		ReviewDays: map[string]int{"low": 1095, "medium": 365, "high": 180},
+		Rules: []Rule{
+			{ID: "pep", Factor: FactorPEPHits, Min: &one, Points: 5, MinRating: models.RatingHigh},
+			{ID: "wire", Factor: FactorProducts, In: []string{"wire"}, Points: 30},
+			{ID: "custody", Factor: FactorProducts, In: []string{"custody"}, Points: 29},
+			{ID: "trade", Factor: FactorProducts, In: []string{"trade"}, Points: 1},
+			{ID: "mixer", Factor: FactorProducts, In: []string{"mixer"}, Points: -30},
+		},
+	}
+
+	tests := []struct {
+		name    string
+		profile Profile
+		want    models.Rating
+	}{
+		{name: "just under medium", profile: Profile{Products: []string{"custody"}}, want: models.RatingLow},
+		{name: "at medium", profile: Profile{Products: []string{"wire"}}, want: models.RatingMedium},
+		{name: "just under high", profile: Profile{Products: []string{"wire", "custody"}}, want: models.RatingMedium},
+		{name: "at high", profile: Profile{Products: []string{"wire", "custody", "trade"}}, want: models.RatingHigh},
+		{name: "negative points", profile: Profile{Products: []string{"wire", "mixer"}}, want: models.RatingLow},
+		{name: "minimum rating above the score", profile: Profile{PEPHits: 1}, want: models.RatingHigh},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ruleset.Evaluate(tt.profile, time.Now()); got.Rating != tt.want {
+				t.Errorf("Evaluate() = %d rated %s, want %s", got.Score, got.Rating, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadRejects(t *testing.T) {
+	const header = "version: \"1\"\nthresholds: {medium: 30, high: 60}\nreview_days: {low: 1095, medium: 365, high: 180}\n"
+	valid := filepath.Join(t.TempDir(), "rules.yaml")
+	if err := os.WriteFile(valid, []byte(header+"rules:\n  - {id: a, factor: country, in: [IR], points: 60}\n"), 0o600); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+	if _, err := Load(valid); err != nil {
+		t.Fatalf("Load() of the rules the cases start from error = %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		file    string
+		content string
+	}{
+		{name: "other extension", file: "rules.toml", content: header},
+		{name: "unknown field", file: "rules.yaml", content: header + "weights: {}\n"},
+		{name: "unknown rule field", file: "rules.json", content: `{"version":"1","thresholds":{"medium":30,"high":60},"review_days":{"low":1,"medium":1,"high":1},"rules":[{"id":"a","factor":"country","in":["IR"],"weight":1}]}`},
+		{name: "no version", file: "rules.yaml", content: "thresholds: {medium: 30, high: 60}\nreview_days: {low: 1095, medium: 365, high: 180}\n"},
+		{name: "high threshold not above medium", file: "rules.yaml", content: "version: \"1\"\nthresholds: {medium: 30, high: 30}\nreview_days: {low: 1095, medium: 365, high: 180}\n"},
+		{name: "rating without review period", file: "rules.yaml", content: "version: \"1\"\nthresholds: {medium: 30, high: 60}\nreview_days: {low: 1095, medium: 365}\n"},
+		{name: "review period of an unknown rating", file: "rules.yaml", content: header[:len(header)-2] + ", severe: 90}\n"},
+		{name: "rule without id", file: "rules.yaml", content: header + "rules:\n  - {factor: country, in: [IR], points: 60}\n"},
+		{name: "rule id used twice", file: "rules.yaml", content: header + "rules:\n  - {id: a, factor: country, in: [IR], points: 60}\n  - {id: a, factor: country, in: [KP], points: 60}\n"},
+		{name: "unknown factor", file: "rules.yaml", content: header + "rules:\n  - {id: a, factor: income, min: 1, points: 10}\n"},
+		{name: "unknown customer type", file: "rules.yaml", content: header + "rules:\n  - {id: a, customer_type: trust, factor: country, in: [IR], points: 10}\n"},
+		{name: "unknown minimum rating", file: "rules.yaml", content: header + "rules:\n  - {id: a, factor: country, in: [IR], points: 10, min_rating: severe}\n"},
+		{name: "list factor with a range", file: "rules.yaml", content: header + "rules:\n  - {id: a, factor: country, min: 1, points: 10}\n"},
+		{name: "range factor with a list", file: "rules.yaml", content: header + "rules:\n  - {id: a, factor: age, in: [\"18\"], points: 10}\n"},
+		{name: "range factor without bounds", file: "rules.yaml", content: header + "rules:\n  - {id: a, factor: age, points: 10}\n"},
+		{name: "min greater than max", file: "rules.yaml", content: header + "rules:\n  - {id: a, factor: age, min: 65, max: 18, points: 10}\n"},
+		{name: "missing combined with a list", file: "rules.yaml", content: header + "rules:\n  - {id: a, factor: country, missing: true, in: [IR], points: 10}\n"},
+		{name: "missing PEP hits", file: "rules.yaml", content: header + "rules:\n  - {id: a, factor: pep_hits, missing: true, points: 10}\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), tt.file)
+			if err := os.WriteFile(path, []byte(tt.content), 0o600); err != nil {
+				t.Fatalf("WriteFile() error = %v", err)
+			}
+			if _, err := Load(path); !errors.Is(err, models.ErrInvalidRules) {
+				t.Errorf("Load() error = %v, want %v", err, models.ErrInvalidRules)
+			}
+		})
+	}
+}
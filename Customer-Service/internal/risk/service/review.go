@@ -0,0 +1,68 @@
+package service
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// ReviewConfig controls how often customers due for review are looked for and how
+// many are rated per transaction
+type ReviewConfig struct {
+	Interval  time.Duration
+	BatchSize int
+}
+
+// Reviewer rates customers again once their review date has passed, and rates those
+// never rated or rated under an earlier version of the rules
+type Reviewer struct {
+	service RiskService
+	cfg     ReviewConfig
+}
+
+// NewReviewer creates a new risk review job
+func NewReviewer(service RiskService, cfg ReviewConfig) *Reviewer {
+	if cfg.Interval <= 0 {
+		cfg.Interval = time.Hour
+	}
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = 100
+	}
+
+	return &Reviewer{
+		service: service,
+		cfg:     cfg,
+	}
+}
+
+// Run reviews due customers every interval until ctx is cancelled
+func (r *Reviewer) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.cfg.Interval)
+	defer ticker.Stop()
+
+	for {
+		if reviewed, err := r.RunOnce(ctx); err != nil && ctx.Err() == nil {
+			log.Printf("Risk review: %v", err)
+		} else if reviewed > 0 {
+			log.Printf("Risk review: rated %d customer(s)", reviewed)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunOnce rates batches until no customer is due and returns how many were rated
+func (r *Reviewer) RunOnce(ctx context.Context) (int, error) {
+	total := 0
+	for {
+		reviewed, err := r.service.ReviewBatch(ctx, r.cfg.BatchSize)
+		total += reviewed
+		if err != nil || reviewed < r.cfg.BatchSize {
+			return total, err
+		}
+	}
+}
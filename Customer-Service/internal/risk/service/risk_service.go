@@ -0,0 +1,194 @@
+package service
+
+import (
+	"context"
+	"customer-service/internal/audit"
+	customermodels "customer-service/internal/customer/models"
+	customerrepository "customer-service/internal/customer/repository"
+	"customer-service/internal/database"
+	"customer-service/internal/events"
+	"customer-service/internal/risk/models"
+	"customer-service/internal/risk/repository"
+	"customer-service/internal/risk/rules"
+	screeningmodels "customer-service/internal/screening/models"
+	screeningrepository "customer-service/internal/screening/repository"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// RiskService defines the interface for customer risk rating
+type RiskService interface {
+	Rate(ctx context.Context, customer *customermodels.Customer, trigger models.Trigger) error
+	GetRisk(ctx context.Context, customerID uuid.UUID) (*models.RiskResponse, error)
+	ReviewBatch(ctx context.Context, limit int) (int, error)
+}
+
+type riskService struct {
+	repo      repository.RiskRepository
+	customers customerrepository.CustomerRepository
+	alerts    screeningrepository.ScreeningRepository
+	outbox    events.Outbox
+	audit     audit.Recorder
+	tx        database.Transactor
+	rules     *rules.Ruleset
+}
+
+// NewRiskService creates a new risk service instance. Customers are rated against the
+// given ruleset; every new assessment is written together with its audit entry, and
+// a change of rating also emits an event.
+func NewRiskService(repo repository.RiskRepository, customers customerrepository.CustomerRepository, alerts screeningrepository.ScreeningRepository, outbox events.Outbox, recorder audit.Recorder, tx database.Transactor, ruleset *rules.Ruleset) RiskService {
+	return &riskService{
+		repo:      repo,
+		customers: customers,
+		alerts:    alerts,
+		outbox:    outbox,
+		audit:     recorder,
+		tx:        tx,
+		rules:     ruleset,
+	}
+}
+
+// Rate scores a customer against the risk rules and stores the assessment. An outcome
+// identical to the current assessment is not stored again, except on periodic review,
+// which always sets a new review date. Called with a transactional context, the
+// assessment is committed together with the change that triggered it.
+func (s *riskService) Rate(ctx context.Context, customer *customermodels.Customer, trigger models.Trigger) error {
+	pepHits, err := s.alerts.CountMatches(ctx, customer.ID, screeningmodels.ListCategoryPEP)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now().UTC()
+	result := s.rules.Evaluate(rules.Profile{
+		CustomerType: string(customer.Type),
+		Country:      customer.Address.Country,
+		DateOfBirth:  customer.DateOfBirth,
+		Occupation:   customer.Occupation,
+		PEPHits:      pepHits,
+		Products:     customer.Products,
+	}, now)
+
+	current, err := s.repo.GetLatest(ctx, customer.ID)
+	if err != nil && !errors.Is(err, models.ErrAssessmentNotFound) {
+		return err
+	}
+	if current != nil && trigger != models.TriggerPeriodicReview && s.sameOutcome(current, result) {
+		return nil
+	}
+
+	assessment := &models.Assessment{
+		CustomerID:   customer.ID,
+		Score:        result.Score,
+		Rating:       result.Rating,
+		Factors:      result.Factors,
+		RulesVersion: s.rules.Version,
+		Trigger:      trigger,
+		NextReviewAt: result.NextReviewAt,
+	}
+	return s.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := s.repo.Create(ctx, assessment); err != nil {
+			return err
+		}
+
+		var fromRating models.Rating
+		var fromScore any
+		if current != nil {
+			fromRating, fromScore = current.Rating, current.Score
+		}
+		changes := []audit.Change{
+			{Field: "risk_rating", Before: nilIfEmpty(fromRating), After: assessment.Rating},
+			{Field: "risk_score", Before: fromScore, After: assessment.Score},
+		}
+		if err := s.audit.Record(ctx, audit.ActionRiskAssess, customer.ID, changes); err != nil {
+			return err
+		}
+		if fromRating == assessment.Rating {
+			return nil
+		}
+
+		event, err := events.NewEvent(events.CustomerRiskRatingChanged, customer.ID, models.CustomerRiskRatingChangedPayload{
+			AssessmentID: assessment.ID,
+			FromRating:   fromRating,
+			ToRating:     assessment.Rating,
+			Score:        assessment.Score,
+			Trigger:      assessment.Trigger,
+			NextReviewAt: assessment.NextReviewAt,
+		})
+		if err != nil {
+			return err
+		}
+		return s.outbox.Append(ctx, event)
+	})
+}
+
+// GetRisk returns the current rating of a customer and its history
+func (s *riskService) GetRisk(ctx context.Context, customerID uuid.UUID) (*models.RiskResponse, error) {
+	if _, err := s.customers.GetByID(ctx, customerID); err != nil {
+		return nil, err
+	}
+
+	assessments, err := s.repo.ListByCustomer(ctx, customerID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.audit.RecordAccess(ctx, audit.ActionRiskView, []uuid.UUID{customerID}); err != nil {
+		return nil, err
+	}
+
+	response := &models.RiskResponse{
+		CustomerID: customerID,
+		History:    assessments,
+	}
+	if len(assessments) > 0 {
+		response.Current = &assessments[0]
+	}
+	return response, nil
+}
+
+// ReviewBatch rates the next customers due for review and returns how many were rated.
+// Customers are locked for the duration of the batch, so several instances can share
+// the work.
+func (s *riskService) ReviewBatch(ctx context.Context, limit int) (int, error) {
+	reviewed := 0
+	err := s.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		ids, err := s.repo.ClaimDue(ctx, s.rules.Version, time.Now().UTC(), limit)
+		if err != nil {
+			return err
+		}
+		for _, id := range ids {
+			customer, err := s.customers.GetByID(ctx, id)
+			if err != nil {
+				return err
+			}
+			if err := s.Rate(ctx, customer, models.TriggerPeriodicReview); err != nil {
+				return fmt.Errorf("failed to rate customer %s: %w", id, err)
+			}
+		}
+		reviewed = len(ids)
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return reviewed, nil
+}
+
+// sameOutcome reports whether an evaluation matches an assessment under the current rules
+func (s *riskService) sameOutcome(assessment *models.Assessment, result rules.Result) bool {
+	return assessment.RulesVersion == s.rules.Version &&
+		assessment.Score == result.Score &&
+		assessment.Rating == result.Rating &&
+		slices.Equal(assessment.Factors, result.Factors)
+}
+
+// nilIfEmpty records a missing rating as null in the audit trail
+func nilIfEmpty(rating models.Rating) any {
+	if rating == "" {
+		return nil
+	}
+	return rating
+}
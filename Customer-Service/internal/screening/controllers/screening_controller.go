@@ -0,0 +1,251 @@
+package controllers
+
+import (
+	customermodels "customer-service/internal/customer/models"
+	"customer-service/internal/screening/models"
+	"customer-service/internal/screening/service"
+	"customer-service/pkg/apierror"
+	"customer-service/pkg/middleware"
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// ScreeningController handles HTTP requests for sanctions and PEP screening
+type ScreeningController struct {
+	service service.ScreeningService
+}
+
+// NewScreeningController creates a new screening controller instance
+func NewScreeningController(screeningService service.ScreeningService) *ScreeningController {
+	return &ScreeningController{
+		service: screeningService,
+	}
+}
+
+// ListCustomerAlerts handles GET /customers/:id/screening
+func (ctrl *ScreeningController) ListCustomerAlerts(c *gin.Context) {
+	id, ok := ctrl.parseUUID(c, "id")
+	if !ok {
+		return
+	}
+
+	var query models.AlertQuery
+	if !ctrl.bindQuery(c, &query) {
+		return
+	}
+	query.CustomerID = &id
+
+	alerts, err := ctrl.service.ListAlerts(c.Request.Context(), query)
+	if err != nil {
+		ctrl.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, alerts)
+}
+
+// ScreenCustomer handles POST /customers/:id/screening
+func (ctrl *ScreeningController) ScreenCustomer(c *gin.Context) {
+	id, ok := ctrl.parseUUID(c, "id")
+	if !ok {
+		return
+	}
+
+	result, err := ctrl.service.ScreenCustomer(c.Request.Context(), id)
+	if err != nil {
+		ctrl.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// LoadWatchlist handles POST /screening/watchlists
+func (ctrl *ScreeningController) LoadWatchlist(c *gin.Context) {
+	var req models.LoadWatchlistRequest
+	if !ctrl.bindJSON(c, &req) {
+		return
+	}
+
+	response, err := ctrl.service.LoadWatchlist(c.Request.Context(), req, actorFromContext(c))
+	if err != nil {
+		ctrl.handleError(c, err)
+		return
+	}
+
+	status := http.StatusCreated
+	if response.Unchanged {
+		status = http.StatusOK
+	}
+	c.JSON(status, response)
+}
+
+// ListWatchlists handles GET /screening/watchlists
+func (ctrl *ScreeningController) ListWatchlists(c *gin.Context) {
+	watchlists, err := ctrl.service.ListWatchlists(c.Request.Context())
+	if err != nil {
+		ctrl.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, watchlists)
+}
+
+// GetRun handles GET /screening/runs/:id
+func (ctrl *ScreeningController) GetRun(c *gin.Context) {
+	id, ok := ctrl.parseUUID(c, "id")
+	if !ok {
+		return
+	}
+
+	run, err := ctrl.service.GetRun(c.Request.Context(), id)
+	if err != nil {
+		ctrl.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, run)
+}
+
+// ListAlerts handles GET /screening/alerts
+func (ctrl *ScreeningController) ListAlerts(c *gin.Context) {
+	var query models.AlertQuery
+	if !ctrl.bindQuery(c, &query) {
+		return
+	}
+
+	alerts, err := ctrl.service.ListAlerts(c.Request.Context(), query)
+	if err != nil {
+		ctrl.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, alerts)
+}
+
+// GetAlert handles GET /screening/alerts/:id
+func (ctrl *ScreeningController) GetAlert(c *gin.Context) {
+	id, ok := ctrl.parseUUID(c, "id")
+	if !ok {
+		return
+	}
+
+	alert, err := ctrl.service.GetAlert(c.Request.Context(), id)
+	if err != nil {
+		ctrl.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, alert)
+}
+
+// DispositionAlert handles POST /screening/alerts/:id/disposition
+func (ctrl *ScreeningController) DispositionAlert(c *gin.Context) {
+	id, ok := ctrl.parseUUID(c, "id")
+	if !ok {
+		return
+	}
+
+	var req models.DispositionRequest
+	if !ctrl.bindJSON(c, &req) {
+		return
+	}
+
+	alert, err := ctrl.service.DispositionAlert(c.Request.Context(), id, req, actorFromContext(c))
+	if err != nil {
+		ctrl.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, alert)
+}
+
+// bindJSON decodes and validates the request body, writing an error response on failure
+func (ctrl *ScreeningController) bindJSON(c *gin.Context, req any) bool {
+	if err := c.ShouldBindJSON(req); err != nil {
+		apierror.Abort(c, http.StatusBadRequest, apierror.CodeInvalidRequest, "invalid request body: "+err.Error())
+		return false
+	}
+
+	if err := customermodels.ValidateStruct(req); err != nil {
+		ctrl.handleError(c, err)
+		return false
+	}
+
+	return true
+}
+
+// bindQuery parses the alert filters, writing an error response on failure
+func (ctrl *ScreeningController) bindQuery(c *gin.Context, query *models.AlertQuery) bool {
+	if err := c.ShouldBindQuery(query); err != nil {
+		apierror.Abort(c, http.StatusBadRequest, apierror.CodeInvalidRequest, "invalid query parameters: "+err.Error())
+		return false
+	}
+	switch query.Status {
+	case "", models.AlertStatusOpen, models.AlertStatusConfirmedMatch, models.AlertStatusFalsePositive:
+	default:
+		apierror.Abort(c, http.StatusBadRequest, apierror.CodeValidationFailed, "request validation failed",
+			apierror.FieldError{Field: "status", Message: "must be one of: open confirmed_match false_positive"})
+		return false
+	}
+	switch query.Category {
+	case "", models.ListCategorySanctions, models.ListCategoryPEP:
+	default:
+		apierror.Abort(c, http.StatusBadRequest, apierror.CodeValidationFailed, "request validation failed",
+			apierror.FieldError{Field: "category", Message: "must be one of: sanctions pep"})
+		return false
+	}
+	return true
+}
+
+// parseUUID parses a UUID path parameter, writing an error response on failure
+func (ctrl *ScreeningController) parseUUID(c *gin.Context, param string) (uuid.UUID, bool) {
+	id, err := uuid.Parse(c.Param(param))
+	if err != nil {
+		apierror.Abort(c, http.StatusBadRequest, apierror.CodeInvalidRequest, "invalid "+param,
+			apierror.FieldError{Field: param, Message: "must be a valid UUID"})
+		return uuid.Nil, false
+	}
+	return id, true
+}
+
+// handleError maps service errors to HTTP responses
+func (ctrl *ScreeningController) handleError(c *gin.Context, err error) {
+	var validationErr *customermodels.ValidationError
+	switch {
+	case errors.As(err, &validationErr):
+		fields := make([]apierror.FieldError, len(validationErr.Fields))
+		for i, f := range validationErr.Fields {
+			fields[i] = apierror.FieldError{Field: f.Field, Message: f.Message}
+		}
+		apierror.Abort(c, http.StatusBadRequest, apierror.CodeValidationFailed, "request validation failed", fields...)
+	case errors.Is(err, customermodels.ErrCustomerNotFound),
+		errors.Is(err, models.ErrAlertNotFound),
+		errors.Is(err, models.ErrRunNotFound),
+		errors.Is(err, models.ErrWatchlistNotFound):
+		apierror.Abort(c, http.StatusNotFound, apierror.CodeNotFound, err.Error())
+	case errors.Is(err, models.ErrWatchlistFileNotFound):
+		apierror.Abort(c, http.StatusBadRequest, apierror.CodeValidationFailed, "request validation failed",
+			apierror.FieldError{Field: "file", Message: "does not exist in the watchlist directory"})
+	case errors.Is(err, models.ErrInvalidWatchlistFile):
+		apierror.Abort(c, http.StatusUnprocessableEntity, apierror.CodeInvalidWatchlist, err.Error())
+	case errors.Is(err, models.ErrAlertDispositioned):
+		apierror.Abort(c, http.StatusConflict, apierror.CodeConflict, err.Error())
+	default:
+		log.Printf("Unhandled error processing %s %s: %v", c.Request.Method, c.Request.URL.Path, err)
+		apierror.Abort(c, http.StatusInternalServerError, apierror.CodeInternal, "internal server error")
+	}
+}
+
+// actorFromContext returns the subject of the authenticated caller
+func actorFromContext(c *gin.Context) string {
+	claims, ok := middleware.GetClaims(c)
+	if !ok {
+		return ""
+	}
+	return claims.Subject
+}
@@ -0,0 +1,233 @@
+package matching
+
+import (
+	"customer-service/internal/screening/models"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Score adjustments applied to the name similarity
+const (
+	dobExactBonus   = 0.05
+	dobPartialBonus = 0.02
+	dobMismatch     = -0.15
+	countryBonus    = 0.03
+)
+
+// Outcomes of comparing a date of birth or country, as recorded in MatchDetails
+const (
+	OutcomeExact    = "exact"
+	OutcomePartial  = "partial"
+	OutcomeMismatch = "mismatch"
+	OutcomeMatch    = "match"
+	OutcomeUnknown  = "unknown"
+)
+
+// Subject is the person or organization being screened. Individuals are matched
+// against listed individuals and entities against listed entities; an empty Kind is
+// an individual.
+type Subject struct {
+	Kind        models.EntryKind
+	Name        string
+	DateOfBirth *time.Time
+	Country     string
+}
+
+// Thresholds decide which candidates are reported. A candidate is a match when its
+// name similarity reaches Name and its final score reaches Alert.
+type Thresholds struct {
+	Name  float64
+	Alert float64
+}
+
+// Match is a watchlist entry that matched a subject
+type Match struct {
+	Entry       *models.WatchlistEntry
+	MatchedName string
+	Score       float64
+	Details     models.MatchDetails
+}
+
+type indexedEntry struct {
+	entry     *models.WatchlistEntry
+	names     []indexedName
+	countries []string
+}
+
+type indexedName struct {
+	name   string
+	tokens []string
+}
+
+// Index holds the listed individuals and entities of one or more watchlists for
+// matching. An Index is read-only once built and safe for concurrent use.
+type Index struct {
+	entries []indexedEntry
+	// blocks maps the first two letters of every name token to the entries having it,
+	// so a subject is only compared with entries sharing at least one such prefix
+	blocks map[string][]int
+}
+
+// NewIndex builds an index over the given entries
+func NewIndex(entries []models.WatchlistEntry) *Index {
+	index := &Index{blocks: make(map[string][]int)}
+	for i := range entries {
+		entry := &entries[i]
+		indexed := indexedEntry{entry: entry}
+		for _, name := range entry.Names {
+			if tokens := Tokens(name); len(tokens) > 0 {
+				indexed.names = append(indexed.names, indexedName{name: name, tokens: tokens})
+			}
+		}
+		if len(indexed.names) == 0 {
+			continue
+		}
+		for _, country := range entry.Countries {
+			indexed.countries = append(indexed.countries, Normalize(country))
+		}
+
+		position := len(index.entries)
+		index.entries = append(index.entries, indexed)
+		seen := make(map[string]bool)
+		for _, name := range indexed.names {
+			for _, token := range name.tokens {
+				key := blockKey(token)
+				if !seen[key] {
+					seen[key] = true
+					index.blocks[key] = append(index.blocks[key], position)
+				}
+			}
+		}
+	}
+	return index
+}
+
+// Len returns the number of indexed entries
+func (idx *Index) Len() int {
+	return len(idx.entries)
+}
+
+// Match returns the entries matching the subject, best score first. Each entry is
+// reported once, under its closest name.
+func (idx *Index) Match(subject Subject, thresholds Thresholds) []Match {
+	tokens := Tokens(subject.Name)
+	if len(tokens) == 0 {
+		return nil
+	}
+	country := Normalize(subject.Country)
+	kind := subject.Kind
+	if kind == "" {
+		kind = models.EntryKindIndividual
+	}
+
+	candidates := make(map[int]bool)
+	for _, token := range tokens {
+		for _, position := range idx.blocks[blockKey(token)] {
+			candidates[position] = true
+		}
+	}
+
+	var matches []Match
+	for position := range candidates {
+		indexed := idx.entries[position]
+		if indexed.entry.Kind != kind {
+			continue
+		}
+
+		nameScore, matchedName := 0.0, ""
+		for _, name := range indexed.names {
+			if score := NameSimilarity(tokens, name.tokens); score > nameScore {
+				nameScore, matchedName = score, name.name
+			}
+		}
+		if nameScore < thresholds.Name {
+			continue
+		}
+
+		details := models.MatchDetails{
+			ScreenedName: subject.Name,
+			NameScore:    round(nameScore),
+			DateOfBirth:  compareDateOfBirth(subject.DateOfBirth, indexed.entry.DatesOfBirth),
+			Country:      compareCountry(country, indexed.countries),
+		}
+		score := nameScore
+		switch details.DateOfBirth {
+		case OutcomeExact:
+			score += dobExactBonus
+		case OutcomePartial:
+			score += dobPartialBonus
+		case OutcomeMismatch:
+			score += dobMismatch
+		}
+		if details.Country == OutcomeMatch {
+			score += countryBonus
+		}
+		score = min(max(score, 0), 1)
+		if score < thresholds.Alert {
+			continue
+		}
+
+		matches = append(matches, Match{
+			Entry:       indexed.entry,
+			MatchedName: matchedName,
+			Score:       round(score),
+			Details:     details,
+		})
+	}
+
+	sort.Slice(matches, func(i, j int) bool {
+		if matches[i].Score != matches[j].Score {
+			return matches[i].Score > matches[j].Score
+		}
+		return matches[i].Entry.ExternalID < matches[j].Entry.ExternalID
+	})
+	return matches
+}
+
+// compareDateOfBirth compares a subject's date of birth with the listed ones, which
+// may only state a year or a year and month
+func compareDateOfBirth(dateOfBirth *time.Time, listed []string) string {
+	if dateOfBirth == nil || len(listed) == 0 {
+		return OutcomeUnknown
+	}
+	full := dateOfBirth.Format("2006-01-02")
+	outcome := OutcomeMismatch
+	for _, value := range listed {
+		switch {
+		case value == full:
+			return OutcomeExact
+		case len(value) < len(full) && strings.HasPrefix(full, value):
+			outcome = OutcomePartial
+		}
+	}
+	return outcome
+}
+
+// compareCountry reports whether the subject's country is among the listed ones.
+// A different country is not held against a match, since people move.
+func compareCountry(country string, listed []string) string {
+	if country == "" {
+		return OutcomeUnknown
+	}
+	for _, value := range listed {
+		if value == country {
+			return OutcomeMatch
+		}
+	}
+	return OutcomeUnknown
+}
+
+// blockKey returns the blocking key of a name token
+func blockKey(token string) string {
+	runes := []rune(token)
+	if len(runes) > 2 {
+		runes = runes[:2]
+	}
+	return string(runes)
+}
+
+// round keeps scores to four decimal places
+func round(score float64) float64 {
+	return float64(int(score*10000+0.5)) / 10000
+}
@@ -0,0 +1,144 @@
+package matching
+
+import (
+	"customer-service/internal/screening/models"
+	"math"
+	"testing"
+	"time"
+)
+
+func testIndex() *Index {
+	return NewIndex([]models.WatchlistEntry{
+		{
+			ExternalID: "1", Kind: models.EntryKindIndividual, Names: []string{"Vladimir PUTIN"},
+			DatesOfBirth: []string{"1952-10-07"}, Countries: []string{"Russia"},
+		},
+		{
+			ExternalID: "2", Kind: models.EntryKindIndividual, Names: []string{"Usama BIN LADIN", "Osama bin Laden"},
+			DatesOfBirth: []string{"1957"}, Countries: []string{"SA", "Saudi Arabia"},
+		},
+		{ExternalID: "3", Kind: models.EntryKindEntity, Names: []string{"Rosneft Oil Company"}, Countries: []string{"Russia"}},
+		{ExternalID: "4", Kind: models.EntryKindIndividual, Names: []string{"--"}},
+	})
+}
+
+func TestMatch(t *testing.T) {
+	idx := testIndex()
+	date := func(value string) *time.Time {
+		d, _ := time.Parse("2006-01-02", value)
+		return &d
+	}
+	defaults := Thresholds{Name: 0.85, Alert: 0.88}
+
+	tests := []struct {
+		name       string
+		subject    Subject
+		thresholds Thresholds
+		want       string
+		wantName   string
+		wantScore  float64
+		wantDOB    string
+		wantCtry   string
+	}{
+		{
+			name:    "same name",
+			subject: Subject{Name: "Vladimir Putin"},
+			want:    "1", wantName: "Vladimir PUTIN", wantScore: 1, wantDOB: OutcomeUnknown, wantCtry: OutcomeUnknown,
+		},
+		{
+			name:    "reordered name with date of birth and country",
+			subject: Subject{Name: "Putin Vladimir", DateOfBirth: date("1952-10-07"), Country: "RUSSIA"},
+			want:    "1", wantName: "Vladimir PUTIN", wantScore: 1, wantDOB: OutcomeExact, wantCtry: OutcomeMatch,
+		},
+		{
+			name:    "misspelled name",
+			subject: Subject{Name: "Vladimir Poutine"},
+			want:    "1", wantName: "Vladimir PUTIN", wantScore: 0.975, wantDOB: OutcomeUnknown, wantCtry: OutcomeUnknown,
+		},
+		{
+			name:    "closest alias",
+			subject: Subject{Name: "Osama Ladin"},
+			want:    "2", wantName: "Osama bin Laden", wantScore: 0.9012, wantDOB: OutcomeUnknown, wantCtry: OutcomeUnknown,
+		},
+		{
+			name:    "year of birth",
+			subject: Subject{Name: "Osama Ladin", DateOfBirth: date("1957-03-10")},
+			want:    "2", wantName: "Osama bin Laden", wantScore: 0.9212, wantDOB: OutcomePartial, wantCtry: OutcomeUnknown,
+		},
+		{
+			name:    "other date of birth",
+			subject: Subject{Name: "Vladimir Putin", DateOfBirth: date("1980-01-01")},
+		},
+		{
+			name:       "other date of birth under a lower alert threshold",
+			subject:    Subject{Name: "Vladimir Putin", DateOfBirth: date("1980-01-01")},
+			thresholds: Thresholds{Name: 0.85, Alert: 0.85},
+			want:       "1", wantName: "Vladimir PUTIN", wantScore: 0.85, wantDOB: OutcomeMismatch, wantCtry: OutcomeUnknown,
+		},
+		{
+			name:    "entity",
+			subject: Subject{Kind: models.EntryKindEntity, Name: "Rosneft Oil"},
+			want:    "3", wantName: "Rosneft Oil Company", wantScore: 0.9158, wantDOB: OutcomeUnknown, wantCtry: OutcomeUnknown,
+		},
+		{name: "entity name screened as an individual", subject: Subject{Name: "Rosneft Oil Company"}},
+		{name: "individual name screened as an entity", subject: Subject{Kind: models.EntryKindEntity, Name: "Vladimir Putin"}},
+		{name: "different name", subject: Subject{Name: "Jane Doe"}},
+		{name: "name without letters", subject: Subject{Name: "--"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			thresholds := tt.thresholds
+			if thresholds == (Thresholds{}) {
+				thresholds = defaults
+			}
+			matches := idx.Match(tt.subject, thresholds)
+			if tt.want == "" {
+				if len(matches) != 0 {
+					t.Errorf("Match() = %+v, want no match", matches)
+				}
+				return
+			}
+			if len(matches) != 1 {
+				t.Fatalf("Match() = %+v, want entry %s", matches, tt.want)
+			}
+			got := matches[0]
+			if got.Entry.ExternalID != tt.want || got.MatchedName != tt.wantName {
+				t.Errorf("Match() = entry %s under %q, want %s under %q", got.Entry.ExternalID, got.MatchedName, tt.want, tt.wantName)
+			}
+			if got.Score != tt.wantScore {
+				t.Errorf("Score = %v, want %v", got.Score, tt.wantScore)
+			}
+			if got.Details.DateOfBirth != tt.wantDOB || got.Details.Country != tt.wantCtry {
+				t.Errorf("Details = %+v, want date of birth %s and country %s", got.Details, tt.wantDOB, tt.wantCtry)
+			}
+		})
+	}
+}
+
+func TestMatchThresholdBoundaries(t *testing.T) {
+	idx := testIndex()
+	subject := Subject{Name: "Osama Ladin"}
+	score := NameSimilarity(Tokens(subject.Name), Tokens("Osama bin Laden"))
+	above := math.Nextafter(score, 2)
+
+	tests := []struct {
+		name       string
+		thresholds Thresholds
+		want       bool
+	}{
+		{name: "both thresholds at the score", thresholds: Thresholds{Name: score, Alert: score}, want: true},
+		{name: "name threshold above the score", thresholds: Thresholds{Name: above, Alert: score}},
+		{name: "alert threshold above the score", thresholds: Thresholds{Name: score, Alert: above}},
+		{name: "thresholds below the score", thresholds: Thresholds{Name: 0.5, Alert: 0.5}, want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			matches := idx.Match(subject, tt.thresholds)
+			if got := len(matches) == 1 && matches[0].Entry.ExternalID == "2"; got != tt.want {
+				t.Errorf("Match() at a score of %v = %+v, want a match: %v", score, matches, tt.want)
+			}
+		})
+	}
+}
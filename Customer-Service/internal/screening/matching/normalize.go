@@ -0,0 +1,47 @@
+package matching
+
+import (
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/runes"
+	"golang.org/x/text/transform"
+	"golang.org/x/text/unicode/norm"
+)
+
+// foldings covers letters that do not decompose into a base letter and a mark
+var foldings = strings.NewReplacer(
+	"ß", "ss", "ø", "o", "Ø", "o", "æ", "ae", "Æ", "ae", "œ", "oe", "Œ", "oe",
+	"ł", "l", "Ł", "l", "đ", "d", "Đ", "d", "ı", "i", "þ", "th", "Þ", "th",
+)
+
+// Normalize folds a name for comparison: diacritics are removed, letters are
+// lowercased and everything other than letters and digits becomes a single space
+func Normalize(value string) string {
+	stripped, _, err := transform.String(transform.Chain(norm.NFD, runes.Remove(runes.In(unicode.Mn)), norm.NFC), value)
+	if err != nil {
+		stripped = value
+	}
+	stripped = foldings.Replace(stripped)
+
+	var b strings.Builder
+	b.Grow(len(stripped))
+	space := false
+	for _, r := range stripped {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			if space && b.Len() > 0 {
+				b.WriteByte(' ')
+			}
+			b.WriteRune(unicode.ToLower(r))
+			space = false
+			continue
+		}
+		space = true
+	}
+	return b.String()
+}
+
+// Tokens splits a name into its normalized words
+func Tokens(value string) []string {
+	return strings.Fields(Normalize(value))
+}
@@ -0,0 +1,109 @@
+package matching
+
+import "strings"
+
+// JaroWinkler returns the Jaro-Winkler similarity of two strings, from 0 for
+// nothing in common to 1 for identical strings
+func JaroWinkler(a, b string) float64 {
+	s1, s2 := []rune(a), []rune(b)
+	if len(s1) == 0 && len(s2) == 0 {
+		return 1
+	}
+	if len(s1) == 0 || len(s2) == 0 {
+		return 0
+	}
+
+	window := max(len(s1), len(s2))/2 - 1
+	if window < 0 {
+		window = 0
+	}
+	matched1 := make([]bool, len(s1))
+	matched2 := make([]bool, len(s2))
+
+	matches := 0
+	for i := range s1 {
+		lo := max(0, i-window)
+		hi := min(len(s2), i+window+1)
+		for j := lo; j < hi; j++ {
+			if matched2[j] || s1[i] != s2[j] {
+				continue
+			}
+			matched1[i], matched2[j] = true, true
+			matches++
+			break
+		}
+	}
+	if matches == 0 {
+		return 0
+	}
+
+	transpositions := 0
+	j := 0
+	for i := range s1 {
+		if !matched1[i] {
+			continue
+		}
+		for !matched2[j] {
+			j++
+		}
+		if s1[i] != s2[j] {
+			transpositions++
+		}
+		j++
+	}
+
+	m := float64(matches)
+	jaro := (m/float64(len(s1)) + m/float64(len(s2)) + (m-float64(transpositions)/2)/m) / 3
+
+	prefix := 0
+	for prefix < min(4, len(s1), len(s2)) && s1[prefix] == s2[prefix] {
+		prefix++
+	}
+	return jaro + float64(prefix)*0.1*(1-jaro)
+}
+
+// NameSimilarity compares two tokenized names. Each token of the shorter name is
+// paired with its closest unused token of the other, so that word order and extra
+// middle names matter little; the result is the better of that and a comparison
+// of the names as written.
+func NameSimilarity(a, b []string) float64 {
+	if len(a) == 0 || len(b) == 0 {
+		return 0
+	}
+	if len(a) > len(b) {
+		a, b = b, a
+	}
+
+	used := make([]bool, len(b))
+	total := 0.0
+	for _, token := range a {
+		best, bestIndex := 0.0, -1
+		for i, candidate := range b {
+			if used[i] {
+				continue
+			}
+			if score := JaroWinkler(token, candidate); score > best {
+				best, bestIndex = score, i
+			}
+		}
+		if bestIndex >= 0 {
+			used[bestIndex] = true
+		}
+		total += best
+	}
+	coverage := float64(len(a)) / float64(len(b))
+	tokenScore := total / float64(len(a)) * (0.7 + 0.3*coverage)
+
+	joined := strings.Join(b, " ")
+	whole := max(JaroWinkler(strings.Join(a, " "), joined), JaroWinkler(strings.Join(reversed(a), " "), joined))
+	return max(tokenScore, whole)
+}
+
+// reversed returns a reversed copy of tokens, to compare "Last First" with "First Last"
+func reversed(tokens []string) []string {
+	out := make([]string, len(tokens))
+	for i, token := range tokens {
+		out[len(tokens)-1-i] = token
+	}
+	return out
+}
@@ -0,0 +1,65 @@
+package matching
+
+import (
+	"math"
+	"testing"
+)
+
+func TestJaroWinkler(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b string
+		want float64
+	}{
+		{name: "identical", a: "martha", b: "martha", want: 1},
+		{name: "both empty", a: "", b: "", want: 1},
+		{name: "one empty", a: "martha", b: "", want: 0},
+		{name: "nothing in common", a: "abc", b: "xyz", want: 0},
+		{name: "transposition", a: "martha", b: "marhta", want: 0.9611},
+		{name: "transposition is symmetric", a: "marhta", b: "martha", want: 0.9611},
+		{name: "missing letter", a: "dwayne", b: "duane", want: 0.84},
+		{name: "extra letters", a: "dixon", b: "dicksonx", want: 0.8133},
+		{name: "different first letter", a: "osama", b: "usama", want: 0.8667},
+		{name: "transliteration", a: "mohammed", b: "muhammad", want: 0.85},
+		{name: "letters compared as runes", a: "müller", b: "muller", want: 0.9},
+		{name: "swap outside the match window", a: "ab", b: "ba", want: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := JaroWinkler(tt.a, tt.b); math.Abs(got-tt.want) > 0.0001 {
+				t.Errorf("JaroWinkler(%q, %q) = %.4f, want %.4f", tt.a, tt.b, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNameSimilarity(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b string
+		// min and max bound the similarity
+		min, max float64
+	}{
+		{name: "same name", a: "Vladimir Putin", b: "Vladimir Putin", min: 1, max: 1},
+		{name: "reordered tokens", a: "Vladimir Putin", b: "PUTIN, Vladimir", min: 1, max: 1},
+		{name: "hyphenated token", a: "Kim Jong Un", b: "Kim Jong-un", min: 1, max: 1},
+		{name: "diacritics", a: "José Müller", b: "Jose Muller", min: 1, max: 1},
+		{name: "folded letters", a: "Łukasz Strøm", b: "Lukasz Strom", min: 1, max: 1},
+		{name: "transposed letters", a: "Jon Smtih", b: "John Smith", min: 0.94, max: 0.95},
+		{name: "extra middle name", a: "John Smith", b: "John Michael Smith", min: 0.9, max: 0.9},
+		{name: "transliterated name", a: "Osama bin Laden", b: "Usama BIN LADIN", min: 0.92, max: 0.93},
+		{name: "one of many tokens", a: "Ali", b: "Ali Hassan Mohammed Al Tikriti", min: 0.79, max: 0.79},
+		{name: "different names", a: "Jane Doe", b: "Vladimir Putin", min: 0, max: 0.5},
+		{name: "no tokens", a: "", b: "Vladimir Putin", min: 0, max: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := NameSimilarity(Tokens(tt.a), Tokens(tt.b))
+			if got < tt.min-0.0001 || got > tt.max+0.0001 {
+				t.Errorf("NameSimilarity(%q, %q) = %.4f, want %.2f to %.2f", tt.a, tt.b, got, tt.min, tt.max)
+			}
+		})
+	}
+}
@@ -0,0 +1,24 @@
+package models
+
+import "github.com/google/uuid"
+
+// CustomerScreeningAlertRaisedPayload is the payload of a CustomerScreeningAlertRaised event
+type CustomerScreeningAlertRaisedPayload struct {
+	AlertID     uuid.UUID    `json:"alert_id"`
+	ListName    string       `json:"list_name"`
+	Category    ListCategory `json:"category"`
+	ExternalID  string       `json:"external_id"`
+	MatchedName string       `json:"matched_name"`
+	Score       float64      `json:"score"`
+	Trigger     Trigger      `json:"trigger"`
+}
+
+// CustomerScreeningAlertDispositionedPayload is the payload of a CustomerScreeningAlertDispositioned event
+type CustomerScreeningAlertDispositionedPayload struct {
+	AlertID  uuid.UUID    `json:"alert_id"`
+	ListName string       `json:"list_name"`
+	Category ListCategory `json:"category"`
+	Status   AlertStatus  `json:"status"`
+	Note     string       `json:"note"`
+	Actor    string       `json:"actor"`
+}
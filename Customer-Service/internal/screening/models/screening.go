@@ -0,0 +1,217 @@
+package models
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Domain errors returned by the screening repository and service layers
+var (
+	ErrWatchlistNotFound     = errors.New("watchlist not found")
+	ErrAlertNotFound         = errors.New("screening alert not found")
+	ErrRunNotFound           = errors.New("screening run not found")
+	ErrAlertDispositioned    = errors.New("screening alert has already been dispositioned")
+	ErrInvalidWatchlistFile  = errors.New("invalid watchlist file")
+	ErrWatchlistFileNotFound = errors.New("watchlist file not found")
+)
+
+// ListCategory separates sanctions lists from politically exposed person (PEP) lists
+type ListCategory string
+
+const (
+	ListCategorySanctions ListCategory = "sanctions"
+	ListCategoryPEP       ListCategory = "pep"
+)
+
+// ListFormat is the file format a watchlist is loaded from
+type ListFormat string
+
+const (
+	// ListFormatOFACSDN is the OFAC Specially Designated Nationals SDN.CSV file
+	ListFormatOFACSDN ListFormat = "ofac_sdn"
+	// ListFormatEUConsolidated is the EU consolidated financial sanctions XML file
+	ListFormatEUConsolidated ListFormat = "eu_consolidated"
+)
+
+// EntryKind distinguishes listed individuals from entities, vessels and aircraft
+type EntryKind string
+
+const (
+	EntryKindIndividual EntryKind = "individual"
+	EntryKindEntity     EntryKind = "entity"
+)
+
+// Watchlist is one loaded version of a named list. Loading a list with the same
+// name again replaces the active version.
+type Watchlist struct {
+	ID         uuid.UUID    `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	Name       string       `json:"name" gorm:"not null;size:100"`
+	Format     ListFormat   `json:"format" gorm:"not null;size:30"`
+	Category   ListCategory `json:"category" gorm:"not null;size:20"`
+	FileName   string       `json:"file_name" gorm:"not null;size:255"`
+	SHA256     string       `json:"sha256" gorm:"column:sha256;not null;size:64"`
+	EntryCount int          `json:"entry_count" gorm:"not null"`
+	Active     bool         `json:"active" gorm:"not null"`
+	LoadedBy   string       `json:"loaded_by" gorm:"not null;size:255"`
+	CreatedAt  time.Time    `json:"loaded_at"`
+}
+
+// TableName returns the table name for Watchlist model
+func (Watchlist) TableName() string {
+	return "watchlists"
+}
+
+// WatchlistEntry is a single listed party. Names holds the primary name first,
+// followed by its aliases. Dates of birth are YYYY-MM-DD, YYYY-MM or YYYY,
+// depending on how precisely the list states them.
+type WatchlistEntry struct {
+	ID           uuid.UUID `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	WatchlistID  uuid.UUID `json:"watchlist_id" gorm:"type:uuid;not null;index"`
+	ExternalID   string    `json:"external_id" gorm:"not null;size:100"`
+	Kind         EntryKind `json:"kind" gorm:"not null;size:20"`
+	Names        []string  `json:"names" gorm:"type:jsonb;serializer:json;not null"`
+	DatesOfBirth []string  `json:"dates_of_birth" gorm:"type:jsonb;serializer:json;not null"`
+	Countries    []string  `json:"countries" gorm:"type:jsonb;serializer:json;not null"`
+	Programs     []string  `json:"programs" gorm:"type:jsonb;serializer:json;not null"`
+}
+
+// TableName returns the table name for WatchlistEntry model
+func (WatchlistEntry) TableName() string {
+	return "watchlist_entries"
+}
+
+// AlertStatus represents the analyst disposition of an alert
+type AlertStatus string
+
+const (
+	AlertStatusOpen           AlertStatus = "open"
+	AlertStatusConfirmedMatch AlertStatus = "confirmed_match"
+	AlertStatusFalsePositive  AlertStatus = "false_positive"
+)
+
+// Trigger records why a customer was screened
+type Trigger string
+
+const (
+	TriggerOnboarding     Trigger = "onboarding"
+	TriggerCustomerUpdate Trigger = "customer_update"
+	TriggerRescreen       Trigger = "rescreen"
+	TriggerManual         Trigger = "manual"
+)
+
+// MatchDetails explains how an alert's score was reached
+type MatchDetails struct {
+	ScreenedName string  `json:"screened_name"`
+	NameScore    float64 `json:"name_score"`
+	// DateOfBirth is exact, partial, mismatch or unknown
+	DateOfBirth string `json:"date_of_birth"`
+	// Country is match or unknown
+	Country string `json:"country"`
+}
+
+// Alert is a potential watchlist match waiting for, or carrying, an analyst disposition.
+// A customer is alerted at most once per listed party for the same screened details.
+type Alert struct {
+	ID                 uuid.UUID    `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	CustomerID         uuid.UUID    `json:"customer_id" gorm:"type:uuid;not null;index"`
+	WatchlistID        uuid.UUID    `json:"watchlist_id" gorm:"type:uuid;not null"`
+	EntryID            *uuid.UUID   `json:"entry_id,omitempty" gorm:"type:uuid"`
+	ListName           string       `json:"list_name" gorm:"not null;size:100"`
+	Category           ListCategory `json:"category" gorm:"not null;size:20"`
+	ExternalID         string       `json:"external_id" gorm:"not null;size:100"`
+	MatchedName        string       `json:"matched_name" gorm:"not null;size:500"`
+	Score              float64      `json:"score" gorm:"not null"`
+	Details            MatchDetails `json:"details" gorm:"type:jsonb;serializer:json;not null"`
+	SubjectFingerprint string       `json:"-" gorm:"not null;size:64"`
+	Trigger            Trigger      `json:"trigger" gorm:"not null;size:20"`
+	Status             AlertStatus  `json:"status" gorm:"not null;size:20"`
+	DispositionNote    string       `json:"disposition_note,omitempty" gorm:"size:1000"`
+	DispositionedBy    string       `json:"dispositioned_by,omitempty" gorm:"size:255"`
+	DispositionedAt    *time.Time   `json:"dispositioned_at,omitempty"`
+	CreatedAt          time.Time    `json:"created_at"`
+	UpdatedAt          time.Time    `json:"updated_at"`
+}
+
+// TableName returns the table name for Alert model
+func (Alert) TableName() string {
+	return "screening_alerts"
+}
+
+// RunStatus represents the progress of a rescreening of the customer base
+type RunStatus string
+
+const (
+	RunStatusRunning   RunStatus = "running"
+	RunStatusCompleted RunStatus = "completed"
+	// RunStatusSuperseded marks a run replaced by a newer one before it finished
+	RunStatusSuperseded RunStatus = "superseded"
+)
+
+// Run is a rescreening of every customer, processed in batches by the background
+// worker. Cursor is the ID of the last customer screened.
+type Run struct {
+	ID          uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	WatchlistID *uuid.UUID `json:"watchlist_id,omitempty" gorm:"type:uuid"`
+	Status      RunStatus  `json:"status" gorm:"not null;size:20"`
+	Cursor      uuid.UUID  `json:"-" gorm:"type:uuid;not null"`
+	Screened    int        `json:"screened" gorm:"not null"`
+	Alerts      int        `json:"alerts" gorm:"not null"`
+	StartedAt   time.Time  `json:"started_at" gorm:"not null"`
+	FinishedAt  *time.Time `json:"finished_at,omitempty"`
+}
+
+// TableName returns the table name for Run model
+func (Run) TableName() string {
+	return "screening_runs"
+}
+
+// LoadWatchlistRequest represents the request payload for loading a watchlist. File
+// is a path relative to the configured watchlist directory.
+type LoadWatchlistRequest struct {
+	Name     string       `json:"name" validate:"required,max=100"`
+	Format   ListFormat   `json:"format" validate:"required,oneof=ofac_sdn eu_consolidated"`
+	Category ListCategory `json:"category" validate:"required,oneof=sanctions pep"`
+	File     string       `json:"file" validate:"required,max=255"`
+}
+
+// LoadWatchlistResponse represents the outcome of loading a watchlist. Run is
+// absent when the file was identical to the active version.
+type LoadWatchlistResponse struct {
+	Watchlist Watchlist `json:"watchlist"`
+	Unchanged bool      `json:"unchanged"`
+	Run       *Run      `json:"run,omitempty"`
+}
+
+// DispositionRequest represents an analyst's ruling on an alert
+type DispositionRequest struct {
+	Status AlertStatus `json:"status" validate:"required,oneof=confirmed_match false_positive"`
+	Note   string      `json:"note" validate:"required,max=1000"`
+}
+
+// AlertQuery filters screening alerts. Zero values match everything.
+type AlertQuery struct {
+	CustomerID *uuid.UUID   `form:"-"`
+	Status     AlertStatus  `form:"status"`
+	Category   ListCategory `form:"category"`
+	Page       int          `form:"page"`
+	PageSize   int          `form:"page_size"`
+}
+
+// AlertListResponse represents a page of screening alerts
+type AlertListResponse struct {
+	Alerts     []Alert `json:"alerts"`
+	Total      int64   `json:"total"`
+	Page       int     `json:"page"`
+	PageSize   int     `json:"page_size"`
+	TotalPages int     `json:"total_pages"`
+}
+
+// ScreeningResult represents the outcome of screening one customer
+type ScreeningResult struct {
+	CustomerID uuid.UUID `json:"customer_id"`
+	ScreenedAt time.Time `json:"screened_at"`
+	// AlertsRaised lists the alerts created by this screening; known matches are not repeated
+	AlertsRaised []Alert `json:"alerts_raised"`
+}
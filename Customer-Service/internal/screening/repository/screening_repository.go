@@ -0,0 +1,282 @@
+package repository
+
+import (
+	"context"
+	"customer-service/internal/database"
+	"customer-service/internal/screening/models"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// entryBatchSize is how many watchlist entries are inserted per statement
+const entryBatchSize = 500
+
+// ScreeningRepository defines the interface for watchlist, alert and run data access
+type ScreeningRepository interface {
+	GetActiveWatchlist(ctx context.Context, name string) (*models.Watchlist, error)
+	ListWatchlists(ctx context.Context) ([]models.Watchlist, error)
+	ListActiveWatchlists(ctx context.Context) ([]models.Watchlist, error)
+	ReplaceWatchlist(ctx context.Context, watchlist *models.Watchlist, entries []models.WatchlistEntry) error
+	ListActiveEntries(ctx context.Context) ([]models.WatchlistEntry, error)
+
+	CreateAlert(ctx context.Context, alert *models.Alert) (bool, error)
+	GetAlert(ctx context.Context, id uuid.UUID) (*models.Alert, error)
+	ListAlerts(ctx context.Context, query models.AlertQuery) ([]models.Alert, int64, error)
+	DispositionAlert(ctx context.Context, alert *models.Alert) error
+	CountMatches(ctx context.Context, customerID uuid.UUID, category models.ListCategory) (int, error)
+
+	CreateRun(ctx context.Context, run *models.Run) error
+	SupersedeRuns(ctx context.Context, now time.Time) error
+	ClaimRun(ctx context.Context) (*models.Run, error)
+	GetRun(ctx context.Context, id uuid.UUID) (*models.Run, error)
+	UpdateRun(ctx context.Context, run *models.Run) error
+}
+
+type screeningRepository struct {
+	db *gorm.DB
+}
+
+// NewScreeningRepository creates a new screening repository instance
+func NewScreeningRepository(db *gorm.DB) ScreeningRepository {
+	return &screeningRepository{db: db}
+}
+
+// GetActiveWatchlist retrieves the active version of a named watchlist
+func (r *screeningRepository) GetActiveWatchlist(ctx context.Context, name string) (*models.Watchlist, error) {
+	var watchlist models.Watchlist
+	err := database.Conn(ctx, r.db).
+		Where("name = ? AND active", name).
+		Take(&watchlist).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, models.ErrWatchlistNotFound
+		}
+		return nil, fmt.Errorf("failed to get watchlist: %w", err)
+	}
+	return &watchlist, nil
+}
+
+// ListWatchlists returns every loaded watchlist version, newest first
+func (r *screeningRepository) ListWatchlists(ctx context.Context) ([]models.Watchlist, error) {
+	var watchlists []models.Watchlist
+	if err := database.Conn(ctx, r.db).Order("created_at DESC").Find(&watchlists).Error; err != nil {
+		return nil, fmt.Errorf("failed to list watchlists: %w", err)
+	}
+	return watchlists, nil
+}
+
+// ListActiveWatchlists returns the active version of every watchlist, in ID order
+func (r *screeningRepository) ListActiveWatchlists(ctx context.Context) ([]models.Watchlist, error) {
+	var watchlists []models.Watchlist
+	if err := database.Conn(ctx, r.db).Where("active").Order("id").Find(&watchlists).Error; err != nil {
+		return nil, fmt.Errorf("failed to list active watchlists: %w", err)
+	}
+	return watchlists, nil
+}
+
+// ReplaceWatchlist stores a new version of a watchlist with its entries and retires the
+// previous version of the same name, whose entries are removed. Alerts raised against
+// the previous version keep their copy of the matched details.
+func (r *screeningRepository) ReplaceWatchlist(ctx context.Context, watchlist *models.Watchlist, entries []models.WatchlistEntry) error {
+	db := database.Conn(ctx, r.db)
+	return db.Transaction(func(tx *gorm.DB) error {
+		var previous []uuid.UUID
+		err := tx.Model(&models.Watchlist{}).
+			Where("name = ? AND active", watchlist.Name).
+			Pluck("id", &previous).Error
+		if err != nil {
+			return fmt.Errorf("failed to find previous watchlist: %w", err)
+		}
+		if len(previous) > 0 {
+			if err := tx.Where("watchlist_id IN ?", previous).Delete(&models.WatchlistEntry{}).Error; err != nil {
+				return fmt.Errorf("failed to remove previous watchlist entries: %w", err)
+			}
+			if err := tx.Model(&models.Watchlist{}).Where("id IN ?", previous).Update("active", false).Error; err != nil {
+				return fmt.Errorf("failed to retire previous watchlist: %w", err)
+			}
+		}
+
+		watchlist.Active = true
+		watchlist.EntryCount = len(entries)
+		if err := tx.Create(watchlist).Error; err != nil {
+			return fmt.Errorf("failed to create watchlist: %w", err)
+		}
+		for i := range entries {
+			entries[i].WatchlistID = watchlist.ID
+		}
+		if err := tx.CreateInBatches(entries, entryBatchSize).Error; err != nil {
+			return fmt.Errorf("failed to create watchlist entries: %w", err)
+		}
+		return nil
+	})
+}
+
+// ListActiveEntries returns the listed individuals of every active watchlist
+func (r *screeningRepository) ListActiveEntries(ctx context.Context) ([]models.WatchlistEntry, error) {
+	var entries []models.WatchlistEntry
+	err := database.Conn(ctx, r.db).
+		Joins("JOIN watchlists ON watchlists.id = watchlist_entries.watchlist_id").
+		Where("watchlists.active AND watchlist_entries.kind = ?", models.EntryKindIndividual).
+		Find(&entries).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list watchlist entries: %w", err)
+	}
+	return entries, nil
+}
+
+// CreateAlert stores an alert unless the customer was already alerted for the same
+// listed party and screened details. It reports whether the alert was created.
+func (r *screeningRepository) CreateAlert(ctx context.Context, alert *models.Alert) (bool, error) {
+	result := database.Conn(ctx, r.db).
+		Clauses(clause.OnConflict{DoNothing: true}).
+		Create(alert)
+	if result.Error != nil {
+		return false, fmt.Errorf("failed to create screening alert: %w", result.Error)
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// GetAlert retrieves an alert by ID
+func (r *screeningRepository) GetAlert(ctx context.Context, id uuid.UUID) (*models.Alert, error) {
+	var alert models.Alert
+	if err := database.Conn(ctx, r.db).Where("id = ?", id).First(&alert).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, models.ErrAlertNotFound
+		}
+		return nil, fmt.Errorf("failed to get screening alert: %w", err)
+	}
+	return &alert, nil
+}
+
+// ListAlerts returns a page of alerts matching the query, newest first
+func (r *screeningRepository) ListAlerts(ctx context.Context, query models.AlertQuery) ([]models.Alert, int64, error) {
+	db := database.Conn(ctx, r.db).Model(&models.Alert{})
+	if query.CustomerID != nil {
+		db = db.Where("customer_id = ?", *query.CustomerID)
+	}
+	if query.Status != "" {
+		db = db.Where("status = ?", query.Status)
+	}
+	if query.Category != "" {
+		db = db.Where("category = ?", query.Category)
+	}
+
+	var total int64
+	if err := db.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to count screening alerts: %w", err)
+	}
+
+	var alerts []models.Alert
+	err := db.Order("created_at DESC").
+		Limit(query.PageSize).
+		Offset((query.Page - 1) * query.PageSize).
+		Find(&alerts).Error
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list screening alerts: %w", err)
+	}
+	return alerts, total, nil
+}
+
+// DispositionAlert saves the disposition of an alert, provided it is still open. It
+// returns ErrAlertDispositioned when another analyst ruled on it first.
+func (r *screeningRepository) DispositionAlert(ctx context.Context, alert *models.Alert) error {
+	alert.UpdatedAt = time.Now()
+	result := database.Conn(ctx, r.db).Model(&models.Alert{}).
+		Where("id = ? AND status = ?", alert.ID, models.AlertStatusOpen).
+		Updates(map[string]interface{}{
+			"status":           alert.Status,
+			"disposition_note": alert.DispositionNote,
+			"dispositioned_by": alert.DispositionedBy,
+			"dispositioned_at": alert.DispositionedAt,
+			"updated_at":       alert.UpdatedAt,
+		})
+	if result.Error != nil {
+		return fmt.Errorf("failed to update screening alert: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return models.ErrAlertDispositioned
+	}
+	return nil
+}
+
+// CountMatches counts a customer's alerts in a list category that were not ruled a
+// false positive
+func (r *screeningRepository) CountMatches(ctx context.Context, customerID uuid.UUID, category models.ListCategory) (int, error) {
+	var count int64
+	err := database.Conn(ctx, r.db).Model(&models.Alert{}).
+		Where("customer_id = ? AND category = ? AND status <> ?", customerID, category, models.AlertStatusFalsePositive).
+		Count(&count).Error
+	if err != nil {
+		return 0, fmt.Errorf("failed to count screening alerts: %w", err)
+	}
+	return int(count), nil
+}
+
+// CreateRun stores a new rescreening run
+func (r *screeningRepository) CreateRun(ctx context.Context, run *models.Run) error {
+	if err := database.Conn(ctx, r.db).Create(run).Error; err != nil {
+		return fmt.Errorf("failed to create screening run: %w", err)
+	}
+	return nil
+}
+
+// SupersedeRuns stops every running run; a new run screens the whole base anyway
+func (r *screeningRepository) SupersedeRuns(ctx context.Context, now time.Time) error {
+	err := database.Conn(ctx, r.db).Model(&models.Run{}).
+		Where("status = ?", models.RunStatusRunning).
+		Updates(map[string]interface{}{
+			"status":      models.RunStatusSuperseded,
+			"finished_at": now,
+		}).Error
+	if err != nil {
+		return fmt.Errorf("failed to supersede screening runs: %w", err)
+	}
+	return nil
+}
+
+// ClaimRun locks the oldest running run until the surrounding transaction ends,
+// skipping runs another instance is processing. It returns ErrRunNotFound when
+// there is nothing to do.
+func (r *screeningRepository) ClaimRun(ctx context.Context) (*models.Run, error) {
+	var run models.Run
+	err := database.Conn(ctx, r.db).
+		Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+		Where("status = ?", models.RunStatusRunning).
+		Order("started_at").
+		Take(&run).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, models.ErrRunNotFound
+		}
+		return nil, fmt.Errorf("failed to claim screening run: %w", err)
+	}
+	return &run, nil
+}
+
+// GetRun retrieves a run by ID
+func (r *screeningRepository) GetRun(ctx context.Context, id uuid.UUID) (*models.Run, error) {
+	var run models.Run
+	if err := database.Conn(ctx, r.db).Where("id = ?", id).First(&run).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, models.ErrRunNotFound
+		}
+		return nil, fmt.Errorf("failed to get screening run: %w", err)
+	}
+	return &run, nil
+}
+
+// UpdateRun saves the progress of a run
+func (r *screeningRepository) UpdateRun(ctx context.Context, run *models.Run) error {
+	err := database.Conn(ctx, r.db).Model(run).
+		Select("status", "cursor", "screened", "alerts", "finished_at").
+		Updates(run).Error
+	if err != nil {
+		return fmt.Errorf("failed to update screening run: %w", err)
+	}
+	return nil
+}
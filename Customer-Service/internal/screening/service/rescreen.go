@@ -0,0 +1,68 @@
+package service
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// RescreenConfig controls how often pending rescreening runs are picked up and how
+// many customers are screened per transaction
+type RescreenConfig struct {
+	Interval  time.Duration
+	BatchSize int
+}
+
+// Rescreener screens the whole customer base again after a watchlist is loaded. Runs
+// are processed in batches that each commit the alerts raised and the run's progress,
+// so a restart resumes where the previous process stopped.
+type Rescreener struct {
+	service ScreeningService
+	cfg     RescreenConfig
+}
+
+// NewRescreener creates a new rescreening job
+func NewRescreener(service ScreeningService, cfg RescreenConfig) *Rescreener {
+	if cfg.Interval <= 0 {
+		cfg.Interval = 10 * time.Second
+	}
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = 100
+	}
+
+	return &Rescreener{
+		service: service,
+		cfg:     cfg,
+	}
+}
+
+// Run processes rescreening runs every interval until ctx is cancelled
+func (r *Rescreener) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.cfg.Interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := r.RunOnce(ctx); err != nil && ctx.Err() == nil {
+			log.Printf("Screening rescreen: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunOnce screens batches until no run has customers left and returns how many
+// customers were screened
+func (r *Rescreener) RunOnce(ctx context.Context) (int, error) {
+	total := 0
+	for {
+		screened, err := r.service.RescreenBatch(ctx, r.cfg.BatchSize)
+		total += screened
+		if err != nil || screened < r.cfg.BatchSize {
+			return total, err
+		}
+	}
+}
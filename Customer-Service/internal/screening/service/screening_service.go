@@ -0,0 +1,576 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"customer-service/internal/audit"
+	customermodels "customer-service/internal/customer/models"
+	customerrepository "customer-service/internal/customer/repository"
+	"customer-service/internal/database"
+	"customer-service/internal/encryption"
+	"customer-service/internal/events"
+	riskmodels "customer-service/internal/risk/models"
+	"customer-service/internal/screening/matching"
+	"customer-service/internal/screening/models"
+	"customer-service/internal/screening/repository"
+	"customer-service/internal/screening/watchlist"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Config holds the watchlist location and matching thresholds of the screening service
+type Config struct {
+	// WatchlistDir is the directory watchlist files are loaded from
+	WatchlistDir string
+	// NameThreshold is the lowest name similarity, from 0 to 1, considered a candidate
+	NameThreshold float64
+	// AlertThreshold is the lowest final score, after date of birth and country
+	// adjustments, that raises an alert
+	AlertThreshold float64
+}
+
+// ScreeningService defines the interface for sanctions and PEP screening
+type ScreeningService interface {
+	Screen(ctx context.Context, customer *customermodels.Customer, trigger models.Trigger) error
+	ScreenCustomer(ctx context.Context, customerID uuid.UUID) (*models.ScreeningResult, error)
+	LoadWatchlist(ctx context.Context, req models.LoadWatchlistRequest, actor string) (*models.LoadWatchlistResponse, error)
+	ListWatchlists(ctx context.Context) ([]models.Watchlist, error)
+	ListAlerts(ctx context.Context, query models.AlertQuery) (*models.AlertListResponse, error)
+	GetAlert(ctx context.Context, id uuid.UUID) (*models.Alert, error)
+	DispositionAlert(ctx context.Context, id uuid.UUID, req models.DispositionRequest, actor string) (*models.Alert, error)
+	GetRun(ctx context.Context, id uuid.UUID) (*models.Run, error)
+	RescreenBatch(ctx context.Context, limit int) (int, error)
+}
+
+// RiskRater rates a customer again when their PEP screening hits change
+type RiskRater interface {
+	Rate(ctx context.Context, customer *customermodels.Customer, trigger riskmodels.Trigger) error
+}
+
+// loadedIndex is the matching index of a particular set of active watchlist versions
+type loadedIndex struct {
+	key        string
+	index      *matching.Index
+	watchlists map[uuid.UUID]models.Watchlist
+}
+
+type screeningService struct {
+	repo      repository.ScreeningRepository
+	customers customerrepository.CustomerRepository
+	cipher    *encryption.Cipher
+	outbox    events.Outbox
+	audit     audit.Recorder
+	rater     RiskRater
+	tx        database.Transactor
+	cfg       Config
+
+	mu     sync.RWMutex
+	loaded *loadedIndex
+}
+
+// NewScreeningService creates a new screening service instance. Listed individuals are
+// matched from an in-memory index, rebuilt whenever the set of active watchlists
+// changes. Alerts are written together with their audit entries and events. Raising
+// or dispositioning a PEP alert outside of a customer change rates the customer again.
+func NewScreeningService(repo repository.ScreeningRepository, customers customerrepository.CustomerRepository, cipher *encryption.Cipher, outbox events.Outbox, recorder audit.Recorder, rater RiskRater, tx database.Transactor, cfg Config) ScreeningService {
+	if cfg.WatchlistDir == "" {
+		cfg.WatchlistDir = "watchlists"
+	}
+	if cfg.NameThreshold <= 0 {
+		cfg.NameThreshold = 0.85
+	}
+	if cfg.AlertThreshold <= 0 {
+		cfg.AlertThreshold = 0.88
+	}
+
+	return &screeningService{
+		repo:      repo,
+		customers: customers,
+		cipher:    cipher,
+		outbox:    outbox,
+		audit:     recorder,
+		rater:     rater,
+		tx:        tx,
+		cfg:       cfg,
+	}
+}
+
+// Screen matches a customer against the active watchlists and raises an alert for
+// every new potential match. Called with a transactional context, the alerts are
+// committed together with the change that triggered the screening.
+func (s *screeningService) Screen(ctx context.Context, customer *customermodels.Customer, trigger models.Trigger) error {
+	_, err := s.screen(ctx, customer, trigger)
+	return err
+}
+
+// ScreenCustomer screens a customer on demand and returns the alerts it raised
+func (s *screeningService) ScreenCustomer(ctx context.Context, customerID uuid.UUID) (*models.ScreeningResult, error) {
+	customer, err := s.customers.GetByID(ctx, customerID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &models.ScreeningResult{CustomerID: customer.ID, ScreenedAt: time.Now().UTC()}
+	err = s.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		alerts, err := s.screen(ctx, customer, models.TriggerManual)
+		if err != nil {
+			return err
+		}
+		result.AlertsRaised = alerts
+		return s.rateIfPEP(ctx, customer, alerts)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// LoadWatchlist loads a watchlist file from the watchlist directory. A file identical
+// to the active version of the list is not loaded again; otherwise the new version
+// replaces it and a rescreening of every customer is started.
+func (s *screeningService) LoadWatchlist(ctx context.Context, req models.LoadWatchlistRequest, actor string) (*models.LoadWatchlistResponse, error) {
+	if actor == "" {
+		return nil, customermodels.NewValidationError("actor", "actor is required")
+	}
+	if !filepath.IsLocal(req.File) {
+		return nil, customermodels.NewValidationError("file", "must be a relative path inside the watchlist directory")
+	}
+
+	file, err := os.Open(filepath.Join(s.cfg.WatchlistDir, req.File))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, fmt.Errorf("%w: %s", models.ErrWatchlistFileNotFound, req.File)
+		}
+		return nil, fmt.Errorf("failed to open watchlist file: %w", err)
+	}
+	defer file.Close()
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, file); err != nil {
+		return nil, fmt.Errorf("failed to read watchlist file: %w", err)
+	}
+	digest := hex.EncodeToString(hash.Sum(nil))
+
+	active, err := s.repo.GetActiveWatchlist(ctx, req.Name)
+	if err != nil && !errors.Is(err, models.ErrWatchlistNotFound) {
+		return nil, err
+	}
+	if active != nil && active.SHA256 == digest && active.Format == req.Format && active.Category == req.Category {
+		return &models.LoadWatchlistResponse{Watchlist: *active, Unchanged: true}, nil
+	}
+
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return nil, fmt.Errorf("failed to read watchlist file: %w", err)
+	}
+	entries, err := watchlist.Parse(req.Format, file)
+	if err != nil {
+		return nil, err
+	}
+
+	list := &models.Watchlist{
+		Name:     req.Name,
+		Format:   req.Format,
+		Category: req.Category,
+		FileName: req.File,
+		SHA256:   digest,
+		LoadedBy: actor,
+	}
+	run := &models.Run{
+		Status:    models.RunStatusRunning,
+		StartedAt: time.Now().UTC(),
+	}
+	err = s.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := s.repo.ReplaceWatchlist(ctx, list, entries); err != nil {
+			return err
+		}
+		if err := s.repo.SupersedeRuns(ctx, run.StartedAt); err != nil {
+			return err
+		}
+		run.WatchlistID = &list.ID
+		return s.repo.CreateRun(ctx, run)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("Screening: loaded %s watchlist %q (%d entries) from %s; rescreening started as run %s",
+		list.Category, list.Name, list.EntryCount, list.FileName, run.ID)
+	return &models.LoadWatchlistResponse{Watchlist: *list, Run: run}, nil
+}
+
+// ListWatchlists returns every loaded watchlist version, newest first
+func (s *screeningService) ListWatchlists(ctx context.Context) ([]models.Watchlist, error) {
+	return s.repo.ListWatchlists(ctx)
+}
+
+// ListAlerts returns a page of alerts matching the query
+func (s *screeningService) ListAlerts(ctx context.Context, query models.AlertQuery) (*models.AlertListResponse, error) {
+	if query.Page <= 0 {
+		query.Page = 1
+	}
+	if query.PageSize <= 0 {
+		query.PageSize = 10
+	}
+	if query.PageSize > 100 {
+		query.PageSize = 100
+	}
+
+	if query.CustomerID != nil {
+		if _, err := s.customers.GetByID(ctx, *query.CustomerID); err != nil {
+			return nil, err
+		}
+	}
+
+	alerts, total, err := s.repo.ListAlerts(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.audit.RecordAccess(ctx, audit.ActionScreeningView, alertCustomerIDs(alerts)); err != nil {
+		return nil, err
+	}
+
+	return &models.AlertListResponse{
+		Alerts:     alerts,
+		Total:      total,
+		Page:       query.Page,
+		PageSize:   query.PageSize,
+		TotalPages: int(math.Ceil(float64(total) / float64(query.PageSize))),
+	}, nil
+}
+
+// GetAlert retrieves an alert by ID
+func (s *screeningService) GetAlert(ctx context.Context, id uuid.UUID) (*models.Alert, error) {
+	alert, err := s.repo.GetAlert(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.audit.RecordAccess(ctx, audit.ActionScreeningView, []uuid.UUID{alert.CustomerID}); err != nil {
+		return nil, err
+	}
+	return alert, nil
+}
+
+// DispositionAlert records an analyst's ruling on an open alert
+func (s *screeningService) DispositionAlert(ctx context.Context, id uuid.UUID, req models.DispositionRequest, actor string) (*models.Alert, error) {
+	if actor == "" {
+		return nil, customermodels.NewValidationError("actor", "actor is required")
+	}
+
+	alert, err := s.repo.GetAlert(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if alert.Status != models.AlertStatusOpen {
+		return nil, models.ErrAlertDispositioned
+	}
+
+	now := time.Now().UTC()
+	alert.Status = req.Status
+	alert.DispositionNote = req.Note
+	alert.DispositionedBy = actor
+	alert.DispositionedAt = &now
+
+	err = s.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := s.repo.DispositionAlert(ctx, alert); err != nil {
+			return err
+		}
+		changes := []audit.Change{
+			{Field: "alert_id", Before: nil, After: alert.ID},
+			{Field: "status", Before: models.AlertStatusOpen, After: alert.Status},
+		}
+		if err := s.audit.Record(ctx, audit.ActionScreeningDisposition, alert.CustomerID, changes); err != nil {
+			return err
+		}
+		if alert.Category == models.ListCategoryPEP {
+			customer, err := s.customers.GetByID(ctx, alert.CustomerID)
+			if err != nil {
+				return err
+			}
+			if err := s.rater.Rate(ctx, customer, riskmodels.TriggerScreening); err != nil {
+				return err
+			}
+		}
+
+		event, err := events.NewEvent(events.CustomerScreeningAlertDispositioned, alert.CustomerID, models.CustomerScreeningAlertDispositionedPayload{
+			AlertID:  alert.ID,
+			ListName: alert.ListName,
+			Category: alert.Category,
+			Status:   alert.Status,
+			Note:     alert.DispositionNote,
+			Actor:    actor,
+		})
+		if err != nil {
+			return err
+		}
+		return s.outbox.Append(ctx, event)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return alert, nil
+}
+
+// GetRun retrieves a rescreening run by ID
+func (s *screeningService) GetRun(ctx context.Context, id uuid.UUID) (*models.Run, error) {
+	return s.repo.GetRun(ctx, id)
+}
+
+// RescreenBatch screens the next customers of the oldest running rescreening run and
+// returns how many were screened. The run is locked for the duration of the batch, so
+// several instances can share the work.
+func (s *screeningService) RescreenBatch(ctx context.Context, limit int) (int, error) {
+	screened := 0
+	err := s.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		run, err := s.repo.ClaimRun(ctx)
+		if errors.Is(err, models.ErrRunNotFound) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		customers, err := s.customers.ListAfter(ctx, run.Cursor, limit)
+		if err != nil {
+			return err
+		}
+		for i := range customers {
+			alerts, err := s.screen(ctx, &customers[i], models.TriggerRescreen)
+			if err != nil {
+				return fmt.Errorf("failed to screen customer %s: %w", customers[i].ID, err)
+			}
+			if err := s.rateIfPEP(ctx, &customers[i], alerts); err != nil {
+				return fmt.Errorf("failed to rate customer %s: %w", customers[i].ID, err)
+			}
+			run.Cursor = customers[i].ID
+			run.Screened++
+			run.Alerts += len(alerts)
+		}
+		screened = len(customers)
+
+		if len(customers) < limit {
+			now := time.Now().UTC()
+			run.Status = models.RunStatusCompleted
+			run.FinishedAt = &now
+			log.Printf("Screening: run %s completed, %d customer(s) screened, %d alert(s) raised", run.ID, run.Screened, run.Alerts)
+		}
+		return s.repo.UpdateRun(ctx, run)
+	})
+	if err != nil {
+		return 0, err
+	}
+	return screened, nil
+}
+
+// screeningSubject describes a customer for matching. Organizations are matched against
+// listed entities by legal name and jurisdiction.
+func screeningSubject(customer *customermodels.Customer) matching.Subject {
+	if customer.Type == customermodels.CustomerTypeOrganization {
+		country := customer.Jurisdiction
+		if country == "" {
+			country = customer.Address.Country
+		}
+		return matching.Subject{
+			Kind:    models.EntryKindEntity,
+			Name:    customer.LegalName,
+			Country: country,
+		}
+	}
+	return matching.Subject{
+		Kind:        models.EntryKindIndividual,
+		Name:        customer.Name(),
+		DateOfBirth: customer.DateOfBirth,
+		Country:     customer.Address.Country,
+	}
+}
+
+// rateIfPEP rates a customer again when any of the raised alerts is a PEP match
+func (s *screeningService) rateIfPEP(ctx context.Context, customer *customermodels.Customer, alerts []models.Alert) error {
+	for _, alert := range alerts {
+		if alert.Category == models.ListCategoryPEP {
+			return s.rater.Rate(ctx, customer, riskmodels.TriggerScreening)
+		}
+	}
+	return nil
+}
+
+// screen matches a customer against the active watchlists and stores the new alerts
+func (s *screeningService) screen(ctx context.Context, customer *customermodels.Customer, trigger models.Trigger) ([]models.Alert, error) {
+	loaded, err := s.index(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if loaded.index.Len() == 0 {
+		return []models.Alert{}, nil
+	}
+
+	subject := screeningSubject(customer)
+	matches := loaded.index.Match(subject, matching.Thresholds{
+		Name:  s.cfg.NameThreshold,
+		Alert: s.cfg.AlertThreshold,
+	})
+	if len(matches) == 0 {
+		return []models.Alert{}, nil
+	}
+
+	fingerprint, err := s.subjectFingerprint(ctx, subject)
+	if err != nil {
+		return nil, err
+	}
+
+	raised := make([]models.Alert, 0)
+	for _, match := range matches {
+		list := loaded.watchlists[match.Entry.WatchlistID]
+		entryID := match.Entry.ID
+		alert := models.Alert{
+			CustomerID:         customer.ID,
+			WatchlistID:        list.ID,
+			EntryID:            &entryID,
+			ListName:           list.Name,
+			Category:           list.Category,
+			ExternalID:         match.Entry.ExternalID,
+			MatchedName:        match.MatchedName,
+			Score:              match.Score,
+			Details:            match.Details,
+			SubjectFingerprint: fingerprint,
+			Trigger:            trigger,
+			Status:             models.AlertStatusOpen,
+		}
+		created, err := s.repo.CreateAlert(ctx, &alert)
+		if err != nil {
+			return nil, err
+		}
+		if !created {
+			continue
+		}
+		if err := s.recordAlert(ctx, &alert); err != nil {
+			return nil, err
+		}
+		raised = append(raised, alert)
+	}
+	return raised, nil
+}
+
+// recordAlert writes the audit entry and CustomerScreeningAlertRaised event of a new alert
+func (s *screeningService) recordAlert(ctx context.Context, alert *models.Alert) error {
+	changes := []audit.Change{
+		{Field: "alert_id", Before: nil, After: alert.ID},
+		{Field: "list_name", Before: nil, After: alert.ListName},
+		{Field: "external_id", Before: nil, After: alert.ExternalID},
+		{Field: "score", Before: nil, After: alert.Score},
+	}
+	if err := s.audit.Record(ctx, audit.ActionScreeningAlertRaise, alert.CustomerID, changes); err != nil {
+		return err
+	}
+
+	event, err := events.NewEvent(events.CustomerScreeningAlertRaised, alert.CustomerID, models.CustomerScreeningAlertRaisedPayload{
+		AlertID:     alert.ID,
+		ListName:    alert.ListName,
+		Category:    alert.Category,
+		ExternalID:  alert.ExternalID,
+		MatchedName: alert.MatchedName,
+		Score:       alert.Score,
+		Trigger:     alert.Trigger,
+	})
+	if err != nil {
+		return err
+	}
+	return s.outbox.Append(ctx, event)
+}
+
+// subjectFingerprint identifies the screened details of a customer without storing
+// them, so a customer is alerted again for a listed party only once those details change
+func (s *screeningService) subjectFingerprint(ctx context.Context, subject matching.Subject) (string, error) {
+	dateOfBirth := ""
+	if subject.DateOfBirth != nil {
+		dateOfBirth = subject.DateOfBirth.Format("2006-01-02")
+	}
+	value := strings.Join([]string{
+		matching.Normalize(subject.Name),
+		dateOfBirth,
+		matching.Normalize(subject.Country),
+	}, "|")
+	return s.cipher.BlindIndex(ctx, "screening_subject", value)
+}
+
+// index returns the matching index of the currently active watchlists, rebuilding it
+// when a watchlist was loaded since it was last built
+func (s *screeningService) index(ctx context.Context) (*loadedIndex, error) {
+	active, key, err := s.activeWatchlists(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.RLock()
+	loaded := s.loaded
+	s.mu.RUnlock()
+	if loaded != nil && loaded.key == key {
+		return loaded, nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.loaded != nil && s.loaded.key == key {
+		return s.loaded, nil
+	}
+
+	entries, err := s.repo.ListActiveEntries(ctx)
+	if err != nil {
+		return nil, err
+	}
+	loaded = &loadedIndex{
+		key:        key,
+		index:      matching.NewIndex(entries),
+		watchlists: make(map[uuid.UUID]models.Watchlist, len(active)),
+	}
+	for _, list := range active {
+		loaded.watchlists[list.ID] = list
+	}
+
+	// A watchlist loaded while the entries were read may be only partly reflected in
+	// them; such an index serves this call but is not kept
+	_, after, err := s.activeWatchlists(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if after == key {
+		s.loaded = loaded
+	}
+	return loaded, nil
+}
+
+// activeWatchlists returns the active watchlists and a key identifying that set
+func (s *screeningService) activeWatchlists(ctx context.Context) ([]models.Watchlist, string, error) {
+	active, err := s.repo.ListActiveWatchlists(ctx)
+	if err != nil {
+		return nil, "", err
+	}
+	ids := make([]string, len(active))
+	for i, list := range active {
+		ids[i] = list.ID.String()
+	}
+	return active, strings.Join(ids, ","), nil
+}
+
+// alertCustomerIDs returns the distinct customers the alerts belong to
+func alertCustomerIDs(alerts []models.Alert) []uuid.UUID {
+	seen := make(map[uuid.UUID]bool, len(alerts))
+	ids := make([]uuid.UUID, 0, len(alerts))
+	for _, alert := range alerts {
+		if !seen[alert.CustomerID] {
+			seen[alert.CustomerID] = true
+			ids = append(ids, alert.CustomerID)
+		}
+	}
+	return ids
+}
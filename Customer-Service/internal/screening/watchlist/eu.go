@@ -0,0 +1,108 @@
+package watchlist
+
+import (
+	"customer-service/internal/screening/models"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// euEntity is a sanctionEntity element of the EU consolidated list
+type euEntity struct {
+	LogicalID   string `xml:"logicalId,attr"`
+	SubjectType struct {
+		Code string `xml:"code,attr"`
+	} `xml:"subjectType"`
+	Regulations []struct {
+		Programme string `xml:"programme,attr"`
+	} `xml:"regulation"`
+	NameAliases []struct {
+		WholeName  string `xml:"wholeName,attr"`
+		FirstName  string `xml:"firstName,attr"`
+		MiddleName string `xml:"middleName,attr"`
+		LastName   string `xml:"lastName,attr"`
+	} `xml:"nameAlias"`
+	Citizenships []euCountry `xml:"citizenship"`
+	Addresses    []euCountry `xml:"address"`
+	Birthdates   []struct {
+		Birthdate   string `xml:"birthdate,attr"`
+		Year        string `xml:"year,attr"`
+		MonthOfYear string `xml:"monthOfYear,attr"`
+	} `xml:"birthdate"`
+}
+
+// euCountry is any element carrying a country code and description
+type euCountry struct {
+	ISO2        string `xml:"countryIso2Code,attr"`
+	Description string `xml:"countryDescription,attr"`
+}
+
+// ParseEUConsolidated reads the EU consolidated financial sanctions XML file,
+// streaming one sanctionEntity element at a time
+func ParseEUConsolidated(r io.Reader) ([]models.WatchlistEntry, error) {
+	decoder := xml.NewDecoder(r)
+
+	var entries []models.WatchlistEntry
+	for {
+		token, err := decoder.Token()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		start, ok := token.(xml.StartElement)
+		if !ok || start.Name.Local != "sanctionEntity" {
+			continue
+		}
+		var entity euEntity
+		if err := decoder.DecodeElement(&entity, &start); err != nil {
+			return nil, fmt.Errorf("sanctionEntity: %w", err)
+		}
+		if entity.LogicalID == "" {
+			return nil, errors.New("sanctionEntity without logicalId")
+		}
+
+		kind := models.EntryKindEntity
+		if entity.SubjectType.Code == "person" {
+			kind = models.EntryKindIndividual
+		}
+		entry := newEntry(entity.LogicalID, kind)
+		for _, regulation := range entity.Regulations {
+			entry.Programs = appendUnique(entry.Programs, regulation.Programme)
+		}
+		for _, alias := range entity.NameAliases {
+			name := alias.WholeName
+			if name == "" {
+				name = strings.Join(strings.Fields(alias.FirstName+" "+alias.MiddleName+" "+alias.LastName), " ")
+			}
+			entry.Names = appendUnique(entry.Names, name)
+		}
+		for _, birthdate := range entity.Birthdates {
+			switch {
+			case birthdate.Birthdate != "":
+				entry.DatesOfBirth = appendUnique(entry.DatesOfBirth, birthdate.Birthdate)
+			case birthdate.Year != "" && birthdate.MonthOfYear != "":
+				entry.DatesOfBirth = appendUnique(entry.DatesOfBirth, fmt.Sprintf("%s-%02s", birthdate.Year, birthdate.MonthOfYear))
+			case birthdate.Year != "":
+				entry.DatesOfBirth = appendUnique(entry.DatesOfBirth, birthdate.Year)
+			}
+		}
+		for _, country := range append(entity.Citizenships, entity.Addresses...) {
+			if country.ISO2 != "" && country.ISO2 != "00" {
+				entry.Countries = appendUnique(entry.Countries, country.ISO2)
+			}
+			if country.Description != "" && !strings.EqualFold(country.Description, "UNKNOWN") {
+				entry.Countries = appendUnique(entry.Countries, country.Description)
+			}
+		}
+
+		if len(entry.Names) > 0 {
+			entries = append(entries, entry)
+		}
+	}
+	return entries, nil
+}
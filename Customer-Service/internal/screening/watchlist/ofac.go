@@ -0,0 +1,149 @@
+package watchlist
+
+import (
+	"customer-service/internal/screening/models"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SDN.CSV columns; the file has no header row
+const (
+	sdnColumnEntNum = iota
+	sdnColumnName
+	sdnColumnType
+	sdnColumnProgram
+	sdnColumnTitle
+	sdnColumnCallSign
+	sdnColumnVesselType
+	sdnColumnTonnage
+	sdnColumnGRT
+	sdnColumnVesselFlag
+	sdnColumnVesselOwner
+	sdnColumnRemarks
+	sdnColumnCount
+)
+
+// sdnNull is the placeholder OFAC uses for an empty field
+const sdnNull = "-0-"
+
+var (
+	sdnDOBPattern     = regexp.MustCompile(`(?i)\bDOB\s+([^;]+)`)
+	sdnCountryPattern = regexp.MustCompile(`(?i)\b(?:nationality|citizen)\s+([^;()]+)`)
+	sdnAliasPattern   = regexp.MustCompile(`(?i)a\.k\.a\.,?\s+'(.+?)'(?:[;,.]|\s*$)`)
+	sdnYearPattern    = regexp.MustCompile(`\b(\d{4})\b`)
+	sdnRangePattern   = regexp.MustCompile(`\b(\d{4})\s+to\s+(\d{4})\b`)
+)
+
+// ParseOFACSDN reads the OFAC SDN.CSV file. Individuals are listed as
+// "LAST, First"; dates of birth, nationalities and aliases are taken from the
+// remarks column.
+func ParseOFACSDN(r io.Reader) ([]models.WatchlistEntry, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+
+	var entries []models.WatchlistEntry
+	for line := 1; ; line++ {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		// The file ends with a single control character on its own line
+		if len(record) == 1 && strings.TrimSpace(strings.Trim(record[0], "\x1a")) == "" {
+			continue
+		}
+		if len(record) < sdnColumnCount {
+			return nil, fmt.Errorf("line %d: expected %d columns, got %d", line, sdnColumnCount, len(record))
+		}
+		if _, err := strconv.Atoi(strings.TrimSpace(record[sdnColumnEntNum])); err != nil {
+			return nil, fmt.Errorf("line %d: invalid entity number %q", line, record[sdnColumnEntNum])
+		}
+
+		kind := models.EntryKindEntity
+		if strings.EqualFold(sdnField(record[sdnColumnType]), "individual") {
+			kind = models.EntryKindIndividual
+		}
+		entry := newEntry(strings.TrimSpace(record[sdnColumnEntNum]), kind)
+		entry.Names = appendUnique(entry.Names, sdnName(sdnField(record[sdnColumnName]), kind))
+		for _, program := range strings.Split(sdnField(record[sdnColumnProgram]), "] [") {
+			entry.Programs = appendUnique(entry.Programs, strings.Trim(program, "[] "))
+		}
+
+		remarks := sdnField(record[sdnColumnRemarks])
+		for _, match := range sdnAliasPattern.FindAllStringSubmatch(remarks, -1) {
+			entry.Names = appendUnique(entry.Names, sdnName(match[1], kind))
+		}
+		for _, match := range sdnDOBPattern.FindAllStringSubmatch(remarks, -1) {
+			for _, dob := range sdnDates(match[1]) {
+				entry.DatesOfBirth = appendUnique(entry.DatesOfBirth, dob)
+			}
+		}
+		for _, match := range sdnCountryPattern.FindAllStringSubmatch(remarks, -1) {
+			entry.Countries = appendUnique(entry.Countries, strings.TrimSuffix(strings.TrimSpace(match[1]), "."))
+		}
+
+		if len(entry.Names) > 0 {
+			entries = append(entries, entry)
+		}
+	}
+	return entries, nil
+}
+
+// sdnField returns a trimmed field, or "" for the OFAC null placeholder
+func sdnField(value string) string {
+	value = strings.TrimSpace(value)
+	if value == sdnNull {
+		return ""
+	}
+	return value
+}
+
+// sdnName turns an individual's "LAST, First" into "First LAST"
+func sdnName(name string, kind models.EntryKind) string {
+	if kind != models.EntryKindIndividual {
+		return name
+	}
+	last, first, ok := strings.Cut(name, ",")
+	if !ok {
+		return name
+	}
+	return strings.TrimSpace(first) + " " + strings.TrimSpace(last)
+}
+
+// sdnDates parses a DOB remark such as "12 Mar 1965", "Mar 1965", "circa 1965"
+// or "1962 to 1964" into YYYY-MM-DD, YYYY-MM or YYYY values
+func sdnDates(value string) []string {
+	value = strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(value), "."))
+
+	if match := sdnRangePattern.FindStringSubmatch(value); match != nil {
+		from, _ := strconv.Atoi(match[1])
+		to, _ := strconv.Atoi(match[2])
+		if from <= to && to-from <= 10 {
+			dates := make([]string, 0, to-from+1)
+			for year := from; year <= to; year++ {
+				dates = append(dates, strconv.Itoa(year))
+			}
+			return dates
+		}
+	}
+
+	if date, err := time.Parse("02 Jan 2006", value); err == nil {
+		return []string{date.Format("2006-01-02")}
+	}
+	if date, err := time.Parse("Jan 2006", value); err == nil {
+		return []string{date.Format("2006-01")}
+	}
+	if match := sdnYearPattern.FindStringSubmatch(value); match != nil {
+		return []string{match[1]}
+	}
+	return nil
+}
@@ -0,0 +1,57 @@
+package watchlist
+
+import (
+	"customer-service/internal/screening/models"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Parse reads every entry of a watchlist file in the given format
+func Parse(format models.ListFormat, r io.Reader) ([]models.WatchlistEntry, error) {
+	var (
+		entries []models.WatchlistEntry
+		err     error
+	)
+	switch format {
+	case models.ListFormatOFACSDN:
+		entries, err = ParseOFACSDN(r)
+	case models.ListFormatEUConsolidated:
+		entries, err = ParseEUConsolidated(r)
+	default:
+		return nil, fmt.Errorf("%w: unknown format %q", models.ErrInvalidWatchlistFile, format)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", models.ErrInvalidWatchlistFile, err)
+	}
+	if len(entries) == 0 {
+		return nil, fmt.Errorf("%w: no entries found", models.ErrInvalidWatchlistFile)
+	}
+	return entries, nil
+}
+
+// newEntry creates an entry whose list fields are empty rather than nil
+func newEntry(externalID string, kind models.EntryKind) models.WatchlistEntry {
+	return models.WatchlistEntry{
+		ExternalID:   externalID,
+		Kind:         kind,
+		Names:        []string{},
+		DatesOfBirth: []string{},
+		Countries:    []string{},
+		Programs:     []string{},
+	}
+}
+
+// appendUnique appends the trimmed value unless it is empty or already present
+func appendUnique(values []string, value string) []string {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return values
+	}
+	for _, v := range values {
+		if strings.EqualFold(v, value) {
+			return values
+		}
+	}
+	return append(values, value)
+}
@@ -0,0 +1,107 @@
+package watchlist
+
+import (
+	"customer-service/internal/screening/models"
+	"errors"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// entry builds an expected entry; nil lists are empty
+func entry(id string, kind models.EntryKind, names, datesOfBirth, countries, programs []string) models.WatchlistEntry {
+	e := newEntry(id, kind)
+	e.Names = append(e.Names, names...)
+	e.DatesOfBirth = append(e.DatesOfBirth, datesOfBirth...)
+	e.Countries = append(e.Countries, countries...)
+	e.Programs = append(e.Programs, programs...)
+	return e
+}
+
+func TestParseFixtures(t *testing.T) {
+	tests := []struct {
+		name   string
+		format models.ListFormat
+		file   string
+		want   []models.WatchlistEntry
+	}{
+		{
+			name:   "OFAC SDN",
+			format: models.ListFormatOFACSDN,
+			file:   "testdata/sdn.csv",
+			want: []models.WatchlistEntry{
+				entry("36", models.EntryKindEntity, []string{"AEROCARIBBEAN AIRLINES"}, nil, nil, []string{"CUBA"}),
+				entry("173", models.EntryKindEntity, []string{"ANGLO-CARIBBEAN CO., LTD.", "ANGLO CARIB"}, nil, nil, []string{"CUBA", "IRAN"}),
+				entry("2674", models.EntryKindIndividual, []string{"Abu ABBAS", "Muhammad ZAYDAN"}, []string{"1948-12-10"}, []string{"Palestinian"}, []string{"SDGT"}),
+				entry("6365", models.EntryKindIndividual, []string{"Ali HASSAN"}, []string{"1960-03", "1962", "1963", "1964"}, []string{"Iraq"}, []string{"IRAQ2"}),
+				entry("7001", models.EntryKindIndividual, []string{"John SMITH"}, []string{"1970"}, nil, []string{"SDNTK"}),
+				entry("7002", models.EntryKindIndividual, []string{"Jane DOE"}, nil, nil, []string{"SDNTK"}),
+			},
+		},
+		{
+			name:   "EU consolidated",
+			format: models.ListFormatEUConsolidated,
+			file:   "testdata/eu.xml",
+			want: []models.WatchlistEntry{
+				entry("13", models.EntryKindIndividual,
+					[]string{"Vladimir Vladimirovich PUTIN", "Владимир Владимирович Путин", "Vladimir Putin"},
+					[]string{"1952-10-07"}, []string{"RU", "RUSSIAN FEDERATION"}, []string{"RUS", "UKR"}),
+				entry("27", models.EntryKindIndividual,
+					[]string{"Usama bin Muhammad bin Awad BIN LADIN", "Osama bin Laden"},
+					[]string{"1957-03", "1956"}, []string{"SA", "SAUDI ARABIA"}, []string{"TAQA"}),
+				entry("5400", models.EntryKindEntity, []string{"Rosneft Oil Company"}, nil, []string{"RU", "RUSSIAN FEDERATION"}, []string{"RUS"}),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			file, err := os.Open(tt.file)
+			if err != nil {
+				t.Fatalf("Open() error = %v", err)
+			}
+			defer file.Close()
+
+			got, err := Parse(tt.format, file)
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("Parse() returned %d entries, want %d: %+v", len(got), len(tt.want), got)
+			}
+			for i := range tt.want {
+				if !reflect.DeepEqual(got[i], tt.want[i]) {
+					t.Errorf("entry %d = %+v, want %+v", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		format  models.ListFormat
+		content string
+	}{
+		{name: "unknown format", format: "un_consolidated", content: `36,"AEROCARIBBEAN AIRLINES",-0- ,"CUBA",-0- ,-0- ,-0- ,-0- ,-0- ,-0- ,-0- ,-0- `},
+		{name: "OFAC file without entries", format: models.ListFormatOFACSDN, content: "\x1a\r\n"},
+		{name: "OFAC row missing columns", format: models.ListFormatOFACSDN, content: `36,"AEROCARIBBEAN AIRLINES",-0- ,"CUBA"`},
+		{name: "OFAC row without entity number", format: models.ListFormatOFACSDN, content: `ent_num,"AEROCARIBBEAN AIRLINES",-0- ,"CUBA",-0- ,-0- ,-0- ,-0- ,-0- ,-0- ,-0- ,-0- `},
+		{name: "EU file without entities", format: models.ListFormatEUConsolidated, content: `<export></export>`},
+		{name: "EU entity without logicalId", format: models.ListFormatEUConsolidated, content: `<export><sanctionEntity><nameAlias wholeName="Rosneft"/></sanctionEntity></export>`},
+		{name: "EU entities without names", format: models.ListFormatEUConsolidated, content: `<export><sanctionEntity logicalId="1"/></export>`},
+		{name: "malformed EU file", format: models.ListFormatEUConsolidated, content: `<export><sanctionEntity logicalId="1">`},
+		{name: "OFAC file given as EU", format: models.ListFormatEUConsolidated, content: `36,"AEROCARIBBEAN AIRLINES",-0- ,"CUBA",-0- ,-0- ,-0- ,-0- ,-0- ,-0- ,-0- ,-0- `},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entries, err := Parse(tt.format, strings.NewReader(tt.content))
+			if !errors.Is(err, models.ErrInvalidWatchlistFile) {
+				t.Errorf("Parse() = %+v, %v, want %v", entries, err, models.ErrInvalidWatchlistFile)
+			}
+		})
+	}
+}
@@ -0,0 +1,197 @@
+package controllers
+
+import (
+	customermodels "customer-service/internal/customer/models"
+	"customer-service/internal/webhook/models"
+	"customer-service/internal/webhook/service"
+	"customer-service/pkg/apierror"
+	"customer-service/pkg/middleware"
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// WebhookController handles HTTP requests for webhook subscriptions
+type WebhookController struct {
+	service service.WebhookService
+}
+
+// NewWebhookController creates a new webhook controller instance
+func NewWebhookController(webhookService service.WebhookService) *WebhookController {
+	return &WebhookController{
+		service: webhookService,
+	}
+}
+
+// CreateSubscription handles POST /webhooks
+func (ctrl *WebhookController) CreateSubscription(c *gin.Context) {
+	var req models.SubscriptionRequest
+	if !ctrl.bindJSON(c, &req) {
+		return
+	}
+
+	var actor string
+	if claims, ok := middleware.GetClaims(c); ok {
+		actor = claims.Subject
+	}
+
+	subscription, err := ctrl.service.CreateSubscription(c.Request.Context(), req, actor)
+	if err != nil {
+		ctrl.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, subscription)
+}
+
+// GetSubscription handles GET /webhooks/:id
+func (ctrl *WebhookController) GetSubscription(c *gin.Context) {
+	id, ok := ctrl.parseUUID(c, "id")
+	if !ok {
+		return
+	}
+
+	subscription, err := ctrl.service.GetSubscription(c.Request.Context(), id)
+	if err != nil {
+		ctrl.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, subscription)
+}
+
+// ListSubscriptions handles GET /webhooks
+func (ctrl *WebhookController) ListSubscriptions(c *gin.Context) {
+	subscriptions, err := ctrl.service.ListSubscriptions(c.Request.Context())
+	if err != nil {
+		ctrl.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, subscriptions)
+}
+
+// UpdateSubscription handles PUT /webhooks/:id
+func (ctrl *WebhookController) UpdateSubscription(c *gin.Context) {
+	id, ok := ctrl.parseUUID(c, "id")
+	if !ok {
+		return
+	}
+
+	var req models.SubscriptionRequest
+	if !ctrl.bindJSON(c, &req) {
+		return
+	}
+
+	subscription, err := ctrl.service.UpdateSubscription(c.Request.Context(), id, req)
+	if err != nil {
+		ctrl.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, subscription)
+}
+
+// DeleteSubscription handles DELETE /webhooks/:id
+func (ctrl *WebhookController) DeleteSubscription(c *gin.Context) {
+	id, ok := ctrl.parseUUID(c, "id")
+	if !ok {
+		return
+	}
+
+	if err := ctrl.service.DeleteSubscription(c.Request.Context(), id); err != nil {
+		ctrl.handleError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// ListDeliveries handles GET /webhooks/:id/deliveries
+func (ctrl *WebhookController) ListDeliveries(c *gin.Context) {
+	id, ok := ctrl.parseUUID(c, "id")
+	if !ok {
+		return
+	}
+
+	var req models.DeliveryListRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		apierror.Abort(c, http.StatusBadRequest, apierror.CodeInvalidRequest, "invalid query parameters: "+err.Error())
+		return
+	}
+
+	deliveries, err := ctrl.service.ListDeliveries(c.Request.Context(), id, req)
+	if err != nil {
+		ctrl.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, deliveries)
+}
+
+// ReplayDelivery handles POST /webhooks/:id/deliveries/:deliveryId/replay
+func (ctrl *WebhookController) ReplayDelivery(c *gin.Context) {
+	id, ok := ctrl.parseUUID(c, "id")
+	if !ok {
+		return
+	}
+	deliveryID, ok := ctrl.parseUUID(c, "deliveryId")
+	if !ok {
+		return
+	}
+
+	delivery, err := ctrl.service.ReplayDelivery(c.Request.Context(), id, deliveryID)
+	if err != nil {
+		ctrl.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusAccepted, delivery)
+}
+
+// bindJSON decodes and validates the request body, writing an error response on failure
+func (ctrl *WebhookController) bindJSON(c *gin.Context, req any) bool {
+	if err := c.ShouldBindJSON(req); err != nil {
+		apierror.Abort(c, http.StatusBadRequest, apierror.CodeInvalidRequest, "invalid request body: "+err.Error())
+		return false
+	}
+
+	if err := customermodels.ValidateStruct(req); err != nil {
+		ctrl.handleError(c, err)
+		return false
+	}
+
+	return true
+}
+
+// parseUUID parses a UUID path parameter, writing an error response on failure
+func (ctrl *WebhookController) parseUUID(c *gin.Context, param string) (uuid.UUID, bool) {
+	id, err := uuid.Parse(c.Param(param))
+	if err != nil {
+		apierror.Abort(c, http.StatusBadRequest, apierror.CodeInvalidRequest, "invalid "+param,
+			apierror.FieldError{Field: param, Message: "must be a valid UUID"})
+		return uuid.Nil, false
+	}
+	return id, true
+}
+
+// handleError maps service errors to HTTP responses
+func (ctrl *WebhookController) handleError(c *gin.Context, err error) {
+	var validationErr *customermodels.ValidationError
+	switch {
+	case errors.As(err, &validationErr):
+		fields := make([]apierror.FieldError, len(validationErr.Fields))
+		for i, f := range validationErr.Fields {
+			fields[i] = apierror.FieldError{Field: f.Field, Message: f.Message}
+		}
+		apierror.Abort(c, http.StatusBadRequest, apierror.CodeValidationFailed, "request validation failed", fields...)
+	case errors.Is(err, models.ErrSubscriptionNotFound), errors.Is(err, models.ErrDeliveryNotFound):
+		apierror.Abort(c, http.StatusNotFound, apierror.CodeNotFound, err.Error())
+	default:
+		log.Printf("Unhandled error processing %s %s: %v", c.Request.Method, c.Request.URL.Path, err)
+		apierror.Abort(c, http.StatusInternalServerError, apierror.CodeInternal, "internal server error")
+	}
+}
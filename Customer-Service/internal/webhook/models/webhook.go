@@ -0,0 +1,113 @@
+package models
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Domain errors returned by the webhook repository and service layers
+var (
+	ErrSubscriptionNotFound = errors.New("webhook subscription not found")
+	ErrDeliveryNotFound     = errors.New("webhook delivery not found")
+)
+
+// Subscription is a registered webhook endpoint
+type Subscription struct {
+	ID                  uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	URL                 string     `json:"url" gorm:"not null;size:2048"`
+	Description         string     `json:"description" gorm:"size:255"`
+	EventTypes          []string   `json:"event_types" gorm:"type:jsonb;serializer:json;not null"`
+	Secret              string     `json:"-" gorm:"type:text;not null;serializer:encrypted"`
+	Active              bool       `json:"active" gorm:"not null"`
+	DisabledReason      string     `json:"disabled_reason,omitempty" gorm:"size:500"`
+	DisabledAt          *time.Time `json:"disabled_at,omitempty"`
+	ConsecutiveFailures int        `json:"consecutive_failures" gorm:"not null;default:0"`
+	CreatedBy           string     `json:"created_by" gorm:"size:255"`
+	CreatedAt           time.Time  `json:"created_at"`
+	UpdatedAt           time.Time  `json:"updated_at"`
+}
+
+// TableName returns the table name for Subscription model
+func (Subscription) TableName() string {
+	return "webhook_subscriptions"
+}
+
+// Matches reports whether the subscription wants events of the given type.
+// An empty filter subscribes to every event.
+func (s *Subscription) Matches(eventType string) bool {
+	if len(s.EventTypes) == 0 {
+		return true
+	}
+	for _, t := range s.EventTypes {
+		if t == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// DeliveryStatus represents the state of a webhook delivery
+type DeliveryStatus string
+
+const (
+	DeliveryStatusPending   DeliveryStatus = "pending"
+	DeliveryStatusSucceeded DeliveryStatus = "succeeded"
+	DeliveryStatusFailed    DeliveryStatus = "failed"
+)
+
+// Delivery is a single event sent, or to be sent, to a subscription
+type Delivery struct {
+	ID             uuid.UUID      `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	SubscriptionID uuid.UUID      `json:"subscription_id" gorm:"type:uuid;not null;index"`
+	EventID        uuid.UUID      `json:"event_id" gorm:"type:uuid;not null"`
+	EventType      string         `json:"event_type" gorm:"not null;size:100"`
+	Payload        string         `json:"-" gorm:"type:jsonb;not null"`
+	Status         DeliveryStatus `json:"status" gorm:"not null;size:20;index"`
+	Attempts       int            `json:"attempts" gorm:"not null;default:0"`
+	NextAttemptAt  *time.Time     `json:"next_attempt_at,omitempty"`
+	LastAttemptAt  *time.Time     `json:"last_attempt_at,omitempty"`
+	LastStatusCode *int           `json:"last_status_code,omitempty"`
+	LastError      string         `json:"last_error,omitempty" gorm:"type:text"`
+	DeliveredAt    *time.Time     `json:"delivered_at,omitempty"`
+	ReplayOf       *uuid.UUID     `json:"replay_of,omitempty" gorm:"type:uuid"`
+	CreatedAt      time.Time      `json:"created_at"`
+}
+
+// TableName returns the table name for Delivery model
+func (Delivery) TableName() string {
+	return "webhook_deliveries"
+}
+
+// SubscriptionRequest represents the request payload for creating/updating a subscription
+type SubscriptionRequest struct {
+	URL         string   `json:"url" validate:"required,url,max=2048"`
+	Description string   `json:"description" validate:"max=255"`
+	EventTypes  []string `json:"event_types"`
+	// Secret is optional on create; one is generated when omitted
+	Secret string `json:"secret" validate:"omitempty,min=16,max=255"`
+	Active *bool  `json:"active"`
+}
+
+// SubscriptionResponse represents a subscription; the secret is only included on creation
+type SubscriptionResponse struct {
+	Subscription
+	Secret string `json:"secret,omitempty"`
+}
+
+// DeliveryListResponse represents a page of deliveries for a subscription
+type DeliveryListResponse struct {
+	Deliveries []Delivery `json:"deliveries"`
+	Total      int64      `json:"total"`
+	Page       int        `json:"page"`
+	PageSize   int        `json:"page_size"`
+	TotalPages int        `json:"total_pages"`
+}
+
+// DeliveryListRequest represents delivery log query parameters
+type DeliveryListRequest struct {
+	Status   DeliveryStatus `form:"status"`
+	Page     int            `form:"page"`
+	PageSize int            `form:"page_size"`
+}
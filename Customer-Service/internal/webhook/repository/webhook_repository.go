@@ -0,0 +1,296 @@
+package repository
+
+import (
+	"context"
+	"customer-service/internal/database"
+	"customer-service/internal/encryption"
+	"customer-service/internal/webhook/models"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// WebhookRepository defines the interface for webhook subscription and delivery data access
+type WebhookRepository interface {
+	CreateSubscription(ctx context.Context, subscription *models.Subscription) error
+	GetSubscription(ctx context.Context, id uuid.UUID) (*models.Subscription, error)
+	ListSubscriptions(ctx context.Context) ([]models.Subscription, error)
+	ListActiveSubscriptions(ctx context.Context) ([]models.Subscription, error)
+	UpdateSubscription(ctx context.Context, subscription *models.Subscription) error
+	DeleteSubscription(ctx context.Context, id uuid.UUID) error
+	RecordSubscriptionSuccess(ctx context.Context, id uuid.UUID) error
+	RecordSubscriptionFailure(ctx context.Context, id uuid.UUID, disableAfter int) (bool, error)
+	ReencryptSecrets(ctx context.Context, keyVersion int) (int, error)
+
+	EnqueueDeliveries(ctx context.Context, deliveries []models.Delivery) error
+	CreateDelivery(ctx context.Context, delivery *models.Delivery) error
+	GetDelivery(ctx context.Context, subscriptionID, id uuid.UUID) (*models.Delivery, error)
+	ListDeliveries(ctx context.Context, subscriptionID uuid.UUID, req models.DeliveryListRequest) ([]models.Delivery, int64, error)
+	ClaimDueDeliveries(ctx context.Context, limit int, lease time.Duration) ([]models.Delivery, error)
+	MarkDelivered(ctx context.Context, delivery *models.Delivery, statusCode int) error
+	MarkAttemptFailed(ctx context.Context, delivery *models.Delivery, statusCode *int, cause string, nextAttempt *time.Time) error
+}
+
+type webhookRepository struct {
+	db *gorm.DB
+}
+
+// NewWebhookRepository creates a new webhook repository instance
+func NewWebhookRepository(db *gorm.DB) WebhookRepository {
+	return &webhookRepository{db: db}
+}
+
+// CreateSubscription creates a new webhook subscription
+func (r *webhookRepository) CreateSubscription(ctx context.Context, subscription *models.Subscription) error {
+	// The encrypted secret is bound to the ID, so it is assigned before the insert
+	if subscription.ID == uuid.Nil {
+		subscription.ID = uuid.New()
+	}
+	if err := database.Conn(ctx, r.db).Create(subscription).Error; err != nil {
+		return fmt.Errorf("failed to create webhook subscription: %w", err)
+	}
+	return nil
+}
+
+// GetSubscription retrieves a webhook subscription by ID
+func (r *webhookRepository) GetSubscription(ctx context.Context, id uuid.UUID) (*models.Subscription, error) {
+	var subscription models.Subscription
+	if err := database.Conn(ctx, r.db).Where("id = ?", id).First(&subscription).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, models.ErrSubscriptionNotFound
+		}
+		return nil, fmt.Errorf("failed to get webhook subscription: %w", err)
+	}
+	return &subscription, nil
+}
+
+// ListSubscriptions returns every webhook subscription, oldest first
+func (r *webhookRepository) ListSubscriptions(ctx context.Context) ([]models.Subscription, error) {
+	var subscriptions []models.Subscription
+	if err := database.Conn(ctx, r.db).Order("created_at").Find(&subscriptions).Error; err != nil {
+		return nil, fmt.Errorf("failed to list webhook subscriptions: %w", err)
+	}
+	return subscriptions, nil
+}
+
+// ListActiveSubscriptions returns the subscriptions that currently receive events
+func (r *webhookRepository) ListActiveSubscriptions(ctx context.Context) ([]models.Subscription, error) {
+	var subscriptions []models.Subscription
+	if err := database.Conn(ctx, r.db).Where("active").Find(&subscriptions).Error; err != nil {
+		return nil, fmt.Errorf("failed to list active webhook subscriptions: %w", err)
+	}
+	return subscriptions, nil
+}
+
+// UpdateSubscription saves every field of a webhook subscription
+func (r *webhookRepository) UpdateSubscription(ctx context.Context, subscription *models.Subscription) error {
+	result := database.Conn(ctx, r.db).Save(subscription)
+	if result.Error != nil {
+		return fmt.Errorf("failed to update webhook subscription: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return models.ErrSubscriptionNotFound
+	}
+	return nil
+}
+
+// DeleteSubscription deletes a webhook subscription together with its delivery log
+func (r *webhookRepository) DeleteSubscription(ctx context.Context, id uuid.UUID) error {
+	result := database.Conn(ctx, r.db).Where("id = ?", id).Delete(&models.Subscription{})
+	if result.Error != nil {
+		return fmt.Errorf("failed to delete webhook subscription: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return models.ErrSubscriptionNotFound
+	}
+	return nil
+}
+
+// RecordSubscriptionSuccess resets the consecutive failure count of a subscription
+func (r *webhookRepository) RecordSubscriptionSuccess(ctx context.Context, id uuid.UUID) error {
+	err := database.Conn(ctx, r.db).Model(&models.Subscription{}).
+		Where("id = ? AND consecutive_failures > 0", id).
+		Update("consecutive_failures", 0).Error
+	if err != nil {
+		return fmt.Errorf("failed to reset webhook failures: %w", err)
+	}
+	return nil
+}
+
+// RecordSubscriptionFailure counts a failed delivery attempt against a subscription and
+// disables it once disableAfter consecutive attempts have failed. It reports whether
+// this call disabled the subscription. A disableAfter of zero never disables.
+func (r *webhookRepository) RecordSubscriptionFailure(ctx context.Context, id uuid.UUID, disableAfter int) (bool, error) {
+	db := database.Conn(ctx, r.db)
+
+	var failures int
+	err := db.Raw(`UPDATE webhook_subscriptions
+		SET consecutive_failures = consecutive_failures + 1, updated_at = ?
+		WHERE id = ?
+		RETURNING consecutive_failures`, time.Now(), id).Scan(&failures).Error
+	if err != nil {
+		return false, fmt.Errorf("failed to record webhook failure: %w", err)
+	}
+	if disableAfter <= 0 || failures < disableAfter {
+		return false, nil
+	}
+
+	result := db.Model(&models.Subscription{}).
+		Where("id = ? AND active", id).
+		Updates(map[string]any{
+			"active":          false,
+			"disabled_at":     time.Now(),
+			"disabled_reason": fmt.Sprintf("disabled after %d consecutive failed deliveries", failures),
+		})
+	if result.Error != nil {
+		return false, fmt.Errorf("failed to disable webhook subscription: %w", result.Error)
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// ReencryptSecrets rewrites the signing secrets not encrypted under keyVersion and
+// bound to their subscription, such as those stored in plaintext before secrets were
+// encrypted, and returns how many it rewrote
+func (r *webhookRepository) ReencryptSecrets(ctx context.Context, keyVersion int) (int, error) {
+	db := database.Conn(ctx, r.db)
+
+	var subscriptions []models.Subscription
+	if err := db.Where("secret NOT LIKE ?", encryption.CurrentPrefix(keyVersion)+"%").Find(&subscriptions).Error; err != nil {
+		return 0, fmt.Errorf("failed to list webhook secrets to re-encrypt: %w", err)
+	}
+	for i := range subscriptions {
+		// UpdateColumns leaves updated_at alone; the serializer encrypts under the current key
+		if err := db.Model(&subscriptions[i]).Select("secret").UpdateColumns(&subscriptions[i]).Error; err != nil {
+			return i, fmt.Errorf("failed to re-encrypt webhook secret: %w", err)
+		}
+	}
+	return len(subscriptions), nil
+}
+
+// EnqueueDeliveries stores pending deliveries. An event already queued for a
+// subscription is skipped, so the relay may safely publish it again.
+func (r *webhookRepository) EnqueueDeliveries(ctx context.Context, deliveries []models.Delivery) error {
+	if len(deliveries) == 0 {
+		return nil
+	}
+	if err := database.Conn(ctx, r.db).Clauses(clause.OnConflict{DoNothing: true}).Create(&deliveries).Error; err != nil {
+		return fmt.Errorf("failed to enqueue webhook deliveries: %w", err)
+	}
+	return nil
+}
+
+// CreateDelivery stores a single delivery, such as a manual replay
+func (r *webhookRepository) CreateDelivery(ctx context.Context, delivery *models.Delivery) error {
+	if err := database.Conn(ctx, r.db).Create(delivery).Error; err != nil {
+		return fmt.Errorf("failed to create webhook delivery: %w", err)
+	}
+	return nil
+}
+
+// GetDelivery retrieves a delivery belonging to a subscription
+func (r *webhookRepository) GetDelivery(ctx context.Context, subscriptionID, id uuid.UUID) (*models.Delivery, error) {
+	var delivery models.Delivery
+	err := database.Conn(ctx, r.db).
+		Where("id = ? AND subscription_id = ?", id, subscriptionID).
+		First(&delivery).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, models.ErrDeliveryNotFound
+		}
+		return nil, fmt.Errorf("failed to get webhook delivery: %w", err)
+	}
+	return &delivery, nil
+}
+
+// ListDeliveries returns a page of a subscription's delivery log, newest first
+func (r *webhookRepository) ListDeliveries(ctx context.Context, subscriptionID uuid.UUID, req models.DeliveryListRequest) ([]models.Delivery, int64, error) {
+	query := database.Conn(ctx, r.db).Model(&models.Delivery{}).Where("subscription_id = ?", subscriptionID)
+	if req.Status != "" {
+		query = query.Where("status = ?", req.Status)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to count webhook deliveries: %w", err)
+	}
+
+	var deliveries []models.Delivery
+	offset := (req.Page - 1) * req.PageSize
+	if err := query.Order("created_at DESC").Offset(offset).Limit(req.PageSize).Find(&deliveries).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to list webhook deliveries: %w", err)
+	}
+	return deliveries, total, nil
+}
+
+// ClaimDueDeliveries returns pending deliveries of active subscriptions whose next
+// attempt is due, pushing their next attempt back by lease so that concurrent
+// workers do not send them as well. Deliveries locked by another worker are skipped.
+func (r *webhookRepository) ClaimDueDeliveries(ctx context.Context, limit int, lease time.Duration) ([]models.Delivery, error) {
+	now := time.Now()
+
+	var deliveries []models.Delivery
+	err := database.Conn(ctx, r.db).Raw(`UPDATE webhook_deliveries
+		SET next_attempt_at = ?
+		WHERE id IN (
+			SELECT d.id FROM webhook_deliveries d
+			JOIN webhook_subscriptions s ON s.id = d.subscription_id
+			WHERE d.status = ? AND d.next_attempt_at <= ? AND s.active
+			ORDER BY d.next_attempt_at
+			LIMIT ?
+			FOR UPDATE OF d SKIP LOCKED
+		)
+		RETURNING *`, now.Add(lease), models.DeliveryStatusPending, now, limit).
+		Scan(&deliveries).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim webhook deliveries: %w", err)
+	}
+	return deliveries, nil
+}
+
+// MarkDelivered records a successful delivery attempt
+func (r *webhookRepository) MarkDelivered(ctx context.Context, delivery *models.Delivery, statusCode int) error {
+	now := time.Now()
+	err := database.Conn(ctx, r.db).Model(&models.Delivery{}).
+		Where("id = ?", delivery.ID).
+		Updates(map[string]any{
+			"status":           models.DeliveryStatusSucceeded,
+			"attempts":         gorm.Expr("attempts + 1"),
+			"last_attempt_at":  now,
+			"last_status_code": statusCode,
+			"last_error":       "",
+			"delivered_at":     now,
+			"next_attempt_at":  nil,
+		}).Error
+	if err != nil {
+		return fmt.Errorf("failed to mark webhook delivery %s delivered: %w", delivery.ID, err)
+	}
+	return nil
+}
+
+// MarkAttemptFailed records a failed delivery attempt. A nil nextAttempt means no
+// further attempts are made and the delivery is marked failed.
+func (r *webhookRepository) MarkAttemptFailed(ctx context.Context, delivery *models.Delivery, statusCode *int, cause string, nextAttempt *time.Time) error {
+	status := models.DeliveryStatusPending
+	if nextAttempt == nil {
+		status = models.DeliveryStatusFailed
+	}
+
+	err := database.Conn(ctx, r.db).Model(&models.Delivery{}).
+		Where("id = ?", delivery.ID).
+		Updates(map[string]any{
+			"status":           status,
+			"attempts":         gorm.Expr("attempts + 1"),
+			"last_attempt_at":  time.Now(),
+			"last_status_code": statusCode,
+			"last_error":       cause,
+			"next_attempt_at":  nextAttempt,
+		}).Error
+	if err != nil {
+		return fmt.Errorf("failed to mark webhook delivery %s failed: %w", delivery.ID, err)
+	}
+	return nil
+}
@@ -0,0 +1,52 @@
+package service
+
+import (
+	"context"
+	"customer-service/internal/events"
+	"customer-service/internal/webhook/models"
+	"customer-service/internal/webhook/repository"
+	"encoding/json"
+	"fmt"
+)
+
+// Dispatcher is an events.EventPublisher that queues a webhook delivery for every
+// active subscription interested in the event. Called by the outbox relay, the
+// deliveries are committed in the same transaction that marks the event published.
+type Dispatcher struct {
+	repo repository.WebhookRepository
+}
+
+// NewDispatcher creates a new webhook dispatcher
+func NewDispatcher(repo repository.WebhookRepository) *Dispatcher {
+	return &Dispatcher{repo: repo}
+}
+
+// Publish queues the event for delivery to matching subscriptions
+func (d *Dispatcher) Publish(ctx context.Context, event events.Event) error {
+	subscriptions, err := d.repo.ListActiveSubscriptions(ctx)
+	if err != nil {
+		return err
+	}
+
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to encode event: %w", err)
+	}
+
+	deliveries := make([]models.Delivery, 0, len(subscriptions))
+	for _, subscription := range subscriptions {
+		if !subscription.Matches(event.Type) {
+			continue
+		}
+		deliveries = append(deliveries, models.Delivery{
+			SubscriptionID: subscription.ID,
+			EventID:        event.ID,
+			EventType:      event.Type,
+			Payload:        string(payload),
+			Status:         models.DeliveryStatusPending,
+			NextAttemptAt:  &event.OccurredAt,
+		})
+	}
+
+	return d.repo.EnqueueDeliveries(ctx, deliveries)
+}
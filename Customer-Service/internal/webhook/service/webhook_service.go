@@ -0,0 +1,231 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	customermodels "customer-service/internal/customer/models"
+	"customer-service/internal/encryption"
+	"customer-service/internal/events"
+	"customer-service/internal/webhook/models"
+	"customer-service/internal/webhook/repository"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// WebhookService defines the interface for managing webhook subscriptions and deliveries
+type WebhookService interface {
+	CreateSubscription(ctx context.Context, req models.SubscriptionRequest, actor string) (*models.SubscriptionResponse, error)
+	GetSubscription(ctx context.Context, id uuid.UUID) (*models.Subscription, error)
+	ListSubscriptions(ctx context.Context) ([]models.Subscription, error)
+	UpdateSubscription(ctx context.Context, id uuid.UUID, req models.SubscriptionRequest) (*models.Subscription, error)
+	DeleteSubscription(ctx context.Context, id uuid.UUID) error
+	ListDeliveries(ctx context.Context, subscriptionID uuid.UUID, req models.DeliveryListRequest) (*models.DeliveryListResponse, error)
+	ReplayDelivery(ctx context.Context, subscriptionID, deliveryID uuid.UUID) (*models.Delivery, error)
+	ReencryptSecrets(ctx context.Context) (int, error)
+}
+
+type webhookService struct {
+	repo   repository.WebhookRepository
+	cipher *encryption.Cipher
+}
+
+// NewWebhookService creates a new webhook service instance. Signing secrets are
+// stored encrypted with cipher.
+func NewWebhookService(repo repository.WebhookRepository, cipher *encryption.Cipher) WebhookService {
+	return &webhookService{repo: repo, cipher: cipher}
+}
+
+// CreateSubscription registers a webhook endpoint. The response carries the signing
+// secret, which is generated when the request does not supply one.
+func (s *webhookService) CreateSubscription(ctx context.Context, req models.SubscriptionRequest, actor string) (*models.SubscriptionResponse, error) {
+	if err := validateSubscriptionRequest(req); err != nil {
+		return nil, err
+	}
+
+	secret := req.Secret
+	if secret == "" {
+		generated, err := generateSecret()
+		if err != nil {
+			return nil, err
+		}
+		secret = generated
+	}
+
+	subscription := &models.Subscription{
+		URL:         req.URL,
+		Description: req.Description,
+		EventTypes:  eventTypes(req.EventTypes),
+		Secret:      secret,
+		Active:      req.Active == nil || *req.Active,
+		CreatedBy:   actor,
+	}
+	if err := s.repo.CreateSubscription(ctx, subscription); err != nil {
+		return nil, err
+	}
+
+	return &models.SubscriptionResponse{Subscription: *subscription, Secret: secret}, nil
+}
+
+// GetSubscription retrieves a webhook subscription by ID
+func (s *webhookService) GetSubscription(ctx context.Context, id uuid.UUID) (*models.Subscription, error) {
+	return s.repo.GetSubscription(ctx, id)
+}
+
+// ListSubscriptions returns every webhook subscription
+func (s *webhookService) ListSubscriptions(ctx context.Context) ([]models.Subscription, error) {
+	return s.repo.ListSubscriptions(ctx)
+}
+
+// UpdateSubscription replaces the settings of a subscription. The secret is only
+// rotated when one is supplied, and re-activating a disabled subscription clears
+// its failure count.
+func (s *webhookService) UpdateSubscription(ctx context.Context, id uuid.UUID, req models.SubscriptionRequest) (*models.Subscription, error) {
+	if err := validateSubscriptionRequest(req); err != nil {
+		return nil, err
+	}
+
+	subscription, err := s.repo.GetSubscription(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	subscription.URL = req.URL
+	subscription.Description = req.Description
+	subscription.EventTypes = eventTypes(req.EventTypes)
+	if req.Secret != "" {
+		subscription.Secret = req.Secret
+	}
+	if req.Active != nil && *req.Active != subscription.Active {
+		subscription.Active = *req.Active
+		if subscription.Active {
+			subscription.ConsecutiveFailures = 0
+			subscription.DisabledAt = nil
+			subscription.DisabledReason = ""
+		} else {
+			now := time.Now()
+			subscription.DisabledAt = &now
+			subscription.DisabledReason = "disabled by an administrator"
+		}
+	}
+
+	if err := s.repo.UpdateSubscription(ctx, subscription); err != nil {
+		return nil, err
+	}
+	return subscription, nil
+}
+
+// DeleteSubscription removes a subscription and its delivery log
+func (s *webhookService) DeleteSubscription(ctx context.Context, id uuid.UUID) error {
+	return s.repo.DeleteSubscription(ctx, id)
+}
+
+// ListDeliveries returns a page of the delivery log of a subscription
+func (s *webhookService) ListDeliveries(ctx context.Context, subscriptionID uuid.UUID, req models.DeliveryListRequest) (*models.DeliveryListResponse, error) {
+	if _, err := s.repo.GetSubscription(ctx, subscriptionID); err != nil {
+		return nil, err
+	}
+
+	switch req.Status {
+	case "", models.DeliveryStatusPending, models.DeliveryStatusSucceeded, models.DeliveryStatusFailed:
+	default:
+		return nil, customermodels.NewValidationError("status", "must be one of: pending succeeded failed")
+	}
+	if req.Page < 1 {
+		req.Page = 1
+	}
+	if req.PageSize < 1 || req.PageSize > 100 {
+		req.PageSize = 20
+	}
+
+	deliveries, total, err := s.repo.ListDeliveries(ctx, subscriptionID, req)
+	if err != nil {
+		return nil, err
+	}
+
+	totalPages := int(total) / req.PageSize
+	if int(total)%req.PageSize > 0 {
+		totalPages++
+	}
+
+	return &models.DeliveryListResponse{
+		Deliveries: deliveries,
+		Total:      total,
+		Page:       req.Page,
+		PageSize:   req.PageSize,
+		TotalPages: totalPages,
+	}, nil
+}
+
+// ReplayDelivery queues a fresh delivery of the same event to the subscription.
+// The original delivery is left untouched in the log.
+func (s *webhookService) ReplayDelivery(ctx context.Context, subscriptionID, deliveryID uuid.UUID) (*models.Delivery, error) {
+	original, err := s.repo.GetDelivery(ctx, subscriptionID, deliveryID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	replay := &models.Delivery{
+		SubscriptionID: original.SubscriptionID,
+		EventID:        original.EventID,
+		EventType:      original.EventType,
+		Payload:        original.Payload,
+		Status:         models.DeliveryStatusPending,
+		NextAttemptAt:  &now,
+		ReplayOf:       &original.ID,
+	}
+	if err := s.repo.CreateDelivery(ctx, replay); err != nil {
+		return nil, err
+	}
+	return replay, nil
+}
+
+// ReencryptSecrets rewrites the signing secrets stored in plaintext or under a key
+// other than the current one, and returns how many it rewrote
+func (s *webhookService) ReencryptSecrets(ctx context.Context) (int, error) {
+	version, err := s.cipher.CurrentVersion(ctx)
+	if err != nil {
+		return 0, err
+	}
+	return s.repo.ReencryptSecrets(ctx, version)
+}
+
+// validateSubscriptionRequest checks the fields the validate tags cannot express
+func validateSubscriptionRequest(req models.SubscriptionRequest) error {
+	verr := &customermodels.ValidationError{}
+
+	if target, err := url.Parse(req.URL); err != nil || (target.Scheme != "http" && target.Scheme != "https") || target.Host == "" {
+		verr.Add("url", "must be an absolute http or https URL")
+	}
+	for i, eventType := range req.EventTypes {
+		if !events.IsKnownType(eventType) {
+			verr.Add(fmt.Sprintf("event_types[%d]", i), "unknown event type "+eventType)
+		}
+	}
+
+	if verr.HasErrors() {
+		return verr
+	}
+	return nil
+}
+
+// eventTypes normalises an event filter so that an absent filter is stored as an empty list
+func eventTypes(types []string) []string {
+	if types == nil {
+		return []string{}
+	}
+	return types
+}
+
+// generateSecret returns a random signing secret
+func generateSecret() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate webhook secret: %w", err)
+	}
+	return "whsec_" + hex.EncodeToString(buf), nil
+}
@@ -0,0 +1,232 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"customer-service/internal/database"
+	"customer-service/internal/webhook/models"
+	"customer-service/internal/webhook/repository"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Headers sent with every webhook request
+const (
+	HeaderSignature = "X-Webhook-Signature"
+	HeaderID        = "X-Webhook-Id"
+	HeaderEvent     = "X-Webhook-Event"
+	HeaderTimestamp = "X-Webhook-Timestamp"
+)
+
+// WorkerConfig controls how deliveries are sent and retried
+type WorkerConfig struct {
+	PollInterval time.Duration
+	BatchSize    int
+	Timeout      time.Duration
+	MaxAttempts  int
+	MinBackoff   time.Duration
+	MaxBackoff   time.Duration
+	// DisableAfter is the number of consecutive failed attempts after which a
+	// subscription is disabled; zero never disables
+	DisableAfter int
+}
+
+// Worker sends queued webhook deliveries and schedules retries with exponential backoff
+type Worker struct {
+	repo   repository.WebhookRepository
+	tx     database.Transactor
+	client *http.Client
+	cfg    WorkerConfig
+}
+
+// NewWorker creates a new webhook delivery worker
+func NewWorker(repo repository.WebhookRepository, tx database.Transactor, cfg WorkerConfig) *Worker {
+	if cfg.PollInterval <= 0 {
+		cfg.PollInterval = time.Second
+	}
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = 50
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 10 * time.Second
+	}
+	if cfg.MaxAttempts <= 0 {
+		cfg.MaxAttempts = 10
+	}
+	if cfg.MinBackoff <= 0 {
+		cfg.MinBackoff = 30 * time.Second
+	}
+	if cfg.MaxBackoff < cfg.MinBackoff {
+		cfg.MaxBackoff = 6 * time.Hour
+	}
+
+	return &Worker{
+		repo:   repo,
+		tx:     tx,
+		client: &http.Client{Timeout: cfg.Timeout},
+		cfg:    cfg,
+	}
+}
+
+// Run delivers due webhooks until ctx is cancelled
+func (w *Worker) Run(ctx context.Context) {
+	ticker := time.NewTicker(w.cfg.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := w.ProcessBatch(ctx); err != nil && ctx.Err() == nil {
+			log.Printf("Webhook worker: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// ProcessBatch sends one batch of due deliveries and returns how many succeeded
+func (w *Worker) ProcessBatch(ctx context.Context) (int, error) {
+	// The lease must outlive a full batch of requests timing out, so a delivery
+	// is never picked up by another worker while it is still being sent
+	lease := w.cfg.Timeout*time.Duration(w.cfg.BatchSize) + time.Minute
+	deliveries, err := w.repo.ClaimDueDeliveries(ctx, w.cfg.BatchSize, lease)
+	if err != nil {
+		return 0, err
+	}
+
+	subscriptions := make(map[uuid.UUID]*models.Subscription)
+	delivered := 0
+	for i := range deliveries {
+		delivery := &deliveries[i]
+
+		subscription, ok := subscriptions[delivery.SubscriptionID]
+		if !ok {
+			subscription, err = w.repo.GetSubscription(ctx, delivery.SubscriptionID)
+			if err != nil {
+				return delivered, err
+			}
+			subscriptions[delivery.SubscriptionID] = subscription
+		}
+		if !subscription.Active {
+			// Disabled while this batch was running; the lease expires and the
+			// delivery resumes if the subscription is re-enabled
+			continue
+		}
+
+		statusCode, sendErr := w.send(ctx, subscription, delivery)
+		if ctx.Err() != nil {
+			return delivered, ctx.Err()
+		}
+		if err := w.record(ctx, subscription, delivery, statusCode, sendErr); err != nil {
+			return delivered, err
+		}
+		if sendErr == nil {
+			delivered++
+		}
+	}
+	return delivered, nil
+}
+
+// send posts the delivery payload to the subscription URL. A non-2xx response is an error.
+func (w *Worker) send(ctx context.Context, subscription *models.Subscription, delivery *models.Delivery) (int, error) {
+	body := []byte(delivery.Payload)
+	timestamp := time.Now().Unix()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, subscription.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, fmt.Errorf("failed to build request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "customer-service-webhooks/1.0")
+	req.Header.Set(HeaderID, delivery.ID.String())
+	req.Header.Set(HeaderEvent, delivery.EventType)
+	req.Header.Set(HeaderTimestamp, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(HeaderSignature, Sign(subscription.Secret, timestamp, body))
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("endpoint responded %s", resp.Status)
+	}
+	return resp.StatusCode, nil
+}
+
+// record stores the outcome of an attempt, scheduling a retry or giving up after
+// MaxAttempts, and updates the failure count of the subscription
+func (w *Worker) record(ctx context.Context, subscription *models.Subscription, delivery *models.Delivery, statusCode int, sendErr error) error {
+	return w.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		if sendErr == nil {
+			if err := w.repo.MarkDelivered(ctx, delivery, statusCode); err != nil {
+				return err
+			}
+			return w.repo.RecordSubscriptionSuccess(ctx, subscription.ID)
+		}
+
+		var code *int
+		if statusCode != 0 {
+			code = &statusCode
+		}
+
+		var nextAttempt *time.Time
+		attempts := delivery.Attempts + 1
+		if attempts < w.cfg.MaxAttempts {
+			next := time.Now().Add(w.backoff(delivery.Attempts))
+			nextAttempt = &next
+		}
+		log.Printf("Webhook worker: delivery %s of %s to %s failed (attempt %d): %v",
+			delivery.ID, delivery.EventType, subscription.URL, attempts, sendErr)
+
+		if err := w.repo.MarkAttemptFailed(ctx, delivery, code, sendErr.Error(), nextAttempt); err != nil {
+			return err
+		}
+
+		disabled, err := w.repo.RecordSubscriptionFailure(ctx, subscription.ID, w.cfg.DisableAfter)
+		if err != nil {
+			return err
+		}
+		if disabled {
+			subscription.Active = false
+			log.Printf("Webhook worker: disabled subscription %s after %d consecutive failures", subscription.ID, w.cfg.DisableAfter)
+		}
+		return nil
+	})
+}
+
+// backoff returns the exponential delay before retrying a delivery that has failed attempts times
+func (w *Worker) backoff(attempts int) time.Duration {
+	delay := w.cfg.MinBackoff
+	for i := 0; i < attempts && delay < w.cfg.MaxBackoff; i++ {
+		delay *= 2
+	}
+	if delay > w.cfg.MaxBackoff {
+		delay = w.cfg.MaxBackoff
+	}
+	return delay
+}
+
+// Sign returns the X-Webhook-Signature value for a request body: the timestamp and
+// an HMAC-SHA256 of "<timestamp>.<body>" keyed with the subscription secret.
+// Receivers recompute the HMAC and reject stale timestamps to prevent replays.
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return fmt.Sprintf("t=%d,v1=%s", timestamp, hex.EncodeToString(mac.Sum(nil)))
+}
@@ -0,0 +1,243 @@
+package service
+
+import (
+	"context"
+	"customer-service/internal/webhook/models"
+	"customer-service/internal/webhook/repository"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestSign(t *testing.T) {
+	tests := []struct {
+		name      string
+		secret    string
+		timestamp int64
+		body      string
+		want      string
+	}{
+		{
+			name: "event", secret: "whsec_test", timestamp: 1700000000, body: `{"id":"1"}`,
+			want: "t=1700000000,v1=11bf4466ea17c3df3fd743af0b435368e16b7a05eb8eced85e8c4670767bdec5",
+		},
+		{
+			name: "empty body", secret: "whsec_test", timestamp: 1700000000,
+			want: "t=1700000000,v1=5967f3c560522fa40cf2876ebc3c3a08551dd6959aaade3b413460591895bdcc",
+		},
+		{
+			name: "another secret", secret: "whsec_other", timestamp: 1700000000, body: `{"id":"1"}`,
+			want: "t=1700000000,v1=eab0e844c24ee1175c6a6f3af3669ed257ad73931101a09a27ecbbaa6f628b3b",
+		},
+		{
+			name: "another timestamp", secret: "whsec_test", timestamp: 1700000001, body: `{"id":"1"}`,
+			want: "t=1700000001,v1=b1feba12f212f2ce5192c1233a9427597af5f3d45ee6bb9cb6c30b434095284d",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Sign(tt.secret, tt.timestamp, []byte(tt.body)); got != tt.want {
+				t.Errorf("Sign() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	w := NewWorker(nil, nil, WorkerConfig{})
+
+	tests := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{0, 30 * time.Second},
+		{1, time.Minute},
+		{2, 2 * time.Minute},
+		{5, 16 * time.Minute},
+		{9, 256 * time.Minute},
+		{10, 6 * time.Hour},
+		{1000, 6 * time.Hour},
+	}
+
+	for _, tt := range tests {
+		t.Run(strconv.Itoa(tt.attempts), func(t *testing.T) {
+			if got := w.backoff(tt.attempts); got != tt.want {
+				t.Errorf("backoff(%d) = %v, want %v", tt.attempts, got, tt.want)
+			}
+		})
+	}
+}
+
+// attempt is a failed delivery attempt recorded by deliveryRepository
+type attempt struct {
+	statusCode  *int
+	nextAttempt *time.Time
+}
+
+// deliveryRepository serves one subscription and its due deliveries, and counts the
+// subscription's consecutive failures as the database does. Calls to any other
+// repository method panic.
+type deliveryRepository struct {
+	repository.WebhookRepository
+	subscription models.Subscription
+	due          []models.Delivery
+	failures     int
+	delivered    []uuid.UUID
+	failed       map[uuid.UUID]attempt
+}
+
+func (r *deliveryRepository) ClaimDueDeliveries(ctx context.Context, limit int, lease time.Duration) ([]models.Delivery, error) {
+	return r.due, nil
+}
+
+func (r *deliveryRepository) GetSubscription(ctx context.Context, id uuid.UUID) (*models.Subscription, error) {
+	subscription := r.subscription
+	return &subscription, nil
+}
+
+func (r *deliveryRepository) MarkDelivered(ctx context.Context, delivery *models.Delivery, statusCode int) error {
+	r.delivered = append(r.delivered, delivery.ID)
+	return nil
+}
+
+func (r *deliveryRepository) RecordSubscriptionSuccess(ctx context.Context, id uuid.UUID) error {
+	r.failures = 0
+	return nil
+}
+
+func (r *deliveryRepository) MarkAttemptFailed(ctx context.Context, delivery *models.Delivery, statusCode *int, cause string, nextAttempt *time.Time) error {
+	r.failed[delivery.ID] = attempt{statusCode: statusCode, nextAttempt: nextAttempt}
+	return nil
+}
+
+func (r *deliveryRepository) RecordSubscriptionFailure(ctx context.Context, id uuid.UUID, disableAfter int) (bool, error) {
+	r.failures++
+	if disableAfter <= 0 || r.failures < disableAfter || !r.subscription.Active {
+		return false, nil
+	}
+	r.subscription.Active = false
+	return true, nil
+}
+
+// noTransaction runs functions directly
+type noTransaction struct{}
+
+func (noTransaction) WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
+
+func TestProcessBatch(t *testing.T) {
+	const secret = "whsec_0123456789abcdef"
+
+	tests := []struct {
+		name         string
+		status       int
+		deliveries   int
+		attempts     int
+		maxAttempts  int
+		failures     int
+		disableAfter int
+		wantSent     int
+		wantFailed   int
+		wantDisabled bool
+		// wantNext is the delay before the next attempt, or zero if it was the last
+		wantNext time.Duration
+	}{
+		{
+			name:   "delivered",
+			status: http.StatusNoContent, deliveries: 3, failures: 4, disableAfter: 5,
+			wantSent: 3,
+		},
+		{
+			name:   "failed and retried",
+			status: http.StatusInternalServerError, deliveries: 1, attempts: 2, disableAfter: 5,
+			wantSent: 1, wantFailed: 1, wantNext: 2 * time.Minute,
+		},
+		{
+			name:   "failed for the last time",
+			status: http.StatusBadGateway, deliveries: 1, attempts: 2, maxAttempts: 3, disableAfter: 5,
+			wantSent: 1, wantFailed: 1,
+		},
+		{
+			name:   "disabled mid-batch",
+			status: http.StatusServiceUnavailable, deliveries: 4, failures: 3, disableAfter: 5,
+			wantSent: 2, wantFailed: 2, wantDisabled: true, wantNext: 30 * time.Second,
+		},
+		{
+			name:   "never disabled",
+			status: http.StatusServiceUnavailable, deliveries: 4, failures: 100,
+			wantSent: 4, wantFailed: 4, wantNext: 30 * time.Second,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sent := 0
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				sent++
+				body, _ := io.ReadAll(r.Body)
+				timestamp, err := strconv.ParseInt(r.Header.Get(HeaderTimestamp), 10, 64)
+				if err != nil || r.Header.Get(HeaderSignature) != Sign(secret, timestamp, body) {
+					t.Errorf("request signed %q at %q, want it signed with the subscription secret",
+						r.Header.Get(HeaderSignature), r.Header.Get(HeaderTimestamp))
+				}
+				w.WriteHeader(tt.status)
+			}))
+			defer server.Close()
+
+			subscription := models.Subscription{ID: uuid.New(), URL: server.URL, Secret: secret, Active: true}
+			repo := &deliveryRepository{subscription: subscription, failures: tt.failures, failed: make(map[uuid.UUID]attempt)}
+			for i := 0; i < tt.deliveries; i++ {
+				repo.due = append(repo.due, models.Delivery{
+					ID: uuid.New(), SubscriptionID: subscription.ID, EventType: "CustomerCreated",
+					Payload: `{"id":"` + strconv.Itoa(i) + `"}`, Attempts: tt.attempts,
+				})
+			}
+			w := NewWorker(repo, noTransaction{}, WorkerConfig{MaxAttempts: tt.maxAttempts, DisableAfter: tt.disableAfter})
+
+			start := time.Now()
+			delivered, err := w.ProcessBatch(context.Background())
+			if err != nil {
+				t.Fatalf("ProcessBatch() error = %v", err)
+			}
+
+			if sent != tt.wantSent {
+				t.Errorf("sent %d requests, want %d", sent, tt.wantSent)
+			}
+			if delivered != tt.wantSent-tt.wantFailed || len(repo.delivered) != delivered {
+				t.Errorf("ProcessBatch() = %d, marked %d delivered, want %d", delivered, len(repo.delivered), tt.wantSent-tt.wantFailed)
+			}
+			if len(repo.failed) != tt.wantFailed {
+				t.Errorf("recorded %d failed attempts, want %d", len(repo.failed), tt.wantFailed)
+			}
+			if disabled := !repo.subscription.Active; disabled != tt.wantDisabled {
+				t.Errorf("subscription disabled = %v, want %v", disabled, tt.wantDisabled)
+			}
+			if tt.wantFailed == 0 && repo.failures != 0 {
+				t.Errorf("consecutive failures = %d after a success, want 0", repo.failures)
+			}
+
+			for id, failure := range repo.failed {
+				if failure.statusCode == nil || *failure.statusCode != tt.status {
+					t.Errorf("delivery %s failed with status %v, want %d", id, failure.statusCode, tt.status)
+				}
+				switch {
+				case tt.wantNext == 0:
+					if failure.nextAttempt != nil {
+						t.Errorf("delivery %s retried at %v, want no retry", id, failure.nextAttempt)
+					}
+				case failure.nextAttempt == nil:
+					t.Errorf("delivery %s not retried, want a retry after %v", id, tt.wantNext)
+				case failure.nextAttempt.Before(start.Add(tt.wantNext)) || failure.nextAttempt.After(time.Now().Add(tt.wantNext)):
+					t.Errorf("delivery %s retried at %v, want %v after the attempt", id, failure.nextAttempt, tt.wantNext)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,65 @@
+package apierror
+
+import (
+	"github.com/gin-gonic/gin"
+)
+
+// RequestIDKey is the gin context key holding the current request ID
+const RequestIDKey = "request_id"
+
+// Error codes shared by all HTTP handlers and middlewares
+const (
+	CodeInvalidRequest        = "INVALID_REQUEST"
+	CodeValidationFailed      = "VALIDATION_FAILED"
+	CodeNotFound              = "NOT_FOUND"
+	CodeConflict              = "CONFLICT"
+	CodeInvalidTransition     = "INVALID_STATUS_TRANSITION"
+	CodePreconditionFailed    = "PRECONDITION_FAILED"
+	CodePreconditionRequired  = "PRECONDITION_REQUIRED"
+	CodePatchFailed           = "PATCH_FAILED"
+	CodeUnsupportedMedia      = "UNSUPPORTED_MEDIA_TYPE"
+	CodePayloadTooLarge       = "PAYLOAD_TOO_LARGE"
+	CodeKYCIncomplete         = "KYC_INCOMPLETE"
+	CodeKYCNotVerified        = "KYC_NOT_VERIFIED"
+	CodeCustomerMerged        = "CUSTOMER_MERGED"
+	CodeInvalidWatchlist      = "INVALID_WATCHLIST"
+	CodeInvalidCustomerType   = "INVALID_CUSTOMER_TYPE"
+	CodeGuardianRequired      = "GUARDIAN_REQUIRED"
+	CodeIdempotencyKeyReused  = "IDEMPOTENCY_KEY_REUSED"
+	CodeIdempotencyInProgress = "IDEMPOTENCY_IN_PROGRESS"
+	CodeUnauthorized          = "UNAUTHORIZED"
+	CodeForbidden             = "FORBIDDEN"
+	CodeTimeout               = "TIMEOUT"
+	CodeInternal              = "INTERNAL_ERROR"
+)
+
+// FieldError describes a problem with a single request field
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// Body is the error payload returned by every endpoint
+type Body struct {
+	Code      string       `json:"code"`
+	Message   string       `json:"message"`
+	Fields    []FieldError `json:"fields,omitempty"`
+	RequestID string       `json:"request_id,omitempty"`
+}
+
+// Response wraps the error payload in a top-level "error" object
+type Response struct {
+	Error Body `json:"error"`
+}
+
+// Abort writes the standard error response and stops the handler chain
+func Abort(c *gin.Context, status int, code, message string, fields ...FieldError) {
+	c.AbortWithStatusJSON(status, Response{
+		Error: Body{
+			Code:      code,
+			Message:   message,
+			Fields:    fields,
+			RequestID: c.GetString(RequestIDKey),
+		},
+	})
+}
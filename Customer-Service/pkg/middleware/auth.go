@@ -0,0 +1,237 @@
+package middleware
+
+import (
+	"crypto/rsa"
+	"customer-service/pkg/apierror"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// ClaimsKey is the gin context key holding the authenticated token claims
+const ClaimsKey = "auth_claims"
+
+// Roles recognised by the authorization middleware
+const (
+	RoleTeller     = "teller"
+	RoleBackOffice = "back_office"
+	RoleAdmin      = "admin"
+	// RoleNotificationService is held by the service that sends customer communications
+	RoleNotificationService = "notification_service"
+)
+
+// Claims represents the JWT claims accepted by the service
+type Claims struct {
+	Roles []string `json:"roles"`
+	jwt.RegisteredClaims
+}
+
+// HasRole reports whether the claims grant the given role
+func (c *Claims) HasRole(role string) bool {
+	for _, r := range c.Roles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
+// AuthConfig holds the settings used to verify bearer tokens
+type AuthConfig struct {
+	Secret   string
+	Audience string
+	Issuer   string
+	JWKSFile string
+}
+
+// Authenticator verifies bearer tokens signed with HS256 or RS256
+type Authenticator struct {
+	secret  []byte
+	rsaKeys map[string]*rsa.PublicKey
+	parser  *jwt.Parser
+}
+
+// NewAuthenticator creates a new authenticator, loading RS256 keys from the JWKS file if configured
+func NewAuthenticator(cfg AuthConfig) (*Authenticator, error) {
+	options := []jwt.ParserOption{
+		jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg(), jwt.SigningMethodRS256.Alg()}),
+		jwt.WithExpirationRequired(),
+	}
+	if cfg.Audience != "" {
+		options = append(options, jwt.WithAudience(cfg.Audience))
+	}
+	if cfg.Issuer != "" {
+		options = append(options, jwt.WithIssuer(cfg.Issuer))
+	}
+
+	auth := &Authenticator{
+		secret: []byte(cfg.Secret),
+		parser: jwt.NewParser(options...),
+	}
+
+	if cfg.JWKSFile != "" {
+		keys, err := loadJWKS(cfg.JWKSFile)
+		if err != nil {
+			return nil, err
+		}
+		auth.rsaKeys = keys
+	}
+
+	return auth, nil
+}
+
+// Authenticate parses and validates a raw token string
+func (a *Authenticator) Authenticate(tokenString string) (*Claims, error) {
+	claims := &Claims{}
+	if _, err := a.parser.ParseWithClaims(tokenString, claims, a.keyFunc); err != nil {
+		return nil, err
+	}
+	return claims, nil
+}
+
+// keyFunc selects the verification key based on the token's signing method
+func (a *Authenticator) keyFunc(token *jwt.Token) (any, error) {
+	switch token.Method.Alg() {
+	case jwt.SigningMethodHS256.Alg():
+		if len(a.secret) == 0 {
+			return nil, errors.New("HS256 tokens are not accepted")
+		}
+		return a.secret, nil
+	case jwt.SigningMethodRS256.Alg():
+		kid, _ := token.Header["kid"].(string)
+		key, ok := a.rsaKeys[kid]
+		if !ok {
+			return nil, fmt.Errorf("unknown signing key %q", kid)
+		}
+		return key, nil
+	default:
+		return nil, fmt.Errorf("unexpected signing method %s", token.Method.Alg())
+	}
+}
+
+// Auth middleware requires a valid bearer token and stores its claims in the context
+func Auth(auth *Authenticator) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		header := c.GetHeader("Authorization")
+		scheme, tokenString, found := strings.Cut(header, " ")
+		if !found || !strings.EqualFold(scheme, "Bearer") || tokenString == "" {
+			apierror.Abort(c, http.StatusUnauthorized, apierror.CodeUnauthorized, "missing bearer token")
+			return
+		}
+
+		claims, err := auth.Authenticate(tokenString)
+		if err != nil {
+			apierror.Abort(c, http.StatusUnauthorized, apierror.CodeUnauthorized, tokenErrorMessage(err))
+			return
+		}
+
+		c.Set(ClaimsKey, claims)
+		c.Next()
+	}
+}
+
+// RequireRoles middleware allows the request only if the caller holds one of the given roles
+func RequireRoles(roles ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		claims, ok := GetClaims(c)
+		if !ok {
+			apierror.Abort(c, http.StatusUnauthorized, apierror.CodeUnauthorized, "authentication required")
+			return
+		}
+
+		for _, role := range roles {
+			if claims.HasRole(role) {
+				c.Next()
+				return
+			}
+		}
+
+		apierror.Abort(c, http.StatusForbidden, apierror.CodeForbidden, "insufficient role for this operation")
+	}
+}
+
+// GetClaims returns the authenticated claims stored by the Auth middleware
+func GetClaims(c *gin.Context) (*Claims, bool) {
+	value, ok := c.Get(ClaimsKey)
+	if !ok {
+		return nil, false
+	}
+	claims, ok := value.(*Claims)
+	return claims, ok
+}
+
+// tokenErrorMessage maps token validation failures to client-facing messages
+func tokenErrorMessage(err error) string {
+	switch {
+	case errors.Is(err, jwt.ErrTokenExpired):
+		return "token has expired"
+	case errors.Is(err, jwt.ErrTokenNotValidYet):
+		return "token is not valid yet"
+	case errors.Is(err, jwt.ErrTokenInvalidAudience):
+		return "token audience is not accepted"
+	case errors.Is(err, jwt.ErrTokenInvalidIssuer):
+		return "token issuer is not accepted"
+	case errors.Is(err, jwt.ErrTokenRequiredClaimMissing):
+		return "token is missing required claims"
+	default:
+		return "invalid token"
+	}
+}
+
+// jwks is the JSON Web Key Set document format
+type jwks struct {
+	Keys []struct {
+		Kty string `json:"kty"`
+		Kid string `json:"kid"`
+		Use string `json:"use"`
+		N   string `json:"n"`
+		E   string `json:"e"`
+	} `json:"keys"`
+}
+
+// loadJWKS reads RSA public keys from a local JWKS file, indexed by key ID
+func loadJWKS(path string) (map[string]*rsa.PublicKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read JWKS file: %w", err)
+	}
+
+	var set jwks
+	if err := json.Unmarshal(data, &set); err != nil {
+		return nil, fmt.Errorf("failed to parse JWKS file: %w", err)
+	}
+
+	keys := make(map[string]*rsa.PublicKey)
+	for _, k := range set.Keys {
+		if k.Kty != "RSA" || (k.Use != "" && k.Use != "sig") {
+			continue
+		}
+
+		n, err := base64.RawURLEncoding.DecodeString(k.N)
+		if err != nil {
+			return nil, fmt.Errorf("invalid modulus for key %q: %w", k.Kid, err)
+		}
+		e, err := base64.RawURLEncoding.DecodeString(k.E)
+		if err != nil {
+			return nil, fmt.Errorf("invalid exponent for key %q: %w", k.Kid, err)
+		}
+
+		keys[k.Kid] = &rsa.PublicKey{
+			N: new(big.Int).SetBytes(n),
+			E: int(new(big.Int).SetBytes(e).Int64()),
+		}
+	}
+
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("no RSA signing keys found in %s", path)
+	}
+	return keys, nil
+}
@@ -0,0 +1,214 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"customer-service/pkg/apierror"
+	"encoding/hex"
+	"io"
+	"log"
+	"net/http"
+	"path"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// IdempotencyKeyHeader is the request header carrying the client's idempotency key
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+// IdempotentReplayHeader is set on responses replayed from a stored result
+const IdempotentReplayHeader = "Idempotent-Replayed"
+
+// maxIdempotencyKeyLength bounds the size of client supplied keys
+const maxIdempotencyKeyLength = 255
+
+// replayedHeaders are the response headers stored and replayed with a result
+var replayedHeaders = []string{"Content-Type", "ETag", "Location"}
+
+// IdempotencyRecord is the stored state of an idempotency key
+type IdempotencyRecord struct {
+	Key         string
+	Actor       string
+	RequestHash string
+	Completed   bool
+	StatusCode  int
+	Headers     map[string]string
+	Body        []byte
+	// ResourceID is the resource the request acted on: its :id path parameter, or
+	// for a create the last segment of the Location it answered with
+	ResourceID string
+	ExpiresAt  time.Time
+	// LockedUntil is when the lease of a reservation runs out. It also tells the
+	// holder's reservation apart from one that took it over.
+	LockedUntil time.Time
+}
+
+// IdempotencyStore persists idempotency keys and the responses they produced
+type IdempotencyStore interface {
+	// Reserve claims the key for a new request. If the key is already claimed and
+	// not expired, the existing record is returned instead, unless it is a
+	// reservation of the same request whose lease has run out, which is taken over.
+	Reserve(ctx context.Context, record *IdempotencyRecord) (*IdempotencyRecord, error)
+	// Complete stores the response produced for a reserved key, if the reservation
+	// is still the caller's
+	Complete(ctx context.Context, record *IdempotencyRecord) error
+	// Release removes a reservation so the request can be retried, if it is still
+	// the caller's
+	Release(ctx context.Context, record *IdempotencyRecord) error
+}
+
+// responseRecorder captures the response body while still writing it to the client
+type responseRecorder struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *responseRecorder) Write(data []byte) (int, error) {
+	w.body.Write(data)
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *responseRecorder) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
+
+// Idempotency middleware makes a mutating request safe to retry. The first response
+// for an Idempotency-Key is stored for ttl and replayed for repeats of the same request;
+// reusing a key with a different request is rejected. While a request runs its key is
+// reserved for lease, after which a retry takes it over, so a reservation left behind
+// by a crash does not hold the key for the whole ttl. Requests without the header are
+// processed normally.
+func Idempotency(store IdempotencyStore, ttl, lease time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader(IdempotencyKeyHeader)
+		if key == "" {
+			c.Next()
+			return
+		}
+		if len(key) > maxIdempotencyKeyLength {
+			apierror.Abort(c, http.StatusBadRequest, apierror.CodeInvalidRequest, "invalid Idempotency-Key header",
+				apierror.FieldError{Field: IdempotencyKeyHeader, Message: "must be at most 255 characters"})
+			return
+		}
+
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			apierror.Abort(c, http.StatusBadRequest, apierror.CodeInvalidRequest, "failed to read request body")
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		actor := ""
+		if claims, ok := GetClaims(c); ok {
+			actor = claims.Subject
+		}
+
+		// Timestamps are stored to the microsecond
+		now := time.Now().Truncate(time.Microsecond)
+		record := &IdempotencyRecord{
+			Key:         key,
+			Actor:       actor,
+			RequestHash: requestHash(c, body),
+			ExpiresAt:   now.Add(ttl),
+			LockedUntil: now.Add(lease),
+		}
+
+		existing, err := store.Reserve(c.Request.Context(), record)
+		if err != nil {
+			log.Printf("Failed to reserve idempotency key: %v", err)
+			apierror.Abort(c, http.StatusInternalServerError, apierror.CodeInternal, "internal server error")
+			return
+		}
+		if existing != nil {
+			replay(c, existing, record.RequestHash)
+			return
+		}
+
+		// Use a context that outlives the request so the outcome is recorded even if
+		// the client has gone away
+		ctx := context.WithoutCancel(c.Request.Context())
+
+		// Release the reservation on a server error or panic, so the client can retry
+		handled := false
+		defer func() {
+			if !handled {
+				if err := store.Release(ctx, record); err != nil {
+					log.Printf("Failed to release idempotency key: %v", err)
+				}
+			}
+		}()
+
+		recorder := &responseRecorder{ResponseWriter: c.Writer}
+		c.Writer = recorder
+		c.Next()
+
+		// Server errors are not cached so the client can retry them
+		if recorder.Status() >= http.StatusInternalServerError {
+			return
+		}
+		handled = true
+
+		record.Completed = true
+		record.StatusCode = recorder.Status()
+		record.Body = recorder.body.Bytes()
+		record.Headers = make(map[string]string)
+		for _, name := range replayedHeaders {
+			if value := recorder.Header().Get(name); value != "" {
+				record.Headers[name] = value
+			}
+		}
+		record.ResourceID = resourceID(c, record.Headers["Location"])
+		if err := store.Complete(ctx, record); err != nil {
+			log.Printf("Failed to store idempotent response: %v", err)
+		}
+	}
+}
+
+// replay answers a repeated request from the stored record
+func replay(c *gin.Context, existing *IdempotencyRecord, requestHash string) {
+	if existing.RequestHash != requestHash {
+		apierror.Abort(c, http.StatusUnprocessableEntity, apierror.CodeIdempotencyKeyReused,
+			"Idempotency-Key has already been used for a different request")
+		return
+	}
+	if !existing.Completed {
+		apierror.Abort(c, http.StatusConflict, apierror.CodeIdempotencyInProgress,
+			"a request with this Idempotency-Key is still being processed")
+		return
+	}
+
+	for name, value := range existing.Headers {
+		c.Header(name, value)
+	}
+	c.Header(IdempotentReplayHeader, "true")
+	c.Status(existing.StatusCode)
+	if len(existing.Body) > 0 {
+		c.Writer.Write(existing.Body)
+	}
+	c.Abort()
+}
+
+// resourceID returns the resource a request acted on
+func resourceID(c *gin.Context, location string) string {
+	if id := c.Param("id"); id != "" {
+		return id
+	}
+	if location != "" {
+		return path.Base(location)
+	}
+	return ""
+}
+
+// requestHash fingerprints a request by method, path and body
+func requestHash(c *gin.Context, body []byte) string {
+	h := sha256.New()
+	h.Write([]byte(c.Request.Method))
+	h.Write([]byte{0})
+	h.Write([]byte(c.Request.URL.Path))
+	h.Write([]byte{0})
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
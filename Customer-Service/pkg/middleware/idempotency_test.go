@@ -0,0 +1,312 @@
+package middleware
+
+import (
+	"context"
+	"customer-service/pkg/apierror"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func init() {
+	gin.SetMode(gin.TestMode)
+}
+
+const (
+	testTTL   = time.Hour
+	testLease = time.Minute
+)
+
+// memoryStore keeps idempotency keys in memory, following the IdempotencyStore contract
+type memoryStore struct {
+	records  map[string]IdempotencyRecord
+	released int
+}
+
+func newMemoryStore(records ...IdempotencyRecord) *memoryStore {
+	s := &memoryStore{records: make(map[string]IdempotencyRecord)}
+	for _, record := range records {
+		s.records[record.Actor+"\x00"+record.Key] = record
+	}
+	return s
+}
+
+func (s *memoryStore) Reserve(ctx context.Context, record *IdempotencyRecord) (*IdempotencyRecord, error) {
+	id := record.Actor + "\x00" + record.Key
+	existing, ok := s.records[id]
+	if ok && (existing.Completed || existing.LockedUntil.After(time.Now()) || existing.RequestHash != record.RequestHash) {
+		return &existing, nil
+	}
+	s.records[id] = *record
+	return nil, nil
+}
+
+func (s *memoryStore) Complete(ctx context.Context, record *IdempotencyRecord) error {
+	id := record.Actor + "\x00" + record.Key
+	if existing, ok := s.records[id]; ok && !existing.Completed && existing.LockedUntil.Equal(record.LockedUntil) {
+		s.records[id] = *record
+	}
+	return nil
+}
+
+func (s *memoryStore) Release(ctx context.Context, record *IdempotencyRecord) error {
+	id := record.Actor + "\x00" + record.Key
+	if existing, ok := s.records[id]; ok && !existing.Completed && existing.LockedUntil.Equal(record.LockedUntil) {
+		delete(s.records, id)
+		s.released++
+	}
+	return nil
+}
+
+// testRequest is a request to the router built by idempotentRouter
+type testRequest struct {
+	method, path, body string
+	key, actor         string
+}
+
+func (r testRequest) hash() string {
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest(r.method, r.path, nil)
+	return requestHash(c, []byte(r.body))
+}
+
+// idempotentRouter serves customer routes behind the Idempotency middleware. The
+// handler answers with status, or panics when status is zero, and counts its calls.
+func idempotentRouter(store IdempotencyStore, status int, calls *int) *gin.Engine {
+	handler := func(c *gin.Context) {
+		*calls++
+		if status == 0 {
+			panic("handler failed")
+		}
+		id := c.Param("id")
+		if id == "" {
+			id = "c-new"
+			c.Header("Location", "/api/v1/customers/"+id)
+		}
+		c.JSON(status, gin.H{"id": id, "call": *calls})
+	}
+
+	r := gin.New()
+	r.Use(gin.CustomRecoveryWithWriter(io.Discard, func(c *gin.Context, err any) {
+		c.AbortWithStatus(http.StatusInternalServerError)
+	}))
+	r.Use(func(c *gin.Context) {
+		if actor := c.GetHeader("X-Test-Actor"); actor != "" {
+			c.Set(ClaimsKey, &Claims{RegisteredClaims: jwt.RegisteredClaims{Subject: actor}})
+		}
+	})
+	r.Use(Idempotency(store, testTTL, testLease))
+	r.POST("/customers", handler)
+	r.PUT("/customers/:id", handler)
+	return r
+}
+
+func TestIdempotency(t *testing.T) {
+	create := testRequest{method: http.MethodPost, path: "/customers", body: `{"first_name":"Jane"}`, key: "k1", actor: "teller-1"}
+	update := testRequest{method: http.MethodPut, path: "/customers/c-1", body: `{"first_name":"Joan"}`, key: "k1", actor: "teller-1"}
+	past := time.Now().Add(-time.Second)
+	future := time.Now().Add(time.Hour)
+
+	completed := IdempotencyRecord{
+		Key: "k1", Actor: "teller-1", RequestHash: create.hash(), Completed: true,
+		StatusCode: http.StatusCreated,
+		Headers:    map[string]string{"Content-Type": "application/json; charset=utf-8", "Location": "/api/v1/customers/c-old"},
+		Body:       []byte(`{"id":"c-old","call":0}`),
+		ExpiresAt:  future, LockedUntil: past,
+	}
+	running := IdempotencyRecord{Key: "k1", Actor: "teller-1", RequestHash: create.hash(), ExpiresAt: future, LockedUntil: future}
+	abandoned := IdempotencyRecord{Key: "k1", Actor: "teller-1", RequestHash: create.hash(), ExpiresAt: future, LockedUntil: past}
+
+	tests := []struct {
+		name     string
+		seed     []IdempotencyRecord
+		request  testRequest
+		status   int
+		wantCode int
+		// wantError is the error code of the response, if it is an error
+		wantError    string
+		wantCalls    int
+		wantReplayed bool
+		// wantStored is the status stored for the key, or zero if no response is
+		wantStored   int
+		wantResource string
+		wantReleased int
+	}{
+		{
+			name:     "no key",
+			request:  testRequest{method: http.MethodPost, path: "/customers", body: "{}"},
+			status:   http.StatusCreated,
+			wantCode: http.StatusCreated, wantCalls: 1,
+		},
+		{
+			name:     "key too long",
+			request:  testRequest{method: http.MethodPost, path: "/customers", body: "{}", key: strings.Repeat("k", 256)},
+			status:   http.StatusCreated,
+			wantCode: http.StatusBadRequest, wantError: apierror.CodeInvalidRequest,
+		},
+		{
+			name:     "first use of a key",
+			request:  create,
+			status:   http.StatusCreated,
+			wantCode: http.StatusCreated, wantCalls: 1,
+			wantStored: http.StatusCreated, wantResource: "c-new",
+		},
+		{
+			name:     "first use of a key on an existing resource",
+			request:  update,
+			status:   http.StatusOK,
+			wantCode: http.StatusOK, wantCalls: 1,
+			wantStored: http.StatusOK, wantResource: "c-1",
+		},
+		{
+			name:     "client error is stored",
+			request:  create,
+			status:   http.StatusConflict,
+			wantCode: http.StatusConflict, wantCalls: 1,
+			wantStored: http.StatusConflict, wantResource: "c-new",
+		},
+		{
+			name:     "server error releases the key",
+			request:  create,
+			status:   http.StatusServiceUnavailable,
+			wantCode: http.StatusServiceUnavailable, wantCalls: 1,
+			wantReleased: 1,
+		},
+		{
+			name:     "panic releases the key",
+			request:  create,
+			wantCode: http.StatusInternalServerError, wantCalls: 1,
+			wantReleased: 1,
+		},
+		{
+			name:     "retry of a completed request",
+			seed:     []IdempotencyRecord{completed},
+			request:  create,
+			status:   http.StatusCreated,
+			wantCode: http.StatusCreated, wantReplayed: true,
+			wantStored: http.StatusCreated,
+		},
+		{
+			name:     "key reused for another request",
+			seed:     []IdempotencyRecord{completed},
+			request:  testRequest{method: create.method, path: create.path, body: `{"first_name":"John"}`, key: "k1", actor: "teller-1"},
+			status:   http.StatusCreated,
+			wantCode: http.StatusUnprocessableEntity, wantError: apierror.CodeIdempotencyKeyReused,
+			wantStored: http.StatusCreated,
+		},
+		{
+			name:     "key reused on another path",
+			seed:     []IdempotencyRecord{completed},
+			request:  testRequest{method: http.MethodPut, path: "/customers/c-old", body: create.body, key: "k1", actor: "teller-1"},
+			status:   http.StatusOK,
+			wantCode: http.StatusUnprocessableEntity, wantError: apierror.CodeIdempotencyKeyReused,
+			wantStored: http.StatusCreated,
+		},
+		{
+			name:     "same key from another caller",
+			seed:     []IdempotencyRecord{completed},
+			request:  testRequest{method: create.method, path: create.path, body: create.body, key: "k1", actor: "teller-2"},
+			status:   http.StatusCreated,
+			wantCode: http.StatusCreated, wantCalls: 1,
+			wantStored: http.StatusCreated, wantResource: "c-new",
+		},
+		{
+			name:     "retry while the first request holds its lease",
+			seed:     []IdempotencyRecord{running},
+			request:  create,
+			status:   http.StatusCreated,
+			wantCode: http.StatusConflict, wantError: apierror.CodeIdempotencyInProgress,
+		},
+		{
+			name:     "retry after the lease has run out",
+			seed:     []IdempotencyRecord{abandoned},
+			request:  create,
+			status:   http.StatusCreated,
+			wantCode: http.StatusCreated, wantCalls: 1,
+			wantStored: http.StatusCreated, wantResource: "c-new",
+		},
+		{
+			name:     "another request after the lease has run out",
+			seed:     []IdempotencyRecord{abandoned},
+			request:  testRequest{method: create.method, path: create.path, body: `{"first_name":"John"}`, key: "k1", actor: "teller-1"},
+			status:   http.StatusCreated,
+			wantCode: http.StatusUnprocessableEntity, wantError: apierror.CodeIdempotencyKeyReused,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := newMemoryStore(tt.seed...)
+			calls := 0
+			router := idempotentRouter(store, tt.status, &calls)
+
+			req := httptest.NewRequest(tt.request.method, tt.request.path, strings.NewReader(tt.request.body))
+			if tt.request.key != "" {
+				req.Header.Set(IdempotencyKeyHeader, tt.request.key)
+			}
+			if tt.request.actor != "" {
+				req.Header.Set("X-Test-Actor", tt.request.actor)
+			}
+			start := time.Now().Truncate(time.Microsecond)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.wantCode {
+				t.Errorf("status = %d, want %d", w.Code, tt.wantCode)
+			}
+			if calls != tt.wantCalls {
+				t.Errorf("handler called %d times, want %d", calls, tt.wantCalls)
+			}
+			if tt.wantError != "" {
+				var resp apierror.Response
+				if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil || resp.Error.Code != tt.wantError {
+					t.Errorf("body = %s, want error %s", w.Body, tt.wantError)
+				}
+			}
+			if replayed := w.Header().Get(IdempotentReplayHeader) == "true"; replayed != tt.wantReplayed {
+				t.Errorf("replayed = %v, want %v", replayed, tt.wantReplayed)
+			}
+			if tt.wantReplayed {
+				if w.Body.String() != string(completed.Body) || w.Header().Get("Location") != completed.Headers["Location"] {
+					t.Errorf("replayed %s with Location %q, want the stored response", w.Body, w.Header().Get("Location"))
+				}
+			}
+			if store.released != tt.wantReleased {
+				t.Errorf("released %d times, want %d", store.released, tt.wantReleased)
+			}
+
+			stored, ok := store.records[tt.request.actor+"\x00"+tt.request.key]
+			switch {
+			case tt.wantStored == 0:
+				if ok && stored.Completed {
+					t.Errorf("stored %+v, want no response", stored)
+				}
+				return
+			case !ok || !stored.Completed:
+				t.Fatalf("stored %+v, want a completed record", stored)
+			}
+			if stored.StatusCode != tt.wantStored {
+				t.Errorf("stored status = %d, want %d", stored.StatusCode, tt.wantStored)
+			}
+			if tt.wantCalls == 0 {
+				return
+			}
+			if stored.ResourceID != tt.wantResource {
+				t.Errorf("stored resource = %q, want %q", stored.ResourceID, tt.wantResource)
+			}
+			if string(stored.Body) != w.Body.String() {
+				t.Errorf("stored body = %s, want %s", stored.Body, w.Body)
+			}
+			if stored.LockedUntil.Before(start.Add(testLease)) || stored.ExpiresAt.Sub(stored.LockedUntil) != testTTL-testLease {
+				t.Errorf("stored lease until %v and expiry %v, want %v and %v from the request", stored.LockedUntil, stored.ExpiresAt, testLease, testTTL)
+			}
+		})
+	}
+}
@@ -1,12 +1,23 @@
 package middleware
 
 import (
+	"customer-service/pkg/apierror"
 	"fmt"
+	"log"
+	"net/http"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 )
 
+// RequestIDHeader is the header used to propagate request IDs
+const RequestIDHeader = "X-Request-ID"
+
+// maxRequestIDLength is the longest caller-supplied request ID kept. Request IDs are
+// stored with audit entries, whose column holds up to 100 characters.
+const maxRequestIDLength = 64
+
 // Logger creates a logging middleware
 func Logger() gin.HandlerFunc {
 	return gin.LoggerWithFormatter(func(param gin.LogFormatterParams) string {
@@ -28,9 +39,9 @@ func Logger() gin.HandlerFunc {
 func CORS() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		c.Header("Access-Control-Allow-Origin", "*")
-		c.Header("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		c.Header("Access-Control-Allow-Headers", "Origin, Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization")
-		c.Header("Access-Control-Expose-Headers", "Content-Length")
+		c.Header("Access-Control-Allow-Methods", "GET, POST, PUT, PATCH, DELETE, OPTIONS")
+		c.Header("Access-Control-Allow-Headers", "Origin, Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, X-Request-ID, If-Match, If-None-Match, Idempotency-Key")
+		c.Header("Access-Control-Expose-Headers", "Content-Length, X-Request-ID, ETag, Idempotent-Replayed")
 		c.Header("Access-Control-Allow-Credentials", "true")
 
 		if c.Request.Method == "OPTIONS" {
@@ -42,7 +53,42 @@ func CORS() gin.HandlerFunc {
 	}
 }
 
+// RequestID middleware assigns every request an ID, reusing the caller's
+// X-Request-ID header when it is a valid ID, and echoes it back in the response
+func RequestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		requestID := c.GetHeader(RequestIDHeader)
+		if !validRequestID(requestID) {
+			requestID = uuid.NewString()
+		}
+
+		c.Set(apierror.RequestIDKey, requestID)
+		c.Header(RequestIDHeader, requestID)
+
+		c.Next()
+	}
+}
+
+// validRequestID reports whether a caller-supplied request ID can be kept: at most
+// maxRequestIDLength letters, digits, dashes, underscores and dots
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for _, r := range id {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '_', r == '.':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
 // Recovery middleware for handling panics
 func Recovery() gin.HandlerFunc {
-	return gin.Recovery()
+	return gin.CustomRecovery(func(c *gin.Context, recovered any) {
+		log.Printf("Recovered from panic: %v", recovered)
+		apierror.Abort(c, http.StatusInternalServerError, apierror.CodeInternal, "internal server error")
+	})
 }